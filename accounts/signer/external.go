@@ -0,0 +1,115 @@
+package signer
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+
+	libcommon "github.com/ledgerwatch/erigon-lib/common"
+	"github.com/ledgerwatch/erigon-lib/common/hexutility"
+	types2 "github.com/ledgerwatch/erigon-lib/types"
+	"github.com/ledgerwatch/log/v3"
+
+	"github.com/ledgerwatch/erigon/common/hexutil"
+	"github.com/ledgerwatch/erigon/core/types"
+	"github.com/ledgerwatch/erigon/rpc"
+)
+
+// External is a signer forwarding the signing requests to an external signer over JSON-RPC, with the account_ API
+// of clef
+type External struct {
+	client *rpc.Client
+}
+
+// NewExternal connects to the external signer at url, which may be a HTTP, websocket or IPC endpoint
+func NewExternal(ctx context.Context, url string, logger log.Logger) (*External, error) {
+	client, err := rpc.DialContext(ctx, url, logger)
+	if err != nil {
+		return nil, fmt.Errorf("connecting to external signer: %w", err)
+	}
+	return &External{client: client}, nil
+}
+
+// Close closes the connection to the external signer
+func (e *External) Close() {
+	e.client.Close()
+}
+
+func (e *External) Accounts(ctx context.Context) ([]libcommon.Address, error) {
+	var accounts []libcommon.Address
+	if err := e.client.CallContext(ctx, &accounts, "account_list"); err != nil {
+		return nil, err
+	}
+	return accounts, nil
+}
+
+func (e *External) SignText(ctx context.Context, account libcommon.Address, data []byte) ([]byte, error) {
+	var sig hexutility.Bytes
+	if err := e.client.CallContext(ctx, &sig, "account_signData", "text/plain", account, hexutility.Bytes(data)); err != nil {
+		return nil, err
+	}
+	return sig, nil
+}
+
+// sendTxArgs are the transaction fields sent to account_signTransaction
+type sendTxArgs struct {
+	From                 libcommon.Address  `json:"from"`
+	To                   *libcommon.Address `json:"to"`
+	Gas                  hexutil.Uint64     `json:"gas"`
+	GasPrice             *hexutil.Big       `json:"gasPrice,omitempty"`
+	MaxFeePerGas         *hexutil.Big       `json:"maxFeePerGas,omitempty"`
+	MaxPriorityFeePerGas *hexutil.Big       `json:"maxPriorityFeePerGas,omitempty"`
+	Value                hexutil.Big        `json:"value"`
+	Nonce                hexutil.Uint64     `json:"nonce"`
+	Data                 *hexutility.Bytes  `json:"data"`
+	AccessList           *types2.AccessList `json:"accessList,omitempty"`
+	ChainID              *hexutil.Big       `json:"chainId,omitempty"`
+}
+
+type signTransactionResult struct {
+	Raw hexutility.Bytes `json:"raw"`
+}
+
+func (e *External) SignTx(ctx context.Context, account libcommon.Address, tx types.Transaction, chainID *big.Int) (types.Transaction, error) {
+	data := hexutility.Bytes(tx.GetData())
+	args := sendTxArgs{
+		From:    account,
+		To:      tx.GetTo(),
+		Gas:     hexutil.Uint64(tx.GetGas()),
+		Value:   hexutil.Big(*tx.GetValue().ToBig()),
+		Nonce:   hexutil.Uint64(tx.GetNonce()),
+		Data:    &data,
+		ChainID: (*hexutil.Big)(chainID),
+	}
+	switch tx.Type() {
+	case types.LegacyTxType, types.AccessListTxType:
+		args.GasPrice = (*hexutil.Big)(tx.GetPrice().ToBig())
+	case types.DynamicFeeTxType:
+		args.MaxFeePerGas = (*hexutil.Big)(tx.GetFeeCap().ToBig())
+		args.MaxPriorityFeePerGas = (*hexutil.Big)(tx.GetTip().ToBig())
+	default:
+		return nil, fmt.Errorf("external signer does not support transactions of type %d", tx.Type())
+	}
+	if tx.Type() != types.LegacyTxType {
+		accessList := tx.GetAccessList()
+		args.AccessList = &accessList
+	}
+
+	var res signTransactionResult
+	if err := e.client.CallContext(ctx, &res, "account_signTransaction", args); err != nil {
+		return nil, err
+	}
+	signed, err := types.DecodeTransaction(res.Raw)
+	if err != nil {
+		return nil, fmt.Errorf("invalid transaction from external signer: %w", err)
+	}
+	// The external signer may have modified the transaction, only accept it if it is the requested one
+	sender, err := signed.Sender(*types.LatestSignerForChainID(chainID))
+	if err != nil {
+		return nil, err
+	}
+	if sender != account || signed.Type() != tx.Type() || signed.SigningHash(chainID) != tx.SigningHash(chainID) {
+		return nil, fmt.Errorf("external signer returned a different transaction")
+	}
+	return signed, nil
+}
@@ -0,0 +1,111 @@
+package signer
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"errors"
+	"math/big"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+
+	"github.com/holiman/uint256"
+	libcommon "github.com/ledgerwatch/erigon-lib/common"
+	"github.com/ledgerwatch/erigon-lib/common/hexutility"
+	"github.com/ledgerwatch/log/v3"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ledgerwatch/erigon/core/types"
+	"github.com/ledgerwatch/erigon/crypto"
+	"github.com/ledgerwatch/erigon/rpc"
+)
+
+// clefService serves the account_ API of clef with a single key
+type clefService struct {
+	key     *ecdsa.PrivateKey
+	chainID *big.Int
+	tamper  atomic.Bool // Whether the transactions are changed before being signed
+}
+
+func (s *clefService) List() []libcommon.Address {
+	return []libcommon.Address{crypto.PubkeyToAddress(s.key.PublicKey)}
+}
+
+func (s *clefService) SignData(contentType string, account libcommon.Address, data hexutility.Bytes) (hexutility.Bytes, error) {
+	if contentType != "text/plain" {
+		return nil, errors.New("unsupported content type")
+	}
+	return NewKeyStore(s.key).SignText(context.Background(), account, data)
+}
+
+func (s *clefService) SignTransaction(args sendTxArgs) (*signTransactionResult, error) {
+	tx := &types.DynamicFeeTransaction{
+		CommonTx: types.CommonTx{
+			Nonce: uint64(args.Nonce),
+			Gas:   uint64(args.Gas),
+			To:    args.To,
+			Value: uint256.MustFromBig(args.Value.ToInt()),
+			Data:  *args.Data,
+		},
+		ChainID: uint256.MustFromBig(args.ChainID.ToInt()),
+		Tip:     uint256.MustFromBig(args.MaxPriorityFeePerGas.ToInt()),
+		FeeCap:  uint256.MustFromBig(args.MaxFeePerGas.ToInt()),
+	}
+	if s.tamper.Load() {
+		tx.Gas++
+	}
+	signed, err := NewKeyStore(s.key).SignTx(context.Background(), args.From, tx, s.chainID)
+	if err != nil {
+		return nil, err
+	}
+	raw, err := types.MarshalTransactionsBinary(types.Transactions{signed})
+	if err != nil {
+		return nil, err
+	}
+	return &signTransactionResult{Raw: raw[0]}, nil
+}
+
+func TestExternal(t *testing.T) {
+	ctx := context.Background()
+	logger := log.New()
+	key, _ := crypto.GenerateKey()
+	account := crypto.PubkeyToAddress(key.PublicKey)
+	chainID := big.NewInt(1337)
+
+	clef := &clefService{key: key, chainID: chainID}
+	srv := rpc.NewServer(50, false, true, logger)
+	require.NoError(t, srv.RegisterName("account", clef))
+	httpSrv := httptest.NewServer(srv)
+	defer httpSrv.Close()
+
+	e, err := NewExternal(ctx, httpSrv.URL, logger)
+	require.NoError(t, err)
+	defer e.Close()
+
+	accounts, err := e.Accounts(ctx)
+	require.NoError(t, err)
+	require.Equal(t, []libcommon.Address{account}, accounts)
+
+	sig, err := e.SignText(ctx, account, []byte("hello"))
+	require.NoError(t, err)
+	sig[crypto.RecoveryIDOffset] -= 27
+	pub, err := crypto.SigToPub(TextHash([]byte("hello")), sig)
+	require.NoError(t, err)
+	require.Equal(t, account, crypto.PubkeyToAddress(*pub))
+
+	to := libcommon.Address{1}
+	tx := &types.DynamicFeeTransaction{
+		CommonTx: types.CommonTx{Nonce: 3, Gas: 21000, To: &to, Value: uint256.NewInt(5)},
+		ChainID:  uint256.MustFromBig(chainID),
+		Tip:      uint256.NewInt(1),
+		FeeCap:   uint256.NewInt(10),
+	}
+	signed, err := e.SignTx(ctx, account, tx, chainID)
+	require.NoError(t, err)
+	require.Equal(t, tx.SigningHash(chainID), signed.SigningHash(chainID))
+
+	// A transaction changed by the external signer is refused
+	clef.tamper.Store(true)
+	_, err = e.SignTx(ctx, account, tx, chainID)
+	require.Error(t, err)
+}
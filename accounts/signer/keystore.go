@@ -0,0 +1,284 @@
+package signer
+
+import (
+	"bytes"
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/ecdsa"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	libcommon "github.com/ledgerwatch/erigon-lib/common"
+	"golang.org/x/crypto/pbkdf2"
+	"golang.org/x/crypto/scrypt"
+
+	"github.com/ledgerwatch/erigon/core/types"
+	"github.com/ledgerwatch/erigon/crypto"
+)
+
+const (
+	// StandardScryptN and StandardScryptP are the scrypt parameters of the keys generated by geth and clef
+	StandardScryptN = 1 << 18
+	StandardScryptP = 1
+	// LightScryptN and LightScryptP are scrypt parameters making keys fast to decrypt, for tests and devnets
+	LightScryptN = 1 << 12
+	LightScryptP = 6
+
+	scryptR     = 8
+	scryptDKLen = 32
+)
+
+// ErrDecrypt is returned when no password decrypts a key file
+var ErrDecrypt = errors.New("could not decrypt key with given password")
+
+// encryptedKey is the version 3 key file format of the Web3 Secret Storage, used by geth and clef
+type encryptedKey struct {
+	Address string     `json:"address"`
+	Crypto  cryptoJSON `json:"crypto"`
+	ID      string     `json:"id"`
+	Version int        `json:"version"`
+}
+
+type cryptoJSON struct {
+	Cipher       string                 `json:"cipher"`
+	CipherText   string                 `json:"ciphertext"`
+	CipherParams cipherParamsJSON       `json:"cipherparams"`
+	KDF          string                 `json:"kdf"`
+	KDFParams    map[string]interface{} `json:"kdfparams"`
+	MAC          string                 `json:"mac"`
+}
+
+type cipherParamsJSON struct {
+	IV string `json:"iv"`
+}
+
+// KeyStore is a signer holding the keys decrypted from a directory of key files
+type KeyStore struct {
+	keys map[libcommon.Address]*ecdsa.PrivateKey
+}
+
+// OpenKeyStore decrypts the key files of dir with passwords. Every key file must be decrypted by one of the passwords.
+func OpenKeyStore(dir string, passwords []string) (*KeyStore, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, fmt.Errorf("reading keystore: %w", err)
+	}
+	ks := &KeyStore{keys: map[libcommon.Address]*ecdsa.PrivateKey{}}
+	for _, entry := range entries {
+		// Skip editor backups and hidden files, like geth does
+		name := entry.Name()
+		if entry.IsDir() || strings.HasPrefix(name, ".") || strings.HasSuffix(name, "~") {
+			continue
+		}
+		content, err := os.ReadFile(filepath.Join(dir, name))
+		if err != nil {
+			return nil, err
+		}
+		key, err := decryptWithAny(content, passwords)
+		if err != nil {
+			return nil, fmt.Errorf("key file %s: %w", name, err)
+		}
+		ks.keys[crypto.PubkeyToAddress(key.PublicKey)] = key
+	}
+	return ks, nil
+}
+
+// NewKeyStore returns a signer holding the given keys in memory
+func NewKeyStore(keys ...*ecdsa.PrivateKey) *KeyStore {
+	ks := &KeyStore{keys: map[libcommon.Address]*ecdsa.PrivateKey{}}
+	for _, key := range keys {
+		ks.keys[crypto.PubkeyToAddress(key.PublicKey)] = key
+	}
+	return ks
+}
+
+func decryptWithAny(content []byte, passwords []string) (*ecdsa.PrivateKey, error) {
+	if len(passwords) == 0 {
+		passwords = []string{""}
+	}
+	for _, password := range passwords {
+		key, err := DecryptKey(content, password)
+		if err == nil {
+			return key, nil
+		}
+		if !errors.Is(err, ErrDecrypt) {
+			return nil, err
+		}
+	}
+	return nil, ErrDecrypt
+}
+
+func (ks *KeyStore) Accounts(context.Context) ([]libcommon.Address, error) {
+	accounts := make([]libcommon.Address, 0, len(ks.keys))
+	for account := range ks.keys {
+		accounts = append(accounts, account)
+	}
+	sort.Slice(accounts, func(i, j int) bool {
+		return bytes.Compare(accounts[i][:], accounts[j][:]) < 0
+	})
+	return accounts, nil
+}
+
+func (ks *KeyStore) SignText(_ context.Context, account libcommon.Address, data []byte) ([]byte, error) {
+	key, ok := ks.keys[account]
+	if !ok {
+		return nil, fmt.Errorf("%w %x", ErrUnknownAccount, account)
+	}
+	sig, err := crypto.Sign(TextHash(data), key)
+	if err != nil {
+		return nil, err
+	}
+	sig[crypto.RecoveryIDOffset] += 27 // Transform V from 0/1 to 27/28 according to the yellow paper
+	return sig, nil
+}
+
+func (ks *KeyStore) SignTx(_ context.Context, account libcommon.Address, tx types.Transaction, chainID *big.Int) (types.Transaction, error) {
+	key, ok := ks.keys[account]
+	if !ok {
+		return nil, fmt.Errorf("%w %x", ErrUnknownAccount, account)
+	}
+	return types.SignTx(tx, *types.LatestSignerForChainID(chainID), key)
+}
+
+// EncryptKey encrypts key with password into a version 3 key file, using scrypt with the parameters scryptN and
+// scryptP
+func EncryptKey(key *ecdsa.PrivateKey, password string, scryptN, scryptP int) ([]byte, error) {
+	salt := make([]byte, 32)
+	iv := make([]byte, aes.BlockSize)
+	id := make([]byte, 16)
+	for _, b := range [][]byte{salt, iv, id} {
+		if _, err := rand.Read(b); err != nil {
+			return nil, err
+		}
+	}
+	derivedKey, err := scrypt.Key([]byte(password), salt, scryptN, scryptR, scryptP, scryptDKLen)
+	if err != nil {
+		return nil, err
+	}
+	cipherText, err := aesCTRXOR(derivedKey[:16], crypto.FromECDSA(key), iv)
+	if err != nil {
+		return nil, err
+	}
+	id[6] = (id[6] & 0x0f) | 0x40 // Random UUID, version 4
+	id[8] = (id[8] & 0x3f) | 0x80
+	return json.Marshal(encryptedKey{
+		Address: hex.EncodeToString(crypto.PubkeyToAddress(key.PublicKey).Bytes()),
+		Crypto: cryptoJSON{
+			Cipher:       "aes-128-ctr",
+			CipherText:   hex.EncodeToString(cipherText),
+			CipherParams: cipherParamsJSON{IV: hex.EncodeToString(iv)},
+			KDF:          "scrypt",
+			KDFParams: map[string]interface{}{
+				"n":     scryptN,
+				"r":     scryptR,
+				"p":     scryptP,
+				"dklen": scryptDKLen,
+				"salt":  hex.EncodeToString(salt),
+			},
+			MAC: hex.EncodeToString(crypto.Keccak256(derivedKey[16:32], cipherText)),
+		},
+		ID:      fmt.Sprintf("%x-%x-%x-%x-%x", id[0:4], id[4:6], id[6:8], id[8:10], id[10:]),
+		Version: 3,
+	})
+}
+
+// DecryptKey decrypts a version 3 key file with password
+func DecryptKey(content []byte, password string) (*ecdsa.PrivateKey, error) {
+	var k encryptedKey
+	if err := json.Unmarshal(content, &k); err != nil {
+		return nil, err
+	}
+	if k.Version != 3 {
+		return nil, fmt.Errorf("unsupported key file version %d", k.Version)
+	}
+	if k.Crypto.Cipher != "aes-128-ctr" {
+		return nil, fmt.Errorf("unsupported cipher %q", k.Crypto.Cipher)
+	}
+	mac, err := hex.DecodeString(k.Crypto.MAC)
+	if err != nil {
+		return nil, err
+	}
+	iv, err := hex.DecodeString(k.Crypto.CipherParams.IV)
+	if err != nil {
+		return nil, err
+	}
+	if len(iv) != aes.BlockSize {
+		return nil, fmt.Errorf("invalid IV length %d", len(iv))
+	}
+	cipherText, err := hex.DecodeString(k.Crypto.CipherText)
+	if err != nil {
+		return nil, err
+	}
+	derivedKey, err := deriveKey(k.Crypto, password)
+	if err != nil {
+		return nil, err
+	}
+	if !bytes.Equal(crypto.Keccak256(derivedKey[16:32], cipherText), mac) {
+		return nil, ErrDecrypt
+	}
+	plainText, err := aesCTRXOR(derivedKey[:16], cipherText, iv)
+	if err != nil {
+		return nil, err
+	}
+	key, err := crypto.ToECDSA(plainText)
+	if err != nil {
+		return nil, err
+	}
+	if k.Address != "" && !strings.EqualFold(strings.TrimPrefix(k.Address, "0x"), hex.EncodeToString(crypto.PubkeyToAddress(key.PublicKey).Bytes())) {
+		return nil, fmt.Errorf("key file address %s differs from the one of the key", k.Address)
+	}
+	return key, nil
+}
+
+func deriveKey(c cryptoJSON, password string) ([]byte, error) {
+	salt, err := hex.DecodeString(kdfString(c.KDFParams, "salt"))
+	if err != nil {
+		return nil, err
+	}
+	dkLen := kdfInt(c.KDFParams, "dklen")
+	// The first half of the derived key is the AES key and the second half is the MAC key
+	if dkLen != scryptDKLen {
+		return nil, fmt.Errorf("invalid derived key length %d", dkLen)
+	}
+	switch c.KDF {
+	case "scrypt":
+		return scrypt.Key([]byte(password), salt, kdfInt(c.KDFParams, "n"), kdfInt(c.KDFParams, "r"), kdfInt(c.KDFParams, "p"), dkLen)
+	case "pbkdf2":
+		if prf := kdfString(c.KDFParams, "prf"); prf != "hmac-sha256" {
+			return nil, fmt.Errorf("unsupported PBKDF2 PRF %q", prf)
+		}
+		return pbkdf2.Key([]byte(password), salt, kdfInt(c.KDFParams, "c"), dkLen, sha256.New), nil
+	default:
+		return nil, fmt.Errorf("unsupported KDF %q", c.KDF)
+	}
+}
+
+func kdfInt(params map[string]interface{}, name string) int {
+	f, _ := params[name].(float64) // Numbers of JSON objects are decoded to float64
+	return int(f)
+}
+
+func kdfString(params map[string]interface{}, name string) string {
+	s, _ := params[name].(string)
+	return s
+}
+
+func aesCTRXOR(key, in, iv []byte) ([]byte, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	out := make([]byte, len(in))
+	cipher.NewCTR(block, iv).XORKeyStream(out, in)
+	return out, nil
+}
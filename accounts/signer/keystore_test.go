@@ -0,0 +1,105 @@
+package signer
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/holiman/uint256"
+	libcommon "github.com/ledgerwatch/erigon-lib/common"
+	"github.com/ledgerwatch/log/v3"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ledgerwatch/erigon/core/types"
+	"github.com/ledgerwatch/erigon/crypto"
+)
+
+func TestEncryptDecryptKey(t *testing.T) {
+	key, err := crypto.GenerateKey()
+	require.NoError(t, err)
+
+	content, err := EncryptKey(key, "foo", LightScryptN, LightScryptP)
+	require.NoError(t, err)
+
+	decrypted, err := DecryptKey(content, "foo")
+	require.NoError(t, err)
+	require.Equal(t, crypto.FromECDSA(key), crypto.FromECDSA(decrypted))
+
+	_, err = DecryptKey(content, "bar")
+	require.ErrorIs(t, err, ErrDecrypt)
+
+	// Malformed key files are rejected instead of making the decryption panic
+	var k encryptedKey
+	require.NoError(t, json.Unmarshal(content, &k))
+	k.Crypto.CipherParams.IV = "00"
+	malformed, err := json.Marshal(k)
+	require.NoError(t, err)
+	_, err = DecryptKey(malformed, "foo")
+	require.ErrorContains(t, err, "invalid IV length")
+
+	require.NoError(t, json.Unmarshal(content, &k))
+	k.Crypto.KDFParams["dklen"] = 64
+	malformed, err = json.Marshal(k)
+	require.NoError(t, err)
+	_, err = DecryptKey(malformed, "foo")
+	require.ErrorContains(t, err, "invalid derived key length")
+}
+
+func TestOpenKeyStore(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+	key1, _ := crypto.GenerateKey()
+	key2, _ := crypto.GenerateKey()
+	for i, k := range []struct {
+		key      *ecdsa.PrivateKey
+		password string
+	}{{key1, "foo"}, {key2, "bar"}} {
+		content, err := EncryptKey(k.key, k.password, LightScryptN, LightScryptP)
+		require.NoError(t, err)
+		require.NoError(t, os.WriteFile(filepath.Join(dir, fmt.Sprintf("key%d", i)), content, 0600))
+	}
+	passwordFile := filepath.Join(t.TempDir(), "passwords")
+	require.NoError(t, os.WriteFile(passwordFile, []byte("bar\nfoo\n"), 0600))
+
+	s, err := New(ctx, Config{Keystore: dir, PasswordFile: passwordFile}, log.New())
+	require.NoError(t, err)
+	accounts, err := s.Accounts(ctx)
+	require.NoError(t, err)
+	require.ElementsMatch(t, []libcommon.Address{crypto.PubkeyToAddress(key1.PublicKey), crypto.PubkeyToAddress(key2.PublicKey)}, accounts)
+
+	// Every key must be decrypted
+	require.NoError(t, os.WriteFile(passwordFile, []byte("foo\n"), 0600))
+	_, err = New(ctx, Config{Keystore: dir, PasswordFile: passwordFile}, log.New())
+	require.ErrorIs(t, err, ErrDecrypt)
+}
+
+func TestKeyStoreSign(t *testing.T) {
+	ctx := context.Background()
+	key, _ := crypto.GenerateKey()
+	account := crypto.PubkeyToAddress(key.PublicKey)
+	ks := NewKeyStore(key)
+
+	sig, err := ks.SignText(ctx, account, []byte("hello"))
+	require.NoError(t, err)
+	v := sig[crypto.RecoveryIDOffset]
+	require.True(t, v == 27 || v == 28, "v = %d", v)
+	sig[crypto.RecoveryIDOffset] -= 27
+	pub, err := crypto.SigToPub(TextHash([]byte("hello")), sig)
+	require.NoError(t, err)
+	require.Equal(t, account, crypto.PubkeyToAddress(*pub))
+
+	_, err = ks.SignText(ctx, libcommon.Address{1}, []byte("hello"))
+	require.ErrorIs(t, err, ErrUnknownAccount)
+
+	chainID := big.NewInt(1337)
+	tx, err := ks.SignTx(ctx, account, types.NewTransaction(0, libcommon.Address{1}, uint256.NewInt(1), 21000, uint256.NewInt(1), nil), chainID)
+	require.NoError(t, err)
+	sender, err := tx.Sender(*types.LatestSignerForChainID(chainID))
+	require.NoError(t, err)
+	require.Equal(t, account, sender)
+}
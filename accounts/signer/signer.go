@@ -0,0 +1,74 @@
+// Package signer provides the account backends behind eth_accounts, eth_sign, eth_signTransaction and
+// eth_sendTransaction: a directory of encrypted keys, or an external signer such as clef.
+package signer
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+	"os"
+	"strings"
+
+	libcommon "github.com/ledgerwatch/erigon-lib/common"
+	"github.com/ledgerwatch/log/v3"
+
+	"github.com/ledgerwatch/erigon/core/types"
+	"github.com/ledgerwatch/erigon/crypto"
+)
+
+// ErrUnknownAccount is returned when the signer does not hold the key of an account
+var ErrUnknownAccount = errors.New("unknown account")
+
+// Signer signs messages and transactions on behalf of the accounts it holds the keys of
+type Signer interface {
+	// Accounts returns the accounts the signer can sign for
+	Accounts(ctx context.Context) ([]libcommon.Address, error)
+	// SignText returns the signature of TextHash(data) by account, with V being 27 or 28
+	SignText(ctx context.Context, account libcommon.Address, data []byte) ([]byte, error)
+	// SignTx returns tx signed by account for the chain chainID
+	SignTx(ctx context.Context, account libcommon.Address, tx types.Transaction, chainID *big.Int) (types.Transaction, error)
+}
+
+// Config selects the signer of the RPC daemon. The signer is disabled if both Keystore and URL are empty.
+type Config struct {
+	Keystore     string // Directory of encrypted key files
+	PasswordFile string // File with the passwords of the key files, one per line
+	URL          string // Endpoint of an external signer, e.g. clef
+}
+
+// Enabled tells whether a signer is configured
+func (c Config) Enabled() bool {
+	return c.Keystore != "" || c.URL != ""
+}
+
+// New returns the signer selected by cfg
+func New(ctx context.Context, cfg Config, logger log.Logger) (Signer, error) {
+	switch {
+	case cfg.Keystore != "" && cfg.URL != "":
+		return nil, fmt.Errorf("keystore and external signer are mutually exclusive")
+	case cfg.URL != "":
+		return NewExternal(ctx, cfg.URL, logger)
+	case cfg.Keystore != "":
+		var passwords []string
+		if cfg.PasswordFile != "" {
+			content, err := os.ReadFile(cfg.PasswordFile)
+			if err != nil {
+				return nil, fmt.Errorf("reading password file: %w", err)
+			}
+			passwords = strings.Split(strings.TrimRight(string(content), "\r\n"), "\n")
+			for i := range passwords {
+				passwords[i] = strings.TrimRight(passwords[i], "\r")
+			}
+		}
+		return OpenKeyStore(cfg.Keystore, passwords)
+	default:
+		return nil, fmt.Errorf("no signer configured")
+	}
+}
+
+// TextHash returns the hash signed by eth_sign for data:
+// keccak256("\x19Ethereum Signed Message:\n" + len(data) + data)
+func TextHash(data []byte) []byte {
+	return crypto.Keccak256([]byte(fmt.Sprintf("\x19Ethereum Signed Message:\n%d", len(data))), data)
+}
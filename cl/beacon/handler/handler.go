@@ -5,7 +5,9 @@ import (
 	"sync"
 
 	"github.com/go-chi/chi/v5"
+	"github.com/ledgerwatch/erigon-lib/kv"
 	"github.com/ledgerwatch/erigon/cl/clparams"
+	"github.com/ledgerwatch/erigon/cl/phase1/archive"
 )
 
 type ApiHandler struct {
@@ -13,10 +15,12 @@ type ApiHandler struct {
 	mux            chi.Router
 	genesisCfg     *clparams.GenesisConfig
 	beaconChainCfg *clparams.BeaconChainConfig
+	db             kv.RoDB          // optional, used to serve light client data
+	archive        *archive.Archive // optional, used to serve historical states
 }
 
-func NewApiHandler(genesisConfig *clparams.GenesisConfig, beaconChainConfig *clparams.BeaconChainConfig) *ApiHandler {
-	return &ApiHandler{o: sync.Once{}, genesisCfg: genesisConfig, beaconChainCfg: beaconChainConfig}
+func NewApiHandler(genesisConfig *clparams.GenesisConfig, beaconChainConfig *clparams.BeaconChainConfig, db kv.RoDB, archive *archive.Archive) *ApiHandler {
+	return &ApiHandler{o: sync.Once{}, genesisCfg: genesisConfig, beaconChainCfg: beaconChainConfig, db: db, archive: archive}
 }
 
 func (a *ApiHandler) init() {
@@ -31,6 +35,12 @@ func (a *ApiHandler) init() {
 				r.Get("/headers/{tag}", nil)      // otterscan
 				r.Get("/blocks/{slot}/root", nil) //otterscan
 				r.Get("/genesis", a.getGenesis)
+				r.Route("/light_client", func(r chi.Router) {
+					r.Get("/bootstrap/{block_root}", a.getLightClientBootstrap)
+					r.Get("/updates", a.getLightClientUpdates)
+					r.Get("/finality_update", a.getLightClientFinalityUpdate)
+					r.Get("/optimistic_update", a.getLightClientOptimisticUpdate)
+				})
 				r.Post("/binded_blocks", nil)
 				r.Post("/blocks", nil)
 				r.Route("/pool", func(r chi.Router) {
@@ -46,8 +56,14 @@ func (a *ApiHandler) init() {
 						r.Get("/validators", nil)
 						r.Get("/fork", nil)
 						r.Get("/validators/{id}", nil)
+						r.Get("/validator_balances", a.getValidatorBalances)
 					})
 				})
+				r.Route("/rewards", func(r chi.Router) {
+					r.Get("/blocks/{block_id}", a.getBlockRewards)
+					r.Post("/attestations/{epoch}", a.getAttestationRewards)
+					r.Post("/sync_committee/{block_id}", a.getSyncCommitteeRewards)
+				})
 			})
 			r.Route("/validator", func(r chi.Router) {
 				r.Route("/duties", func(r chi.Router) {
@@ -67,6 +83,9 @@ func (a *ApiHandler) init() {
 			})
 		})
 		r.Route("/v2", func(r chi.Router) {
+			r.Route("/debug", func(r chi.Router) {
+				r.Get("/beacon/states/{state_id}", a.getDebugState)
+			})
 			r.Route("/beacon", func(r chi.Router) {
 				r.Post("/blocks/{slot}", nil) //otterscan
 			})
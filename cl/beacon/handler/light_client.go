@@ -0,0 +1,352 @@
+package handler
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"io"
+	"math/big"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/ledgerwatch/erigon-lib/common"
+	"github.com/ledgerwatch/erigon-lib/common/hexutility"
+	"github.com/ledgerwatch/erigon-lib/kv"
+	"github.com/ledgerwatch/erigon-lib/types/ssz"
+	"github.com/ledgerwatch/log/v3"
+
+	"github.com/ledgerwatch/erigon/cl/clparams"
+	"github.com/ledgerwatch/erigon/cl/cltypes"
+	"github.com/ledgerwatch/erigon/cl/cltypes/solid"
+	"github.com/ledgerwatch/erigon/cl/fork"
+	"github.com/ledgerwatch/erigon/cl/phase1/core/rawdb"
+)
+
+// maxRequestLightClientUpdates is MAX_REQUEST_LIGHT_CLIENT_UPDATES from the specs.
+const maxRequestLightClientUpdates = 128
+
+type lightClientResponse struct {
+	Version string      `json:"version"`
+	Data    interface{} `json:"data"`
+}
+
+type beaconBlockHeaderJson struct {
+	Slot          string      `json:"slot"`
+	ProposerIndex string      `json:"proposer_index"`
+	ParentRoot    common.Hash `json:"parent_root"`
+	StateRoot     common.Hash `json:"state_root"`
+	BodyRoot      common.Hash `json:"body_root"`
+}
+
+type executionPayloadHeaderJson struct {
+	ParentHash       common.Hash      `json:"parent_hash"`
+	FeeRecipient     common.Address   `json:"fee_recipient"`
+	StateRoot        common.Hash      `json:"state_root"`
+	ReceiptsRoot     common.Hash      `json:"receipts_root"`
+	LogsBloom        hexutility.Bytes `json:"logs_bloom"`
+	PrevRandao       common.Hash      `json:"prev_randao"`
+	BlockNumber      string           `json:"block_number"`
+	GasLimit         string           `json:"gas_limit"`
+	GasUsed          string           `json:"gas_used"`
+	Timestamp        string           `json:"timestamp"`
+	ExtraData        hexutility.Bytes `json:"extra_data"`
+	BaseFeePerGas    string           `json:"base_fee_per_gas"`
+	BlockHash        common.Hash      `json:"block_hash"`
+	TransactionsRoot common.Hash      `json:"transactions_root"`
+	WithdrawalsRoot  common.Hash      `json:"withdrawals_root"`
+	DataGasUsed      *string          `json:"data_gas_used,omitempty"`
+	ExcessDataGas    *string          `json:"excess_data_gas,omitempty"`
+}
+
+type lightClientHeaderJson struct {
+	Beacon          beaconBlockHeaderJson       `json:"beacon"`
+	Execution       *executionPayloadHeaderJson `json:"execution,omitempty"`
+	ExecutionBranch []common.Hash               `json:"execution_branch,omitempty"`
+}
+
+type syncCommitteeJson struct {
+	Pubkeys         []hexutility.Bytes `json:"pubkeys"`
+	AggregatePubkey hexutility.Bytes   `json:"aggregate_pubkey"`
+}
+
+type syncAggregateJson struct {
+	SyncCommitteeBits      hexutility.Bytes `json:"sync_committee_bits"`
+	SyncCommitteeSignature hexutility.Bytes `json:"sync_committee_signature"`
+}
+
+type lightClientBootstrapJson struct {
+	Header                     lightClientHeaderJson `json:"header"`
+	CurrentSyncCommittee       syncCommitteeJson     `json:"current_sync_committee"`
+	CurrentSyncCommitteeBranch []common.Hash         `json:"current_sync_committee_branch"`
+}
+
+type lightClientUpdateJson struct {
+	AttestedHeader          lightClientHeaderJson `json:"attested_header"`
+	NextSyncCommittee       syncCommitteeJson     `json:"next_sync_committee"`
+	NextSyncCommitteeBranch []common.Hash         `json:"next_sync_committee_branch"`
+	FinalizedHeader         lightClientHeaderJson `json:"finalized_header"`
+	FinalityBranch          []common.Hash         `json:"finality_branch"`
+	SyncAggregate           syncAggregateJson     `json:"sync_aggregate"`
+	SignatureSlot           string                `json:"signature_slot"`
+}
+
+type lightClientFinalityUpdateJson struct {
+	AttestedHeader  lightClientHeaderJson `json:"attested_header"`
+	FinalizedHeader lightClientHeaderJson `json:"finalized_header"`
+	FinalityBranch  []common.Hash         `json:"finality_branch"`
+	SyncAggregate   syncAggregateJson     `json:"sync_aggregate"`
+	SignatureSlot   string                `json:"signature_slot"`
+}
+
+type lightClientOptimisticUpdateJson struct {
+	AttestedHeader lightClientHeaderJson `json:"attested_header"`
+	SyncAggregate  syncAggregateJson     `json:"sync_aggregate"`
+	SignatureSlot  string                `json:"signature_slot"`
+}
+
+func (a *ApiHandler) getLightClientBootstrap(w http.ResponseWriter, r *http.Request) {
+	blockRoot := common.HexToHash(chi.URLParam(r, "block_root"))
+	var bootstrap *cltypes.LightClientBootstrap
+	if !a.readLightClientObject(w, r, func(tx kv.Tx) (found bool, err error) {
+		bootstrap, err = rawdb.ReadLightClientBootstrap(tx, blockRoot)
+		return bootstrap != nil, err
+	}) {
+		return
+	}
+	a.writeLightClientObject(w, r, bootstrap.Version(), bootstrap, lightClientBootstrapJson{
+		Header:                     lightClientHeaderToJson(bootstrap.Header),
+		CurrentSyncCommittee:       syncCommitteeToJson(bootstrap.CurrentSyncCommittee),
+		CurrentSyncCommitteeBranch: hashVectorToJson(bootstrap.CurrentSyncCommitteeBranch),
+	})
+}
+
+func (a *ApiHandler) getLightClientUpdates(w http.ResponseWriter, r *http.Request) {
+	startPeriod, err := strconv.ParseUint(r.URL.Query().Get("start_period"), 10, 64)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		io.WriteString(w, "invalid start_period")
+		return
+	}
+	count, err := strconv.ParseUint(r.URL.Query().Get("count"), 10, 64)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		io.WriteString(w, "invalid count")
+		return
+	}
+	if count > maxRequestLightClientUpdates {
+		count = maxRequestLightClientUpdates
+	}
+	var updates []*cltypes.LightClientUpdate
+	if !a.readLightClientObject(w, r, func(tx kv.Tx) (bool, error) {
+		for period := startPeriod; period < startPeriod+count; period++ {
+			update, err := rawdb.ReadLightClientUpdate(tx, period)
+			if err != nil {
+				return false, err
+			}
+			if update == nil {
+				break
+			}
+			updates = append(updates, update)
+		}
+		return true, nil
+	}) {
+		return
+	}
+
+	if wantsSSZ(r) {
+		// Each update is prefixed by its length (8 bytes, little endian) and the fork digest of its version.
+		var out []byte
+		for _, update := range updates {
+			digest, err := fork.ComputeForkDigestForStateVersion(update.Version(), a.beaconChainCfg, a.genesisCfg.GenesisValidatorRoot)
+			if err != nil {
+				a.writeInternalError(w, err)
+				return
+			}
+			encoded, err := update.EncodeSSZ(nil)
+			if err != nil {
+				a.writeInternalError(w, err)
+				return
+			}
+			out = binary.LittleEndian.AppendUint64(out, uint64(len(encoded)+len(digest)))
+			out = append(out, digest[:]...)
+			out = append(out, encoded...)
+		}
+		w.Header().Set("Content-Type", "application/octet-stream")
+		w.WriteHeader(http.StatusOK)
+		w.Write(out)
+		return
+	}
+	resp := make([]lightClientResponse, 0, len(updates))
+	for _, update := range updates {
+		resp = append(resp, lightClientResponse{
+			Version: update.Version().String(),
+			Data: lightClientUpdateJson{
+				AttestedHeader:          lightClientHeaderToJson(update.AttestedHeader),
+				NextSyncCommittee:       syncCommitteeToJson(update.NextSyncCommittee),
+				NextSyncCommitteeBranch: hashVectorToJson(update.NextSyncCommitteeBranch),
+				FinalizedHeader:         lightClientHeaderToJson(update.FinalizedHeader),
+				FinalityBranch:          hashVectorToJson(update.FinalityBranch),
+				SyncAggregate:           syncAggregateToJson(update.SyncAggregate),
+				SignatureSlot:           strconv.FormatUint(update.SignatureSlot, 10),
+			},
+		})
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(resp)
+}
+
+func (a *ApiHandler) getLightClientFinalityUpdate(w http.ResponseWriter, r *http.Request) {
+	var update *cltypes.LightClientFinalityUpdate
+	if !a.readLightClientObject(w, r, func(tx kv.Tx) (found bool, err error) {
+		update, err = rawdb.ReadLightClientFinalityUpdate(tx)
+		return update != nil, err
+	}) {
+		return
+	}
+	a.writeLightClientObject(w, r, update.Version(), update, lightClientFinalityUpdateJson{
+		AttestedHeader:  lightClientHeaderToJson(update.AttestedHeader),
+		FinalizedHeader: lightClientHeaderToJson(update.FinalizedHeader),
+		FinalityBranch:  hashVectorToJson(update.FinalityBranch),
+		SyncAggregate:   syncAggregateToJson(update.SyncAggregate),
+		SignatureSlot:   strconv.FormatUint(update.SignatureSlot, 10),
+	})
+}
+
+func (a *ApiHandler) getLightClientOptimisticUpdate(w http.ResponseWriter, r *http.Request) {
+	var update *cltypes.LightClientOptimisticUpdate
+	if !a.readLightClientObject(w, r, func(tx kv.Tx) (found bool, err error) {
+		update, err = rawdb.ReadLightClientOptimisticUpdate(tx)
+		return update != nil, err
+	}) {
+		return
+	}
+	a.writeLightClientObject(w, r, update.Version(), update, lightClientOptimisticUpdateJson{
+		AttestedHeader: lightClientHeaderToJson(update.AttestedHeader),
+		SyncAggregate:  syncAggregateToJson(update.SyncAggregate),
+		SignatureSlot:  strconv.FormatUint(update.SignatureSlot, 10),
+	})
+}
+
+// readLightClientObject runs read against the database and writes an error response if it fails or finds nothing.
+func (a *ApiHandler) readLightClientObject(w http.ResponseWriter, r *http.Request, read func(tx kv.Tx) (bool, error)) bool {
+	if a.db == nil {
+		w.WriteHeader(http.StatusNotFound)
+		io.WriteString(w, "Light client data is not available")
+		return false
+	}
+	var found bool
+	if err := a.db.View(r.Context(), func(tx kv.Tx) (err error) {
+		found, err = read(tx)
+		return
+	}); err != nil {
+		a.writeInternalError(w, err)
+		return false
+	}
+	if !found {
+		w.WriteHeader(http.StatusNotFound)
+		io.WriteString(w, "Light client data is not available")
+		return false
+	}
+	return true
+}
+
+// writeLightClientObject writes either the SSZ encoding of obj or its JSON representation, depending on what the client accepts.
+func (a *ApiHandler) writeLightClientObject(w http.ResponseWriter, r *http.Request, version clparams.StateVersion, obj ssz.Marshaler, data interface{}) {
+	w.Header().Set("Eth-Consensus-Version", version.String())
+	if wantsSSZ(r) {
+		encoded, err := obj.EncodeSSZ(nil)
+		if err != nil {
+			a.writeInternalError(w, err)
+			return
+		}
+		w.Header().Set("Content-Type", "application/octet-stream")
+		w.WriteHeader(http.StatusOK)
+		w.Write(encoded)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(lightClientResponse{Version: version.String(), Data: data})
+}
+
+func (a *ApiHandler) writeInternalError(w http.ResponseWriter, err error) {
+	w.WriteHeader(http.StatusInternalServerError)
+	io.WriteString(w, "Internal error")
+	log.Error("[Beacon API] light client handler failed", "err", err)
+}
+
+func wantsSSZ(r *http.Request) bool {
+	return strings.Contains(r.Header.Get("Accept"), "application/octet-stream")
+}
+
+func lightClientHeaderToJson(header *cltypes.LightClientHeader) lightClientHeaderJson {
+	out := lightClientHeaderJson{
+		Beacon: beaconBlockHeaderJson{
+			Slot:          strconv.FormatUint(header.Beacon.Slot, 10),
+			ProposerIndex: strconv.FormatUint(header.Beacon.ProposerIndex, 10),
+			ParentRoot:    header.Beacon.ParentRoot,
+			StateRoot:     header.Beacon.Root,
+			BodyRoot:      header.Beacon.BodyRoot,
+		},
+	}
+	if header.Version() < clparams.CapellaVersion {
+		return out
+	}
+	payload := header.ExecutionPayloadHeader
+	// The base fee is stored as a little endian 256 bits integer.
+	baseFee := make([]byte, len(payload.BaseFeePerGas))
+	for i := range payload.BaseFeePerGas {
+		baseFee[len(baseFee)-1-i] = payload.BaseFeePerGas[i]
+	}
+	out.Execution = &executionPayloadHeaderJson{
+		ParentHash:       payload.ParentHash,
+		FeeRecipient:     payload.FeeRecipient,
+		StateRoot:        payload.StateRoot,
+		ReceiptsRoot:     payload.ReceiptsRoot,
+		LogsBloom:        payload.LogsBloom[:],
+		PrevRandao:       payload.PrevRandao,
+		BlockNumber:      strconv.FormatUint(payload.BlockNumber, 10),
+		GasLimit:         strconv.FormatUint(payload.GasLimit, 10),
+		GasUsed:          strconv.FormatUint(payload.GasUsed, 10),
+		Timestamp:        strconv.FormatUint(payload.Time, 10),
+		ExtraData:        payload.Extra.Bytes(),
+		BaseFeePerGas:    new(big.Int).SetBytes(baseFee).String(),
+		BlockHash:        payload.BlockHash,
+		TransactionsRoot: payload.TransactionsRoot,
+		WithdrawalsRoot:  payload.WithdrawalsRoot,
+	}
+	if header.Version() >= clparams.DenebVersion {
+		dataGasUsed, excessDataGas := strconv.FormatUint(payload.DataGasUsed, 10), strconv.FormatUint(payload.ExcessDataGas, 10)
+		out.Execution.DataGasUsed, out.Execution.ExcessDataGas = &dataGasUsed, &excessDataGas
+	}
+	out.ExecutionBranch = hashVectorToJson(header.ExecutionBranch)
+	return out
+}
+
+func syncCommitteeToJson(committee *solid.SyncCommittee) syncCommitteeJson {
+	keys := committee.GetCommittee()
+	out := syncCommitteeJson{Pubkeys: make([]hexutility.Bytes, len(keys))}
+	for i := range keys {
+		out.Pubkeys[i] = keys[i][:]
+	}
+	aggregate := committee.AggregatePublicKey()
+	out.AggregatePubkey = aggregate[:]
+	return out
+}
+
+func syncAggregateToJson(aggregate *cltypes.SyncAggregate) syncAggregateJson {
+	return syncAggregateJson{
+		SyncCommitteeBits:      aggregate.SyncCommiteeBits[:],
+		SyncCommitteeSignature: aggregate.SyncCommiteeSignature[:],
+	}
+}
+
+func hashVectorToJson(vector solid.HashVectorSSZ) []common.Hash {
+	out := make([]common.Hash, vector.Length())
+	for i := range out {
+		out[i] = vector.Get(i)
+	}
+	return out
+}
@@ -0,0 +1,231 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"sort"
+	"strconv"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/ledgerwatch/log/v3"
+
+	"github.com/ledgerwatch/erigon/cl/phase1/archive"
+	"github.com/ledgerwatch/erigon/cl/transition/rewards"
+)
+
+type blockRewards struct {
+	ProposerIndex     string `json:"proposer_index"`
+	Total             string `json:"total"`
+	Attestations      string `json:"attestations"`
+	SyncAggregate     string `json:"sync_aggregate"`
+	ProposerSlashings string `json:"proposer_slashings"`
+	AttesterSlashings string `json:"attester_slashings"`
+}
+
+type idealAttestationRewards struct {
+	EffectiveBalance string `json:"effective_balance"`
+	Head             string `json:"head"`
+	Target           string `json:"target"`
+	Source           string `json:"source"`
+	InclusionDelay   string `json:"inclusion_delay"`
+	Inactivity       string `json:"inactivity"`
+}
+
+type totalAttestationRewards struct {
+	ValidatorIndex string `json:"validator_index"`
+	Head           string `json:"head"`
+	Target         string `json:"target"`
+	Source         string `json:"source"`
+	InclusionDelay string `json:"inclusion_delay"`
+	Inactivity     string `json:"inactivity"`
+}
+
+type attestationRewards struct {
+	IdealRewards []idealAttestationRewards `json:"ideal_rewards"`
+	TotalRewards []totalAttestationRewards `json:"total_rewards"`
+}
+
+type syncCommitteeReward struct {
+	ValidatorIndex string `json:"validator_index"`
+	Reward         string `json:"reward"`
+}
+
+type rewardsResponse struct {
+	ExecutionOptimistic bool        `json:"execution_optimistic"`
+	Finalized           bool        `json:"finalized"`
+	Data                interface{} `json:"data"`
+}
+
+// writeRewardsError writes the error response of a failed rewards computation.
+func writeRewardsError(w http.ResponseWriter, err error) {
+	if errors.Is(err, os.ErrNotExist) || errors.Is(err, archive.ErrStateNotArchived) {
+		w.WriteHeader(http.StatusNotFound)
+		io.WriteString(w, "Block or state not found")
+		return
+	}
+	w.WriteHeader(http.StatusInternalServerError)
+	io.WriteString(w, "Failed to compute rewards")
+	log.Error("[Beacon API] rewards computation failed", "err", err)
+}
+
+func writeRewards(w http.ResponseWriter, finalized bool, data interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(rewardsResponse{Finalized: finalized, Data: data})
+}
+
+// readRewardsRequest parses the block id or epoch URL parameter of a rewards request and makes sure the archive is enabled.
+func (a *ApiHandler) readRewardsRequest(w http.ResponseWriter, r *http.Request, param string) (uint64, bool) {
+	if a.archive == nil {
+		w.WriteHeader(http.StatusNotFound)
+		io.WriteString(w, "Archive mode is not enabled")
+		return 0, false
+	}
+	if param == "block_id" {
+		slot, err := a.blockSlot(chi.URLParam(r, param))
+		if err != nil {
+			writeIdError(w, err)
+			return 0, false
+		}
+		return slot, true
+	}
+	value, err := strconv.ParseUint(chi.URLParam(r, param), 10, 64)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		io.WriteString(w, fmt.Sprintf("invalid %s %q", param, chi.URLParam(r, param)))
+		return 0, false
+	}
+	return value, true
+}
+
+// readValidatorIndicies reads the optional list of validator indicies in the body of a rewards request.
+func readValidatorIndicies(r *http.Request) (map[uint64]struct{}, error) {
+	body, err := io.ReadAll(r.Body)
+	if err != nil || len(body) == 0 {
+		return nil, err
+	}
+	var ids []string
+	if err := json.Unmarshal(body, &ids); err != nil {
+		return nil, err
+	}
+	if len(ids) == 0 {
+		return nil, nil
+	}
+	indicies := make(map[uint64]struct{}, len(ids))
+	for _, id := range ids {
+		index, err := strconv.ParseUint(id, 10, 64)
+		if err != nil {
+			return nil, errors.New("validators can only be selected by index")
+		}
+		indicies[index] = struct{}{}
+	}
+	return indicies, nil
+}
+
+// sortedIndicies returns the keys of m selected by filter, a nil filter selects all of them.
+func sortedIndicies[T any](m map[uint64]T, filter map[uint64]struct{}) []uint64 {
+	keys := make([]uint64, 0, len(m))
+	for key := range m {
+		if _, ok := filter[key]; filter == nil || ok {
+			keys = append(keys, key)
+		}
+	}
+	sort.Slice(keys, func(i, j int) bool { return keys[i] < keys[j] })
+	return keys
+}
+
+func (a *ApiHandler) getBlockRewards(w http.ResponseWriter, r *http.Request) {
+	slot, ok := a.readRewardsRequest(w, r, "block_id")
+	if !ok {
+		return
+	}
+	collector, err := a.archive.BlockRewards(slot)
+	if err != nil {
+		writeRewardsError(w, err)
+		return
+	}
+	// Only the finalized blocks are archived
+	writeRewards(w, true, blockRewards{
+		ProposerIndex:     strconv.FormatUint(collector.Block.ProposerIndex, 10),
+		Total:             strconv.FormatUint(collector.Block.Total(), 10),
+		Attestations:      strconv.FormatUint(collector.Block.Attestations, 10),
+		SyncAggregate:     strconv.FormatUint(collector.Block.SyncAggregate, 10),
+		ProposerSlashings: strconv.FormatUint(collector.Block.ProposerSlashings, 10),
+		AttesterSlashings: strconv.FormatUint(collector.Block.AttesterSlashings, 10),
+	})
+}
+
+func (a *ApiHandler) getAttestationRewards(w http.ResponseWriter, r *http.Request) {
+	epoch, ok := a.readRewardsRequest(w, r, "epoch")
+	if !ok {
+		return
+	}
+	filter, err := readValidatorIndicies(r)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		io.WriteString(w, err.Error())
+		return
+	}
+	collector, err := a.archive.AttestationRewards(epoch)
+	if err != nil {
+		writeRewardsError(w, err)
+		return
+	}
+	format := func(r *rewards.AttestationRewards, component rewards.Component) string {
+		return strconv.FormatInt(r[component], 10)
+	}
+	data := attestationRewards{IdealRewards: []idealAttestationRewards{}, TotalRewards: []totalAttestationRewards{}}
+	for _, effectiveBalance := range sortedIndicies(collector.IdealAttestations, nil) {
+		ideal := collector.IdealAttestations[effectiveBalance]
+		data.IdealRewards = append(data.IdealRewards, idealAttestationRewards{
+			EffectiveBalance: strconv.FormatUint(effectiveBalance, 10),
+			Head:             format(ideal, rewards.Head),
+			Target:           format(ideal, rewards.Target),
+			Source:           format(ideal, rewards.Source),
+			InclusionDelay:   format(ideal, rewards.InclusionDelay),
+			Inactivity:       format(ideal, rewards.Inactivity),
+		})
+	}
+	for _, index := range sortedIndicies(collector.Attestations, filter) {
+		total := collector.Attestations[index]
+		data.TotalRewards = append(data.TotalRewards, totalAttestationRewards{
+			ValidatorIndex: strconv.FormatUint(index, 10),
+			Head:           format(total, rewards.Head),
+			Target:         format(total, rewards.Target),
+			Source:         format(total, rewards.Source),
+			InclusionDelay: format(total, rewards.InclusionDelay),
+			Inactivity:     format(total, rewards.Inactivity),
+		})
+	}
+	writeRewards(w, a.isFinalized((epoch+2)*a.beaconChainCfg.SlotsPerEpoch-1), data)
+}
+
+func (a *ApiHandler) getSyncCommitteeRewards(w http.ResponseWriter, r *http.Request) {
+	slot, ok := a.readRewardsRequest(w, r, "block_id")
+	if !ok {
+		return
+	}
+	filter, err := readValidatorIndicies(r)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		io.WriteString(w, err.Error())
+		return
+	}
+	collector, err := a.archive.BlockRewards(slot)
+	if err != nil {
+		writeRewardsError(w, err)
+		return
+	}
+	data := []syncCommitteeReward{}
+	for _, index := range sortedIndicies(collector.SyncCommittee, filter) {
+		data = append(data, syncCommitteeReward{
+			ValidatorIndex: strconv.FormatUint(index, 10),
+			Reward:         strconv.FormatInt(collector.SyncCommittee[index], 10),
+		})
+	}
+	writeRewards(w, true, data)
+}
@@ -0,0 +1,174 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/go-chi/chi/v5"
+	libcommon "github.com/ledgerwatch/erigon-lib/common"
+	"github.com/ledgerwatch/erigon-lib/common/length"
+	"github.com/ledgerwatch/log/v3"
+
+	"github.com/ledgerwatch/erigon/cl/phase1/archive"
+	"github.com/ledgerwatch/erigon/cl/phase1/core/state"
+	"github.com/ledgerwatch/erigon/common/hexutil"
+)
+
+type validatorBalance struct {
+	Index   string `json:"index"`
+	Balance string `json:"balance"`
+}
+
+type validatorBalancesResponse struct {
+	ExecutionOptimistic bool               `json:"execution_optimistic"`
+	Finalized           bool               `json:"finalized"`
+	Data                []validatorBalance `json:"data"`
+}
+
+var errInvalidId = errors.New("invalid id")
+
+// blockSlot resolves a block id to its slot, the ids are head, finalized, genesis or a slot.
+func (a *ApiHandler) blockSlot(blockId string) (uint64, error) {
+	switch blockId {
+	case "head":
+		return a.archive.HeadSlot()
+	case "finalized":
+		return a.archive.FinalizedSlot()
+	case "genesis":
+		return 0, nil
+	}
+	slot, err := strconv.ParseUint(blockId, 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("%w %q, expected head, finalized, genesis or a slot", errInvalidId, blockId)
+	}
+	return slot, nil
+}
+
+// stateSlot resolves a state id to the slot of its state, the ids are those of blockSlot or the hex encoded root of
+// the state.
+func (a *ApiHandler) stateSlot(stateId string) (uint64, error) {
+	if !strings.HasPrefix(stateId, "0x") {
+		return a.blockSlot(stateId)
+	}
+	root, err := hexutil.Decode(stateId)
+	if err != nil || len(root) != length.Hash {
+		return 0, fmt.Errorf("%w %q, expected a 32 bytes hex encoded state root", errInvalidId, stateId)
+	}
+	return a.archive.SlotByStateRoot(libcommon.BytesToHash(root))
+}
+
+// isFinalized tells whether the state at the given slot is finalized. Before a block is finalized since the archive
+// was created, the states are only read out of the archive and so they are all finalized.
+func (a *ApiHandler) isFinalized(slot uint64) bool {
+	finalizedSlot, err := a.archive.FinalizedSlot()
+	return err != nil || slot <= finalizedSlot
+}
+
+// writeIdError writes the error response of an id which could not be resolved.
+func writeIdError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, errInvalidId):
+		w.WriteHeader(http.StatusBadRequest)
+		io.WriteString(w, err.Error())
+	case errors.Is(err, archive.ErrStateNotArchived):
+		w.WriteHeader(http.StatusNotFound)
+		io.WriteString(w, "State not found")
+	default:
+		w.WriteHeader(http.StatusInternalServerError)
+		io.WriteString(w, "Failed to resolve id")
+		log.Error("[Beacon API] id resolution failed", "err", err)
+	}
+}
+
+// readArchivedState reads the state of the state_id URL parameter and writes an error response if it is not available.
+func (a *ApiHandler) readArchivedState(w http.ResponseWriter, r *http.Request) *state.CachingBeaconState {
+	if a.archive == nil {
+		w.WriteHeader(http.StatusNotFound)
+		io.WriteString(w, "Archive mode is not enabled")
+		return nil
+	}
+	slot, err := a.stateSlot(chi.URLParam(r, "state_id"))
+	if err != nil {
+		writeIdError(w, err)
+		return nil
+	}
+	s, err := a.archive.ReadState(slot)
+	if errors.Is(err, archive.ErrStateNotArchived) {
+		w.WriteHeader(http.StatusNotFound)
+		io.WriteString(w, "State not found")
+		return nil
+	}
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		io.WriteString(w, "Failed to reconstruct state")
+		log.Error("[Beacon API] state reconstruction failed", "slot", slot, "err", err)
+		return nil
+	}
+	return s
+}
+
+func (a *ApiHandler) getDebugState(w http.ResponseWriter, r *http.Request) {
+	if !wantsSSZ(r) {
+		w.WriteHeader(http.StatusNotAcceptable)
+		io.WriteString(w, "Beacon states are only served SSZ encoded")
+		return
+	}
+	s := a.readArchivedState(w, r)
+	if s == nil {
+		return
+	}
+	encoded, err := s.EncodeSSZ(nil)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		io.WriteString(w, "Failed to encode state")
+		log.Error("[Beacon API] state encoding failed", "err", err)
+		return
+	}
+	w.Header().Set("Eth-Consensus-Version", s.Version().String())
+	w.Header().Set("Content-Type", "application/octet-stream")
+	w.WriteHeader(http.StatusOK)
+	w.Write(encoded)
+}
+
+func (a *ApiHandler) getValidatorBalances(w http.ResponseWriter, r *http.Request) {
+	s := a.readArchivedState(w, r)
+	if s == nil {
+		return
+	}
+	var indicies []uint64
+	for _, id := range r.URL.Query()["id"] {
+		index, err := strconv.ParseUint(id, 10, 64)
+		if err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			io.WriteString(w, "Validators can only be selected by index")
+			return
+		}
+		indicies = append(indicies, index)
+	}
+	if len(indicies) == 0 {
+		for i := 0; i < s.ValidatorLength(); i++ {
+			indicies = append(indicies, uint64(i))
+		}
+	}
+
+	resp := validatorBalancesResponse{Finalized: a.isFinalized(s.Slot()), Data: make([]validatorBalance, 0, len(indicies))}
+	for _, index := range indicies {
+		balance, err := s.ValidatorBalance(int(index))
+		if err != nil {
+			// Unknown validators are skipped.
+			continue
+		}
+		resp.Data = append(resp.Data, validatorBalance{
+			Index:   strconv.FormatUint(index, 10),
+			Balance: strconv.FormatUint(balance, 10),
+		})
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(resp)
+}
@@ -27,3 +27,21 @@ func StringToClVersion(s string) StateVersion {
 		panic("unsupported fork version: " + s)
 	}
 }
+
+// String returns the name of the fork the state version belongs to.
+func (v StateVersion) String() string {
+	switch v {
+	case Phase0Version:
+		return "phase0"
+	case AltairVersion:
+		return "altair"
+	case BellatrixVersion:
+		return "bellatrix"
+	case CapellaVersion:
+		return "capella"
+	case DenebVersion:
+		return "deneb"
+	default:
+		return "unknown"
+	}
+}
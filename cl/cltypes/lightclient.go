@@ -0,0 +1,318 @@
+package cltypes
+
+import (
+	"github.com/ledgerwatch/erigon-lib/common/length"
+	"github.com/ledgerwatch/erigon-lib/types/clonable"
+
+	"github.com/ledgerwatch/erigon/cl/clparams"
+	"github.com/ledgerwatch/erigon/cl/cltypes/solid"
+	"github.com/ledgerwatch/erigon/cl/merkle_tree"
+	ssz2 "github.com/ledgerwatch/erigon/cl/ssz"
+)
+
+// Depths of the merkle branches carried by light client objects, they are floorlog2 of their generalized indices.
+const (
+	CurrentSyncCommitteeBranchLength = 5 // floorlog2(CURRENT_SYNC_COMMITTEE_INDEX)
+	NextSyncCommitteeBranchLength    = 5 // floorlog2(NEXT_SYNC_COMMITTEE_INDEX)
+	FinalityBranchLength             = 6 // floorlog2(FINALIZED_ROOT_INDEX)
+	ExecutionBranchLength            = 4 // floorlog2(EXECUTION_PAYLOAD_INDEX)
+)
+
+/*
+ * LightClientHeader is the header light clients track. From Capella onwards it also
+ * carries the execution payload header together with its inclusion proof in the block body.
+ */
+type LightClientHeader struct {
+	Beacon *BeaconBlockHeader
+
+	ExecutionPayloadHeader *Eth1Header
+	ExecutionBranch        solid.HashVectorSSZ
+
+	version clparams.StateVersion
+}
+
+func NewLightClientHeader(version clparams.StateVersion) *LightClientHeader {
+	return &LightClientHeader{
+		version:                version,
+		Beacon:                 &BeaconBlockHeader{},
+		ExecutionBranch:        solid.NewHashVector(ExecutionBranchLength),
+		ExecutionPayloadHeader: NewEth1Header(version),
+	}
+}
+
+func (l *LightClientHeader) Version() clparams.StateVersion {
+	return l.version
+}
+
+func (l *LightClientHeader) EncodeSSZ(buf []byte) ([]byte, error) {
+	return ssz2.MarshalSSZ(buf, l.getSchema()...)
+}
+
+func (l *LightClientHeader) DecodeSSZ(buf []byte, version int) error {
+	l.version = clparams.StateVersion(version)
+	l.Beacon = &BeaconBlockHeader{}
+	l.ExecutionBranch = solid.NewHashVector(ExecutionBranchLength)
+	l.ExecutionPayloadHeader = NewEth1Header(l.version)
+	return ssz2.UnmarshalSSZ(buf, version, l.getSchema()...)
+}
+
+func (l *LightClientHeader) EncodingSizeSSZ() int {
+	size := l.Beacon.EncodingSizeSSZ()
+	if l.version >= clparams.CapellaVersion {
+		size += l.ExecutionPayloadHeader.EncodingSizeSSZ() + 4 // the extra 4 is for the offset
+		size += l.ExecutionBranch.EncodingSizeSSZ()
+	}
+	return size
+}
+
+func (l *LightClientHeader) HashSSZ() ([32]byte, error) {
+	return merkle_tree.HashTreeRoot(l.getSchema()...)
+}
+
+func (l *LightClientHeader) Static() bool {
+	return l.version < clparams.CapellaVersion
+}
+
+func (l *LightClientHeader) Clone() clonable.Clonable {
+	return NewLightClientHeader(l.version)
+}
+
+func (l *LightClientHeader) getSchema() []interface{} {
+	schema := []interface{}{l.Beacon}
+	if l.version >= clparams.CapellaVersion {
+		schema = append(schema, l.ExecutionPayloadHeader, l.ExecutionBranch)
+	}
+	return schema
+}
+
+/*
+ * LightClientBootstrap is the object a light client uses to initialize itself from a trusted block root.
+ */
+type LightClientBootstrap struct {
+	Header                     *LightClientHeader
+	CurrentSyncCommittee       *solid.SyncCommittee
+	CurrentSyncCommitteeBranch solid.HashVectorSSZ
+}
+
+func NewLightClientBootstrap(version clparams.StateVersion) *LightClientBootstrap {
+	return &LightClientBootstrap{
+		Header:                     NewLightClientHeader(version),
+		CurrentSyncCommittee:       &solid.SyncCommittee{},
+		CurrentSyncCommitteeBranch: solid.NewHashVector(CurrentSyncCommitteeBranchLength),
+	}
+}
+
+func (l *LightClientBootstrap) Version() clparams.StateVersion {
+	return l.Header.version
+}
+
+func (l *LightClientBootstrap) EncodeSSZ(buf []byte) ([]byte, error) {
+	return ssz2.MarshalSSZ(buf, l.Header, l.CurrentSyncCommittee, l.CurrentSyncCommitteeBranch)
+}
+
+func (l *LightClientBootstrap) DecodeSSZ(buf []byte, version int) error {
+	l.Header = NewLightClientHeader(clparams.StateVersion(version))
+	l.CurrentSyncCommittee = &solid.SyncCommittee{}
+	l.CurrentSyncCommitteeBranch = solid.NewHashVector(CurrentSyncCommitteeBranchLength)
+	return ssz2.UnmarshalSSZ(buf, version, l.Header, l.CurrentSyncCommittee, l.CurrentSyncCommitteeBranch)
+}
+
+func (l *LightClientBootstrap) EncodingSizeSSZ() int {
+	size := l.CurrentSyncCommittee.EncodingSizeSSZ() + l.CurrentSyncCommitteeBranch.EncodingSizeSSZ()
+	return size + lightClientHeaderFieldSize(l.Header)
+}
+
+func (l *LightClientBootstrap) HashSSZ() ([32]byte, error) {
+	return merkle_tree.HashTreeRoot(l.Header, l.CurrentSyncCommittee, l.CurrentSyncCommitteeBranch)
+}
+
+func (*LightClientBootstrap) Static() bool {
+	return false
+}
+
+func (l *LightClientBootstrap) Clone() clonable.Clonable {
+	return NewLightClientBootstrap(l.Version())
+}
+
+/*
+ * LightClientUpdate is the best update for a given sync committee period, it lets light clients
+ * move on to the next sync committee.
+ */
+type LightClientUpdate struct {
+	AttestedHeader          *LightClientHeader
+	NextSyncCommittee       *solid.SyncCommittee
+	NextSyncCommitteeBranch solid.HashVectorSSZ
+	FinalizedHeader         *LightClientHeader
+	FinalityBranch          solid.HashVectorSSZ
+	SyncAggregate           *SyncAggregate
+	SignatureSlot           uint64
+}
+
+func NewLightClientUpdate(version clparams.StateVersion) *LightClientUpdate {
+	return &LightClientUpdate{
+		AttestedHeader:          NewLightClientHeader(version),
+		NextSyncCommittee:       &solid.SyncCommittee{},
+		NextSyncCommitteeBranch: solid.NewHashVector(NextSyncCommitteeBranchLength),
+		FinalizedHeader:         NewLightClientHeader(version),
+		FinalityBranch:          solid.NewHashVector(FinalityBranchLength),
+		SyncAggregate:           &SyncAggregate{},
+	}
+}
+
+func (l *LightClientUpdate) Version() clparams.StateVersion {
+	return l.AttestedHeader.version
+}
+
+func (l *LightClientUpdate) EncodeSSZ(buf []byte) ([]byte, error) {
+	return ssz2.MarshalSSZ(buf, l.AttestedHeader, l.NextSyncCommittee, l.NextSyncCommitteeBranch, l.FinalizedHeader, l.FinalityBranch, l.SyncAggregate, &l.SignatureSlot)
+}
+
+func (l *LightClientUpdate) DecodeSSZ(buf []byte, version int) error {
+	*l = *NewLightClientUpdate(clparams.StateVersion(version))
+	return ssz2.UnmarshalSSZ(buf, version, l.AttestedHeader, l.NextSyncCommittee, l.NextSyncCommitteeBranch, l.FinalizedHeader, l.FinalityBranch, l.SyncAggregate, &l.SignatureSlot)
+}
+
+func (l *LightClientUpdate) EncodingSizeSSZ() int {
+	size := l.NextSyncCommittee.EncodingSizeSSZ() + l.NextSyncCommitteeBranch.EncodingSizeSSZ() +
+		l.FinalityBranch.EncodingSizeSSZ() + l.SyncAggregate.EncodingSizeSSZ() + length.BlockNum
+	return size + lightClientHeaderFieldSize(l.AttestedHeader) + lightClientHeaderFieldSize(l.FinalizedHeader)
+}
+
+func (l *LightClientUpdate) HashSSZ() ([32]byte, error) {
+	return merkle_tree.HashTreeRoot(l.AttestedHeader, l.NextSyncCommittee, l.NextSyncCommitteeBranch, l.FinalizedHeader, l.FinalityBranch, l.SyncAggregate, &l.SignatureSlot)
+}
+
+func (*LightClientUpdate) Static() bool {
+	return false
+}
+
+func (l *LightClientUpdate) Clone() clonable.Clonable {
+	return NewLightClientUpdate(l.Version())
+}
+
+/*
+ * LightClientFinalityUpdate is gossiped whenever a new finalized header can be proven to light clients.
+ */
+type LightClientFinalityUpdate struct {
+	AttestedHeader  *LightClientHeader
+	FinalizedHeader *LightClientHeader
+	FinalityBranch  solid.HashVectorSSZ
+	SyncAggregate   *SyncAggregate
+	SignatureSlot   uint64
+}
+
+func NewLightClientFinalityUpdate(version clparams.StateVersion) *LightClientFinalityUpdate {
+	return &LightClientFinalityUpdate{
+		AttestedHeader:  NewLightClientHeader(version),
+		FinalizedHeader: NewLightClientHeader(version),
+		FinalityBranch:  solid.NewHashVector(FinalityBranchLength),
+		SyncAggregate:   &SyncAggregate{},
+	}
+}
+
+func (l *LightClientFinalityUpdate) Version() clparams.StateVersion {
+	return l.AttestedHeader.version
+}
+
+func (l *LightClientFinalityUpdate) EncodeSSZ(buf []byte) ([]byte, error) {
+	return ssz2.MarshalSSZ(buf, l.AttestedHeader, l.FinalizedHeader, l.FinalityBranch, l.SyncAggregate, &l.SignatureSlot)
+}
+
+func (l *LightClientFinalityUpdate) DecodeSSZ(buf []byte, version int) error {
+	*l = *NewLightClientFinalityUpdate(clparams.StateVersion(version))
+	return ssz2.UnmarshalSSZ(buf, version, l.AttestedHeader, l.FinalizedHeader, l.FinalityBranch, l.SyncAggregate, &l.SignatureSlot)
+}
+
+func (l *LightClientFinalityUpdate) EncodingSizeSSZ() int {
+	size := l.FinalityBranch.EncodingSizeSSZ() + l.SyncAggregate.EncodingSizeSSZ() + length.BlockNum
+	return size + lightClientHeaderFieldSize(l.AttestedHeader) + lightClientHeaderFieldSize(l.FinalizedHeader)
+}
+
+func (l *LightClientFinalityUpdate) HashSSZ() ([32]byte, error) {
+	return merkle_tree.HashTreeRoot(l.AttestedHeader, l.FinalizedHeader, l.FinalityBranch, l.SyncAggregate, &l.SignatureSlot)
+}
+
+func (*LightClientFinalityUpdate) Static() bool {
+	return false
+}
+
+func (l *LightClientFinalityUpdate) Clone() clonable.Clonable {
+	return NewLightClientFinalityUpdate(l.Version())
+}
+
+/*
+ * LightClientOptimisticUpdate is gossiped at every slot, it lets light clients follow the head of the chain.
+ */
+type LightClientOptimisticUpdate struct {
+	AttestedHeader *LightClientHeader
+	SyncAggregate  *SyncAggregate
+	SignatureSlot  uint64
+}
+
+func NewLightClientOptimisticUpdate(version clparams.StateVersion) *LightClientOptimisticUpdate {
+	return &LightClientOptimisticUpdate{
+		AttestedHeader: NewLightClientHeader(version),
+		SyncAggregate:  &SyncAggregate{},
+	}
+}
+
+func (l *LightClientOptimisticUpdate) Version() clparams.StateVersion {
+	return l.AttestedHeader.version
+}
+
+func (l *LightClientOptimisticUpdate) EncodeSSZ(buf []byte) ([]byte, error) {
+	return ssz2.MarshalSSZ(buf, l.AttestedHeader, l.SyncAggregate, &l.SignatureSlot)
+}
+
+func (l *LightClientOptimisticUpdate) DecodeSSZ(buf []byte, version int) error {
+	*l = *NewLightClientOptimisticUpdate(clparams.StateVersion(version))
+	return ssz2.UnmarshalSSZ(buf, version, l.AttestedHeader, l.SyncAggregate, &l.SignatureSlot)
+}
+
+func (l *LightClientOptimisticUpdate) EncodingSizeSSZ() int {
+	return lightClientHeaderFieldSize(l.AttestedHeader) + l.SyncAggregate.EncodingSizeSSZ() + length.BlockNum
+}
+
+func (l *LightClientOptimisticUpdate) HashSSZ() ([32]byte, error) {
+	return merkle_tree.HashTreeRoot(l.AttestedHeader, l.SyncAggregate, &l.SignatureSlot)
+}
+
+func (*LightClientOptimisticUpdate) Static() bool {
+	return false
+}
+
+func (l *LightClientOptimisticUpdate) Clone() clonable.Clonable {
+	return NewLightClientOptimisticUpdate(l.Version())
+}
+
+/*
+ * LightClientUpdatesByRangeRequest is the request for getting the best updates of a range of sync committee periods.
+ */
+type LightClientUpdatesByRangeRequest struct {
+	StartPeriod uint64
+	Count       uint64
+}
+
+func (l *LightClientUpdatesByRangeRequest) EncodeSSZ(buf []byte) ([]byte, error) {
+	return ssz2.MarshalSSZ(buf, l.StartPeriod, l.Count)
+}
+
+func (l *LightClientUpdatesByRangeRequest) DecodeSSZ(buf []byte, version int) error {
+	return ssz2.UnmarshalSSZ(buf, version, &l.StartPeriod, &l.Count)
+}
+
+func (l *LightClientUpdatesByRangeRequest) EncodingSizeSSZ() int {
+	return 2 * length.BlockNum
+}
+
+func (*LightClientUpdatesByRangeRequest) Clone() clonable.Clonable {
+	return &LightClientUpdatesByRangeRequest{}
+}
+
+// lightClientHeaderFieldSize returns how much space a header takes when it is a field of a container.
+func lightClientHeaderFieldSize(header *LightClientHeader) int {
+	if header.Static() {
+		return header.EncodingSizeSSZ()
+	}
+	return header.EncodingSizeSSZ() + 4 // the extra 4 is for the offset
+}
@@ -0,0 +1,69 @@
+package cltypes_test
+
+import (
+	"testing"
+
+	libcommon "github.com/ledgerwatch/erigon-lib/common"
+	"github.com/ledgerwatch/erigon/cl/clparams"
+	"github.com/ledgerwatch/erigon/cl/cltypes"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLightClientUpdateEncoding(t *testing.T) {
+	for _, version := range []clparams.StateVersion{clparams.AltairVersion, clparams.CapellaVersion, clparams.DenebVersion} {
+		update := cltypes.NewLightClientUpdate(version)
+		update.AttestedHeader.Beacon.Slot = 100
+		update.AttestedHeader.ExecutionPayloadHeader.BlockNumber = 20
+		update.AttestedHeader.ExecutionBranch.Set(1, libcommon.HexToHash("0xaa"))
+		update.FinalizedHeader.Beacon.Slot = 64
+		update.FinalityBranch.Set(5, libcommon.HexToHash("0xbb"))
+		update.NextSyncCommitteeBranch.Set(0, libcommon.HexToHash("0xcc"))
+		update.SyncAggregate.SyncCommiteeBits[0] = 0xff
+		update.SignatureSlot = 101
+
+		encoded, err := update.EncodeSSZ(nil)
+		require.NoError(t, err)
+		require.Equal(t, update.EncodingSizeSSZ(), len(encoded))
+
+		decoded := &cltypes.LightClientUpdate{}
+		require.NoError(t, decoded.DecodeSSZ(encoded, int(version)))
+		require.Equal(t, version, decoded.Version())
+		require.Equal(t, uint64(101), decoded.SignatureSlot)
+		require.Equal(t, uint64(64), decoded.FinalizedHeader.Beacon.Slot)
+
+		root, err := update.HashSSZ()
+		require.NoError(t, err)
+		decodedRoot, err := decoded.HashSSZ()
+		require.NoError(t, err)
+		require.Equal(t, root, decodedRoot)
+	}
+}
+
+func TestLightClientBootstrapEncoding(t *testing.T) {
+	for _, version := range []clparams.StateVersion{clparams.AltairVersion, clparams.CapellaVersion} {
+		bootstrap := cltypes.NewLightClientBootstrap(version)
+		bootstrap.Header.Beacon.Slot = 32
+		bootstrap.CurrentSyncCommittee.SetAggregatePublicKey([48]byte{1})
+		bootstrap.CurrentSyncCommitteeBranch.Set(4, libcommon.HexToHash("0xdd"))
+
+		encoded, err := bootstrap.EncodeSSZ(nil)
+		require.NoError(t, err)
+		require.Equal(t, bootstrap.EncodingSizeSSZ(), len(encoded))
+
+		decoded := &cltypes.LightClientBootstrap{}
+		require.NoError(t, decoded.DecodeSSZ(encoded, int(version)))
+		require.Equal(t, bootstrap.CurrentSyncCommittee.AggregatePublicKey(), decoded.CurrentSyncCommittee.AggregatePublicKey())
+		require.Equal(t, libcommon.HexToHash("0xdd"), decoded.CurrentSyncCommitteeBranch.Get(4))
+	}
+}
+
+func TestLightClientHeaderRoot(t *testing.T) {
+	// Before Capella the light client header is a container with only the beacon header.
+	header := cltypes.NewLightClientHeader(clparams.AltairVersion)
+	header.Beacon.Slot = 10
+	root, err := header.HashSSZ()
+	require.NoError(t, err)
+	beaconRoot, err := header.Beacon.HashSSZ()
+	require.NoError(t, err)
+	require.Equal(t, beaconRoot, root)
+}
@@ -171,6 +171,26 @@ func ComputeForkDigestForVersion(currentVersion [4]byte, genesisValidatorsRoot [
 	return
 }
 
+// ComputeForkDigestForStateVersion computes the fork digest of the fork the given state version belongs to.
+func ComputeForkDigestForStateVersion(version clparams.StateVersion, beaconConfig *clparams.BeaconChainConfig, genesisValidatorsRoot [32]byte) ([4]byte, error) {
+	var forkVersion uint32
+	switch version {
+	case clparams.Phase0Version:
+		forkVersion = beaconConfig.GenesisForkVersion
+	case clparams.AltairVersion:
+		forkVersion = beaconConfig.AltairForkVersion
+	case clparams.BellatrixVersion:
+		forkVersion = beaconConfig.BellatrixForkVersion
+	case clparams.CapellaVersion:
+		forkVersion = beaconConfig.CapellaForkVersion
+	case clparams.DenebVersion:
+		forkVersion = beaconConfig.DenebForkVersion
+	default:
+		return [4]byte{}, fmt.Errorf("invalid state version: %d", version)
+	}
+	return ComputeForkDigestForVersion(utils.Uint32ToBytes4(forkVersion), genesisValidatorsRoot)
+}
+
 func ComputeForkId(
 	beaconConfig *clparams.BeaconChainConfig,
 	genesisConfig *clparams.GenesisConfig,
@@ -0,0 +1,42 @@
+package merkle_tree
+
+import (
+	"fmt"
+
+	"github.com/ledgerwatch/erigon/cl/utils"
+)
+
+// MerkleProof computes the merkle branch of the schema component at proofIndex, for a tree of the given depth.
+// The schema follows the same rules as HashTreeRoot and the resulting branch is ordered from the bottom of
+// the tree to the top, as expected by is_valid_merkle_branch.
+func MerkleProof(depth, proofIndex int, schema ...interface{}) ([][32]byte, error) {
+	if len(schema) > 1<<depth {
+		return nil, fmt.Errorf("merkle proof: schema of %d components does not fit in depth %d", len(schema), depth)
+	}
+	if proofIndex >= 1<<depth {
+		return nil, fmt.Errorf("merkle proof: index %d out of range for depth %d", proofIndex, depth)
+	}
+	// Leaves with no schema component are zero-padded.
+	layer := make([][32]byte, 1<<depth)
+	for i, element := range schema {
+		leaf, err := HashTreeRoot(element)
+		if err != nil {
+			return nil, err
+		}
+		layer[i] = leaf
+	}
+
+	branch := make([][32]byte, 0, depth)
+	index := proofIndex
+	for i := 0; i < depth; i++ {
+		// Take the sibling at the current level and then move one level up.
+		branch = append(branch, layer[index^1])
+		nextLayer := make([][32]byte, len(layer)/2)
+		for j := range nextLayer {
+			nextLayer[j] = utils.Keccak256(layer[2*j][:], layer[2*j+1][:])
+		}
+		layer = nextLayer
+		index /= 2
+	}
+	return branch, nil
+}
@@ -0,0 +1,35 @@
+package merkle_tree_test
+
+import (
+	"testing"
+
+	libcommon "github.com/ledgerwatch/erigon-lib/common"
+	"github.com/ledgerwatch/erigon/cl/merkle_tree"
+	"github.com/ledgerwatch/erigon/cl/utils"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMerkleProof(t *testing.T) {
+	schema := []interface{}{uint64(1), uint64(2), libcommon.HexToHash("0xff").Bytes(), uint64(4), uint64(5)}
+	root, err := merkle_tree.HashTreeRoot(schema...)
+	require.NoError(t, err)
+
+	for i := range schema {
+		branch, err := merkle_tree.MerkleProof(3, i, schema...)
+		require.NoError(t, err)
+		require.Len(t, branch, 3)
+
+		leaf, err := merkle_tree.HashTreeRoot(schema[i])
+		require.NoError(t, err)
+		hashes := make([]libcommon.Hash, len(branch))
+		for j := range branch {
+			hashes[j] = branch[j]
+		}
+		require.True(t, utils.IsValidMerkleBranch(leaf, hashes, 3, uint64(i), root))
+	}
+
+	_, err = merkle_tree.MerkleProof(2, 0, schema...)
+	require.Error(t, err)
+	_, err = merkle_tree.MerkleProof(3, 8, schema...)
+	require.Error(t, err)
+}
@@ -0,0 +1,505 @@
+package archive
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strconv"
+	"sync"
+
+	libcommon "github.com/ledgerwatch/erigon-lib/common"
+
+	"github.com/ledgerwatch/erigon/cl/clparams"
+	"github.com/ledgerwatch/erigon/cl/cltypes"
+	"github.com/ledgerwatch/erigon/cl/freezer"
+	"github.com/ledgerwatch/erigon/cl/phase1/core/state"
+	"github.com/ledgerwatch/erigon/cl/transition"
+	"github.com/ledgerwatch/erigon/cl/utils"
+)
+
+const (
+	freezerNamespace = "caplin_archive"
+
+	snapshotObject  = "beaconStateSnapshot"
+	diffObject      = "beaconStateDiff"
+	blockObject     = "signedBeaconBlock"
+	stateRootObject = "beaconStateRootSlot"
+	boundsObject    = "archiveBounds"
+
+	// maxPendingAnchors is how many states of the epochs which are not finalized yet are kept in memory. During long
+	// periods of non-finality the oldest are dropped, the states of their epochs are then replayed from an earlier one.
+	maxPendingAnchors = 8
+
+	// stateSlotOffset is where the slot is in the SSZ encoding of a beacon state (after genesis time and genesis validators root).
+	stateSlotOffset = 8 + 32
+)
+
+var ErrStateNotArchived = errors.New("state is not archived")
+
+type pendingBlock struct {
+	root  libcommon.Hash
+	block *cltypes.SignedBeaconBlock
+	// anchor is the snappy compressed post state, only kept for the first block of each epoch.
+	anchor []byte
+}
+
+// bounds are the first epoch with an archived state and the last archived slot, they are persisted so that reads after a
+// restart do not look for states outside of them.
+type bounds struct {
+	firstEpoch uint64
+	lastSlot   uint64
+}
+
+// Archive keeps every finalized block and one state per epoch (the post state of its first block) in a freezer.
+// States are stored as a full snapshot every snapshotInterval epochs and as diffs against the previous epoch in between,
+// any other state is reconstructed by replaying blocks on top of the closest archived state.
+type Archive struct {
+	f                freezer.Freezer
+	beaconCfg        *clparams.BeaconChainConfig
+	snapshotInterval uint64
+
+	mu sync.Mutex
+	// head returns the root and slot of the head block, set by the fork choice.
+	head func() (libcommon.Hash, uint64, error)
+	// blocks which are not finalized yet, indexed by block root.
+	pending           map[libcommon.Hash]*pendingBlock
+	lastFinalizedRoot libcommon.Hash
+	lastArchivedRoot  libcommon.Hash
+	lastArchivedSlot  uint64
+	// last archived anchor, diffs are computed against it.
+	lastAnchor        []byte
+	lastAnchorEpoch   uint64
+	lastSnapshotEpoch uint64
+	// bounds of the archive, nil until they are read or written.
+	bounds *bounds
+}
+
+// New creates an archive on top of the given freezer, a full state snapshot is taken every snapshotInterval epochs.
+func New(f freezer.Freezer, beaconCfg *clparams.BeaconChainConfig, snapshotInterval uint64) *Archive {
+	if snapshotInterval == 0 {
+		snapshotInterval = 1
+	}
+	return &Archive{
+		f:                f,
+		beaconCfg:        beaconCfg,
+		snapshotInterval: snapshotInterval,
+		pending:          map[libcommon.Hash]*pendingBlock{},
+	}
+}
+
+// OnBlock must be called for every imported block together with its post state. Nothing is written
+// until the block is finalized, so that forks never end up in the archive.
+func (a *Archive) OnBlock(block *cltypes.SignedBeaconBlock, blockRoot libcommon.Hash, postState *state.CachingBeaconState) error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	p := &pendingBlock{root: blockRoot, block: block}
+	if a.isFirstInEpoch(block) {
+		encoded, err := postState.EncodeSSZ(nil)
+		if err != nil {
+			return err
+		}
+		p.anchor = utils.CompressSnappy(encoded)
+	}
+	a.pending[blockRoot] = p
+	if p.anchor != nil {
+		a.dropOldPendingAnchors()
+	}
+
+	finalizedRoot := postState.FinalizedCheckpoint().BlockRoot()
+	if finalizedRoot == a.lastFinalizedRoot {
+		return nil
+	}
+	a.lastFinalizedRoot = finalizedRoot
+	return a.flush(finalizedRoot)
+}
+
+// SetHead sets how the archive finds the head block, the states after the last finalized block are reconstructed out of
+// the blocks on its chain.
+func (a *Archive) SetHead(head func() (libcommon.Hash, uint64, error)) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.head = head
+}
+
+// FinalizedSlot returns the slot of the last finalized block, which is the last archived one. It is only known once a
+// block was finalized since the archive was created.
+func (a *Archive) FinalizedSlot() (uint64, error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if a.lastArchivedRoot == (libcommon.Hash{}) {
+		return 0, ErrStateNotArchived
+	}
+	return a.lastArchivedSlot, nil
+}
+
+// HeadSlot returns the slot of the head block.
+func (a *Archive) HeadSlot() (uint64, error) {
+	_, slot, err := a.unfinalizedChain()
+	return slot, err
+}
+
+// unfinalizedChain returns the blocks after the last finalized one up to the head, oldest first, and the head slot.
+func (a *Archive) unfinalizedChain() ([]*cltypes.SignedBeaconBlock, uint64, error) {
+	a.mu.Lock()
+	head := a.head
+	a.mu.Unlock()
+	if head == nil {
+		return nil, 0, ErrStateNotArchived
+	}
+	// The fork choice calls OnBlock with its own lock held, so it must not be called with ours.
+	headRoot, headSlot, err := head()
+	if err != nil {
+		return nil, 0, err
+	}
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	var chain []*cltypes.SignedBeaconBlock
+	for root := headRoot; root != a.lastArchivedRoot; {
+		p, ok := a.pending[root]
+		if !ok {
+			return nil, 0, ErrStateNotArchived
+		}
+		chain = append(chain, p.block)
+		root = p.block.Block.ParentRoot
+	}
+	for i, j := 0, len(chain)-1; i < j; i, j = i+1, j-1 {
+		chain[i], chain[j] = chain[j], chain[i]
+	}
+	return chain, headSlot, nil
+}
+
+// SlotByStateRoot returns the slot of the state with the given root. Only the post states of blocks are known, those of
+// the finalized blocks and those of the blocks on the chain of the head.
+func (a *Archive) SlotByStateRoot(root libcommon.Hash) (uint64, error) {
+	r, _, err := a.f.Get(freezerNamespace, stateRootObject, root.Hex())
+	if err == nil {
+		defer r.Close()
+		encoded, err := io.ReadAll(r)
+		if err != nil {
+			return 0, err
+		}
+		if len(encoded) != 8 {
+			return 0, fmt.Errorf("archived slot of state root %x has invalid length %d", root, len(encoded))
+		}
+		return binary.BigEndian.Uint64(encoded), nil
+	}
+	if !errors.Is(err, os.ErrNotExist) {
+		return 0, err
+	}
+	chain, _, err := a.unfinalizedChain()
+	if err != nil {
+		return 0, err
+	}
+	for _, block := range chain {
+		if block.Block.StateRoot == root {
+			return block.Block.Slot, nil
+		}
+	}
+	return 0, ErrStateNotArchived
+}
+
+// isFirstInEpoch tells whether the parent of the block is in a previous epoch, or unknown.
+func (a *Archive) isFirstInEpoch(block *cltypes.SignedBeaconBlock) bool {
+	var parentSlot uint64
+	if parent, ok := a.pending[block.Block.ParentRoot]; ok {
+		parentSlot = parent.block.Block.Slot
+	} else if block.Block.ParentRoot == a.lastArchivedRoot {
+		parentSlot = a.lastArchivedSlot
+	} else {
+		return true
+	}
+	return parentSlot/a.beaconCfg.SlotsPerEpoch < block.Block.Slot/a.beaconCfg.SlotsPerEpoch
+}
+
+// dropOldPendingAnchors keeps the anchors of at most maxPendingAnchors blocks which are not finalized, the most recent ones.
+func (a *Archive) dropOldPendingAnchors() {
+	var anchored []*pendingBlock
+	for _, p := range a.pending {
+		if p.anchor != nil {
+			anchored = append(anchored, p)
+		}
+	}
+	if len(anchored) <= maxPendingAnchors {
+		return
+	}
+	sort.Slice(anchored, func(i, j int) bool { return anchored[i].block.Block.Slot < anchored[j].block.Block.Slot })
+	for _, p := range anchored[:len(anchored)-maxPendingAnchors] {
+		p.anchor = nil
+	}
+}
+
+// flush writes the chain ending in the finalized root and forgets about everything before it.
+func (a *Archive) flush(finalizedRoot libcommon.Hash) error {
+	var chain []*pendingBlock
+	for root := finalizedRoot; ; {
+		p, ok := a.pending[root]
+		if !ok {
+			break
+		}
+		chain = append(chain, p)
+		root = p.block.Block.ParentRoot
+	}
+	if len(chain) == 0 {
+		return nil
+	}
+	for i := len(chain) - 1; i >= 0; i-- {
+		p := chain[i]
+		if err := freezer.PutObjectSSZIntoFreezer(blockObject, freezerNamespace, p.block.Block.Slot, p.block, a.f); err != nil {
+			return err
+		}
+		slot := make([]byte, 8)
+		binary.BigEndian.PutUint64(slot, p.block.Block.Slot)
+		if err := a.f.Put(bytes.NewReader(slot), nil, freezerNamespace, stateRootObject, p.block.Block.StateRoot.Hex()); err != nil {
+			return err
+		}
+		if p.anchor != nil {
+			if err := a.putAnchor(p.block.Block.Slot/a.beaconCfg.SlotsPerEpoch, p.anchor); err != nil {
+				return err
+			}
+		}
+		a.lastArchivedRoot, a.lastArchivedSlot = p.root, p.block.Block.Slot
+	}
+	b, err := a.readBounds()
+	if err != nil {
+		return err
+	}
+	if b == nil {
+		b = &bounds{firstEpoch: a.lastArchivedSlot / a.beaconCfg.SlotsPerEpoch}
+	}
+	b.lastSlot = a.lastArchivedSlot
+	if err := a.putBounds(b); err != nil {
+		return err
+	}
+	for root, p := range a.pending {
+		if p.block.Block.Slot <= a.lastArchivedSlot {
+			delete(a.pending, root)
+		}
+	}
+	return nil
+}
+
+func (a *Archive) putAnchor(epoch uint64, compressed []byte) error {
+	encoded, err := utils.DecompressSnappy(compressed)
+	if err != nil {
+		return err
+	}
+	id := strconv.FormatUint(epoch, 10)
+	if a.lastAnchor == nil || epoch >= a.lastSnapshotEpoch+a.snapshotInterval {
+		if err := a.f.Put(bytes.NewReader(compressed), nil, freezerNamespace, snapshotObject, id); err != nil {
+			return err
+		}
+		a.lastSnapshotEpoch = epoch
+	} else {
+		diff := computeDiff(a.lastAnchorEpoch, a.lastAnchor, encoded)
+		if err := a.f.Put(bytes.NewReader(utils.CompressSnappy(diff)), nil, freezerNamespace, diffObject, id); err != nil {
+			return err
+		}
+	}
+	a.lastAnchor, a.lastAnchorEpoch = encoded, epoch
+
+	b, err := a.readBounds()
+	if err != nil {
+		return err
+	}
+	if b == nil {
+		b = &bounds{firstEpoch: epoch}
+	}
+	if len(encoded) >= stateSlotOffset+8 {
+		b.lastSlot = utils.Max64(b.lastSlot, binary.LittleEndian.Uint64(encoded[stateSlotOffset:]))
+	}
+	return a.putBounds(b)
+}
+
+// readBounds returns the bounds of the archive, nil if nothing was archived yet.
+func (a *Archive) readBounds() (*bounds, error) {
+	if a.bounds != nil {
+		b := *a.bounds
+		return &b, nil
+	}
+	r, _, err := a.f.Get(freezerNamespace, boundsObject, "0")
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer r.Close()
+	encoded, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	if len(encoded) != 16 {
+		return nil, fmt.Errorf("archive bounds have invalid length %d", len(encoded))
+	}
+	a.bounds = &bounds{firstEpoch: binary.BigEndian.Uint64(encoded), lastSlot: binary.BigEndian.Uint64(encoded[8:])}
+	b := *a.bounds
+	return &b, nil
+}
+
+func (a *Archive) putBounds(b *bounds) error {
+	encoded := make([]byte, 16)
+	binary.BigEndian.PutUint64(encoded, b.firstEpoch)
+	binary.BigEndian.PutUint64(encoded[8:], b.lastSlot)
+	if err := a.f.Put(bytes.NewReader(encoded), nil, freezerNamespace, boundsObject, "0"); err != nil {
+		return err
+	}
+	a.bounds = b
+	return nil
+}
+
+// ReadBlock reads the finalized block at the given slot, it returns os.ErrNotExist if the slot was empty or not archived.
+func (a *Archive) ReadBlock(slot uint64) (*cltypes.SignedBeaconBlock, error) {
+	encoded, err := a.readObject(blockObject, slot)
+	if err != nil {
+		return nil, err
+	}
+	block := new(cltypes.SignedBeaconBlock)
+	if err := block.DecodeSSZ(encoded, int(a.beaconCfg.GetCurrentStateVersion(slot/a.beaconCfg.SlotsPerEpoch))); err != nil {
+		return nil, err
+	}
+	return block, nil
+}
+
+// ReadState reconstructs the state at the given slot out of the closest archived state before it and the blocks in between.
+// The states after the last finalized block are reconstructed out of the blocks on the chain of the head, those after the
+// head or before the first archived state are not known.
+func (a *Archive) ReadState(slot uint64) (*state.CachingBeaconState, error) {
+	if finalizedSlot, err := a.FinalizedSlot(); err == nil && slot > finalizedSlot {
+		return a.readUnfinalizedState(finalizedSlot, slot)
+	}
+	a.mu.Lock()
+	b, err := a.readBounds()
+	a.mu.Unlock()
+	if err != nil {
+		return nil, err
+	}
+	// Until a block is finalized since the archive was created, the blocks after the last archived one are not known.
+	if b == nil || slot > b.lastSlot {
+		return nil, ErrStateNotArchived
+	}
+	// Epochs without blocks have no anchor, so we look further back until the closest one.
+	for next := slot/a.beaconCfg.SlotsPerEpoch + 1; next > b.firstEpoch; next-- {
+		encoded, err := a.readAnchor(next - 1)
+		if errors.Is(err, os.ErrNotExist) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		if len(encoded) < stateSlotOffset+8 {
+			return nil, fmt.Errorf("archived state is too short: %d", len(encoded))
+		}
+		anchorSlot := binary.LittleEndian.Uint64(encoded[stateSlotOffset:])
+		if anchorSlot > slot {
+			continue
+		}
+		s := state.New(a.beaconCfg)
+		if err := s.DecodeSSZ(encoded, int(a.beaconCfg.GetCurrentStateVersion(anchorSlot/a.beaconCfg.SlotsPerEpoch))); err != nil {
+			return nil, err
+		}
+		if err := a.replay(s, slot); err != nil {
+			return nil, err
+		}
+		return s, nil
+	}
+	return nil, ErrStateNotArchived
+}
+
+// readUnfinalizedState reconstructs the state at the given slot, after the last finalized block, by replaying the
+// blocks on the chain of the head on top of the finalized state.
+func (a *Archive) readUnfinalizedState(finalizedSlot, slot uint64) (*state.CachingBeaconState, error) {
+	chain, headSlot, err := a.unfinalizedChain()
+	if err != nil {
+		return nil, err
+	}
+	if slot > headSlot {
+		return nil, ErrStateNotArchived
+	}
+	s, err := a.ReadState(finalizedSlot)
+	if err != nil {
+		return nil, err
+	}
+	for _, block := range chain {
+		if block.Block.Slot > slot {
+			break
+		}
+		if err := transition.TransitionState(s, block, false); err != nil {
+			return nil, fmt.Errorf("could not replay block at slot %d: %w", block.Block.Slot, err)
+		}
+	}
+	if s.Slot() < slot {
+		if err := transition.DefaultMachine.ProcessSlots(s, slot); err != nil {
+			return nil, err
+		}
+	}
+	return s, nil
+}
+
+// replay applies the archived blocks on top of s until it reaches the given slot.
+func (a *Archive) replay(s *state.CachingBeaconState, slot uint64) error {
+	for currentSlot := s.Slot() + 1; currentSlot <= slot; currentSlot++ {
+		block, err := a.ReadBlock(currentSlot)
+		if errors.Is(err, os.ErrNotExist) {
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		if err := transition.TransitionState(s, block, false); err != nil {
+			return fmt.Errorf("could not replay block at slot %d: %w", currentSlot, err)
+		}
+	}
+	if s.Slot() < slot {
+		return transition.DefaultMachine.ProcessSlots(s, slot)
+	}
+	return nil
+}
+
+// readAnchor returns the SSZ encoding of the anchor state of an epoch, following diffs down to the closest snapshot.
+func (a *Archive) readAnchor(epoch uint64) ([]byte, error) {
+	var diffs [][]byte
+	for {
+		snapshot, err := a.readObject(snapshotObject, epoch)
+		if err == nil {
+			for i := len(diffs) - 1; i >= 0; i-- {
+				if snapshot, err = applyDiff(snapshot, diffs[i]); err != nil {
+					return nil, err
+				}
+			}
+			return snapshot, nil
+		}
+		if !errors.Is(err, os.ErrNotExist) {
+			return nil, err
+		}
+		diff, err := a.readObject(diffObject, epoch)
+		if err != nil {
+			return nil, err
+		}
+		baseEpoch, err := diffBaseEpoch(diff)
+		if err != nil {
+			return nil, err
+		}
+		if baseEpoch >= epoch {
+			return nil, fmt.Errorf("state diff of epoch %d has invalid base epoch %d", epoch, baseEpoch)
+		}
+		diffs = append(diffs, diff)
+		epoch = baseEpoch
+	}
+}
+
+func (a *Archive) readObject(object string, id uint64) ([]byte, error) {
+	r, _, err := a.f.Get(freezerNamespace, object, strconv.FormatUint(id, 10))
+	if err != nil {
+		return nil, err
+	}
+	defer r.Close()
+	compressed, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	return utils.DecompressSnappy(compressed)
+}
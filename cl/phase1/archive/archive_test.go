@@ -0,0 +1,100 @@
+package archive
+
+import (
+	"math"
+	"testing"
+
+	libcommon "github.com/ledgerwatch/erigon-lib/common"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ledgerwatch/erigon/cl/clparams"
+	"github.com/ledgerwatch/erigon/cl/cltypes"
+	"github.com/ledgerwatch/erigon/cl/freezer"
+	"github.com/ledgerwatch/erigon/cl/phase1/core/state/raw"
+	"github.com/ledgerwatch/erigon/cl/utils"
+)
+
+func TestDiff(t *testing.T) {
+	base := []byte{1, 2, 3, 4, 5}
+	for _, target := range [][]byte{{1, 2, 3, 4, 6}, {1, 2}, {1, 2, 3, 4, 5, 6, 7}, {}} {
+		diff := computeDiff(7, base, target)
+		baseEpoch, err := diffBaseEpoch(diff)
+		require.NoError(t, err)
+		require.Equal(t, uint64(7), baseEpoch)
+		rebuilt, err := applyDiff(base, diff)
+		require.NoError(t, err)
+		require.Equal(t, target, rebuilt)
+	}
+	_, err := applyDiff(base, []byte{1})
+	require.Error(t, err)
+}
+
+func TestArchiveAnchors(t *testing.T) {
+	cfg := clparams.MainnetBeaconConfig
+	cfg.AltairForkEpoch, cfg.BellatrixForkEpoch, cfg.CapellaForkEpoch, cfg.DenebForkEpoch = 0, 0, 0, 0
+	f := &freezer.InMemory{}
+	a := New(f, &cfg, 4)
+
+	s := raw.GetTestState()
+	epoch := s.Slot() / cfg.SlotsPerEpoch
+	encoded, err := s.EncodeSSZ(nil)
+	require.NoError(t, err)
+	require.NoError(t, a.putAnchor(epoch, utils.CompressSnappy(encoded)))
+
+	// The next epoch is stored as a diff against the first one.
+	s.SetSlot(s.Slot() + cfg.SlotsPerEpoch)
+	require.NoError(t, s.SetValidatorBalance(0, 42))
+	encoded, err = s.EncodeSSZ(nil)
+	require.NoError(t, err)
+	require.NoError(t, a.putAnchor(epoch+1, utils.CompressSnappy(encoded)))
+	_, err = a.readObject(diffObject, epoch+1)
+	require.NoError(t, err)
+
+	expectedRoot, err := s.HashSSZ()
+	require.NoError(t, err)
+	reconstructed, err := a.ReadState(s.Slot())
+	require.NoError(t, err)
+	root, err := reconstructed.HashSSZ()
+	require.NoError(t, err)
+	require.Equal(t, expectedRoot, root)
+	balance, err := reconstructed.ValidatorBalance(0)
+	require.NoError(t, err)
+	require.Equal(t, uint64(42), balance)
+
+	// The epochs without blocks after the last anchor are replayed from it, however many there are.
+	later := s.Slot() + 2*a.snapshotInterval*cfg.SlotsPerEpoch
+	_, err = a.ReadState(later)
+	require.ErrorIs(t, err, ErrStateNotArchived)
+	require.NoError(t, a.putBounds(&bounds{firstEpoch: epoch, lastSlot: later}))
+	reconstructed, err = a.ReadState(later)
+	require.NoError(t, err)
+	require.Equal(t, later, reconstructed.Slot())
+
+	// States outside of the archive are not looked for.
+	_, err = a.ReadState(epoch*cfg.SlotsPerEpoch - 1)
+	require.ErrorIs(t, err, ErrStateNotArchived)
+	_, err = a.ReadState(math.MaxUint64)
+	require.ErrorIs(t, err, ErrStateNotArchived)
+
+	// The bounds are kept across restarts.
+	reopened := New(f, &cfg, 4)
+	reconstructed, err = reopened.ReadState(later)
+	require.NoError(t, err)
+	require.Equal(t, later, reconstructed.Slot())
+	_, err = reopened.ReadState(later + 1)
+	require.ErrorIs(t, err, ErrStateNotArchived)
+}
+
+func TestDropOldPendingAnchors(t *testing.T) {
+	a := New(&freezer.InMemory{}, &clparams.MainnetBeaconConfig, 4)
+	for slot := uint64(0); slot < 2*maxPendingAnchors; slot++ {
+		a.pending[libcommon.Hash{byte(slot)}] = &pendingBlock{
+			block:  &cltypes.SignedBeaconBlock{Block: &cltypes.BeaconBlock{Slot: slot}},
+			anchor: []byte{1},
+		}
+	}
+	a.dropOldPendingAnchors()
+	for _, p := range a.pending {
+		require.Equal(t, p.block.Block.Slot >= maxPendingAnchors, p.anchor != nil, "slot %d", p.block.Block.Slot)
+	}
+}
@@ -0,0 +1,49 @@
+package archive
+
+import (
+	"encoding/binary"
+	"fmt"
+)
+
+// diffHeaderSize is the size of the header of a state diff: [base epoch + target length].
+const diffHeaderSize = 16
+
+// computeDiff encodes target relative to base as the xor of the two. Consecutive states keep most of their encoding at the
+// same offsets, so the xor is mostly zeroes and compresses well, up to the end of the validator list. When validators are
+// added, the lists after it (balances, participations, inactivity scores...) are shifted and their xor does not compress.
+func computeDiff(baseEpoch uint64, base, target []byte) []byte {
+	diff := make([]byte, diffHeaderSize+len(target))
+	binary.BigEndian.PutUint64(diff, baseEpoch)
+	binary.BigEndian.PutUint64(diff[8:], uint64(len(target)))
+	out := diff[diffHeaderSize:]
+	copy(out, target)
+	for i := 0; i < len(base) && i < len(out); i++ {
+		out[i] ^= base[i]
+	}
+	return diff
+}
+
+// diffBaseEpoch returns the epoch of the state a diff was computed against.
+func diffBaseEpoch(diff []byte) (uint64, error) {
+	if len(diff) < diffHeaderSize {
+		return 0, fmt.Errorf("state diff too short: %d", len(diff))
+	}
+	return binary.BigEndian.Uint64(diff), nil
+}
+
+// applyDiff rebuilds the target encoding out of its base and diff.
+func applyDiff(base, diff []byte) ([]byte, error) {
+	if len(diff) < diffHeaderSize {
+		return nil, fmt.Errorf("state diff too short: %d", len(diff))
+	}
+	targetLength := binary.BigEndian.Uint64(diff[8:])
+	if uint64(len(diff)-diffHeaderSize) != targetLength {
+		return nil, fmt.Errorf("state diff has mismatching length, expected %d, got %d", targetLength, len(diff)-diffHeaderSize)
+	}
+	target := make([]byte, targetLength)
+	copy(target, diff[diffHeaderSize:])
+	for i := 0; i < len(base) && i < len(target); i++ {
+		target[i] ^= base[i]
+	}
+	return target, nil
+}
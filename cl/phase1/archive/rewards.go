@@ -0,0 +1,46 @@
+package archive
+
+import (
+	"github.com/ledgerwatch/erigon/cl/transition/impl/eth2"
+	"github.com/ledgerwatch/erigon/cl/transition/machine"
+	"github.com/ledgerwatch/erigon/cl/transition/rewards"
+)
+
+// BlockRewards replays the archived block at the given slot on top of its pre state and returns the rewards it
+// collected, both the block and the sync committee ones. It returns os.ErrNotExist if the slot is empty.
+func (a *Archive) BlockRewards(slot uint64) (*rewards.Collector, error) {
+	block, err := a.ReadBlock(slot)
+	if err != nil {
+		return nil, err
+	}
+	if slot == 0 {
+		// The genesis block is not processed, so it has no rewards.
+		return rewards.NewCollector(), nil
+	}
+	s, err := a.ReadState(slot - 1)
+	if err != nil {
+		return nil, err
+	}
+	collector := rewards.NewCollector()
+	if err := machine.TransitionState(&eth2.Impl{Rewards: collector}, s, block); err != nil {
+		return nil, err
+	}
+	// Attestation rewards of an epoch transition right before the block are not part of the block rewards.
+	collector.Attestations, collector.IdealAttestations = map[uint64]*rewards.AttestationRewards{}, map[uint64]*rewards.AttestationRewards{}
+	return collector, nil
+}
+
+// AttestationRewards returns the rewards of the attestations of the given epoch, which are applied at the end
+// of the following epoch.
+func (a *Archive) AttestationRewards(epoch uint64) (*rewards.Collector, error) {
+	transitionSlot := (epoch + 2) * a.beaconCfg.SlotsPerEpoch
+	s, err := a.ReadState(transitionSlot - 1)
+	if err != nil {
+		return nil, err
+	}
+	collector := rewards.NewCollector()
+	if err := (&eth2.Impl{Rewards: collector}).ProcessSlots(s, transitionSlot); err != nil {
+		return nil, err
+	}
+	return collector, nil
+}
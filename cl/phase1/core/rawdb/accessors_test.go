@@ -44,3 +44,41 @@ func TestFinalizedBlockRoot(t *testing.T) {
 	require.NoError(t, err)
 	require.Equal(t, libcommon.BytesToHash(root[:]), newRoot)
 }
+
+func TestLightClientObjects(t *testing.T) {
+	_, tx := memdb.NewTestTx(t)
+
+	update := cltypes.NewLightClientUpdate(clparams.CapellaVersion)
+	update.AttestedHeader.Beacon.Slot = 8192
+	update.SignatureSlot = 8193
+	require.NoError(t, rawdb.WriteLightClientUpdate(tx, 1, update))
+	readUpdate, err := rawdb.ReadLightClientUpdate(tx, 1)
+	require.NoError(t, err)
+	require.Equal(t, clparams.CapellaVersion, readUpdate.Version())
+	require.Equal(t, update.SignatureSlot, readUpdate.SignatureSlot)
+	missingUpdate, err := rawdb.ReadLightClientUpdate(tx, 2)
+	require.NoError(t, err)
+	require.Nil(t, missingUpdate)
+
+	bootstrap := cltypes.NewLightClientBootstrap(clparams.AltairVersion)
+	bootstrap.Header.Beacon.Slot = 64
+	root := libcommon.HexToHash("0x01")
+	require.NoError(t, rawdb.WriteLightClientBootstrap(tx, root, bootstrap))
+	readBootstrap, err := rawdb.ReadLightClientBootstrap(tx, root)
+	require.NoError(t, err)
+	require.Equal(t, uint64(64), readBootstrap.Header.Beacon.Slot)
+
+	finalityUpdate := cltypes.NewLightClientFinalityUpdate(clparams.DenebVersion)
+	finalityUpdate.FinalizedHeader.Beacon.Slot = 96
+	require.NoError(t, rawdb.WriteLightClientFinalityUpdate(tx, finalityUpdate))
+	readFinalityUpdate, err := rawdb.ReadLightClientFinalityUpdate(tx)
+	require.NoError(t, err)
+	require.Equal(t, uint64(96), readFinalityUpdate.FinalizedHeader.Beacon.Slot)
+
+	optimisticUpdate := cltypes.NewLightClientOptimisticUpdate(clparams.AltairVersion)
+	optimisticUpdate.SignatureSlot = 97
+	require.NoError(t, rawdb.WriteLightClientOptimisticUpdate(tx, optimisticUpdate))
+	readOptimisticUpdate, err := rawdb.ReadLightClientOptimisticUpdate(tx)
+	require.NoError(t, err)
+	require.Equal(t, uint64(97), readOptimisticUpdate.SignatureSlot)
+}
@@ -0,0 +1,116 @@
+package rawdb
+
+import (
+	libcommon "github.com/ledgerwatch/erigon-lib/common"
+	"github.com/ledgerwatch/erigon-lib/kv"
+	"github.com/ledgerwatch/erigon-lib/types/ssz"
+
+	"github.com/ledgerwatch/erigon/cl/clparams"
+	"github.com/ledgerwatch/erigon/cl/cltypes"
+	"github.com/ledgerwatch/erigon/cl/utils"
+)
+
+// Light client objects change shape across forks, so each of them is stored as [version byte + snappy(ssz)].
+
+func encodeLightClientObject(version clparams.StateVersion, obj ssz.Marshaler) ([]byte, error) {
+	encoded, err := obj.EncodeSSZ(nil)
+	if err != nil {
+		return nil, err
+	}
+	return append([]byte{byte(version)}, utils.CompressSnappy(encoded)...), nil
+}
+
+func decodeLightClientObject(data []byte, obj ssz.Unmarshaler) (bool, error) {
+	if len(data) == 0 {
+		return false, nil
+	}
+	decompressed, err := utils.DecompressSnappy(data[1:])
+	if err != nil {
+		return false, err
+	}
+	return true, obj.DecodeSSZ(decompressed, int(data[0]))
+}
+
+// WriteLightClientUpdate writes the best light client update for the sync committee period of its attested header.
+func WriteLightClientUpdate(tx kv.RwTx, period uint64, update *cltypes.LightClientUpdate) error {
+	data, err := encodeLightClientObject(update.Version(), update)
+	if err != nil {
+		return err
+	}
+	return tx.Put(kv.LightClientUpdates, EncodeNumber(period), data)
+}
+
+// ReadLightClientUpdate reads the best light client update of a sync committee period, nil if there is none.
+func ReadLightClientUpdate(tx kv.Getter, period uint64) (*cltypes.LightClientUpdate, error) {
+	data, err := tx.GetOne(kv.LightClientUpdates, EncodeNumber(period))
+	if err != nil {
+		return nil, err
+	}
+	update := &cltypes.LightClientUpdate{}
+	if found, err := decodeLightClientObject(data, update); err != nil || !found {
+		return nil, err
+	}
+	return update, nil
+}
+
+// WriteLightClientBootstrap writes the bootstrap object for a given (finalized) block root.
+func WriteLightClientBootstrap(tx kv.RwTx, blockRoot libcommon.Hash, bootstrap *cltypes.LightClientBootstrap) error {
+	data, err := encodeLightClientObject(bootstrap.Version(), bootstrap)
+	if err != nil {
+		return err
+	}
+	return tx.Put(kv.LightClient, blockRoot[:], data)
+}
+
+// ReadLightClientBootstrap reads the bootstrap object for a given block root, nil if there is none.
+func ReadLightClientBootstrap(tx kv.Getter, blockRoot libcommon.Hash) (*cltypes.LightClientBootstrap, error) {
+	data, err := tx.GetOne(kv.LightClient, blockRoot[:])
+	if err != nil {
+		return nil, err
+	}
+	bootstrap := &cltypes.LightClientBootstrap{}
+	if found, err := decodeLightClientObject(data, bootstrap); err != nil || !found {
+		return nil, err
+	}
+	return bootstrap, nil
+}
+
+func WriteLightClientFinalityUpdate(tx kv.RwTx, update *cltypes.LightClientFinalityUpdate) error {
+	data, err := encodeLightClientObject(update.Version(), update)
+	if err != nil {
+		return err
+	}
+	return tx.Put(kv.LightClient, kv.LightClientFinalityUpdate, data)
+}
+
+func ReadLightClientFinalityUpdate(tx kv.Getter) (*cltypes.LightClientFinalityUpdate, error) {
+	data, err := tx.GetOne(kv.LightClient, kv.LightClientFinalityUpdate)
+	if err != nil {
+		return nil, err
+	}
+	update := &cltypes.LightClientFinalityUpdate{}
+	if found, err := decodeLightClientObject(data, update); err != nil || !found {
+		return nil, err
+	}
+	return update, nil
+}
+
+func WriteLightClientOptimisticUpdate(tx kv.RwTx, update *cltypes.LightClientOptimisticUpdate) error {
+	data, err := encodeLightClientObject(update.Version(), update)
+	if err != nil {
+		return err
+	}
+	return tx.Put(kv.LightClient, kv.LightClientOptimisticUpdate, data)
+}
+
+func ReadLightClientOptimisticUpdate(tx kv.Getter) (*cltypes.LightClientOptimisticUpdate, error) {
+	data, err := tx.GetOne(kv.LightClient, kv.LightClientOptimisticUpdate)
+	if err != nil {
+		return nil, err
+	}
+	update := &cltypes.LightClientOptimisticUpdate{}
+	if found, err := decodeLightClientObject(data, update); err != nil || !found {
+		return nil, err
+	}
+	return update, nil
+}
@@ -301,3 +301,35 @@ func (b *BeaconState) markLeaf(idxs ...StateLeafIndex) {
 		b.touchedLeaves[idx] = true
 	}
 }
+
+// CurrentSyncCommitteeBranch returns the merkle branch of the current sync committee against the state root.
+func (b *BeaconState) CurrentSyncCommitteeBranch() ([][32]byte, error) {
+	return b.leafBranch(CurrentSyncCommitteeLeafIndex)
+}
+
+// NextSyncCommitteeBranch returns the merkle branch of the next sync committee against the state root.
+func (b *BeaconState) NextSyncCommitteeBranch() ([][32]byte, error) {
+	return b.leafBranch(NextSyncCommitteeLeafIndex)
+}
+
+// FinalityRootBranch returns the merkle branch of the finalized checkpoint root against the state root.
+func (b *BeaconState) FinalityRootBranch() ([][32]byte, error) {
+	branch, err := b.leafBranch(FinalizedCheckpointLeafIndex)
+	if err != nil {
+		return nil, err
+	}
+	// The root is the second field of the checkpoint, so the first sibling is the epoch.
+	epochRoot := merkle_tree.Uint64Root(b.finalizedCheckpoint.Epoch())
+	return append([][32]byte{epochRoot}, branch...), nil
+}
+
+func (b *BeaconState) leafBranch(idx StateLeafIndex) ([][32]byte, error) {
+	if err := b.computeDirtyLeaves(); err != nil {
+		return nil, err
+	}
+	schema := make([]interface{}, 0, len(b.leaves)/32)
+	for i := 0; i < len(b.leaves); i += 32 {
+		schema = append(schema, b.leaves[i:i+32])
+	}
+	return merkle_tree.MerkleProof(int(merkle_tree.GetDepth(uint64(len(schema)))), int(idx), schema...)
+}
@@ -5,6 +5,7 @@ import (
 	"testing"
 
 	"github.com/ledgerwatch/erigon-lib/common"
+	"github.com/ledgerwatch/erigon/cl/utils"
 	"github.com/stretchr/testify/require"
 )
 
@@ -29,3 +30,33 @@ func TestGetters(t *testing.T) {
 	require.NoError(t, err)
 	require.Equal(t, common.Hash(root), common.HexToHash("0x9f1620db18ee06b9cbdf1b7fa9658701063d2bd05d54b09780f6c0a074b4ce5f"))
 }
+
+func TestLightClientBranches(t *testing.T) {
+	state := GetTestState()
+	stateRoot, err := state.HashSSZ()
+	require.NoError(t, err)
+
+	toHashes := func(branch [][32]byte) []common.Hash {
+		ret := make([]common.Hash, len(branch))
+		for i := range branch {
+			ret[i] = branch[i]
+		}
+		return ret
+	}
+
+	currentSyncCommitteeRoot, err := state.CurrentSyncCommittee().HashSSZ()
+	require.NoError(t, err)
+	branch, err := state.CurrentSyncCommitteeBranch()
+	require.NoError(t, err)
+	require.True(t, utils.IsValidMerkleBranch(currentSyncCommitteeRoot, toHashes(branch), 5, uint64(CurrentSyncCommitteeLeafIndex), stateRoot))
+
+	nextSyncCommitteeRoot, err := state.NextSyncCommittee().HashSSZ()
+	require.NoError(t, err)
+	branch, err = state.NextSyncCommitteeBranch()
+	require.NoError(t, err)
+	require.True(t, utils.IsValidMerkleBranch(nextSyncCommitteeRoot, toHashes(branch), 5, uint64(NextSyncCommitteeLeafIndex), stateRoot))
+
+	branch, err = state.FinalityRootBranch()
+	require.NoError(t, err)
+	require.True(t, utils.IsValidMerkleBranch(state.FinalizedCheckpoint().BlockRoot(), toHashes(branch), 6, 41, stateRoot))
+}
@@ -5,9 +5,11 @@ import (
 
 	"github.com/ledgerwatch/erigon/cl/cltypes/solid"
 	"github.com/ledgerwatch/erigon/cl/freezer"
+	"github.com/ledgerwatch/erigon/cl/phase1/archive"
 	state2 "github.com/ledgerwatch/erigon/cl/phase1/core/state"
 	"github.com/ledgerwatch/erigon/cl/phase1/execution_client"
 	"github.com/ledgerwatch/erigon/cl/phase1/forkchoice/fork_graph"
+	"github.com/ledgerwatch/erigon/cl/phase1/light_client"
 
 	lru "github.com/hashicorp/golang-lru/v2"
 	libcommon "github.com/ledgerwatch/erigon-lib/common"
@@ -41,6 +43,10 @@ type ForkChoiceStore struct {
 	engine execution_client.ExecutionEngine
 	// freezer
 	recorder freezer.Freezer
+	// light client server, optional
+	lightClient *light_client.Server
+	// historical states archive, optional
+	archive *archive.Archive
 }
 
 type LatestMessage struct {
@@ -146,3 +152,21 @@ func (f *ForkChoiceStore) AnchorSlot() uint64 {
 	defer f.mu.Unlock()
 	return f.forkGraph.AnchorSlot()
 }
+
+// SetLightClientServer sets the server which gets fed every imported block to produce light client updates.
+func (f *ForkChoiceStore) SetLightClientServer(server *light_client.Server) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.lightClient = server
+}
+
+// SetArchive sets the archive which gets fed every imported block to store historical states, it finds the head
+// through the fork choice.
+func (f *ForkChoiceStore) SetArchive(a *archive.Archive) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.archive = a
+	if a != nil {
+		a.SetHead(f.GetHead)
+	}
+}
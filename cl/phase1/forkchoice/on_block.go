@@ -95,5 +95,15 @@ func (f *ForkChoiceStore) OnBlock(block *cltypes.SignedBeaconBlock, newPayload,
 	if blockEpoch < currentEpoch {
 		f.updateCheckpoints(lastProcessedState.CurrentJustifiedCheckpoint().Copy(), lastProcessedState.FinalizedCheckpoint().Copy())
 	}
+	if f.lightClient != nil {
+		if err := f.lightClient.OnBlock(block, blockRoot, lastProcessedState); err != nil {
+			log.Warn("could not produce light client updates", "err", err)
+		}
+	}
+	if f.archive != nil {
+		if err := f.archive.OnBlock(block, blockRoot, lastProcessedState); err != nil {
+			log.Warn("could not archive block", "err", err)
+		}
+	}
 	return nil
 }
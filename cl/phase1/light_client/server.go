@@ -0,0 +1,383 @@
+package light_client
+
+import (
+	"context"
+	"sync"
+
+	lru "github.com/hashicorp/golang-lru/v2"
+	libcommon "github.com/ledgerwatch/erigon-lib/common"
+	"github.com/ledgerwatch/erigon-lib/kv"
+
+	"github.com/ledgerwatch/erigon/cl/clparams"
+	"github.com/ledgerwatch/erigon/cl/cltypes"
+	"github.com/ledgerwatch/erigon/cl/cltypes/solid"
+	"github.com/ledgerwatch/erigon/cl/phase1/core/rawdb"
+	"github.com/ledgerwatch/erigon/cl/phase1/core/state"
+)
+
+// blocksCacheSize is how many recent blocks we keep light client data for, enough to go back to the finalized checkpoint.
+const blocksCacheSize = 256
+
+// bootstrapsCacheSize is how many recent bootstraps are kept in memory, older ones are read from the database.
+const bootstrapsCacheSize = 16
+
+// bestUpdatesCachePeriods is how many recent sync committee periods the best updates are kept in memory for, older ones are
+// read from the database.
+const bestUpdatesCachePeriods = 2
+
+// blockData is everything light clients may need to know about a processed block and its post state.
+type blockData struct {
+	header                     *cltypes.LightClientHeader
+	currentSyncCommittee       *solid.SyncCommittee
+	currentSyncCommitteeBranch [][32]byte
+	nextSyncCommittee          *solid.SyncCommittee
+	nextSyncCommitteeBranch    [][32]byte
+	finalizedRoot              libcommon.Hash
+	finalityBranch             [][32]byte
+}
+
+// Server produces light client bootstraps and updates out of the blocks imported by the fork choice.
+// Objects are persisted, if a database is given, so that the sentinel and the beacon API can serve them. Only the recent ones
+// are kept in memory, so without a database older bootstraps and updates are forgotten.
+type Server struct {
+	ctx       context.Context
+	db        kv.RwDB
+	beaconCfg *clparams.BeaconChainConfig
+
+	blocks *lru.Cache[libcommon.Hash, *blockData]
+
+	mu                sync.RWMutex
+	finalityUpdate    *cltypes.LightClientFinalityUpdate
+	optimisticUpdate  *cltypes.LightClientOptimisticUpdate
+	bestUpdates       map[uint64]*cltypes.LightClientUpdate
+	bootstraps        *lru.Cache[libcommon.Hash, *cltypes.LightClientBootstrap]
+	lastFinalizedRoot libcommon.Hash
+}
+
+// NewServer creates a light client server, db can be nil in which case nothing is persisted.
+func NewServer(ctx context.Context, db kv.RwDB, beaconCfg *clparams.BeaconChainConfig) (*Server, error) {
+	blocks, err := lru.New[libcommon.Hash, *blockData](blocksCacheSize)
+	if err != nil {
+		return nil, err
+	}
+	bootstraps, err := lru.New[libcommon.Hash, *cltypes.LightClientBootstrap](bootstrapsCacheSize)
+	if err != nil {
+		return nil, err
+	}
+	return &Server{
+		ctx:         ctx,
+		db:          db,
+		beaconCfg:   beaconCfg,
+		blocks:      blocks,
+		bestUpdates: map[uint64]*cltypes.LightClientUpdate{},
+		bootstraps:  bootstraps,
+	}, nil
+}
+
+// OnBlock must be called for every block successfully imported, together with its post state.
+func (s *Server) OnBlock(block *cltypes.SignedBeaconBlock, blockRoot libcommon.Hash, postState *state.CachingBeaconState) error {
+	if block.Version() < clparams.AltairVersion {
+		return nil
+	}
+	data, err := s.computeBlockData(block, postState)
+	if err != nil {
+		return err
+	}
+	s.blocks.Add(blockRoot, data)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := s.processSyncAggregate(block); err != nil {
+		return err
+	}
+	// Once a block is finalized it becomes a safe starting point for light clients.
+	finalizedRoot := postState.FinalizedCheckpoint().BlockRoot()
+	if finalizedRoot == s.lastFinalizedRoot {
+		return nil
+	}
+	s.lastFinalizedRoot = finalizedRoot
+	finalized, ok := s.blocks.Get(finalizedRoot)
+	if !ok {
+		return nil
+	}
+	bootstrap := &cltypes.LightClientBootstrap{
+		Header:                     finalized.header,
+		CurrentSyncCommittee:       finalized.currentSyncCommittee,
+		CurrentSyncCommitteeBranch: toHashVector(finalized.currentSyncCommitteeBranch),
+	}
+	s.bootstraps.Add(finalizedRoot, bootstrap)
+	return s.persist(func(tx kv.RwTx) error {
+		return rawdb.WriteLightClientBootstrap(tx, finalizedRoot, bootstrap)
+	})
+}
+
+// processSyncAggregate builds the updates attested by the sync aggregate of the block, which signs its parent.
+func (s *Server) processSyncAggregate(block *cltypes.SignedBeaconBlock) error {
+	attested, ok := s.blocks.Get(block.Block.ParentRoot)
+	if !ok {
+		return nil
+	}
+	aggregate := block.Block.Body.SyncAggregate
+	if aggregate == nil || uint64(aggregate.Sum()) < s.beaconCfg.MinSyncCommitteeParticipants {
+		return nil
+	}
+	signatureSlot := block.Block.Slot
+	version := attested.header.Version()
+
+	update := cltypes.NewLightClientUpdate(version)
+	update.AttestedHeader = attested.header
+	update.NextSyncCommittee = attested.nextSyncCommittee
+	update.NextSyncCommitteeBranch = toHashVector(attested.nextSyncCommitteeBranch)
+	update.SyncAggregate = aggregate
+	update.SignatureSlot = signatureSlot
+	if finalized, ok := s.blocks.Get(attested.finalizedRoot); ok {
+		update.FinalizedHeader = upgradeHeader(finalized.header, version)
+		update.FinalityBranch = toHashVector(attested.finalityBranch)
+	}
+	hasFinality := update.FinalizedHeader.Beacon.Slot != 0
+
+	optimistic := &cltypes.LightClientOptimisticUpdate{
+		AttestedHeader: update.AttestedHeader,
+		SyncAggregate:  aggregate,
+		SignatureSlot:  signatureSlot,
+	}
+	optimisticChanged := s.optimisticUpdate == nil ||
+		optimistic.AttestedHeader.Beacon.Slot > s.optimisticUpdate.AttestedHeader.Beacon.Slot
+	if optimisticChanged {
+		s.optimisticUpdate = optimistic
+	}
+
+	var finality *cltypes.LightClientFinalityUpdate
+	if hasFinality && (s.finalityUpdate == nil ||
+		update.FinalizedHeader.Beacon.Slot > s.finalityUpdate.FinalizedHeader.Beacon.Slot ||
+		(update.FinalizedHeader.Beacon.Slot == s.finalityUpdate.FinalizedHeader.Beacon.Slot &&
+			update.AttestedHeader.Beacon.Slot > s.finalityUpdate.AttestedHeader.Beacon.Slot)) {
+		finality = &cltypes.LightClientFinalityUpdate{
+			AttestedHeader:  update.AttestedHeader,
+			FinalizedHeader: update.FinalizedHeader,
+			FinalityBranch:  update.FinalityBranch,
+			SyncAggregate:   aggregate,
+			SignatureSlot:   signatureSlot,
+		}
+		s.finalityUpdate = finality
+	}
+
+	period := s.syncCommitteePeriod(update.AttestedHeader.Beacon.Slot)
+	best, err := s.bestUpdate(period)
+	if err != nil {
+		return err
+	}
+	bestChanged := best == nil || s.isBetterUpdate(update, best)
+	if bestChanged {
+		s.bestUpdates[period] = update
+	}
+
+	if err := s.persist(func(tx kv.RwTx) error {
+		if optimisticChanged {
+			if err := rawdb.WriteLightClientOptimisticUpdate(tx, optimistic); err != nil {
+				return err
+			}
+		}
+		if finality != nil {
+			if err := rawdb.WriteLightClientFinalityUpdate(tx, finality); err != nil {
+				return err
+			}
+		}
+		if bestChanged {
+			return rawdb.WriteLightClientUpdate(tx, period, update)
+		}
+		return nil
+	}); err != nil {
+		return err
+	}
+	s.pruneBestUpdates(period)
+	return nil
+}
+
+// pruneBestUpdates drops from memory the best updates of the periods before the recent ones, once they are persisted.
+func (s *Server) pruneBestUpdates(period uint64) {
+	for p := range s.bestUpdates {
+		if p+bestUpdatesCachePeriods <= period {
+			delete(s.bestUpdates, p)
+		}
+	}
+}
+
+// isBetterUpdate is a simplified version of is_better_update from the light client specs.
+func (s *Server) isBetterUpdate(newUpdate, oldUpdate *cltypes.LightClientUpdate) bool {
+	maxParticipants := s.beaconCfg.SyncCommitteeSize
+	newParticipants, oldParticipants := uint64(newUpdate.SyncAggregate.Sum()), uint64(oldUpdate.SyncAggregate.Sum())
+	// Compare supermajority (> 2/3) sync committee participation
+	newSupermajority, oldSupermajority := newParticipants*3 >= maxParticipants*2, oldParticipants*3 >= maxParticipants*2
+	if newSupermajority != oldSupermajority {
+		return newSupermajority
+	}
+	if !newSupermajority && newParticipants != oldParticipants {
+		return newParticipants > oldParticipants
+	}
+	// Compare presence of relevant sync committee
+	newRelevant := s.syncCommitteePeriod(newUpdate.AttestedHeader.Beacon.Slot) == s.syncCommitteePeriod(newUpdate.SignatureSlot)
+	oldRelevant := s.syncCommitteePeriod(oldUpdate.AttestedHeader.Beacon.Slot) == s.syncCommitteePeriod(oldUpdate.SignatureSlot)
+	if newRelevant != oldRelevant {
+		return newRelevant
+	}
+	// Compare indication of any finality
+	newFinality, oldFinality := newUpdate.FinalizedHeader.Beacon.Slot != 0, oldUpdate.FinalizedHeader.Beacon.Slot != 0
+	if newFinality != oldFinality {
+		return newFinality
+	}
+	// Tiebreaker 1: Sync committee participation beyond supermajority
+	if newParticipants != oldParticipants {
+		return newParticipants > oldParticipants
+	}
+	// Tiebreaker 2: Prefer older data (fewer changes to best)
+	if newUpdate.AttestedHeader.Beacon.Slot != oldUpdate.AttestedHeader.Beacon.Slot {
+		return newUpdate.AttestedHeader.Beacon.Slot < oldUpdate.AttestedHeader.Beacon.Slot
+	}
+	return newUpdate.SignatureSlot < oldUpdate.SignatureSlot
+}
+
+func (s *Server) computeBlockData(block *cltypes.SignedBeaconBlock, postState *state.CachingBeaconState) (*blockData, error) {
+	header, err := lightClientHeaderFromBlock(block)
+	if err != nil {
+		return nil, err
+	}
+	currentSyncCommitteeBranch, err := postState.CurrentSyncCommitteeBranch()
+	if err != nil {
+		return nil, err
+	}
+	nextSyncCommitteeBranch, err := postState.NextSyncCommitteeBranch()
+	if err != nil {
+		return nil, err
+	}
+	finalityBranch, err := postState.FinalityRootBranch()
+	if err != nil {
+		return nil, err
+	}
+	return &blockData{
+		header:                     header,
+		currentSyncCommittee:       postState.CurrentSyncCommittee().Copy(),
+		currentSyncCommitteeBranch: currentSyncCommitteeBranch,
+		nextSyncCommittee:          postState.NextSyncCommittee().Copy(),
+		nextSyncCommitteeBranch:    nextSyncCommitteeBranch,
+		finalizedRoot:              postState.FinalizedCheckpoint().BlockRoot(),
+		finalityBranch:             finalityBranch,
+	}, nil
+}
+
+func (s *Server) syncCommitteePeriod(slot uint64) uint64 {
+	return slot / s.beaconCfg.SlotsPerEpoch / s.beaconCfg.EpochsPerSyncCommitteePeriod
+}
+
+func (s *Server) persist(fn func(tx kv.RwTx) error) error {
+	if s.db == nil {
+		return nil
+	}
+	return s.db.Update(s.ctx, fn)
+}
+
+func (s *Server) bestUpdate(period uint64) (*cltypes.LightClientUpdate, error) {
+	if update, ok := s.bestUpdates[period]; ok || s.db == nil {
+		return update, nil
+	}
+	var update *cltypes.LightClientUpdate
+	if err := s.db.View(s.ctx, func(tx kv.Tx) (err error) {
+		update, err = rawdb.ReadLightClientUpdate(tx, period)
+		return
+	}); err != nil {
+		return nil, err
+	}
+	return update, nil
+}
+
+// Bootstrap returns the bootstrap for a finalized block root, nil if unknown.
+func (s *Server) Bootstrap(blockRoot libcommon.Hash) (*cltypes.LightClientBootstrap, error) {
+	bootstrap, ok := s.bootstraps.Get(blockRoot)
+	if ok || s.db == nil {
+		return bootstrap, nil
+	}
+	if err := s.db.View(s.ctx, func(tx kv.Tx) (err error) {
+		bootstrap, err = rawdb.ReadLightClientBootstrap(tx, blockRoot)
+		return
+	}); err != nil {
+		return nil, err
+	}
+	return bootstrap, nil
+}
+
+// Updates returns the best updates for up to count sync committee periods starting from startPeriod, it stops at the first gap.
+func (s *Server) Updates(startPeriod, count uint64) ([]*cltypes.LightClientUpdate, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	updates := make([]*cltypes.LightClientUpdate, 0, count)
+	for period := startPeriod; period < startPeriod+count; period++ {
+		update, err := s.bestUpdate(period)
+		if err != nil {
+			return nil, err
+		}
+		if update == nil {
+			break
+		}
+		updates = append(updates, update)
+	}
+	return updates, nil
+}
+
+// FinalityUpdate returns the latest finality update, nil if none was produced yet.
+func (s *Server) FinalityUpdate() *cltypes.LightClientFinalityUpdate {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.finalityUpdate
+}
+
+// OptimisticUpdate returns the latest optimistic update, nil if none was produced yet.
+func (s *Server) OptimisticUpdate() *cltypes.LightClientOptimisticUpdate {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.optimisticUpdate
+}
+
+func lightClientHeaderFromBlock(block *cltypes.SignedBeaconBlock) (*cltypes.LightClientHeader, error) {
+	bodyRoot, err := block.Block.Body.HashSSZ()
+	if err != nil {
+		return nil, err
+	}
+	header := cltypes.NewLightClientHeader(block.Version())
+	header.Beacon = &cltypes.BeaconBlockHeader{
+		Slot:          block.Block.Slot,
+		ProposerIndex: block.Block.ProposerIndex,
+		ParentRoot:    block.Block.ParentRoot,
+		Root:          block.Block.StateRoot,
+		BodyRoot:      bodyRoot,
+	}
+	if block.Version() < clparams.CapellaVersion {
+		return header, nil
+	}
+	if header.ExecutionPayloadHeader, err = block.Block.Body.ExecutionPayload.PayloadHeader(); err != nil {
+		return nil, err
+	}
+	branch, err := block.Block.Body.ExecutionPayloadMerkleProof()
+	if err != nil {
+		return nil, err
+	}
+	header.ExecutionBranch = toHashVector(branch)
+	return header, nil
+}
+
+// upgradeHeader lifts a header to a later fork, the execution part is left empty as in the upgrade functions of the specs.
+func upgradeHeader(header *cltypes.LightClientHeader, version clparams.StateVersion) *cltypes.LightClientHeader {
+	if header.Version() >= version {
+		return header
+	}
+	upgraded := cltypes.NewLightClientHeader(version)
+	upgraded.Beacon = header.Beacon
+	return upgraded
+}
+
+func toHashVector(branch [][32]byte) solid.HashVectorSSZ {
+	vector := solid.NewHashVector(len(branch))
+	for i := range branch {
+		vector.Set(i, branch[i])
+	}
+	return vector
+}
@@ -0,0 +1,91 @@
+package light_client
+
+import (
+	"context"
+	"testing"
+
+	"github.com/ledgerwatch/erigon-lib/kv"
+	"github.com/ledgerwatch/erigon-lib/kv/memdb"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ledgerwatch/erigon/cl/clparams"
+	"github.com/ledgerwatch/erigon/cl/cltypes"
+	"github.com/ledgerwatch/erigon/cl/phase1/core/rawdb"
+)
+
+func updateWithParticipants(participants int, attestedSlot, signatureSlot uint64) *cltypes.LightClientUpdate {
+	update := cltypes.NewLightClientUpdate(clparams.AltairVersion)
+	for i := 0; i < participants; i++ {
+		update.SyncAggregate.SyncCommiteeBits[i/8] |= 1 << (i % 8)
+	}
+	update.AttestedHeader.Beacon.Slot = attestedSlot
+	update.SignatureSlot = signatureSlot
+	return update
+}
+
+func TestIsBetterUpdate(t *testing.T) {
+	s, err := NewServer(context.Background(), nil, &clparams.MainnetBeaconConfig)
+	require.NoError(t, err)
+
+	// Supermajority wins over anything else.
+	require.True(t, s.isBetterUpdate(updateWithParticipants(400, 10, 11), updateWithParticipants(300, 10, 11)))
+	// Updates whose sync committee is relevant to the signature period are preferred.
+	period := s.beaconCfg.SlotsPerEpoch * s.beaconCfg.EpochsPerSyncCommitteePeriod
+	require.True(t, s.isBetterUpdate(updateWithParticipants(400, 10, 11), updateWithParticipants(500, period-1, period)))
+	// Updates with finality are preferred.
+	withFinality := updateWithParticipants(400, 10, 11)
+	withFinality.FinalizedHeader.Beacon.Slot = 1
+	require.True(t, s.isBetterUpdate(withFinality, updateWithParticipants(500, 10, 11)))
+	// Then more participants, then older data.
+	require.True(t, s.isBetterUpdate(updateWithParticipants(500, 10, 11), updateWithParticipants(400, 10, 11)))
+	require.True(t, s.isBetterUpdate(updateWithParticipants(400, 9, 11), updateWithParticipants(400, 10, 11)))
+}
+
+func TestUpdatesFromDatabase(t *testing.T) {
+	db := memdb.NewTestDB(t)
+	s, err := NewServer(context.Background(), db, &clparams.MainnetBeaconConfig)
+	require.NoError(t, err)
+
+	s.bestUpdates[1] = updateWithParticipants(400, 10, 11)
+	s.bestUpdates[2] = updateWithParticipants(400, 20, 21)
+	s.bestUpdates[4] = updateWithParticipants(400, 40, 41)
+
+	updates, err := s.Updates(1, 5)
+	require.NoError(t, err)
+	// Period 3 is missing, so only the first two can be returned.
+	require.Len(t, updates, 2)
+	require.Equal(t, uint64(20), updates[1].AttestedHeader.Beacon.Slot)
+
+	bootstrap, err := s.Bootstrap([32]byte{1})
+	require.NoError(t, err)
+	require.Nil(t, bootstrap)
+}
+
+func TestBestUpdatesPruned(t *testing.T) {
+	db := memdb.NewTestDB(t)
+	s, err := NewServer(context.Background(), db, &clparams.MainnetBeaconConfig)
+	require.NoError(t, err)
+
+	require.NoError(t, s.persist(func(tx kv.RwTx) error {
+		return rawdb.WriteLightClientUpdate(tx, 1, updateWithParticipants(400, 10, 11))
+	}))
+	s.bestUpdates[1] = updateWithParticipants(400, 10, 11)
+	s.bestUpdates[4] = updateWithParticipants(400, 40, 41)
+	s.bestUpdates[5] = updateWithParticipants(400, 50, 51)
+
+	s.pruneBestUpdates(5)
+	require.Len(t, s.bestUpdates, 2)
+	// The pruned update is still served from the database.
+	update, err := s.bestUpdate(1)
+	require.NoError(t, err)
+	require.Equal(t, uint64(10), update.AttestedHeader.Beacon.Slot)
+}
+
+func TestUpgradeHeader(t *testing.T) {
+	header := cltypes.NewLightClientHeader(clparams.AltairVersion)
+	header.Beacon.Slot = 42
+	upgraded := upgradeHeader(header, clparams.CapellaVersion)
+	require.Equal(t, clparams.CapellaVersion, upgraded.Version())
+	require.Equal(t, uint64(42), upgraded.Beacon.Slot)
+	require.Equal(t, header, upgradeHeader(header, clparams.AltairVersion))
+}
@@ -1,6 +1,10 @@
 package eth2
 
-import "github.com/ledgerwatch/erigon/cl/transition/machine"
+import (
+	"github.com/ledgerwatch/erigon/cl/abstract"
+	"github.com/ledgerwatch/erigon/cl/transition/machine"
+	"github.com/ledgerwatch/erigon/cl/transition/rewards"
+)
 
 type Impl = impl
 
@@ -8,4 +12,32 @@ var _ machine.Interface = (*impl)(nil)
 
 type impl struct {
 	FullValidation bool
+	// Rewards, if not nil, collects the breakdown of the rewards applied during the transition.
+	Rewards *rewards.Collector
+}
+
+// recordProposerReward runs fn and records the increase of the proposer balance it caused as a block reward.
+func (I *impl) recordProposerReward(s abstract.BeaconState, component rewards.BlockComponent, fn func() error) error {
+	if I.Rewards == nil {
+		return fn()
+	}
+	proposerIndex, err := s.GetBeaconProposerIndex()
+	if err != nil {
+		return err
+	}
+	before, err := s.ValidatorBalance(int(proposerIndex))
+	if err != nil {
+		return err
+	}
+	if err := fn(); err != nil {
+		return err
+	}
+	after, err := s.ValidatorBalance(int(proposerIndex))
+	if err != nil {
+		return err
+	}
+	if after > before {
+		I.Rewards.AddBlockReward(proposerIndex, component, after-before)
+	}
+	return nil
 }
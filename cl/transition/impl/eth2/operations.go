@@ -22,6 +22,7 @@ import (
 	"github.com/ledgerwatch/erigon/cl/clparams"
 	"github.com/ledgerwatch/erigon/cl/cltypes"
 	"github.com/ledgerwatch/erigon/cl/fork"
+	"github.com/ledgerwatch/erigon/cl/transition/rewards"
 	"github.com/ledgerwatch/erigon/cl/utils"
 	"github.com/ledgerwatch/erigon/core/types"
 )
@@ -78,8 +79,10 @@ func (I *impl) ProcessProposerSlashing(s abstract.BeaconState, propSlashing *clt
 	}
 
 	// Set whistleblower index to 0 so current proposer gets reward.
-	s.SlashValidator(h1.ProposerIndex, nil)
-	return nil
+	return I.recordProposerReward(s, rewards.BlockProposerSlashings, func() error {
+		s.SlashValidator(h1.ProposerIndex, nil)
+		return nil
+	})
 }
 
 func (I *impl) ProcessAttesterSlashing(s abstract.BeaconState, attSlashing *cltypes.AttesterSlashing) error {
@@ -116,7 +119,9 @@ func (I *impl) ProcessAttesterSlashing(s abstract.BeaconState, attSlashing *clty
 			return err
 		}
 		if validator.IsSlashable(currentEpoch) {
-			err := s.SlashValidator(ind, nil)
+			err := I.recordProposerReward(s, rewards.BlockAttesterSlashings, func() error {
+				return s.SlashValidator(ind, nil)
+			})
 			if err != nil {
 				return fmt.Errorf("unable to slash validator: %d", ind)
 			}
@@ -312,7 +317,7 @@ func (I *impl) ProcessExecutionPayload(s abstract.BeaconState, payload *cltypes.
 }
 
 func (I *impl) ProcessSyncAggregate(s abstract.BeaconState, sync *cltypes.SyncAggregate) error {
-	votedKeys, err := processSyncAggregate(s, sync)
+	votedKeys, err := processSyncAggregate(s, sync, I.Rewards)
 	if err != nil {
 		return err
 	}
@@ -341,8 +346,8 @@ func (I *impl) ProcessSyncAggregate(s abstract.BeaconState, sync *cltypes.SyncAg
 
 // processSyncAggregate applies all the logic in the spec function `process_sync_aggregate` except
 // verifying the BLS signatures. It returns the modified beacons state and the list of validators'
-// public keys that voted, for future signature verification.
-func processSyncAggregate(s abstract.BeaconState, sync *cltypes.SyncAggregate) ([][]byte, error) {
+// public keys that voted, for future signature verification. Rewards are recorded into the collector if not nil.
+func processSyncAggregate(s abstract.BeaconState, sync *cltypes.SyncAggregate, collector *rewards.Collector) ([][]byte, error) {
 	currentSyncCommittee := s.CurrentSyncCommittee()
 
 	if currentSyncCommittee == nil {
@@ -379,16 +384,19 @@ func processSyncAggregate(s abstract.BeaconState, sync *cltypes.SyncAggregate) (
 				if err := state.IncreaseBalance(s, vIdx, participantReward); err != nil {
 					return nil, err
 				}
+				collector.AddSyncCommitteeReward(vIdx, int64(participantReward))
 				earnedProposerReward += proposerReward
 			} else {
 				if err := state.DecreaseBalance(s, vIdx, participantReward); err != nil {
 					return nil, err
 				}
+				collector.AddSyncCommitteeReward(vIdx, -int64(participantReward))
 			}
 			currPubKeyIndex++
 		}
 	}
 
+	collector.AddBlockReward(proposerIndex, rewards.BlockSyncAggregate, earnedProposerReward)
 	return votedKeys, state.IncreaseBalance(s, proposerIndex, earnedProposerReward)
 }
 
@@ -484,11 +492,13 @@ func (I *impl) ProcessAttestations(s abstract.BeaconState, attestations *solid.L
 
 	c := h.Tag("attestation_step", "process")
 	var err error
-	if err := solid.RangeErr[*solid.Attestation](attestations, func(i int, a *solid.Attestation, _ int) error {
-		if attestingIndiciesSet[i], err = processAttestation(s, a, baseRewardPerIncrement); err != nil {
-			return err
-		}
-		return nil
+	if err := I.recordProposerReward(s, rewards.BlockAttestations, func() error {
+		return solid.RangeErr[*solid.Attestation](attestations, func(i int, a *solid.Attestation, _ int) error {
+			if attestingIndiciesSet[i], err = processAttestation(s, a, baseRewardPerIncrement); err != nil {
+				return err
+			}
+			return nil
+		})
 	}); err != nil {
 		return err
 	}
@@ -860,7 +870,7 @@ func (I *impl) ProcessSlots(s abstract.BeaconState, slot uint64) error {
 		// TODO(Someone): Add epoch transition.
 		if (sSlot+1)%beaconConfig.SlotsPerEpoch == 0 {
 			start := time.Now()
-			if err := statechange.ProcessEpoch(s); err != nil {
+			if err := statechange.ProcessEpoch(s, I.Rewards); err != nil {
 				return err
 			}
 			log.Debug("Processed new epoch successfully", "epoch", state.Epoch(s), "process_epoch_elpsed", time.Since(start))
@@ -3,10 +3,11 @@ package statechange
 import (
 	"github.com/ledgerwatch/erigon/cl/abstract"
 	"github.com/ledgerwatch/erigon/cl/clparams"
+	"github.com/ledgerwatch/erigon/cl/transition/rewards"
 )
 
-// ProcessEpoch process epoch transition.
-func ProcessEpoch(state abstract.BeaconState) error {
+// ProcessEpoch process epoch transition, the attestation rewards are recorded into the collector if not nil.
+func ProcessEpoch(state abstract.BeaconState, collector *rewards.Collector) error {
 	if err := ProcessJustificationBitsAndFinality(state); err != nil {
 		return err
 	}
@@ -15,7 +16,7 @@ func ProcessEpoch(state abstract.BeaconState) error {
 			return err
 		}
 	}
-	if err := ProcessRewardsAndPenalties(state); err != nil {
+	if err := processRewardsAndPenalties(state, collector); err != nil {
 		return err
 	}
 	if err := ProcessRegistryUpdates(state); err != nil {
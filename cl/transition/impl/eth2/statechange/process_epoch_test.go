@@ -7,6 +7,7 @@ import (
 
 	"github.com/ledgerwatch/erigon/cl/clparams"
 	"github.com/ledgerwatch/erigon/cl/phase1/core/state"
+	"github.com/ledgerwatch/erigon/cl/transition/rewards"
 	"github.com/ledgerwatch/erigon/cl/utils"
 	"github.com/stretchr/testify/require"
 )
@@ -94,6 +95,34 @@ func TestProcessRewardsAndPenalties(t *testing.T) {
 	})
 }
 
+func TestProcessRewardsAndPenaltiesCollector(t *testing.T) {
+	testState := state.New(&clparams.MainnetBeaconConfig)
+	require.NoError(t, utils.DecodeSSZSnappy(testState, startingRewardsPenaltyState, int(clparams.BellatrixVersion)))
+	before := make([]uint64, testState.ValidatorLength())
+	for i := range before {
+		before[i], _ = testState.ValidatorBalance(i)
+	}
+	collector := rewards.NewCollector()
+	require.NoError(t, processRewardsAndPenalties(testState, collector))
+	require.NotEmpty(t, collector.Attestations)
+	require.NotEmpty(t, collector.IdealAttestations)
+	// The recorded breakdown must add up to the actual balance changes.
+	for i := range before {
+		after, err := testState.ValidatorBalance(i)
+		require.NoError(t, err)
+		var delta int64
+		if r, ok := collector.Attestations[uint64(i)]; ok {
+			for _, amount := range r {
+				delta += amount
+			}
+		}
+		if after == 0 {
+			continue
+		}
+		require.Equal(t, int64(after)-int64(before[i]), delta, "validator %d", i)
+	}
+}
+
 func TestProcessRegistryUpdates(t *testing.T) {
 	runEpochTransitionConsensusTest(t, startingRegistryUpdatesState, expectedRegistryUpdatesState, func(s abstract.BeaconState) error {
 		return ProcessRegistryUpdates(s)
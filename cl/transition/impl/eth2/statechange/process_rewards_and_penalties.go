@@ -5,9 +5,22 @@ import (
 	"github.com/ledgerwatch/erigon/cl/clparams"
 	"github.com/ledgerwatch/erigon/cl/cltypes/solid"
 	"github.com/ledgerwatch/erigon/cl/phase1/core/state"
+	"github.com/ledgerwatch/erigon/cl/transition/rewards"
 )
 
-func processRewardsAndPenaltiesPostAltair(s abstract.BeaconState) (err error) {
+// flagComponent maps a participation flag to its component of the attestation rewards.
+func flagComponent(beaconConfig *clparams.BeaconChainConfig, flagIdx int) rewards.Component {
+	switch uint8(flagIdx) {
+	case beaconConfig.TimelySourceFlagIndex:
+		return rewards.Source
+	case beaconConfig.TimelyTargetFlagIndex:
+		return rewards.Target
+	default:
+		return rewards.Head
+	}
+}
+
+func processRewardsAndPenaltiesPostAltair(s abstract.BeaconState, collector *rewards.Collector) (err error) {
 	beaconConfig := s.BeaconConfig()
 	weights := beaconConfig.ParticipationWeights()
 	eligibleValidators := state.EligibleValidatorsIndicies(s)
@@ -40,6 +53,19 @@ func processRewardsAndPenaltiesPostAltair(s abstract.BeaconState) (err error) {
 		if err != nil {
 			return
 		}
+		if collector != nil {
+			effectiveBalance, err := s.ValidatorEffectiveBalance(int(index))
+			if err != nil {
+				return err
+			}
+			for flagIdx := range weights {
+				var idealReward uint64
+				if !state.InactivityLeaking(s) {
+					idealReward = baseReward * rewardMultipliers[flagIdx] / rewardDenominator
+				}
+				collector.SetIdealAttestationReward(effectiveBalance, flagComponent(beaconConfig, flagIdx), int64(idealReward))
+			}
+		}
 		for flagIdx := range weights {
 			if state.IsUnslashedParticipatingIndex(s, previousEpoch, index, flagIdx) {
 				if !state.InactivityLeaking(s) {
@@ -47,11 +73,14 @@ func processRewardsAndPenaltiesPostAltair(s abstract.BeaconState) (err error) {
 					if err := state.IncreaseBalance(s, index, rewardNumerator/rewardDenominator); err != nil {
 						return err
 					}
+					collector.AddAttestationReward(index, flagComponent(beaconConfig, flagIdx), int64(rewardNumerator/rewardDenominator))
 				}
 			} else if flagIdx != int(beaconConfig.TimelyHeadFlagIndex) {
-				if err := state.DecreaseBalance(s, index, baseReward*weights[flagIdx]/beaconConfig.WeightDenominator); err != nil {
+				penalty := baseReward * weights[flagIdx] / beaconConfig.WeightDenominator
+				if err := state.DecreaseBalance(s, index, penalty); err != nil {
 					return err
 				}
+				collector.AddAttestationReward(index, flagComponent(beaconConfig, flagIdx), -int64(penalty))
 			}
 		}
 		if !state.IsUnslashedParticipatingIndex(s, previousEpoch, index, int(beaconConfig.TimelyTargetFlagIndex)) {
@@ -65,13 +94,14 @@ func processRewardsAndPenaltiesPostAltair(s abstract.BeaconState) (err error) {
 				return err
 			}
 			state.DecreaseBalance(s, index, (effectiveBalance*inactivityScore)/inactivityPenaltyDenominator)
+			collector.AddAttestationReward(index, rewards.Inactivity, -int64((effectiveBalance*inactivityScore)/inactivityPenaltyDenominator))
 		}
 	}
 	return
 }
 
 // processRewardsAndPenaltiesPhase0 process rewards and penalties for phase0 state.
-func processRewardsAndPenaltiesPhase0(s abstract.BeaconState) (err error) {
+func processRewardsAndPenaltiesPhase0(s abstract.BeaconState, collector *rewards.Collector) (err error) {
 	beaconConfig := s.BeaconConfig()
 	if state.Epoch(s) == beaconConfig.GenesisEpoch {
 		return nil
@@ -151,6 +181,14 @@ func processRewardsAndPenaltiesPhase0(s abstract.BeaconState) (err error) {
 			missed = 3 - attested
 		}
 
+		if collector != nil {
+			recordPhase0AttestationRewards(s, collector, index, currentValidator, baseReward, [3]bool{
+				previousMatchingSourceAttester, previousMatchingTargetAttester, previousMatchingHeadAttester,
+			}, [3]uint64{
+				unslashedMatchingSourceBalanceIncrements, unslashedMatchingTargetBalanceIncrements, unslashedMatchingHeadBalanceIncrements,
+			}, rewardDenominator)
+		}
+
 		// If we attested then we reward the validator.
 		if state.InactivityLeaking(s) {
 			if err := state.IncreaseBalance(s, index, baseReward*attested); err != nil {
@@ -225,6 +263,8 @@ func processRewardsAndPenaltiesPhase0(s abstract.BeaconState) (err error) {
 		if err = state.IncreaseBalance(s, uint64(index), maxAttesterReward/attestation.InclusionDelay()); err != nil {
 			return false
 		}
+		collector.AddAttestationReward(uint64(index), rewards.InclusionDelay, int64(maxAttesterReward/attestation.InclusionDelay()))
+		collector.SetIdealAttestationReward(validator.EffectiveBalance(), rewards.InclusionDelay, int64(maxAttesterReward))
 		return true
 	})
 	if err != nil {
@@ -233,13 +273,45 @@ func processRewardsAndPenaltiesPhase0(s abstract.BeaconState) (err error) {
 	return
 }
 
+// recordPhase0AttestationRewards records the source, target, head and inactivity deltas of a phase0 validator,
+// following the same rules processRewardsAndPenaltiesPhase0 applies to its balance.
+func recordPhase0AttestationRewards(s abstract.BeaconState, collector *rewards.Collector, index uint64, validator solid.Validator,
+	baseReward uint64, attested [3]bool, matchingBalanceIncrements [3]uint64, rewardDenominator uint64) {
+	beaconConfig := s.BeaconConfig()
+	leaking := state.InactivityLeaking(s)
+	for i, component := range []rewards.Component{rewards.Source, rewards.Target, rewards.Head} {
+		reward := baseReward
+		if !leaking {
+			reward = baseReward * matchingBalanceIncrements[i] / rewardDenominator
+		}
+		collector.SetIdealAttestationReward(validator.EffectiveBalance(), component, int64(reward))
+		if validator.Slashed() || !attested[i] {
+			collector.AddAttestationReward(index, component, -int64(baseReward))
+			continue
+		}
+		collector.AddAttestationReward(index, component, int64(reward))
+	}
+	if !leaking {
+		return
+	}
+	penalty := beaconConfig.BaseRewardsPerEpoch*baseReward - baseReward/beaconConfig.ProposerRewardQuotient
+	if validator.Slashed() || !attested[1] {
+		penalty += validator.EffectiveBalance() * state.FinalityDelay(s) / beaconConfig.InactivityPenaltyQuotient
+	}
+	collector.AddAttestationReward(index, rewards.Inactivity, -int64(penalty))
+}
+
 // ProcessRewardsAndPenalties applies rewards/penalties accumulated during previous epoch.
 func ProcessRewardsAndPenalties(s abstract.BeaconState) error {
+	return processRewardsAndPenalties(s, nil)
+}
+
+func processRewardsAndPenalties(s abstract.BeaconState, collector *rewards.Collector) error {
 	if state.Epoch(s) == s.BeaconConfig().GenesisEpoch {
 		return nil
 	}
 	if s.Version() == clparams.Phase0Version {
-		return processRewardsAndPenaltiesPhase0(s)
+		return processRewardsAndPenaltiesPhase0(s, collector)
 	}
-	return processRewardsAndPenaltiesPostAltair(s)
+	return processRewardsAndPenaltiesPostAltair(s, collector)
 }
@@ -0,0 +1,114 @@
+package rewards
+
+// Component is one of the duties validators are rewarded or penalized for in the epoch transition.
+type Component int
+
+const (
+	Source Component = iota
+	Target
+	Head
+	// InclusionDelay is only rewarded before Altair.
+	InclusionDelay
+	Inactivity
+
+	componentsCount
+)
+
+// AttestationRewards is the breakdown of the attestation rewards of a validator, penalties are negative.
+type AttestationRewards [componentsCount]int64
+
+// BlockComponent is one of the parts of a block its proposer is rewarded for.
+type BlockComponent int
+
+const (
+	BlockAttestations BlockComponent = iota
+	BlockSyncAggregate
+	BlockProposerSlashings
+	BlockAttesterSlashings
+)
+
+// BlockRewards is the breakdown of the rewards of a block proposer.
+type BlockRewards struct {
+	ProposerIndex     uint64
+	Attestations      uint64
+	SyncAggregate     uint64
+	ProposerSlashings uint64
+	AttesterSlashings uint64
+}
+
+// Total is the sum of all the rewards of the proposer.
+func (b *BlockRewards) Total() uint64 {
+	return b.Attestations + b.SyncAggregate + b.ProposerSlashings + b.AttesterSlashings
+}
+
+// Collector accumulates the rewards and penalties applied during a state transition. Amounts are the nominal ones
+// computed by the spec functions, so a penalty may be larger than what was actually subtracted from a balance close to zero.
+// All methods are no-ops on a nil collector, so the transition can call them unconditionally.
+type Collector struct {
+	// Attestations are the rewards of the attestations of the previous epoch, applied in the epoch transition.
+	Attestations map[uint64]*AttestationRewards
+	// IdealAttestations are the rewards a perfectly performing validator would get, by effective balance.
+	IdealAttestations map[uint64]*AttestationRewards
+	Block             BlockRewards
+	SyncCommittee     map[uint64]int64
+}
+
+func NewCollector() *Collector {
+	return &Collector{
+		Attestations:      map[uint64]*AttestationRewards{},
+		IdealAttestations: map[uint64]*AttestationRewards{},
+		SyncCommittee:     map[uint64]int64{},
+	}
+}
+
+// AddAttestationReward adds a reward, or a penalty if negative, to a component of the attestation rewards of a validator.
+func (c *Collector) AddAttestationReward(validatorIndex uint64, component Component, amount int64) {
+	if c == nil {
+		return
+	}
+	r, ok := c.Attestations[validatorIndex]
+	if !ok {
+		r = &AttestationRewards{}
+		c.Attestations[validatorIndex] = r
+	}
+	r[component] += amount
+}
+
+// SetIdealAttestationReward sets the reward of a component for a validator with the given effective balance attesting perfectly.
+func (c *Collector) SetIdealAttestationReward(effectiveBalance uint64, component Component, amount int64) {
+	if c == nil {
+		return
+	}
+	r, ok := c.IdealAttestations[effectiveBalance]
+	if !ok {
+		r = &AttestationRewards{}
+		c.IdealAttestations[effectiveBalance] = r
+	}
+	r[component] = amount
+}
+
+// AddBlockReward adds a reward to a component of the rewards of the block proposer.
+func (c *Collector) AddBlockReward(proposerIndex uint64, component BlockComponent, amount uint64) {
+	if c == nil {
+		return
+	}
+	c.Block.ProposerIndex = proposerIndex
+	switch component {
+	case BlockAttestations:
+		c.Block.Attestations += amount
+	case BlockSyncAggregate:
+		c.Block.SyncAggregate += amount
+	case BlockProposerSlashings:
+		c.Block.ProposerSlashings += amount
+	case BlockAttesterSlashings:
+		c.Block.AttesterSlashings += amount
+	}
+}
+
+// AddSyncCommitteeReward adds a reward, or a penalty if negative, to a sync committee member.
+func (c *Collector) AddSyncCommitteeReward(validatorIndex uint64, amount int64) {
+	if c == nil {
+		return
+	}
+	c.SyncCommittee[validatorIndex] += amount
+}
@@ -0,0 +1,33 @@
+package rewards
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestCollector(t *testing.T) {
+	c := NewCollector()
+	c.AddAttestationReward(1, Source, 10)
+	c.AddAttestationReward(1, Source, -4)
+	c.AddAttestationReward(1, Inactivity, -2)
+	c.SetIdealAttestationReward(32, Head, 7)
+	c.AddBlockReward(5, BlockAttestations, 3)
+	c.AddBlockReward(5, BlockSyncAggregate, 2)
+	c.AddSyncCommitteeReward(2, 1)
+	c.AddSyncCommitteeReward(2, -3)
+
+	require.Equal(t, AttestationRewards{Source: 6, Inactivity: -2}, *c.Attestations[1])
+	require.Equal(t, int64(7), c.IdealAttestations[32][Head])
+	require.Equal(t, uint64(5), c.Block.ProposerIndex)
+	require.Equal(t, uint64(5), c.Block.Total())
+	require.Equal(t, int64(-2), c.SyncCommittee[2])
+}
+
+func TestNilCollector(t *testing.T) {
+	var c *Collector
+	c.AddAttestationReward(1, Source, 10)
+	c.SetIdealAttestationReward(32, Head, 7)
+	c.AddBlockReward(5, BlockAttestations, 3)
+	c.AddSyncCommitteeReward(2, 1)
+}
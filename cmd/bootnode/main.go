@@ -18,36 +18,51 @@
 package main
 
 import (
+	"context"
 	"crypto/ecdsa"
 	"flag"
 	"fmt"
 	"net"
 	"os"
+	"time"
 
 	"github.com/ledgerwatch/erigon-lib/common"
 	"github.com/ledgerwatch/erigon/turbo/logging"
+	"github.com/ledgerwatch/log/v3"
 
 	"github.com/ledgerwatch/erigon/cmd/utils"
 	"github.com/ledgerwatch/erigon/crypto"
+	"github.com/ledgerwatch/erigon/p2p"
 	"github.com/ledgerwatch/erigon/p2p/discover"
 	"github.com/ledgerwatch/erigon/p2p/enode"
 	"github.com/ledgerwatch/erigon/p2p/nat"
 	"github.com/ledgerwatch/erigon/p2p/netutil"
 )
 
+// identity is one of the node identities the bootnode serves, each on its own address.
+type identity struct {
+	key  *ecdsa.PrivateKey
+	from *ecdsa.PrivateKey // Previous key the record sequence continues from, if any
+	addr string
+}
+
 func main() {
 	var (
-		listenAddr  = flag.String("addr", ":30301", "listen address")
+		listenAddr  = flag.String("addr", ":30301", "listen address, comma separated if there are several node keys")
 		genKey      = flag.String("genkey", "", "generate a node key")
 		writeAddr   = flag.Bool("writeaddress", false, "write out the node's public key and quit")
-		nodeKeyFile = flag.String("nodekey", "", "private key filename")
+		nodeKeyFile = flag.String("nodekey", "", "private key filename, comma separated to serve several identities")
 		nodeKeyHex  = flag.String("nodekeyhex", "", "private key as hex (for testing)")
+		rotate      = flag.Bool("rotate", false, "replace the -nodekey keys with new ones before starting, keeping the replaced keys for the -grace period")
+		grace       = flag.Duration("grace", 24*time.Hour, "how long the keys replaced by -rotate stay valid")
+		prevAddr    = flag.String("prevaddr", "", "listen addresses of the previous identities of the -nodekey files during their grace period, one per file")
+		nodeDB      = flag.String("nodedb", "", "node database path, keeps the record sequence numbers across restarts and rotations (in memory if empty)")
 		natdesc     = flag.String(utils.NATFlag.Name, "", utils.NATFlag.Usage)
 		netrestrict = flag.String("netrestrict", "", "restrict network communication to the given IP networks (CIDR masks)")
 		runv5       = flag.Bool("v5", false, "run a v5 topic discovery bootnode")
 
-		nodeKey *ecdsa.PrivateKey
-		err     error
+		identities []identity
+		err        error
 	)
 	flag.Parse()
 
@@ -57,9 +72,12 @@ func main() {
 	if err != nil {
 		utils.Fatalf("-nat: %v", err)
 	}
+	addrs := utils.SplitAndTrim(*listenAddr)
+	prevAddrs := utils.SplitAndTrim(*prevAddr)
+	keyFiles := utils.SplitAndTrim(*nodeKeyFile)
 	switch {
 	case *genKey != "":
-		nodeKey, err = crypto.GenerateKey()
+		nodeKey, err := crypto.GenerateKey()
 		if err != nil {
 			utils.Fatalf("could not generate key: %v", err)
 		}
@@ -69,22 +87,49 @@ func main() {
 		if !*writeAddr {
 			return
 		}
+		identities = []identity{{key: nodeKey}}
 	case *nodeKeyFile == "" && *nodeKeyHex == "":
 		utils.Fatalf("Use -nodekey or -nodekeyhex to specify a private key")
 	case *nodeKeyFile != "" && *nodeKeyHex != "":
 		utils.Fatalf("Options -nodekey and -nodekeyhex are mutually exclusive")
 	case *nodeKeyFile != "":
-		if nodeKey, err = crypto.LoadECDSA(*nodeKeyFile); err != nil {
-			utils.Fatalf("-nodekey: %v", err)
+		if len(addrs) != len(keyFiles) {
+			utils.Fatalf("-addr: %d addresses for %d node keys", len(addrs), len(keyFiles))
+		}
+		if len(prevAddrs) > 0 && len(prevAddrs) != len(keyFiles) {
+			utils.Fatalf("-prevaddr: %d addresses for %d node keys", len(prevAddrs), len(keyFiles))
+		}
+		for i, file := range keyFiles {
+			current, previous := loadIdentity(file, *rotate, *grace)
+			ident := identity{key: current}
+			if n := len(previous); n > 0 {
+				// The most recent previous identity is served during its grace period, the older ones aren't
+				ident.from = previous[n-1].Key
+				if len(prevAddrs) > 0 {
+					identities = append(identities, identity{key: previous[n-1].Key, addr: prevAddrs[i]})
+				} else {
+					fmt.Printf("Previous identity %x of %s is valid until %v, set -prevaddr to serve it\n",
+						crypto.MarshalPubkey(&previous[n-1].Key.PublicKey), file, previous[n-1].Expires)
+				}
+			}
+			ident.addr = addrs[i]
+			identities = append(identities, ident)
 		}
 	case *nodeKeyHex != "":
-		if nodeKey, err = crypto.HexToECDSA(*nodeKeyHex); err != nil {
+		nodeKey, err := crypto.HexToECDSA(*nodeKeyHex)
+		if err != nil {
 			utils.Fatalf("-nodekeyhex: %v", err)
 		}
+		if len(addrs) != 1 {
+			utils.Fatalf("-addr: %d addresses for 1 node key", len(addrs))
+		}
+		identities = []identity{{key: nodeKey, addr: addrs[0]}}
 	}
 
 	if *writeAddr {
-		fmt.Printf("%x\n", crypto.MarshalPubkey(&nodeKey.PublicKey))
+		for _, ident := range identities {
+			fmt.Printf("%x\n", crypto.MarshalPubkey(&ident.key.PublicKey))
+		}
 		os.Exit(0)
 	}
 
@@ -96,7 +141,46 @@ func main() {
 		}
 	}
 
-	addr, err := net.ResolveUDPAddr("udp", *listenAddr)
+	db, err := enode.OpenDB(*nodeDB, "" /* tmpDir */)
+	if err != nil {
+		panic(err)
+	}
+
+	ctx, cancel := common.RootContext()
+	defer cancel()
+
+	for _, ident := range identities {
+		listen(ctx, ident, db, natm, restrictList, *runv5, logger)
+	}
+
+	select {}
+}
+
+// loadIdentity loads a node key file and its previous keys, after rotating the key if requested.
+func loadIdentity(file string, rotate bool, grace time.Duration) (*ecdsa.PrivateKey, []p2p.PreviousNodeKey) {
+	config := p2p.NodeKeyConfig{}
+	if rotate {
+		key, previous, err := config.Rotate(file, grace)
+		if err != nil {
+			utils.Fatalf("-rotate: %v", err)
+		}
+		fmt.Printf("Rotated the node key of %s, the new public key is %x\n", file, crypto.MarshalPubkey(&key.PublicKey))
+		return key, previous
+	}
+	key, err := crypto.LoadECDSA(file)
+	if err != nil {
+		utils.Fatalf("-nodekey: %v", err)
+	}
+	previous, err := config.LoadPrevious(file)
+	if err != nil {
+		utils.Fatalf("-nodekey: %v", err)
+	}
+	return key, previous
+}
+
+// listen starts the discovery of an identity on its address.
+func listen(ctx context.Context, ident identity, db *enode.DB, natm nat.Interface, restrictList *netutil.Netlist, runv5 bool, logger log.Logger) {
+	addr, err := net.ResolveUDPAddr("udp", ident.addr)
 	if err != nil {
 		utils.Fatalf("-ResolveUDPAddr: %v", err)
 	}
@@ -115,22 +199,22 @@ func main() {
 		}
 	}
 
-	printNotice(&nodeKey.PublicKey, *realaddr)
+	printNotice(&ident.key.PublicKey, *realaddr)
 
-	db, err := enode.OpenDB("" /* path */, "" /* tmpDir */)
-	if err != nil {
-		panic(err)
+	var ln *enode.LocalNode
+	if ident.from != nil {
+		// Continue the record sequence of the previous identity
+		ln = enode.NewLocalNode(db, ident.from, logger)
+		ln.SetKey(ident.key)
+	} else {
+		ln = enode.NewLocalNode(db, ident.key, logger)
 	}
-	ln := enode.NewLocalNode(db, nodeKey, logger)
 	cfg := discover.Config{
-		PrivateKey:  nodeKey,
+		PrivateKey:  ident.key,
 		NetRestrict: restrictList,
 	}
 
-	ctx, cancel := common.RootContext()
-	defer cancel()
-
-	if *runv5 {
+	if runv5 {
 		if _, err := discover.ListenV5(ctx, conn, ln, cfg); err != nil {
 			utils.Fatalf("%v", err)
 		}
@@ -139,8 +223,6 @@ func main() {
 			utils.Fatalf("%v", err)
 		}
 	}
-
-	select {}
 }
 
 func printNotice(nodeKey *ecdsa.PublicKey, addr net.UDPAddr) {
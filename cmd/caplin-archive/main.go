@@ -0,0 +1,55 @@
+package main
+
+import (
+	"flag"
+	"os"
+
+	"github.com/ledgerwatch/log/v3"
+
+	"github.com/ledgerwatch/erigon/cl/clparams"
+	"github.com/ledgerwatch/erigon/cl/freezer"
+	"github.com/ledgerwatch/erigon/cl/phase1/archive"
+	"github.com/ledgerwatch/erigon/cl/utils"
+)
+
+// caplin-archive reconstructs historical beacon states out of the archive written by Caplin in archive mode,
+// by replaying archived blocks with cl/transition on top of the closest stored state.
+func main() {
+	log.Root().SetHandler(log.LvlFilterHandler(log.LvlInfo, log.StderrHandler))
+	archiveDir := flag.String("archive-dir", "caplin-archive", "directory of the archive")
+	chain := flag.String("chain", "mainnet", "chain the archive belongs to")
+	slot := flag.Uint64("slot", 0, "slot of the state to reconstruct")
+	snapshotInterval := flag.Uint64("snapshot-interval", 64, "epochs between snapshots, must match the one used to write the archive")
+	out := flag.String("out", "", "file where to write the ssz_snappy encoded state, if empty only the state root is printed")
+	flag.Parse()
+
+	_, _, beaconCfg, _, err := clparams.GetConfigsByNetworkName(*chain)
+	if err != nil {
+		log.Error("Unknown chain", "err", err)
+		os.Exit(1)
+	}
+	a := archive.New(&freezer.RootPathOsFs{Root: *archiveDir}, beaconCfg, *snapshotInterval)
+	s, err := a.ReadState(*slot)
+	if err != nil {
+		log.Error("Could not reconstruct state", "slot", *slot, "err", err)
+		os.Exit(1)
+	}
+	root, err := s.HashSSZ()
+	if err != nil {
+		log.Error("Could not hash state", "err", err)
+		os.Exit(1)
+	}
+	log.Info("Reconstructed state", "slot", s.Slot(), "root", root)
+	if *out == "" {
+		return
+	}
+	encoded, err := utils.EncodeSSZSnappy(s)
+	if err != nil {
+		log.Error("Could not encode state", "err", err)
+		os.Exit(1)
+	}
+	if err := os.WriteFile(*out, encoded, 0644); err != nil {
+		log.Error("Could not write state", "err", err)
+		os.Exit(1)
+	}
+}
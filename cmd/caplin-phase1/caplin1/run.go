@@ -5,14 +5,17 @@ import (
 
 	"github.com/ledgerwatch/erigon/cl/cltypes/solid"
 	"github.com/ledgerwatch/erigon/cl/freezer"
+	"github.com/ledgerwatch/erigon/cl/phase1/archive"
 	"github.com/ledgerwatch/erigon/cl/phase1/core/state"
 	"github.com/ledgerwatch/erigon/cl/phase1/execution_client"
 	"github.com/ledgerwatch/erigon/cl/phase1/forkchoice"
+	"github.com/ledgerwatch/erigon/cl/phase1/light_client"
 	network2 "github.com/ledgerwatch/erigon/cl/phase1/network"
 	"github.com/ledgerwatch/erigon/cl/phase1/stages"
 
 	"github.com/Giulio2002/bls"
 	"github.com/ledgerwatch/erigon-lib/gointerfaces/sentinel"
+	"github.com/ledgerwatch/erigon-lib/kv"
 	"github.com/ledgerwatch/erigon/cl/clparams"
 	"github.com/ledgerwatch/erigon/cl/rpc"
 	"github.com/ledgerwatch/log/v3"
@@ -21,7 +24,7 @@ import (
 )
 
 func RunCaplinPhase1(ctx context.Context, sentinel sentinel.SentinelClient, beaconConfig *clparams.BeaconChainConfig, genesisConfig *clparams.GenesisConfig,
-	engine execution_client.ExecutionEngine, state *state.CachingBeaconState, caplinFreezer freezer.Freezer) error {
+	engine execution_client.ExecutionEngine, state *state.CachingBeaconState, caplinFreezer freezer.Freezer, db kv.RwDB, stateArchive *archive.Archive) error {
 	beaconRpc := rpc.NewBeaconRpcP2P(ctx, sentinel, beaconConfig, genesisConfig)
 	downloader := network2.NewForwardBeaconDownloader(ctx, beaconRpc)
 
@@ -35,6 +38,12 @@ func RunCaplinPhase1(ctx context.Context, sentinel sentinel.SentinelClient, beac
 		log.Error("Could not create forkchoice", "err", err)
 		return err
 	}
+	lightClientServer, err := light_client.NewServer(ctx, db, beaconConfig)
+	if err != nil {
+		return err
+	}
+	forkChoice.SetLightClientServer(lightClientServer)
+	forkChoice.SetArchive(stateArchive)
 	bls.SetEnabledCaching(true)
 	state.ForEachValidator(func(v solid.Validator, idx, total int) bool {
 		pk := v.PublicKey()
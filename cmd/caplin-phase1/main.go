@@ -21,10 +21,12 @@ import (
 	"github.com/ledgerwatch/erigon/cl/beacon"
 	"github.com/ledgerwatch/erigon/cl/beacon/handler"
 	"github.com/ledgerwatch/erigon/cl/freezer"
+	"github.com/ledgerwatch/erigon/cl/phase1/archive"
 	"github.com/ledgerwatch/erigon/cl/phase1/core"
 	"github.com/ledgerwatch/erigon/cl/phase1/core/state"
 	"github.com/ledgerwatch/erigon/cl/phase1/execution_client"
 
+	"github.com/ledgerwatch/erigon-lib/kv/mdbx"
 	"github.com/ledgerwatch/log/v3"
 	"github.com/urfave/cli/v2"
 
@@ -77,6 +79,12 @@ func runCaplinNode(cliCtx *cli.Context) error {
 	if err != nil {
 		return err
 	}
+	// Light client data is produced by Caplin and served by both the sentinel and the beacon API.
+	db, err := mdbx.NewMDBX(log.Root()).Path(cfg.Chaindata).Open()
+	if err != nil {
+		return err
+	}
+	defer db.Close()
 
 	sentinel, err := service.StartSentinelService(&sentinel.SentinelConfig{
 		IpAddr:        cfg.Addr,
@@ -86,7 +94,7 @@ func runCaplinNode(cliCtx *cli.Context) error {
 		NetworkConfig: cfg.NetworkCfg,
 		BeaconConfig:  cfg.BeaconCfg,
 		NoDiscovery:   cfg.NoDiscovery,
-	}, nil, &service.ServerConfig{Network: cfg.ServerProtocol, Addr: cfg.ServerAddr}, nil, &cltypes.Status{
+	}, db, &service.ServerConfig{Network: cfg.ServerProtocol, Addr: cfg.ServerAddr}, nil, &cltypes.Status{
 		ForkDigest:     forkDigest,
 		FinalizedRoot:  state.FinalizedCheckpoint().BlockRoot(),
 		FinalizedEpoch: state.FinalizedCheckpoint().Epoch(),
@@ -114,8 +122,13 @@ func runCaplinNode(cliCtx *cli.Context) error {
 		executionEngine = cc
 	}
 
+	var stateArchive *archive.Archive
+	if cfg.ArchiveMode {
+		stateArchive = archive.New(&freezer.RootPathOsFs{Root: cfg.ArchiveDir}, cfg.BeaconCfg, cfg.ArchiveSnapshotInterval)
+	}
+
 	if !cfg.NoBeaconApi {
-		apiHandler := handler.NewApiHandler(cfg.GenesisCfg, cfg.BeaconCfg)
+		apiHandler := handler.NewApiHandler(cfg.GenesisCfg, cfg.BeaconCfg, db, stateArchive)
 		go beacon.ListenAndServe(apiHandler, &beacon.RouterConfiguration{
 			Protocol:        cfg.BeaconProtocol,
 			Address:         cfg.BeaconAddr,
@@ -133,5 +146,5 @@ func runCaplinNode(cliCtx *cli.Context) error {
 		}
 	}
 
-	return caplin1.RunCaplinPhase1(ctx, sentinel, cfg.BeaconCfg, cfg.GenesisCfg, executionEngine, state, caplinFreezer)
+	return caplin1.RunCaplinPhase1(ctx, sentinel, cfg.BeaconCfg, cfg.GenesisCfg, executionEngine, state, caplinFreezer, db, stateArchive)
 }
@@ -3,6 +3,8 @@ package args
 import (
 	"fmt"
 	"reflect"
+	"sort"
+	"strconv"
 	"strings"
 	"unicode"
 	"unicode/utf8"
@@ -22,7 +24,7 @@ func AsArgs(args interface{}) (Args, error) {
 		return nil, fmt.Errorf("Args type must be struct or struc pointer, got %T", args)
 	}
 
-	return gatherArgs(argsValue, func(v reflect.Value, field reflect.StructField) (string, error) {
+	gathered, err := gatherArgs(argsValue, func(v reflect.Value, field reflect.StructField) (string, error) {
 		tag := field.Tag.Get("arg")
 
 		if tag == "-" {
@@ -119,6 +121,121 @@ func AsArgs(args interface{}) (Args, error) {
 
 		return fmt.Sprintf("%s=%s", key, value), nil
 	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	if extra, ok := args.(interface{ ExtraArgs() Args }); ok {
+		gathered = append(gathered, extra.ExtraArgs()...)
+	}
+
+	return gathered, nil
+}
+
+// SetFlags sets the fields of the args struct pointed to by args from flag values keyed by flag name without the
+// leading hyphens, as in "log.dir.verbosity": "5". Flags without a field are added to the ExtraFlags of the struct,
+// or rejected if it has none.
+func SetFlags(args interface{}, flags map[string]string) error {
+	argsValue := reflect.ValueOf(args)
+
+	if argsValue.Kind() != reflect.Ptr || argsValue.Elem().Kind() != reflect.Struct {
+		return fmt.Errorf("Args type must be struct pointer, got %T", args)
+	}
+
+	argsValue = argsValue.Elem()
+
+	names := make([]string, 0, len(flags))
+
+	for name := range flags {
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+
+	var extra Args
+
+	for _, name := range names {
+		value := flags[name]
+
+		field, ok := flagField(argsValue, name)
+
+		if !ok {
+			extra = append(extra, fmt.Sprintf("--%s=%s", name, value))
+			continue
+		}
+
+		switch field.Kind() {
+		case reflect.String:
+			field.SetString(value)
+		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+			i, err := strconv.ParseInt(value, 10, 64)
+
+			if err != nil {
+				return fmt.Errorf("flag %s: %w", name, err)
+			}
+
+			field.SetInt(i)
+		case reflect.Bool:
+			b, err := strconv.ParseBool(value)
+
+			if err != nil {
+				return fmt.Errorf("flag %s: %w", name, err)
+			}
+
+			field.SetBool(b)
+		default:
+			return fmt.Errorf("flag %s: unsupported field type %s", name, field.Type())
+		}
+	}
+
+	if len(extra) > 0 {
+		field := argsValue.FieldByName("ExtraFlags")
+
+		if !field.IsValid() || field.Type() != reflect.TypeOf(Args{}) {
+			return fmt.Errorf("unknown flags %v", extra)
+		}
+
+		field.Set(reflect.ValueOf(append(field.Interface().(Args), extra...)))
+	}
+
+	return nil
+}
+
+// flagField returns the field of v, or of the structs it embeds, with the arg tag of the flag. Fields of v take
+// precedence over the fields of embedded structs.
+func flagField(v reflect.Value, name string) (reflect.Value, bool) {
+	var embedded []reflect.Value
+
+	for i := 0; i < v.NumField(); i++ {
+		field := v.Type().Field(i)
+
+		if field.Type.Kind() == reflect.Struct {
+			if field.IsExported() {
+				embedded = append(embedded, v.Field(i))
+			}
+
+			continue
+		}
+
+		if !field.IsExported() {
+			continue
+		}
+
+		for _, key := range strings.Split(field.Tag.Get("arg"), ",") {
+			if strings.TrimLeft(strings.TrimSpace(key), "-") == name && strings.HasPrefix(strings.TrimSpace(key), "-") {
+				return v.Field(i), true
+			}
+		}
+	}
+
+	for _, e := range embedded {
+		if field, ok := flagField(e, name); ok {
+			return field, true
+		}
+	}
+
+	return reflect.Value{}, false
 }
 
 func gatherArgs(v reflect.Value, visit func(v reflect.Value, field reflect.StructField) (string, error)) (args Args, err error) {
@@ -34,6 +34,7 @@ type Node struct {
 	HttpVHosts                string `arg:"--http.vhosts" json:"http.vhosts"`
 	AuthRpcPort               int    `arg:"--authrpc.port" default:"8551" json:"authrpc.port"`
 	AuthRpcVHosts             string `arg:"--authrpc.vhosts" json:"authrpc.vhosts"`
+	JWTSecretPath             string `arg:"--authrpc.jwtsecret" json:"authrpc.jwtsecret,omitempty"`
 	WSPort                    int    `arg:"-" default:"8546" json:"-"` // flag not defined
 	GRPCPort                  int    `arg:"-" default:"8547" json:"-"` // flag not defined
 	TCPPort                   int    `arg:"-" default:"8548" json:"-"` // flag not defined
@@ -43,6 +44,12 @@ type Node struct {
 	StaticPeers               string `arg:"--staticpeers" json:"staticpeers,omitempty"`
 	WithoutHeimdall           bool   `arg:"--bor.withoutheimdall" flag:"" default:"false" json:"bor.withoutheimdall,omitempty"`
 	HeimdallGRpc              string `arg:"--bor.heimdallgRPC" json:"bor.heimdallgRPC,omitempty"`
+	ExtraFlags                Args   `arg:"-" json:"-"` // flags without a field, see SetFlags
+}
+
+// ExtraArgs returns the flags set by SetFlags which have no field, which AsArgs appends to the node arguments
+func (node Node) ExtraArgs() Args {
+	return node.ExtraFlags
 }
 
 func (node *Node) configure(base Node, nodeNumber int) error {
@@ -84,9 +91,15 @@ func (node *Node) configure(base Node, nodeNumber int) error {
 
 	node.Port = base.Port + nodeNumber
 
+	// the nodes of a network share an engine API secret, so that a devnet consensus layer can drive all of them
+	node.JWTSecretPath = filepath.Join(base.DataDir, JWTSecretFile)
+
 	return nil
 }
 
+// JWTSecretFile is the engine API secret of the nodes of a network, in the network data directory
+const JWTSecretFile = "jwt.hex"
+
 func (node Node) ChainID() *big.Int {
 	return &big.Int{}
 }
@@ -140,6 +153,40 @@ func (n BlockProducer) IsBlockProducer() bool {
 	return true
 }
 
+// Validator is a block producer of a proof of stake network. It does not mine: the devnet consensus layer builds the
+// blocks of the validators assigned to it through its engine API.
+type Validator struct {
+	Node
+	HttpApi      string `arg:"--http.api" default:"admin,eth,erigon,web3,net,debug,trace,txpool,parity,ots"`
+	AccountSlots int    `arg:"--txpool.accountslots" default:"16"`
+	account      *accounts.Account
+}
+
+func (v Validator) Configure(baseNode Node, nodeNumber int) (int, interface{}, error) {
+	err := v.configure(baseNode, nodeNumber)
+
+	if err != nil {
+		return -1, nil, err
+	}
+
+	// the fee recipient of the blocks the node builds
+	v.account = accounts.NewAccount(v.Name() + "-etherbase")
+
+	return v.HttpPort, v, nil
+}
+
+func (v Validator) Name() string {
+	return v.Node.Name
+}
+
+func (v Validator) Account() *accounts.Account {
+	return v.account
+}
+
+func (v Validator) IsBlockProducer() bool {
+	return true
+}
+
 type NonBlockProducer struct {
 	Node
 	HttpApi     string `arg:"--http.api" default:"admin,eth,debug,net,trace,web3,erigon,txpool" json:"http.api"`
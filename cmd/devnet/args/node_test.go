@@ -61,6 +61,39 @@ func TestNodeArgs(t *testing.T) {
 	}
 }
 
+func TestSetFlags(t *testing.T) {
+	var node args.BlockProducer
+
+	err := args.SetFlags(&node, map[string]string{
+		"log.dir.verbosity":   "5",
+		"txpool.accountslots": "200",
+		"bor.withoutheimdall": "true",
+		"db.size.limit":       "1GB",
+	})
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if node.DirVerbosity != "5" || node.AccountSlots != 200 || !node.WithoutHeimdall {
+		t.Fatalf("flags not set: %+v", node)
+	}
+
+	nodeArgs, err := args.AsArgs(node)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if last := nodeArgs[len(nodeArgs)-1]; last != "--db.size.limit=1GB" {
+		t.Fatalf("expected the extra flag last, got %s", last)
+	}
+
+	if err := args.SetFlags(&node, map[string]string{"txpool.accountslots": "many"}); err == nil {
+		t.Fatal("expected an error for an invalid int flag")
+	}
+}
+
 func TestParameterFromArgument(t *testing.T) {
 	enode := fmt.Sprintf("%q", "1234567")
 	testCases := []struct {
@@ -37,9 +37,13 @@ type Network struct {
 	Nodes              []Node
 	Services           []Service
 	Alloc              types.GenesisAlloc
-	wg                 sync.WaitGroup
-	peers              []string
-	namedNodes         map[string]Node
+	// ProofOfStake starts the chain merged, with no mining: the blocks are built through the engine API of the nodes
+	// by a consensus layer service of the network. Cancun activates Cancun at genesis.
+	ProofOfStake bool
+	Cancun       bool
+	wg           sync.WaitGroup
+	peers        []string
+	namedNodes   map[string]Node
 }
 
 func (nw *Network) ChainID() *big.Int {
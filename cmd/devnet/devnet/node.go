@@ -8,8 +8,8 @@ import (
 
 	"github.com/c2h5oh/datasize"
 	"github.com/ledgerwatch/erigon/cmd/devnet/accounts"
-	"github.com/ledgerwatch/erigon/cmd/devnet/args"
 	"github.com/ledgerwatch/erigon/cmd/devnet/requests"
+	"github.com/ledgerwatch/erigon/core/types"
 	"github.com/ledgerwatch/erigon/eth/ethconfig"
 	"github.com/ledgerwatch/erigon/node/nodecfg"
 	"github.com/ledgerwatch/erigon/params"
@@ -37,6 +37,21 @@ func (f NodeSelectorFunc) Test(ctx context.Context, node Node) bool {
 	return f(ctx, node)
 }
 
+// AuthRPCHost returns the engine API address of the node
+func AuthRPCHost(n Node) string {
+	if n, ok := n.(*node); ok {
+		host := n.nodeCfg.Http.AuthRpcHTTPListenAddress
+
+		if host == "" {
+			host = "localhost"
+		}
+
+		return fmt.Sprintf("%s:%d", host, n.nodeCfg.Http.AuthRpcPort)
+	}
+
+	return ""
+}
+
 func HTTPHost(n Node) string {
 	if n, ok := n.(*node); ok {
 		host := n.nodeCfg.Http.HttpListenAddress
@@ -97,13 +112,16 @@ func (n *node) done() {
 }
 
 func (n *node) IsBlockProducer() bool {
-	_, isBlockProducer := n.args.(args.BlockProducer)
-	return isBlockProducer
+	if producer, ok := n.args.(interface{ IsBlockProducer() bool }); ok {
+		return producer.IsBlockProducer()
+	}
+
+	return false
 }
 
 func (n *node) Account() *accounts.Account {
-	if miner, ok := n.args.(args.BlockProducer); ok {
-		return miner.Account()
+	if producer, ok := n.args.(interface{ Account() *accounts.Account }); ok {
+		return producer.Account()
 	}
 
 	return nil
@@ -156,6 +174,10 @@ func (n *node) run(ctx *cli.Context) error {
 	n.nodeCfg.MdbxGrowthStep = 32 * datasize.MB
 	n.nodeCfg.MdbxDBSizeLimit = 512 * datasize.MB
 
+	if n.network.ProofOfStake {
+		n.ethCfg.Genesis = proofOfStakeGenesis(n.ethCfg.Genesis, n.network.Cancun)
+	}
+
 	for addr, account := range n.network.Alloc {
 		n.ethCfg.Genesis.Alloc[addr] = account
 	}
@@ -183,3 +205,24 @@ func (n *node) run(ctx *cli.Context) error {
 
 	return err
 }
+
+// proofOfStakeGenesis returns the genesis of a chain which starts merged, and with the withdrawals of Shanghai. The
+// clique signer is dropped from the extra data so that the nodes of the network share the genesis.
+func proofOfStakeGenesis(genesis *types.Genesis, cancun bool) *types.Genesis {
+	posGenesis := *genesis
+	config := *genesis.Config
+
+	config.TerminalTotalDifficulty = big.NewInt(0)
+	config.TerminalTotalDifficultyPassed = true
+	config.ShanghaiTime = big.NewInt(0)
+
+	if cancun {
+		config.CancunTime = big.NewInt(0)
+	}
+
+	posGenesis.Config = &config
+	posGenesis.Difficulty = big.NewInt(0)
+	posGenesis.ExtraData = nil
+
+	return &posGenesis
+}
@@ -1,8 +1,10 @@
 package devnetutils
 
 import (
+	"bytes"
 	"crypto/rand"
 	"encoding/binary"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"io/ioutil"
@@ -16,6 +18,7 @@ import (
 
 	"github.com/ledgerwatch/erigon/crypto"
 	"github.com/ledgerwatch/log/v3"
+	"gopkg.in/yaml.v3"
 )
 
 var ErrInvalidEnodeString = errors.New("invalid enode string")
@@ -134,3 +137,40 @@ func RandomNumberInRange(min, max uint64) (uint64, error) {
 
 	return uint64(RandomInt(int(max-min)) + int(min)), nil
 }
+
+// ReadConfigFile decodes a YAML or JSON configuration file into v using the json tags of v. YAML files are
+// converted to JSON first so that both formats share the same field names, and unknown fields are rejected.
+// Numbers decoded into interface{} values are kept as json.Number.
+func ReadConfigFile(path string, v interface{}) error {
+	data, err := os.ReadFile(path)
+
+	if err != nil {
+		return err
+	}
+
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".json":
+	case ".yaml", ".yml":
+		var doc interface{}
+
+		if err := yaml.Unmarshal(data, &doc); err != nil {
+			return fmt.Errorf("%s: %w", path, err)
+		}
+
+		if data, err = json.Marshal(doc); err != nil {
+			return fmt.Errorf("%s: %w", path, err)
+		}
+	default:
+		return fmt.Errorf("%s: unsupported configuration file type, expected .yaml, .yml or .json", path)
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	decoder.DisallowUnknownFields()
+
+	if err := decoder.Decode(v); err != nil {
+		return fmt.Errorf("%s: %w", path, err)
+	}
+
+	return nil
+}
@@ -18,6 +18,8 @@ import (
 	_ "github.com/ledgerwatch/erigon/cmd/devnet/contracts/steps"
 	account_services "github.com/ledgerwatch/erigon/cmd/devnet/services/accounts"
 	"github.com/ledgerwatch/erigon/cmd/devnet/services/bor"
+	"github.com/ledgerwatch/erigon/cmd/devnet/services/pos"
+	_ "github.com/ledgerwatch/erigon/cmd/devnet/services/pos/steps"
 	"github.com/ledgerwatch/erigon/cmd/devnet/transactions"
 	"github.com/ledgerwatch/erigon/core/types"
 
@@ -25,6 +27,7 @@ import (
 	"github.com/ledgerwatch/erigon/cmd/devnet/args"
 	"github.com/ledgerwatch/erigon/cmd/devnet/devnet"
 	"github.com/ledgerwatch/erigon/cmd/devnet/devnetutils"
+	"github.com/ledgerwatch/erigon/cmd/devnet/networks"
 	"github.com/ledgerwatch/erigon/cmd/devnet/requests"
 	"github.com/ledgerwatch/erigon/cmd/devnet/scenarios"
 	"github.com/ledgerwatch/erigon/cmd/devnet/services"
@@ -41,10 +44,9 @@ import (
 
 var (
 	DataDirFlag = flags.DirectoryFlag{
-		Name:     "datadir",
-		Usage:    "Data directory for the devnet",
-		Value:    flags.DirectoryString(""),
-		Required: true,
+		Name:  "datadir",
+		Usage: "Data directory for the devnet (required)",
+		Value: flags.DirectoryString(""),
 	}
 
 	ChainFlag = cli.StringFlag{
@@ -116,6 +118,31 @@ var (
 		Name:  "wait",
 		Usage: "Wait until interrupted after all scenarios have run",
 	}
+
+	NetworkFileFlag = cli.StringFlag{
+		Name:  "network.file",
+		Usage: "YAML or JSON file defining the devnet networks, used instead of the networks of --chain",
+	}
+
+	ScenarioFilesFlag = cli.StringSliceFlag{
+		Name:  "scenario.files",
+		Usage: "YAML or JSON files defining scenarios. Without --scenarios all the scenarios of the files are run",
+	}
+
+	JUnitFlag = cli.StringFlag{
+		Name:  "junit",
+		Usage: "File to write a JUnit XML report of the scenario results to",
+	}
+
+	ProofOfStakeFlag = cli.BoolFlag{
+		Name:  "pos",
+		Usage: "Run the dev chain as a proof of stake network, with blocks proposed by the devnet validator service",
+	}
+
+	CancunFlag = cli.BoolFlag{
+		Name:  "pos.cancun",
+		Usage: "Activate Cancun at the genesis of the proof of stake network",
+	}
 )
 
 type PanicHandler struct {
@@ -136,6 +163,18 @@ func main() {
 	app.Action = func(ctx *cli.Context) error {
 		return action(ctx)
 	}
+	app.Commands = []*cli.Command{
+		{
+			Name:      "validate",
+			Usage:     "Validate network and scenario files without running the devnet",
+			ArgsUsage: "--network.file <file> --scenario.files <files>",
+			Action:    validate,
+			Flags: []cli.Flag{
+				&NetworkFileFlag,
+				&ScenarioFilesFlag,
+			},
+		},
+	}
 	app.Flags = []cli.Flag{
 		&DataDirFlag,
 		&ChainFlag,
@@ -151,6 +190,11 @@ func main() {
 		&insecureFlag,
 		&metricsURLsFlag,
 		&WaitFlag,
+		&NetworkFileFlag,
+		&ScenarioFilesFlag,
+		&JUnitFlag,
+		&ProofOfStakeFlag,
+		&CancunFlag,
 		&logging.LogVerbosityFlag,
 		&logging.LogConsoleVerbosityFlag,
 		&logging.LogDirVerbosityFlag,
@@ -163,6 +207,7 @@ func main() {
 	}
 	if err := app.Run(os.Args); err != nil {
 		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
 	}
 }
 
@@ -171,7 +216,41 @@ const (
 	sendValue        uint64 = 10000
 )
 
+// validate checks that the network and scenario files can be loaded, and that the scenario steps match step handlers
+// with arguments of their parameter types
+func validate(ctx *cli.Context) error {
+	if !ctx.IsSet(NetworkFileFlag.Name) && !ctx.IsSet(ScenarioFilesFlag.Name) {
+		return fmt.Errorf("nothing to validate, set --%s or --%s", NetworkFileFlag.Name, ScenarioFilesFlag.Name)
+	}
+
+	if path := ctx.String(NetworkFileFlag.Name); len(path) > 0 {
+		definition, err := networks.Load(path)
+
+		if err != nil {
+			return err
+		}
+
+		fmt.Printf("%s: %d networks\n", path, len(definition.Networks))
+	}
+
+	for _, path := range ctx.StringSlice(ScenarioFilesFlag.Name) {
+		loaded, err := scenarios.LoadScenarios(nil, path)
+
+		if err != nil {
+			return err
+		}
+
+		fmt.Printf("%s: %d scenarios\n", path, len(loaded))
+	}
+
+	return nil
+}
+
 func action(ctx *cli.Context) error {
+	if !ctx.IsSet(DataDirFlag.Name) {
+		return fmt.Errorf("Required flag %q not set", DataDirFlag.Name)
+	}
+
 	dataDir := ctx.String("datadir")
 	logsDir := filepath.Join(dataDir, "logs")
 
@@ -189,12 +268,28 @@ func action(ctx *cli.Context) error {
 		return err
 	}
 
-	network, err := initDevnet(ctx, logger)
+	var network devnet.Devnet
+	var err error
+
+	if path := ctx.String(NetworkFileFlag.Name); len(path) > 0 {
+		var definition *networks.Definition
+
+		if definition, err = networks.Load(path); err == nil {
+			network, err = definition.Devnet(ctx, dataDir, logger)
+		}
+	} else {
+		network, err = initDevnet(ctx, logger)
+	}
 
 	if err != nil {
 		return err
 	}
 
+	// load the scenario files before starting the network so that invalid files fail fast
+	if _, err := scenarios.LoadScenarios(nil, ctx.StringSlice(ScenarioFilesFlag.Name)...); err != nil {
+		return err
+	}
+
 	metrics := ctx.Bool("metrics")
 
 	if metrics {
@@ -234,11 +329,11 @@ func action(ctx *cli.Context) error {
 		}()
 	}
 
-	if ctx.String(ChainFlag.Name) == networkname.DevChainName {
+	if network[0].Chain == networkname.DevChainName {
 		transactions.MaxNumberOfEmptyBlockChecks = 30
 	}
 
-	scenarios.Scenarios{
+	runScenarios := scenarios.Scenarios{
 		"dynamic-tx-node-0": {
 			Context: runCtx.
 				WithCurrentNetwork(0).
@@ -278,7 +373,42 @@ func action(ctx *cli.Context) error {
 				{Text: "ProcessTransfers", Args: []any{"faucet-source", 10, 2, 2}},
 			},
 		},
-	}.Run(runCtx, strings.Split(ctx.String("scenarios"), ",")...)
+	}
+
+	scenarioNames := strings.Split(ctx.String(ScenariosFlag.Name), ",")
+
+	if files := ctx.StringSlice(ScenarioFilesFlag.Name); len(files) > 0 {
+		fileScenarios, err := scenarios.LoadScenarios(runCtx, files...)
+
+		if err != nil {
+			return err
+		}
+
+		if !ctx.IsSet(ScenariosFlag.Name) {
+			scenarioNames = nil
+			runScenarios = scenarios.Scenarios{}
+		}
+
+		for name, scenario := range fileScenarios {
+			if _, ok := runScenarios[name]; ok {
+				return fmt.Errorf("scenario %q is already defined", name)
+			}
+
+			runScenarios[name] = scenario
+		}
+	}
+
+	results, runErr := runScenarios.RunWithResults(runCtx, scenarioNames...)
+
+	if runErr != nil {
+		logger.Error("Scenarios failed", "err", runErr)
+	}
+
+	if path := ctx.String(JUnitFlag.Name); len(path) > 0 {
+		if err := writeJUnit(path, results); err != nil {
+			return err
+		}
+	}
 
 	if ctx.Bool("wait") || (metrics && len(diagnosticsUrl) > 0) {
 		logger.Info("Waiting")
@@ -288,7 +418,23 @@ func action(ctx *cli.Context) error {
 		network.Stop()
 	}
 
-	return nil
+	return runErr
+}
+
+func writeJUnit(path string, results []*scenarios.ScenarioResult) error {
+	file, err := os.Create(path)
+
+	if err != nil {
+		return err
+	}
+
+	defer file.Close()
+
+	if err := scenarios.WriteJUnit(file, "devnet", results); err != nil {
+		return err
+	}
+
+	return file.Close()
 }
 
 func initDevnet(ctx *cli.Context, logger log.Logger) (devnet.Devnet, error) {
@@ -420,6 +566,45 @@ func initDevnet(ctx *cli.Context, logger log.Logger) (devnet.Devnet, error) {
 		}
 
 	case networkname.DevChainName:
+		if ctx.Bool(ProofOfStakeFlag.Name) {
+			config := pos.DefaultConfig
+			config.Cancun = ctx.Bool(CancunFlag.Name)
+
+			return []*devnet.Network{
+				{
+					DataDir:            dataDir,
+					Chain:              networkname.DevChainName,
+					Logger:             logger,
+					BasePrivateApiAddr: "localhost:10090",
+					BaseRPCHost:        "localhost",
+					BaseRPCPort:        8545,
+					ProofOfStake:       true,
+					Cancun:             config.Cancun,
+					Alloc: types.GenesisAlloc{
+						faucetSource.Address: {Balance: accounts.EtherAmount(200_000)},
+					},
+					Services: []devnet.Service{
+						account_services.NewFaucet(networkname.DevChainName, faucetSource.Address),
+						pos.NewValidator(dataDir, config, logger),
+					},
+					Nodes: []devnet.Node{
+						args.Validator{
+							Node: args.Node{
+								ConsoleVerbosity: "0",
+								DirVerbosity:     "5",
+							},
+							AccountSlots: 200,
+						},
+						args.NonBlockProducer{
+							Node: args.Node{
+								ConsoleVerbosity: "0",
+								DirVerbosity:     "5",
+							},
+						},
+					},
+				}}, nil
+		}
+
 		return []*devnet.Network{
 			{
 				DataDir:            dataDir,
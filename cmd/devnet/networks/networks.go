@@ -0,0 +1,361 @@
+package networks
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/ledgerwatch/erigon/cmd/devnet/accounts"
+	"github.com/ledgerwatch/erigon/cmd/devnet/args"
+	"github.com/ledgerwatch/erigon/cmd/devnet/devnet"
+	"github.com/ledgerwatch/erigon/cmd/devnet/devnetutils"
+	account_services "github.com/ledgerwatch/erigon/cmd/devnet/services/accounts"
+	"github.com/ledgerwatch/erigon/cmd/devnet/services/bor"
+	"github.com/ledgerwatch/erigon/cmd/devnet/services/pos"
+	"github.com/ledgerwatch/erigon/core/types"
+	"github.com/ledgerwatch/erigon/params"
+	"github.com/ledgerwatch/erigon/params/networkname"
+	"github.com/ledgerwatch/log/v3"
+	"github.com/urfave/cli/v2"
+)
+
+const (
+	BlockProducer    = "block-producer"
+	NonBlockProducer = "non-block-producer"
+)
+
+const (
+	// HeimdallLocal runs a devnet local Heimdall service
+	HeimdallLocal = "local"
+	// HeimdallNone runs bor nodes without Heimdall
+	HeimdallNone = "none"
+)
+
+// Definition is the content of a YAML or JSON network file, for example:
+//
+//	networks:
+//	  - chain: bor-devnet
+//	    basePort: 30303
+//	    basePrivateApiAddr: localhost:10090
+//	    baseRPCPort: 8545
+//	    faucet: 200000
+//	    heimdall: local
+//	    nodes:
+//	      - role: block-producer
+//	        count: 2
+//	        flags:
+//	          log.dir.verbosity: "5"
+//	          txpool.accountslots: "200"
+//	      - role: non-block-producer
+//
+// A dev network with proofOfStake set starts merged, and its block producers are validators which the network
+// validator service drives through the engine API:
+//
+//	networks:
+//	  - chain: dev
+//	    faucet: 200000
+//	    proofOfStake:
+//	      validators: 64
+//	      slotTime: 4s
+//	      slotsPerEpoch: 8
+//	      cancun: true
+//	    nodes:
+//	      - role: block-producer
+//	        count: 2
+//	      - role: non-block-producer
+type Definition struct {
+	Networks []*Network `json:"networks"`
+}
+
+type Network struct {
+	Chain              string `json:"chain"`
+	BasePort           int    `json:"basePort,omitempty"`
+	BasePrivateApiAddr string `json:"basePrivateApiAddr,omitempty"`
+	BaseRPCHost        string `json:"baseRPCHost,omitempty"`
+	BaseRPCPort        int    `json:"baseRPCPort,omitempty"`
+	Snapshots          bool   `json:"snapshots,omitempty"`
+	// Faucet is the ether funding the faucet service of the network, which is not run if it is 0
+	Faucet float64 `json:"faucet,omitempty"`
+	// Heimdall is HeimdallLocal, HeimdallNone or the gRPC address of a Heimdall service. Only bor nodes connect to
+	// it, the local service is shared by all the networks which use it.
+	Heimdall string `json:"heimdall,omitempty"`
+	// SprintSize overrides the bor sprint size of the local Heimdall service
+	SprintSize uint64 `json:"sprintSize,omitempty"`
+	// ProofOfStake makes a dev network a proof of stake network
+	ProofOfStake *ProofOfStake `json:"proofOfStake,omitempty"`
+	Nodes        []*Node       `json:"nodes"`
+}
+
+// ProofOfStake configures the validator service of a proof of stake network, the unset values are the defaults of
+// pos.DefaultConfig
+type ProofOfStake struct {
+	Validators    int    `json:"validators,omitempty"`
+	SlotTime      string `json:"slotTime,omitempty"`
+	SlotsPerEpoch uint64 `json:"slotsPerEpoch,omitempty"`
+	// Cancun activates Cancun at genesis
+	Cancun bool `json:"cancun,omitempty"`
+}
+
+func (p *ProofOfStake) config() (pos.Config, error) {
+	config := pos.DefaultConfig
+	config.Cancun = p.Cancun
+
+	if p.Validators < 0 {
+		return config, fmt.Errorf("negative number of validators")
+	}
+
+	if p.Validators > 0 {
+		config.Validators = p.Validators
+	}
+
+	if len(p.SlotTime) > 0 {
+		slotTime, err := time.ParseDuration(p.SlotTime)
+
+		if err != nil {
+			return config, fmt.Errorf("invalid slot time: %w", err)
+		}
+
+		if slotTime <= 0 {
+			return config, fmt.Errorf("invalid slot time %s", p.SlotTime)
+		}
+
+		config.SlotTime = slotTime
+	}
+
+	if p.SlotsPerEpoch > 0 {
+		config.SlotsPerEpoch = p.SlotsPerEpoch
+	}
+
+	return config, nil
+}
+
+type Node struct {
+	Role string `json:"role"`
+	// Count is the number of nodes, 1 if it is not set
+	Count *int `json:"count,omitempty"`
+	// Flags are the node flags by name, without the leading hyphens, which override the defaults of the role
+	Flags map[string]string `json:"flags,omitempty"`
+}
+
+// Load reads and validates a network file
+func Load(path string) (*Definition, error) {
+	var definition Definition
+
+	if err := devnetutils.ReadConfigFile(path, &definition); err != nil {
+		return nil, err
+	}
+
+	if err := definition.Validate(); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+
+	return &definition, nil
+}
+
+// Validate checks the chains, roles and flags of the networks
+func (d *Definition) Validate() error {
+	if len(d.Networks) == 0 {
+		return fmt.Errorf("no networks defined")
+	}
+
+	for i, nw := range d.Networks {
+		if nw == nil {
+			return fmt.Errorf("network %d: empty definition", i)
+		}
+
+		switch nw.Chain {
+		case networkname.DevChainName, networkname.BorDevnetChainName:
+		default:
+			return fmt.Errorf("network %d: unsupported chain %q, expected %s or %s", i, nw.Chain, networkname.DevChainName, networkname.BorDevnetChainName)
+		}
+
+		if nw.ProofOfStake != nil {
+			if nw.Chain != networkname.DevChainName {
+				return fmt.Errorf("network %d: proof of stake is only supported by %s", i, networkname.DevChainName)
+			}
+
+			if _, err := nw.ProofOfStake.config(); err != nil {
+				return fmt.Errorf("network %d: %w", i, err)
+			}
+		}
+
+		if nw.Faucet < 0 {
+			return fmt.Errorf("network %d: negative faucet funds", i)
+		}
+
+		var nodes int
+
+		for j, node := range nw.Nodes {
+			if node == nil {
+				return fmt.Errorf("network %d: node %d: empty definition", i, j)
+			}
+
+			if _, err := node.args(nw.ProofOfStake != nil); err != nil {
+				return fmt.Errorf("network %d: node %d: %w", i, j, err)
+			}
+
+			count := node.count()
+
+			if count < 0 {
+				return fmt.Errorf("network %d: node %d: negative count", i, j)
+			}
+
+			nodes += count
+		}
+
+		if nodes == 0 {
+			return fmt.Errorf("network %d: no nodes defined", i)
+		}
+	}
+
+	return nil
+}
+
+func (n *Node) count() int {
+	if n.Count == nil {
+		return 1
+	}
+
+	return *n.Count
+}
+
+func (n *Node) args(proofOfStake bool) (devnet.Node, error) {
+	switch n.Role {
+	case BlockProducer:
+		if proofOfStake {
+			var node args.Validator
+
+			if err := args.SetFlags(&node, n.Flags); err != nil {
+				return nil, err
+			}
+
+			return node, nil
+		}
+
+		var node args.BlockProducer
+
+		if err := args.SetFlags(&node, n.Flags); err != nil {
+			return nil, err
+		}
+
+		return node, nil
+	case NonBlockProducer:
+		var node args.NonBlockProducer
+
+		if err := args.SetFlags(&node, n.Flags); err != nil {
+			return nil, err
+		}
+
+		return node, nil
+	}
+
+	return nil, fmt.Errorf("unknown role %q, expected %s or %s", n.Role, BlockProducer, NonBlockProducer)
+}
+
+// Devnet creates the networks of the definition
+func (d *Definition) Devnet(ctx *cli.Context, dataDir string, logger log.Logger) (devnet.Devnet, error) {
+	faucetSource := accounts.NewAccount("faucet-source")
+
+	var heimdall devnet.Service
+	var networks devnet.Devnet
+
+	for _, definition := range d.Networks {
+		nw := &devnet.Network{
+			DataDir:            dataDir,
+			Chain:              definition.Chain,
+			Logger:             logger,
+			BasePort:           definition.BasePort,
+			BasePrivateApiAddr: definition.BasePrivateApiAddr,
+			BaseRPCHost:        definition.BaseRPCHost,
+			BaseRPCPort:        definition.BaseRPCPort,
+			Snapshots:          definition.Snapshots,
+		}
+
+		if len(nw.BasePrivateApiAddr) == 0 {
+			nw.BasePrivateApiAddr = "localhost:10090"
+		}
+
+		if len(nw.BaseRPCHost) == 0 {
+			nw.BaseRPCHost = "localhost"
+		}
+
+		if nw.BaseRPCPort == 0 {
+			nw.BaseRPCPort = 8545
+		}
+
+		var heimdallGrpc string
+
+		switch definition.Heimdall {
+		case "", HeimdallNone:
+		case HeimdallLocal:
+			if heimdall == nil {
+				config := *params.BorDevnetChainConfig
+
+				if definition.SprintSize > 0 {
+					config.Bor.Sprint = map[string]uint64{"0": definition.SprintSize}
+				}
+
+				heimdall = bor.NewHeimdall(&config, logger)
+			}
+
+			nw.Services = append(nw.Services, heimdall)
+			heimdallGrpc = bor.HeimdallGRpc(devnet.WithCliContext(context.Background(), ctx))
+		default:
+			heimdallGrpc = definition.Heimdall
+		}
+
+		if definition.Faucet > 0 {
+			nw.Alloc = types.GenesisAlloc{
+				faucetSource.Address: {Balance: accounts.EtherAmount(definition.Faucet)},
+			}
+			nw.Services = append(nw.Services, account_services.NewFaucet(definition.Chain, faucetSource.Address))
+		}
+
+		if definition.ProofOfStake != nil {
+			config, err := definition.ProofOfStake.config()
+
+			if err != nil {
+				return nil, err
+			}
+
+			nw.ProofOfStake = true
+			nw.Cancun = config.Cancun
+			nw.Services = append(nw.Services, pos.NewValidator(dataDir, config, logger))
+		}
+
+		for _, nodeDefinition := range definition.Nodes {
+			for i := 0; i < nodeDefinition.count(); i++ {
+				node, err := nodeDefinition.args(definition.ProofOfStake != nil)
+
+				if err != nil {
+					return nil, err
+				}
+
+				if definition.Chain == networkname.BorDevnetChainName {
+					node = withHeimdall(node, definition.Heimdall == HeimdallNone, heimdallGrpc)
+				}
+
+				nw.Nodes = append(nw.Nodes, node)
+			}
+		}
+
+		networks = append(networks, nw)
+	}
+
+	return networks, nil
+}
+
+func withHeimdall(node devnet.Node, withoutHeimdall bool, heimdallGrpc string) devnet.Node {
+	switch node := node.(type) {
+	case args.BlockProducer:
+		node.WithoutHeimdall = withoutHeimdall
+		node.HeimdallGRpc = heimdallGrpc
+		return node
+	case args.NonBlockProducer:
+		node.WithoutHeimdall = withoutHeimdall
+		node.HeimdallGRpc = heimdallGrpc
+		return node
+	}
+
+	return node
+}
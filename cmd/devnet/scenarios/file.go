@@ -0,0 +1,110 @@
+package scenarios
+
+import (
+	"fmt"
+
+	"github.com/ledgerwatch/erigon/cmd/devnet/devnet"
+	"github.com/ledgerwatch/erigon/cmd/devnet/devnetutils"
+)
+
+// scenarioFile is the content of a YAML or JSON scenario file, for example:
+//
+//	scenarios:
+//	  dynamic-tx-node-0:
+//	    network: 0
+//	    node: 0
+//	    steps:
+//	      - text: InitSubscriptions
+//	        args: [[eth_newHeads]]
+//	      - text: SendTxWithDynamicFee
+//	        args: ["0x71562b71999873DB5b286dF957af199Ec94617F7", "0x67b1d87101671b127f5f8714789C7192f7ad340e", 10000]
+//	      - text: AwaitBlocks
+//	        args: [2s]
+type scenarioFile struct {
+	Scenarios map[string]*scenarioDefinition `json:"scenarios"`
+}
+
+type scenarioDefinition struct {
+	Scenario
+	// Network and Node select the current network and node of the scenario context
+	Network *int `json:"network,omitempty"`
+	Node    *int `json:"node,omitempty"`
+}
+
+// LoadScenarios reads scenario files and checks that each of their steps matches a registered step handler, converting
+// the step arguments to the types of the handler parameters. The scenarios run with ctx, or with the network and node
+// they select in it. ctx may be nil to only validate the files.
+func LoadScenarios(ctx devnet.Context, paths ...string) (Scenarios, error) {
+	scenarios := Scenarios{}
+
+	for _, path := range paths {
+		var file scenarioFile
+
+		if err := devnetutils.ReadConfigFile(path, &file); err != nil {
+			return nil, err
+		}
+
+		for name, definition := range file.Scenarios {
+			if definition == nil {
+				return nil, fmt.Errorf("%s: scenario %q has no steps", path, name)
+			}
+
+			if _, ok := scenarios[name]; ok {
+				return nil, fmt.Errorf("%s: duplicate scenario %q", path, name)
+			}
+
+			scenario := definition.Scenario
+			scenario.Name = name
+
+			if err := scenario.convertSteps(); err != nil {
+				return nil, fmt.Errorf("%s: %w", path, err)
+			}
+
+			if ctx != nil {
+				scenario.Context = ctx
+
+				if definition.Network != nil {
+					scenario.Context = scenario.Context.WithCurrentNetwork(*definition.Network)
+				}
+
+				if definition.Node != nil {
+					scenario.Context = scenario.Context.WithCurrentNode(*definition.Node)
+				}
+			}
+
+			scenarios[name] = &scenario
+		}
+	}
+
+	return scenarios, nil
+}
+
+func (s *Scenario) convertSteps() error {
+	if len(s.Steps) == 0 {
+		return fmt.Errorf("scenario %q has no steps", s.Name)
+	}
+
+	var suite suite
+
+	for i, step := range s.Steps {
+		if step == nil || len(step.Text) == 0 {
+			return fmt.Errorf("scenario %q: step %d has no text", s.Name, i)
+		}
+
+		runner := suite.matchStep(step.Text)
+
+		if runner == nil {
+			return fmt.Errorf("scenario %q: step %d %q: %w", s.Name, i, step.Text, ErrUndefined)
+		}
+
+		args, err := runner.ConvertArgs(step.Args)
+
+		if err != nil {
+			return fmt.Errorf("scenario %q: step %d %q: %w", s.Name, i, step.Text, err)
+		}
+
+		step.Args = args
+	}
+
+	return nil
+}
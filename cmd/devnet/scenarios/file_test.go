@@ -0,0 +1,124 @@
+package scenarios
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	libcommon "github.com/ledgerwatch/erigon-lib/common"
+)
+
+type testSubMethod string
+
+var testCalls []interface{}
+
+func FileStep(ctx context.Context, methods []testSubMethod, wait time.Duration, count uint64, address libcommon.Address) error {
+	testCalls = append(testCalls, methods, wait, count, address)
+	return nil
+}
+
+func FailingStep(ctx context.Context, reason string) error {
+	return errors.New(reason)
+}
+
+func init() {
+	MustRegisterStepHandlers(
+		StepHandler(FileStep),
+		StepHandler(FailingStep),
+	)
+}
+
+func writeFile(t *testing.T, name, content string) string {
+	path := filepath.Join(t.TempDir(), name)
+
+	if err := os.WriteFile(path, []byte(content), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	return path
+}
+
+func TestLoadScenarios(t *testing.T) {
+	yamlFile := writeFile(t, "scenarios.yaml", `
+scenarios:
+  pass:
+    steps:
+      - text: FileStep
+        args: [[eth_newHeads], 2s, 10000, "0x67b1d87101671b127f5f8714789C7192f7ad340e"]
+  fail:
+    steps:
+      - text: FailingStep
+        args: [boom]
+      - text: FileStep
+        args: [[], 1s, 1, "0x67b1d87101671b127f5f8714789C7192f7ad340e"]
+`)
+	jsonFile := writeFile(t, "scenarios.json", `{"scenarios": {"json": {"steps": [{"text": "FailingStep", "args": ["json"]}]}}}`)
+
+	loaded, err := LoadScenarios(nil, yamlFile, jsonFile)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(loaded) != 3 {
+		t.Fatalf("expected 3 scenarios, got %d", len(loaded))
+	}
+
+	args := loaded["pass"].Steps[0].Args
+
+	if methods, ok := args[0].([]testSubMethod); !ok || len(methods) != 1 || methods[0] != "eth_newHeads" {
+		t.Fatalf("unexpected methods %#v", args[0])
+	}
+
+	if args[1] != 2*time.Second || args[2] != uint64(10000) || args[3] != libcommon.HexToAddress("0x67b1d87101671b127f5f8714789C7192f7ad340e") {
+		t.Fatalf("unexpected args %#v", args)
+	}
+
+	for _, invalid := range []string{
+		`{"scenarios": {"undefined": {"steps": [{"text": "NoSuchStep"}]}}}`,
+		`{"scenarios": {"args": {"steps": [{"text": "FailingStep", "args": ["a", "b"]}]}}}`,
+		`{"scenarios": {"types": {"steps": [{"text": "FileStep", "args": [[], "2", 1, "0x00"]}]}}}`,
+		`{"scenarios": {"unknown": {"stepz": []}}}`,
+	} {
+		if _, err := LoadScenarios(nil, writeFile(t, "invalid.json", invalid)); err == nil {
+			t.Fatalf("expected an error loading %s", invalid)
+		}
+	}
+
+	if _, err := LoadScenarios(nil, jsonFile, jsonFile); err == nil {
+		t.Fatal("expected an error for duplicate scenarios")
+	}
+
+	testCalls = nil
+	results, err := loaded.RunWithResults(context.Background(), "pass", "fail")
+
+	if err == nil || err.Error() != "boom" {
+		t.Fatalf("expected the failing step error, got %v", err)
+	}
+
+	if len(testCalls) != 4 || len(results) != 2 {
+		t.Fatalf("expected only the passing scenario steps to be called, got %v", testCalls)
+	}
+
+	var report bytes.Buffer
+
+	if err := WriteJUnit(&report, "devnet", results); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, expected := range []string{
+		`<testsuites name="devnet" tests="3" failures="1" skipped="1"`,
+		`<testsuite name="fail" tests="2" failures="1" skipped="1"`,
+		`<failure message="boom" type="failed">boom</failure>`,
+		`<skipped message="skipped"></skipped>`,
+	} {
+		if !strings.Contains(report.String(), expected) {
+			t.Fatalf("report does not contain %s:\n%s", expected, report.String())
+		}
+	}
+}
@@ -1,12 +1,16 @@
 package scenarios
 
 import (
+	"encoding/xml"
+	"fmt"
+	"io"
 	"time"
 )
 
 type ScenarioResult struct {
-	ScenarioId string
-	StartedAt  time.Time
+	ScenarioId   string
+	ScenarioName string
+	StartedAt    time.Time
 
 	StepResults []StepResult
 }
@@ -52,3 +56,122 @@ func (st StepStatus) String() string {
 		return "unknown"
 	}
 }
+
+type junitTestSuites struct {
+	XMLName  xml.Name         `xml:"testsuites"`
+	Name     string           `xml:"name,attr"`
+	Tests    int              `xml:"tests,attr"`
+	Failures int              `xml:"failures,attr"`
+	Skipped  int              `xml:"skipped,attr"`
+	Time     string           `xml:"time,attr"`
+	Suites   []junitTestSuite `xml:"testsuite"`
+}
+
+type junitTestSuite struct {
+	Name      string          `xml:"name,attr"`
+	Tests     int             `xml:"tests,attr"`
+	Failures  int             `xml:"failures,attr"`
+	Skipped   int             `xml:"skipped,attr"`
+	Time      string          `xml:"time,attr"`
+	Timestamp string          `xml:"timestamp,attr"`
+	TestCases []junitTestCase `xml:"testcase"`
+}
+
+type junitTestCase struct {
+	Name      string        `xml:"name,attr"`
+	Classname string        `xml:"classname,attr"`
+	Time      string        `xml:"time,attr"`
+	Failure   *junitMessage `xml:"failure,omitempty"`
+	Skipped   *junitMessage `xml:"skipped,omitempty"`
+}
+
+type junitMessage struct {
+	Message string `xml:"message,attr,omitempty"`
+	Type    string `xml:"type,attr,omitempty"`
+	Text    string `xml:",chardata"`
+}
+
+func junitTime(d time.Duration) string {
+	return fmt.Sprintf("%.3f", d.Seconds())
+}
+
+// WriteJUnit writes the results of a run as a JUnit XML report, with a test suite per scenario and a test case per
+// step. Failed and undefined steps are failures, skipped and pending steps are skipped.
+func WriteJUnit(w io.Writer, name string, results []*ScenarioResult) error {
+	report := junitTestSuites{Name: name}
+
+	var total time.Duration
+
+	for _, result := range results {
+		suite := junitTestSuite{
+			Name:      result.ScenarioName,
+			Timestamp: result.StartedAt.Format(time.RFC3339),
+		}
+
+		if len(suite.Name) == 0 {
+			suite.Name = result.ScenarioId
+		}
+
+		startedAt := result.StartedAt
+
+		for _, step := range result.StepResults {
+			testCase := junitTestCase{
+				Classname: suite.Name,
+			}
+
+			if step.Step != nil {
+				testCase.Name = step.Step.Text
+			}
+
+			if !step.FinishedAt.IsZero() && step.FinishedAt.After(startedAt) {
+				testCase.Time = junitTime(step.FinishedAt.Sub(startedAt))
+				startedAt = step.FinishedAt
+			} else {
+				testCase.Time = junitTime(0)
+			}
+
+			switch step.Status {
+			case Failed, Undefined:
+				failure := &junitMessage{Type: step.Status.String()}
+
+				if step.Err != nil {
+					failure.Message = step.Err.Error()
+					failure.Text = fmt.Sprintf("%+v", step.Err)
+				}
+
+				testCase.Failure = failure
+				suite.Failures++
+			case Skipped, Pending:
+				testCase.Skipped = &junitMessage{Message: step.Status.String()}
+				suite.Skipped++
+			}
+
+			suite.TestCases = append(suite.TestCases, testCase)
+		}
+
+		suite.Tests = len(suite.TestCases)
+		suite.Time = junitTime(startedAt.Sub(result.StartedAt))
+		total += startedAt.Sub(result.StartedAt)
+
+		report.Tests += suite.Tests
+		report.Failures += suite.Failures
+		report.Skipped += suite.Skipped
+		report.Suites = append(report.Suites, suite)
+	}
+
+	report.Time = junitTime(total)
+
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+
+	encoder := xml.NewEncoder(w)
+	encoder.Indent("", "  ")
+
+	if err := encoder.Encode(report); err != nil {
+		return err
+	}
+
+	_, err := io.WriteString(w, "\n")
+	return err
+}
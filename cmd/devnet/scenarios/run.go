@@ -10,8 +10,14 @@ import (
 type SimulationInitializer func(*SimulationContext)
 
 func Run(ctx context.Context, scenarios ...*Scenario) error {
+	_, err := RunWithResults(ctx, scenarios...)
+	return err
+}
+
+// RunWithResults runs the scenarios like Run and returns the result of each scenario which ran
+func RunWithResults(ctx context.Context, scenarios ...*Scenario) ([]*ScenarioResult, error) {
 	if len(scenarios) == 0 {
-		return nil
+		return nil, nil
 	}
 
 	return runner{scenarios: scenarios}.runWithOptions(ctx, getDefaultOptions())
@@ -26,7 +32,7 @@ type runner struct {
 	simulationInitializer SimulationInitializer
 }
 
-func (r *runner) concurrent(ctx context.Context, rate int) (err error) {
+func (r *runner) concurrent(ctx context.Context, rate int) (results []*ScenarioResult, err error) {
 	var copyLock sync.Mutex
 
 	queue := make(chan int, rate)
@@ -72,12 +78,14 @@ func (r *runner) concurrent(ctx context.Context, rate int) (err error) {
 				r.simulationInitializer(&sc)
 			}
 
-			_, serr := suite.runScenario(&scenario)
+			sr, serr := suite.runScenario(&scenario)
+
+			copyLock.Lock()
+			results = append(results, sr)
 			if suite.shouldFail(serr) {
-				copyLock.Lock()
 				*err = serr
-				copyLock.Unlock()
 			}
+			copyLock.Unlock()
 		}
 
 		if rate == 1 {
@@ -96,10 +104,10 @@ func (r *runner) concurrent(ctx context.Context, rate int) (err error) {
 
 	close(queue)
 
-	return err
+	return results, err
 }
 
-func (runner runner) runWithOptions(ctx context.Context, opt *Options) error {
+func (runner runner) runWithOptions(ctx context.Context, opt *Options) ([]*ScenarioResult, error) {
 	//var output io.Writer = os.Stdout
 	//if nil != opt.Output {
 	//	output = opt.Output
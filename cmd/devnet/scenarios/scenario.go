@@ -2,15 +2,19 @@ package scenarios
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"path"
 	"reflect"
 	"regexp"
 	"runtime"
+	"strconv"
+	"time"
 	"unicode"
 
 	"github.com/ledgerwatch/erigon/cmd/devnet/devnet"
+	"github.com/ledgerwatch/erigon/common/hexutil"
 	"github.com/ledgerwatch/log/v3"
 )
 
@@ -103,6 +107,201 @@ var typeOfBytes = reflect.TypeOf([]byte(nil))
 
 var typeOfContext = reflect.TypeOf((*context.Context)(nil)).Elem()
 
+var typeOfDuration = reflect.TypeOf(time.Duration(0))
+
+// ConvertArgs converts the arguments of a step read from a configuration file, which are strings, numbers, bools,
+// lists and maps, to the types of the parameters of the handler
+func (c *stepRunner) ConvertArgs(args []interface{}) ([]interface{}, error) {
+	typ := c.Handler.Type()
+	params := make([]reflect.Type, 0, typ.NumIn())
+
+	for i := 0; i < typ.NumIn(); i++ {
+		if i == 0 && typ.In(0).Implements(typeOfContext) {
+			continue
+		}
+
+		params = append(params, typ.In(i))
+	}
+
+	variadic := typ.IsVariadic()
+
+	if variadic {
+		if len(args) < len(params)-1 {
+			return nil, fmt.Errorf("expected at least %d arguments, got %d", len(params)-1, len(args))
+		}
+	} else if len(args) > len(params) {
+		return nil, fmt.Errorf("%w: expected %d, got %d", ErrUnmatchedStepArgumentNumber, len(params), len(args))
+	} else if len(args) < len(params) {
+		return nil, fmt.Errorf("expected %d arguments, got %d", len(params), len(args))
+	}
+
+	converted := make([]interface{}, len(args))
+
+	for i, arg := range args {
+		var param reflect.Type
+
+		if variadic && i >= len(params)-1 {
+			param = params[len(params)-1].Elem()
+		} else {
+			param = params[i]
+		}
+
+		value, err := convertArg(arg, param)
+
+		if err != nil {
+			return nil, fmt.Errorf("argument %d: %w", i, err)
+		}
+
+		converted[i] = value.Interface()
+	}
+
+	return converted, nil
+}
+
+func convertArg(arg interface{}, typ reflect.Type) (reflect.Value, error) {
+	if arg == nil {
+		return reflect.Zero(typ), nil
+	}
+
+	value := reflect.ValueOf(arg)
+
+	if value.Type().AssignableTo(typ) {
+		return value, nil
+	}
+
+	converted := reflect.New(typ).Elem()
+
+	cannotConvert := func(err error) (reflect.Value, error) {
+		if err != nil {
+			return reflect.Value{}, fmt.Errorf("%w %v to %s: %s", ErrCannotConvert, arg, typ, err)
+		}
+
+		return reflect.Value{}, fmt.Errorf("%w %v to %s", ErrCannotConvert, arg, typ)
+	}
+
+	if typ == typeOfDuration {
+		s, ok := arg.(string)
+
+		if !ok {
+			return cannotConvert(nil)
+		}
+
+		d, err := time.ParseDuration(s)
+
+		if err != nil {
+			return cannotConvert(err)
+		}
+
+		return reflect.ValueOf(d), nil
+	}
+
+	var text string
+
+	switch arg := arg.(type) {
+	case json.Number:
+		text = arg.String()
+	case string:
+		text = arg
+	}
+
+	switch typ.Kind() {
+	case reflect.String:
+		if s, ok := arg.(string); ok {
+			converted.SetString(s)
+			return converted, nil
+		}
+
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		if len(text) > 0 {
+			i, err := strconv.ParseInt(text, 0, typ.Bits())
+
+			if err != nil {
+				return cannotConvert(err)
+			}
+
+			converted.SetInt(i)
+			return converted, nil
+		}
+
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		if len(text) > 0 {
+			u, err := strconv.ParseUint(text, 0, typ.Bits())
+
+			if err != nil {
+				return cannotConvert(err)
+			}
+
+			converted.SetUint(u)
+			return converted, nil
+		}
+
+	case reflect.Float32, reflect.Float64:
+		if len(text) > 0 {
+			f, err := strconv.ParseFloat(text, typ.Bits())
+
+			if err != nil {
+				return cannotConvert(err)
+			}
+
+			converted.SetFloat(f)
+			return converted, nil
+		}
+
+	case reflect.Bool:
+		if b, ok := arg.(bool); ok {
+			converted.SetBool(b)
+			return converted, nil
+		}
+
+	case reflect.Slice:
+		if s, ok := arg.(string); ok && typ.Elem().Kind() == reflect.Uint8 {
+			b, err := hexutil.Decode(s)
+
+			if err != nil {
+				return cannotConvert(err)
+			}
+
+			converted.SetBytes(b)
+			return converted, nil
+		}
+
+		if list, ok := arg.([]interface{}); ok {
+			converted = reflect.MakeSlice(typ, len(list), len(list))
+
+			for i, elem := range list {
+				value, err := convertArg(elem, typ.Elem())
+
+				if err != nil {
+					return reflect.Value{}, err
+				}
+
+				converted.Index(i).Set(value)
+			}
+
+			return converted, nil
+		}
+
+	case reflect.Func, reflect.Chan, reflect.UnsafePointer:
+		return reflect.Value{}, fmt.Errorf("%w: %s", ErrUnsupportedArgumentType, typ)
+
+	default:
+		// addresses, hashes, big ints and structs decode themselves from their json form
+		data, err := json.Marshal(arg)
+
+		if err != nil {
+			return cannotConvert(err)
+		}
+
+		if err := json.Unmarshal(data, converted.Addr().Interface()); err != nil {
+			return cannotConvert(err)
+		}
+
+		return converted, nil
+	}
+
+	return cannotConvert(nil)
+}
+
 func (c *stepRunner) Run(ctx context.Context, text string, args []interface{}, logger log.Logger) (context.Context, interface{}) {
 	var values = make([]reflect.Value, 0, len(args))
 
@@ -158,6 +357,12 @@ func (c *stepRunner) Run(ctx context.Context, text string, args []interface{}, l
 type Scenarios map[string]*Scenario
 
 func (s Scenarios) Run(ctx context.Context, scenarioNames ...string) error {
+	_, err := s.RunWithResults(ctx, scenarioNames...)
+	return err
+}
+
+// RunWithResults runs the named scenarios, or all of them if no names are given, and returns their results
+func (s Scenarios) RunWithResults(ctx context.Context, scenarioNames ...string) ([]*ScenarioResult, error) {
 	var scenarios []*Scenario
 
 	if len(scenarioNames) == 0 {
@@ -174,5 +379,5 @@ func (s Scenarios) Run(ctx context.Context, scenarioNames ...string) error {
 		}
 	}
 
-	return Run(ctx, scenarios...)
+	return RunWithResults(ctx, scenarios...)
 }
@@ -90,7 +90,9 @@ func (s *suite) runStep(ctx context.Context, scenario *Scenario, step *Step, pre
 		earlyReturn := prevStepErr != nil || sr.Err == ErrUndefined
 
 		if !earlyReturn {
+			err := sr.Err
 			sr = NewStepResult(scenario.Id, step)
+			sr.Err = err
 		}
 
 		// Run after step handlers.
@@ -268,13 +270,13 @@ func (s *suite) runScenario(scenario *Scenario) (sr *ScenarioResult, err error)
 	defer cancel()
 
 	if len(scenario.Steps) == 0 {
-		return &ScenarioResult{ScenarioId: scenario.Id, StartedAt: TimeNowFunc()}, ErrUndefined
+		return &ScenarioResult{ScenarioId: scenario.Id, ScenarioName: scenario.Name, StartedAt: TimeNowFunc()}, ErrUndefined
 	}
 
 	// Before scenario hooks are called in context of first evaluated step
 	// so that error from handler can be added to step.
 
-	sr = &ScenarioResult{ScenarioId: scenario.Id, StartedAt: TimeNowFunc()}
+	sr = &ScenarioResult{ScenarioId: scenario.Id, ScenarioName: scenario.Name, StartedAt: TimeNowFunc()}
 
 	// scenario
 	if s.testingT != nil {
@@ -6,6 +6,7 @@ import (
 	"github.com/ledgerwatch/erigon/cmd/devnet/devnet"
 	"github.com/ledgerwatch/erigon/cmd/devnet/services/accounts"
 	"github.com/ledgerwatch/erigon/cmd/devnet/services/bor"
+	"github.com/ledgerwatch/erigon/cmd/devnet/services/pos"
 )
 
 type ctxKey int
@@ -37,3 +38,15 @@ func Heimdall(ctx context.Context) *bor.Heimdall {
 
 	return nil
 }
+
+func Validator(ctx context.Context) *pos.Validator {
+	if network := devnet.CurrentNetwork(ctx); network != nil {
+		for _, service := range network.Services {
+			if validator, ok := service.(*pos.Validator); ok {
+				return validator
+			}
+		}
+	}
+
+	return nil
+}
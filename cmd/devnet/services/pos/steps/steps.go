@@ -0,0 +1,103 @@
+package pos_steps
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+	"time"
+
+	libcommon "github.com/ledgerwatch/erigon-lib/common"
+
+	"github.com/ledgerwatch/erigon/cmd/devnet/accounts"
+	"github.com/ledgerwatch/erigon/cmd/devnet/devnet"
+	"github.com/ledgerwatch/erigon/cmd/devnet/scenarios"
+	"github.com/ledgerwatch/erigon/cmd/devnet/services"
+	"github.com/ledgerwatch/erigon/cmd/devnet/services/pos"
+	"github.com/ledgerwatch/erigon/params"
+)
+
+func init() {
+	scenarios.MustRegisterStepHandlers(
+		scenarios.StepHandler(QueueWithdrawal),
+		scenarios.StepHandler(Reorg),
+		scenarios.StepHandler(AwaitFinalized),
+	)
+}
+
+func validator(ctx context.Context) (*pos.Validator, error) {
+	if validator := services.Validator(ctx); validator != nil {
+		return validator, nil
+	}
+
+	return nil, errors.New("the network has no proof of stake validator")
+}
+
+// QueueWithdrawal withdraws the ether amount to the named account, or to the address if there is no such account
+func QueueWithdrawal(ctx context.Context, to string, ethAmount float64) error {
+	validator, err := validator(ctx)
+
+	if err != nil {
+		return err
+	}
+
+	var address libcommon.Address
+
+	if account := accounts.GetAccount(to); account != nil {
+		address = account.Address
+	} else if libcommon.IsHexAddress(to) {
+		address = libcommon.HexToAddress(to)
+	} else {
+		return fmt.Errorf("unknown account: %s", to)
+	}
+
+	gwei := accounts.EtherAmount(ethAmount)
+	gwei.Div(gwei, big.NewInt(params.GWei))
+
+	if !gwei.IsUint64() {
+		return fmt.Errorf("withdrawal of %v ether is too large", ethAmount)
+	}
+
+	return validator.QueueWithdrawal(address, gwei.Uint64())
+}
+
+// Reorg replaces the last depth blocks of the chain by the next proposed block
+func Reorg(ctx context.Context, depth uint64) error {
+	validator, err := validator(ctx)
+
+	if err != nil {
+		return err
+	}
+
+	return validator.Reorg(depth)
+}
+
+// AwaitFinalized waits until the block number is finalized
+func AwaitFinalized(ctx context.Context, blockNum uint64, timeout time.Duration) error {
+	validator, err := validator(ctx)
+
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	ticker := time.NewTicker(time.Second)
+	defer ticker.Stop()
+
+	for {
+		finalized := validator.Finalized()
+
+		if finalized >= blockNum {
+			devnet.Logger(ctx).Info("Block finalized", "block", blockNum, "finalized", finalized)
+			return nil
+		}
+
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("block %d not finalized, finalized block is %d: %w", blockNum, finalized, ctx.Err())
+		case <-ticker.C:
+		}
+	}
+}
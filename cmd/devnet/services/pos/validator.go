@@ -0,0 +1,534 @@
+package pos
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	libcommon "github.com/ledgerwatch/erigon-lib/common"
+	"github.com/ledgerwatch/erigon-lib/common/hexutility"
+	"github.com/ledgerwatch/log/v3"
+
+	"github.com/ledgerwatch/erigon/cl/phase1/execution_client/rpc_helper"
+	"github.com/ledgerwatch/erigon/cmd/devnet/args"
+	"github.com/ledgerwatch/erigon/cmd/devnet/devnet"
+	"github.com/ledgerwatch/erigon/common/hexutil"
+	"github.com/ledgerwatch/erigon/core/types"
+	"github.com/ledgerwatch/erigon/crypto"
+	"github.com/ledgerwatch/erigon/rpc"
+	"github.com/ledgerwatch/erigon/turbo/engineapi/engine_types"
+)
+
+// maxWithdrawalsPerPayload is the mainnet limit of withdrawals per block
+const maxWithdrawalsPerPayload = 16
+
+type Config struct {
+	// Validators is the number of mock validator indices, assigned round robin to the block producers of the network.
+	// They pick the proposer of each slot, weight the votes of the nodes and own the queued withdrawals.
+	Validators    int
+	SlotTime      time.Duration
+	SlotsPerEpoch uint64
+	// Cancun selects the Cancun engine API methods, for networks with Cancun active at genesis
+	Cancun bool
+}
+
+var DefaultConfig = Config{
+	Validators:    64,
+	SlotTime:      4 * time.Second,
+	SlotsPerEpoch: 8,
+}
+
+// Validator is an engine API only mock of a consensus layer for proof of stake devnets. Every slot the proposing
+// validator index builds a block through the engine API of the block producer it is assigned to, and every node of
+// the network is asked to validate it. The validator indices of the nodes which found it valid count as votes for it,
+// and when the last block of an epoch gets the votes of two thirds of the indices it becomes the safe block, moving
+// the finalized block to the previous safe block if that one closed the previous epoch. The nodes are then moved to
+// the new head, safe and finalized blocks.
+//
+// This is not a beacon chain: there are no validator keys, signatures or attestations, and no beacon state. The
+// safe and finalized blocks follow the simple vote rule above, and the nodes only see the engine API calls a beacon
+// node would make.
+type Validator struct {
+	sync.Mutex
+	config          Config
+	jwtSecretPath   string
+	logger          log.Logger
+	nodes           []*engineNode
+	genesis         *block
+	head            *block
+	safe            *block
+	safeEpoch       uint64 // epoch+1 of the safe block, 0 for genesis
+	finalized       *block
+	slot            uint64
+	randao          libcommon.Hash
+	withdrawals     []*types.Withdrawal
+	withdrawalIndex uint64
+	reorgDepth      uint64
+	cancelFunc      context.CancelFunc
+	done            chan struct{}
+}
+
+type engineNode struct {
+	devnet.Node
+	client *rpc.Client
+}
+
+type block struct {
+	hash      libcommon.Hash
+	number    uint64
+	timestamp uint64
+	parent    *block
+}
+
+// payloadStatus and forkChoiceUpdatedResponse decode the engine API responses, the engine_types ones only encode them
+type payloadStatus struct {
+	Status          engine_types.EngineStatus `json:"status"`
+	ValidationError *string                   `json:"validationError"`
+	LatestValidHash *libcommon.Hash           `json:"latestValidHash"`
+}
+
+func (s payloadStatus) String() string {
+	if s.ValidationError != nil {
+		return fmt.Sprintf("%s: %s", s.Status, *s.ValidationError)
+	}
+
+	return string(s.Status)
+}
+
+type forkChoiceUpdatedResponse struct {
+	PayloadId     *hexutility.Bytes `json:"payloadId"`
+	PayloadStatus *payloadStatus    `json:"payloadStatus"`
+}
+
+type getPayloadResponse struct {
+	ExecutionPayload *engine_types.ExecutionPayload `json:"executionPayload"`
+	BlockValue       *hexutil.Big                   `json:"blockValue"`
+}
+
+// NewValidator creates the validator service of the network with the data directory, in which it writes the engine
+// API secret of the nodes
+func NewValidator(dataDir string, config Config, logger log.Logger) *Validator {
+	return &Validator{
+		config:        config,
+		jwtSecretPath: filepath.Join(dataDir, args.JWTSecretFile),
+		logger:        logger,
+	}
+}
+
+func (v *Validator) Start(ctx context.Context) error {
+	v.Lock()
+	defer v.Unlock()
+
+	if v.cancelFunc != nil {
+		return nil
+	}
+
+	if v.config.Validators <= 0 || v.config.SlotTime <= 0 || v.config.SlotsPerEpoch == 0 {
+		return fmt.Errorf("invalid proof of stake config %+v", v.config)
+	}
+
+	if err := v.writeJWTSecret(); err != nil {
+		return err
+	}
+
+	ctx, v.cancelFunc = context.WithCancel(ctx)
+	v.done = make(chan struct{})
+
+	go v.run(ctx, v.done)
+
+	return nil
+}
+
+func (v *Validator) Stop() {
+	v.Lock()
+	cancel, done := v.cancelFunc, v.done
+	v.cancelFunc, v.done = nil, nil
+	v.Unlock()
+
+	if cancel == nil {
+		return
+	}
+
+	cancel()
+	<-done
+
+	v.Lock()
+	defer v.Unlock()
+
+	for _, node := range v.nodes {
+		node.client.Close()
+	}
+
+	v.nodes = nil
+}
+
+// writeJWTSecret generates the engine API secret of the nodes, unless one was left by a previous run
+func (v *Validator) writeJWTSecret() error {
+	if _, err := v.jwtSecret(); err == nil {
+		return nil
+	}
+
+	secret := make([]byte, 32)
+
+	if _, err := rand.Read(secret); err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(v.jwtSecretPath), 0755); err != nil {
+		return err
+	}
+
+	return os.WriteFile(v.jwtSecretPath, []byte(hexutility.Encode(secret)), 0600)
+}
+
+func (v *Validator) jwtSecret() ([]byte, error) {
+	data, err := os.ReadFile(v.jwtSecretPath)
+
+	if err != nil {
+		return nil, err
+	}
+
+	secret := libcommon.FromHex(strings.TrimSpace(string(data)))
+
+	if len(secret) != 32 {
+		return nil, fmt.Errorf("invalid JWT secret in %s", v.jwtSecretPath)
+	}
+
+	return secret, nil
+}
+
+func (v *Validator) NodeCreated(ctx context.Context, node devnet.Node) {
+}
+
+func (v *Validator) NodeStarted(ctx context.Context, node devnet.Node) {
+	secret, err := v.jwtSecret()
+
+	if err != nil {
+		v.logger.Error("[pos] Can't read the engine API secret", "node", node.Name(), "err", err)
+		return
+	}
+
+	client, err := rpc.DialHTTPWithClient("http://"+devnet.AuthRPCHost(node), &http.Client{
+		Timeout:   v.config.SlotTime,
+		Transport: rpc_helper.NewJWTRoundTripper(secret),
+	}, v.logger)
+
+	if err != nil {
+		v.logger.Error("[pos] Can't connect to the engine API", "node", node.Name(), "err", err)
+		return
+	}
+
+	v.Lock()
+	defer v.Unlock()
+
+	v.nodes = append(v.nodes, &engineNode{node, client})
+}
+
+// readGenesis reads the genesis block the chain starts from, once a node serves it
+func (v *Validator) readGenesis() error {
+	if v.genesis != nil {
+		return nil
+	}
+
+	genesis, err := v.nodes[0].GetBlockByNumber(0, false)
+
+	if err != nil {
+		return fmt.Errorf("can't read the genesis block: %w", err)
+	}
+
+	if genesis.Result == nil {
+		return errors.New("can't read the genesis block")
+	}
+
+	v.genesis = &block{hash: genesis.Result.Hash}
+	v.head, v.safe, v.finalized = v.genesis, v.genesis, v.genesis
+
+	return nil
+}
+
+func (v *Validator) run(ctx context.Context, done chan struct{}) {
+	defer close(done)
+
+	slots := time.NewTicker(v.config.SlotTime)
+	defer slots.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-slots.C:
+			if err := v.runSlot(ctx); err != nil && ctx.Err() == nil {
+				v.logger.Warn("[pos] Slot failed", "slot", v.slot, "err", err)
+			}
+		}
+	}
+}
+
+// runSlot proposes a block, has it validated by the nodes, and moves the nodes to it
+func (v *Validator) runSlot(ctx context.Context) error {
+	v.Lock()
+	defer v.Unlock()
+
+	var producers []*engineNode
+
+	for _, node := range v.nodes {
+		if node.IsBlockProducer() {
+			producers = append(producers, node)
+		}
+	}
+
+	if len(producers) == 0 {
+		return nil
+	}
+
+	if err := v.readGenesis(); err != nil {
+		return err
+	}
+
+	v.slot++
+	slot := v.slot
+	proposer := int(slot % uint64(v.config.Validators))
+	producer := producers[proposer%len(producers)]
+
+	parent := v.head
+
+	if v.reorgDepth > 0 {
+		for i := uint64(0); i < v.reorgDepth && parent != v.finalized; i++ {
+			parent = parent.parent
+		}
+
+		v.logger.Info("[pos] Reorganising", "from", v.head.number, "to", parent.number)
+		v.reorgDepth = 0
+	}
+
+	var slotBytes [8]byte
+	binary.BigEndian.PutUint64(slotBytes[:], slot)
+	v.randao = crypto.Keccak256Hash(v.randao[:], slotBytes[:])
+
+	timestamp := uint64(time.Now().Unix())
+
+	if timestamp <= parent.timestamp {
+		timestamp = parent.timestamp + 1
+	}
+
+	attributes := &engine_types.PayloadAttributes{
+		Timestamp:   hexutil.Uint64(timestamp),
+		PrevRandao:  v.randao,
+		Withdrawals: v.withdrawals,
+	}
+
+	if len(attributes.Withdrawals) > maxWithdrawalsPerPayload {
+		attributes.Withdrawals = attributes.Withdrawals[:maxWithdrawalsPerPayload]
+	}
+
+	if attributes.Withdrawals == nil {
+		attributes.Withdrawals = []*types.Withdrawal{}
+	}
+
+	if account := producer.Account(); account != nil {
+		attributes.SuggestedFeeRecipient = account.Address
+	}
+
+	var forkChoice forkChoiceUpdatedResponse
+
+	if err := producer.client.CallContext(ctx, &forkChoice, "engine_forkchoiceUpdatedV2", v.forkChoiceState(parent), attributes); err != nil {
+		return fmt.Errorf("%s: %w", producer.Name(), err)
+	}
+
+	if forkChoice.PayloadId == nil {
+		if forkChoice.PayloadStatus != nil {
+			return fmt.Errorf("%s did not build a payload: %s", producer.Name(), forkChoice.PayloadStatus)
+		}
+
+		return fmt.Errorf("%s did not build a payload", producer.Name())
+	}
+
+	getPayload, newPayload := "engine_getPayloadV2", "engine_newPayloadV2"
+
+	if v.config.Cancun {
+		getPayload, newPayload = "engine_getPayloadV3", "engine_newPayloadV3"
+	}
+
+	var built getPayloadResponse
+
+	if err := producer.client.CallContext(ctx, &built, getPayload, forkChoice.PayloadId); err != nil {
+		return fmt.Errorf("%s: %w", producer.Name(), err)
+	}
+
+	payload := built.ExecutionPayload
+
+	if payload == nil {
+		return fmt.Errorf("%s returned no payload", producer.Name())
+	}
+
+	v.withdrawals = v.withdrawals[len(payload.Withdrawals):]
+
+	head := &block{
+		hash:      payload.BlockHash,
+		number:    uint64(payload.BlockNumber),
+		timestamp: uint64(payload.Timestamp),
+		parent:    parent,
+	}
+
+	valid := map[*engineNode]bool{}
+
+	for _, node := range v.nodes {
+		var status payloadStatus
+
+		if err := node.client.CallContext(ctx, &status, newPayload, payload); err != nil {
+			v.logger.Warn("[pos] New payload failed", "node", node.Name(), "block", head.number, "err", err)
+			continue
+		}
+
+		if status.Status != engine_types.ValidStatus {
+			v.logger.Debug("[pos] Payload not validated", "node", node.Name(), "block", head.number, "status", status)
+			continue
+		}
+
+		valid[node] = true
+	}
+
+	if !valid[producer] {
+		return fmt.Errorf("%s did not validate its block %d", producer.Name(), head.number)
+	}
+
+	v.head = head
+
+	votes := v.votes(producers, valid)
+
+	if !v.isAncestor(v.safe, head) {
+		v.safe, v.safeEpoch = v.finalized, 0
+	}
+
+	if epoch := slot / v.config.SlotsPerEpoch; slot%v.config.SlotsPerEpoch == v.config.SlotsPerEpoch-1 && 3*votes >= 2*v.config.Validators {
+		if v.safeEpoch == epoch {
+			v.finalized = v.safe
+		}
+
+		v.safe, v.safeEpoch = head, epoch+1
+	}
+
+	for _, node := range v.nodes {
+		var forkChoice forkChoiceUpdatedResponse
+
+		if err := node.client.CallContext(ctx, &forkChoice, "engine_forkchoiceUpdatedV2", v.forkChoiceState(head), nil); err != nil {
+			v.logger.Warn("[pos] Fork choice update failed", "node", node.Name(), "block", head.number, "err", err)
+		}
+	}
+
+	v.logger.Info("[pos] Proposed block", "slot", slot, "proposer", proposer, "node", producer.Name(), "number", head.number,
+		"hash", head.hash, "txs", len(payload.Transactions), "withdrawals", len(payload.Withdrawals),
+		"votes", votes, "safe", v.safe.number, "finalized", v.finalized.number)
+
+	return nil
+}
+
+// votes returns the number of validator indices assigned to the nodes which validated the head
+func (v *Validator) votes(producers []*engineNode, valid map[*engineNode]bool) int {
+	var votes int
+
+	for i := 0; i < v.config.Validators; i++ {
+		if valid[producers[i%len(producers)]] {
+			votes++
+		}
+	}
+
+	return votes
+}
+
+func (v *Validator) forkChoiceState(head *block) *engine_types.ForkChoiceState {
+	return &engine_types.ForkChoiceState{
+		HeadHash:           head.hash,
+		SafeBlockHash:      v.safe.hash,
+		FinalizedBlockHash: v.finalized.hash,
+	}
+}
+
+func (v *Validator) isAncestor(ancestor, b *block) bool {
+	for ; b != nil; b = b.parent {
+		if b == ancestor {
+			return true
+		}
+	}
+
+	return false
+}
+
+// QueueWithdrawal adds a withdrawal of the amount of gwei to the address to the next blocks, from a validator chosen
+// round robin
+func (v *Validator) QueueWithdrawal(address libcommon.Address, gwei uint64) error {
+	v.Lock()
+	defer v.Unlock()
+
+	if v.cancelFunc == nil {
+		return errors.New("the validator is not started")
+	}
+
+	v.withdrawals = append(v.withdrawals, &types.Withdrawal{
+		Index:     v.withdrawalIndex,
+		Validator: v.withdrawalIndex % uint64(v.config.Validators),
+		Address:   address,
+		Amount:    gwei,
+	})
+
+	v.withdrawalIndex++
+
+	return nil
+}
+
+// PendingWithdrawals returns the number of queued withdrawals not in a block yet
+func (v *Validator) PendingWithdrawals() int {
+	v.Lock()
+	defer v.Unlock()
+	return len(v.withdrawals)
+}
+
+// Reorg makes the next block replace the last depth blocks of the chain, which can't go past the finalized block
+func (v *Validator) Reorg(depth uint64) error {
+	v.Lock()
+	defer v.Unlock()
+
+	if v.head == nil || depth == 0 {
+		return fmt.Errorf("can't reorganise %d blocks", depth)
+	}
+
+	if v.head.number-v.finalized.number < depth {
+		return fmt.Errorf("can't reorganise %d blocks, %d blocks are not finalized", depth, v.head.number-v.finalized.number)
+	}
+
+	v.reorgDepth = depth
+
+	return nil
+}
+
+// Head returns the number and hash of the last proposed block
+func (v *Validator) Head() (uint64, libcommon.Hash) {
+	v.Lock()
+	defer v.Unlock()
+
+	if v.head == nil {
+		return 0, libcommon.Hash{}
+	}
+
+	return v.head.number, v.head.hash
+}
+
+// Finalized returns the number of the finalized block
+func (v *Validator) Finalized() uint64 {
+	v.Lock()
+	defer v.Unlock()
+
+	if v.finalized == nil {
+		return 0
+	}
+
+	return v.finalized.number
+}
@@ -0,0 +1,244 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	libcommon "github.com/ledgerwatch/erigon-lib/common"
+	"github.com/ledgerwatch/log/v3"
+	"github.com/urfave/cli/v2"
+
+	"github.com/ledgerwatch/erigon/core/vm"
+	"github.com/ledgerwatch/erigon/eth/tracers/logger"
+	"github.com/ledgerwatch/erigon/tests"
+)
+
+var (
+	BlockTestTraceFlag = cli.BoolFlag{
+		Name:  "trace",
+		Usage: "output the JSON traces of the transactions of the imported blocks to stderr",
+	}
+	BlockTestRunFlag = cli.StringFlag{
+		Name:  "run",
+		Usage: "regular expression selecting the tests to run by name",
+	}
+	FillOutputFlag = cli.StringFlag{
+		Name:  "output",
+		Usage: "directory where the fixtures are written",
+		Value: ".",
+	}
+)
+
+var blockTestCommand = cli.Command{
+	Action:    blockTestCmd,
+	Name:      "blocktest",
+	Usage:     "executes the given blockchain tests",
+	ArgsUsage: "<file|dir>",
+	Flags: []cli.Flag{
+		&BlockTestTraceFlag,
+		&BlockTestRunFlag,
+	},
+	Subcommands: []*cli.Command{
+		{
+			Action:    blockTestFillCmd,
+			Name:      "fill",
+			Usage:     "fills the given blockchain test sources into fixtures",
+			ArgsUsage: "<file|dir>",
+			Flags: []cli.Flag{
+				&FillOutputFlag,
+				&BlockTestRunFlag,
+			},
+		},
+	},
+}
+
+// BlocktestResult contains the result of running a blockchain test
+type BlocktestResult struct {
+	Name  string `json:"name"`
+	File  string `json:"file"`
+	Pass  bool   `json:"pass"`
+	Fork  string `json:"fork"`
+	Error string `json:"error,omitempty"`
+}
+
+func blockTestCmd(ctx *cli.Context) error {
+	if ctx.Args().Len() != 1 {
+		return errors.New("path to a test file or directory is required")
+	}
+	log.Root().SetHandler(log.LvlFilterHandler(log.LvlError, log.StderrHandler))
+	match, err := testNameMatcher(ctx)
+	if err != nil {
+		return err
+	}
+	files, err := jsonFiles(ctx.Args().First())
+	if err != nil {
+		return err
+	}
+
+	results := make([]BlocktestResult, 0)
+	for _, fname := range files {
+		blockTests, err := loadTests[tests.BlockTest](fname)
+		if err != nil {
+			return err
+		}
+		for _, name := range sortedNames(blockTests) {
+			if !match(name) {
+				continue
+			}
+			test := blockTests[name]
+			var tracer func(uint64, int, libcommon.Hash) vm.EVMLogger
+			if ctx.Bool(BlockTestTraceFlag.Name) {
+				tracer = func(uint64, int, libcommon.Hash) vm.EVMLogger {
+					return logger.NewJSONLogger(&logger.LogConfig{}, os.Stderr)
+				}
+			}
+			result := BlocktestResult{Name: name, File: fname, Fork: test.Network(), Pass: true}
+			if err := test.RunWithTracer(tracer); err != nil {
+				result.Pass, result.Error = false, err.Error()
+			}
+			results = append(results, result)
+		}
+	}
+
+	out, _ := json.MarshalIndent(results, "", "  ")
+	fmt.Println(string(out))
+	failed := 0
+	for _, result := range results {
+		if !result.Pass {
+			failed++
+		}
+	}
+	if failed > 0 {
+		return fmt.Errorf("%d of %d block tests failed", failed, len(results))
+	}
+	return nil
+}
+
+// blockTestFillCmd fills the tests of each source file into a fixture file of the output directory. The fixture of
+// fooFiller.json is written to foo.json.
+func blockTestFillCmd(ctx *cli.Context) error {
+	if ctx.Args().Len() != 1 {
+		return errors.New("path to a test source file or directory is required")
+	}
+	log.Root().SetHandler(log.LvlFilterHandler(log.LvlError, log.StderrHandler))
+	match, err := testNameMatcher(ctx)
+	if err != nil {
+		return err
+	}
+	files, err := jsonFiles(ctx.Args().First())
+	if err != nil {
+		return err
+	}
+	outDir := ctx.String(FillOutputFlag.Name)
+	if err := os.MkdirAll(outDir, 0755); err != nil {
+		return err
+	}
+
+	for _, fname := range files {
+		fillers, err := loadTests[tests.BlockTestFiller](fname)
+		if err != nil {
+			return err
+		}
+		fixtures := make(map[string]*tests.BlockTest, len(fillers))
+		for _, name := range sortedNames(fillers) {
+			if !match(name) {
+				continue
+			}
+			filler := fillers[name]
+			fixture, err := filler.Fill()
+			if err != nil {
+				return fmt.Errorf("filling %s of %s: %w", name, fname, err)
+			}
+			fixtures[name] = fixture
+		}
+		if len(fixtures) == 0 {
+			continue
+		}
+		outName := filepath.Join(outDir, strings.TrimSuffix(filepath.Base(fname), "Filler.json"))
+		outName = strings.TrimSuffix(outName, ".json") + ".json"
+		if same, _ := sameFile(fname, outName); same {
+			return fmt.Errorf("fixture of %s would overwrite it, use another output directory", fname)
+		}
+		out, err := json.MarshalIndent(fixtures, "", "  ")
+		if err != nil {
+			return err
+		}
+		if err := os.WriteFile(outName, out, 0644); err != nil { //nolint:gosec
+			return err
+		}
+		log.Info("Wrote fixture", "file", outName, "tests", len(fixtures))
+	}
+	return nil
+}
+
+func testNameMatcher(ctx *cli.Context) (func(name string) bool, error) {
+	if ctx.String(BlockTestRunFlag.Name) == "" {
+		return func(string) bool { return true }, nil
+	}
+	re, err := regexp.Compile(ctx.String(BlockTestRunFlag.Name))
+	if err != nil {
+		return nil, fmt.Errorf("invalid --%s: %w", BlockTestRunFlag.Name, err)
+	}
+	return re.MatchString, nil
+}
+
+// jsonFiles returns path if it is a file, or the JSON files below path if it is a directory
+func jsonFiles(path string) ([]string, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+	if !info.IsDir() {
+		return []string{path}, nil
+	}
+	var files []string
+	err = filepath.Walk(path, func(p string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if !info.IsDir() && filepath.Ext(p) == ".json" {
+			files = append(files, p)
+		}
+		return nil
+	})
+	return files, err
+}
+
+func loadTests[T any](fname string) (map[string]*T, error) {
+	src, err := os.ReadFile(fname)
+	if err != nil {
+		return nil, err
+	}
+	var loaded map[string]*T
+	if err := json.Unmarshal(src, &loaded); err != nil {
+		return nil, fmt.Errorf("decoding %s: %w", fname, err)
+	}
+	return loaded, nil
+}
+
+func sortedNames[T any](m map[string]T) []string {
+	names := make([]string, 0, len(m))
+	for name := range m {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func sameFile(a, b string) (bool, error) {
+	infoA, err := os.Stat(a)
+	if err != nil {
+		return false, err
+	}
+	infoB, err := os.Stat(b)
+	if err != nil {
+		return false, err
+	}
+	return os.SameFile(infoA, infoB), nil
+}
@@ -181,6 +181,7 @@ func init() {
 		&DisableReturnDataFlag,
 	}
 	app.Commands = []*cli.Command{
+		&blockTestCommand,
 		&compileCommand,
 		&disasmCommand,
 		&runCommand,
@@ -0,0 +1,32 @@
+package main
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/ledgerwatch/erigon/turbo/cmdtest"
+)
+
+func TestStateTestEOF(t *testing.T) {
+	for _, fixture := range []string{
+		"./testdata/eof/statetest_prague.json",
+		"./testdata/eof/statetest_shanghai.json",
+	} {
+		tt := new(testT8n)
+		tt.TestCmd = cmdtest.NewTestCmd(t, tt)
+		tt.Run("evm-test", "statetest", fixture)
+		var results []StatetestResult
+		if err := json.Unmarshal(tt.Output(), &results); err != nil {
+			t.Fatalf("%s: invalid output: %v", fixture, err)
+		}
+		tt.WaitExit()
+		if len(results) == 0 {
+			t.Fatalf("%s: no results", fixture)
+		}
+		for _, result := range results {
+			if !result.Pass {
+				t.Errorf("%s: %s/%s failed: %s", fixture, result.Name, result.Fork, result.Error)
+			}
+		}
+	}
+}
@@ -0,0 +1,94 @@
+package dashboard
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"net"
+	"net/netip"
+	"os"
+	"sort"
+	"strings"
+)
+
+type ipRange struct {
+	start netip.Addr
+	end   netip.Addr
+	value string
+}
+
+// IPRanges maps IP addresses to a value, like a country or an autonomous system.
+type IPRanges struct {
+	ranges []ipRange
+}
+
+// LoadIPRanges reads a CSV file of "start IP,end IP,value..." rows,
+// like the IP to country and IP to ASN databases of https://db-ip.com/db/lite.php
+// The columns after the range are joined by spaces into the value.
+func LoadIPRanges(filePath string) (*IPRanges, error) {
+	file, err := os.Open(filePath)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = file.Close() }()
+	ranges, err := ReadIPRanges(file)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read IP ranges from %s: %w", filePath, err)
+	}
+	return ranges, nil
+}
+
+func ReadIPRanges(reader io.Reader) (*IPRanges, error) {
+	csvReader := csv.NewReader(reader)
+	csvReader.FieldsPerRecord = -1
+	csvReader.ReuseRecord = true
+
+	var ranges []ipRange
+	for {
+		record, err := csvReader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		if len(record) < 3 {
+			return nil, fmt.Errorf("expected start IP, end IP and value, got %q", strings.Join(record, ","))
+		}
+		start, err := netip.ParseAddr(record[0])
+		if err != nil {
+			return nil, err
+		}
+		end, err := netip.ParseAddr(record[1])
+		if err != nil {
+			return nil, err
+		}
+		if (start.Is4() != end.Is4()) || (end.Less(start)) {
+			return nil, fmt.Errorf("invalid IP range %s - %s", start, end)
+		}
+		ranges = append(ranges, ipRange{start, end, strings.Join(record[2:], " ")})
+	}
+
+	sort.Slice(ranges, func(i, j int) bool {
+		return ranges[i].start.Less(ranges[j].start)
+	})
+	return &IPRanges{ranges}, nil
+}
+
+// Find returns the value of the range containing the IP, if any
+func (r *IPRanges) Find(ip net.IP) (string, bool) {
+	addr, ok := netip.AddrFromSlice(ip)
+	if !ok {
+		return "", false
+	}
+	addr = addr.Unmap()
+
+	// the last range starting before the IP
+	i := sort.Search(len(r.ranges), func(i int) bool {
+		return addr.Less(r.ranges[i].start)
+	}) - 1
+	if (i < 0) || r.ranges[i].end.Less(addr) {
+		return "", false
+	}
+	return r.ranges[i].value, true
+}
@@ -0,0 +1,41 @@
+package dashboard
+
+import (
+	"net"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestIPRanges(t *testing.T) {
+	csv := `1.0.4.0,1.0.7.255,AU
+1.0.0.0,1.0.0.255,AU
+2.16.0.0,2.16.255.255,13335,"Cloudflare, Inc."
+2001:200::,2001:200:ffff:ffff:ffff:ffff:ffff:ffff,JP
+`
+	ranges, err := ReadIPRanges(strings.NewReader(csv))
+	require.Nil(t, err)
+
+	for ip, expected := range map[string]string{
+		"1.0.0.0":     "AU",
+		"1.0.0.255":   "AU",
+		"1.0.5.1":     "AU",
+		"2.16.1.2":    "13335 Cloudflare, Inc.",
+		"2001:200::1": "JP",
+		"1.0.1.0":     "",
+		"0.0.0.1":     "",
+		"3.0.0.0":     "",
+		"2001:300::":  "",
+	} {
+		value, ok := ranges.Find(net.ParseIP(ip))
+		assert.Equal(t, expected != "", ok, ip)
+		assert.Equal(t, expected, value, ip)
+	}
+
+	_, err = ReadIPRanges(strings.NewReader("1.0.0.0,1.0.0.255\n"))
+	assert.NotNil(t, err)
+	_, err = ReadIPRanges(strings.NewReader("1.0.0.255,1.0.0.0,AU\n"))
+	assert.NotNil(t, err)
+}
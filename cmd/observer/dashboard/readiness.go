@@ -0,0 +1,133 @@
+package dashboard
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	libcommon "github.com/ledgerwatch/erigon-lib/common"
+
+	"github.com/ledgerwatch/erigon/cmd/observer/database"
+	"github.com/ledgerwatch/erigon/cmd/observer/observer"
+	"github.com/ledgerwatch/erigon/core/forkid"
+	"github.com/ledgerwatch/erigon/params"
+)
+
+// ForkReadiness counts the nodes by how their fork ID compares to the fork ID expected at a time.
+type ForkReadiness struct {
+	Time time.Time `json:"time"`
+	// ForkHash and ForkNext are the expected fork ID, ForkNext is the upcoming fork, or 0 if none is scheduled
+	ForkHash string `json:"forkHash"`
+	ForkNext uint64 `json:"forkNext"`
+
+	// Ready nodes have the expected fork ID
+	Ready uint `json:"ready"`
+	// NotReady nodes have the current fork hash, but don't know about the upcoming fork
+	NotReady uint `json:"notReady"`
+	// Stale nodes have the fork hash of a passed fork, they missed an upgrade or are not synced
+	Stale uint `json:"stale"`
+	// Other nodes have the current fork hash with another upcoming fork, or an unknown fork hash
+	Other uint `json:"other"`
+	// Unknown nodes have not reported their fork ID
+	Unknown uint `json:"unknown"`
+}
+
+// ForkSchedule is the fork schedule of a chain.
+// The block forks are considered passed, only the time forks depend on the time.
+type ForkSchedule struct {
+	heightForks []uint64
+	timeForks   []uint64
+	genesis     libcommon.Hash
+}
+
+func NewForkSchedule(heightForks, timeForks []uint64, genesis libcommon.Hash) *ForkSchedule {
+	return &ForkSchedule{heightForks, timeForks, genesis}
+}
+
+func NewForkScheduleOfChain(chain string) (*ForkSchedule, error) {
+	chainConfig := params.ChainConfigByChainName(chain)
+	genesisHash := params.GenesisHashByChainName(chain)
+	if (chainConfig == nil) || (genesisHash == nil) {
+		return nil, fmt.Errorf("unknown chain %s", chain)
+	}
+	heightForks, timeForks := forkid.GatherForks(chainConfig)
+	return NewForkSchedule(heightForks, timeForks, *genesisHash), nil
+}
+
+// ExpectedForkID is the fork ID of the synced nodes at the time
+func (schedule *ForkSchedule) ExpectedForkID(now time.Time) forkid.ID {
+	return forkid.NewIDFromForks(schedule.heightForks, schedule.timeForks, schedule.genesis, math.MaxUint64, uint64(now.Unix()))
+}
+
+// passedForkHashes are the fork hashes of the nodes which did not apply a passed fork
+func (schedule *ForkSchedule) passedForkHashes(now time.Time) map[string]struct{} {
+	hashes := make(map[string]struct{})
+	for _, fork := range schedule.heightForks {
+		id := forkid.NewIDFromForks(schedule.heightForks, schedule.timeForks, schedule.genesis, fork-1, 0)
+		hashes[observer.ForkHashString(id)] = struct{}{}
+	}
+	for _, fork := range schedule.timeForks {
+		if fork <= uint64(now.Unix()) {
+			id := forkid.NewIDFromForks(schedule.heightForks, schedule.timeForks, schedule.genesis, math.MaxUint64, fork-1)
+			hashes[observer.ForkHashString(id)] = struct{}{}
+		}
+	}
+	return hashes
+}
+
+// Readiness computes the fork readiness of each time of a StatsKindFork time series
+func (schedule *ForkSchedule) Readiness(series []database.Stats) ([]ForkReadiness, error) {
+	var readiness []ForkReadiness
+	var passed map[string]struct{}
+	for _, stats := range series {
+		if stats.Kind != StatsKindFork {
+			return nil, fmt.Errorf("expected %s stats, got %s", StatsKindFork, stats.Kind)
+		}
+		if (len(readiness) == 0) || !readiness[len(readiness)-1].Time.Equal(stats.Time) {
+			expected := schedule.ExpectedForkID(stats.Time)
+			readiness = append(readiness, ForkReadiness{
+				Time:     stats.Time,
+				ForkHash: observer.ForkHashString(expected),
+				ForkNext: expected.Next,
+			})
+			passed = schedule.passedForkHashes(stats.Time)
+		}
+		entry := &readiness[len(readiness)-1]
+
+		if stats.Key == StatsKeyUnknown {
+			entry.Unknown += stats.Count
+			continue
+		}
+		hash, next, err := parseForkKey(stats.Key)
+		if err != nil {
+			return nil, err
+		}
+		switch {
+		case (hash == entry.ForkHash) && (next == entry.ForkNext):
+			entry.Ready += stats.Count
+		case (hash == entry.ForkHash) && (next == 0):
+			entry.NotReady += stats.Count
+		default:
+			if _, ok := passed[hash]; ok {
+				entry.Stale += stats.Count
+			} else {
+				entry.Other += stats.Count
+			}
+		}
+	}
+	return readiness, nil
+}
+
+func parseForkKey(key string) (string, uint64, error) {
+	hash, nextStr, ok := strings.Cut(key, "/")
+	if !ok {
+		return "", 0, fmt.Errorf("invalid fork ID %q", key)
+	}
+	next, err := strconv.ParseUint(nextStr, 10, 64)
+	if err != nil {
+		return "", 0, fmt.Errorf("invalid fork ID %q: %w", key, err)
+	}
+	return hash, next, nil
+}
@@ -0,0 +1,65 @@
+package dashboard
+
+import (
+	"fmt"
+	"math"
+	"testing"
+	"time"
+
+	libcommon "github.com/ledgerwatch/erigon-lib/common"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ledgerwatch/erigon/cmd/observer/database"
+	"github.com/ledgerwatch/erigon/cmd/observer/observer"
+	"github.com/ledgerwatch/erigon/core/forkid"
+)
+
+func TestForkReadiness(t *testing.T) {
+	heightForks := []uint64{10, 20}
+	timeForks := []uint64{1000, 2000}
+	genesis := libcommon.Hash{1}
+	schedule := NewForkSchedule(heightForks, timeForks, genesis)
+
+	forkKey := func(headTime uint64, next uint64) string {
+		id := forkid.NewIDFromForks(heightForks, timeForks, genesis, math.MaxUint64, headTime)
+		return fmt.Sprintf("%s/%d", observer.ForkHashString(id), next)
+	}
+
+	now := time.Unix(1500, 0)
+	later := time.Unix(2500, 0)
+	series := []database.Stats{
+		{Time: now, Kind: StatsKindFork, Key: forkKey(1500, 2000), Count: 5},
+		{Time: now, Kind: StatsKindFork, Key: forkKey(1500, 0), Count: 4},
+		{Time: now, Kind: StatsKindFork, Key: forkKey(999, 1000), Count: 3},
+		{Time: now, Kind: StatsKindFork, Key: forkKey(1500, 3000), Count: 2},
+		{Time: now, Kind: StatsKindFork, Key: "deadbeef/0", Count: 1},
+		{Time: now, Kind: StatsKindFork, Key: StatsKeyUnknown, Count: 6},
+		// after the fork the nodes which were not ready are stale
+		{Time: later, Kind: StatsKindFork, Key: forkKey(2500, 0), Count: 5},
+		{Time: later, Kind: StatsKindFork, Key: forkKey(1500, 0), Count: 4},
+	}
+
+	readiness, err := schedule.Readiness(series)
+	require.Nil(t, err)
+	require.Equal(t, 2, len(readiness))
+
+	expected := forkid.NewIDFromForks(heightForks, timeForks, genesis, math.MaxUint64, 1500)
+	assert.Equal(t, ForkReadiness{
+		Time:     now,
+		ForkHash: observer.ForkHashString(expected),
+		ForkNext: 2000,
+		Ready:    5,
+		NotReady: 4,
+		Stale:    3,
+		Other:    3,
+		Unknown:  6,
+	}, readiness[0])
+
+	assert.Equal(t, uint64(0), readiness[1].ForkNext)
+	assert.Equal(t, uint(5), readiness[1].Ready)
+	assert.Equal(t, uint(4), readiness[1].Stale)
+
+	_, err = schedule.Readiness([]database.Stats{{Time: now, Kind: StatsKindFork, Key: "deadbeef"}})
+	assert.NotNil(t, err)
+}
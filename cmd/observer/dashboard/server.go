@@ -0,0 +1,235 @@
+package dashboard
+
+import (
+	"context"
+	_ "embed"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/ledgerwatch/erigon/cmd/observer/database"
+	"github.com/ledgerwatch/log/v3"
+)
+
+//go:embed dashboard.html
+var dashboardHTML []byte
+
+// Server serves the dashboard page, and the recorded time series as JSON and CSV:
+//
+//	GET /api/stats?kind=client[&from=<unix time>][&to=<unix time>]
+//	GET /api/stats.csv?kind=client[&from=<unix time>][&to=<unix time>]
+//	GET /api/readiness[?from=<unix time>][&to=<unix time>]
+//	GET /api/readiness.csv[?from=<unix time>][&to=<unix time>]
+type Server struct {
+	db       database.DB
+	schedule *ForkSchedule
+	log      log.Logger
+}
+
+// StatsPoint is the counts of a time series kind at a time
+type StatsPoint struct {
+	Time   time.Time       `json:"time"`
+	Counts map[string]uint `json:"counts"`
+}
+
+func NewServer(db database.DB, chain string, logger log.Logger) (*Server, error) {
+	schedule, err := NewForkScheduleOfChain(chain)
+	if err != nil {
+		return nil, err
+	}
+
+	instance := Server{
+		db,
+		schedule,
+		logger,
+	}
+	return &instance, nil
+}
+
+func (server *Server) Handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/", server.serveDashboard)
+	mux.HandleFunc("/api/stats", server.serveStats)
+	mux.HandleFunc("/api/stats.csv", server.serveStatsCSV)
+	mux.HandleFunc("/api/readiness", server.serveReadiness)
+	mux.HandleFunc("/api/readiness.csv", server.serveReadinessCSV)
+	return mux
+}
+
+// ListenAndServe serves the dashboard until the context is done
+func (server *Server) ListenAndServe(ctx context.Context, addr string) error {
+	listener, err := net.Listen("tcp", addr)
+	if err != nil {
+		return fmt.Errorf("failed to listen on %s: %w", addr, err)
+	}
+	httpServer := &http.Server{
+		Handler:           server.Handler(),
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+	go func() {
+		<-ctx.Done()
+		_ = httpServer.Close()
+	}()
+
+	server.log.Info("Serving the dashboard", "url", "http://"+listener.Addr().String())
+	err = httpServer.Serve(listener)
+	if errors.Is(err, http.ErrServerClosed) {
+		return ctx.Err()
+	}
+	return err
+}
+
+func (server *Server) serveDashboard(w http.ResponseWriter, r *http.Request) {
+	if r.URL.Path != "/" {
+		http.NotFound(w, r)
+		return
+	}
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	_, _ = w.Write(dashboardHTML)
+}
+
+func (server *Server) findStats(w http.ResponseWriter, r *http.Request, kind string) ([]database.Stats, bool) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return nil, false
+	}
+	from, err := parseTimeParam(r, "from", time.Unix(0, 0))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return nil, false
+	}
+	to, err := parseTimeParam(r, "to", time.Now())
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return nil, false
+	}
+	stats, err := server.db.FindStats(r.Context(), kind, from, to)
+	if err != nil {
+		server.log.Warn("Failed to find stats", "kind", kind, "err", err)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return nil, false
+	}
+	return stats, true
+}
+
+func (server *Server) statsKind(w http.ResponseWriter, r *http.Request) (string, bool) {
+	kind := r.URL.Query().Get("kind")
+	for _, known := range StatsKinds {
+		if kind == known {
+			return kind, true
+		}
+	}
+	http.Error(w, fmt.Sprintf("unknown kind %q, expected one of %v", kind, StatsKinds), http.StatusBadRequest)
+	return "", false
+}
+
+func (server *Server) serveStats(w http.ResponseWriter, r *http.Request) {
+	kind, ok := server.statsKind(w, r)
+	if !ok {
+		return
+	}
+	stats, ok := server.findStats(w, r, kind)
+	if !ok {
+		return
+	}
+
+	points := make([]StatsPoint, 0)
+	for _, entry := range stats {
+		if (len(points) == 0) || !points[len(points)-1].Time.Equal(entry.Time) {
+			points = append(points, StatsPoint{entry.Time, make(map[string]uint)})
+		}
+		points[len(points)-1].Counts[entry.Key] = entry.Count
+	}
+	writeJSON(w, points)
+}
+
+func (server *Server) serveStatsCSV(w http.ResponseWriter, r *http.Request) {
+	kind, ok := server.statsKind(w, r)
+	if !ok {
+		return
+	}
+	stats, ok := server.findStats(w, r, kind)
+	if !ok {
+		return
+	}
+
+	rows := [][]string{{"time", kind, "count"}}
+	for _, entry := range stats {
+		rows = append(rows, []string{entry.Time.UTC().Format(time.RFC3339), entry.Key, strconv.FormatUint(uint64(entry.Count), 10)})
+	}
+	writeCSV(w, kind, rows)
+}
+
+func (server *Server) readiness(w http.ResponseWriter, r *http.Request) ([]ForkReadiness, bool) {
+	stats, ok := server.findStats(w, r, StatsKindFork)
+	if !ok {
+		return nil, false
+	}
+	readiness, err := server.schedule.Readiness(stats)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return nil, false
+	}
+	return readiness, true
+}
+
+func (server *Server) serveReadiness(w http.ResponseWriter, r *http.Request) {
+	readiness, ok := server.readiness(w, r)
+	if !ok {
+		return
+	}
+	if readiness == nil {
+		readiness = make([]ForkReadiness, 0)
+	}
+	writeJSON(w, readiness)
+}
+
+func (server *Server) serveReadinessCSV(w http.ResponseWriter, r *http.Request) {
+	readiness, ok := server.readiness(w, r)
+	if !ok {
+		return
+	}
+
+	rows := [][]string{{"time", "fork_hash", "fork_next", "ready", "not_ready", "stale", "other", "unknown"}}
+	for _, entry := range readiness {
+		rows = append(rows, []string{
+			entry.Time.UTC().Format(time.RFC3339),
+			entry.ForkHash,
+			strconv.FormatUint(entry.ForkNext, 10),
+			strconv.FormatUint(uint64(entry.Ready), 10),
+			strconv.FormatUint(uint64(entry.NotReady), 10),
+			strconv.FormatUint(uint64(entry.Stale), 10),
+			strconv.FormatUint(uint64(entry.Other), 10),
+			strconv.FormatUint(uint64(entry.Unknown), 10),
+		})
+	}
+	writeCSV(w, "readiness", rows)
+}
+
+func parseTimeParam(r *http.Request, name string, defaultValue time.Time) (time.Time, error) {
+	value := r.URL.Query().Get(name)
+	if value == "" {
+		return defaultValue, nil
+	}
+	timestamp, err := strconv.ParseInt(value, 10, 64)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid %s unix time %q", name, value)
+	}
+	return time.Unix(timestamp, 0), nil
+}
+
+func writeJSON(w http.ResponseWriter, value interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(value)
+}
+
+func writeCSV(w http.ResponseWriter, name string, rows [][]string) {
+	w.Header().Set("Content-Type", "text/csv")
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", name+".csv"))
+	_ = csv.NewWriter(w).WriteAll(rows)
+}
@@ -0,0 +1,172 @@
+package dashboard
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"sort"
+	"time"
+
+	"github.com/ledgerwatch/erigon/cmd/observer/database"
+	"github.com/ledgerwatch/erigon/cmd/observer/observer"
+	"github.com/ledgerwatch/erigon/cmd/observer/utils"
+	"github.com/ledgerwatch/log/v3"
+)
+
+// Kinds of the recorded time series
+const (
+	// StatsKindClient counts the nodes by client name
+	StatsKindClient = "client"
+	// StatsKindFork counts the nodes by fork ID, as "<fork hash>/<next fork>"
+	StatsKindFork = "fork"
+	// StatsKindNetwork counts the nodes by autonomous system if the ASN ranges are known, otherwise by IP subnet
+	StatsKindNetwork = "network"
+	// StatsKindCountry counts the nodes by country, if the country ranges are known
+	StatsKindCountry = "country"
+)
+
+var StatsKinds = []string{StatsKindClient, StatsKindFork, StatsKindNetwork, StatsKindCountry}
+
+const (
+	// StatsKeyUnknown groups the nodes without a value
+	StatsKeyUnknown = "unknown"
+	// StatsKeyOthers groups the nodes beyond the top groups of a kind
+	StatsKeyOthers = "others"
+
+	// statsTopLimit is the number of groups kept for each kind, except the fork IDs which are few
+	statsTopLimit = 20
+)
+
+type StatsConfig struct {
+	NetworkID    uint
+	MaxPingTries uint
+	// ASNs and Countries map the node IPs to their autonomous system and country, they are optional
+	ASNs      *IPRanges
+	Countries *IPRanges
+}
+
+// CreateStats counts the alive nodes of the network by client, fork ID, network and country
+func CreateStats(ctx context.Context, db database.DB, config StatsConfig, now time.Time) ([]database.Stats, error) {
+	counts := make(map[string]map[string]uint)
+	for _, kind := range StatsKinds {
+		counts[kind] = make(map[string]uint)
+	}
+
+	enumFunc := func(node database.NodeStats) {
+		if (node.ClientID != nil) && observer.IsClientIDBlacklisted(*node.ClientID) {
+			return
+		}
+
+		clientName := StatsKeyUnknown
+		if node.ClientID != nil {
+			clientName = observer.NameFromClientID(*node.ClientID)
+		}
+		counts[StatsKindClient][clientName]++
+
+		fork := StatsKeyUnknown
+		if node.ForkHash != nil {
+			fork = fmt.Sprintf("%s/%d", *node.ForkHash, *node.ForkNext)
+		}
+		counts[StatsKindFork][fork]++
+
+		ip := node.IP
+		if ip == nil {
+			ip = node.IPv6
+		}
+		counts[StatsKindNetwork][networkOf(ip, config.ASNs)]++
+		if config.Countries != nil {
+			country, ok := config.Countries.Find(ip)
+			if !ok {
+				country = StatsKeyUnknown
+			}
+			counts[StatsKindCountry][country]++
+		}
+	}
+	if err := db.EnumerateNodeStats(ctx, config.MaxPingTries, config.NetworkID, enumFunc); err != nil {
+		return nil, err
+	}
+
+	var stats []database.Stats
+	for _, kind := range StatsKinds {
+		limit := statsTopLimit
+		if kind == StatsKindFork {
+			limit = len(counts[kind])
+		}
+		for key, count := range topCounts(counts[kind], limit) {
+			stats = append(stats, database.Stats{
+				Time:  now,
+				Kind:  kind,
+				Key:   key,
+				Count: count,
+			})
+		}
+	}
+	return stats, nil
+}
+
+func networkOf(ip net.IP, asns *IPRanges) string {
+	if ip == nil {
+		return StatsKeyUnknown
+	}
+	if asns != nil {
+		asn, ok := asns.Find(ip)
+		if !ok {
+			return StatsKeyUnknown
+		}
+		return asn
+	}
+	if ip4 := ip.To4(); ip4 != nil {
+		return (&net.IPNet{IP: ip4.Mask(net.CIDRMask(16, 32)), Mask: net.CIDRMask(16, 32)}).String()
+	}
+	return (&net.IPNet{IP: ip.Mask(net.CIDRMask(32, 128)), Mask: net.CIDRMask(32, 128)}).String()
+}
+
+// topCounts keeps the limit largest counts, and sums the rest as StatsKeyOthers
+func topCounts(counts map[string]uint, limit int) map[string]uint {
+	if len(counts) <= limit {
+		return counts
+	}
+	keys := make([]string, 0, len(counts))
+	for key := range counts {
+		keys = append(keys, key)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if counts[keys[i]] != counts[keys[j]] {
+			return counts[keys[i]] > counts[keys[j]]
+		}
+		return keys[i] < keys[j]
+	})
+
+	top := make(map[string]uint, limit+1)
+	for i, key := range keys {
+		if i < limit {
+			top[key] = counts[key]
+		} else {
+			top[StatsKeyOthers] += counts[key]
+		}
+	}
+	return top
+}
+
+// StatsLoop periodically records the network statistics
+func StatsLoop(ctx context.Context, db database.DB, config StatsConfig, period time.Duration, logger log.Logger) {
+	for ctx.Err() == nil {
+		utils.Sleep(ctx, period)
+		if ctx.Err() != nil {
+			break
+		}
+
+		stats, err := CreateStats(ctx, db, config, time.Now())
+		if err == nil {
+			err = db.InsertStats(ctx, stats)
+		}
+		if err != nil {
+			if !errors.Is(err, context.Canceled) {
+				logger.Error("Failed to record stats", "err", err)
+			}
+			continue
+		}
+		logger.Debug("Recorded stats", "count", len(stats))
+	}
+}
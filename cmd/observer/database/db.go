@@ -25,6 +25,33 @@ type HandshakeError struct {
 	Time       time.Time
 }
 
+// NodeStats is the handshake result of a node, used to compute the network statistics
+type NodeStats struct {
+	ClientID *string
+	IP       net.IP
+	IPv6     net.IP
+	ForkHash *string
+	ForkNext *uint64
+}
+
+// NodeENR is the signed node record of a live node
+type NodeENR struct {
+	ID       NodeID
+	ENR      string
+	Updated  time.Time
+	ClientID *string
+	ForkHash *string
+	ForkNext *uint64
+}
+
+// Stats is the count of the nodes of a group at a time, the groups of a kind make a time series.
+type Stats struct {
+	Time  time.Time
+	Kind  string
+	Key   string
+	Count uint
+}
+
 type DB interface {
 	io.Closer
 
@@ -52,6 +79,11 @@ type DB interface {
 	TakeHandshakeCandidates(ctx context.Context, limit uint) ([]NodeID, error)
 
 	UpdateForkCompatibility(ctx context.Context, id NodeID, isCompatFork bool) error
+	UpdateForkID(ctx context.Context, id NodeID, forkHash string, forkNext uint64) error
+
+	UpdateENR(ctx context.Context, id NodeID, enr string) error
+	// FindLiveNodeENRs returns the records of the nodes which replied to the last PING, updated since the given time, the most recent first.
+	FindLiveNodeENRs(ctx context.Context, updatedSince time.Time, networkID uint) ([]NodeENR, error)
 
 	UpdateNeighborBucketKeys(ctx context.Context, id NodeID, keys []string) error
 	FindNeighborBucketKeys(ctx context.Context, id NodeID) ([]string, error)
@@ -74,4 +106,8 @@ type DB interface {
 	CountClientsWithNetworkID(ctx context.Context, clientIDPrefix string, maxPingTries uint) (uint, error)
 	CountClientsWithHandshakeTransientError(ctx context.Context, clientIDPrefix string, maxPingTries uint) (uint, error)
 	EnumerateClientIDs(ctx context.Context, maxPingTries uint, networkID uint, enumFunc func(clientID *string)) error
+	EnumerateNodeStats(ctx context.Context, maxPingTries uint, networkID uint, enumFunc func(stats NodeStats)) error
+
+	InsertStats(ctx context.Context, stats []Stats) error
+	FindStats(ctx context.Context, kind string, from time.Time, to time.Time) ([]Stats, error)
 }
@@ -37,6 +37,11 @@ CREATE TABLE IF NOT EXISTS nodes (
 
     compat_fork INTEGER,
     compat_fork_updated INTEGER,
+    fork_hash TEXT,
+    fork_next INTEGER,
+
+    enr TEXT,
+    enr_updated INTEGER,
 
     client_id TEXT,
     network_id INTEGER,
@@ -56,6 +61,13 @@ CREATE TABLE IF NOT EXISTS handshake_errors (
     updated INTEGER NOT NULL
 );
 
+CREATE TABLE IF NOT EXISTS stats (
+    time INTEGER NOT NULL,
+    kind TEXT NOT NULL,
+    key TEXT NOT NULL,
+    count INTEGER NOT NULL
+);
+
 CREATE TABLE IF NOT EXISTS sentry_candidates_intake (
     id INTEGER PRIMARY KEY,
     last_event_time INTEGER NOT NULL
@@ -69,6 +81,15 @@ CREATE INDEX IF NOT EXISTS idx_nodes_compat_fork ON nodes (compat_fork);
 CREATE INDEX IF NOT EXISTS idx_nodes_network_id ON nodes (network_id);
 CREATE INDEX IF NOT EXISTS idx_nodes_handshake_retry_time ON nodes (handshake_retry_time);
 CREATE INDEX IF NOT EXISTS idx_handshake_errors_id ON handshake_errors (id);
+CREATE INDEX IF NOT EXISTS idx_stats_kind_time ON stats (kind, time);
+`
+
+	// columns added after the initial schema, for the databases created before them
+	sqlMigrateSchema = `
+ALTER TABLE nodes ADD COLUMN fork_hash TEXT;
+ALTER TABLE nodes ADD COLUMN fork_next INTEGER;
+ALTER TABLE nodes ADD COLUMN enr TEXT;
+ALTER TABLE nodes ADD COLUMN enr_updated INTEGER;
 `
 
 	sqlUpsertNodeAddr = `
@@ -195,6 +216,24 @@ UPDATE nodes SET handshake_retry_time = ? WHERE id IN (123)
 
 	sqlUpdateForkCompatibility = `
 UPDATE nodes SET compat_fork = ?, compat_fork_updated = ? WHERE id = ?
+`
+
+	sqlUpdateForkID = `
+UPDATE nodes SET fork_hash = ?, fork_next = ? WHERE id = ?
+`
+
+	sqlUpdateENR = `
+UPDATE nodes SET enr = ?, enr_updated = ? WHERE id = ?
+`
+
+	sqlFindLiveNodeENRs = `
+SELECT id, enr, enr_updated, client_id, fork_hash, fork_next FROM nodes
+WHERE (ping_try = 0)
+    AND (enr IS NOT NULL)
+    AND (enr_updated >= ?)
+    AND ((network_id = ?) OR (network_id IS NULL))
+    AND ((compat_fork == TRUE) OR (compat_fork IS NULL))
+ORDER BY enr_updated DESC
 `
 
 	sqlUpdateNeighborBucketKeys = `
@@ -284,6 +323,30 @@ SELECT client_id FROM nodes
 WHERE (ping_try < ?)
     AND ((network_id = ?) OR (network_id IS NULL))
     AND ((compat_fork == TRUE) OR (compat_fork IS NULL))
+`
+
+	sqlEnumerateNodeStats = `
+SELECT client_id, ip, ip_v6, fork_hash, fork_next FROM nodes
+WHERE (ping_try < ?)
+    AND ((network_id = ?) OR (network_id IS NULL))
+    AND ((compat_fork == TRUE) OR (compat_fork IS NULL))
+`
+
+	sqlInsertStats = `
+INSERT INTO stats(
+	time,
+	kind,
+	key,
+	count
+) VALUES (?, ?, ?, ?)
+`
+
+	sqlFindStats = `
+SELECT time, key, count FROM stats
+WHERE (kind = ?)
+	AND (time >= ?)
+	AND (time <= ?)
+ORDER BY time, count DESC
 `
 )
 
@@ -298,6 +361,13 @@ func NewDBSQLite(filePath string) (*DBSQLite, error) {
 		return nil, fmt.Errorf("failed to create the DB schema: %w", err)
 	}
 
+	for _, statement := range strings.Split(strings.TrimSpace(sqlMigrateSchema), "\n") {
+		_, err = db.Exec(statement)
+		if (err != nil) && !strings.Contains(err.Error(), "duplicate column name") {
+			return nil, fmt.Errorf("failed to migrate the DB schema: %w", err)
+		}
+	}
+
 	instance := DBSQLite{db}
 	return &instance, nil
 }
@@ -685,6 +755,68 @@ func (db *DBSQLite) UpdateForkCompatibility(ctx context.Context, id NodeID, isCo
 	return nil
 }
 
+func (db *DBSQLite) UpdateForkID(ctx context.Context, id NodeID, forkHash string, forkNext uint64) error {
+	_, err := db.db.ExecContext(ctx, sqlUpdateForkID, forkHash, forkNext, id)
+	if err != nil {
+		return fmt.Errorf("UpdateForkID failed to update a node: %w", err)
+	}
+	return nil
+}
+
+func (db *DBSQLite) UpdateENR(ctx context.Context, id NodeID, enr string) error {
+	updated := time.Now().Unix()
+
+	_, err := db.db.ExecContext(ctx, sqlUpdateENR, enr, updated, id)
+	if err != nil {
+		return fmt.Errorf("UpdateENR failed to update a node: %w", err)
+	}
+	return nil
+}
+
+func (db *DBSQLite) FindLiveNodeENRs(ctx context.Context, updatedSince time.Time, networkID uint) ([]NodeENR, error) {
+	cursor, err := db.db.QueryContext(ctx, sqlFindLiveNodeENRs, updatedSince.Unix(), networkID)
+	if err != nil {
+		return nil, fmt.Errorf("FindLiveNodeENRs failed to query: %w", err)
+	}
+	defer func() {
+		_ = cursor.Close()
+	}()
+
+	var nodes []NodeENR
+	for cursor.Next() {
+		var id string
+		var enr string
+		var updatedTimestamp int64
+		var clientID sql.NullString
+		var forkHash sql.NullString
+		var forkNext sql.NullInt64
+		err := cursor.Scan(&id, &enr, &updatedTimestamp, &clientID, &forkHash, &forkNext)
+		if err != nil {
+			return nil, fmt.Errorf("FindLiveNodeENRs failed to read data: %w", err)
+		}
+
+		node := NodeENR{
+			ID:      NodeID(id),
+			ENR:     enr,
+			Updated: time.Unix(updatedTimestamp, 0),
+		}
+		if clientID.Valid {
+			node.ClientID = &clientID.String
+		}
+		if forkHash.Valid && forkNext.Valid {
+			value := uint64(forkNext.Int64)
+			node.ForkHash = &forkHash.String
+			node.ForkNext = &value
+		}
+		nodes = append(nodes, node)
+	}
+
+	if err := cursor.Err(); err != nil {
+		return nil, fmt.Errorf("FindLiveNodeENRs failed to iterate: %w", err)
+	}
+	return nodes, nil
+}
+
 func (db *DBSQLite) UpdateNeighborBucketKeys(ctx context.Context, id NodeID, keys []string) error {
 	keysStr := strings.Join(keys, ",")
 
@@ -920,6 +1052,109 @@ func (db *DBSQLite) EnumerateClientIDs(
 	return nil
 }
 
+func (db *DBSQLite) EnumerateNodeStats(
+	ctx context.Context,
+	maxPingTries uint,
+	networkID uint,
+	enumFunc func(stats NodeStats),
+) error {
+	cursor, err := db.db.QueryContext(ctx, sqlEnumerateNodeStats, maxPingTries, networkID)
+	if err != nil {
+		return fmt.Errorf("EnumerateNodeStats failed to query: %w", err)
+	}
+	defer func() {
+		_ = cursor.Close()
+	}()
+
+	for cursor.Next() {
+		var clientID sql.NullString
+		var ip sql.NullString
+		var ipV6 sql.NullString
+		var forkHash sql.NullString
+		var forkNext sql.NullInt64
+		err := cursor.Scan(&clientID, &ip, &ipV6, &forkHash, &forkNext)
+		if err != nil {
+			return fmt.Errorf("EnumerateNodeStats failed to read data: %w", err)
+		}
+
+		var stats NodeStats
+		if clientID.Valid {
+			stats.ClientID = &clientID.String
+		}
+		if ip.Valid {
+			stats.IP = net.ParseIP(ip.String)
+		}
+		if ipV6.Valid {
+			stats.IPv6 = net.ParseIP(ipV6.String)
+		}
+		if forkHash.Valid && forkNext.Valid {
+			value := uint64(forkNext.Int64)
+			stats.ForkHash = &forkHash.String
+			stats.ForkNext = &value
+		}
+		enumFunc(stats)
+	}
+
+	if err := cursor.Err(); err != nil {
+		return fmt.Errorf("EnumerateNodeStats failed to iterate: %w", err)
+	}
+	return nil
+}
+
+func (db *DBSQLite) InsertStats(ctx context.Context, stats []Stats) error {
+	tx, err := db.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("InsertStats failed to start transaction: %w", err)
+	}
+
+	for _, entry := range stats {
+		_, err := tx.ExecContext(ctx, sqlInsertStats, entry.Time.Unix(), entry.Kind, entry.Key, entry.Count)
+		if err != nil {
+			_ = tx.Rollback()
+			return fmt.Errorf("InsertStats failed: %w", err)
+		}
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return fmt.Errorf("InsertStats failed to commit transaction: %w", err)
+	}
+	return nil
+}
+
+func (db *DBSQLite) FindStats(ctx context.Context, kind string, from time.Time, to time.Time) ([]Stats, error) {
+	cursor, err := db.db.QueryContext(ctx, sqlFindStats, kind, from.Unix(), to.Unix())
+	if err != nil {
+		return nil, fmt.Errorf("FindStats failed to query: %w", err)
+	}
+	defer func() {
+		_ = cursor.Close()
+	}()
+
+	var stats []Stats
+	for cursor.Next() {
+		var timestamp int64
+		var key string
+		var count uint
+		err := cursor.Scan(&timestamp, &key, &count)
+		if err != nil {
+			return nil, fmt.Errorf("FindStats failed to read data: %w", err)
+		}
+
+		stats = append(stats, Stats{
+			time.Unix(timestamp, 0),
+			kind,
+			key,
+			count,
+		})
+	}
+
+	if err := cursor.Err(); err != nil {
+		return nil, fmt.Errorf("FindStats failed to iterate: %w", err)
+	}
+	return stats, nil
+}
+
 func stringsToAny(strValues []NodeID) []interface{} {
 	values := make([]interface{}, 0, len(strValues))
 	for _, value := range strValues {
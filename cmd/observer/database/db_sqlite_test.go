@@ -5,6 +5,7 @@ import (
 	"net"
 	"path/filepath"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -39,3 +40,49 @@ func TestDBSQLiteInsertAndFind(t *testing.T) {
 	assert.Equal(t, addr.PortDisc, candidate.PortDisc)
 	assert.Equal(t, addr.PortRLPx, candidate.PortRLPx)
 }
+
+func TestDBSQLiteStats(t *testing.T) {
+	ctx := context.Background()
+	db, err := NewDBSQLite(filepath.Join(t.TempDir(), "observer.sqlite"))
+	require.Nil(t, err)
+	defer func() { _ = db.Close() }()
+
+	var id NodeID = "ba85011c70bcc5c04d8607d3a0ed29aa6179c092cbdda10d5d32684fb33ed01bd94f588ca8f91ac48318087dcb02eaf36773a7a453f0eedd6742af668097b29c"
+	var addr NodeAddr
+	addr.IP = net.ParseIP("10.0.1.16")
+	require.Nil(t, db.UpsertNodeAddr(ctx, id, addr))
+	require.Nil(t, db.UpdateClientID(ctx, id, "erigon/v2.48.0"))
+	require.Nil(t, db.UpdateForkID(ctx, id, "f0afd0e3", 1705473120))
+
+	var nodes []NodeStats
+	err = db.EnumerateNodeStats(ctx, 3, 1, func(stats NodeStats) {
+		nodes = append(nodes, stats)
+	})
+	require.Nil(t, err)
+	require.Equal(t, 1, len(nodes))
+	assert.Equal(t, "erigon/v2.48.0", *nodes[0].ClientID)
+	assert.Equal(t, "f0afd0e3", *nodes[0].ForkHash)
+	assert.Equal(t, uint64(1705473120), *nodes[0].ForkNext)
+	assert.Equal(t, addr.IP.String(), nodes[0].IP.String())
+
+	start := time.Unix(1690000000, 0)
+	err = db.InsertStats(ctx, []Stats{
+		{start, "client", "erigon", 1},
+		{start, "client", "geth", 2},
+		{start.Add(time.Hour), "client", "geth", 3},
+		{start, "fork", "f0afd0e3/0", 4},
+	})
+	require.Nil(t, err)
+
+	stats, err := db.FindStats(ctx, "client", start, start.Add(time.Hour))
+	require.Nil(t, err)
+	assert.Equal(t, []Stats{
+		{start, "client", "geth", 2},
+		{start, "client", "erigon", 1},
+		{start.Add(time.Hour), "client", "geth", 3},
+	}, stats)
+
+	stats, err = db.FindStats(ctx, "client", start.Add(time.Minute), start.Add(2*time.Hour))
+	require.Nil(t, err)
+	assert.Equal(t, 1, len(stats))
+}
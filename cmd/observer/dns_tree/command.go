@@ -0,0 +1,180 @@
+package dns_tree
+
+import (
+	"context"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/urfave/cli/v2"
+
+	"github.com/ledgerwatch/erigon/cmd/utils"
+)
+
+type CommandFlags struct {
+	DataDir string
+	Chain   string
+
+	Domain  string
+	KeyFile string
+	Seq     uint
+	Links   []string
+
+	MaxAge        time.Duration
+	MaxNodes      uint
+	Clients       []string
+	ForkReadyOnly bool
+
+	ZoneFilePath string
+	TXTFilePath  string
+	PublishDir   string
+}
+
+type Command struct {
+	command cobra.Command
+	flags   CommandFlags
+}
+
+func NewCommand() *Command {
+	command := cobra.Command{
+		Use:   "dns",
+		Short: "Make a signed EIP-1459 DNS discovery tree of the live nodes",
+	}
+
+	instance := Command{
+		command: command,
+	}
+	instance.withDatadir()
+	instance.withChain()
+	instance.withDomain()
+	instance.withKeyFile()
+	instance.withSeq()
+	instance.withLinks()
+	instance.withMaxAge()
+	instance.withMaxNodes()
+	instance.withClients()
+	instance.withForkReadyOnly()
+	instance.withZoneFilePath()
+	instance.withTXTFilePath()
+	instance.withPublishDir()
+
+	return &instance
+}
+
+func (command *Command) withDatadir() {
+	flag := utils.DataDirFlag
+	command.command.Flags().StringVar(&command.flags.DataDir, flag.Name, flag.Value.String(), flag.Usage)
+	must(command.command.MarkFlagDirname(utils.DataDirFlag.Name))
+}
+
+func (command *Command) withChain() {
+	flag := utils.ChainFlag
+	command.command.Flags().StringVar(&command.flags.Chain, flag.Name, flag.Value, flag.Usage)
+}
+
+func (command *Command) withDomain() {
+	flag := cli.StringFlag{
+		Name:  "domain",
+		Usage: "Domain name of the tree, e.g. all.mainnet.example.org",
+	}
+	command.command.Flags().StringVar(&command.flags.Domain, flag.Name, flag.Value, flag.Usage)
+	must(command.command.MarkFlagRequired(flag.Name))
+}
+
+func (command *Command) withKeyFile() {
+	flag := cli.StringFlag{
+		Name:  "key",
+		Usage: "Private key file (hex) to sign the tree with",
+	}
+	command.command.Flags().StringVar(&command.flags.KeyFile, flag.Name, flag.Value, flag.Usage)
+	must(command.command.MarkFlagRequired(flag.Name))
+}
+
+func (command *Command) withSeq() {
+	flag := cli.UintFlag{
+		Name:  "seq",
+		Usage: "Sequence number of the tree, the current unix time if 0. Must increase with each published tree.",
+	}
+	command.command.Flags().UintVar(&command.flags.Seq, flag.Name, flag.Value, flag.Usage)
+}
+
+func (command *Command) withLinks() {
+	flag := cli.StringSliceFlag{
+		Name:  "links",
+		Usage: "Comma separated enrtree:// URLs of the trees to link",
+	}
+	command.command.Flags().StringSliceVar(&command.flags.Links, flag.Name, nil, flag.Usage)
+}
+
+func (command *Command) withMaxAge() {
+	flag := cli.DurationFlag{
+		Name:  "max-age",
+		Usage: "How recently the node records must have been received",
+		Value: 24 * time.Hour,
+	}
+	command.command.Flags().DurationVar(&command.flags.MaxAge, flag.Name, flag.Value, flag.Usage)
+}
+
+func (command *Command) withMaxNodes() {
+	flag := cli.UintFlag{
+		Name:  "max-nodes",
+		Usage: "A number of the most recently verified nodes to include, all if 0",
+		Value: 500,
+	}
+	command.command.Flags().UintVar(&command.flags.MaxNodes, flag.Name, flag.Value, flag.Usage)
+}
+
+func (command *Command) withClients() {
+	flag := cli.StringSliceFlag{
+		Name:  "clients",
+		Usage: "Comma separated client names to include, e.g. erigon,geth. All if empty.",
+	}
+	command.command.Flags().StringSliceVar(&command.flags.Clients, flag.Name, nil, flag.Usage)
+}
+
+func (command *Command) withForkReadyOnly() {
+	flag := cli.BoolFlag{
+		Name:  "fork-ready",
+		Usage: "Include only the nodes announcing the upcoming fork",
+	}
+	command.command.Flags().BoolVar(&command.flags.ForkReadyOnly, flag.Name, false, flag.Usage)
+}
+
+func (command *Command) withZoneFilePath() {
+	flag := cli.StringFlag{
+		Name:  "zone-file",
+		Usage: "Path of the DNS zone file to write",
+	}
+	command.command.Flags().StringVar(&command.flags.ZoneFilePath, flag.Name, flag.Value, flag.Usage)
+}
+
+func (command *Command) withTXTFilePath() {
+	flag := cli.StringFlag{
+		Name:  "txt-file",
+		Usage: "Path of the JSON file of the TXT records to write",
+	}
+	command.command.Flags().StringVar(&command.flags.TXTFilePath, flag.Name, flag.Value, flag.Usage)
+}
+
+func (command *Command) withPublishDir() {
+	flag := cli.StringFlag{
+		Name:  "publish-dir",
+		Usage: "Directory of the file DNS provider to publish the tree to, for testing",
+	}
+	command.command.Flags().StringVar(&command.flags.PublishDir, flag.Name, flag.Value, flag.Usage)
+}
+
+func (command *Command) RawCommand() *cobra.Command {
+	return &command.command
+}
+
+func (command *Command) OnRun(runFunc func(ctx context.Context, flags CommandFlags) error) {
+	command.command.RunE = func(cmd *cobra.Command, args []string) error {
+		return runFunc(cmd.Context(), command.flags)
+	}
+}
+
+func must(err error) {
+	if err != nil {
+		panic(err)
+	}
+}
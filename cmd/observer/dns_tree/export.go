@@ -0,0 +1,49 @@
+package dns_tree
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+
+	"github.com/ledgerwatch/erigon/p2p/dnsdisc"
+)
+
+// zoneTTL is the TTL of the zone file records, the clients check the root for updates every 30 minutes by default
+const zoneTTL = 1800
+
+// Export writes the signed tree to the outputs of the flags
+func Export(ctx context.Context, tree *dnsdisc.Tree, flags CommandFlags) error {
+	records := tree.ToTXT(flags.Domain)
+
+	if flags.TXTFilePath != "" {
+		data, err := json.MarshalIndent(records, "", "  ")
+		if err != nil {
+			return err
+		}
+		if err := os.WriteFile(flags.TXTFilePath, data, 0644); err != nil {
+			return err
+		}
+	}
+
+	if flags.ZoneFilePath != "" {
+		file, err := os.Create(flags.ZoneFilePath)
+		if err != nil {
+			return err
+		}
+		err = dnsdisc.WriteZone(file, flags.Domain, zoneTTL, records)
+		if closeErr := file.Close(); err == nil {
+			err = closeErr
+		}
+		if err != nil {
+			return err
+		}
+	}
+
+	if flags.PublishDir != "" {
+		provider := dnsdisc.NewFileProvider(flags.PublishDir)
+		if err := dnsdisc.Publish(ctx, provider, flags.Domain, tree); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,80 @@
+package dns_tree
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/ledgerwatch/erigon/cmd/observer/database"
+	"github.com/ledgerwatch/erigon/cmd/observer/observer"
+	"github.com/ledgerwatch/erigon/core/forkid"
+	"github.com/ledgerwatch/erigon/p2p/dnsdisc"
+	"github.com/ledgerwatch/erigon/p2p/enode"
+)
+
+type TreeConfig struct {
+	NetworkID uint
+	// MaxAge is how recently the node records must have been received
+	MaxAge   time.Duration
+	MaxNodes uint
+
+	// Clients are the accepted client names, all clients are accepted if empty
+	Clients []string
+	// ForkID is the expected fork ID, the nodes must have its fork hash,
+	// and its next fork if RequireForkNext is set
+	ForkID          forkid.ID
+	RequireForkNext bool
+
+	Seq   uint
+	Links []string
+}
+
+// CreateTree makes an unsigned tree of the recently verified live nodes
+func CreateTree(ctx context.Context, db database.DB, config TreeConfig, now time.Time) (*dnsdisc.Tree, error) {
+	nodes, err := db.FindLiveNodeENRs(ctx, now.Add(-config.MaxAge), config.NetworkID)
+	if err != nil {
+		return nil, err
+	}
+	return dnsdisc.MakeTree(config.Seq, FilterNodes(nodes, config), config.Links)
+}
+
+// FilterNodes returns the records of the nodes with an accepted client and the expected fork ID,
+// up to MaxNodes in the order of the given nodes
+func FilterNodes(nodes []database.NodeENR, config TreeConfig) []*enode.Node {
+	forkHash := observer.ForkHashString(config.ForkID)
+
+	var records []*enode.Node
+	for _, node := range nodes {
+		if (config.MaxNodes > 0) && (uint(len(records)) >= config.MaxNodes) {
+			break
+		}
+		if (node.ClientID == nil) || observer.IsClientIDBlacklisted(*node.ClientID) || !isClientAccepted(*node.ClientID, config.Clients) {
+			continue
+		}
+		if (node.ForkHash == nil) || (*node.ForkHash != forkHash) {
+			continue
+		}
+		if config.RequireForkNext && (*node.ForkNext != config.ForkID.Next) {
+			continue
+		}
+		record, err := enode.Parse(enode.ValidSchemes, node.ENR)
+		if err != nil {
+			continue
+		}
+		records = append(records, record)
+	}
+	return records
+}
+
+func isClientAccepted(clientID string, clients []string) bool {
+	if len(clients) == 0 {
+		return true
+	}
+	name := observer.NameFromClientID(clientID)
+	for _, client := range clients {
+		if strings.EqualFold(name, client) {
+			return true
+		}
+	}
+	return false
+}
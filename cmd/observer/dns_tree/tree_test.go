@@ -0,0 +1,94 @@
+package dns_tree
+
+import (
+	"context"
+	"net"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ledgerwatch/erigon/cmd/observer/database"
+	"github.com/ledgerwatch/erigon/cmd/observer/observer/node_utils"
+	"github.com/ledgerwatch/erigon/core/forkid"
+	"github.com/ledgerwatch/erigon/crypto"
+	"github.com/ledgerwatch/erigon/p2p/enode"
+	"github.com/ledgerwatch/erigon/p2p/enr"
+)
+
+func makeTestNode(t *testing.T, ip string) *enode.Node {
+	key, err := crypto.GenerateKey()
+	require.Nil(t, err)
+	var record enr.Record
+	record.Set(enr.IP(net.ParseIP(ip)))
+	record.Set(enr.TCP(30303))
+	record.Set(enr.UDP(30303))
+	require.Nil(t, enode.SignV4(&record, key))
+	node, err := enode.New(enode.ValidSchemes, &record)
+	require.Nil(t, err)
+	return node
+}
+
+func TestCreateTree(t *testing.T) {
+	ctx := context.Background()
+	db, err := database.NewDBSQLite(filepath.Join(t.TempDir(), "observer.sqlite"))
+	require.Nil(t, err)
+	defer func() { _ = db.Close() }()
+
+	forkID := forkid.ID{Hash: [4]byte{0xf0, 0xaf, 0xd0, 0xe3}, Next: 1705473120}
+	addNode := func(ip string, clientID string, forkHash string, forkNext uint64) *enode.Node {
+		node := makeTestNode(t, ip)
+		id, err := node_utils.NodeID(node)
+		require.Nil(t, err)
+		require.Nil(t, db.UpsertNodeAddr(ctx, id, node_utils.MakeNodeAddr(node)))
+		require.Nil(t, db.UpdateClientID(ctx, id, clientID))
+		require.Nil(t, db.UpdateForkID(ctx, id, forkHash, forkNext))
+		require.Nil(t, db.UpdateENR(ctx, id, node.String()))
+		return node
+	}
+
+	ready := addNode("10.0.0.1", "erigon/v2.48.0", "f0afd0e3", forkID.Next)
+	notReady := addNode("10.0.0.2", "Geth/v1.12.0", "f0afd0e3", 0)
+	addNode("10.0.0.3", "Nethermind/v1.20.0", "f0afd0e3", forkID.Next)
+	addNode("10.0.0.4", "erigon/v2.40.0", "dce96c2d", 1681338455)
+	addNode("10.0.0.5", "bor/v0.4.0", "f0afd0e3", forkID.Next)
+
+	config := TreeConfig{
+		NetworkID: 1,
+		MaxAge:    time.Hour,
+		Clients:   []string{"erigon", "geth"},
+		ForkID:    forkID,
+		Seq:       7,
+	}
+	tree, err := CreateTree(ctx, db, config, time.Now())
+	require.Nil(t, err)
+	assert.Equal(t, uint(7), tree.Seq())
+	assert.ElementsMatch(t, []enode.ID{ready.ID(), notReady.ID()}, nodeIDs(tree.Nodes()))
+
+	config.RequireForkNext = true
+	tree, err = CreateTree(ctx, db, config, time.Now())
+	require.Nil(t, err)
+	assert.ElementsMatch(t, []enode.ID{ready.ID()}, nodeIDs(tree.Nodes()))
+
+	config.RequireForkNext = false
+	config.Clients = nil
+	config.MaxNodes = 2
+	tree, err = CreateTree(ctx, db, config, time.Now())
+	require.Nil(t, err)
+	assert.Equal(t, 2, len(tree.Nodes()))
+
+	// the records are too old
+	tree, err = CreateTree(ctx, db, config, time.Now().Add(2*time.Hour))
+	require.Nil(t, err)
+	assert.Equal(t, 0, len(tree.Nodes()))
+}
+
+func nodeIDs(nodes []*enode.Node) []enode.ID {
+	ids := make([]enode.ID, 0, len(nodes))
+	for _, node := range nodes {
+		ids = append(ids, node.ID())
+	}
+	return ids
+}
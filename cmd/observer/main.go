@@ -5,12 +5,16 @@ import (
 	"errors"
 	"fmt"
 	"path/filepath"
+	"time"
 
 	"github.com/ledgerwatch/erigon-lib/common"
+	"github.com/ledgerwatch/erigon/cmd/observer/dashboard"
 	"github.com/ledgerwatch/erigon/cmd/observer/database"
+	"github.com/ledgerwatch/erigon/cmd/observer/dns_tree"
 	"github.com/ledgerwatch/erigon/cmd/observer/observer"
 	"github.com/ledgerwatch/erigon/cmd/observer/reports"
 	"github.com/ledgerwatch/erigon/cmd/utils"
+	"github.com/ledgerwatch/erigon/crypto"
 	"github.com/ledgerwatch/erigon/params"
 	"github.com/ledgerwatch/log/v3"
 )
@@ -35,6 +39,37 @@ func mainWithFlags(ctx context.Context, flags observer.CommandFlags, logger log.
 	networkID := uint(params.NetworkIDByChainName(flags.Chain))
 	go observer.StatusLoggerLoop(ctx, db, networkID, flags.StatusLogPeriod, log.Root())
 
+	if flags.StatsPeriod > 0 {
+		statsConfig := dashboard.StatsConfig{
+			NetworkID:    networkID,
+			MaxPingTries: flags.MaxPingTries,
+		}
+		if flags.GeoIPCountryPath != "" {
+			if statsConfig.Countries, err = dashboard.LoadIPRanges(flags.GeoIPCountryPath); err != nil {
+				return err
+			}
+		}
+		if flags.GeoIPASNPath != "" {
+			if statsConfig.ASNs, err = dashboard.LoadIPRanges(flags.GeoIPASNPath); err != nil {
+				return err
+			}
+		}
+		go dashboard.StatsLoop(ctx, db, statsConfig, flags.StatsPeriod, log.Root())
+	}
+
+	if flags.DashboardAddr != "" {
+		dashboardServer, err := dashboard.NewServer(db, flags.Chain, log.Root())
+		if err != nil {
+			return err
+		}
+		go func() {
+			err := dashboardServer.ListenAndServe(ctx, flags.DashboardAddr)
+			if (err != nil) && !errors.Is(err, context.Canceled) {
+				log.Error("Dashboard failed", "err", err)
+			}
+		}()
+	}
+
 	crawlerConfig := observer.CrawlerConfig{
 		Chain:            flags.Chain,
 		Bootnodes:        server.Bootnodes(),
@@ -103,6 +138,57 @@ func reportWithFlags(ctx context.Context, flags reports.CommandFlags) error {
 	return nil
 }
 
+func dnsTreeWithFlags(ctx context.Context, flags dns_tree.CommandFlags) error {
+	db, err := database.NewDBSQLite(filepath.Join(flags.DataDir, "observer.sqlite"))
+	if err != nil {
+		return err
+	}
+	defer func() { _ = db.Close() }()
+
+	key, err := crypto.LoadECDSA(flags.KeyFile)
+	if err != nil {
+		return fmt.Errorf("failed to load the signing key: %w", err)
+	}
+
+	schedule, err := dashboard.NewForkScheduleOfChain(flags.Chain)
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	seq := flags.Seq
+	if seq == 0 {
+		seq = uint(now.Unix())
+	}
+	treeConfig := dns_tree.TreeConfig{
+		NetworkID:       uint(params.NetworkIDByChainName(flags.Chain)),
+		MaxAge:          flags.MaxAge,
+		MaxNodes:        flags.MaxNodes,
+		Clients:         flags.Clients,
+		ForkID:          schedule.ExpectedForkID(now),
+		RequireForkNext: flags.ForkReadyOnly,
+		Seq:             seq,
+		Links:           flags.Links,
+	}
+
+	tree, err := dns_tree.CreateTree(ctx, db, treeConfig, now)
+	if err != nil {
+		return err
+	}
+	url, err := tree.Sign(key, flags.Domain)
+	if err != nil {
+		return err
+	}
+	if err := dns_tree.Export(ctx, tree, flags); err != nil {
+		return err
+	}
+
+	fmt.Printf("nodes: %d\n", len(tree.Nodes()))
+	fmt.Printf("seq: %d\n", tree.Seq())
+	fmt.Println(url)
+	return nil
+}
+
 func main() {
 	ctx, cancel := common.RootContext()
 	defer cancel()
@@ -113,6 +199,10 @@ func main() {
 	reportCommand.OnRun(reportWithFlags)
 	command.AddSubCommand(reportCommand.RawCommand())
 
+	dnsTreeCommand := dns_tree.NewCommand()
+	dnsTreeCommand.OnRun(dnsTreeWithFlags)
+	command.AddSubCommand(dnsTreeCommand.RawCommand())
+
 	err := command.ExecuteContext(ctx, mainWithFlags)
 	if (err != nil) && !errors.Is(err, context.Canceled) {
 		utils.Fatalf("%v", err)
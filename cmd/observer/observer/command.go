@@ -41,6 +41,11 @@ type CommandFlags struct {
 	HandshakeMaxTries       uint
 
 	ErigonLogPath string
+
+	StatsPeriod      time.Duration
+	DashboardAddr    string
+	GeoIPCountryPath string
+	GeoIPASNPath     string
 }
 
 type Command struct {
@@ -86,6 +91,11 @@ func NewCommand() *Command {
 
 	instance.withErigonLogPath()
 
+	instance.withStatsPeriod()
+	instance.withDashboardAddr()
+	instance.withGeoIPCountryPath()
+	instance.withGeoIPASNPath()
+
 	return &instance
 }
 
@@ -219,6 +229,39 @@ func (command *Command) withErigonLogPath() {
 	command.command.Flags().StringVar(&command.flags.ErigonLogPath, flag.Name, flag.Value, flag.Usage)
 }
 
+func (command *Command) withStatsPeriod() {
+	flag := cli.DurationFlag{
+		Name:  "stats-period",
+		Usage: "How often to record the client, fork ID, network and country time series. Disabled if 0.",
+		Value: time.Hour,
+	}
+	command.command.Flags().DurationVar(&command.flags.StatsPeriod, flag.Name, flag.Value, flag.Usage)
+}
+
+func (command *Command) withDashboardAddr() {
+	flag := cli.StringFlag{
+		Name:  "dashboard-addr",
+		Usage: "HTTP address of the dashboard serving the time series, e.g. localhost:8080. Disabled if empty.",
+	}
+	command.command.Flags().StringVar(&command.flags.DashboardAddr, flag.Name, flag.Value, flag.Usage)
+}
+
+func (command *Command) withGeoIPCountryPath() {
+	flag := cli.StringFlag{
+		Name:  "geoip-country",
+		Usage: "IP to country CSV file path, with 'start IP,end IP,country' rows. Enables the country time series.",
+	}
+	command.command.Flags().StringVar(&command.flags.GeoIPCountryPath, flag.Name, flag.Value, flag.Usage)
+}
+
+func (command *Command) withGeoIPASNPath() {
+	flag := cli.StringFlag{
+		Name:  "geoip-asn",
+		Usage: "IP to ASN CSV file path, with 'start IP,end IP,ASN,organization' rows. The networks are IP subnets without it.",
+	}
+	command.command.Flags().StringVar(&command.flags.GeoIPASNPath, flag.Name, flag.Value, flag.Usage)
+}
+
 func (command *Command) ExecuteContext(ctx context.Context, runFunc func(ctx context.Context, flags CommandFlags, logger log.Logger) error) error {
 	command.command.PersistentPostRun = func(cmd *cobra.Command, args []string) {
 		debug.Exit()
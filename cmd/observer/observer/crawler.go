@@ -488,6 +488,27 @@ func (crawler *Crawler) saveInterrogationResult(
 		}
 	}
 
+	if (result != nil) && (result.ENR != nil) {
+		dbErr := crawler.db.UpdateENR(ctx, id, result.ENR.String())
+		if dbErr != nil {
+			return dbErr
+		}
+	}
+
+	// the fork ID of the Status message is fresher than the ENR one, which is updated less often
+	var forkID *forkid.ID
+	if (result != nil) && (result.HandshakeResult != nil) && (result.HandshakeResult.ForkID != nil) {
+		forkID = result.HandshakeResult.ForkID
+	} else if result != nil {
+		forkID = result.ForkID
+	}
+	if forkID != nil {
+		dbErr := crawler.db.UpdateForkID(ctx, id, ForkHashString(*forkID), forkID.Next)
+		if dbErr != nil {
+			return dbErr
+		}
+	}
+
 	if (result != nil) && (result.HandshakeResult != nil) && (result.HandshakeResult.HandshakeErr != nil) {
 		dbErr := crawler.db.InsertHandshakeError(ctx, id, result.HandshakeResult.HandshakeErr.StringCode())
 		if dbErr != nil {
@@ -57,6 +57,11 @@ type StatusMessage struct {
 	Rest            []rlp.RawValue `rlp:"tail"`
 }
 
+// ForkHashString formats the fork hash of a fork ID as stored in the database
+func ForkHashString(forkID forkid.ID) string {
+	return fmt.Sprintf("%x", forkID.Hash)
+}
+
 type HandshakeErrorID string
 
 const (
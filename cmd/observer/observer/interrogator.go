@@ -41,6 +41,8 @@ type Interrogator struct {
 type InterrogationResult struct {
 	Node               *enode.Node
 	IsCompatFork       *bool
+	ForkID             *forkid.ID
+	ENR                *enode.Node
 	HandshakeResult    *DiplomatResult
 	HandshakeRetryTime *time.Time
 	KeygenKeys         []*ecdsa.PublicKey
@@ -164,6 +166,8 @@ func (interrogator *Interrogator) Run(ctx context.Context) (*InterrogationResult
 	result := InterrogationResult{
 		interrogator.node,
 		isCompatFork,
+		forkID,
+		enr,
 		handshakeResult,
 		handshakeRetryTime,
 		keys,
@@ -70,7 +70,9 @@ var rootCmd = &cobra.Command{
 }
 
 var (
-	stateCacheStr string
+	stateCacheStr                 string
+	captureSize                   string
+	captureMethods, captureRedact string
 )
 
 func RootCommand() (*cobra.Command, *httpcfg.HttpCfg) {
@@ -91,6 +93,7 @@ func RootCommand() (*cobra.Command, *httpcfg.HttpCfg) {
 	rootCmd.PersistentFlags().StringSliceVar(&cfg.API, "http.api", []string{"eth", "erigon"}, "API's offered over the HTTP-RPC interface: eth,erigon,web3,net,debug,trace,txpool,db. Supported methods: https://github.com/ledgerwatch/erigon/tree/devel/cmd/rpcdaemon")
 	rootCmd.PersistentFlags().Uint64Var(&cfg.Gascap, "rpc.gascap", 50_000_000, "Sets a cap on gas that can be used in eth_call/estimateGas")
 	rootCmd.PersistentFlags().Uint64Var(&cfg.MaxTraces, "trace.maxtraces", 200, "Sets a limit on traces that can be returned in trace_filter")
+	rootCmd.PersistentFlags().Uint64Var(&cfg.MaxLogs, "rpc.maxlogs", 0, "Sets a limit on logs that can be returned in eth_getLogs, 0 for no limit")
 	rootCmd.PersistentFlags().BoolVar(&cfg.WebsocketEnabled, "ws", false, "Enable Websockets - Same port as HTTP")
 	rootCmd.PersistentFlags().BoolVar(&cfg.WebsocketCompression, "ws.compression", false, "Enable Websocket compression (RFC 7692)")
 	rootCmd.PersistentFlags().StringVar(&cfg.RpcAllowListFilePath, utils.RpcAccessListFlag.Name, "", "Specify granular (method-by-method) API allowlist")
@@ -118,6 +121,15 @@ func RootCommand() (*cobra.Command, *httpcfg.HttpCfg) {
 	rootCmd.PersistentFlags().DurationVar(&cfg.EvmCallTimeout, "rpc.evmtimeout", rpccfg.DefaultEvmCallTimeout, "Maximum amount of time to wait for the answer from EVM call.")
 	rootCmd.PersistentFlags().IntVar(&cfg.BatchLimit, utils.RpcBatchLimit.Name, utils.RpcBatchLimit.Value, utils.RpcBatchLimit.Usage)
 	rootCmd.PersistentFlags().IntVar(&cfg.ReturnDataLimit, utils.RpcReturnDataLimit.Name, utils.RpcReturnDataLimit.Value, utils.RpcReturnDataLimit.Usage)
+	rootCmd.PersistentFlags().StringVar(&cfg.Signer.Keystore, utils.RpcSignerKeystoreFlag.Name, "", utils.RpcSignerKeystoreFlag.Usage)
+	rootCmd.PersistentFlags().StringVar(&cfg.Signer.PasswordFile, utils.RpcSignerPasswordFlag.Name, "", utils.RpcSignerPasswordFlag.Usage)
+	rootCmd.PersistentFlags().StringVar(&cfg.Signer.URL, utils.RpcSignerURLFlag.Name, "", utils.RpcSignerURLFlag.Usage)
+	rootCmd.PersistentFlags().StringVar(&cfg.Capture.Dir, utils.RpcCaptureDirFlag.Name, "", utils.RpcCaptureDirFlag.Usage)
+	rootCmd.PersistentFlags().Float64Var(&cfg.Capture.SampleRate, utils.RpcCaptureSampleFlag.Name, utils.RpcCaptureSampleFlag.Value, utils.RpcCaptureSampleFlag.Usage)
+	rootCmd.PersistentFlags().StringVar(&captureSize, utils.RpcCaptureFileSizeFlag.Name, utils.RpcCaptureFileSizeFlag.Value, utils.RpcCaptureFileSizeFlag.Usage)
+	rootCmd.PersistentFlags().IntVar(&cfg.Capture.MaxFiles, utils.RpcCaptureMaxFilesFlag.Name, utils.RpcCaptureMaxFilesFlag.Value, utils.RpcCaptureMaxFilesFlag.Usage)
+	rootCmd.PersistentFlags().StringVar(&captureMethods, utils.RpcCaptureMethodsFlag.Name, "", utils.RpcCaptureMethodsFlag.Usage)
+	rootCmd.PersistentFlags().StringVar(&captureRedact, utils.RpcCaptureRedactFlag.Name, utils.RpcCaptureRedactFlag.Value, utils.RpcCaptureRedactFlag.Usage)
 
 	if err := rootCmd.MarkPersistentFlagFilename("rpc.accessList", "json"); err != nil {
 		panic(err)
@@ -138,6 +150,12 @@ func RootCommand() (*cobra.Command, *httpcfg.HttpCfg) {
 			return fmt.Errorf("state.cache value of %v is not valid", stateCacheStr)
 		}
 
+		if err = cfg.Capture.MaxFileSize.UnmarshalText([]byte(captureSize)); err != nil {
+			return fmt.Errorf("rpc.capture.filesize value of %v is not valid", captureSize)
+		}
+		cfg.Capture.Methods = utils.SplitAndTrim(captureMethods)
+		cfg.Capture.Redact = utils.SplitAndTrim(captureRedact)
+
 		cfg.WithDatadir = cfg.DataDir != ""
 		if cfg.WithDatadir {
 			if cfg.DataDir == "" {
@@ -242,15 +260,11 @@ func EmbeddedServices(ctx context.Context,
 	miningServer txpool.MiningServer, stateDiffClient StateChangesClient,
 	logger log.Logger,
 ) (eth rpchelper.ApiBackend, txPool txpool.TxpoolClient, mining txpool.MiningClient, stateCache kvcache.Cache, ff *rpchelper.Filters, err error) {
-	if stateCacheCfg.CacheSize > 0 {
-		// notification about new blocks (state stream) doesn't work now inside erigon - because
-		// erigon does send this stream to privateAPI (erigon with enabled rpc, still have enabled privateAPI).
-		// without this state stream kvcache can't work and only slow-down things
-		// ... adding back in place to see about the above statement
-		stateCache = kvcache.New(stateCacheCfg)
-	} else {
-		stateCache = kvcache.NewDummy()
-	}
+	// notification about new blocks (state stream) doesn't work now inside erigon - because
+	// erigon does send this stream to privateAPI (erigon with enabled rpc, still have enabled privateAPI).
+	// without this state stream kvcache can't work and only slow-down things
+	// ... adding back in place to see about the above statement
+	stateCache = rpchelper.NewStateCache(stateCacheCfg, erigonDB)
 
 	subscribeToStateChangesLoop(ctx, stateDiffClient, stateCache)
 
@@ -299,7 +313,7 @@ func RemoteServices(ctx context.Context, cfg httpcfg.HttpCfg, logger log.Logger,
 		dir.MustExist(cfg.Dirs.SnapHistory)
 		logger.Trace("Creating chain db", "path", cfg.Dirs.Chaindata)
 		limiter := semaphore.NewWeighted(int64(cfg.DBReadConcurrency))
-		rwKv, err = kv2.NewMDBX(logger).RoTxsLimiter(limiter).Path(cfg.Dirs.Chaindata).Readonly().Open()
+		rwKv, err = kv2.NewMDBX(logger).RoTxsLimiter(limiter).Path(cfg.Dirs.Chaindata).WithTableCfg(rawdb.WithLogCounts).Readonly().Open()
 		if err != nil {
 			return nil, nil, nil, nil, nil, nil, nil, ff, nil, err
 		}
@@ -422,11 +436,7 @@ func RemoteServices(ctx context.Context, cfg httpcfg.HttpCfg, logger log.Logger,
 		// Skip the compatibility check, until we have a schema in erigon-lib
 		borDb = borKv
 	} else {
-		if cfg.StateCache.CacheSize > 0 {
-			stateCache = kvcache.New(cfg.StateCache)
-		} else {
-			stateCache = kvcache.NewDummy()
-		}
+		stateCache = rpchelper.NewStateCache(cfg.StateCache, db)
 		logger.Info("if you run RPCDaemon on same machine with Erigon add --datadir option")
 	}
 
@@ -505,6 +515,16 @@ func startRegularRpcServer(ctx context.Context, cfg httpcfg.HttpCfg, rpcAPI []rp
 	srv.SetAllowList(allowListForRPC)
 
 	srv.SetBatchLimit(cfg.BatchLimit)
+	srv.SetMethodContext(cfg.StateCacheStats)
+
+	if len(cfg.Capture.Dir) > 0 {
+		capture, err := rpc.NewCapture(cfg.Capture, logger)
+		if err != nil {
+			return fmt.Errorf("could not start RPC capture: %w", err)
+		}
+		defer capture.Close()
+		srv.SetCapture(capture)
+	}
 
 	var defaultAPIList []rpc.API
 
@@ -5,7 +5,9 @@ import (
 
 	"github.com/ledgerwatch/erigon-lib/common/datadir"
 	"github.com/ledgerwatch/erigon-lib/kv/kvcache"
+	"github.com/ledgerwatch/erigon/accounts/signer"
 	"github.com/ledgerwatch/erigon/eth/ethconfig"
+	"github.com/ledgerwatch/erigon/p2p/txpolicy"
 	"github.com/ledgerwatch/erigon/rpc/rpccfg"
 )
 
@@ -30,6 +32,10 @@ type HttpCfg struct {
 	API                      []string
 	Gascap                   uint64
 	MaxTraces                uint64
+	MaxLogs                  uint64               // Maximum number of logs eth_getLogs returns, 0 for no limit
+	PrivateTxClients         string               // CIDR masks of the clients whose transactions are never gossiped
+	PrivateTxs               *txpolicy.PrivateTxs // Registry of the private transactions shared with the sentries of the process
+	StateCacheStats          bool                 // Whether the state cache collects statistics per RPC method
 	WebsocketEnabled         bool
 	WebsocketCompression     bool
 	RpcAllowListFilePath     string
@@ -63,4 +69,8 @@ type HttpCfg struct {
 
 	BatchLimit      int // Maximum number of requests in a batch
 	ReturnDataLimit int // Maximum number of bytes returned from calls (like eth_call)
+
+	Signer signer.Config // Accounts of eth_sign, eth_signTransaction and eth_sendTransaction
+
+	Capture rpccfg.CaptureConfig // Capture of sampled HTTP requests, for `rpctest replay`
 }
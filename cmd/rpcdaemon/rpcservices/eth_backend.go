@@ -281,6 +281,22 @@ func (back *RemoteBackend) NodeInfo(ctx context.Context, limit uint32) ([]p2p.No
 			return nil, fmt.Errorf("cannot decode protocols metadata: %w", err)
 		}
 
+		var discovery *p2p.DiscoveryStats
+		if raw, ok := rawProtocols[p2p.DiscoveryStatsProtocolKey]; ok {
+			delete(rawProtocols, p2p.DiscoveryStatsProtocolKey)
+			if err = json.Unmarshal(raw, &discovery); err != nil {
+				return nil, fmt.Errorf("cannot decode discovery stats: %w", err)
+			}
+		}
+
+		var peerScores []*p2p.PeerScore
+		if raw, ok := rawProtocols[p2p.PeerScoresProtocolKey]; ok {
+			delete(rawProtocols, p2p.PeerScoresProtocolKey)
+			if err = json.Unmarshal(raw, &peerScores); err != nil {
+				return nil, fmt.Errorf("cannot decode peer scores: %w", err)
+			}
+		}
+
 		protocols := make(map[string]interface{}, len(rawProtocols))
 		for k, v := range rawProtocols {
 			protocols[k] = v
@@ -300,7 +316,9 @@ func (back *RemoteBackend) NodeInfo(ctx context.Context, limit uint32) ([]p2p.No
 				Discovery: int(node.Ports.Discovery),
 				Listener:  int(node.Ports.Listener),
 			},
-			Protocols: protocols,
+			Protocols:  protocols,
+			Discovery:  discovery,
+			PeerScores: peerScores,
 		})
 	}
 
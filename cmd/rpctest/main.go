@@ -287,6 +287,44 @@ func main() {
 	compareAccountRange.Flags().StringVar(&tmpDataDir, "tmpdir", "/media/b00ris/nvme/accrange1", "dir for tmp db")
 	compareAccountRange.Flags().StringVar(&tmpDataDirOrig, "gethtmpdir", "/media/b00ris/nvme/accrangeorig1", "dir for tmp db")
 
+	var chainName string
+	var verifyWitnessCmd = &cobra.Command{
+		Use:   "verifyWitness",
+		Short: "Verifies blocks statelessly with the witnesses of debug_getBlockWitness",
+		Long:  ``,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return rpctest.VerifyBlockWitnesses(log.New(), erigonURL, chainName, blockFrom, blockTo)
+		},
+	}
+	with(verifyWitnessCmd, withErigonUrl, withBlockNum)
+	verifyWitnessCmd.Flags().StringVar(&chainName, "chain", "mainnet", "Name of the chain the blocks belong to")
+
+	var (
+		fuzzConfig   rpctest.FuzzConfig
+		referenceURL string
+		replayFile   string
+	)
+	var fuzzCmd = &cobra.Command{
+		Use:   "fuzz",
+		Short: "Send random requests built from chain data to Erigon and a reference node, and record the different responses",
+		Long:  ``,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			fuzzConfig.BlockFrom, fuzzConfig.BlockTo = blockFrom, blockTo
+			fuzzConfig.ReplayFile = replayFile
+			return rpctest.Fuzz(cmd.Context(), erigonURL, referenceURL, fuzzConfig)
+		},
+	}
+	with(fuzzCmd, withErigonUrl)
+	fuzzCmd.Flags().StringVar(&referenceURL, "referenceUrl", "http://localhost:8546", "rpc url of the reference node, any node serving the fuzzed namespaces")
+	fuzzCmd.Flags().Uint64Var(&blockFrom, "blockFrom", 0, "Block number to start test generation from")
+	fuzzCmd.Flags().Uint64Var(&blockTo, "blockTo", 0, "Block number to end test generation at, the head of the nodes if 0")
+	fuzzCmd.Flags().IntVar(&fuzzConfig.Blocks, "blocks", 100, "Number of random blocks to build requests from")
+	fuzzCmd.Flags().IntVar(&fuzzConfig.Requests, "requests", 1000, "Number of requests to send")
+	fuzzCmd.Flags().Int64Var(&fuzzConfig.Seed, "seed", 0, "Seed of the random requests, random if 0")
+	fuzzCmd.Flags().StringSliceVar(&fuzzConfig.Namespaces, "namespaces", rpctest.FuzzNamespaces, "Namespaces of the requests")
+	fuzzCmd.Flags().StringSliceVar(&fuzzConfig.IgnoreFields, "ignoreFields", nil, "Names of the response fields which are not compared")
+	fuzzCmd.Flags().StringVar(&replayFile, "replayFile", "", "File where to record the requests with different responses, for the replay command")
+
 	var rootCmd = &cobra.Command{Use: "test"}
 	rootCmd.Flags().StringVar(&erigonURL, "erigonUrl", "http://localhost:8545", "Erigon rpcdaemon url")
 	rootCmd.Flags().StringVar(&gethURL, "gethUrl", "http://localhost:8546", "geth rpc url")
@@ -316,6 +354,8 @@ func main() {
 		benchEthBlockByNumberCmd,
 		benchEthGetBalanceCmd,
 		replayCmd,
+		verifyWitnessCmd,
+		fuzzCmd,
 	)
 	if err := rootCmd.ExecuteContext(rootContext()); err != nil {
 		fmt.Println(err)
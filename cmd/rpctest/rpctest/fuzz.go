@@ -0,0 +1,800 @@
+package rpctest
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"math/rand"
+	"net/http"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	libcommon "github.com/ledgerwatch/erigon-lib/common"
+	"github.com/ledgerwatch/erigon-lib/common/hexutility"
+	"github.com/ledgerwatch/log/v3"
+	"github.com/valyala/fastjson"
+
+	"github.com/ledgerwatch/erigon/common/hexutil"
+)
+
+// FuzzNamespaces are the namespaces the fuzzer generates requests for by default
+var FuzzNamespaces = []string{"eth", "debug", "trace", "erigon", "ots", "parity", "net", "web3"}
+
+type FuzzConfig struct {
+	BlockFrom uint64
+	// BlockTo is the last block requests are generated for, the head of the endpoints if it is 0 or beyond it
+	BlockTo uint64
+	// Blocks is the number of random blocks whose transactions, addresses, storage slots and logs the requests use
+	Blocks   int
+	Requests int
+	// Seed seeds the generation of the requests, which is reproducible for the same seed and chain
+	Seed       int64
+	Namespaces []string
+	// IgnoreFields are the names of the fields which are removed from the responses before they are compared
+	IgnoreFields []string
+	// ReplayFile records the requests with different responses, see Replay
+	ReplayFile string
+}
+
+// Fuzz sends random requests built from the chain data of the endpoints to both of them, and reports the requests
+// for which the endpoints respond differently. Only one of the endpoints returning an error is a difference, the
+// messages of errors returned by both are not compared as they vary between implementations.
+//
+// The differences are recorded in the replay file as the request, the response of the reference endpoint, a comment
+// line with the difference and an empty line, so that `replay` can run the requests against the endpoint again.
+func Fuzz(ctx context.Context, erigonURL, referenceURL string, config FuzzConfig) error {
+	setRoutes(erigonURL, referenceURL)
+	var client = &http.Client{
+		Timeout: time.Second * 600,
+	}
+
+	if config.Seed == 0 {
+		config.Seed = time.Now().UnixNano()
+	}
+	log.Info("Fuzzing", "seed", config.Seed, "erigon", erigonURL, "reference", referenceURL)
+
+	f := &fuzzer{
+		reqGen: &RequestGenerator{
+			client: client,
+		},
+		rnd:    rand.New(rand.NewSource(config.Seed)), // nolint:gosec
+		ignore: map[string]struct{}{},
+	}
+
+	for _, field := range config.IgnoreFields {
+		f.ignore[field] = struct{}{}
+	}
+
+	methods, err := fuzzMethodsOf(config.Namespaces)
+	if err != nil {
+		return err
+	}
+
+	head, err := f.head()
+	if err != nil {
+		return err
+	}
+	if config.BlockTo == 0 || config.BlockTo > head {
+		config.BlockTo = head
+	}
+	if config.BlockFrom > config.BlockTo {
+		return fmt.Errorf("no blocks to fuzz, the head is %d", head)
+	}
+
+	for i := 0; i < config.Blocks; i++ {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if err := f.harvest(config.BlockFrom + uint64(f.rnd.Int63n(int64(config.BlockTo-config.BlockFrom+1)))); err != nil {
+			return err
+		}
+	}
+	if len(f.blocks) == 0 {
+		return fmt.Errorf("no blocks harvested")
+	}
+	log.Info("Harvested", "blocks", len(f.blocks), "txs", len(f.txs), "addresses", len(f.addresses),
+		"contracts", len(f.contracts), "logs", len(f.logs))
+
+	var replay *bufio.Writer
+	if config.ReplayFile != "" {
+		file, err := os.Create(config.ReplayFile)
+		if err != nil {
+			return fmt.Errorf("cannot create file %s for replay: %w", config.ReplayFile, err)
+		}
+		defer file.Close()
+		replay = bufio.NewWriter(file)
+		defer replay.Flush()
+	}
+
+	stats := map[string]*fuzzStats{}
+	var diffs int
+	for i := 0; i < config.Requests; i++ {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		method := methods[f.rnd.Intn(len(methods))]
+		params, ok := method.params(f)
+		if !ok {
+			continue
+		}
+		f.reqGen.reqID++
+		request, err := f.request(method.name, params)
+		if err != nil {
+			return err
+		}
+
+		s, ok := stats[method.name]
+		if !ok {
+			s = &fuzzStats{}
+			stats[method.name] = s
+		}
+		s.requests++
+
+		res := f.reqGen.Erigon2(method.name, request)
+		if res.Err != nil {
+			return fmt.Errorf("could not invoke %s (Erigon): %w", method.name, res.Err)
+		}
+		resg := f.reqGen.Geth2(method.name, request)
+		if resg.Err != nil {
+			return fmt.Errorf("could not invoke %s (reference): %w", method.name, resg.Err)
+		}
+		if res.Result.Get("error") != nil {
+			s.errors++
+		}
+
+		if err := f.compare(res.Result, resg.Result); err != nil {
+			s.diffs++
+			diffs++
+			fmt.Printf("Different results for %s: %v\n", request, err)
+			if replay != nil {
+				var response bytes.Buffer
+				if err := json.Compact(&response, resg.Response); err != nil {
+					return err
+				}
+				fmt.Fprintf(replay, "%s\n%s\n# %s: %s\n\n", request, response.Bytes(), method.name, strings.ReplaceAll(err.Error(), "\n", " "))
+				replay.Flush() // nolint:errcheck
+			}
+		}
+	}
+
+	names := make([]string, 0, len(stats))
+	for name := range stats {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		s := stats[name]
+		fmt.Printf("%-45s requests=%-6d erigon errors=%-6d differences=%d\n", name, s.requests, s.errors, s.diffs)
+	}
+
+	if diffs > 0 {
+		return fmt.Errorf("%d requests with different responses, seed %d", diffs, config.Seed)
+	}
+	return nil
+}
+
+type fuzzStats struct {
+	requests int
+	errors   int
+	diffs    int
+}
+
+type fuzzMethod struct {
+	name string
+	// params returns the parameters of a random request, or false if the harvested data has none for the method
+	params func(f *fuzzer) ([]interface{}, bool)
+}
+
+type fuzzBlock struct {
+	number    uint64
+	hash      libcommon.Hash
+	timestamp uint64
+	txs       int
+}
+
+type fuzzTx struct {
+	Hash             libcommon.Hash     `json:"hash"`
+	BlockNumber      hexutil.Uint64     `json:"blockNumber"`
+	BlockHash        libcommon.Hash     `json:"blockHash"`
+	TransactionIndex hexutil.Uint64     `json:"transactionIndex"`
+	From             libcommon.Address  `json:"from"`
+	To               *libcommon.Address `json:"to"`
+	Nonce            hexutil.Uint64     `json:"nonce"`
+	Gas              hexutil.Uint64     `json:"gas"`
+	Value            hexutil.Big        `json:"value"`
+	Input            hexutility.Bytes   `json:"input"`
+}
+
+type fuzzLog struct {
+	Address libcommon.Address `json:"address"`
+	Topics  []libcommon.Hash  `json:"topics"`
+	block   uint64
+}
+
+type fuzzBlockResponse struct {
+	CommonResponse
+	Result *struct {
+		Number       hexutil.Uint64    `json:"number"`
+		Hash         libcommon.Hash    `json:"hash"`
+		Timestamp    hexutil.Uint64    `json:"timestamp"`
+		Miner        libcommon.Address `json:"miner"`
+		Transactions []*fuzzTx         `json:"transactions"`
+	} `json:"result"`
+}
+
+type fuzzReceiptsResponse struct {
+	CommonResponse
+	Result []struct {
+		ContractAddress *libcommon.Address `json:"contractAddress"`
+		Logs            []*fuzzLog         `json:"logs"`
+	} `json:"result"`
+}
+
+// fuzzer holds the chain data requests are built from
+type fuzzer struct {
+	reqGen    *RequestGenerator
+	rnd       *rand.Rand
+	ignore    map[string]struct{}
+	blocks    []fuzzBlock
+	txs       []*fuzzTx
+	addresses []libcommon.Address
+	contracts []libcommon.Address
+	slots     map[libcommon.Address][]libcommon.Hash
+	logs      []*fuzzLog
+	known     map[libcommon.Address]struct{}
+}
+
+// head returns the lowest of the heads of the endpoints
+func (f *fuzzer) head() (uint64, error) {
+	f.reqGen.reqID++
+	var blockNumber, blockNumberg EthBlockNumber
+	if res := f.reqGen.Erigon("eth_blockNumber", f.reqGen.blockNumber(), &blockNumber); res.Err != nil {
+		return 0, fmt.Errorf("could not get block number (Erigon): %w", res.Err)
+	}
+	if blockNumber.Error != nil {
+		return 0, fmt.Errorf("error getting block number (Erigon): %d %s", blockNumber.Error.Code, blockNumber.Error.Message)
+	}
+	if res := f.reqGen.Geth("eth_blockNumber", f.reqGen.blockNumber(), &blockNumberg); res.Err != nil {
+		return 0, fmt.Errorf("could not get block number (reference): %w", res.Err)
+	}
+	if blockNumberg.Error != nil {
+		return 0, fmt.Errorf("error getting block number (reference): %d %s", blockNumberg.Error.Code, blockNumberg.Error.Message)
+	}
+	if blockNumberg.Number < blockNumber.Number {
+		return uint64(blockNumberg.Number), nil
+	}
+	return uint64(blockNumber.Number), nil
+}
+
+// harvest adds the block, its transactions, their addresses, logs and some of the storage slots of the contracts
+// they call to the fuzzer data
+func (f *fuzzer) harvest(bn uint64) error {
+	if f.known == nil {
+		f.known = map[libcommon.Address]struct{}{}
+		f.slots = map[libcommon.Address][]libcommon.Hash{}
+	}
+	addAddress := func(address libcommon.Address, contract bool) {
+		if _, ok := f.known[address]; !ok {
+			f.known[address] = struct{}{}
+			f.addresses = append(f.addresses, address)
+		}
+		if contract {
+			if _, ok := f.slots[address]; !ok {
+				f.slots[address] = nil
+				f.contracts = append(f.contracts, address)
+			}
+		}
+	}
+
+	f.reqGen.reqID++
+	var b fuzzBlockResponse
+	if res := f.reqGen.Erigon("eth_getBlockByNumber", f.reqGen.getBlockByNumber(bn, true), &b); res.Err != nil {
+		return fmt.Errorf("could not retrieve block (Erigon) %d: %w", bn, res.Err)
+	}
+	if b.Error != nil {
+		return fmt.Errorf("error retrieving block (Erigon) %d: %d %s", bn, b.Error.Code, b.Error.Message)
+	}
+	if b.Result == nil {
+		return nil
+	}
+	f.blocks = append(f.blocks, fuzzBlock{
+		number:    uint64(b.Result.Number),
+		hash:      b.Result.Hash,
+		timestamp: uint64(b.Result.Timestamp),
+		txs:       len(b.Result.Transactions),
+	})
+	addAddress(b.Result.Miner, false)
+	for _, tx := range b.Result.Transactions {
+		f.txs = append(f.txs, tx)
+		addAddress(tx.From, false)
+		if tx.To != nil {
+			addAddress(*tx.To, len(tx.Input) > 0)
+		}
+	}
+
+	f.reqGen.reqID++
+	var receipts fuzzReceiptsResponse
+	request := fmt.Sprintf(`{"jsonrpc":"2.0","method":"eth_getBlockReceipts","params":["0x%x"],"id":%d}`, bn, f.reqGen.reqID)
+	if res := f.reqGen.Erigon("eth_getBlockReceipts", request, &receipts); res.Err != nil {
+		return fmt.Errorf("could not retrieve receipts (Erigon) %d: %w", bn, res.Err)
+	}
+	for _, receipt := range receipts.Result {
+		if receipt.ContractAddress != nil {
+			addAddress(*receipt.ContractAddress, true)
+		}
+		for _, l := range receipt.Logs {
+			l.block = bn
+			f.logs = append(f.logs, l)
+			addAddress(l.Address, true)
+		}
+	}
+
+	// The storage slots of a contract called in the block, the debug namespace may not be available
+	for _, tx := range b.Result.Transactions {
+		if tx.To == nil || len(tx.Input) == 0 || len(f.slots[*tx.To]) > 0 {
+			continue
+		}
+		f.reqGen.reqID++
+		var sr DebugStorageRange
+		if res := f.reqGen.Erigon("debug_storageRangeAt", f.reqGen.storageRangeAt(b.Result.Hash, int(tx.TransactionIndex), tx.To, libcommon.Hash{}), &sr); res.Err != nil {
+			return fmt.Errorf("could not get storage range (Erigon) %d: %w", bn, res.Err)
+		}
+		for _, entry := range sr.Result.Storage {
+			if entry.Key != nil {
+				f.slots[*tx.To] = append(f.slots[*tx.To], *entry.Key)
+			}
+		}
+		sort.Slice(f.slots[*tx.To], func(i, j int) bool {
+			return bytes.Compare(f.slots[*tx.To][i][:], f.slots[*tx.To][j][:]) < 0
+		})
+		break
+	}
+	return nil
+}
+
+func (f *fuzzer) request(method string, params []interface{}) (string, error) {
+	p, err := json.Marshal(params)
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf(`{"jsonrpc":"2.0","method":%q,"params":%s,"id":%d}`, method, p, f.reqGen.reqID), nil
+}
+
+// compare compares the results, or the presence of errors, of the responses once the ignored fields are removed
+func (f *fuzzer) compare(v, vg *fastjson.Value) error {
+	errVal, errValg := v.Get("error"), vg.Get("error")
+	switch {
+	case errVal != nil && errValg != nil:
+		return nil
+	case errVal != nil:
+		return fmt.Errorf("erigon returns error code=%d message=%s, while the reference returns OK", errVal.GetInt("code"), errVal.GetStringBytes("message"))
+	case errValg != nil:
+		return fmt.Errorf("erigon returns OK, while the reference returns error code=%d message=%s", errValg.GetInt("code"), errValg.GetStringBytes("message"))
+	}
+	f.normalize(v)
+	f.normalize(vg)
+	return compareResults(v, vg)
+}
+
+// normalize removes the ignored fields from the value
+func (f *fuzzer) normalize(v *fastjson.Value) {
+	if v == nil || len(f.ignore) == 0 {
+		return
+	}
+	switch v.Type() {
+	case fastjson.TypeObject:
+		obj, _ := v.Object()
+		var ignored []string
+		obj.Visit(func(key []byte, item *fastjson.Value) {
+			if _, ok := f.ignore[string(key)]; ok {
+				ignored = append(ignored, string(key))
+			} else {
+				f.normalize(item)
+			}
+		})
+		for _, key := range ignored {
+			obj.Del(key)
+		}
+	case fastjson.TypeArray:
+		arr, _ := v.Array()
+		for _, item := range arr {
+			f.normalize(item)
+		}
+	}
+}
+
+func (f *fuzzer) block() fuzzBlock {
+	return f.blocks[f.rnd.Intn(len(f.blocks))]
+}
+
+func (f *fuzzer) tx() (*fuzzTx, bool) {
+	if len(f.txs) == 0 {
+		return nil, false
+	}
+	return f.txs[f.rnd.Intn(len(f.txs))], true
+}
+
+func (f *fuzzer) address() libcommon.Address {
+	if len(f.addresses) == 0 || f.rnd.Intn(20) == 0 {
+		var address libcommon.Address
+		f.rnd.Read(address[:])
+		return address
+	}
+	return f.addresses[f.rnd.Intn(len(f.addresses))]
+}
+
+func (f *fuzzer) contract() (libcommon.Address, bool) {
+	if len(f.contracts) == 0 {
+		return libcommon.Address{}, false
+	}
+	return f.contracts[f.rnd.Intn(len(f.contracts))], true
+}
+
+func (f *fuzzer) slot(contract libcommon.Address) libcommon.Hash {
+	slots := f.slots[contract]
+	if len(slots) == 0 || f.rnd.Intn(10) == 0 {
+		// one of the first slots, used by most contracts
+		var slot libcommon.Hash
+		slot[len(slot)-1] = byte(f.rnd.Intn(8))
+		return slot
+	}
+	return slots[f.rnd.Intn(len(slots))]
+}
+
+func (f *fuzzer) number(bn uint64) string {
+	return hexutil.EncodeUint64(bn)
+}
+
+// blockRef returns a block number, or an EIP-1898 block hash, of one of the blocks
+func (f *fuzzer) blockRef() interface{} {
+	b := f.block()
+	if f.rnd.Intn(2) == 0 {
+		return map[string]interface{}{"blockHash": b.hash}
+	}
+	return f.number(b.number)
+}
+
+// index returns the index of one of the transactions of the block, or the first index past them
+func (f *fuzzer) index(b fuzzBlock) string {
+	return hexutil.EncodeUint64(uint64(f.rnd.Intn(b.txs + 1)))
+}
+
+// call returns the call of a harvested transaction, and the number of the block before it
+func (f *fuzzer) call() (map[string]interface{}, string, bool) {
+	tx, ok := f.tx()
+	if !ok {
+		return nil, "", false
+	}
+	call := map[string]interface{}{
+		"from":  tx.From,
+		"gas":   tx.Gas,
+		"value": &tx.Value,
+		"data":  tx.Input,
+	}
+	if tx.To != nil {
+		call["to"] = *tx.To
+	}
+	parent := uint64(tx.BlockNumber)
+	if parent > 0 {
+		parent--
+	}
+	return call, f.number(parent), true
+}
+
+// filter returns a log filter over a range of blocks from one with logs, with some of the addresses and topics of
+// the logs of the fuzzer
+func (f *fuzzer) filter(fromKey, toKey string) (map[string]interface{}, bool) {
+	if len(f.logs) == 0 {
+		return nil, false
+	}
+	l := f.logs[f.rnd.Intn(len(f.logs))]
+	filter := map[string]interface{}{
+		fromKey: f.number(l.block),
+		toKey:   f.number(l.block + uint64(f.rnd.Intn(100))),
+	}
+	switch f.rnd.Intn(3) {
+	case 1:
+		filter["address"] = l.Address
+	case 2:
+		filter["address"] = []libcommon.Address{l.Address, f.logs[f.rnd.Intn(len(f.logs))].Address}
+	}
+	if len(l.Topics) > 0 && f.rnd.Intn(2) == 0 {
+		topics := make([]interface{}, f.rnd.Intn(len(l.Topics))+1)
+		for i := range topics {
+			switch f.rnd.Intn(3) {
+			case 0:
+				topics[i] = nil
+			case 1:
+				topics[i] = l.Topics[i]
+			case 2:
+				other := f.logs[f.rnd.Intn(len(f.logs))]
+				if i < len(other.Topics) {
+					topics[i] = []libcommon.Hash{l.Topics[i], other.Topics[i]}
+				} else {
+					topics[i] = []libcommon.Hash{l.Topics[i]}
+				}
+			}
+		}
+		filter["topics"] = topics
+	}
+	return filter, true
+}
+
+func fuzzMethodsOf(namespaces []string) ([]*fuzzMethod, error) {
+	if len(namespaces) == 0 {
+		namespaces = FuzzNamespaces
+	}
+	var methods []*fuzzMethod
+	for _, namespace := range namespaces {
+		found := false
+		for _, method := range fuzzMethods {
+			if strings.HasPrefix(method.name, namespace+"_") {
+				methods = append(methods, method)
+				found = true
+			}
+		}
+		if !found {
+			return nil, fmt.Errorf("unknown namespace %q, expected one of %s", namespace, strings.Join(FuzzNamespaces, ","))
+		}
+	}
+	return methods, nil
+}
+
+func withTx(params func(f *fuzzer, tx *fuzzTx) []interface{}) func(f *fuzzer) ([]interface{}, bool) {
+	return func(f *fuzzer) ([]interface{}, bool) {
+		tx, ok := f.tx()
+		if !ok {
+			return nil, false
+		}
+		return params(f, tx), true
+	}
+}
+
+func withBlock(params func(f *fuzzer, b fuzzBlock) []interface{}) func(f *fuzzer) ([]interface{}, bool) {
+	return func(f *fuzzer) ([]interface{}, bool) {
+		return params(f, f.block()), true
+	}
+}
+
+func withContract(params func(f *fuzzer, contract libcommon.Address) []interface{}) func(f *fuzzer) ([]interface{}, bool) {
+	return func(f *fuzzer) ([]interface{}, bool) {
+		contract, ok := f.contract()
+		if !ok {
+			return nil, false
+		}
+		return params(f, contract), true
+	}
+}
+
+func withCall(params func(f *fuzzer, call map[string]interface{}, bn string) []interface{}) func(f *fuzzer) ([]interface{}, bool) {
+	return func(f *fuzzer) ([]interface{}, bool) {
+		call, bn, ok := f.call()
+		if !ok {
+			return nil, false
+		}
+		return params(f, call, bn), true
+	}
+}
+
+func withFilter(fromKey, toKey string, params func(f *fuzzer, filter map[string]interface{}) []interface{}) func(f *fuzzer) ([]interface{}, bool) {
+	return func(f *fuzzer) ([]interface{}, bool) {
+		filter, ok := f.filter(fromKey, toKey)
+		if !ok {
+			return nil, false
+		}
+		return params(f, filter), true
+	}
+}
+
+func noParams(f *fuzzer) ([]interface{}, bool) {
+	return []interface{}{}, true
+}
+
+// fuzzMethods are the methods of the turbo/jsonrpc namespaces with results which do not depend on the node, its
+// pool or its head
+var fuzzMethods = []*fuzzMethod{
+	{"eth_chainId", noParams},
+	{"eth_getBlockByNumber", withBlock(func(f *fuzzer, b fuzzBlock) []interface{} {
+		return []interface{}{f.number(b.number), f.rnd.Intn(2) == 0}
+	})},
+	{"eth_getBlockByHash", withBlock(func(f *fuzzer, b fuzzBlock) []interface{} {
+		return []interface{}{b.hash, f.rnd.Intn(2) == 0}
+	})},
+	{"eth_getBlockTransactionCountByNumber", withBlock(func(f *fuzzer, b fuzzBlock) []interface{} {
+		return []interface{}{f.number(b.number)}
+	})},
+	{"eth_getBlockTransactionCountByHash", withBlock(func(f *fuzzer, b fuzzBlock) []interface{} {
+		return []interface{}{b.hash}
+	})},
+	{"eth_getUncleCountByBlockNumber", withBlock(func(f *fuzzer, b fuzzBlock) []interface{} {
+		return []interface{}{f.number(b.number)}
+	})},
+	{"eth_getUncleByBlockNumberAndIndex", withBlock(func(f *fuzzer, b fuzzBlock) []interface{} {
+		return []interface{}{f.number(b.number), "0x0"}
+	})},
+	{"eth_getTransactionByHash", withTx(func(f *fuzzer, tx *fuzzTx) []interface{} {
+		return []interface{}{tx.Hash}
+	})},
+	{"eth_getTransactionByBlockNumberAndIndex", withBlock(func(f *fuzzer, b fuzzBlock) []interface{} {
+		return []interface{}{f.number(b.number), f.index(b)}
+	})},
+	{"eth_getTransactionByBlockHashAndIndex", withBlock(func(f *fuzzer, b fuzzBlock) []interface{} {
+		return []interface{}{b.hash, f.index(b)}
+	})},
+	{"eth_getRawTransactionByHash", withTx(func(f *fuzzer, tx *fuzzTx) []interface{} {
+		return []interface{}{tx.Hash}
+	})},
+	{"eth_getTransactionReceipt", withTx(func(f *fuzzer, tx *fuzzTx) []interface{} {
+		return []interface{}{tx.Hash}
+	})},
+	{"eth_getBlockReceipts", withBlock(func(f *fuzzer, b fuzzBlock) []interface{} {
+		return []interface{}{f.number(b.number)}
+	})},
+	{"eth_getBalance", func(f *fuzzer) ([]interface{}, bool) {
+		return []interface{}{f.address(), f.blockRef()}, true
+	}},
+	{"eth_getTransactionCount", func(f *fuzzer) ([]interface{}, bool) {
+		return []interface{}{f.address(), f.blockRef()}, true
+	}},
+	{"eth_getCode", withContract(func(f *fuzzer, contract libcommon.Address) []interface{} {
+		return []interface{}{contract, f.blockRef()}
+	})},
+	{"eth_getStorageAt", withContract(func(f *fuzzer, contract libcommon.Address) []interface{} {
+		return []interface{}{contract, f.slot(contract), f.blockRef()}
+	})},
+	{"eth_getProof", withContract(func(f *fuzzer, contract libcommon.Address) []interface{} {
+		return []interface{}{contract, []libcommon.Hash{f.slot(contract), f.slot(contract)}, f.blockRef()}
+	})},
+	{"eth_getLogs", withFilter("fromBlock", "toBlock", func(f *fuzzer, filter map[string]interface{}) []interface{} {
+		return []interface{}{filter}
+	})},
+	{"eth_call", withCall(func(f *fuzzer, call map[string]interface{}, bn string) []interface{} {
+		return []interface{}{call, bn}
+	})},
+	{"eth_estimateGas", withCall(func(f *fuzzer, call map[string]interface{}, bn string) []interface{} {
+		delete(call, "gas")
+		return []interface{}{call, bn}
+	})},
+	{"eth_createAccessList", withCall(func(f *fuzzer, call map[string]interface{}, bn string) []interface{} {
+		return []interface{}{call, bn}
+	})},
+
+	{"debug_traceTransaction", withTx(func(f *fuzzer, tx *fuzzTx) []interface{} {
+		return []interface{}{tx.Hash}
+	})},
+	{"debug_traceBlockByNumber", withBlock(func(f *fuzzer, b fuzzBlock) []interface{} {
+		return []interface{}{f.number(b.number), map[string]interface{}{"tracer": "callTracer"}}
+	})},
+	{"debug_traceBlockByHash", withBlock(func(f *fuzzer, b fuzzBlock) []interface{} {
+		return []interface{}{b.hash, map[string]interface{}{"tracer": "callTracer"}}
+	})},
+	{"debug_traceCall", withCall(func(f *fuzzer, call map[string]interface{}, bn string) []interface{} {
+		return []interface{}{call, bn}
+	})},
+	{"debug_storageRangeAt", withTx(func(f *fuzzer, tx *fuzzTx) []interface{} {
+		to := f.address()
+		if tx.To != nil {
+			to = *tx.To
+		}
+		return []interface{}{tx.BlockHash, tx.TransactionIndex, to, libcommon.Hash{}, f.rnd.Intn(64) + 1}
+	})},
+	{"debug_getModifiedAccountsByNumber", withBlock(func(f *fuzzer, b fuzzBlock) []interface{} {
+		return []interface{}{b.number, b.number + uint64(f.rnd.Intn(10)) + 1}
+	})},
+	{"debug_getRawHeader", withBlock(func(f *fuzzer, b fuzzBlock) []interface{} {
+		return []interface{}{f.number(b.number)}
+	})},
+	{"debug_getRawBlock", withBlock(func(f *fuzzer, b fuzzBlock) []interface{} {
+		return []interface{}{f.number(b.number)}
+	})},
+
+	{"trace_block", withBlock(func(f *fuzzer, b fuzzBlock) []interface{} {
+		return []interface{}{f.number(b.number)}
+	})},
+	{"trace_transaction", withTx(func(f *fuzzer, tx *fuzzTx) []interface{} {
+		return []interface{}{tx.Hash}
+	})},
+	{"trace_get", withTx(func(f *fuzzer, tx *fuzzTx) []interface{} {
+		return []interface{}{tx.Hash, []string{hexutil.EncodeUint64(uint64(f.rnd.Intn(3)))}}
+	})},
+	{"trace_replayTransaction", withTx(func(f *fuzzer, tx *fuzzTx) []interface{} {
+		return []interface{}{tx.Hash, []string{"trace", "stateDiff"}}
+	})},
+	{"trace_replayBlockTransactions", withBlock(func(f *fuzzer, b fuzzBlock) []interface{} {
+		return []interface{}{f.number(b.number), []string{"trace"}}
+	})},
+	{"trace_call", withCall(func(f *fuzzer, call map[string]interface{}, bn string) []interface{} {
+		return []interface{}{call, []string{"trace", "stateDiff"}, bn}
+	})},
+	{"trace_filter", withTx(func(f *fuzzer, tx *fuzzTx) []interface{} {
+		filter := map[string]interface{}{
+			"fromBlock": f.number(uint64(tx.BlockNumber)),
+			"toBlock":   f.number(uint64(tx.BlockNumber) + uint64(f.rnd.Intn(10))),
+		}
+		if f.rnd.Intn(2) == 0 {
+			filter["fromAddress"] = []libcommon.Address{tx.From}
+		} else if tx.To != nil {
+			filter["toAddress"] = []libcommon.Address{*tx.To}
+		}
+		return []interface{}{filter}
+	})},
+
+	{"erigon_forks", noParams},
+	{"erigon_getHeaderByNumber", withBlock(func(f *fuzzer, b fuzzBlock) []interface{} {
+		return []interface{}{b.number}
+	})},
+	{"erigon_getHeaderByHash", withBlock(func(f *fuzzer, b fuzzBlock) []interface{} {
+		return []interface{}{b.hash}
+	})},
+	{"erigon_getBlockByTimestamp", withBlock(func(f *fuzzer, b fuzzBlock) []interface{} {
+		return []interface{}{b.timestamp + uint64(f.rnd.Intn(3)), f.rnd.Intn(2) == 0}
+	})},
+	{"erigon_getBalanceChangesInBlock", withBlock(func(f *fuzzer, b fuzzBlock) []interface{} {
+		return []interface{}{f.number(b.number)}
+	})},
+	{"erigon_getLogsByHash", withBlock(func(f *fuzzer, b fuzzBlock) []interface{} {
+		return []interface{}{b.hash}
+	})},
+	{"erigon_getLogs", withFilter("fromBlock", "toBlock", func(f *fuzzer, filter map[string]interface{}) []interface{} {
+		return []interface{}{filter}
+	})},
+	{"erigon_getLogsCount", withFilter("fromBlock", "toBlock", func(f *fuzzer, filter map[string]interface{}) []interface{} {
+		return []interface{}{filter}
+	})},
+	{"erigon_getBlockReceiptsByBlockHash", withBlock(func(f *fuzzer, b fuzzBlock) []interface{} {
+		return []interface{}{b.hash}
+	})},
+
+	{"ots_getApiLevel", noParams},
+	{"ots_getBlockDetails", withBlock(func(f *fuzzer, b fuzzBlock) []interface{} {
+		return []interface{}{b.number}
+	})},
+	{"ots_getBlockDetailsByHash", withBlock(func(f *fuzzer, b fuzzBlock) []interface{} {
+		return []interface{}{b.hash}
+	})},
+	{"ots_getBlockTransactions", withBlock(func(f *fuzzer, b fuzzBlock) []interface{} {
+		return []interface{}{b.number, f.rnd.Intn(3), f.rnd.Intn(25) + 1}
+	})},
+	{"ots_hasCode", func(f *fuzzer) ([]interface{}, bool) {
+		return []interface{}{f.address(), f.blockRef()}, true
+	}},
+	{"ots_traceTransaction", withTx(func(f *fuzzer, tx *fuzzTx) []interface{} {
+		return []interface{}{tx.Hash}
+	})},
+	{"ots_getTransactionError", withTx(func(f *fuzzer, tx *fuzzTx) []interface{} {
+		return []interface{}{tx.Hash}
+	})},
+	{"ots_getInternalOperations", withTx(func(f *fuzzer, tx *fuzzTx) []interface{} {
+		return []interface{}{tx.Hash}
+	})},
+	{"ots_getTransactionBySenderAndNonce", withTx(func(f *fuzzer, tx *fuzzTx) []interface{} {
+		return []interface{}{tx.From, uint64(tx.Nonce)}
+	})},
+	{"ots_getContractCreator", withContract(func(f *fuzzer, contract libcommon.Address) []interface{} {
+		return []interface{}{contract}
+	})},
+	{"ots_searchTransactionsBefore", withTx(func(f *fuzzer, tx *fuzzTx) []interface{} {
+		return []interface{}{tx.From, uint64(tx.BlockNumber) + 1, f.rnd.Intn(25) + 1}
+	})},
+	{"ots_searchTransactionsAfter", withTx(func(f *fuzzer, tx *fuzzTx) []interface{} {
+		bn := uint64(tx.BlockNumber)
+		if bn > 0 {
+			bn--
+		}
+		return []interface{}{tx.From, bn, f.rnd.Intn(25) + 1}
+	})},
+
+	{"parity_listStorageKeys", withContract(func(f *fuzzer, contract libcommon.Address) []interface{} {
+		return []interface{}{contract, f.rnd.Intn(16) + 1, nil, f.blockRef()}
+	})},
+
+	{"net_version", noParams},
+
+	{"web3_sha3", withTx(func(f *fuzzer, tx *fuzzTx) []interface{} {
+		return []interface{}{tx.Input}
+	})},
+}
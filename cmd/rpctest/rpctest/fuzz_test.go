@@ -0,0 +1,88 @@
+package rpctest
+
+import (
+	"encoding/json"
+	"math/rand"
+	"testing"
+
+	libcommon "github.com/ledgerwatch/erigon-lib/common"
+	"github.com/stretchr/testify/require"
+	"github.com/valyala/fastjson"
+)
+
+func mockFuzzer(seed int64) *fuzzer {
+	to := libcommon.Address{2}
+	f := &fuzzer{
+		reqGen:    MockRequestGenerator(0),
+		rnd:       rand.New(rand.NewSource(seed)), // nolint:gosec
+		ignore:    map[string]struct{}{},
+		blocks:    []fuzzBlock{{number: 10, hash: libcommon.Hash{10}, timestamp: 100, txs: 1}},
+		txs:       []*fuzzTx{{Hash: libcommon.Hash{1}, BlockNumber: 10, BlockHash: libcommon.Hash{10}, From: libcommon.Address{1}, To: &to, Input: []byte{1, 2}}},
+		addresses: []libcommon.Address{{1}, to},
+		contracts: []libcommon.Address{to},
+		slots:     map[libcommon.Address][]libcommon.Hash{to: {{3}}},
+		logs:      []*fuzzLog{{Address: to, Topics: []libcommon.Hash{{4}, {5}}, block: 10}},
+	}
+	return f
+}
+
+func TestFuzzRequests(t *testing.T) {
+	methods, err := fuzzMethodsOf(nil)
+	require.NoError(t, err)
+	require.Len(t, methods, len(fuzzMethods))
+
+	generate := func(seed int64) []string {
+		f := mockFuzzer(seed)
+		var requests []string
+		for i := 0; i < 500; i++ {
+			method := methods[f.rnd.Intn(len(methods))]
+			params, ok := method.params(f)
+			require.True(t, ok, method.name)
+			f.reqGen.reqID++
+			request, err := f.request(method.name, params)
+			require.NoError(t, err)
+			var decoded struct {
+				Method string            `json:"method"`
+				Params []json.RawMessage `json:"params"`
+				ID     int               `json:"id"`
+			}
+			require.NoError(t, json.Unmarshal([]byte(request), &decoded), request)
+			require.Equal(t, method.name, decoded.Method)
+			require.Equal(t, f.reqGen.reqID, decoded.ID)
+			requests = append(requests, request)
+		}
+		return requests
+	}
+
+	// The requests are reproducible from the seed
+	require.Equal(t, generate(1), generate(1))
+	require.NotEqual(t, generate(1), generate(2))
+
+	methods, err = fuzzMethodsOf([]string{"ots", "net"})
+	require.NoError(t, err)
+	for _, method := range methods {
+		require.Regexp(t, "^(ots|net)_", method.name)
+	}
+	_, err = fuzzMethodsOf([]string{"admin"})
+	require.Error(t, err)
+}
+
+func TestFuzzCompare(t *testing.T) {
+	f := mockFuzzer(1)
+	parse := func(s string) *fastjson.Value {
+		return fastjson.MustParse(s)
+	}
+
+	require.NoError(t, f.compare(parse(`{"id":1,"result":{"a":"0x1","b":[{"c":1}]}}`), parse(`{"id":2,"result":{"b":[{"c":1}],"a":"0x1"}}`)))
+	require.Error(t, f.compare(parse(`{"result":{"a":"0x1","b":[{"c":1}]}}`), parse(`{"result":{"a":"0x1","b":[{"c":2}]}}`)))
+
+	// Errors only differ by their presence
+	require.NoError(t, f.compare(parse(`{"error":{"code":-32000,"message":"a"}}`), parse(`{"error":{"code":-32601,"message":"b"}}`)))
+	require.Error(t, f.compare(parse(`{"error":{"code":-32000,"message":"a"}}`), parse(`{"result":null}`)))
+	require.Error(t, f.compare(parse(`{"result":null}`), parse(`{"error":{"code":-32000,"message":"a"}}`)))
+
+	// Ignored fields are removed at any depth
+	f.ignore["c"] = struct{}{}
+	require.NoError(t, f.compare(parse(`{"result":{"a":"0x1","b":[{"c":1}]}}`), parse(`{"result":{"a":"0x1","b":[{"c":2}]}}`)))
+	require.Error(t, f.compare(parse(`{"result":{"a":"0x1","b":[{"c":1}]}}`), parse(`{"result":{"a":"0x2","b":[{"c":2}]}}`)))
+}
@@ -199,6 +199,21 @@ func (g *RequestGenerator) traceReplayTransaction(hash string) string {
 	return fmt.Sprintf(template, hash, g.reqID)
 }
 
+func (g *RequestGenerator) getRawBlock(bn uint64) string {
+	const template = `{"jsonrpc":"2.0","method":"debug_getRawBlock","params":["0x%x"],"id":%d}`
+	return fmt.Sprintf(template, bn, g.reqID)
+}
+
+func (g *RequestGenerator) getRawHeader(bn uint64) string {
+	const template = `{"jsonrpc":"2.0","method":"debug_getRawHeader","params":["0x%x"],"id":%d}`
+	return fmt.Sprintf(template, bn, g.reqID)
+}
+
+func (g *RequestGenerator) getBlockWitness(bn uint64) string {
+	const template = `{"jsonrpc":"2.0","method":"debug_getBlockWitness","params":["0x%x"],"id":%d}`
+	return fmt.Sprintf(template, bn, g.reqID)
+}
+
 func (g *RequestGenerator) ethCall(from libcommon.Address, to *libcommon.Address, gas *hexutil.Big, gasPrice *hexutil.Big, value *hexutil.Big, data hexutility.Bytes, bn uint64) string {
 	var sb strings.Builder
 	fmt.Fprintf(&sb, `{ "jsonrpc": "2.0", "method": "eth_call", "params": [{"from":"0x%x"`, from)
@@ -266,3 +266,8 @@ type ParityListStorageKeysResult struct {
 	CommonResponse
 	Result []hexutility.Bytes `json:"result"`
 }
+
+type DebugRawBytes struct {
+	CommonResponse
+	Result hexutility.Bytes `json:"result"`
+}
@@ -0,0 +1,84 @@
+package rpctest
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/ledgerwatch/log/v3"
+
+	"github.com/ledgerwatch/erigon/core"
+	"github.com/ledgerwatch/erigon/core/types"
+	"github.com/ledgerwatch/erigon/eth/ethconsensusconfig"
+	"github.com/ledgerwatch/erigon/params"
+	"github.com/ledgerwatch/erigon/rlp"
+)
+
+// VerifyBlockWitnesses verifies the blocks of [blockFrom, blockTo] statelessly: each block is executed with the witness
+// served by debug_getBlockWitness on top of its parent header, which must lead to the state root of its header
+func VerifyBlockWitnesses(logger log.Logger, erigonURL, chainName string, blockFrom, blockTo uint64) error {
+	chainConfig := params.ChainConfigByChainName(chainName)
+	if chainConfig == nil {
+		return fmt.Errorf("unknown chain %s", chainName)
+	}
+	if blockFrom == 0 {
+		return fmt.Errorf("the genesis block has no witness")
+	}
+	engine := ethconsensusconfig.CreateConsensusEngineBareBones(chainConfig, logger)
+	setRoutes(erigonURL, erigonURL)
+	reqGen := &RequestGenerator{
+		client: &http.Client{
+			Timeout: time.Second * 600,
+		},
+	}
+
+	get := func(method, request string) ([]byte, error) {
+		var res DebugRawBytes
+		if r := reqGen.Erigon(method, request, &res); r.Err != nil {
+			return nil, r.Err
+		}
+		if res.Error != nil {
+			return nil, fmt.Errorf("%s: %d %s", method, res.Error.Code, res.Error.Message)
+		}
+		return res.Result, nil
+	}
+
+	var parent *types.Header
+	for bn := blockFrom; bn <= blockTo; bn++ {
+		if parent == nil {
+			reqGen.reqID++
+			enc, err := get("debug_getRawHeader", reqGen.getRawHeader(bn-1))
+			if err != nil {
+				return err
+			}
+			parent = new(types.Header)
+			if err := rlp.DecodeBytes(enc, parent); err != nil {
+				return fmt.Errorf("decoding header %d: %w", bn-1, err)
+			}
+		}
+		reqGen.reqID++
+		enc, err := get("debug_getRawBlock", reqGen.getRawBlock(bn))
+		if err != nil {
+			return err
+		}
+		block := new(types.Block)
+		if err := rlp.DecodeBytes(enc, block); err != nil {
+			return fmt.Errorf("decoding block %d: %w", bn, err)
+		}
+		reqGen.reqID++
+		enc, err = get("debug_getBlockWitness", reqGen.getBlockWitness(bn))
+		if err != nil {
+			return err
+		}
+		witness, err := core.DecodeBlockWitness(enc)
+		if err != nil {
+			return fmt.Errorf("decoding witness of block %d: %w", bn, err)
+		}
+		if err := core.ExecuteBlockStateless(chainConfig, engine, parent, block, witness, logger); err != nil {
+			return err
+		}
+		logger.Info("Verified block", "number", bn, "witness", len(witness.State), "headers", len(witness.Headers))
+		parent = block.Header()
+	}
+	return nil
+}
@@ -22,35 +22,38 @@ import (
 )
 
 type ConsensusClientCliCfg struct {
-	GenesisCfg            *clparams.GenesisConfig
-	BeaconCfg             *clparams.BeaconChainConfig
-	NetworkCfg            *clparams.NetworkConfig
-	BeaconDataCfg         *rawdb.BeaconDataConfig
-	Port                  uint   `json:"port"`
-	Addr                  string `json:"address"`
-	ServerAddr            string `json:"serverAddr"`
-	ServerProtocol        string `json:"serverProtocol"`
-	ServerTcpPort         uint   `json:"serverTcpPort"`
-	LogLvl                uint   `json:"logLevel"`
-	NoDiscovery           bool   `json:"noDiscovery"`
-	LocalDiscovery        bool   `json:"localDiscovery"`
-	CheckpointUri         string `json:"checkpointUri"`
-	Chaindata             string `json:"chaindata"`
-	ErigonPrivateApi      string `json:"erigonPrivateApi"`
-	TransitionChain       bool   `json:"transitionChain"`
-	NetworkType           clparams.NetworkType
-	InitialSync           bool          `json:"initialSync"`
-	NoBeaconApi           bool          `json:"noBeaconApi"`
-	BeaconApiReadTimeout  time.Duration `json:"beaconApiReadTimeout"`
-	BeaconApiWriteTimeout time.Duration `json:"beaconApiWriteTimeout"`
-	BeaconAddr            string        `json:"beaconAddr"`
-	BeaconProtocol        string        `json:"beaconProtocol"`
-	RecordMode            bool          `json:"recordMode"`
-	RecordDir             string        `json:"recordDir"`
-	RunEngineAPI          bool          `json:"run_engine_api"`
-	EngineAPIAddr         string        `json:"engine_api_addr"`
-	EngineAPIPort         int           `json:"engine_api_port"`
-	JwtSecret             []byte
+	GenesisCfg              *clparams.GenesisConfig
+	BeaconCfg               *clparams.BeaconChainConfig
+	NetworkCfg              *clparams.NetworkConfig
+	BeaconDataCfg           *rawdb.BeaconDataConfig
+	Port                    uint   `json:"port"`
+	Addr                    string `json:"address"`
+	ServerAddr              string `json:"serverAddr"`
+	ServerProtocol          string `json:"serverProtocol"`
+	ServerTcpPort           uint   `json:"serverTcpPort"`
+	LogLvl                  uint   `json:"logLevel"`
+	NoDiscovery             bool   `json:"noDiscovery"`
+	LocalDiscovery          bool   `json:"localDiscovery"`
+	CheckpointUri           string `json:"checkpointUri"`
+	Chaindata               string `json:"chaindata"`
+	ErigonPrivateApi        string `json:"erigonPrivateApi"`
+	TransitionChain         bool   `json:"transitionChain"`
+	NetworkType             clparams.NetworkType
+	InitialSync             bool          `json:"initialSync"`
+	NoBeaconApi             bool          `json:"noBeaconApi"`
+	BeaconApiReadTimeout    time.Duration `json:"beaconApiReadTimeout"`
+	BeaconApiWriteTimeout   time.Duration `json:"beaconApiWriteTimeout"`
+	BeaconAddr              string        `json:"beaconAddr"`
+	BeaconProtocol          string        `json:"beaconProtocol"`
+	RecordMode              bool          `json:"recordMode"`
+	RecordDir               string        `json:"recordDir"`
+	ArchiveMode             bool          `json:"archiveMode"`
+	ArchiveDir              string        `json:"archiveDir"`
+	ArchiveSnapshotInterval uint64        `json:"archiveSnapshotInterval"`
+	RunEngineAPI            bool          `json:"run_engine_api"`
+	EngineAPIAddr           string        `json:"engine_api_addr"`
+	EngineAPIPort           int           `json:"engine_api_port"`
+	JwtSecret               []byte
 
 	InitalState *state.CachingBeaconState
 }
@@ -95,6 +98,9 @@ func SetupConsensusClientCfg(ctx *cli.Context) (*ConsensusClientCliCfg, error) {
 	cfg.BeaconProtocol = "tcp"
 	cfg.RecordMode = ctx.Bool(flags.RecordModeFlag.Name)
 	cfg.RecordDir = ctx.String(flags.RecordModeDir.Name)
+	cfg.ArchiveMode = ctx.Bool(flags.ArchiveModeFlag.Name)
+	cfg.ArchiveDir = ctx.String(flags.ArchiveDirFlag.Name)
+	cfg.ArchiveSnapshotInterval = ctx.Uint64(flags.ArchiveSnapshotIntervalFlag.Name)
 
 	cfg.RunEngineAPI = ctx.Bool(flags.RunEngineAPI.Name)
 	cfg.EngineAPIAddr = ctx.String(flags.EngineApiHostFlag.Name)
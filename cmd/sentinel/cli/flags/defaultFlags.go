@@ -26,6 +26,9 @@ var CLDefaultFlags = []cli.Flag{
 	&InitSyncFlag,
 	&RecordModeDir,
 	&RecordModeFlag,
+	&ArchiveModeFlag,
+	&ArchiveDirFlag,
+	&ArchiveSnapshotIntervalFlag,
 	&RunEngineAPI,
 	&EngineApiHostFlag,
 	&EngineApiPortFlag,
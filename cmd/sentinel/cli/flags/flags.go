@@ -88,7 +88,7 @@ var (
 	ChaindataFlag = cli.StringFlag{
 		Name:  "chaindata",
 		Usage: "chaindata of database",
-		Value: "",
+		Value: "caplin-chaindata",
 	}
 	BeaconDBModeFlag = cli.StringFlag{
 		Name:  "beacon-db-mode",
@@ -149,4 +149,19 @@ var (
 		Name:  "record-dir",
 		Usage: "directory for states and block recordings",
 	}
+	ArchiveModeFlag = cli.BoolFlag{
+		Value: false,
+		Name:  "archive-mode",
+		Usage: "enable/disable archiving of historical beacon states",
+	}
+	ArchiveDirFlag = cli.StringFlag{
+		Value: "caplin-archive",
+		Name:  "archive-dir",
+		Usage: "directory for the historical beacon states archive",
+	}
+	ArchiveSnapshotIntervalFlag = cli.Uint64Flag{
+		Value: 64,
+		Name:  "archive-snapshot-interval",
+		Usage: "how many epochs between full beacon state snapshots in the archive, diffs are stored in between",
+	}
 )
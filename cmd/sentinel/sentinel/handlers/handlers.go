@@ -65,6 +65,11 @@ func NewConsensusHandlers(ctx context.Context, db kv.RoDB, host host.Host,
 		communication.MetadataProtocolV2:            c.metadataV2Handler,
 		communication.BeaconBlocksByRangeProtocolV1: c.blocksByRangeHandler,
 		communication.BeaconBlocksByRootProtocolV1:  c.beaconBlocksByRootHandler,
+
+		communication.LightClientBootstrapProtocolV1:        c.lightClientBootstrapHandler,
+		communication.LightClientUpdatesByRangeProtocolV1:   c.lightClientUpdatesByRangeHandler,
+		communication.LightClientFinalityUpdateProtocolV1:   c.lightClientFinalityUpdateHandler,
+		communication.LightClientOptimisticUpdateProtocolV1: c.lightClientOptimisticUpdateHandler,
 	}
 
 	c.handlers = map[protocol.ID]network.StreamHandler{}
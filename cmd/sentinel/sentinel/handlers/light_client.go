@@ -0,0 +1,127 @@
+/*
+   Copyright 2022 Erigon-Lightclient contributors
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+       http://www.apache.org/licenses/LICENSE-2.0
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package handlers
+
+import (
+	"github.com/ledgerwatch/erigon-lib/kv"
+	"github.com/ledgerwatch/erigon-lib/types/ssz"
+	"github.com/ledgerwatch/erigon/cl/clparams"
+	"github.com/ledgerwatch/erigon/cl/cltypes"
+	"github.com/ledgerwatch/erigon/cl/cltypes/solid"
+	"github.com/ledgerwatch/erigon/cl/fork"
+	"github.com/ledgerwatch/erigon/cl/phase1/core/rawdb"
+	"github.com/ledgerwatch/erigon/cl/utils"
+	"github.com/ledgerwatch/erigon/cmd/sentinel/sentinel/communication"
+	"github.com/ledgerwatch/erigon/cmd/sentinel/sentinel/communication/ssz_snappy"
+	"github.com/ledgerwatch/log/v3"
+	"github.com/libp2p/go-libp2p/core/network"
+)
+
+func (c *ConsensusHandlers) lightClientBootstrapHandler(s network.Stream) error {
+	log.Trace("Got light client bootstrap handler call")
+	root := solid.NewHashVector(1)
+	if err := ssz_snappy.DecodeAndReadNoForkDigest(s, root, clparams.Phase0Version); err != nil {
+		return err
+	}
+	if c.db == nil {
+		return ssz_snappy.EncodeAndWrite(s, &emptyString{}, ResourceUnavaiablePrefix)
+	}
+	var bootstrap *cltypes.LightClientBootstrap
+	if err := c.db.View(c.ctx, func(tx kv.Tx) (err error) {
+		bootstrap, err = rawdb.ReadLightClientBootstrap(tx, root.Get(0))
+		return
+	}); err != nil {
+		return err
+	}
+	if bootstrap == nil {
+		return ssz_snappy.EncodeAndWrite(s, &emptyString{}, ResourceUnavaiablePrefix)
+	}
+	return c.writeLightClientObject(s, bootstrap, bootstrap.Version())
+}
+
+func (c *ConsensusHandlers) lightClientUpdatesByRangeHandler(s network.Stream) error {
+	log.Trace("Got light client updates by range handler call")
+	req := &cltypes.LightClientUpdatesByRangeRequest{}
+	if err := ssz_snappy.DecodeAndReadNoForkDigest(s, req, clparams.Phase0Version); err != nil {
+		return err
+	}
+	if c.db == nil {
+		return ssz_snappy.EncodeAndWrite(s, &emptyString{}, ResourceUnavaiablePrefix)
+	}
+	count := utils.Min64(req.Count, communication.MaximumRequestClientUpdates)
+	tx, err := c.db.BeginRo(c.ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	for period := req.StartPeriod; period < req.StartPeriod+count; period++ {
+		update, err := rawdb.ReadLightClientUpdate(tx, period)
+		if err != nil {
+			return err
+		}
+		// Updates must be consecutive, so we stop at the first missing period.
+		if update == nil {
+			break
+		}
+		if err := c.writeLightClientObject(s, update, update.Version()); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (c *ConsensusHandlers) lightClientFinalityUpdateHandler(s network.Stream) error {
+	log.Trace("Got light client finality update handler call")
+	if c.db == nil {
+		return ssz_snappy.EncodeAndWrite(s, &emptyString{}, ResourceUnavaiablePrefix)
+	}
+	var update *cltypes.LightClientFinalityUpdate
+	if err := c.db.View(c.ctx, func(tx kv.Tx) (err error) {
+		update, err = rawdb.ReadLightClientFinalityUpdate(tx)
+		return
+	}); err != nil {
+		return err
+	}
+	if update == nil {
+		return ssz_snappy.EncodeAndWrite(s, &emptyString{}, ResourceUnavaiablePrefix)
+	}
+	return c.writeLightClientObject(s, update, update.Version())
+}
+
+func (c *ConsensusHandlers) lightClientOptimisticUpdateHandler(s network.Stream) error {
+	log.Trace("Got light client optimistic update handler call")
+	if c.db == nil {
+		return ssz_snappy.EncodeAndWrite(s, &emptyString{}, ResourceUnavaiablePrefix)
+	}
+	var update *cltypes.LightClientOptimisticUpdate
+	if err := c.db.View(c.ctx, func(tx kv.Tx) (err error) {
+		update, err = rawdb.ReadLightClientOptimisticUpdate(tx)
+		return
+	}); err != nil {
+		return err
+	}
+	if update == nil {
+		return ssz_snappy.EncodeAndWrite(s, &emptyString{}, ResourceUnavaiablePrefix)
+	}
+	return c.writeLightClientObject(s, update, update.Version())
+}
+
+// writeLightClientObject writes a response chunk, light client objects have the fork digest of their version as context bytes.
+func (c *ConsensusHandlers) writeLightClientObject(s network.Stream, obj ssz.Marshaler, version clparams.StateVersion) error {
+	digest, err := fork.ComputeForkDigestForStateVersion(version, c.beaconConfig, c.genesisConfig.GenesisValidatorRoot)
+	if err != nil {
+		return err
+	}
+	return ssz_snappy.EncodeAndWrite(s, obj, append([]byte{SuccessfulResponsePrefix}, digest[:]...)...)
+}
@@ -44,6 +44,9 @@ const (
 	ProposerSlashingTopic        TopicName = "proposer_slashing"
 	AttesterSlashingTopic        TopicName = "attester_slashing"
 	BlobSidecarTopic             TopicName = "blob_sidecar_%d" // This topic needs an index
+
+	LightClientFinalityUpdateTopic   TopicName = "light_client_finality_update"
+	LightClientOptimisticUpdateTopic TopicName = "light_client_optimistic_update"
 )
 
 type GossipTopic struct {
@@ -71,6 +74,14 @@ var AttesterSlashingSsz = GossipTopic{
 	Name:     AttesterSlashingTopic,
 	CodecStr: SSZSnappyCodec,
 }
+var LightClientFinalityUpdateSsz = GossipTopic{
+	Name:     LightClientFinalityUpdateTopic,
+	CodecStr: SSZSnappyCodec,
+}
+var LightClientOptimisticUpdateSsz = GossipTopic{
+	Name:     LightClientOptimisticUpdateTopic,
+	CodecStr: SSZSnappyCodec,
+}
 
 type GossipManager struct {
 	ch            chan *pubsub.Message
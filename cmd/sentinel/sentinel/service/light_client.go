@@ -0,0 +1,70 @@
+package service
+
+import (
+	"time"
+
+	"github.com/ledgerwatch/erigon-lib/kv"
+	"github.com/ledgerwatch/erigon-lib/types/ssz"
+	"github.com/ledgerwatch/erigon/cl/clparams"
+	"github.com/ledgerwatch/erigon/cl/cltypes"
+	"github.com/ledgerwatch/erigon/cl/phase1/core/rawdb"
+	"github.com/ledgerwatch/erigon/cl/utils"
+	"github.com/ledgerwatch/erigon/cmd/sentinel/sentinel"
+)
+
+// publishLightClientUpdates gossips, once per slot, the light client updates Caplin stored in the database shared with the sentinel.
+func (s *SentinelServer) publishLightClientUpdates(beaconCfg *clparams.BeaconChainConfig) {
+	db := s.sentinel.DB()
+	if db == nil {
+		return
+	}
+	ticker := time.NewTicker(time.Duration(beaconCfg.SecondsPerSlot) * time.Second)
+	defer ticker.Stop()
+
+	var lastFinalitySlot, lastOptimisticSlot uint64
+	for {
+		select {
+		case <-s.ctx.Done():
+			return
+		case <-ticker.C:
+		}
+		var (
+			finality   *cltypes.LightClientFinalityUpdate
+			optimistic *cltypes.LightClientOptimisticUpdate
+		)
+		if err := db.View(s.ctx, func(tx kv.Tx) (err error) {
+			if finality, err = rawdb.ReadLightClientFinalityUpdate(tx); err != nil {
+				return
+			}
+			optimistic, err = rawdb.ReadLightClientOptimisticUpdate(tx)
+			return
+		}); err != nil {
+			s.logger.Debug("[Sentinel] could not read light client updates", "err", err)
+			continue
+		}
+		if finality != nil && finality.SignatureSlot > lastFinalitySlot {
+			if err := s.publishSSZ(sentinel.LightClientFinalityUpdateTopic, finality); err != nil {
+				s.logger.Debug("[Sentinel] could not publish light client finality update", "err", err)
+			}
+			lastFinalitySlot = finality.SignatureSlot
+		}
+		if optimistic != nil && optimistic.SignatureSlot > lastOptimisticSlot {
+			if err := s.publishSSZ(sentinel.LightClientOptimisticUpdateTopic, optimistic); err != nil {
+				s.logger.Debug("[Sentinel] could not publish light client optimistic update", "err", err)
+			}
+			lastOptimisticSlot = optimistic.SignatureSlot
+		}
+	}
+}
+
+func (s *SentinelServer) publishSSZ(topic sentinel.TopicName, obj ssz.Marshaler) error {
+	subscription := s.sentinel.GossipManager().GetMatchingSubscription(string(topic))
+	if subscription == nil {
+		return nil
+	}
+	encoded, err := obj.EncodeSSZ(nil)
+	if err != nil {
+		return err
+	}
+	return subscription.Publish(utils.CompressSnappy(encoded))
+}
@@ -39,6 +39,8 @@ func createSentinel(cfg *sentinel.SentinelConfig, db kv.RoDB, logger log.Logger)
 		//sentinel.VoluntaryExitSsz,
 		//sentinel.ProposerSlashingSsz,
 		//sentinel.AttesterSlashingSsz,
+		sentinel.LightClientFinalityUpdateSsz,
+		sentinel.LightClientOptimisticUpdateSsz,
 	}
 	// gossipTopics = append(gossipTopics, sentinel.GossipSidecarTopics(chain.MaxBlobsPerBlock)...)
 
@@ -78,6 +80,7 @@ func StartSentinelService(cfg *sentinel.SentinelConfig, db kv.RoDB, srvCfg *Serv
 	}
 
 	go StartServe(server, srvCfg, creds)
+	go server.publishLightClientUpdates(cfg.BeaconConfig)
 	timeOutTimer := time.NewTimer(5 * time.Second)
 WaitingLoop:
 	for {
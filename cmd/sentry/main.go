@@ -11,6 +11,8 @@ import (
 	"github.com/ledgerwatch/erigon/cmd/sentry/sentry"
 	"github.com/ledgerwatch/erigon/cmd/utils"
 	"github.com/ledgerwatch/erigon/common/paths"
+	"github.com/ledgerwatch/erigon/p2p"
+	"github.com/ledgerwatch/erigon/p2p/txpolicy"
 	"github.com/ledgerwatch/erigon/turbo/debug"
 	"github.com/ledgerwatch/erigon/turbo/logging"
 	node2 "github.com/ledgerwatch/erigon/turbo/node"
@@ -30,7 +32,10 @@ var (
 	nodiscover   bool // disable sentry's discovery mechanism
 	protocol     uint
 	allowedPorts []uint
-	netRestrict  string // CIDR to restrict peering to
+	netRestrict  string   // CIDR to restrict peering to
+	enrFilter    []string // predicates on the node records of dial candidates
+	txBroadcast  string   // how transactions are broadcast in full
+	txPeerRate   int      // transactions and announcements sent to a peer per second
 	maxPeers     int
 	maxPendPeers int
 	healthCheck  bool
@@ -51,6 +56,9 @@ func init() {
 	rootCmd.Flags().UintVar(&protocol, utils.P2pProtocolVersionFlag.Name, utils.P2pProtocolVersionFlag.Value.Value()[0], utils.P2pProtocolVersionFlag.Usage)
 	rootCmd.Flags().UintSliceVar(&allowedPorts, utils.P2pProtocolAllowedPorts.Name, utils.P2pProtocolAllowedPorts.Value.Value(), utils.P2pProtocolAllowedPorts.Usage)
 	rootCmd.Flags().StringVar(&netRestrict, utils.NetrestrictFlag.Name, utils.NetrestrictFlag.Value, utils.NetrestrictFlag.Usage)
+	rootCmd.Flags().StringSliceVar(&enrFilter, utils.P2pENRFilterFlag.Name, []string{}, utils.P2pENRFilterFlag.Usage)
+	rootCmd.Flags().StringVar(&txBroadcast, utils.P2pTxBroadcastFlag.Name, utils.P2pTxBroadcastFlag.Value, utils.P2pTxBroadcastFlag.Usage)
+	rootCmd.Flags().IntVar(&txPeerRate, utils.P2pTxPeerRateFlag.Name, utils.P2pTxPeerRateFlag.Value, utils.P2pTxPeerRateFlag.Usage)
 	rootCmd.Flags().IntVar(&maxPeers, utils.MaxPeersFlag.Name, utils.MaxPeersFlag.Value, utils.MaxPeersFlag.Usage)
 	rootCmd.Flags().IntVar(&maxPendPeers, utils.MaxPendingPeersFlag.Name, utils.MaxPendingPeersFlag.Value, utils.MaxPendingPeersFlag.Usage)
 	rootCmd.Flags().BoolVar(&healthCheck, utils.HealthCheckFlag.Name, false, utils.HealthCheckFlag.Usage)
@@ -92,6 +100,15 @@ var rootCmd = &cobra.Command{
 		if err != nil {
 			return err
 		}
+		if _, err := p2p.ParseENRFilter(enrFilter); err != nil {
+			return fmt.Errorf("bad option %s: %w", utils.P2pENRFilterFlag.Name, err)
+		}
+		p2pConfig.ENRFilter = enrFilter
+		if err := txpolicy.CheckBroadcast(txBroadcast); err != nil {
+			return fmt.Errorf("bad option %s: %w", utils.P2pTxBroadcastFlag.Name, err)
+		}
+		p2pConfig.TxBroadcast = txBroadcast
+		p2pConfig.TxPeerRate = txPeerRate
 
 		logger := debug.SetupCobra(cmd, "sentry")
 		return sentry.Sentry(cmd.Context(), dirs, sentryAddr, discoveryDNS, p2pConfig, protocol, healthCheck, logger)
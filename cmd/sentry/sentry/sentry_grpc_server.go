@@ -57,7 +57,11 @@ type PeerInfo struct {
 	peer          *p2p.Peer
 	lock          sync.RWMutex
 	deadlines     []time.Time // Request deadlines
+	requested     []time.Time // Request times, in the order of the deadlines
 	latestDealine time.Time
+	scores        *p2p.PeerScores // Records the timeouts and the response latencies, if set
+	txWindow      time.Time       // Start of the current second of the transaction rate cap
+	txWindowCount int             // Transactions and announcements sent to the peer in the current second
 	height        uint64
 	rw            p2p.MsgReadWriter
 	protocol      uint
@@ -75,9 +79,11 @@ type PeerInfo struct {
 type PeerRef struct {
 	pi     *PeerInfo
 	height uint64
+	score  int64
 }
 
-// PeersByMinBlock is the priority queue of peers. Used to select certain number of peers considered to be "best available"
+// PeersByMinBlock is the priority queue of peers. Used to select certain number of peers considered to be "best available":
+// the highest ones, and the ones with the best scores among the peers of the same height
 type PeersByMinBlock []PeerRef
 
 // Len (part of heap.Interface) returns the current size of the best peers queue
@@ -87,6 +93,9 @@ func (bp PeersByMinBlock) Len() int {
 
 // Less (part of heap.Interface) compares two peers
 func (bp PeersByMinBlock) Less(i, j int) bool {
+	if bp[i].height == bp[j].height {
+		return bp[i].score < bp[j].score
+	}
 	return bp[i].height < bp[j].height
 }
 
@@ -151,6 +160,7 @@ func (pi *PeerInfo) AddDeadline(deadline time.Time) {
 	pi.lock.Lock()
 	defer pi.lock.Unlock()
 	pi.deadlines = append(pi.deadlines, deadline)
+	pi.requested = append(pi.requested, time.Now())
 	pi.latestDealine = deadline
 }
 
@@ -171,20 +181,39 @@ func (pi *PeerInfo) SetIncreasedHeight(newHeight uint64) {
 // ClearDeadlines goes through the deadlines of
 // given peers and removes the ones that have passed
 // Optionally, it also clears one extra deadline - this is used when response is received
+// The passed deadlines count as timeouts and the cleared extra one as a response in the peer scores
 // It returns the number of deadlines left
 func (pi *PeerInfo) ClearDeadlines(now time.Time, givePermit bool) int {
 	pi.lock.Lock()
-	defer pi.lock.Unlock()
 	// Look for the first deadline which is not passed yet
 	firstNotPassed := sort.Search(len(pi.deadlines), func(i int) bool {
 		return pi.deadlines[i].After(now)
 	})
 	cutOff := firstNotPassed
+	var latency time.Duration
+	answered := false
 	if cutOff < len(pi.deadlines) && givePermit {
+		latency = now.Sub(pi.requested[cutOff])
+		answered = true
 		cutOff++
 	}
 	pi.deadlines = pi.deadlines[cutOff:]
-	return len(pi.deadlines)
+	pi.requested = pi.requested[cutOff:]
+	left := len(pi.deadlines)
+	scores := pi.scores
+	pi.lock.Unlock()
+
+	if scores != nil {
+		if firstNotPassed > 0 && scores.Timeouts(pi.peer.ID(), firstNotPassed) {
+			if info := pi.peer.Info(); !info.Network.Static && !info.Network.Trusted {
+				pi.Remove()
+			}
+		}
+		if answered {
+			scores.Response(pi.peer.ID(), latency)
+		}
+	}
+	return left
 }
 
 func (pi *PeerInfo) LatestDeadline() time.Time {
@@ -557,6 +586,8 @@ func NewGrpcServer(ctx context.Context, dialCandidates func() enode.Iterator, re
 		ctx:          ctx,
 		p2p:          cfg,
 		peersStreams: NewPeersStreams(),
+		peerScores:   p2p.NewPeerScores(),
+		txPolicy:     newTxPolicy(cfg, cfg.PrivateTxs),
 		logger:       logger,
 	}
 
@@ -584,8 +615,16 @@ func NewGrpcServer(ctx context.Context, dialCandidates func() enode.Iterator, re
 				}
 				logger.Trace("[p2p] start with peer", "peerId", printablePeerID)
 
+				banned := ss.peerScores.Connect(peer.ID(), peer.Fullname())
+				defer ss.peerScores.Disconnect(peer.ID())
+				if banned && !peer.Info().Network.Static && !peer.Info().Network.Trusted {
+					logger.Trace("[p2p] peer is banned", "peerId", printablePeerID)
+					return p2p.DiscUselessPeer
+				}
+
 				peerInfo := NewPeerInfo(peer, rw)
 				peerInfo.protocol = protocol
+				peerInfo.scores = ss.peerScores
 				defer peerInfo.Close()
 
 				defer ss.GoodPeers.Delete(peerID)
@@ -663,7 +702,9 @@ type GrpcServer struct {
 	Protocols            []p2p.Protocol
 	discoveryDNS         []string
 	GoodPeers            sync.Map
+	snapPeers            sync.Map // peers connected with the snap protocol, see EnableSnap
 	statusData           *proto_sentry.StatusData
+	forkFilter           forkid.Filter // filter of the fork IDs of dial candidates, updated with the status
 	P2pServer            *p2p.Server
 	TxSubscribed         uint32 // Set to non-zero if downloader is subscribed to transaction messages
 	lock                 sync.RWMutex
@@ -671,6 +712,8 @@ type GrpcServer struct {
 	messagesSubscriberID uint64
 	messageStreamsLock   sync.RWMutex
 	peersStreams         *PeersStreams
+	peerScores           *p2p.PeerScores // Reputations of the peers, persisted in the node database of the p2p server
+	txPolicy             *txPolicy       // Decides which transactions are sent to which peers
 	p2p                  *p2p.Config
 	logger               log.Logger
 }
@@ -750,6 +793,8 @@ func (ss *GrpcServer) startSync(ctx context.Context, bestHash libcommon.Hash, pe
 func (ss *GrpcServer) PenalizePeer(_ context.Context, req *proto_sentry.PenalizePeerRequest) (*emptypb.Empty, error) {
 	//log.Warn("Received penalty", "kind", req.GetPenalty().Descriptor().FullName, "from", fmt.Sprintf("%s", req.GetPeerId()))
 	peerID := ConvertH512ToPeerID(req.PeerId)
+	// The penalties are sent for invalid blocks and messages
+	ss.peerScores.Invalid(enode.PubkeyEncoded(peerID).ID())
 	peerInfo := ss.getPeer(peerID)
 	if ss.statusData != nil && peerInfo != nil && !peerInfo.peer.Info().Network.Static && !peerInfo.peer.Info().Network.Trusted {
 		ss.removePeer(peerID)
@@ -778,7 +823,7 @@ func (ss *GrpcServer) findBestPeersWithPermit(peerCount int) []*PeerInfo {
 		height := peerInfo.Height()
 		//fmt.Printf("%d deadlines for peer %s\n", deadlines, peerID)
 		if deadlines < maxPermitsPerPeer {
-			heap.Push(&byMinBlock, PeerRef{pi: peerInfo, height: height})
+			heap.Push(&byMinBlock, PeerRef{pi: peerInfo, height: height, score: ss.peerScores.Score(peerInfo.peer.ID())})
 			if byMinBlock.Len() > peerCount {
 				// Remove the worst peer
 				peerRef := heap.Pop(&byMinBlock).(PeerRef)
@@ -805,9 +850,11 @@ func (ss *GrpcServer) findBestPeersWithPermit(peerCount int) []*PeerInfo {
 }
 
 func (ss *GrpcServer) findPeerByMinBlock(minBlock uint64) (*PeerInfo, bool) {
-	// Choose a peer that we can send this request to, with maximum number of permits
+	// Choose a peer that we can send this request to, with maximum number of permits,
+	// and the best score among the peers with the same number of permits
 	var foundPeerInfo *PeerInfo
 	var maxPermits int
+	var maxScore int64
 	now := time.Now()
 	ss.rangePeers(func(peerInfo *PeerInfo) bool {
 		if peerInfo.Height() >= minBlock {
@@ -815,8 +862,10 @@ func (ss *GrpcServer) findPeerByMinBlock(minBlock uint64) (*PeerInfo, bool) {
 			//fmt.Printf("%d deadlines for peer %s\n", deadlines, peerID)
 			if deadlines < maxPermitsPerPeer {
 				permits := maxPermitsPerPeer - deadlines
-				if permits > maxPermits {
+				score := ss.peerScores.Score(peerInfo.peer.ID())
+				if permits > maxPermits || (permits == maxPermits && score > maxScore) {
 					maxPermits = permits
+					maxScore = score
 					foundPeerInfo = peerInfo
 				}
 			}
@@ -873,7 +922,25 @@ func (ss *GrpcServer) SendMessageById(_ context.Context, inreq *proto_sentry.Sen
 		return reply, nil
 	}
 
-	ss.writePeer("[sentry] sendMessageById", peerInfo, msgcode, inreq.Data.Data, 0)
+	txs, isTx, err := ss.txPolicy.filter(inreq.Data.Id, msgcode, inreq.Data.Data)
+	if err != nil {
+		return reply, err
+	}
+	if isTx {
+		if txs.data == nil {
+			return reply, nil
+		}
+		if msgcode == eth.NewPooledTransactionHashesMsg {
+			if !ss.txPolicy.allow(peerInfo, inreq.Data.Id, txs.kept, time.Now()) {
+				return reply, nil
+			}
+		} else {
+			// Responses to the requests of the peer aren't rate capped
+			ss.txPolicy.sent(peerInfo, inreq.Data.Id, txs.kept)
+		}
+	}
+
+	ss.writePeer("[sentry] sendMessageById", peerInfo, msgcode, txs.data, 0)
 	reply.Peers = []*proto_types.H512{inreq.PeerId}
 	return reply, nil
 }
@@ -888,6 +955,13 @@ func (ss *GrpcServer) SendMessageToRandomPeers(ctx context.Context, req *proto_s
 		msgcode != eth.TransactionsMsg {
 		return reply, fmt.Errorf("sendMessageToRandomPeers not implemented for message Id: %s", req.Data.Id)
 	}
+	txs, isTx, err := ss.txPolicy.filter(req.Data.Id, msgcode, req.Data.Data)
+	if err != nil {
+		return reply, err
+	}
+	if isTx && txs.data == nil {
+		return reply, nil
+	}
 
 	peerInfos := make([]*PeerInfo, 0, 32) // 32 gives capacity for 1024 peers, well beyond default
 	ss.rangePeers(func(peerInfo *PeerInfo) bool {
@@ -897,6 +971,17 @@ func (ss *GrpcServer) SendMessageToRandomPeers(ctx context.Context, req *proto_s
 	rand.Shuffle(len(peerInfos), func(i int, j int) {
 		peerInfos[i], peerInfos[j] = peerInfos[j], peerInfos[i]
 	})
+	if isTx {
+		// The peers over their rate cap are left out before choosing the subset, so that it is as large as it can be
+		now := time.Now()
+		capped := peerInfos[:0]
+		for _, peerInfo := range peerInfos {
+			if ss.txPolicy.fits(peerInfo, txs.kept, now) {
+				capped = append(capped, peerInfo)
+			}
+		}
+		peerInfos = capped
+	}
 	peersToSendCount := len(peerInfos)
 	if peersToSendCount > 0 {
 		peerCountConstrained := math.Min(float64(len(peerInfos)), float64(req.MaxPeers))
@@ -906,8 +991,12 @@ func (ss *GrpcServer) SendMessageToRandomPeers(ctx context.Context, req *proto_s
 
 	var lastErr error
 	// Send the block to a subset of our peers at random
+	now := time.Now()
 	for _, peerInfo := range peerInfos[:peersToSendCount] {
-		ss.writePeer("[sentry] sendMessageToRandomPeers", peerInfo, msgcode, req.Data.Data, 0)
+		if isTx && !ss.txPolicy.allow(peerInfo, req.Data.Id, txs.kept, now) {
+			continue
+		}
+		ss.writePeer("[sentry] sendMessageToRandomPeers", peerInfo, msgcode, txs.data, 0)
 		reply.Peers = append(reply.Peers, gointerfaces.ConvertHashToH512(peerInfo.ID()))
 	}
 	return reply, lastErr
@@ -922,10 +1011,21 @@ func (ss *GrpcServer) SendMessageToAll(ctx context.Context, req *proto_sentry.Ou
 		msgcode != eth.NewBlockHashesMsg {
 		return reply, fmt.Errorf("sendMessageToAll not implemented for message Id: %s", req.Id)
 	}
+	txs, isTx, err := ss.txPolicy.filter(req.Id, msgcode, req.Data)
+	if err != nil {
+		return reply, err
+	}
+	if isTx && txs.data == nil {
+		return reply, nil
+	}
 
 	var lastErr error
+	now := time.Now()
 	ss.rangePeers(func(peerInfo *PeerInfo) bool {
-		ss.writePeer("[sentry] SendMessageToAll", peerInfo, msgcode, req.Data, 0)
+		if isTx && !ss.txPolicy.allow(peerInfo, req.Id, txs.kept, now) {
+			return true
+		}
+		ss.writePeer("[sentry] SendMessageToAll", peerInfo, msgcode, txs.data, 0)
 		reply.Peers = append(reply.Peers, gointerfaces.ConvertHashToH512(peerInfo.ID()))
 		return true
 	})
@@ -968,7 +1068,12 @@ func (ss *GrpcServer) SetStatus(ctx context.Context, statusData *proto_sentry.St
 			}
 		}
 
-		srv, err := makeP2PServer(*ss.p2p, genesisHash, ss.Protocols)
+		p2pConfig := *ss.p2p
+		forkFilter := eth.NewNodeFilter(ss.getForkFilter)
+		p2pConfig.DialFilter = func(node *enode.Node) bool {
+			return forkFilter(node) && !ss.peerScores.Banned(node.ID())
+		}
+		srv, err := makeP2PServer(p2pConfig, genesisHash, ss.Protocols)
 		if err != nil {
 			return reply, err
 		}
@@ -980,16 +1085,26 @@ func (ss *GrpcServer) SetStatus(ctx context.Context, statusData *proto_sentry.St
 		}
 
 		ss.P2pServer = srv
+		ss.peerScores.SetDB(srv.NodeDB())
 	}
 
 	ss.P2pServer.LocalNode().Set(eth.CurrentENREntryFromForks(statusData.ForkData.HeightForks, statusData.ForkData.TimeForks, genesisHash, statusData.MaxBlockHeight, statusData.MaxBlockTime))
 	if ss.statusData == nil || statusData.MaxBlockHeight != 0 {
 		// Not overwrite statusData if the message contains zero MaxBlock (comes from standalone transaction pool)
 		ss.statusData = statusData
+		ss.forkFilter = forkid.NewFilterFromForks(statusData.ForkData.HeightForks, statusData.ForkData.TimeForks, genesisHash, statusData.MaxBlockHeight, statusData.MaxBlockTime)
 	}
 	return reply, nil
 }
 
+// getForkFilter returns the filter of the fork IDs of the peers compatible with the current status, nil before the
+// first status.
+func (ss *GrpcServer) getForkFilter() forkid.Filter {
+	ss.lock.RLock()
+	defer ss.lock.RUnlock()
+	return ss.forkFilter
+}
+
 func (ss *GrpcServer) Peers(_ context.Context, _ *emptypb.Empty) (*proto_sentry.PeersReply, error) {
 	if ss.P2pServer == nil {
 		return nil, errors.New("p2p server was not started")
@@ -1160,8 +1275,17 @@ func (ss *GrpcServer) Messages(req *proto_sentry.MessagesRequest, server proto_s
 	}
 }
 
+// Server returns the p2p server of the sentry, it is nil until the first status is set.
+func (ss *GrpcServer) Server() *p2p.Server {
+	ss.lock.RLock()
+	defer ss.lock.RUnlock()
+	return ss.P2pServer
+}
+
 // Close performs cleanup operations for the sentry
 func (ss *GrpcServer) Close() {
+	ss.peerScores.Close()
+	ss.txPolicy.close()
 	if ss.P2pServer != nil {
 		ss.P2pServer.Stop()
 	}
@@ -1208,7 +1332,13 @@ func (ss *GrpcServer) NodeInfo(_ context.Context, _ *emptypb.Empty) (*proto_type
 		ListenerAddr: info.ListenAddr,
 	}
 
-	protos, err := json.Marshal(info.Protocols)
+	protocols := make(map[string]interface{}, len(info.Protocols)+2)
+	for name, protocol := range info.Protocols {
+		protocols[name] = protocol
+	}
+	protocols[p2p.DiscoveryStatsProtocolKey] = info.Discovery
+	protocols[p2p.PeerScoresProtocolKey] = ss.peerScores.List()
+	protos, err := json.Marshal(protocols)
 	if err != nil {
 		return nil, fmt.Errorf("cannot encode protocols map: %w", err)
 	}
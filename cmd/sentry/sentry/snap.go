@@ -0,0 +1,138 @@
+package sentry
+
+import (
+	"context"
+	"encoding/hex"
+	"fmt"
+
+	libcommon "github.com/ledgerwatch/erigon-lib/common"
+	"github.com/ledgerwatch/erigon-lib/kv"
+	"github.com/ledgerwatch/log/v3"
+
+	"github.com/ledgerwatch/erigon/eth/protocols/eth"
+	"github.com/ledgerwatch/erigon/eth/protocols/snap"
+	"github.com/ledgerwatch/erigon/p2p"
+	"github.com/ledgerwatch/erigon/turbo/services"
+)
+
+// EnableSnap makes the sentry serve the snap/1 protocol next to eth, answering the requests from the state in db.
+// It must be called before the p2p server is started by SetStatus. The snap protocol has no message ids in the sentry
+// gRPC interface, so only in-process sentries, which have access to the state, can serve it.
+func (ss *GrpcServer) EnableSnap(db kv.RoDB, blockReader services.HeaderReader) {
+	prover := snap.NewProver()
+	ss.Protocols = append(ss.Protocols, p2p.Protocol{
+		Name:    snap.ProtocolName,
+		Version: snap.SNAP1,
+		Length:  snap.ProtocolLength,
+		Run: func(peer *p2p.Peer, rw p2p.MsgReadWriter) error {
+			peerID := peer.Pubkey()
+			printablePeerID := hex.EncodeToString(peerID[:])[:20]
+			snapPeer := snap.NewPeer(hex.EncodeToString(peerID[:]), rw)
+			ss.snapPeers.Store(peerID, snapPeer)
+			defer ss.snapPeers.Delete(peerID)
+
+			err := runSnapPeer(ss.ctx, db, blockReader, prover, snapPeer, rw, ss.logger)
+			ss.logger.Trace("[p2p] error while running snap peer", "peerId", printablePeerID, "err", err)
+			return err
+		},
+		NodeInfo: func() interface{} {
+			return nil
+		},
+		PeerInfo: func(peerID [64]byte) interface{} {
+			return nil
+		},
+	})
+}
+
+// SnapPeer returns the snap protocol peer with the given id, to request state ranges from, or nil if the peer is not
+// connected with the snap protocol.
+func (ss *GrpcServer) SnapPeer(peerID [64]byte) *snap.Peer {
+	if value, ok := ss.snapPeers.Load(peerID); ok {
+		return value.(*snap.Peer)
+	}
+	return nil
+}
+
+func runSnapPeer(ctx context.Context, db kv.RoDB, blockReader services.HeaderReader, prover *snap.Prover, peer *snap.Peer, rw p2p.MsgReadWriter, logger log.Logger) error {
+	for {
+		if err := libcommon.Stopped(ctx.Done()); err != nil {
+			return err
+		}
+		msg, err := rw.ReadMsg()
+		if err != nil {
+			return fmt.Errorf("reading message: %w", err)
+		}
+		if msg.Size > eth.ProtocolMaxMsgSize {
+			msg.Discard()
+			return fmt.Errorf("message is too large %d, limit %d", msg.Size, eth.ProtocolMaxMsgSize)
+		}
+		if err := handleSnapMsg(ctx, db, blockReader, prover, peer, rw, msg); err != nil {
+			msg.Discard()
+			logger.Debug("[p2p] snap message handling failed", "peer", peer.ID()[:20], "msg", msg.Code, "err", err)
+			return err
+		}
+		msg.Discard()
+	}
+}
+
+// handleSnapMsg answers the requests from the state in db, and hands the responses over to the peer.
+func handleSnapMsg(ctx context.Context, db kv.RoDB, blockReader services.HeaderReader, prover *snap.Prover, peer *snap.Peer, rw p2p.MsgReadWriter, msg p2p.Msg) error {
+	switch msg.Code {
+	case snap.GetAccountRangeMsg:
+		var req snap.GetAccountRangePacket
+		if err := msg.Decode(&req); err != nil {
+			return err
+		}
+		res := &snap.AccountRangePacket{ID: req.ID}
+		if err := db.View(ctx, func(tx kv.Tx) (err error) {
+			res.Accounts, res.Proof, err = snap.AnswerGetAccountRangeQuery(tx, prover, &req, blockReader)
+			return err
+		}); err != nil {
+			return err
+		}
+		return p2p.Send(rw, snap.AccountRangeMsg, res)
+	case snap.GetStorageRangesMsg:
+		var req snap.GetStorageRangesPacket
+		if err := msg.Decode(&req); err != nil {
+			return err
+		}
+		res := &snap.StorageRangesPacket{ID: req.ID}
+		if err := db.View(ctx, func(tx kv.Tx) (err error) {
+			res.Slots, res.Proof, err = snap.AnswerGetStorageRangesQuery(tx, prover, &req, blockReader)
+			return err
+		}); err != nil {
+			return err
+		}
+		return p2p.Send(rw, snap.StorageRangesMsg, res)
+	case snap.GetByteCodesMsg:
+		var req snap.GetByteCodesPacket
+		if err := msg.Decode(&req); err != nil {
+			return err
+		}
+		res := &snap.ByteCodesPacket{ID: req.ID}
+		if err := db.View(ctx, func(tx kv.Tx) (err error) {
+			res.Codes, err = snap.AnswerGetByteCodesQuery(tx, &req)
+			return err
+		}); err != nil {
+			return err
+		}
+		return p2p.Send(rw, snap.ByteCodesMsg, res)
+	case snap.GetTrieNodesMsg:
+		var req snap.GetTrieNodesPacket
+		if err := msg.Decode(&req); err != nil {
+			return err
+		}
+		res := &snap.TrieNodesPacket{ID: req.ID}
+		if err := db.View(ctx, func(tx kv.Tx) (err error) {
+			res.Nodes, err = snap.AnswerGetTrieNodesQuery(tx, prover, &req, blockReader)
+			return err
+		}); err != nil {
+			return err
+		}
+		return p2p.Send(rw, snap.TrieNodesMsg, res)
+	case snap.AccountRangeMsg, snap.StorageRangesMsg, snap.ByteCodesMsg, snap.TrieNodesMsg:
+		return peer.Deliver(msg)
+	default:
+		return fmt.Errorf("unknown snap message code %d", msg.Code)
+	}
+}
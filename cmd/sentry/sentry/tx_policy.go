@@ -0,0 +1,332 @@
+package sentry
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/VictoriaMetrics/metrics"
+	libcommon "github.com/ledgerwatch/erigon-lib/common"
+	proto_sentry "github.com/ledgerwatch/erigon-lib/gointerfaces/sentry"
+
+	"github.com/ledgerwatch/erigon/core/types"
+	"github.com/ledgerwatch/erigon/crypto"
+	"github.com/ledgerwatch/erigon/eth/protocols/eth"
+	"github.com/ledgerwatch/erigon/p2p"
+	"github.com/ledgerwatch/erigon/p2p/txpolicy"
+	"github.com/ledgerwatch/erigon/rlp"
+)
+
+// Reasons of withholding transactions from peers, labels of the metrics
+const (
+	txWithheldPrivate      = "private"       // Private transactions are never sent to peers
+	txWithheldBlob         = "blob"          // Blob transactions are only announced
+	txWithheldAnnounceOnly = "announce_only" // The broadcast mode only announces transactions
+	txWithheldRateLimit    = "rate_limit"    // The peer got the maximum number of transactions for the second
+)
+
+// txPolicyClients are the client names of the peers the sent transactions are counted for, the other peers are
+// counted together to bound the cardinality of the metrics.
+var txPolicyClients = []string{"erigon", "geth", "nethermind", "besu", "reth", "bor"}
+
+// txPolicy decides which transactions and announcements are sent to which peers: whether the transactions are
+// broadcast in full, how many of them a peer may get per second, and which ones are private. Blob transactions are
+// never broadcast in full, peers fetch them after the announcements as eth/68 requires.
+type txPolicy struct {
+	broadcast string // One of the txpolicy broadcast modes
+	peerRate  int    // Transactions and announcements sent to a peer per second, unlimited if 0
+	private   *txpolicy.PrivateTxs
+}
+
+// newTxPolicy returns the policy of a sentry, which withholds the private transactions of the registry until it is
+// closed.
+func newTxPolicy(cfg *p2p.Config, private *txpolicy.PrivateTxs) *txPolicy {
+	broadcast := cfg.TxBroadcast
+	if broadcast == "" {
+		broadcast = txpolicy.BroadcastSqrt
+	}
+	if private != nil {
+		private.AddSentry()
+	}
+	return &txPolicy{broadcast: broadcast, peerRate: cfg.TxPeerRate, private: private}
+}
+
+func (p *txPolicy) close() {
+	if p.private != nil {
+		p.private.RemoveSentry()
+		p.private = nil
+	}
+}
+
+func (p *txPolicy) isPrivate(hash libcommon.Hash) bool {
+	return p.private != nil && p.private.Contains(hash)
+}
+
+// txEnvelope returns the type and the hash of a transaction of a network message: an RLP list for legacy
+// transactions, an RLP string of the type and the payload for the typed ones, with the blobs for the blob
+// transactions in the pooled transactions responses.
+func txEnvelope(raw []byte) (txType byte, hash libcommon.Hash, err error) {
+	kind, content, _, err := rlp.Split(raw)
+	if err != nil {
+		return 0, libcommon.Hash{}, err
+	}
+	if kind == rlp.List {
+		return types.LegacyTxType, crypto.Keccak256Hash(raw), nil
+	}
+	if len(content) == 0 {
+		return 0, libcommon.Hash{}, fmt.Errorf("empty typed transaction")
+	}
+	txType = content[0]
+	if txType == types.BlobTxType {
+		// The network form is the list of the transaction payload, the blobs, the commitments and the proofs,
+		// the hash is the one of the transaction payload.
+		if fields, _, err := rlp.SplitList(content[1:]); err == nil {
+			if kind, _, rest, err := rlp.Split(fields); err == nil && kind == rlp.List {
+				payload := fields[:len(fields)-len(rest)]
+				return txType, crypto.Keccak256Hash([]byte{txType}, payload), nil
+			}
+		}
+	}
+	return txType, crypto.Keccak256Hash(content), nil
+}
+
+// txFilterResult is a message with the withheld transactions or announcements removed.
+type txFilterResult struct {
+	data     []byte
+	kept     int
+	withheld map[string]int // Number of the withheld transactions by reason
+}
+
+func (r *txFilterResult) withhold(reason string) {
+	if r.withheld == nil {
+		r.withheld = make(map[string]int)
+	}
+	r.withheld[reason]++
+}
+
+// filterTransactions removes the private transactions from a list of transactions, and the blob ones if it is a
+// broadcast.
+func (p *txPolicy) filterTransactions(data []byte, broadcast bool) (txFilterResult, error) {
+	var txs []rlp.RawValue
+	if err := rlp.DecodeBytes(data, &txs); err != nil {
+		return txFilterResult{}, err
+	}
+	result := txFilterResult{data: data}
+	kept := txs[:0:0]
+	for _, tx := range txs {
+		txType, hash, err := txEnvelope(tx)
+		if err != nil {
+			return txFilterResult{}, err
+		}
+		switch {
+		case p.isPrivate(hash):
+			result.withhold(txWithheldPrivate)
+		case broadcast && txType == types.BlobTxType:
+			result.withhold(txWithheldBlob)
+		default:
+			kept = append(kept, tx)
+		}
+	}
+	result.kept = len(kept)
+	if len(kept) == len(txs) {
+		return result, nil
+	}
+	var err error
+	result.data, err = rlp.EncodeToBytes(kept)
+	return result, err
+}
+
+// filterPooledTransactions removes the private transactions from a response to a pooled transactions request.
+func (p *txPolicy) filterPooledTransactions(data []byte) (txFilterResult, error) {
+	var packet eth.PooledTransactionsRLPPacket66
+	if err := rlp.DecodeBytes(data, &packet); err != nil {
+		return txFilterResult{}, err
+	}
+	result := txFilterResult{data: data}
+	kept := packet.PooledTransactionsRLPPacket[:0:0]
+	for _, tx := range packet.PooledTransactionsRLPPacket {
+		_, hash, err := txEnvelope(tx)
+		if err != nil {
+			return txFilterResult{}, err
+		}
+		if p.isPrivate(hash) {
+			result.withhold(txWithheldPrivate)
+			continue
+		}
+		kept = append(kept, tx)
+	}
+	result.kept = len(kept)
+	if len(kept) == len(packet.PooledTransactionsRLPPacket) {
+		return result, nil
+	}
+	packet.PooledTransactionsRLPPacket = kept
+	var err error
+	result.data, err = rlp.EncodeToBytes(&packet)
+	return result, err
+}
+
+// announcements68 is the eth/68 transaction announcement with the types and the sizes of the transactions.
+type announcements68 struct {
+	Types  []byte
+	Sizes  []uint32
+	Hashes []libcommon.Hash
+}
+
+// filterAnnouncements removes the private transactions from the announcements of an eth/66 or eth/68 message.
+func (p *txPolicy) filterAnnouncements(id proto_sentry.MessageId, data []byte) (txFilterResult, error) {
+	result := txFilterResult{data: data}
+	if id == proto_sentry.MessageId_NEW_POOLED_TRANSACTION_HASHES_68 {
+		var packet announcements68
+		if err := rlp.DecodeBytes(data, &packet); err != nil {
+			return txFilterResult{}, err
+		}
+		if len(packet.Types) != len(packet.Hashes) || len(packet.Sizes) != len(packet.Hashes) {
+			return txFilterResult{}, fmt.Errorf("announcement lengths mismatch: %d types, %d sizes, %d hashes", len(packet.Types), len(packet.Sizes), len(packet.Hashes))
+		}
+		var kept announcements68
+		for i, hash := range packet.Hashes {
+			if p.isPrivate(hash) {
+				result.withhold(txWithheldPrivate)
+				continue
+			}
+			kept.Types = append(kept.Types, packet.Types[i])
+			kept.Sizes = append(kept.Sizes, packet.Sizes[i])
+			kept.Hashes = append(kept.Hashes, hash)
+		}
+		result.kept = len(kept.Hashes)
+		if len(kept.Hashes) == len(packet.Hashes) {
+			return result, nil
+		}
+		var err error
+		result.data, err = rlp.EncodeToBytes(&kept)
+		return result, err
+	}
+
+	var hashes eth.NewPooledTransactionHashesPacket
+	if err := rlp.DecodeBytes(data, &hashes); err != nil {
+		return txFilterResult{}, err
+	}
+	kept := hashes[:0:0]
+	for _, hash := range hashes {
+		if p.isPrivate(hash) {
+			result.withhold(txWithheldPrivate)
+			continue
+		}
+		kept = append(kept, hash)
+	}
+	result.kept = len(kept)
+	if len(kept) == len(hashes) {
+		return result, nil
+	}
+	var err error
+	result.data, err = rlp.EncodeToBytes(kept)
+	return result, err
+}
+
+// filter applies the policy to an outbound transaction message, isTx is false for the messages of other kinds,
+// which are returned as they are. The result of a broadcast or an announcement has no data if all the transactions
+// are withheld, a response is sent even if it becomes empty.
+func (p *txPolicy) filter(id proto_sentry.MessageId, msgcode uint64, data []byte) (result txFilterResult, isTx bool, err error) {
+	switch msgcode {
+	case eth.TransactionsMsg:
+		if p.broadcast == txpolicy.BroadcastAnnounce {
+			var txs []rlp.RawValue
+			if err = rlp.DecodeBytes(data, &txs); err == nil {
+				result.withheld = map[string]int{txWithheldAnnounceOnly: len(txs)}
+			}
+		} else {
+			result, err = p.filterTransactions(data, true /* broadcast */)
+		}
+	case eth.PooledTransactionsMsg:
+		result, err = p.filterPooledTransactions(data)
+	case eth.NewPooledTransactionHashesMsg:
+		result, err = p.filterAnnouncements(id, data)
+	default:
+		return txFilterResult{data: data}, false, nil
+	}
+	if err != nil {
+		return txFilterResult{}, true, fmt.Errorf("transaction policy of %s: %w", id, err)
+	}
+	for reason, count := range result.withheld {
+		txWithheldCounter(id, reason).Add(count)
+	}
+	if result.kept == 0 && msgcode != eth.PooledTransactionsMsg {
+		result.data = nil
+	}
+	return result, true, nil
+}
+
+// allow returns whether a peer may get a message with the given number of transactions or announcements under the
+// rate cap, and counts them as sent to the peer or withheld.
+func (p *txPolicy) allow(pi *PeerInfo, id proto_sentry.MessageId, count int, now time.Time) bool {
+	if !pi.allowTxs(count, p.peerRate, now) {
+		txWithheldCounter(id, txWithheldRateLimit).Add(count)
+		return false
+	}
+	p.sent(pi, id, count)
+	return true
+}
+
+// fits returns whether a peer may get a message with the given number of transactions or announcements under the
+// rate cap, without counting them.
+func (p *txPolicy) fits(pi *PeerInfo, count int, now time.Time) bool {
+	if p.peerRate <= 0 {
+		return true
+	}
+	pi.lock.Lock()
+	defer pi.lock.Unlock()
+	return pi.txsFit(count, p.peerRate, now)
+}
+
+// sent counts the transactions or the announcements sent to a peer.
+func (p *txPolicy) sent(pi *PeerInfo, id proto_sentry.MessageId, count int) {
+	txSentCounter(id, peerClientName(pi.peer.Name())).Add(count)
+}
+
+// allowTxs counts the transactions or the announcements sent to the peer in the current second, and returns false
+// if they would exceed the rate. A message larger than the rate is allowed alone in its second.
+func (pi *PeerInfo) allowTxs(count, rate int, now time.Time) bool {
+	if rate <= 0 {
+		return true
+	}
+	pi.lock.Lock()
+	defer pi.lock.Unlock()
+	if !pi.txsFit(count, rate, now) {
+		return false
+	}
+	if now.Sub(pi.txWindow) >= time.Second {
+		pi.txWindow = now
+		pi.txWindowCount = 0
+	}
+	pi.txWindowCount += count
+	return true
+}
+
+// txsFit returns whether count transactions fit in the rate of the current second of the peer, pi.lock must be held.
+func (pi *PeerInfo) txsFit(count, rate int, now time.Time) bool {
+	if now.Sub(pi.txWindow) >= time.Second {
+		return true
+	}
+	return pi.txWindowCount == 0 || pi.txWindowCount+count <= rate
+}
+
+// peerClientName returns the lowercase client name of a peer name like Geth/v1.12.0-stable/linux-amd64/go1.20.5
+// if it is among txPolicyClients, or "other".
+func peerClientName(name string) string {
+	client, _, _ := strings.Cut(name, "/")
+	client = strings.ToLower(client)
+	for _, known := range txPolicyClients {
+		if client == known {
+			return client
+		}
+	}
+	return "other"
+}
+
+func txSentCounter(id proto_sentry.MessageId, client string) *metrics.Counter {
+	return metrics.GetOrCreateCounter(fmt.Sprintf(`sentry_tx_sent_total{msg="%s",client="%s"}`, strings.ToLower(id.String()), client))
+}
+
+func txWithheldCounter(id proto_sentry.MessageId, reason string) *metrics.Counter {
+	return metrics.GetOrCreateCounter(fmt.Sprintf(`sentry_tx_withheld_total{msg="%s",reason="%s"}`, strings.ToLower(id.String()), reason))
+}
@@ -0,0 +1,161 @@
+package sentry
+
+import (
+	"testing"
+	"time"
+
+	libcommon "github.com/ledgerwatch/erigon-lib/common"
+	proto_sentry "github.com/ledgerwatch/erigon-lib/gointerfaces/sentry"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ledgerwatch/erigon/core/types"
+	"github.com/ledgerwatch/erigon/crypto"
+	"github.com/ledgerwatch/erigon/eth/protocols/eth"
+	"github.com/ledgerwatch/erigon/p2p"
+	"github.com/ledgerwatch/erigon/p2p/txpolicy"
+	"github.com/ledgerwatch/erigon/rlp"
+)
+
+func mustEncode(t *testing.T, val interface{}) []byte {
+	t.Helper()
+	b, err := rlp.EncodeToBytes(val)
+	require.NoError(t, err)
+	return b
+}
+
+// testTxs returns a legacy, a dynamic fee and a blob transaction in their network encoding with their hashes.
+func testTxs(t *testing.T) ([]rlp.RawValue, []libcommon.Hash) {
+	legacy := mustEncode(t, []uint64{1, 2, 3})
+	dynamicPayload := append([]byte{types.DynamicFeeTxType}, mustEncode(t, []uint64{4, 5})...)
+	dynamic := mustEncode(t, dynamicPayload)
+	blobPayload := mustEncode(t, []uint64{6})
+	blobWrapper := mustEncode(t, []rlp.RawValue{blobPayload, mustEncode(t, [][]byte{{7}}), mustEncode(t, [][]byte{{8}}), mustEncode(t, [][]byte{{9}})})
+	blob := mustEncode(t, append([]byte{types.BlobTxType}, blobWrapper...))
+	return []rlp.RawValue{legacy, dynamic, blob}, []libcommon.Hash{
+		crypto.Keccak256Hash(legacy),
+		crypto.Keccak256Hash(dynamicPayload),
+		crypto.Keccak256Hash([]byte{types.BlobTxType}, blobPayload),
+	}
+}
+
+func TestTxEnvelope(t *testing.T) {
+	txs, hashes := testTxs(t)
+	for i, expectedType := range []byte{types.LegacyTxType, types.DynamicFeeTxType, types.BlobTxType} {
+		txType, hash, err := txEnvelope(txs[i])
+		require.NoError(t, err)
+		require.Equal(t, expectedType, txType)
+		require.Equal(t, hashes[i], hash)
+	}
+}
+
+func TestTxPolicyFilter(t *testing.T) {
+	txs, hashes := testTxs(t)
+	private := txpolicy.NewPrivateTxs(time.Hour)
+	private.Add(hashes[1])
+	policy := newTxPolicy(&p2p.Config{}, private)
+
+	// broadcasts drop the private and the blob transactions
+	result, isTx, err := policy.filter(proto_sentry.MessageId_TRANSACTIONS_66, eth.TransactionsMsg, mustEncode(t, txs))
+	require.NoError(t, err)
+	require.True(t, isTx)
+	require.Equal(t, 1, result.kept)
+	require.Equal(t, map[string]int{txWithheldPrivate: 1, txWithheldBlob: 1}, result.withheld)
+	require.Equal(t, mustEncode(t, txs[:1]), result.data)
+
+	// responses only drop the private transactions
+	response := mustEncode(t, &eth.PooledTransactionsRLPPacket66{RequestId: 7, PooledTransactionsRLPPacket: txs})
+	result, _, err = policy.filter(proto_sentry.MessageId_POOLED_TRANSACTIONS_66, eth.PooledTransactionsMsg, response)
+	require.NoError(t, err)
+	require.Equal(t, 2, result.kept)
+	var packet eth.PooledTransactionsRLPPacket66
+	require.NoError(t, rlp.DecodeBytes(result.data, &packet))
+	require.Equal(t, uint64(7), packet.RequestId)
+	require.Equal(t, eth.PooledTransactionsRLPPacket{txs[0], txs[2]}, packet.PooledTransactionsRLPPacket)
+
+	// announcements drop the private transactions in both forms
+	result, _, err = policy.filter(proto_sentry.MessageId_NEW_POOLED_TRANSACTION_HASHES_66, eth.NewPooledTransactionHashesMsg, mustEncode(t, hashes))
+	require.NoError(t, err)
+	require.Equal(t, mustEncode(t, []libcommon.Hash{hashes[0], hashes[2]}), result.data)
+	announcements := &announcements68{
+		Types:  []byte{types.LegacyTxType, types.DynamicFeeTxType, types.BlobTxType},
+		Sizes:  []uint32{10, 20, 30},
+		Hashes: hashes,
+	}
+	result, _, err = policy.filter(proto_sentry.MessageId_NEW_POOLED_TRANSACTION_HASHES_68, eth.NewPooledTransactionHashesMsg, mustEncode(t, announcements))
+	require.NoError(t, err)
+	require.Equal(t, mustEncode(t, &announcements68{
+		Types:  []byte{types.LegacyTxType, types.BlobTxType},
+		Sizes:  []uint32{10, 30},
+		Hashes: []libcommon.Hash{hashes[0], hashes[2]},
+	}), result.data)
+
+	// nothing is left to broadcast
+	result, _, err = policy.filter(proto_sentry.MessageId_TRANSACTIONS_66, eth.TransactionsMsg, mustEncode(t, txs[1:]))
+	require.NoError(t, err)
+	require.Nil(t, result.data)
+
+	// other messages pass through
+	result, isTx, err = policy.filter(proto_sentry.MessageId_NEW_BLOCK_66, eth.NewBlockMsg, []byte{1})
+	require.NoError(t, err)
+	require.False(t, isTx)
+	require.Equal(t, []byte{1}, result.data)
+}
+
+func TestTxPolicyAnnounceOnly(t *testing.T) {
+	txs, _ := testTxs(t)
+	policy := newTxPolicy(&p2p.Config{TxBroadcast: txpolicy.BroadcastAnnounce}, nil)
+	result, isTx, err := policy.filter(proto_sentry.MessageId_TRANSACTIONS_66, eth.TransactionsMsg, mustEncode(t, txs))
+	require.NoError(t, err)
+	require.True(t, isTx)
+	require.Nil(t, result.data)
+	require.Equal(t, map[string]int{txWithheldAnnounceOnly: 3}, result.withheld)
+}
+
+func TestPeerInfoAllowTxs(t *testing.T) {
+	pi := &PeerInfo{}
+	now := time.Unix(1_700_000_000, 0)
+	require.True(t, pi.allowTxs(100, 0, now))
+	require.True(t, pi.allowTxs(6, 10, now))
+	require.True(t, pi.allowTxs(4, 10, now.Add(100*time.Millisecond)))
+	require.False(t, pi.allowTxs(1, 10, now.Add(900*time.Millisecond)))
+
+	// a large message is allowed alone in a new second
+	require.True(t, pi.allowTxs(50, 10, now.Add(time.Second)))
+	require.False(t, pi.allowTxs(1, 10, now.Add(1500*time.Millisecond)))
+	require.True(t, pi.allowTxs(1, 10, now.Add(2*time.Second)))
+}
+
+func TestTxPolicyFits(t *testing.T) {
+	policy := newTxPolicy(&p2p.Config{TxPeerRate: 10}, nil)
+	pi := &PeerInfo{}
+	now := time.Unix(1_700_000_000, 0)
+	require.True(t, policy.fits(pi, 6, now))
+	require.True(t, pi.allowTxs(6, 10, now))
+
+	// fits does not count the transactions
+	require.True(t, policy.fits(pi, 4, now))
+	require.True(t, policy.fits(pi, 4, now))
+	require.False(t, policy.fits(pi, 5, now))
+	require.True(t, policy.fits(pi, 5, now.Add(time.Second)))
+}
+
+func TestTxPolicyEnforcesPrivateTxs(t *testing.T) {
+	private := txpolicy.NewPrivateTxs(time.Hour)
+	require.False(t, private.Enforced())
+	policy := newTxPolicy(&p2p.Config{}, private)
+	require.True(t, private.Enforced())
+	policy.close()
+	require.False(t, private.Enforced())
+
+	// Sentries of other processes would broadcast the private transactions
+	newTxPolicy(&p2p.Config{}, private)
+	private.SetExternalSentries()
+	require.False(t, private.Enforced())
+}
+
+func TestPeerClientName(t *testing.T) {
+	require.Equal(t, "geth", peerClientName("Geth/v1.12.0-stable/linux-amd64/go1.20.5"))
+	require.Equal(t, "erigon", peerClientName("erigon/v2.48.1/linux-amd64/go1.20.6"))
+	require.Equal(t, "other", peerClientName("Unknown/v1"))
+	require.Equal(t, "other", peerClientName(""))
+}
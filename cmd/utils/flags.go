@@ -18,7 +18,6 @@
 package utils
 
 import (
-	"crypto/ecdsa"
 	"fmt"
 	"math/big"
 	"path/filepath"
@@ -55,8 +54,10 @@ import (
 	"github.com/ledgerwatch/erigon/p2p/enode"
 	"github.com/ledgerwatch/erigon/p2p/nat"
 	"github.com/ledgerwatch/erigon/p2p/netutil"
+	"github.com/ledgerwatch/erigon/p2p/txpolicy"
 	"github.com/ledgerwatch/erigon/params"
 	"github.com/ledgerwatch/erigon/params/networkname"
+	"github.com/ledgerwatch/erigon/rpc/rpccfg"
 )
 
 // These are all the command line flags we support.
@@ -208,6 +209,25 @@ var (
 		Name:  "proposer.disable",
 		Usage: "Disables PoS proposer",
 	}
+	BuilderRelayURLFlag = cli.StringFlag{
+		Name:  "builder.relay",
+		Usage: "URL of the builder-API endpoint of an external block builder, whose bids compete with the locally built PoS payloads. A bid is used only if its payload is valid and its last transaction pays the bid value to the fee recipient",
+	}
+	BuilderRelayTimeoutFlag = cli.DurationFlag{
+		Name:  "builder.relay.timeout",
+		Usage: "Maximum time to wait for a bid of the external block builder when a payload is requested",
+		Value: ethconfig.Defaults.BuilderRelay.Timeout,
+	}
+	BuilderRelayMaxMissedSlotsFlag = cli.IntFlag{
+		Name:  "builder.relay.maxmissedslots",
+		Usage: "Number of consecutive slots missed with payloads of the external block builder, after which payloads are built locally",
+		Value: ethconfig.Defaults.BuilderRelay.MaxMissedSlots,
+	}
+	BuilderRelayCooldownSlotsFlag = cli.IntFlag{
+		Name:  "builder.relay.cooldownslots",
+		Usage: "Number of slots built locally after too many missed slots, before trying the external block builder again",
+		Value: ethconfig.Defaults.BuilderRelay.CooldownSlots,
+	}
 	MinerNotifyFlag = cli.StringFlag{
 		Name:  "miner.notify",
 		Usage: "Comma separated HTTP URL list to notify of new work packages",
@@ -366,6 +386,46 @@ var (
 		Usage: "Maximum number of bytes returned from eth_call or similar invocations",
 		Value: 100_000,
 	}
+	RpcSignerKeystoreFlag = cli.StringFlag{
+		Name:  "rpc.signer.keystore",
+		Usage: "Directory of encrypted key files used by eth_sign, eth_signTransaction and eth_sendTransaction (disabled if empty)",
+	}
+	RpcSignerPasswordFlag = cli.StringFlag{
+		Name:  "rpc.signer.password",
+		Usage: "File with the passwords of the key files of --rpc.signer.keystore, one per line",
+	}
+	RpcSignerURLFlag = cli.StringFlag{
+		Name:  "rpc.signer.url",
+		Usage: "Endpoint of an external signer (clef compatible) used by eth_sign, eth_signTransaction and eth_sendTransaction (disabled if empty)",
+	}
+	RpcCaptureDirFlag = cli.StringFlag{
+		Name:  "rpc.capture.dir",
+		Usage: "Directory where sampled HTTP requests are captured with their responses, in the format of `rpctest replay` (disabled if empty)",
+	}
+	RpcCaptureSampleFlag = cli.Float64Flag{
+		Name:  "rpc.capture.sample",
+		Usage: "Fraction of the HTTP requests which are captured",
+		Value: rpccfg.DefaultCaptureConfig.SampleRate,
+	}
+	RpcCaptureFileSizeFlag = cli.StringFlag{
+		Name:  "rpc.capture.filesize",
+		Usage: "Size above which a new capture file is started",
+		Value: rpccfg.DefaultCaptureConfig.MaxFileSize.String(),
+	}
+	RpcCaptureMaxFilesFlag = cli.IntFlag{
+		Name:  "rpc.capture.maxfiles",
+		Usage: "Number of capture files kept, the oldest are deleted (0 keeps all of them)",
+		Value: rpccfg.DefaultCaptureConfig.MaxFiles,
+	}
+	RpcCaptureMethodsFlag = cli.StringFlag{
+		Name:  "rpc.capture.methods",
+		Usage: "Comma separated methods which are captured, all of them if empty",
+	}
+	RpcCaptureRedactFlag = cli.StringFlag{
+		Name:  "rpc.capture.redact",
+		Usage: "Comma separated redaction rules as method:action, where action is drop (not captured) or hash (strings of the parameters, also nested ones, replaced by their keccak256 hash)",
+		Value: strings.Join(rpccfg.DefaultCaptureConfig.Redact, ","),
+	}
 	HTTPTraceFlag = cli.BoolFlag{
 		Name:  "http.trace",
 		Usage: "Trace HTTP requests with INFO level",
@@ -401,6 +461,16 @@ var (
 		Value: 200,
 	}
 
+	RpcMaxLogsFlag = cli.Uint64Flag{
+		Name:  "rpc.maxlogs",
+		Usage: "Sets a limit on logs that can be returned in eth_getLogs, 0 for no limit",
+		Value: 0,
+	}
+	RpcPrivateTxClientsFlag = cli.StringFlag{
+		Name:  "rpc.privatetx.clients",
+		Usage: "Comma separated CIDR masks of the HTTP clients whose transactions are included locally but never gossiped to peers (in-process sentries only). Raw transactions are then refused over the other transports, which don't know the client address",
+	}
+
 	HTTPPathPrefixFlag = cli.StringFlag{
 		Name:  "http.rpcprefix",
 		Usage: "HTTP path path prefix on which JSON-RPC is served. Use '/' to serve on all paths.",
@@ -498,6 +568,28 @@ var (
 		Usage: "Allowed ports to pick for different eth p2p protocol versions as follows <porta>,<portb>,..,<porti>",
 		Value: cli.NewUintSlice(uint(ListenPortFlag.Value), 30304, 30305, 30306, 30307),
 	}
+	P2pENRFilterFlag = cli.StringSliceFlag{
+		Name:  "p2p.enr-filter",
+		Usage: "Comma separated predicates on the node records of dial candidates: <key> requires the ENR entry, !<key> rejects it, e.g. eth,!les",
+	}
+	P2pTxBroadcastFlag = cli.StringFlag{
+		Name:  "p2p.tx-broadcast",
+		Usage: "How transactions are broadcast in full besides the announcements of their hashes to all peers: sqrt (to the square root of the peers) or announce (announcements only)",
+		Value: txpolicy.BroadcastSqrt,
+	}
+	P2pTxPeerRateFlag = cli.IntFlag{
+		Name:  "p2p.tx-peer-rate",
+		Usage: "Maximum number of transactions and announcements sent to a peer per second, 0 for no limit",
+		Value: 0,
+	}
+	P2pSentryIdentitiesFlag = cli.BoolFlag{
+		Name:  "p2p.sentry-identities",
+		Usage: "Give every sentry of the node but the first (one per --p2p.protocol) its own node key, kept in its node database directory",
+	}
+	P2pProtocolSnapFlag = cli.BoolFlag{
+		Name:  "p2p.protocol.snap",
+		Usage: "Serve the snap/1 state sync protocol next to eth, from the hashed state and intermediate hashes (in-process sentries only, not with --experimental.history.v3)",
+	}
 	SentryAddrFlag = cli.StringFlag{
 		Name:  "sentry.api.addr",
 		Usage: "comma separated sentry addresses '<host>:<port>,<host>:<port>'",
@@ -798,6 +890,17 @@ func setNodeKey(ctx *cli.Context, cfg *p2p.Config, datadir string) {
 		Fatalf("%v", err)
 	}
 	cfg.PrivateKey = key
+
+	// Keys given as hex can't be rotated across restarts
+	if hex == "" {
+		if file == "" {
+			file = config.DefaultPath(datadir)
+		}
+		if cfg.PreviousKeys, err = config.LoadPrevious(file); err != nil {
+			Fatalf("%v", err)
+		}
+		cfg.NodeKeyFile = file
+	}
 }
 
 // setNodeUserIdent creates the user identifier from CLI flags.
@@ -928,7 +1031,10 @@ func NewP2PConfig(
 		return nil, fmt.Errorf("unknown protocol: %v", protocol)
 	}
 
-	serverKey, err := nodeKey(dirs.DataDir)
+	// The node key is loaded from datadir if it exists, otherwise it is generated in datadir
+	keyConfig := p2p.NodeKeyConfig{}
+	keyfile := keyConfig.DefaultPath(dirs.DataDir)
+	serverKey, previousKeys, err := keyConfig.LoadIdentity(keyfile)
 	if err != nil {
 		return nil, err
 	}
@@ -940,6 +1046,8 @@ func NewP2PConfig(
 		NAT:             nat.Any(),
 		NoDiscovery:     nodiscover,
 		PrivateKey:      serverKey,
+		PreviousKeys:    previousKeys,
+		NodeKeyFile:     keyfile,
 		Name:            nodeName,
 		NodeDatabase:    enodeDBPath,
 		AllowedPorts:    allowedPorts,
@@ -972,14 +1080,6 @@ func NewP2PConfig(
 	return cfg, nil
 }
 
-// nodeKey loads a node key from datadir if it exists,
-// otherwise it generates a new key in datadir.
-func nodeKey(datadir string) (*ecdsa.PrivateKey, error) {
-	config := p2p.NodeKeyConfig{}
-	keyfile := config.DefaultPath(datadir)
-	return config.LoadOrGenerateAndSave(keyfile)
-}
-
 // setListenAddress creates a TCP listening address string from set command
 // line flags.
 func setListenAddress(ctx *cli.Context, cfg *p2p.Config) {
@@ -1122,6 +1222,20 @@ func SetP2PConfig(ctx *cli.Context, cfg *p2p.Config, nodeName, datadir string, l
 		cfg.NetRestrict = list
 	}
 
+	if ctx.IsSet(P2pENRFilterFlag.Name) {
+		cfg.ENRFilter = ctx.StringSlice(P2pENRFilterFlag.Name)
+		if _, err := p2p.ParseENRFilter(cfg.ENRFilter); err != nil {
+			Fatalf("Option %q: %v", P2pENRFilterFlag.Name, err)
+		}
+	}
+
+	cfg.TxBroadcast = ctx.String(P2pTxBroadcastFlag.Name)
+	if err := txpolicy.CheckBroadcast(cfg.TxBroadcast); err != nil {
+		Fatalf("Option %q: %v", P2pTxBroadcastFlag.Name, err)
+	}
+	cfg.TxPeerRate = ctx.Int(P2pTxPeerRateFlag.Name)
+	cfg.SentryIdentities = ctx.Bool(P2pSentryIdentitiesFlag.Name)
+
 	if ctx.String(ChainFlag.Name) == networkname.DevChainName {
 		// --dev mode can't use p2p networking.
 		//cfg.MaxPeers = 0 // It can have peers otherwise local sync is not possible
@@ -1381,6 +1495,13 @@ func setMiner(ctx *cli.Context, cfg *params.MiningConfig) {
 	}
 }
 
+func setBuilderRelay(ctx *cli.Context, cfg *ethconfig.BuilderRelay) {
+	cfg.URL = ctx.String(BuilderRelayURLFlag.Name)
+	cfg.Timeout = ctx.Duration(BuilderRelayTimeoutFlag.Name)
+	cfg.MaxMissedSlots = ctx.Int(BuilderRelayMaxMissedSlotsFlag.Name)
+	cfg.CooldownSlots = ctx.Int(BuilderRelayCooldownSlotsFlag.Name)
+}
+
 func setWhitelist(ctx *cli.Context, cfg *ethconfig.Config) {
 	whitelist := ctx.String(WhitelistFlag.Name)
 	if whitelist == "" {
@@ -1503,12 +1624,14 @@ func SetEthConfig(ctx *cli.Context, nodeConfig *nodecfg.Config, cfg *ethconfig.C
 	setEthash(ctx, nodeConfig.Dirs.DataDir, cfg)
 	setClique(ctx, &cfg.Clique, nodeConfig.Dirs.DataDir)
 	setMiner(ctx, &cfg.Miner)
+	setBuilderRelay(ctx, &cfg.BuilderRelay)
 	setWhitelist(ctx, cfg)
 	setBorConfig(ctx, cfg)
 
 	cfg.Ethstats = ctx.String(EthStatsURLFlag.Name)
 	cfg.P2PEnabled = len(nodeConfig.P2P.SentryAddr) == 0
 	cfg.HistoryV3 = ctx.Bool(HistoryV3Flag.Name)
+	cfg.SnapServe = ctx.Bool(P2pProtocolSnapFlag.Name)
 	if ctx.IsSet(NetworkIdFlag.Name) {
 		cfg.NetworkID = ctx.Uint64(NetworkIdFlag.Name)
 	}
@@ -17,9 +17,10 @@ import (
 	"go.uber.org/zap/buffer"
 
 	"github.com/ledgerwatch/erigon/cl/utils"
-	"github.com/ledgerwatch/erigon/cmd/verkle/verkletrie"
 	"github.com/ledgerwatch/erigon/common"
+	"github.com/ledgerwatch/erigon/core/rawdb"
 	"github.com/ledgerwatch/erigon/core/types/accounts"
+	"github.com/ledgerwatch/erigon/core/verkletrie"
 	"github.com/ledgerwatch/erigon/eth/stagedsync/stages"
 )
 
@@ -77,7 +78,14 @@ func IncrementVerkleTree(cfg optionsCfg, logger log.Logger) error {
 	if err := verkletrie.IncrementAccount(vTx, tx, uint64(cfg.workersCount), verkleWriter, from, to); err != nil {
 		return err
 	}
-	if _, err := verkletrie.IncrementStorage(vTx, tx, uint64(cfg.workersCount), verkleWriter, from, to); err != nil {
+	root, err := rawdb.ReadVerkleRoot(vTx, from)
+	if err != nil {
+		return err
+	}
+	if root, err = verkletrie.IncrementStorage(vTx, tx, uint64(cfg.workersCount), verkleWriter, from, to, root); err != nil {
+		return err
+	}
+	if err := rawdb.WriteVerkleRoot(vTx, to, root); err != nil {
 		return err
 	}
 	if err := stages.SaveStageProgress(vTx, stages.VerkleTrie, to); err != nil {
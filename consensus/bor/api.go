@@ -3,21 +3,16 @@ package bor
 import (
 	"encoding/hex"
 	"math"
-	"math/big"
 	"sort"
 	"strconv"
 	"sync"
 
-	"github.com/xsleonard/go-merkle"
-	"golang.org/x/crypto/sha3"
-
 	lru "github.com/hashicorp/golang-lru/arc/v2"
 	"github.com/ledgerwatch/erigon-lib/common"
 
 	"github.com/ledgerwatch/erigon/consensus"
 	"github.com/ledgerwatch/erigon/consensus/bor/valset"
 	"github.com/ledgerwatch/erigon/core/types"
-	"github.com/ledgerwatch/erigon/crypto"
 	"github.com/ledgerwatch/erigon/rpc"
 )
 
@@ -259,29 +254,12 @@ func (api *API) GetRootHash(start uint64, end uint64) (string, error) {
 	wg.Wait()
 	close(concurrent)
 
-	headers := make([][32]byte, NextPowerOfTwo(length))
-
-	for i := 0; i < len(blockHeaders); i++ {
-		blockHeader := blockHeaders[i]
-		header := crypto.Keccak256(AppendBytes32(
-			blockHeader.Number.Bytes(),
-			new(big.Int).SetUint64(blockHeader.Time).Bytes(),
-			blockHeader.TxHash.Bytes(),
-			blockHeader.ReceiptHash.Bytes(),
-		))
-
-		var arr [32]byte
-
-		copy(arr[:], header)
-		headers[i] = arr
-	}
-
-	tree := merkle.NewTreeWithOpts(merkle.TreeOptions{EnableHashSorting: false, DisableHashLeaves: true})
-	if err := tree.Generate(Convert(headers), sha3.NewLegacyKeccak256()); err != nil {
+	rootHash, err := HeadersRootHash(blockHeaders)
+	if err != nil {
 		return "", err
 	}
 
-	root := hex.EncodeToString(tree.Root().Hash)
+	root := hex.EncodeToString(rootHash)
 	api.rootHashCache.Add(key, root)
 
 	return root, nil
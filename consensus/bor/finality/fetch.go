@@ -0,0 +1,137 @@
+package finality
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	libcommon "github.com/ledgerwatch/erigon-lib/common"
+	"github.com/ledgerwatch/erigon-lib/kv"
+	"github.com/ledgerwatch/log/v3"
+
+	"github.com/ledgerwatch/erigon/consensus/bor"
+	"github.com/ledgerwatch/erigon/consensus/bor/heimdallgrpc"
+	"github.com/ledgerwatch/erigon/core/types"
+	"github.com/ledgerwatch/erigon/turbo/services"
+)
+
+const (
+	checkpointInterval = time.Minute
+	milestoneInterval  = 12 * time.Second
+)
+
+var (
+	// ErrMissingBlocks is returned when the blocks of a checkpoint or a milestone are not downloaded yet
+	ErrMissingBlocks = errors.New("missing blocks of the finalized range")
+	// ErrRootHashMismatch is returned when a checkpoint does not commit to the blocks of the chain
+	ErrRootHashMismatch = errors.New("checkpoint root hash does not match the chain")
+	// ErrHashMismatch is returned when the end block of a milestone differs from the one of the chain
+	ErrHashMismatch = errors.New("milestone hash does not match the chain")
+)
+
+// Run whitelists the checkpoints and the milestones fetched from Heimdall, until ctx is done
+func Run(ctx context.Context, w *Whitelist, heimdall bor.IHeimdallClient, db kv.RoDB, blockReader services.HeaderAndCanonicalReader, logger log.Logger) {
+	checkpointTicker := time.NewTicker(checkpointInterval)
+	defer checkpointTicker.Stop()
+	milestoneTicker := time.NewTicker(milestoneInterval)
+	defer milestoneTicker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-checkpointTicker.C:
+			if err := FetchCheckpoint(ctx, w, heimdall, db, blockReader); err != nil && !errors.Is(err, ErrMissingBlocks) && !errors.Is(err, context.Canceled) {
+				logger.Warn("[bor] Failed to whitelist checkpoint", "err", err)
+			}
+		case <-milestoneTicker.C:
+			err := FetchMilestone(ctx, w, heimdall, db, blockReader)
+			if errors.Is(err, heimdallgrpc.ErrMilestoneNotSupported) {
+				logger.Info("[bor] Heimdall does not serve milestones, only checkpoints are whitelisted")
+				milestoneTicker.Stop()
+			} else if err != nil && !errors.Is(err, context.Canceled) {
+				logger.Warn("[bor] Failed to whitelist milestone", "err", err)
+			}
+		}
+	}
+}
+
+// FetchCheckpoint whitelists the end block of the latest checkpoint, once its root hash is verified against the
+// headers of the chain
+func FetchCheckpoint(ctx context.Context, w *Whitelist, heimdall bor.IHeimdallClient, db kv.RoDB, blockReader services.HeaderAndCanonicalReader) error {
+	checkpoint, err := heimdall.FetchCheckpoint(ctx, -1)
+	if err != nil {
+		return err
+	}
+	start, end := checkpoint.StartBlock.Uint64(), checkpoint.EndBlock.Uint64()
+	if last := w.Checkpoint(); last != nil && last.Number >= end {
+		return nil
+	}
+	if start > end || end-start+1 > bor.MaxCheckpointLength {
+		return fmt.Errorf("invalid checkpoint range %d-%d", start, end)
+	}
+
+	var headers []*types.Header
+	if err := db.View(ctx, func(tx kv.Tx) error {
+		headers, err = canonicalHeaders(ctx, tx, blockReader, start, end)
+		return err
+	}); err != nil {
+		return err
+	}
+	rootHash, err := bor.HeadersRootHash(headers)
+	if err != nil {
+		return err
+	}
+	if !bytes.Equal(rootHash, checkpoint.RootHash[:]) {
+		return fmt.Errorf("%w: checkpoint %d-%d, root hash %x, computed %x", ErrRootHashMismatch, start, end, checkpoint.RootHash, rootHash)
+	}
+	return w.ProcessCheckpoint(end, headers[len(headers)-1].Hash())
+}
+
+// FetchMilestone whitelists the end block of the latest milestone. The milestone is whitelisted even if the chain has
+// a different block, so that the chain can only reorg towards the milestone.
+func FetchMilestone(ctx context.Context, w *Whitelist, heimdall bor.IHeimdallClient, db kv.RoDB, blockReader services.HeaderAndCanonicalReader) error {
+	milestone, err := heimdall.FetchMilestone(ctx)
+	if err != nil {
+		return err
+	}
+	end := milestone.EndBlock.Uint64()
+	if last := w.Milestone(); last != nil && last.Number >= end {
+		return nil
+	}
+	if err := w.ProcessMilestone(end, milestone.Hash); err != nil {
+		return err
+	}
+
+	return db.View(ctx, func(tx kv.Tx) error {
+		hash, err := blockReader.CanonicalHash(ctx, tx, end)
+		if err != nil {
+			return err
+		}
+		if (hash != libcommon.Hash{}) && hash != milestone.Hash {
+			return fmt.Errorf("%w: milestone %d, hash %x, chain %x", ErrHashMismatch, end, milestone.Hash, hash)
+		}
+		return nil
+	})
+}
+
+func canonicalHeaders(ctx context.Context, tx kv.Tx, blockReader services.HeaderAndCanonicalReader, start, end uint64) ([]*types.Header, error) {
+	headers := make([]*types.Header, 0, end-start+1)
+	for number := start; number <= end; number++ {
+		hash, err := blockReader.CanonicalHash(ctx, tx, number)
+		if err != nil {
+			return nil, err
+		}
+		header, err := blockReader.Header(ctx, tx, hash, number)
+		if err != nil {
+			return nil, err
+		}
+		if header == nil {
+			return nil, fmt.Errorf("%w: block %d", ErrMissingBlocks, number)
+		}
+		headers = append(headers, header)
+	}
+	return headers, nil
+}
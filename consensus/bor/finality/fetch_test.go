@@ -0,0 +1,95 @@
+package finality
+
+import (
+	"context"
+	"math/big"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	libcommon "github.com/ledgerwatch/erigon-lib/common"
+	"github.com/ledgerwatch/erigon-lib/kv"
+	"github.com/ledgerwatch/erigon-lib/kv/memdb"
+	"github.com/ledgerwatch/log/v3"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ledgerwatch/erigon/consensus/bor"
+	"github.com/ledgerwatch/erigon/consensus/bor/heimdall/checkpoint"
+	"github.com/ledgerwatch/erigon/consensus/bor/heimdall/milestone"
+	"github.com/ledgerwatch/erigon/core/rawdb"
+	"github.com/ledgerwatch/erigon/core/types"
+	"github.com/ledgerwatch/erigon/eth/ethconfig"
+	"github.com/ledgerwatch/erigon/tests/bor/mocks"
+	"github.com/ledgerwatch/erigon/turbo/snapshotsync/freezeblocks"
+)
+
+// writeTestChain writes a canonical chain of headers 0 to n
+func writeTestChain(t *testing.T, db kv.RwDB, n int) []*types.Header {
+	var headers []*types.Header
+	require.NoError(t, db.Update(context.Background(), func(tx kv.RwTx) error {
+		var parent libcommon.Hash
+		for i := 0; i <= n; i++ {
+			header := &types.Header{Number: big.NewInt(int64(i)), Time: uint64(i * 2), ParentHash: parent, Difficulty: big.NewInt(1)}
+			if err := rawdb.WriteHeader(tx, header); err != nil {
+				return err
+			}
+			if err := rawdb.WriteCanonicalHash(tx, header.Hash(), header.Number.Uint64()); err != nil {
+				return err
+			}
+			headers = append(headers, header)
+			parent = header.Hash()
+		}
+		return nil
+	}))
+	return headers
+}
+
+func newTestFetch(t *testing.T) (*Whitelist, *mocks.MockIHeimdallClient, kv.RwDB, *freezeblocks.BlockReader) {
+	w, err := NewWhitelist(memdb.NewTestDB(t))
+	require.NoError(t, err)
+	heimdall := mocks.NewMockIHeimdallClient(gomock.NewController(t))
+	blockReader := freezeblocks.NewBlockReader(freezeblocks.NewRoSnapshots(ethconfig.BlocksFreezing{Enabled: false}, "", log.New()))
+	return w, heimdall, memdb.NewTestDB(t), blockReader
+}
+
+func TestFetchCheckpoint(t *testing.T) {
+	ctx := context.Background()
+	w, heimdall, db, blockReader := newTestFetch(t)
+	headers := writeTestChain(t, db, 10)
+	rootHash, err := bor.HeadersRootHash(headers[5:9])
+	require.NoError(t, err)
+
+	// Root hash not matching the chain
+	heimdall.EXPECT().FetchCheckpoint(gomock.Any(), int64(-1)).Return(&checkpoint.Checkpoint{StartBlock: big.NewInt(1), EndBlock: big.NewInt(4), RootHash: libcommon.BytesToHash(rootHash)}, nil)
+	require.ErrorIs(t, FetchCheckpoint(ctx, w, heimdall, db, blockReader), ErrRootHashMismatch)
+	require.Nil(t, w.Checkpoint())
+
+	// Blocks not downloaded yet
+	heimdall.EXPECT().FetchCheckpoint(gomock.Any(), int64(-1)).Return(&checkpoint.Checkpoint{StartBlock: big.NewInt(9), EndBlock: big.NewInt(12)}, nil)
+	require.ErrorIs(t, FetchCheckpoint(ctx, w, heimdall, db, blockReader), ErrMissingBlocks)
+	require.Nil(t, w.Checkpoint())
+
+	heimdall.EXPECT().FetchCheckpoint(gomock.Any(), int64(-1)).Return(&checkpoint.Checkpoint{StartBlock: big.NewInt(5), EndBlock: big.NewInt(8), RootHash: libcommon.BytesToHash(rootHash)}, nil)
+	require.NoError(t, FetchCheckpoint(ctx, w, heimdall, db, blockReader))
+	require.Equal(t, &Entry{Number: 8, Hash: headers[8].Hash()}, w.Checkpoint())
+}
+
+func TestFetchMilestone(t *testing.T) {
+	ctx := context.Background()
+	w, heimdall, db, blockReader := newTestFetch(t)
+	headers := writeTestChain(t, db, 10)
+
+	heimdall.EXPECT().FetchMilestone(gomock.Any()).Return(&milestone.Milestone{StartBlock: big.NewInt(1), EndBlock: big.NewInt(4), Hash: headers[4].Hash()}, nil)
+	require.NoError(t, FetchMilestone(ctx, w, heimdall, db, blockReader))
+	require.Equal(t, &Entry{Number: 4, Hash: headers[4].Hash()}, w.Milestone())
+
+	// A milestone conflicting with the chain is whitelisted, for the chain to reorg towards it
+	heimdall.EXPECT().FetchMilestone(gomock.Any()).Return(&milestone.Milestone{StartBlock: big.NewInt(5), EndBlock: big.NewInt(8), Hash: libcommon.Hash{1}}, nil)
+	require.ErrorIs(t, FetchMilestone(ctx, w, heimdall, db, blockReader), ErrHashMismatch)
+	require.Equal(t, &Entry{Number: 8, Hash: libcommon.Hash{1}}, w.Milestone())
+	require.False(t, w.IsValidHeader(8, headers[8].Hash()))
+
+	// Milestones older than the whitelisted one are ignored
+	heimdall.EXPECT().FetchMilestone(gomock.Any()).Return(&milestone.Milestone{StartBlock: big.NewInt(1), EndBlock: big.NewInt(4), Hash: headers[4].Hash()}, nil)
+	require.NoError(t, FetchMilestone(ctx, w, heimdall, db, blockReader))
+	require.Equal(t, uint64(8), w.Milestone().Number)
+}
@@ -0,0 +1,169 @@
+package finality
+
+import (
+	"context"
+	"encoding/binary"
+	"fmt"
+	"sync"
+
+	libcommon "github.com/ledgerwatch/erigon-lib/common"
+	"github.com/ledgerwatch/erigon-lib/common/length"
+	"github.com/ledgerwatch/erigon-lib/kv"
+)
+
+var (
+	checkpointKey = []byte("whitelist-checkpoint")
+	milestoneKey  = []byte("whitelist-milestone")
+)
+
+// Entry is a block finalized by Heimdall, which every chain reaching its number must contain
+type Entry struct {
+	Number uint64         `json:"number"`
+	Hash   libcommon.Hash `json:"hash"`
+}
+
+// Whitelist holds the last checkpoint and the last milestone of Heimdall verified against the chain. The chains
+// conflicting with them are refused by the header downloader and the fork choice. The whitelist is persisted in the
+// bor consensus database, for the RPC daemon to read it.
+type Whitelist struct {
+	db kv.RwDB
+
+	lock       sync.RWMutex
+	checkpoint *Entry
+	milestone  *Entry
+}
+
+// NewWhitelist returns the whitelist persisted in db, the bor consensus database
+func NewWhitelist(db kv.RwDB) (*Whitelist, error) {
+	w := &Whitelist{db: db}
+	if err := db.View(context.Background(), func(tx kv.Tx) (err error) {
+		if w.checkpoint, err = ReadCheckpoint(tx); err != nil {
+			return err
+		}
+		w.milestone, err = ReadMilestone(tx)
+		return err
+	}); err != nil {
+		return nil, err
+	}
+	return w, nil
+}
+
+// Checkpoint returns the end block of the last whitelisted checkpoint, nil if there is none
+func (w *Whitelist) Checkpoint() *Entry {
+	w.lock.RLock()
+	defer w.lock.RUnlock()
+	return w.checkpoint
+}
+
+// Milestone returns the end block of the last whitelisted milestone, nil if there is none
+func (w *Whitelist) Milestone() *Entry {
+	w.lock.RLock()
+	defer w.lock.RUnlock()
+	return w.milestone
+}
+
+// ProcessCheckpoint whitelists the end block of a checkpoint
+func (w *Whitelist) ProcessCheckpoint(number uint64, hash libcommon.Hash) error {
+	entry := &Entry{Number: number, Hash: hash}
+	if err := w.db.Update(context.Background(), func(tx kv.RwTx) error {
+		return writeEntry(tx, checkpointKey, entry)
+	}); err != nil {
+		return err
+	}
+	w.lock.Lock()
+	defer w.lock.Unlock()
+	w.checkpoint = entry
+	return nil
+}
+
+// ProcessMilestone whitelists the end block of a milestone
+func (w *Whitelist) ProcessMilestone(number uint64, hash libcommon.Hash) error {
+	entry := &Entry{Number: number, Hash: hash}
+	if err := w.db.Update(context.Background(), func(tx kv.RwTx) error {
+		return writeEntry(tx, milestoneKey, entry)
+	}); err != nil {
+		return err
+	}
+	w.lock.Lock()
+	defer w.lock.Unlock()
+	w.milestone = entry
+	return nil
+}
+
+func (w *Whitelist) entries() []*Entry {
+	w.lock.RLock()
+	defer w.lock.RUnlock()
+	entries := make([]*Entry, 0, 2)
+	for _, entry := range []*Entry{w.checkpoint, w.milestone} {
+		if entry != nil {
+			entries = append(entries, entry)
+		}
+	}
+	return entries
+}
+
+// IsValidHeader tells whether a header does not conflict with the whitelisted blocks, i.e. it is not a different block
+// at a whitelisted number
+func (w *Whitelist) IsValidHeader(number uint64, hash libcommon.Hash) bool {
+	for _, entry := range w.entries() {
+		if entry.Number == number && entry.Hash != hash {
+			return false
+		}
+	}
+	return true
+}
+
+// IsValidChain tells whether the chain ending at tip, which forks from the canonical chain ending at current at the
+// block forkPoint, may become canonical. hashAt returns the hashes of the blocks of the chain after forkPoint. A chain
+// is refused if it has a different block at a whitelisted number, or if it is too short to reach a whitelisted block
+// of the canonical chain.
+func (w *Whitelist) IsValidChain(current, forkPoint, tip uint64, hashAt func(number uint64) (libcommon.Hash, error)) (bool, error) {
+	for _, entry := range w.entries() {
+		switch {
+		case entry.Number <= forkPoint:
+			// Shared with the canonical chain
+		case entry.Number <= tip:
+			hash, err := hashAt(entry.Number)
+			if err != nil {
+				return false, err
+			}
+			if hash != entry.Hash {
+				return false, nil
+			}
+		case current >= entry.Number:
+			return false, nil
+		}
+	}
+	return true, nil
+}
+
+// ReadCheckpoint returns the end block of the last whitelisted checkpoint from the bor consensus database
+func ReadCheckpoint(tx kv.Getter) (*Entry, error) {
+	return readEntry(tx, checkpointKey)
+}
+
+// ReadMilestone returns the end block of the last whitelisted milestone from the bor consensus database
+func ReadMilestone(tx kv.Getter) (*Entry, error) {
+	return readEntry(tx, milestoneKey)
+}
+
+func readEntry(tx kv.Getter, key []byte) (*Entry, error) {
+	v, err := tx.GetOne(kv.BorSeparate, key)
+	if err != nil {
+		return nil, err
+	}
+	if v == nil {
+		return nil, nil
+	}
+	if len(v) != 8+length.Hash {
+		return nil, fmt.Errorf("invalid whitelist entry %s of length %d", key, len(v))
+	}
+	return &Entry{Number: binary.BigEndian.Uint64(v), Hash: libcommon.BytesToHash(v[8:])}, nil
+}
+
+func writeEntry(tx kv.Putter, key []byte, entry *Entry) error {
+	v := make([]byte, 8+length.Hash)
+	binary.BigEndian.PutUint64(v, entry.Number)
+	copy(v[8:], entry.Hash[:])
+	return tx.Put(kv.BorSeparate, key, v)
+}
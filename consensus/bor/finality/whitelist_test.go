@@ -0,0 +1,57 @@
+package finality
+
+import (
+	"testing"
+
+	libcommon "github.com/ledgerwatch/erigon-lib/common"
+	"github.com/ledgerwatch/erigon-lib/kv/memdb"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWhitelistIsValidHeader(t *testing.T) {
+	db := memdb.NewTestDB(t)
+	w, err := NewWhitelist(db)
+	require.NoError(t, err)
+	require.Nil(t, w.Checkpoint())
+	require.Nil(t, w.Milestone())
+	require.True(t, w.IsValidHeader(10, libcommon.Hash{1}))
+
+	require.NoError(t, w.ProcessCheckpoint(10, libcommon.Hash{1}))
+	require.NoError(t, w.ProcessMilestone(20, libcommon.Hash{2}))
+	require.True(t, w.IsValidHeader(10, libcommon.Hash{1}))
+	require.False(t, w.IsValidHeader(10, libcommon.Hash{2}))
+	require.False(t, w.IsValidHeader(20, libcommon.Hash{1}))
+	require.True(t, w.IsValidHeader(15, libcommon.Hash{1}))
+
+	// The whitelist is restored from the database
+	w, err = NewWhitelist(db)
+	require.NoError(t, err)
+	require.Equal(t, &Entry{Number: 10, Hash: libcommon.Hash{1}}, w.Checkpoint())
+	require.Equal(t, &Entry{Number: 20, Hash: libcommon.Hash{2}}, w.Milestone())
+}
+
+func TestWhitelistIsValidChain(t *testing.T) {
+	w, err := NewWhitelist(memdb.NewTestDB(t))
+	require.NoError(t, err)
+	require.NoError(t, w.ProcessMilestone(20, libcommon.Hash{2}))
+	hashes := func(hash libcommon.Hash) func(uint64) (libcommon.Hash, error) {
+		return func(uint64) (libcommon.Hash, error) { return hash, nil }
+	}
+
+	for _, c := range []struct {
+		name                    string
+		current, forkPoint, tip uint64
+		hash                    libcommon.Hash
+		valid                   bool
+	}{
+		{"fork after the milestone", 25, 20, 30, libcommon.Hash{3}, true},
+		{"fork containing the milestone", 25, 15, 30, libcommon.Hash{2}, true},
+		{"fork replacing the milestone", 25, 15, 30, libcommon.Hash{3}, false},
+		{"fork too short to reach the milestone", 25, 15, 18, libcommon.Hash{3}, false},
+		{"fork before the chain reaches the milestone", 18, 15, 19, libcommon.Hash{3}, true},
+	} {
+		valid, err := w.IsValidChain(c.current, c.forkPoint, c.tip, hashes(c.hash))
+		require.NoError(t, err, c.name)
+		require.Equal(t, c.valid, valid, c.name)
+	}
+}
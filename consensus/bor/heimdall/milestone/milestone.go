@@ -0,0 +1,32 @@
+package milestone
+
+import (
+	"math/big"
+
+	libcommon "github.com/ledgerwatch/erigon-lib/common"
+)
+
+// Milestone defines a response object type of bor milestone
+type Milestone struct {
+	Proposer    libcommon.Address `json:"proposer"`
+	StartBlock  *big.Int          `json:"start_block"`
+	EndBlock    *big.Int          `json:"end_block"`
+	Hash        libcommon.Hash    `json:"hash"`
+	BorChainID  string            `json:"bor_chain_id"`
+	MilestoneID string            `json:"milestone_id"`
+	Timestamp   uint64            `json:"timestamp"`
+}
+
+type MilestoneResponse struct {
+	Height string    `json:"height"`
+	Result Milestone `json:"result"`
+}
+
+type MilestoneCount struct {
+	Count int64 `json:"count"`
+}
+
+type MilestoneCountResponse struct {
+	Height string         `json:"height"`
+	Result MilestoneCount `json:"result"`
+}
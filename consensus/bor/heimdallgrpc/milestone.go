@@ -0,0 +1,19 @@
+package heimdallgrpc
+
+import (
+	"context"
+	"errors"
+
+	"github.com/ledgerwatch/erigon/consensus/bor/heimdall/milestone"
+)
+
+// ErrMilestoneNotSupported is returned for milestone requests, which the Heimdall gRPC interface does not serve.
+var ErrMilestoneNotSupported = errors.New("milestones are not served by the Heimdall gRPC interface")
+
+func (h *HeimdallGRPCClient) FetchMilestone(ctx context.Context) (*milestone.Milestone, error) {
+	return nil, ErrMilestoneNotSupported
+}
+
+func (h *HeimdallGRPCClient) FetchMilestoneCount(ctx context.Context) (int64, error) {
+	return 0, ErrMilestoneNotSupported
+}
@@ -45,6 +45,7 @@ import (
 	"github.com/ledgerwatch/erigon/core/rawdb"
 	"github.com/ledgerwatch/erigon/core/state"
 	"github.com/ledgerwatch/erigon/core/types"
+	"github.com/ledgerwatch/erigon/core/verkletrie"
 	"github.com/ledgerwatch/erigon/crypto"
 	"github.com/ledgerwatch/erigon/eth/ethconfig"
 	"github.com/ledgerwatch/erigon/params"
@@ -227,8 +228,34 @@ func WriteGenesisState(g *types.Genesis, tx kv.RwTx, tmpDir string) (*types.Bloc
 			}
 		}
 	}
+	if verkletrie.IsVerkleChain(g.Config) {
+		// The verkle tree is built incrementally from the change sets, which history v3 doesn't keep
+		if histV3 {
+			return nil, statedb, fmt.Errorf("verkle state commitment is not supported with history v3")
+		}
+		root, err := verkleRoot(tx, tmpDir)
+		if err != nil {
+			return nil, statedb, fmt.Errorf("cannot build verkle tree: %w", err)
+		}
+		if root != block.Root() {
+			return nil, statedb, fmt.Errorf("verkle root mismatch: have %x, want %x", root, block.Root())
+		}
+		if err := rawdb.WriteVerkleRoot(tx, 0, root); err != nil {
+			return nil, statedb, err
+		}
+	}
 	return block, statedb, nil
 }
+
+// verkleRoot builds the verkle tree of the genesis state from its change sets and returns its root
+func verkleRoot(tx kv.RwTx, tmpDir string) (libcommon.Hash, error) {
+	verkleWriter := verkletrie.NewVerkleTreeWriter(tx, tmpDir, log.New())
+	defer verkleWriter.Close()
+	if err := verkletrie.IncrementAccount(tx, tx, 1, verkleWriter, 0, 0); err != nil {
+		return libcommon.Hash{}, err
+	}
+	return verkletrie.IncrementStorage(tx, tx, 1, verkleWriter, 0, 0, libcommon.Hash{})
+}
 func MustCommitGenesis(g *types.Genesis, db kv.RwDB, tmpDir string) *types.Block {
 	tx, err := db.BeginRw(context.Background())
 	if err != nil {
@@ -518,8 +545,13 @@ func GenesisToBlock(g *types.Genesis, tmpDir string) (*types.Block, *state.Intra
 		}
 		defer tx.Rollback()
 
-		r, w := state.NewDbStateReader(tx), state.NewDbStateWriter(tx, 0)
-		statedb = state.New(r)
+		// The verkle tree is built from the plain state and its change sets, the trie from the hashed state
+		verkle := verkletrie.IsVerkleChain(g.Config)
+		var w state.StateWriter = state.NewDbStateWriter(tx, 0)
+		if verkle {
+			w = state.NewPlainStateWriter(tx, tx, 0)
+		}
+		statedb = state.New(state.NewDbStateReader(tx))
 
 		hasConstructorAllocation := false
 		for _, account := range g.Alloc {
@@ -564,6 +596,13 @@ func GenesisToBlock(g *types.Genesis, tmpDir string) (*types.Block, *state.Intra
 		if err = statedb.FinalizeTx(&chain.Rules{}, w); err != nil {
 			return
 		}
+		if verkle {
+			if err = w.(state.WriterWithChangeSets).WriteChangeSets(); err != nil {
+				return
+			}
+			root, err = verkleRoot(tx, tmpDir)
+			return
+		}
 		if root, err = trie.CalcRoot("genesis", tx); err != nil {
 			return
 		}
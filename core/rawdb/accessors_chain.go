@@ -1375,6 +1375,23 @@ func WriteVerkleRoot(tx kv.RwTx, blockNum uint64, root libcommon.Hash) error {
 	return tx.Put(kv.VerkleRoots, hexutility.EncodeTs(blockNum), root[:])
 }
 
+// TruncateVerkleRoots deletes the verkle roots of the blocks from blockFrom
+func TruncateVerkleRoots(tx kv.RwTx, blockFrom uint64) error {
+	var keys [][]byte
+	if err := tx.ForEach(kv.VerkleRoots, hexutility.EncodeTs(blockFrom), func(k, _ []byte) error {
+		keys = append(keys, common.CopyBytes(k))
+		return nil
+	}); err != nil {
+		return err
+	}
+	for _, k := range keys {
+		if err := tx.Delete(kv.VerkleRoots, k); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
 func WriteVerkleNode(tx kv.RwTx, node verkle.VerkleNode) error {
 	var (
 		root    libcommon.Hash
@@ -1390,7 +1407,7 @@ func WriteVerkleNode(tx kv.RwTx, node verkle.VerkleNode) error {
 	return tx.Put(kv.VerkleTrie, root[:], encoded)
 }
 
-func ReadVerkleNode(tx kv.RwTx, root libcommon.Hash) (verkle.VerkleNode, error) {
+func ReadVerkleNode(tx kv.Tx, root libcommon.Hash) (verkle.VerkleNode, error) {
 	encoded, err := tx.GetOne(kv.VerkleTrie, root[:])
 	if err != nil {
 		return nil, err
@@ -0,0 +1,248 @@
+package rawdb
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"sort"
+
+	libcommon "github.com/ledgerwatch/erigon-lib/common"
+	"github.com/ledgerwatch/erigon-lib/kv"
+
+	"github.com/ledgerwatch/erigon/core/types"
+)
+
+// LogCounts is the number of logs per shard of LogCountsShardSize blocks of each address and topic, and of all the
+// logs, maintained by the log index stage along with its bitmaps. It tells how many logs a range of blocks holds
+// without reading them.
+//
+// key - kind (1 byte) + address (20 bytes) or topic (32 bytes), or nothing for all the logs + first block of the
+// shard (uint32 BigEndian)
+// value - number of logs (uint64 BigEndian)
+//
+// Topics count the logs which have them in any position, once per log.
+//
+// The log index stage counts the logs of the blocks it indexes from the first time it runs with the table, the key
+// of the first counted block (1 byte kind, value uint64 BigEndian) tells the shards which are complete.
+const LogCounts = "LogCounts"
+
+const LogCountsShardSize = 1_000
+
+const (
+	logCountsAll byte = iota
+	logCountsAddress
+	logCountsTopic
+	logCountsFrom
+)
+
+// WithLogCounts adds LogCounts to the tables of a chaindata database, see mdbx.MdbxOpts.WithTableCfg. The table is not
+// one of the tables of erigon-lib, so it is configured for each database rather than in kv.ChaindataTablesCfg.
+func WithLogCounts(tables kv.TableCfg) kv.TableCfg {
+	cfg := make(kv.TableCfg, len(tables)+1)
+	for name, item := range tables {
+		cfg[name] = item
+	}
+	cfg[LogCounts] = kv.TableCfgItem{}
+	return cfg
+}
+
+// CreateLogCounts creates LogCounts in the databases opened without WithLogCounts
+func CreateLogCounts(tx kv.RwTx) error {
+	exists, err := tx.ExistsBucket(LogCounts)
+	if err != nil || exists {
+		return err
+	}
+	return tx.CreateBucket(LogCounts)
+}
+
+// HasLogCounts tells whether the database of tx has LogCounts. It does not when it was opened without WithLogCounts
+// and the log index stage did not create it, nor when it is remote.
+func HasLogCounts(tx kv.Tx) (bool, error) {
+	migrator, ok := tx.(interface {
+		ExistsBucket(string) (bool, error)
+	})
+	if !ok {
+		return false, nil
+	}
+	return migrator.ExistsBucket(LogCounts)
+}
+
+// ReadLogCountsFrom returns the first block whose logs are counted, ok is false when no logs are counted
+func ReadLogCountsFrom(tx kv.Tx) (from uint64, ok bool, err error) {
+	hasCounts, err := HasLogCounts(tx)
+	if err != nil || !hasCounts {
+		return 0, false, err
+	}
+	v, err := tx.GetOne(LogCounts, []byte{logCountsFrom})
+	if err != nil || len(v) != 8 {
+		return 0, false, err
+	}
+	return binary.BigEndian.Uint64(v), true, nil
+}
+
+// WriteLogCountsFrom sets the first block whose logs are counted
+func WriteLogCountsFrom(tx kv.RwTx, from uint64) error {
+	var buf [8]byte
+	binary.BigEndian.PutUint64(buf[:], from)
+	return tx.Put(LogCounts, []byte{logCountsFrom}, buf[:])
+}
+
+// LogCountsShard returns the first block of the shard of blockNum
+func LogCountsShard(blockNum uint64) uint64 {
+	return blockNum - blockNum%LogCountsShardSize
+}
+
+func logCountsKey(kind byte, key []byte, shard uint64) []byte {
+	k := make([]byte, 1+len(key)+4)
+	k[0] = kind
+	copy(k[1:], key)
+	binary.BigEndian.PutUint32(k[1+len(key):], uint32(shard))
+	return k
+}
+
+// CountLogs adds the logs of a block to the counts of the keys of LogCounts
+func CountLogs(counts map[string]uint64, blockNum uint64, logs types.Logs) {
+	shard := LogCountsShard(blockNum)
+	for _, l := range logs {
+		counts[string(logCountsKey(logCountsAll, nil, shard))]++
+		counts[string(logCountsKey(logCountsAddress, l.Address[:], shard))]++
+		for i, topic := range l.Topics {
+			if !containsTopic(l.Topics[:i], topic) {
+				counts[string(logCountsKey(logCountsTopic, topic[:], shard))]++
+			}
+		}
+	}
+}
+
+func containsTopic(topics []libcommon.Hash, topic libcommon.Hash) bool {
+	for _, t := range topics {
+		if t == topic {
+			return true
+		}
+	}
+	return false
+}
+
+// AddLogCounts adds counts made by CountLogs to LogCounts, or subtracts them if sub is set
+func AddLogCounts(tx kv.RwTx, counts map[string]uint64, sub bool) error {
+	keys := make([]string, 0, len(counts))
+	for k := range counts {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	var buf [8]byte
+	for _, k := range keys {
+		v, err := tx.GetOne(LogCounts, []byte(k))
+		if err != nil {
+			return err
+		}
+		var n uint64
+		if len(v) == 8 {
+			n = binary.BigEndian.Uint64(v)
+		}
+		if sub {
+			if counts[k] > n {
+				return fmt.Errorf("log count %x is %d, below %d", k, n, counts[k])
+			}
+			n -= counts[k]
+		} else {
+			n += counts[k]
+		}
+		if n == 0 {
+			if err := tx.Delete(LogCounts, []byte(k)); err != nil {
+				return err
+			}
+			continue
+		}
+		binary.BigEndian.PutUint64(buf[:], n)
+		if err := tx.Put(LogCounts, []byte(k), buf[:]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// PruneLogCounts deletes the shards of the address or topic which end at or before pruneTo
+func PruneLogCounts(tx kv.RwTx, key []byte, pruneTo uint64) error {
+	for _, prefix := range logCountsPrefixes(key) {
+		if err := pruneLogCounts(tx, prefix, pruneTo); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// PruneAllLogCounts deletes the shards of the count of all the logs which end at or before pruneTo
+func PruneAllLogCounts(tx kv.RwTx, pruneTo uint64) error {
+	return pruneLogCounts(tx, []byte{logCountsAll}, pruneTo)
+}
+
+func logCountsPrefixes(key []byte) [][]byte {
+	switch len(key) {
+	case 20:
+		return [][]byte{append([]byte{logCountsAddress}, key...)}
+	case 32:
+		return [][]byte{append([]byte{logCountsTopic}, key...)}
+	}
+	return nil
+}
+
+func pruneLogCounts(tx kv.RwTx, prefix []byte, pruneTo uint64) error {
+	c, err := tx.RwCursor(LogCounts)
+	if err != nil {
+		return err
+	}
+	defer c.Close()
+	for k, _, err := c.Seek(prefix); k != nil; k, _, err = c.Next() {
+		if err != nil {
+			return err
+		}
+		if !bytes.HasPrefix(k, prefix) || len(k) != len(prefix)+4 {
+			break
+		}
+		if uint64(binary.BigEndian.Uint32(k[len(prefix):]))+LogCountsShardSize > pruneTo {
+			break
+		}
+		if err := c.DeleteCurrent(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ReadAllLogCount returns the number of logs of the shards starting in [fromShard, toShard)
+func ReadAllLogCount(tx kv.Tx, fromShard, toShard uint64) (uint64, error) {
+	return readLogCount(tx, []byte{logCountsAll}, fromShard, toShard)
+}
+
+// ReadAddressLogCount returns the number of logs of the address in the shards starting in [fromShard, toShard)
+func ReadAddressLogCount(tx kv.Tx, address libcommon.Address, fromShard, toShard uint64) (uint64, error) {
+	return readLogCount(tx, append([]byte{logCountsAddress}, address[:]...), fromShard, toShard)
+}
+
+// ReadTopicLogCount returns the number of logs with the topic in the shards starting in [fromShard, toShard)
+func ReadTopicLogCount(tx kv.Tx, topic libcommon.Hash, fromShard, toShard uint64) (uint64, error) {
+	return readLogCount(tx, append([]byte{logCountsTopic}, topic[:]...), fromShard, toShard)
+}
+
+func readLogCount(tx kv.Tx, prefix []byte, fromShard, toShard uint64) (uint64, error) {
+	c, err := tx.Cursor(LogCounts)
+	if err != nil {
+		return 0, err
+	}
+	defer c.Close()
+	var n uint64
+	for k, v, err := c.Seek(logCountsKey(prefix[0], prefix[1:], fromShard)); k != nil; k, v, err = c.Next() {
+		if err != nil {
+			return 0, err
+		}
+		if !bytes.HasPrefix(k, prefix) || len(k) != len(prefix)+4 {
+			break
+		}
+		if uint64(binary.BigEndian.Uint32(k[len(prefix):])) >= toShard {
+			break
+		}
+		n += binary.BigEndian.Uint64(v)
+	}
+	return n, nil
+}
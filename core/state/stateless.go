@@ -0,0 +1,179 @@
+package state
+
+import (
+	"bytes"
+	"fmt"
+	"sort"
+
+	"github.com/holiman/uint256"
+	libcommon "github.com/ledgerwatch/erigon-lib/common"
+
+	"github.com/ledgerwatch/erigon/common"
+	"github.com/ledgerwatch/erigon/core/types/accounts"
+	"github.com/ledgerwatch/erigon/crypto"
+	"github.com/ledgerwatch/erigon/turbo/trie"
+)
+
+var _ StateReader = (*Stateless)(nil)
+var _ WriterWithChangeSets = (*Stateless)(nil)
+
+// Stateless reads and writes the state of a block in the trie built from its witness, which holds only the parts of
+// the state the block accesses. The writes are buffered and applied to the trie by WriteChangeSets, since the order
+// in which the IntraBlockState commits them does not suit the trie.
+type Stateless struct {
+	t       *trie.Trie
+	err     error
+	changes map[libcommon.Address]*statelessChange
+}
+
+type statelessChange struct {
+	deleted bool
+	created bool
+	account *accounts.Account
+	storage map[libcommon.Hash][]byte
+}
+
+func NewStateless(t *trie.Trie) *Stateless {
+	return &Stateless{t: t, changes: map[libcommon.Address]*statelessChange{}}
+}
+
+// Err returns the first read of a part of the state missing from the witness. Such reads return empty values, so
+// the execution of the block is wrong when it is set.
+func (s *Stateless) Err() error {
+	return s.err
+}
+
+// Root returns the root of the trie, with the changes applied by WriteChangeSets
+func (s *Stateless) Root() libcommon.Hash {
+	return s.t.Hash()
+}
+
+func (s *Stateless) missing(format string, args ...interface{}) {
+	if s.err == nil {
+		s.err = fmt.Errorf("missing from the witness: "+format, args...)
+	}
+}
+
+func (s *Stateless) ReadAccountData(address libcommon.Address) (*accounts.Account, error) {
+	addrHash := crypto.Keccak256(address[:])
+	acc, ok := s.t.GetAccount(addrHash)
+	if !ok {
+		s.missing("account %x", address)
+		return nil, nil
+	}
+	if acc != nil && (!acc.IsEmptyCodeHash() || !acc.IsEmptyRoot()) {
+		// Witnesses do not carry incarnations, which only matter to the storage layout of the database
+		acc.Incarnation = FirstContractIncarnation
+	}
+	return acc, nil
+}
+
+func (s *Stateless) ReadAccountStorage(address libcommon.Address, incarnation uint64, key *libcommon.Hash) ([]byte, error) {
+	value, ok := s.t.Get(append(crypto.Keccak256(address[:]), crypto.Keccak256(key[:])...))
+	if !ok {
+		s.missing("storage %x of account %x", *key, address)
+		return nil, nil
+	}
+	return value, nil
+}
+
+func (s *Stateless) ReadAccountCode(address libcommon.Address, incarnation uint64, codeHash libcommon.Hash) ([]byte, error) {
+	if bytes.Equal(codeHash[:], emptyCodeHash) {
+		return nil, nil
+	}
+	code, ok := s.t.GetAccountCode(crypto.Keccak256(address[:]))
+	if !ok {
+		s.missing("code of account %x", address)
+		return nil, nil
+	}
+	return code, nil
+}
+
+func (s *Stateless) ReadAccountCodeSize(address libcommon.Address, incarnation uint64, codeHash libcommon.Hash) (int, error) {
+	code, err := s.ReadAccountCode(address, incarnation, codeHash)
+	return len(code), err
+}
+
+func (s *Stateless) ReadAccountIncarnation(address libcommon.Address) (uint64, error) {
+	return 0, nil
+}
+
+func (s *Stateless) change(address libcommon.Address) *statelessChange {
+	c, ok := s.changes[address]
+	if !ok {
+		c = &statelessChange{storage: map[libcommon.Hash][]byte{}}
+		s.changes[address] = c
+	}
+	return c
+}
+
+func (s *Stateless) UpdateAccountData(address libcommon.Address, original, account *accounts.Account) error {
+	c := s.change(address)
+	c.account = new(accounts.Account)
+	c.account.Copy(account)
+	return nil
+}
+
+func (s *Stateless) UpdateAccountCode(address libcommon.Address, incarnation uint64, codeHash libcommon.Hash, code []byte) error {
+	return nil
+}
+
+func (s *Stateless) DeleteAccount(address libcommon.Address, original *accounts.Account) error {
+	c := s.change(address)
+	c.deleted = true
+	c.account = nil
+	c.storage = map[libcommon.Hash][]byte{}
+	return nil
+}
+
+func (s *Stateless) WriteAccountStorage(address libcommon.Address, incarnation uint64, key *libcommon.Hash, original, value *uint256.Int) error {
+	s.change(address).storage[*key] = value.Bytes()
+	return nil
+}
+
+func (s *Stateless) CreateContract(address libcommon.Address) error {
+	s.change(address).created = true
+	return nil
+}
+
+// WriteChangeSets applies the buffered writes to the trie
+func (s *Stateless) WriteChangeSets() error {
+	addresses := make([]libcommon.Address, 0, len(s.changes))
+	for address := range s.changes {
+		addresses = append(addresses, address)
+	}
+	sort.Slice(addresses, func(i, j int) bool { return bytes.Compare(addresses[i][:], addresses[j][:]) < 0 })
+	for _, address := range addresses {
+		c := s.changes[address]
+		addrHash := crypto.Keccak256(address[:])
+		if c.deleted {
+			s.t.Delete(addrHash)
+		}
+		if c.account == nil {
+			continue
+		}
+		s.t.UpdateAccount(addrHash, c.account)
+		if c.created {
+			s.t.DeleteSubtree(addrHash)
+		}
+		keys := make([]libcommon.Hash, 0, len(c.storage))
+		for key := range c.storage {
+			keys = append(keys, key)
+		}
+		sort.Slice(keys, func(i, j int) bool { return bytes.Compare(keys[i][:], keys[j][:]) < 0 })
+		for _, key := range keys {
+			storageKey := append(common.CopyBytes(addrHash), crypto.Keccak256(key[:])...)
+			if value := c.storage[key]; len(value) > 0 {
+				s.t.Update(storageKey, value)
+			} else {
+				s.t.Delete(storageKey)
+			}
+		}
+	}
+	s.changes = map[libcommon.Address]*statelessChange{}
+	return nil
+}
+
+func (s *Stateless) WriteHistory() error {
+	return nil
+}
@@ -0,0 +1,106 @@
+package state
+
+import (
+	"github.com/holiman/uint256"
+	libcommon "github.com/ledgerwatch/erigon-lib/common"
+
+	"github.com/ledgerwatch/erigon/core/types/accounts"
+)
+
+var _ StateReader = (*WitnessRecorder)(nil)
+var _ WriterWithChangeSets = (*WitnessRecorder)(nil)
+
+// StorageAccess identifies a storage slot as it is read and written, with the incarnation of its contract
+type StorageAccess struct {
+	Address     libcommon.Address
+	Incarnation uint64
+	Key         libcommon.Hash
+}
+
+// WitnessRecorder records the parts of the state a block accesses while it is executed, which make its witness. It
+// reads through the given reader and discards the writes, only noting the deletions, which change the shape of the
+// trie beyond the deleted paths.
+type WitnessRecorder struct {
+	reader StateReader
+
+	Accounts map[libcommon.Address]bool // Accessed accounts, true for the deleted ones
+	Storage  map[StorageAccess]bool     // Accessed storage slots, true for the cleared ones
+	Codes    map[libcommon.Hash][]byte  // Accessed code, including the one of which only the size is read
+}
+
+func NewWitnessRecorder(reader StateReader) *WitnessRecorder {
+	return &WitnessRecorder{
+		reader:   reader,
+		Accounts: map[libcommon.Address]bool{},
+		Storage:  map[StorageAccess]bool{},
+		Codes:    map[libcommon.Hash][]byte{},
+	}
+}
+
+func (r *WitnessRecorder) ReadAccountData(address libcommon.Address) (*accounts.Account, error) {
+	if _, ok := r.Accounts[address]; !ok {
+		r.Accounts[address] = false
+	}
+	return r.reader.ReadAccountData(address)
+}
+
+func (r *WitnessRecorder) ReadAccountStorage(address libcommon.Address, incarnation uint64, key *libcommon.Hash) ([]byte, error) {
+	access := StorageAccess{Address: address, Incarnation: incarnation, Key: *key}
+	if _, ok := r.Storage[access]; !ok {
+		r.Storage[access] = false
+	}
+	return r.reader.ReadAccountStorage(address, incarnation, key)
+}
+
+func (r *WitnessRecorder) ReadAccountCode(address libcommon.Address, incarnation uint64, codeHash libcommon.Hash) ([]byte, error) {
+	code, err := r.reader.ReadAccountCode(address, incarnation, codeHash)
+	if err != nil {
+		return nil, err
+	}
+	if len(code) > 0 {
+		r.Codes[codeHash] = code
+	}
+	return code, nil
+}
+
+func (r *WitnessRecorder) ReadAccountCodeSize(address libcommon.Address, incarnation uint64, codeHash libcommon.Hash) (int, error) {
+	// Witnesses carry the size of the code with the code only
+	code, err := r.ReadAccountCode(address, incarnation, codeHash)
+	return len(code), err
+}
+
+func (r *WitnessRecorder) ReadAccountIncarnation(address libcommon.Address) (uint64, error) {
+	return r.reader.ReadAccountIncarnation(address)
+}
+
+func (r *WitnessRecorder) UpdateAccountData(address libcommon.Address, original, account *accounts.Account) error {
+	return nil
+}
+
+func (r *WitnessRecorder) UpdateAccountCode(address libcommon.Address, incarnation uint64, codeHash libcommon.Hash, code []byte) error {
+	return nil
+}
+
+func (r *WitnessRecorder) DeleteAccount(address libcommon.Address, original *accounts.Account) error {
+	r.Accounts[address] = true
+	return nil
+}
+
+func (r *WitnessRecorder) WriteAccountStorage(address libcommon.Address, incarnation uint64, key *libcommon.Hash, original, value *uint256.Int) error {
+	if value.IsZero() {
+		r.Storage[StorageAccess{Address: address, Incarnation: incarnation, Key: *key}] = true
+	}
+	return nil
+}
+
+func (r *WitnessRecorder) CreateContract(address libcommon.Address) error {
+	return nil
+}
+
+func (r *WitnessRecorder) WriteChangeSets() error {
+	return nil
+}
+
+func (r *WitnessRecorder) WriteHistory() error {
+	return nil
+}
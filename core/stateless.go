@@ -0,0 +1,239 @@
+package core
+
+import (
+	"bytes"
+	"fmt"
+	"math/big"
+
+	"github.com/ledgerwatch/erigon-lib/chain"
+	libcommon "github.com/ledgerwatch/erigon-lib/common"
+	"github.com/ledgerwatch/erigon-lib/kv"
+	"github.com/ledgerwatch/log/v3"
+
+	"github.com/ledgerwatch/erigon/common/dbutils"
+	"github.com/ledgerwatch/erigon/consensus"
+	"github.com/ledgerwatch/erigon/core/state"
+	"github.com/ledgerwatch/erigon/core/types"
+	"github.com/ledgerwatch/erigon/core/vm"
+	"github.com/ledgerwatch/erigon/crypto"
+	"github.com/ledgerwatch/erigon/rlp"
+	"github.com/ledgerwatch/erigon/turbo/trie"
+)
+
+// BlockWitness is what a block needs to be executed on top of its parent header without the state: the parts of the
+// state of the parent the block accesses, in the witness format of the trie, and the headers of the ancestors before
+// the parent the block reads the hashes of with BLOCKHASH, from the grandparent down.
+type BlockWitness struct {
+	Headers []*types.Header
+	State   []byte
+}
+
+func DecodeBlockWitness(enc []byte) (*BlockWitness, error) {
+	w := new(BlockWitness)
+	if err := rlp.DecodeBytes(enc, w); err != nil {
+		return nil, err
+	}
+	return w, nil
+}
+
+// BuildBlockWitness executes the block on top of the state of its parent, read with stateReader, to collect the
+// parts of the state it accesses, and builds its witness out of the trie of the parent. The hashed state and the
+// intermediate hashes in tx must be the ones of the parent.
+func BuildBlockWitness(tx kv.Tx, chainConfig *chain.Config, engine consensus.Engine, block *types.Block, parent *types.Header,
+	stateReader state.StateReader, chainReader consensus.ChainHeaderReader, logger log.Logger) (*BlockWitness, error) {
+	if block.ParentHash() != parent.Hash() {
+		return nil, fmt.Errorf("block %d does not follow the header %x", block.NumberU64(), parent.Hash())
+	}
+	recorder := state.NewWitnessRecorder(stateReader)
+	getHash := GetHashFn(block.Header(), chainReader.GetHeader)
+	lowest := parent.Number.Uint64()
+	blockHashFunc := func(n uint64) libcommon.Hash {
+		if n < lowest {
+			lowest = n
+		}
+		return getHash(n)
+	}
+	if _, err := ExecuteBlockEphemerally(chainConfig, &vm.Config{}, blockHashFunc, engine, block, recorder, recorder, chainReader, nil, logger); err != nil {
+		return nil, fmt.Errorf("executing block %d: %w", block.NumberU64(), err)
+	}
+
+	// The root is always expanded, since a witness made of the root hash only does not build a trie
+	keys, deleted := [][]byte{{}}, [][]byte(nil)
+	for address, del := range recorder.Accounts {
+		key := witnessHexKey(crypto.Keccak256(address[:]))
+		keys = append(keys, key)
+		if del {
+			deleted = append(deleted, key)
+		}
+	}
+	for access, cleared := range recorder.Storage {
+		key := witnessHexKey(witnessStorageKey(access))
+		keys = append(keys, key)
+		if cleared {
+			deleted = append(deleted, key)
+		}
+	}
+	pr, err := witnessProofs(tx, parent.Root, keys)
+	if err != nil {
+		return nil, err
+	}
+	// The deletions may collapse the branches on their paths, which requires the nodes beside them
+	var siblings [][]byte
+	for _, key := range deleted {
+		siblings = append(siblings, pr.SiblingPaths(key)...)
+	}
+	if len(siblings) > 0 {
+		if pr, err = witnessProofs(tx, parent.Root, append(keys, siblings...)); err != nil {
+			return nil, err
+		}
+	}
+	t, err := pr.PartialTrie(parent.Root, recorder.Codes)
+	if err != nil {
+		return nil, err
+	}
+	tw, err := t.ExtractWitness(false, nil)
+	if err != nil {
+		return nil, err
+	}
+	var buf bytes.Buffer
+	if _, err := tw.WriteInto(&buf); err != nil {
+		return nil, err
+	}
+
+	w := &BlockWitness{State: buf.Bytes()}
+	for h := parent; h.Number.Uint64() > lowest+1; {
+		if h = chainReader.GetHeader(h.ParentHash, h.Number.Uint64()-1); h == nil {
+			return nil, fmt.Errorf("no ancestor %d of block %d", lowest+1, block.NumberU64())
+		}
+		w.Headers = append(w.Headers, h)
+	}
+	return w, nil
+}
+
+// witnessProofs collects the nodes of the trie of root in tx on the paths of the given keys
+func witnessProofs(tx kv.Tx, root libcommon.Hash, hexKeys [][]byte) (*trie.ProofRetainer, error) {
+	rl := trie.NewRetainList(0)
+	pr := trie.NewMultiProofRetainer(hexKeys, rl)
+	loader := trie.NewFlatDBTrieLoader("witness", rl, nil, nil, false)
+	loader.SetProofRetainer(pr)
+	computed, err := loader.CalcTrieRoot(tx, nil)
+	if err != nil {
+		return nil, err
+	}
+	if computed != root {
+		return nil, fmt.Errorf("computed state root %x does not match the parent root %x", computed, root)
+	}
+	return pr, nil
+}
+
+// witnessStorageKey is the key of a storage slot in the trie loader, with the incarnation of its contract
+func witnessStorageKey(access state.StorageAccess) []byte {
+	addrHash := libcommon.BytesToHash(crypto.Keccak256(access.Address[:]))
+	return dbutils.GenerateCompositeStorageKey(addrHash, access.Incarnation, libcommon.BytesToHash(crypto.Keccak256(access.Key[:])))
+}
+
+func witnessHexKey(key []byte) []byte {
+	nibbles := make([]byte, 2*len(key))
+	for i, b := range key {
+		nibbles[i*2] = b / 16
+		nibbles[i*2+1] = b % 16
+	}
+	return nibbles
+}
+
+// ExecuteBlockStateless verifies the block on top of its parent header with its witness only: it executes the block
+// on the state of the witness, which must have the root of the parent, and checks the root of the resulting state
+// against the header of the block.
+func ExecuteBlockStateless(chainConfig *chain.Config, engine consensus.Engine, parent *types.Header, block *types.Block,
+	witness *BlockWitness, logger log.Logger) error {
+	if block.ParentHash() != parent.Hash() {
+		return fmt.Errorf("block %d does not follow the header %x", block.NumberU64(), parent.Hash())
+	}
+	chainReader := newWitnessHeaderReader(chainConfig, parent)
+	for _, h := range witness.Headers {
+		if err := chainReader.add(h); err != nil {
+			return err
+		}
+	}
+
+	tw, err := trie.NewWitnessFromReader(bytes.NewReader(witness.State), false)
+	if err != nil {
+		return fmt.Errorf("decoding witness of block %d: %w", block.NumberU64(), err)
+	}
+	t, err := trie.BuildTrieFromWitness(tw, false)
+	if err != nil {
+		return fmt.Errorf("building trie of block %d: %w", block.NumberU64(), err)
+	}
+	if root := t.Hash(); root != parent.Root {
+		return fmt.Errorf("witness root %x does not match the parent root %x", root, parent.Root)
+	}
+
+	stateless := state.NewStateless(t)
+	blockHashFunc := GetHashFn(block.Header(), chainReader.GetHeader)
+	_, err = ExecuteBlockEphemerally(chainConfig, &vm.Config{}, blockHashFunc, engine, block, stateless, stateless, chainReader, nil, logger)
+	if stateless.Err() != nil {
+		return fmt.Errorf("executing block %d: %w", block.NumberU64(), stateless.Err())
+	}
+	if err != nil {
+		return fmt.Errorf("executing block %d: %w", block.NumberU64(), err)
+	}
+	if root := stateless.Root(); root != block.Root() {
+		return fmt.Errorf("wrong state root of block %d: %x, expected (from header): %x", block.NumberU64(), root, block.Root())
+	}
+	return nil
+}
+
+// witnessHeaderReader serves the parent of a block and the ancestors of the witness to its execution
+type witnessHeaderReader struct {
+	config  *chain.Config
+	current *types.Header
+	lowest  *types.Header
+	headers map[libcommon.Hash]*types.Header
+}
+
+func newWitnessHeaderReader(config *chain.Config, parent *types.Header) *witnessHeaderReader {
+	return &witnessHeaderReader{
+		config:  config,
+		current: parent,
+		lowest:  parent,
+		headers: map[libcommon.Hash]*types.Header{parent.Hash(): parent},
+	}
+}
+
+// add adds the parent of the lowest header
+func (r *witnessHeaderReader) add(h *types.Header) error {
+	if h.Hash() != r.lowest.ParentHash || h.Number.Uint64()+1 != r.lowest.Number.Uint64() {
+		return fmt.Errorf("witness header %d is not the parent of header %d", h.Number.Uint64(), r.lowest.Number.Uint64())
+	}
+	r.headers[h.Hash()] = h
+	r.lowest = h
+	return nil
+}
+
+func (r *witnessHeaderReader) Config() *chain.Config        { return r.config }
+func (r *witnessHeaderReader) CurrentHeader() *types.Header { return r.current }
+func (r *witnessHeaderReader) FrozenBlocks() uint64         { return 0 }
+
+func (r *witnessHeaderReader) GetHeader(hash libcommon.Hash, number uint64) *types.Header {
+	if h, ok := r.headers[hash]; ok && h.Number.Uint64() == number {
+		return h
+	}
+	return nil
+}
+
+func (r *witnessHeaderReader) GetHeaderByNumber(number uint64) *types.Header {
+	for _, h := range r.headers {
+		if h.Number.Uint64() == number {
+			return h
+		}
+	}
+	return nil
+}
+
+func (r *witnessHeaderReader) GetHeaderByHash(hash libcommon.Hash) *types.Header {
+	return r.headers[hash]
+}
+
+func (r *witnessHeaderReader) GetTd(hash libcommon.Hash, number uint64) *big.Int {
+	return nil
+}
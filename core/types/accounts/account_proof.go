@@ -22,3 +22,13 @@ type StorProofResult struct {
 	Value *hexutil.Big       `json:"value"`
 	Proof []hexutility.Bytes `json:"proof"`
 }
+
+// VerkleProofResult is the result of eth_getVerkleProof: the values of the leaves of an account and of its storage
+// slots in the verkle tree of the state, with a multiproof of them against the state root
+type VerkleProofResult struct {
+	Address   libcommon.Address  `json:"address"`
+	StateRoot libcommon.Hash     `json:"stateRoot"`
+	Keys      []hexutility.Bytes `json:"keys"`
+	Values    []hexutility.Bytes `json:"values"`
+	Proof     hexutility.Bytes   `json:"proof"`
+}
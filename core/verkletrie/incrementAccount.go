@@ -12,11 +12,37 @@ import (
 	"github.com/ledgerwatch/erigon-lib/kv/temporal/historyv2"
 	"github.com/ledgerwatch/log/v3"
 
+	"github.com/ledgerwatch/erigon/common"
 	"github.com/ledgerwatch/erigon/common/debug"
 	"github.com/ledgerwatch/erigon/core/types/accounts"
 )
 
+// IncrementAccount collects the accounts changed by blocks [from, to] into verkleWriter, with their values after block
+// to. Block to can be behind the plain state, e.g. to unwind the tree.
 func IncrementAccount(vTx kv.RwTx, tx kv.Tx, workers uint64, verkleWriter *VerkleTreeWriter, from, to uint64) error {
+	valuesAfter, err := changedAfter(tx, kv.AccountChangeSet, to, historyv2.DecodeAccounts)
+	if err != nil {
+		return err
+	}
+	return updateAccounts(tx, workers, verkleWriter, from, to, func(address, _ []byte) ([]byte, error) {
+		if value, ok := valuesAfter[string(address)]; ok {
+			return value, nil
+		}
+		return tx.GetOne(kv.PlainState, address)
+	})
+}
+
+// RevertAccount collects the accounts changed by block into verkleWriter, with their values before the block, which
+// turns the tree of the block into the tree of its parent.
+func RevertAccount(tx kv.Tx, workers uint64, verkleWriter *VerkleTreeWriter, block uint64) error {
+	return updateAccounts(tx, workers, verkleWriter, block, block, func(_, before []byte) ([]byte, error) {
+		return before, nil
+	})
+}
+
+// updateAccounts collects the accounts changed by blocks [from, to] into verkleWriter, with the values returned by
+// value from the address and the value before the change of the first block which changed it.
+func updateAccounts(tx kv.Tx, workers uint64, verkleWriter *VerkleTreeWriter, from, to uint64, value func(address, before []byte) ([]byte, error)) error {
 	logInterval := time.NewTicker(30 * time.Second)
 	logPrefix := "IncrementVerkleAccount"
 
@@ -41,9 +67,11 @@ func IncrementAccount(vTx kv.RwTx, tx kv.Tx, workers uint64, verkleWriter *Verkl
 	defer accountCursor.Close()
 
 	// Start Goroutine for collection
+	collected := make(chan struct{})
 	go func() {
 		defer debug.LogPanic()
 		defer cancelWorkers()
+		defer close(collected)
 		for o := range out {
 			if o.absentInState {
 				if err := verkleWriter.DeleteAccount(o.versionHash, o.isContract); err != nil {
@@ -66,7 +94,7 @@ func IncrementAccount(vTx kv.RwTx, tx kv.Tx, workers uint64, verkleWriter *Verkl
 		if err != nil {
 			return err
 		}
-		blockNumber, addressBytes, _, err := historyv2.DecodeAccounts(k, v)
+		blockNumber, addressBytes, before, err := historyv2.DecodeAccounts(k, v)
 		if err != nil {
 			return err
 		}
@@ -85,7 +113,7 @@ func IncrementAccount(vTx kv.RwTx, tx kv.Tx, workers uint64, verkleWriter *Verkl
 			continue
 		}
 
-		encodedAccount, err := tx.GetOne(kv.PlainState, addressBytes)
+		encodedAccount, err := value(addressBytes, before)
 		if err != nil {
 			return err
 		}
@@ -134,5 +162,31 @@ func IncrementAccount(vTx kv.RwTx, tx kv.Tx, workers uint64, verkleWriter *Verkl
 	close(jobs)
 	wg.Wait()
 	close(out)
+	<-collected
 	return nil
 }
+
+// changedAfter returns the values before their first change after block of the keys of the change set table changed
+// after block, i.e. their values after block.
+func changedAfter(tx kv.Tx, changeSetTable string, block uint64, decode func(k, v []byte) (uint64, []byte, []byte, error)) (map[string][]byte, error) {
+	changeSetCursor, err := tx.CursorDupSort(changeSetTable)
+	if err != nil {
+		return nil, err
+	}
+	defer changeSetCursor.Close()
+
+	values := make(map[string][]byte)
+	for k, v, err := changeSetCursor.Seek(hexutility.EncodeTs(block + 1)); k != nil; k, v, err = changeSetCursor.Next() {
+		if err != nil {
+			return nil, err
+		}
+		_, key, value, err := decode(k, v)
+		if err != nil {
+			return nil, err
+		}
+		if _, ok := values[string(key)]; !ok {
+			values[string(key)] = common.CopyBytes(value)
+		}
+	}
+	return values, nil
+}
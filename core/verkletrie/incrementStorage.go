@@ -14,10 +14,37 @@ import (
 
 	"github.com/ledgerwatch/erigon/common"
 	"github.com/ledgerwatch/erigon/common/debug"
-	"github.com/ledgerwatch/erigon/core/rawdb"
 )
 
-func IncrementStorage(vTx kv.RwTx, tx kv.Tx, workers uint64, verkleWriter *VerkleTreeWriter, from, to uint64) (libcommon.Hash, error) {
+// IncrementStorage collects the storage slots changed by blocks [from, to] into verkleWriter, with their values after
+// block to, and commits the collected changes to the tree of root. Block to can be behind the plain state, e.g. to
+// unwind the tree.
+func IncrementStorage(vTx kv.RwTx, tx kv.Tx, workers uint64, verkleWriter *VerkleTreeWriter, from, to uint64, root libcommon.Hash) (libcommon.Hash, error) {
+	valuesAfter, err := changedAfter(tx, kv.StorageChangeSet, to, historyv2.DecodeStorage)
+	if err != nil {
+		return libcommon.Hash{}, err
+	}
+	return updateStorage(tx, workers, verkleWriter, from, to, root, func(key, _ []byte) ([]byte, error) {
+		if value, ok := valuesAfter[string(key)]; ok {
+			return value, nil
+		}
+		return tx.GetOne(kv.PlainState, key)
+	})
+}
+
+// RevertStorage collects the storage slots changed by block into verkleWriter, with their values before the block,
+// and commits the collected changes to the tree of root, the root of the block. It returns the root of the tree of the
+// parent of the block, provided that the accounts of the block were reverted with RevertAccount.
+func RevertStorage(tx kv.Tx, workers uint64, verkleWriter *VerkleTreeWriter, block uint64, root libcommon.Hash) (libcommon.Hash, error) {
+	return updateStorage(tx, workers, verkleWriter, block, block, root, func(_, before []byte) ([]byte, error) {
+		return before, nil
+	})
+}
+
+// updateStorage collects the storage slots changed by blocks [from, to] into verkleWriter, with the values returned
+// by value from the slot key and the value before the change of the first block which changed it, and commits the
+// collected changes to the tree of root.
+func updateStorage(tx kv.Tx, workers uint64, verkleWriter *VerkleTreeWriter, from, to uint64, root libcommon.Hash, value func(key, before []byte) ([]byte, error)) (libcommon.Hash, error) {
 	logInterval := time.NewTicker(30 * time.Second)
 	logPrefix := "IncrementVerkleStorage"
 
@@ -41,9 +68,11 @@ func IncrementStorage(vTx kv.RwTx, tx kv.Tx, workers uint64, verkleWriter *Verkl
 	}
 	defer storageCursor.Close()
 	// Start Goroutine for collection
+	collected := make(chan struct{})
 	go func() {
 		defer debug.LogPanic()
 		defer cancelWorkers()
+		defer close(collected)
 		for o := range out {
 			if err := verkleWriter.Insert(o.storageVerkleKey[:], o.storageValue); err != nil {
 				panic(err)
@@ -57,7 +86,7 @@ func IncrementStorage(vTx kv.RwTx, tx kv.Tx, workers uint64, verkleWriter *Verkl
 		if err != nil {
 			return libcommon.Hash{}, err
 		}
-		blockNumber, changesetKey, _, err := historyv2.DecodeStorage(k, v)
+		blockNumber, changesetKey, before, err := historyv2.DecodeStorage(k, v)
 		if err != nil {
 			return libcommon.Hash{}, err
 		}
@@ -96,15 +125,15 @@ func IncrementStorage(vTx kv.RwTx, tx kv.Tx, workers uint64, verkleWriter *Verkl
 			continue
 		}*/
 
-		storageValue, err := tx.GetOne(kv.PlainState, changesetKey)
+		storageValue, err := value(changesetKey, before)
 		if err != nil {
 			return libcommon.Hash{}, err
 		}
 		storageKey := new(uint256.Int).SetBytes(changesetKey[28:])
-		var storageValueFormatted []byte
-
+		// Cleared slots are written as zero leaves, like deletions in the tree, so that the root doesn't depend on
+		// how the blocks are batched
+		storageValueFormatted := make([]byte, 32)
 		if len(storageValue) > 0 {
-			storageValueFormatted = make([]byte, 32)
 			int256ToVerkleFormat(new(uint256.Int).SetBytes(storageValue), storageValueFormatted)
 		}
 
@@ -125,16 +154,11 @@ func IncrementStorage(vTx kv.RwTx, tx kv.Tx, workers uint64, verkleWriter *Verkl
 	close(jobs)
 	wg.Wait()
 	close(out)
-	// Get root
-	root, err := rawdb.ReadVerkleRoot(vTx, from)
-	if err != nil {
-		return libcommon.Hash{}, err
-	}
+	<-collected
 	newRoot, err := verkleWriter.CommitVerkleTree(root)
 	if err != nil {
 		return libcommon.Hash{}, err
 	}
-	log.Info("Computed verkle root", "root", common.Bytes2Hex(newRoot[:]))
-
-	return newRoot, rawdb.WriteVerkleRoot(vTx, to, newRoot)
+	log.Debug("Computed verkle root", "block", to, "root", common.Bytes2Hex(newRoot[:]))
+	return newRoot, nil
 }
@@ -0,0 +1,69 @@
+package verkletrie
+
+import (
+	"fmt"
+
+	"github.com/gballet/go-verkle"
+	"github.com/holiman/uint256"
+	libcommon "github.com/ledgerwatch/erigon-lib/common"
+	"github.com/ledgerwatch/erigon-lib/kv"
+
+	"github.com/ledgerwatch/erigon/common"
+	"github.com/ledgerwatch/erigon/core/rawdb"
+	"github.com/ledgerwatch/erigon/turbo/trie/vtree"
+)
+
+// AccountKeys returns the keys of the leaves of an account in the verkle tree: version, balance, nonce, code hash
+// and code size, followed by the keys of the given storage slots
+func AccountKeys(address libcommon.Address, storageKeys []libcommon.Hash) [][]byte {
+	keys := [][]byte{
+		vtree.GetTreeKeyVersion(address[:]),
+		vtree.GetTreeKeyBalance(address[:]),
+		vtree.GetTreeKeyNonce(address[:]),
+		vtree.GetTreeKeyCodeKeccak(address[:]),
+		vtree.GetTreeKeyCodeSize(address[:]),
+	}
+	for _, storageKey := range storageKeys {
+		keys = append(keys, vtree.GetTreeKeyStorageSlot(address[:], new(uint256.Int).SetBytes(storageKey[:])))
+	}
+	return keys
+}
+
+// Prove returns the serialized multiproof of keys in the verkle tree of root, with the values of the keys, nil for
+// the absent ones
+func Prove(tx kv.Tx, root libcommon.Hash, keys [][]byte) ([]byte, [][]byte, error) {
+	if root == (libcommon.Hash{}) {
+		return nil, nil, fmt.Errorf("empty verkle root")
+	}
+	rootNode, err := rawdb.ReadVerkleNode(tx, root)
+	if err != nil {
+		return nil, nil, err
+	}
+	resolverFunc := func(root []byte) ([]byte, error) {
+		return tx.GetOne(kv.VerkleTrie, root)
+	}
+	// Resolving the values loads the nodes on their paths, which the proof requires
+	values := make([][]byte, len(keys))
+	keyVals := make(map[string][]byte, len(keys))
+	for i, key := range keys {
+		if values[i], err = rootNode.Get(key, resolverFunc); err != nil {
+			return nil, nil, fmt.Errorf("resolving key %x: %w", key, err)
+		}
+		keyVals[string(key)] = values[i]
+	}
+
+	// The proof sorts the keys it is given
+	sortedKeys := make([][]byte, len(keys))
+	for i, key := range keys {
+		sortedKeys[i] = common.CopyBytes(key)
+	}
+	proof, _, _, _, err := verkle.MakeVerkleMultiProof(rootNode, sortedKeys, keyVals)
+	if err != nil {
+		return nil, nil, err
+	}
+	serialized, _, err := verkle.SerializeProof(proof)
+	if err != nil {
+		return nil, nil, err
+	}
+	return serialized, values, nil
+}
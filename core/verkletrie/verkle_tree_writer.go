@@ -8,6 +8,7 @@ import (
 	"github.com/anacrolix/sync"
 	"github.com/gballet/go-verkle"
 	"github.com/holiman/uint256"
+	"github.com/ledgerwatch/erigon-lib/chain"
 	libcommon "github.com/ledgerwatch/erigon-lib/common"
 	"github.com/ledgerwatch/erigon-lib/etl"
 	"github.com/ledgerwatch/erigon-lib/kv"
@@ -19,6 +20,12 @@ import (
 	"github.com/ledgerwatch/erigon/turbo/trie/vtree"
 )
 
+// IsVerkleChain tells whether the chain commits its state in a verkle tree instead of the Merkle Patricia trie. The
+// verkle testnets activate Prague at genesis, and so do the chains which are to be committed in a verkle tree.
+func IsVerkleChain(config *chain.Config) bool {
+	return config != nil && config.IsPrague(0)
+}
+
 func int256ToVerkleFormat(x *uint256.Int, buffer []byte) {
 	bbytes := x.ToBig().Bytes()
 	if len(bbytes) > 0 {
@@ -221,7 +228,7 @@ func (v *VerkleTreeWriter) CommitVerkleTreeFromScratch() (libcommon.Hash, error)
 	}
 
 	// Flush the rest all at once
-	if err := collectVerkleNode(v.collector, root, logInterval, nil, v.logger); err != nil {
+	if err := collectVerkleNode(verkleCollector, root, logInterval, nil, v.logger); err != nil {
 		return libcommon.Hash{}, err
 	}
 
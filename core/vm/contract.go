@@ -58,6 +58,17 @@ type Contract struct {
 
 	Gas   uint64
 	value *uint256.Int
+
+	// Container is the decoded code of an EOF contract, nil for legacy code
+	Container   *Container
+	section     uint64          // Code section being executed in an EOF contract
+	returnStack []returnContext // Call frames of the CALLF instructions of an EOF contract
+}
+
+// returnContext is the code section and position to return to with RETF
+type returnContext struct {
+	section uint64
+	pc      uint64
 }
 
 // NewContract returns a new contract environment for the execution of EVM.
@@ -148,15 +159,25 @@ func (c *Contract) AsDelegate() *Contract {
 	return c
 }
 
-// GetOp returns the n'th element in the contract's byte array
+// GetOp returns the n'th element in the contract's byte array, or in the
+// code section being executed for EOF contracts
 func (c *Contract) GetOp(n uint64) OpCode {
-	if n < uint64(len(c.Code)) {
-		return OpCode(c.Code[n])
+	if code := c.executedCode(); n < uint64(len(code)) {
+		return OpCode(code[n])
 	}
 
 	return STOP
 }
 
+// executedCode returns the code the program counter refers to: the code
+// section being executed for EOF contracts, the whole code otherwise
+func (c *Contract) executedCode() []byte {
+	if c.Container != nil {
+		return c.Container.Code[c.section]
+	}
+	return c.Code
+}
+
 // Caller returns the caller of the contract.
 //
 // Caller will recursively call caller when the contract is a delegate
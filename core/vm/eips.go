@@ -17,6 +17,7 @@
 package vm
 
 import (
+	"encoding/binary"
 	"fmt"
 	"sort"
 
@@ -291,3 +292,113 @@ func opMcopy(pc *uint64, interpreter *EVMInterpreter, scope *ScopeContext) ([]by
 	scope.Memory.Copy(dst.Uint64(), src.Uint64(), length.Uint64())
 	return nil, nil
 }
+
+// enable4200 applies EIP-4200 (static relative jumps) to the jump table of EOF code:
+// - Adds RJUMP, RJUMPI and RJUMPV, whose immediate offsets are relative to the next instruction
+func enable4200(jt *JumpTable) {
+	jt[RJUMP] = &operation{
+		execute:     opRjump,
+		constantGas: params.RjumpGasEIP4200,
+		numPop:      0,
+		numPush:     0,
+	}
+	jt[RJUMPI] = &operation{
+		execute:     opRjumpi,
+		constantGas: params.RjumpiGasEIP4200,
+		numPop:      1,
+		numPush:     0,
+	}
+	jt[RJUMPV] = &operation{
+		execute:     opRjumpv,
+		constantGas: params.RjumpiGasEIP4200,
+		numPop:      1,
+		numPush:     0,
+	}
+}
+
+// relativeJump moves pc by offset from the instruction following the jump, whose
+// immediates are size bytes long. The interpreter loop then increments pc to the target.
+func relativeJump(pc *uint64, size uint64, offset int16) {
+	*pc = uint64(int64(*pc+size) + int64(offset))
+}
+
+// opRjump implements the RJUMP opcode (https://eips.ethereum.org/EIPS/eip-4200)
+func opRjump(pc *uint64, interpreter *EVMInterpreter, scope *ScopeContext) ([]byte, error) {
+	code := scope.Contract.executedCode()
+	relativeJump(pc, 2, int16(binary.BigEndian.Uint16(code[*pc+1:])))
+	return nil, nil
+}
+
+// opRjumpi implements the RJUMPI opcode (https://eips.ethereum.org/EIPS/eip-4200)
+func opRjumpi(pc *uint64, interpreter *EVMInterpreter, scope *ScopeContext) ([]byte, error) {
+	cond := scope.Stack.Pop()
+	if cond.IsZero() {
+		*pc += 2
+		return nil, nil
+	}
+	return opRjump(pc, interpreter, scope)
+}
+
+// opRjumpv implements the RJUMPV opcode (https://eips.ethereum.org/EIPS/eip-4200). Out of
+// range cases fall through to the next instruction.
+func opRjumpv(pc *uint64, interpreter *EVMInterpreter, scope *ScopeContext) ([]byte, error) {
+	var (
+		code  = scope.Contract.executedCode()
+		index = scope.Stack.Pop()
+		count = uint64(code[*pc+1]) + 1
+		size  = 1 + 2*count
+	)
+	if !index.IsUint64() || index.Uint64() >= count {
+		*pc += size
+		return nil, nil
+	}
+	relativeJump(pc, size, int16(binary.BigEndian.Uint16(code[*pc+2+2*index.Uint64():])))
+	return nil, nil
+}
+
+// enable4750 applies EIP-4750 (functions) to the jump table of EOF code:
+// - Adds CALLF and RETF to call code sections and return from them
+func enable4750(jt *JumpTable) {
+	jt[CALLF] = &operation{
+		execute:     opCallf,
+		constantGas: params.CallfGasEIP4750,
+		numPop:      0,
+		numPush:     0,
+	}
+	jt[RETF] = &operation{
+		execute:     opRetf,
+		constantGas: params.RetfGasEIP4750,
+		numPop:      0,
+		numPush:     0,
+	}
+}
+
+// opCallf implements the CALLF opcode (https://eips.ethereum.org/EIPS/eip-4750)
+func opCallf(pc *uint64, interpreter *EVMInterpreter, scope *ScopeContext) ([]byte, error) {
+	var (
+		contract = scope.Contract
+		code     = contract.executedCode()
+		section  = uint64(binary.BigEndian.Uint16(code[*pc+1:]))
+		typ      = contract.Container.Types[section]
+	)
+	if sLen := scope.Stack.Len(); sLen+int(typ.MaxStackHeight)-int(typ.Input) > int(params.StackLimit) {
+		return nil, &ErrStackOverflow{stackLen: sLen, limit: int(params.StackLimit) - int(typ.MaxStackHeight) + int(typ.Input)}
+	}
+	if len(contract.returnStack) >= returnStackLimit {
+		return nil, ErrReturnStackExceeded
+	}
+	contract.returnStack = append(contract.returnStack, returnContext{section: contract.section, pc: *pc + 3})
+	contract.section = section
+	*pc = ^uint64(0) // The interpreter loop increments it to the first instruction of the section
+	return nil, nil
+}
+
+// opRetf implements the RETF opcode (https://eips.ethereum.org/EIPS/eip-4750)
+func opRetf(pc *uint64, interpreter *EVMInterpreter, scope *ScopeContext) ([]byte, error) {
+	contract := scope.Contract
+	last := contract.returnStack[len(contract.returnStack)-1]
+	contract.returnStack = contract.returnStack[:len(contract.returnStack)-1]
+	contract.section = last.section
+	*pc = last.pc - 1
+	return nil, nil
+}
@@ -0,0 +1,273 @@
+package vm
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+)
+
+// EVM Object Format (EOF) v1 containers, see https://eips.ethereum.org/EIPS/eip-3540
+//
+// The container is made of a header, followed by the body with the types, code and data sections:
+//
+//	magic (0xEF00) version (0x01)
+//	kind_types (0x01) types_size (uint16)
+//	kind_code (0x02) num_code_sections (uint16) code_size (uint16)+
+//	kind_data (0x03) data_size (uint16)
+//	terminator (0x00)
+//	(inputs (uint8) outputs (uint8) max_stack_height (uint16))+ code_section+ data_section
+
+const (
+	eofFormatByte = 0xEF
+	eof1Version   = 1
+
+	kindTypes = 1
+	kindCode  = 2
+	kindData  = 3
+
+	eofHeaderTerminator = 0
+
+	// maxCodeSections is the maximum number of code sections of a container, as functions are called by a uint16
+	// index and the return stack is limited to 1024 frames
+	maxCodeSections = 1024
+	// maxInputsOutputs is the maximum number of stack items a function can take or return
+	maxInputsOutputs = 127
+	// maxStackHeight is the maximum declared stack height of a function
+	maxStackHeight = 1023
+	// returnStackLimit is the maximum number of nested CALLF
+	returnStackLimit = 1024
+)
+
+var eofMagic = []byte{eofFormatByte, 0x00}
+
+var (
+	ErrInvalidMagic           = errors.New("invalid magic")
+	ErrInvalidVersion         = errors.New("invalid version")
+	ErrMissingTypeHeader      = errors.New("missing type header")
+	ErrInvalidTypeSize        = errors.New("invalid type section size")
+	ErrMissingCodeHeader      = errors.New("missing code header")
+	ErrInvalidCodeHeader      = errors.New("invalid code header")
+	ErrInvalidCodeSize        = errors.New("invalid code size")
+	ErrMissingDataHeader      = errors.New("missing data header")
+	ErrMissingTerminator      = errors.New("missing header terminator")
+	ErrTooManyInputs          = errors.New("invalid type content, too many inputs")
+	ErrTooManyOutputs         = errors.New("invalid type content, too many outputs")
+	ErrInvalidSection0Type    = errors.New("invalid section 0 type, inputs and outputs must be 0")
+	ErrTooLargeMaxStackHeight = errors.New("invalid type content, max stack height exceeds limit")
+	ErrInvalidContainerSize   = errors.New("invalid container size")
+	// ErrInvalidEOF is returned when the initcode of a creation or the code it deploys is not a valid EOF container
+	ErrInvalidEOF = errors.New("invalid EOF container")
+)
+
+// FunctionMetadata is the type of a code section: the stack items it takes and returns, and the maximum height of
+// the stack during its execution
+type FunctionMetadata struct {
+	Input          uint8
+	Output         uint8
+	MaxStackHeight uint16
+}
+
+// Container is an EOF container
+type Container struct {
+	Types []*FunctionMetadata
+	Code  [][]byte
+	Data  []byte
+}
+
+// hasEOFMagic checks if code starts with the EOF prefix
+func hasEOFMagic(code []byte) bool {
+	return len(code) >= len(eofMagic) && bytes.Equal(eofMagic, code[:len(eofMagic)])
+}
+
+// isEOFVersion1 checks if code starts with the EOF prefix followed by version 1
+func isEOFVersion1(code []byte) bool {
+	return hasEOFMagic(code) && len(code) > len(eofMagic) && code[len(eofMagic)] == eof1Version
+}
+
+// MarshalBinary encodes the container
+func (c *Container) MarshalBinary() []byte {
+	b := append([]byte{}, eofMagic...)
+	b = append(b, eof1Version)
+	b = append(b, kindTypes)
+	b = binary.BigEndian.AppendUint16(b, uint16(len(c.Types)*4))
+	b = append(b, kindCode)
+	b = binary.BigEndian.AppendUint16(b, uint16(len(c.Code)))
+	for _, code := range c.Code {
+		b = binary.BigEndian.AppendUint16(b, uint16(len(code)))
+	}
+	b = append(b, kindData)
+	b = binary.BigEndian.AppendUint16(b, uint16(len(c.Data)))
+	b = append(b, eofHeaderTerminator)
+	for _, typ := range c.Types {
+		b = append(b, typ.Input, typ.Output)
+		b = binary.BigEndian.AppendUint16(b, typ.MaxStackHeight)
+	}
+	for _, code := range c.Code {
+		b = append(b, code...)
+	}
+	return append(b, c.Data...)
+}
+
+// UnmarshalBinary decodes an EOF container, checking its structure but not its code
+func (c *Container) UnmarshalBinary(b []byte) error {
+	if !hasEOFMagic(b) {
+		return fmt.Errorf("%w: want %x", ErrInvalidMagic, eofMagic)
+	}
+	if len(b) < 15 {
+		return fmt.Errorf("%w: container of %d bytes is too small", ErrInvalidContainerSize, len(b))
+	}
+	if !isEOFVersion1(b) {
+		return fmt.Errorf("%w: have %d, want %d", ErrInvalidVersion, b[2], eof1Version)
+	}
+
+	// Parse the type section header
+	kind, typesSize, err := parseSection(b, 3)
+	if err != nil {
+		return err
+	}
+	if kind != kindTypes {
+		return fmt.Errorf("%w: found section kind %x instead", ErrMissingTypeHeader, kind)
+	}
+	if typesSize < 4 || typesSize%4 != 0 {
+		return fmt.Errorf("%w: type section size must be divisible by 4, have %d", ErrInvalidTypeSize, typesSize)
+	}
+	if typesSize/4 > maxCodeSections {
+		return fmt.Errorf("%w: type section must not exceed 4*%d, have %d", ErrInvalidTypeSize, maxCodeSections, typesSize)
+	}
+
+	// Parse the code section header
+	kind, codeSizes, err := parseSectionList(b, 6)
+	if err != nil {
+		return err
+	}
+	if kind != kindCode {
+		return fmt.Errorf("%w: found section kind %x instead", ErrMissingCodeHeader, kind)
+	}
+	if len(codeSizes) != typesSize/4 {
+		return fmt.Errorf("%w: mismatch of code sections count and type signatures, types %d, code %d", ErrInvalidCodeSize, typesSize/4, len(codeSizes))
+	}
+
+	// Parse the data section header
+	offset := 6 + 3 + 2*len(codeSizes)
+	kind, dataSize, err := parseSection(b, offset)
+	if err != nil {
+		return err
+	}
+	if kind != kindData {
+		return fmt.Errorf("%w: found section %x instead", ErrMissingDataHeader, kind)
+	}
+	offset += 3
+	if offset >= len(b) || b[offset] != eofHeaderTerminator {
+		return fmt.Errorf("%w: at offset %d", ErrMissingTerminator, offset)
+	}
+	offset++
+
+	// The header must describe the whole container
+	expectedSize := offset + typesSize + dataSize
+	for _, codeSize := range codeSizes {
+		expectedSize += codeSize
+	}
+	if len(b) != expectedSize {
+		return fmt.Errorf("%w: have %d, want %d", ErrInvalidContainerSize, len(b), expectedSize)
+	}
+
+	// Parse the types
+	types := make([]*FunctionMetadata, 0, typesSize/4)
+	for i := 0; i < typesSize/4; i++ {
+		sig := &FunctionMetadata{
+			Input:          b[offset+i*4],
+			Output:         b[offset+i*4+1],
+			MaxStackHeight: binary.BigEndian.Uint16(b[offset+i*4+2:]),
+		}
+		if sig.Input > maxInputsOutputs {
+			return fmt.Errorf("%w for section %d: have %d", ErrTooManyInputs, i, sig.Input)
+		}
+		if sig.Output > maxInputsOutputs {
+			return fmt.Errorf("%w for section %d: have %d", ErrTooManyOutputs, i, sig.Output)
+		}
+		if sig.MaxStackHeight > maxStackHeight {
+			return fmt.Errorf("%w for section %d: have %d", ErrTooLargeMaxStackHeight, i, sig.MaxStackHeight)
+		}
+		types = append(types, sig)
+	}
+	if types[0].Input != 0 || types[0].Output != 0 {
+		return fmt.Errorf("%w: have %d, %d", ErrInvalidSection0Type, types[0].Input, types[0].Output)
+	}
+	offset += typesSize
+
+	// Parse the code sections and the data
+	code := make([][]byte, len(codeSizes))
+	for i, size := range codeSizes {
+		code[i] = b[offset : offset+size]
+		offset += size
+	}
+	c.Types, c.Code, c.Data = types, code, b[offset:]
+	return nil
+}
+
+// ValidateCode validates each code section of the container against the EOF rules of jt: EIP-3670 instructions,
+// EIP-4200 relative jumps, EIP-4750 functions and EIP-5450 stack validation.
+func (c *Container) ValidateCode(jt *JumpTable) error {
+	for i, code := range c.Code {
+		if err := validateCode(code, i, c.Types, jt); err != nil {
+			return fmt.Errorf("code section %d: %w", i, err)
+		}
+	}
+	return nil
+}
+
+// parseAndValidateEOF decodes and validates an EOF container
+func parseAndValidateEOF(b []byte, jt *JumpTable) (*Container, error) {
+	var c Container
+	if err := c.UnmarshalBinary(b); err != nil {
+		return nil, err
+	}
+	if err := c.ValidateCode(jt); err != nil {
+		return nil, err
+	}
+	return &c, nil
+}
+
+// op returns the opcode at pc in the given code section
+func (c *Container) op(section, pc uint64) OpCode {
+	if code := c.Code[section]; pc < uint64(len(code)) {
+		return OpCode(code[pc])
+	}
+	return STOP
+}
+
+// parseSection decodes a (kind, size) pair from an EOF header
+func parseSection(b []byte, idx int) (kind, size int, err error) {
+	if idx+3 > len(b) {
+		return 0, 0, fmt.Errorf("%w: section header at offset %d", ErrInvalidContainerSize, idx)
+	}
+	return int(b[idx]), int(binary.BigEndian.Uint16(b[idx+1:])), nil
+}
+
+// parseSectionList decodes a (kind, len, []codeSize) section list from an EOF header
+func parseSectionList(b []byte, idx int) (kind int, sizes []int, err error) {
+	if idx+3 > len(b) {
+		return 0, nil, fmt.Errorf("%w: section header at offset %d", ErrInvalidContainerSize, idx)
+	}
+	kind = int(b[idx])
+	count := int(binary.BigEndian.Uint16(b[idx+1:]))
+	if kind != kindCode {
+		return kind, nil, nil
+	}
+	if count == 0 || count > maxCodeSections {
+		return 0, nil, fmt.Errorf("%w: have %d code sections", ErrInvalidCodeHeader, count)
+	}
+	if idx+3+2*count > len(b) {
+		return 0, nil, fmt.Errorf("%w: code sizes at offset %d", ErrInvalidContainerSize, idx+3)
+	}
+	sizes = make([]int, count)
+	for i := range sizes {
+		size := int(binary.BigEndian.Uint16(b[idx+3+2*i:]))
+		if size == 0 {
+			return 0, nil, fmt.Errorf("%w: size of code section %d must not be 0", ErrInvalidCodeSize, i)
+		}
+		sizes[i] = size
+	}
+	return kind, sizes, nil
+}
@@ -0,0 +1,221 @@
+package vm
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+
+	"github.com/ledgerwatch/erigon/common"
+)
+
+func TestEOFMarshaling(t *testing.T) {
+	for i, test := range []struct {
+		want Container
+	}{
+		{
+			want: Container{
+				Types: []*FunctionMetadata{{Input: 0, Output: 0, MaxStackHeight: 1}},
+				Code:  [][]byte{common.Hex2Bytes("604200")},
+				Data:  []byte{},
+			},
+		},
+		{
+			want: Container{
+				Types: []*FunctionMetadata{{Input: 0, Output: 0, MaxStackHeight: 1}},
+				Code:  [][]byte{common.Hex2Bytes("604200")},
+				Data:  []byte{0x01, 0x02, 0x03},
+			},
+		},
+		{
+			want: Container{
+				Types: []*FunctionMetadata{
+					{Input: 0, Output: 0, MaxStackHeight: 1},
+					{Input: 2, Output: 3, MaxStackHeight: 4},
+					{Input: 1, Output: 1, MaxStackHeight: 1},
+				},
+				Code: [][]byte{
+					common.Hex2Bytes("604200"),
+					common.Hex2Bytes("6042604200"),
+					common.Hex2Bytes("00"),
+				},
+				Data: []byte{},
+			},
+		},
+	} {
+		var (
+			b   = test.want.MarshalBinary()
+			got Container
+		)
+		if err := got.UnmarshalBinary(b); err != nil {
+			t.Fatalf("test %d: unexpected error: %v", i, err)
+		}
+		if len(got.Types) != len(test.want.Types) || len(got.Code) != len(test.want.Code) {
+			t.Fatalf("test %d: sections mismatch: have %d/%d, want %d/%d", i, len(got.Types), len(got.Code), len(test.want.Types), len(test.want.Code))
+		}
+		for j := range got.Types {
+			if *got.Types[j] != *test.want.Types[j] {
+				t.Errorf("test %d: type %d mismatch: have %v, want %v", i, j, *got.Types[j], *test.want.Types[j])
+			}
+			if !bytes.Equal(got.Code[j], test.want.Code[j]) {
+				t.Errorf("test %d: code %d mismatch: have %x, want %x", i, j, got.Code[j], test.want.Code[j])
+			}
+		}
+		if !bytes.Equal(got.Data, test.want.Data) {
+			t.Errorf("test %d: data mismatch: have %x, want %x", i, got.Data, test.want.Data)
+		}
+		if !bytes.Equal(got.MarshalBinary(), b) {
+			t.Errorf("test %d: round trip mismatch", i)
+		}
+	}
+}
+
+func TestEOFUnmarshalErrors(t *testing.T) {
+	for i, test := range []struct {
+		code string
+		err  error
+	}{
+		{"", ErrInvalidMagic},
+		{"ef010101000402000100030300000000000000604200", ErrInvalidMagic},
+		{"ef00", ErrInvalidContainerSize},
+		{"ef000201000402000100030300000000000000604200", ErrInvalidVersion},
+		{"ef000102000402000100030300000000000000604200", ErrMissingTypeHeader},
+		{"ef000101000302000100030300000000000000604200", ErrInvalidTypeSize},
+		{"ef000101000403000100030300000000000000604200", ErrMissingCodeHeader},
+		{"ef00010100040200000300000000000000604200", ErrInvalidCodeHeader},
+		{"ef000101000402000100000300000000000000604200", ErrInvalidCodeSize},
+		{"ef000101000802000100030300000000000000604200", ErrInvalidCodeSize},
+		{"ef000101000402000100030200000000000000604200", ErrMissingDataHeader},
+		{"ef000101000402000100030300000100000000604200", ErrMissingTerminator},
+		{"ef0001010004020001000303000000000000006042", ErrInvalidContainerSize},
+		{"ef00010100040200010003030000000000000060420000", ErrInvalidContainerSize},
+		{"ef000101000402000100030300000001000000604200", ErrInvalidSection0Type},
+		{"ef000101000402000100030300000000000400604200", ErrTooLargeMaxStackHeight},
+	} {
+		var c Container
+		err := c.UnmarshalBinary(common.Hex2Bytes(test.code))
+		if !errors.Is(err, test.err) {
+			t.Errorf("test %d: have %v, want %v", i, err, test.err)
+		}
+	}
+}
+
+func TestEOFValidation(t *testing.T) {
+	for i, test := range []struct {
+		code     []byte
+		section  int
+		metadata []*FunctionMetadata
+		err      error
+	}{
+		{
+			code:     []byte{byte(CALLER), byte(POP), byte(STOP)},
+			metadata: []*FunctionMetadata{{Input: 0, Output: 0, MaxStackHeight: 1}},
+		},
+		{
+			code:     []byte{byte(CALLF), 0x00, 0x00, byte(STOP)},
+			metadata: []*FunctionMetadata{{Input: 0, Output: 0, MaxStackHeight: 0}},
+		},
+		{
+			code: []byte{
+				byte(PUSH1), 0x01,
+				byte(RJUMPI), 0x00, 0x01,
+				byte(STOP),
+				byte(PUSH1), 0x00,
+				byte(RJUMPV), 0x01, 0x00, 0x00, 0xff, 0xf2,
+				byte(INVALID),
+			},
+			metadata: []*FunctionMetadata{{Input: 0, Output: 0, MaxStackHeight: 1}},
+		},
+		{
+			code:     []byte{byte(ADD), byte(RETF)},
+			section:  1,
+			metadata: []*FunctionMetadata{{Input: 0, Output: 0, MaxStackHeight: 0}, {Input: 2, Output: 1, MaxStackHeight: 2}},
+		},
+		{
+			code:     []byte{byte(CALLER), byte(POP)},
+			metadata: []*FunctionMetadata{{Input: 0, Output: 0, MaxStackHeight: 1}},
+			err:      ErrInvalidCodeTermination,
+		},
+		{
+			code:     []byte{byte(JUMPDEST), byte(PC), byte(STOP)},
+			metadata: []*FunctionMetadata{{Input: 0, Output: 0, MaxStackHeight: 1}},
+			err:      ErrUndefinedInstruction,
+		},
+		{
+			code:     []byte{byte(PUSH1), 0x01, byte(JUMP)},
+			metadata: []*FunctionMetadata{{Input: 0, Output: 0, MaxStackHeight: 1}},
+			err:      ErrUndefinedInstruction,
+		},
+		{
+			code:     []byte{byte(STOP), 0x0c},
+			metadata: []*FunctionMetadata{{Input: 0, Output: 0, MaxStackHeight: 0}},
+			err:      ErrUndefinedInstruction,
+		},
+		{
+			code:     []byte{byte(PUSH2), 0x01},
+			metadata: []*FunctionMetadata{{Input: 0, Output: 0, MaxStackHeight: 1}},
+			err:      ErrTruncatedImmediate,
+		},
+		{
+			code:     []byte{byte(RJUMPV), 0x01, 0x00, 0x00},
+			metadata: []*FunctionMetadata{{Input: 0, Output: 0, MaxStackHeight: 1}},
+			err:      ErrTruncatedImmediate,
+		},
+		{
+			code:     []byte{byte(RJUMP), 0x00, 0x01, byte(STOP)},
+			metadata: []*FunctionMetadata{{Input: 0, Output: 0, MaxStackHeight: 0}},
+			err:      ErrInvalidJumpDest,
+		},
+		{
+			code:     []byte{byte(PUSH1), 0x01, byte(RJUMPI), 0xff, 0xfc, byte(STOP)},
+			metadata: []*FunctionMetadata{{Input: 0, Output: 0, MaxStackHeight: 1}},
+			err:      ErrInvalidJumpDest,
+		},
+		{
+			code:     []byte{byte(CALLF), 0x00, 0x01, byte(STOP)},
+			metadata: []*FunctionMetadata{{Input: 0, Output: 0, MaxStackHeight: 0}},
+			err:      ErrInvalidSectionArgument,
+		},
+		{
+			code:     []byte{byte(RETF)},
+			metadata: []*FunctionMetadata{{Input: 0, Output: 0, MaxStackHeight: 0}},
+			err:      ErrInvalidRetf,
+		},
+		{
+			code:     []byte{byte(RJUMP), 0x00, 0x01, byte(CALLER), byte(STOP)},
+			metadata: []*FunctionMetadata{{Input: 0, Output: 0, MaxStackHeight: 0}},
+			err:      ErrUnreachableCode,
+		},
+		{
+			code:     []byte{byte(POP), byte(STOP)},
+			metadata: []*FunctionMetadata{{Input: 0, Output: 0, MaxStackHeight: 0}},
+			err:      ErrEOFStackUnderflow,
+		},
+		{
+			code:     []byte{byte(CALLER), byte(POP), byte(STOP)},
+			metadata: []*FunctionMetadata{{Input: 0, Output: 0, MaxStackHeight: 2}},
+			err:      ErrInvalidMaxStackHeight,
+		},
+		{
+			code:     []byte{byte(RETF)},
+			section:  1,
+			metadata: []*FunctionMetadata{{Input: 0, Output: 0, MaxStackHeight: 0}, {Input: 1, Output: 0, MaxStackHeight: 1}},
+			err:      ErrInvalidOutputs,
+		},
+		{
+			code: []byte{
+				byte(CALLER),
+				byte(PUSH1), 0x01,
+				byte(RJUMPI), 0x00, 0x01,
+				byte(CALLER),
+				byte(RJUMP), 0xff, 0xf6,
+			},
+			metadata: []*FunctionMetadata{{Input: 0, Output: 0, MaxStackHeight: 3}},
+			err:      ErrConflictingStack,
+		},
+	} {
+		err := validateCode(test.code, test.section, test.metadata, &pragueEOFInstructionSet)
+		if !errors.Is(err, test.err) {
+			t.Errorf("test %d: have %v, want %v", i, err, test.err)
+		}
+	}
+}
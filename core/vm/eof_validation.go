@@ -0,0 +1,211 @@
+package vm
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+
+	"github.com/ledgerwatch/erigon/params"
+)
+
+var (
+	ErrUndefinedInstruction   = errors.New("undefined instruction")
+	ErrTruncatedImmediate     = errors.New("truncated immediate")
+	ErrInvalidSectionArgument = errors.New("invalid section argument")
+	ErrInvalidJumpDest        = errors.New("invalid jump destination")
+	ErrConflictingStack       = errors.New("conflicting stack height")
+	ErrInvalidOutputs         = errors.New("invalid number of outputs")
+	ErrInvalidRetf            = errors.New("RETF in section 0")
+	ErrInvalidMaxStackHeight  = errors.New("invalid max stack height")
+	ErrInvalidCodeTermination = errors.New("invalid code termination")
+	ErrUnreachableCode        = errors.New("unreachable code")
+	ErrEOFStackUnderflow      = errors.New("stack underflow")
+	ErrEOFStackOverflow       = errors.New("stack overflow")
+)
+
+// validateCode validates the code of a section of an EOF container:
+//   - EIP-3670: the instructions are defined, their immediates are not truncated and the code terminates
+//   - EIP-4200: the relative jumps land on instructions of the section
+//   - EIP-4750: the functions called exist
+//   - EIP-5450: the stack can't underflow or overflow, its height matches the declared maximum, and all the
+//     instructions are reachable
+func validateCode(code []byte, section int, metadata []*FunctionMetadata, jt *JumpTable) error {
+	var (
+		i            = 0
+		count        = 0
+		op           OpCode
+		instructions = make([]bool, len(code)) // Whether an instruction starts at each position
+		jumps        [][2]int                  // Positions of the relative offsets of the jumps, with their origins
+	)
+	for i < len(code) {
+		count++
+		instructions[i] = true
+		op = OpCode(code[i])
+		if jt[op].undefined {
+			return fmt.Errorf("%w: op %s, pos %d", ErrUndefinedInstruction, op, i)
+		}
+		switch {
+		case op >= PUSH1 && op <= PUSH32:
+			size := int(op - PUSH0)
+			if len(code) <= i+size {
+				return fmt.Errorf("%w: op %s, pos %d", ErrTruncatedImmediate, op, i)
+			}
+			i += size
+		case op == RJUMP || op == RJUMPI:
+			if len(code) <= i+2 {
+				return fmt.Errorf("%w: op %s, pos %d", ErrTruncatedImmediate, op, i)
+			}
+			jumps = append(jumps, [2]int{i + 1, i + 3})
+			i += 2
+		case op == RJUMPV:
+			if len(code) <= i+1 {
+				return fmt.Errorf("%w: jump table size missing, op %s, pos %d", ErrTruncatedImmediate, op, i)
+			}
+			size := int(code[i+1]) + 1
+			if len(code) <= i+1+2*size {
+				return fmt.Errorf("%w: jump table truncated, op %s, pos %d", ErrTruncatedImmediate, op, i)
+			}
+			for j := 0; j < size; j++ {
+				jumps = append(jumps, [2]int{i + 2 + 2*j, i + 2 + 2*size})
+			}
+			i += 1 + 2*size
+		case op == CALLF:
+			if len(code) <= i+2 {
+				return fmt.Errorf("%w: op %s, pos %d", ErrTruncatedImmediate, op, i)
+			}
+			if arg := int(binary.BigEndian.Uint16(code[i+1:])); arg >= len(metadata) {
+				return fmt.Errorf("%w: arg %d, last %d, pos %d", ErrInvalidSectionArgument, arg, len(metadata)-1, i)
+			}
+			i += 2
+		case op == RETF && section == 0:
+			return fmt.Errorf("%w: pos %d", ErrInvalidRetf, i)
+		}
+		i++
+	}
+	// Code sections may not "fall through" and require proper termination
+	if !isTerminal(op) {
+		return fmt.Errorf("%w: end with %s, pos %d", ErrInvalidCodeTermination, op, i)
+	}
+	for _, jump := range jumps {
+		offset := relativeOffset(code, jump[0])
+		if dest := jump[1] + offset; dest < 0 || dest >= len(code) || !instructions[dest] {
+			return fmt.Errorf("%w: offset %d, pos %d", ErrInvalidJumpDest, offset, jump[0])
+		}
+	}
+	paths, err := validateControlFlow(code, section, metadata, jt)
+	if err != nil {
+		return err
+	}
+	if paths != count {
+		return fmt.Errorf("%w: %d of %d instructions are reachable", ErrUnreachableCode, paths, count)
+	}
+	return nil
+}
+
+// relativeOffset returns the relative offset of a jump at position imm
+func relativeOffset(code []byte, imm int) int {
+	return int(int16(binary.BigEndian.Uint16(code[imm:])))
+}
+
+// nextInstruction returns the position of the instruction following the one at pos
+func nextInstruction(code []byte, pos int) int {
+	switch op := OpCode(code[pos]); {
+	case op >= PUSH1 && op <= PUSH32:
+		return pos + 1 + int(op-PUSH0)
+	case op == RJUMP || op == RJUMPI || op == CALLF:
+		return pos + 3
+	case op == RJUMPV:
+		return pos + 2 + 2*(int(code[pos+1])+1)
+	default:
+		return pos + 1
+	}
+}
+
+// isTerminal tells whether op ends the execution of the code section
+func isTerminal(op OpCode) bool {
+	switch op {
+	case STOP, RETURN, REVERT, INVALID, SELFDESTRUCT, RETF, RJUMP:
+		return true
+	default:
+		return false
+	}
+}
+
+// validateControlFlow walks the code paths of a section with the stack height at each instruction (EIP-5450). It
+// returns the number of reachable instructions.
+func validateControlFlow(code []byte, section int, metadata []*FunctionMetadata, jt *JumpTable) (int, error) {
+	type item struct {
+		pos    int
+		height int
+	}
+	var (
+		heights        = make(map[int]int)
+		worklist       = []item{{0, int(metadata[section].Input)}}
+		maxStackHeight = int(metadata[section].Input)
+	)
+	for len(worklist) > 0 {
+		pos, height := worklist[len(worklist)-1].pos, worklist[len(worklist)-1].height
+		worklist = worklist[:len(worklist)-1]
+
+	outer:
+		for pos < len(code) {
+			op := OpCode(code[pos])
+			// The stack height of an instruction must be the same on every path reaching it
+			if want, ok := heights[pos]; ok {
+				if height != want {
+					return 0, fmt.Errorf("%w: have %d, want %d, pos %d", ErrConflictingStack, height, want, pos)
+				}
+				break
+			}
+			heights[pos] = height
+
+			if want := jt[op].numPop; height < want {
+				return 0, fmt.Errorf("%w: have %d, want %d, op %s, pos %d", ErrEOFStackUnderflow, height, want, op, pos)
+			}
+			height += jt[op].numPush - jt[op].numPop
+			if height > int(params.StackLimit) {
+				return 0, fmt.Errorf("%w: op %s, pos %d", ErrEOFStackOverflow, op, pos)
+			}
+			switch op {
+			case CALLF:
+				typ := metadata[binary.BigEndian.Uint16(code[pos+1:])]
+				if height < int(typ.Input) {
+					return 0, fmt.Errorf("%w: have %d, want %d, op %s, pos %d", ErrEOFStackUnderflow, height, typ.Input, op, pos)
+				}
+				if height+int(typ.MaxStackHeight)-int(typ.Input) > int(params.StackLimit) {
+					return 0, fmt.Errorf("%w: op %s, pos %d", ErrEOFStackOverflow, op, pos)
+				}
+				height += int(typ.Output) - int(typ.Input)
+				pos += 3
+			case RETF:
+				if want := int(metadata[section].Output); height != want {
+					return 0, fmt.Errorf("%w: have %d, want %d, pos %d", ErrInvalidOutputs, height, want, pos)
+				}
+				break outer
+			case RJUMP:
+				pos += 3 + relativeOffset(code, pos+1)
+			case RJUMPI:
+				worklist = append(worklist, item{pos + 3 + relativeOffset(code, pos+1), height})
+				pos += 3
+			case RJUMPV:
+				next := nextInstruction(code, pos)
+				for j := 0; j <= int(code[pos+1]); j++ {
+					worklist = append(worklist, item{next + relativeOffset(code, pos+2+2*j), height})
+				}
+				pos = next
+			default:
+				if isTerminal(op) {
+					break outer
+				}
+				pos = nextInstruction(code, pos)
+			}
+			if height > maxStackHeight {
+				maxStackHeight = height
+			}
+		}
+	}
+	if maxStackHeight != int(metadata[section].MaxStackHeight) {
+		return 0, fmt.Errorf("%w: have %d, want %d", ErrInvalidMaxStackHeight, metadata[section].MaxStackHeight, maxStackHeight)
+	}
+	return len(heights), nil
+}
@@ -392,7 +392,22 @@ func (evm *EVM) create(caller ContractRef, codeAndHash *codeAndHash, gas uint64,
 		return nil, address, gas, nil
 	}
 
-	ret, err = run(evm, contract, nil, false)
+	// EIP-3540: EOF initcode is validated before its execution, and must deploy EOF code
+	isEOF := evm.chainRules.IsPrague && hasEOFMagic(codeAndHash.code)
+	if isEOF {
+		contract.Container, err = parseAndValidateEOF(codeAndHash.code, &pragueEOFInstructionSet)
+		if err != nil {
+			err = ErrInvalidEOF
+		}
+	}
+	if err == nil {
+		ret, err = run(evm, contract, nil, false)
+	}
+	if err == nil && isEOF {
+		if _, eofErr := parseAndValidateEOF(ret, &pragueEOFInstructionSet); eofErr != nil {
+			err = ErrInvalidEOF
+		}
+	}
 
 	// EIP-170: Contract code size limit
 	if err == nil && evm.chainRules.IsSpuriousDragon && len(ret) > params.MaxCodeSize {
@@ -403,8 +418,8 @@ func (evm *EVM) create(caller ContractRef, codeAndHash *codeAndHash, gas uint64,
 		}
 	}
 
-	// Reject code starting with 0xEF if EIP-3541 is enabled.
-	if err == nil && evm.chainRules.IsLondon && len(ret) >= 1 && ret[0] == 0xEF {
+	// Reject code starting with 0xEF if EIP-3541 is enabled, unless it is EOF deployed by EOF initcode.
+	if err == nil && !isEOF && evm.chainRules.IsLondon && len(ret) >= 1 && ret[0] == 0xEF {
 		err = ErrInvalidCode
 	}
 	// if the contract creation ran successfully and no errors were returned
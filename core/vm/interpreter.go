@@ -17,6 +17,7 @@
 package vm
 
 import (
+	"fmt"
 	"hash"
 	"sync"
 
@@ -92,6 +93,7 @@ type keccakState interface {
 type EVMInterpreter struct {
 	*VM
 	jt    *JumpTable // EVM instruction table
+	eofJt *JumpTable // EVM instruction table of EOF code, nil before EOF is enabled
 	depth int
 }
 
@@ -160,12 +162,18 @@ func NewEVMInterpreter(evm VMInterpreter, cfg Config) *EVMInterpreter {
 		}
 	}
 
+	var eofJt *JumpTable
+	if evm.ChainRules().IsPrague {
+		eofJt = &pragueEOFInstructionSet
+	}
+
 	return &EVMInterpreter{
 		VM: &VM{
 			evm: evm,
 			cfg: cfg,
 		},
-		jt: jt,
+		jt:    jt,
+		eofJt: eofJt,
 	}
 }
 
@@ -183,6 +191,20 @@ func (in *EVMInterpreter) Run(contract *Contract, input []byte, readOnly bool) (
 		return nil, nil
 	}
 
+	// EOF code is validated when it is deployed, but the code of the genesis
+	// allocation is only validated when it is executed
+	jt := in.jt
+	if in.eofJt != nil && hasEOFMagic(contract.Code) {
+		if contract.Container == nil {
+			container, err := parseAndValidateEOF(contract.Code, in.eofJt)
+			if err != nil {
+				return nil, fmt.Errorf("%w: %v", ErrInvalidEOF, err)
+			}
+			contract.Container = container
+		}
+		jt = in.eofJt
+	}
+
 	// Increment the call depth which is restricted to 1024
 	in.depth++
 	defer in.decrementDepth()
@@ -255,7 +277,7 @@ func (in *EVMInterpreter) Run(contract *Contract, input []byte, readOnly bool) (
 		// Get the operation from the jump table and validate the stack to ensure there are
 		// enough stack items available to perform the operation.
 		op = contract.GetOp(_pc)
-		operation := in.jt[op]
+		operation := jt[op]
 		cost = operation.constantGas // For tracing
 		// Validate stack
 		if sLen := locStack.Len(); sLen < operation.numPop {
@@ -48,6 +48,8 @@ type operation struct {
 	opNum   int // only for push, swap, dup
 	// memorySize returns the memory size required for the operation
 	memorySize memorySizeFunc
+	// undefined tells that the operation is not an instruction of the fork
+	undefined bool
 }
 
 var (
@@ -63,6 +65,7 @@ var (
 	shanghaiInstructionSet         = newShanghaiInstructionSet()
 	cancunInstructionSet           = newCancunInstructionSet()
 	pragueInstructionSet           = newPragueInstructionSet()
+	pragueEOFInstructionSet        = newPragueEOFInstructionSet()
 )
 
 // JumpTable contains the EVM opcodes supported at a given fork.
@@ -95,6 +98,20 @@ func newPragueInstructionSet() JumpTable {
 	return instructionSet
 }
 
+// newPragueEOFInstructionSet returns the prague instructions for the code of
+// EOF contracts: the relative jumps and functions replace the dynamic jumps.
+func newPragueEOFInstructionSet() JumpTable {
+	instructionSet := newPragueInstructionSet()
+	enable4200(&instructionSet) // Static relative jumps https://eips.ethereum.org/EIPS/eip-4200
+	enable4750(&instructionSet) // Functions https://eips.ethereum.org/EIPS/eip-4750
+	// EIP-3670 and EIP-4750 deprecate the instructions observing or changing the program counter
+	for _, op := range []OpCode{JUMP, JUMPI, PC} {
+		instructionSet[op] = &operation{execute: opUndefined, undefined: true}
+	}
+	validateAndFillMaxStack(&instructionSet)
+	return instructionSet
+}
+
 // newCancunInstructionSet returns the frontier, homestead, byzantium,
 // constantinople, istanbul, petersburg, berlin, london, paris, shanghai,
 // and cancun instructions.
@@ -1202,7 +1219,7 @@ func newFrontierInstructionSet() JumpTable {
 	// Fill all unassigned slots with opUndefined.
 	for i, entry := range tbl {
 		if entry == nil {
-			tbl[i] = &operation{execute: opUndefined}
+			tbl[i] = &operation{execute: opUndefined, undefined: true}
 		}
 	}
 
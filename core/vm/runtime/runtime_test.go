@@ -17,7 +17,9 @@
 package runtime
 
 import (
+	"bytes"
 	"context"
+	"errors"
 	"fmt"
 	"math/big"
 	"os"
@@ -129,6 +131,88 @@ func TestCall(t *testing.T) {
 	}
 }
 
+func TestEOF(t *testing.T) {
+	// Section 0 calls section 1 and returns its result, section 1 returns 42 with a relative jump
+	deployed := (&vm.Container{
+		Types: []*vm.FunctionMetadata{
+			{Input: 0, Output: 0, MaxStackHeight: 2},
+			{Input: 0, Output: 1, MaxStackHeight: 1},
+		},
+		Code: [][]byte{
+			{
+				byte(vm.CALLF), 0x00, 0x01,
+				byte(vm.PUSH1), 0,
+				byte(vm.MSTORE),
+				byte(vm.PUSH1), 32,
+				byte(vm.PUSH1), 0,
+				byte(vm.RETURN),
+			},
+			{
+				byte(vm.PUSH1), 1,
+				byte(vm.RJUMPI), 0x00, 0x03,
+				byte(vm.PUSH1), 7,
+				byte(vm.RETF),
+				byte(vm.PUSH1), 42,
+				byte(vm.RETF),
+			},
+		},
+	}).MarshalBinary()
+	// The initcode deploys its data section
+	initCode := func(deployed []byte) []byte {
+		container := &vm.Container{
+			Types: []*vm.FunctionMetadata{{Input: 0, Output: 0, MaxStackHeight: 3}},
+			Data:  deployed,
+		}
+		code := []byte{
+			byte(vm.PUSH1), byte(len(deployed)),
+			byte(vm.PUSH1), 0, // Offset of the data section, set below
+			byte(vm.PUSH1), 0,
+			byte(vm.CODECOPY),
+			byte(vm.PUSH1), byte(len(deployed)),
+			byte(vm.PUSH1), 0,
+			byte(vm.RETURN),
+		}
+		container.Code = [][]byte{code}
+		code[3] = byte(len(container.MarshalBinary()) - len(deployed))
+		return container.MarshalBinary()
+	}
+
+	ret, _, err := Execute(deployed, nil, nil, 0)
+	if err != nil {
+		t.Fatal("didn't expect error", err)
+	}
+	if num := new(big.Int).SetBytes(ret); num.Cmp(big.NewInt(42)) != 0 {
+		t.Error("Expected 42, got", num)
+	}
+
+	_, tx := memdb.NewTestTx(t)
+	cfg := &Config{State: state.New(state.NewDbStateReader(tx))}
+	code, address, _, err := Create(initCode(deployed), cfg, 0)
+	if err != nil {
+		t.Fatal("didn't expect error", err)
+	}
+	if !bytes.Equal(code, deployed) {
+		t.Errorf("Expected deployed code %x, got %x", deployed, code)
+	}
+	ret, _, err = Call(address, nil, cfg)
+	if err != nil {
+		t.Fatal("didn't expect error", err)
+	}
+	if num := new(big.Int).SetBytes(ret); num.Cmp(big.NewInt(42)) != 0 {
+		t.Error("Expected 42, got", num)
+	}
+
+	// EOF initcode must be valid and deploy valid EOF code
+	invalid := append([]byte{}, deployed...)
+	invalid[len(invalid)-1] = byte(vm.ADD)
+	if _, _, _, err = Create(invalid, cfg, 0); !errors.Is(err, vm.ErrInvalidEOF) {
+		t.Errorf("Expected %v, got %v", vm.ErrInvalidEOF, err)
+	}
+	if _, _, _, err = Create(initCode(invalid), cfg, 0); !errors.Is(err, vm.ErrInvalidEOF) {
+		t.Errorf("Expected %v, got %v", vm.ErrInvalidEOF, err)
+	}
+}
+
 func BenchmarkCall(b *testing.B) {
 	var definition = `[{"constant":true,"inputs":[],"name":"seller","outputs":[{"name":"","type":"address"}],"type":"function"},{"constant":false,"inputs":[],"name":"abort","outputs":[],"type":"function"},{"constant":true,"inputs":[],"name":"value","outputs":[{"name":"","type":"uint256"}],"type":"function"},{"constant":false,"inputs":[],"name":"refund","outputs":[],"type":"function"},{"constant":true,"inputs":[],"name":"buyer","outputs":[{"name":"","type":"address"}],"type":"function"},{"constant":false,"inputs":[],"name":"confirmReceived","outputs":[],"type":"function"},{"constant":true,"inputs":[],"name":"state","outputs":[{"name":"","type":"uint8"}],"type":"function"},{"constant":false,"inputs":[],"name":"confirmPurchase","outputs":[],"type":"function"},{"inputs":[],"type":"constructor"},{"anonymous":false,"inputs":[],"name":"Aborted","type":"event"},{"anonymous":false,"inputs":[],"name":"PurchaseConfirmed","type":"event"},{"anonymous":false,"inputs":[],"name":"ItemReceived","type":"event"},{"anonymous":false,"inputs":[],"name":"Refunded","type":"event"}]`
 
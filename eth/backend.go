@@ -43,6 +43,7 @@ import (
 	"github.com/ledgerwatch/erigon/turbo/engineapi"
 	"github.com/ledgerwatch/erigon/turbo/engineapi/engine_helpers"
 	"github.com/ledgerwatch/erigon/turbo/jsonrpc"
+	"github.com/ledgerwatch/erigon/turbo/rpchelper"
 	"github.com/ledgerwatch/erigon/turbo/snapshotsync/freezeblocks"
 	"github.com/ledgerwatch/erigon/turbo/snapshotsync/snap"
 
@@ -85,6 +86,7 @@ import (
 	"github.com/ledgerwatch/erigon/common/debug"
 	"github.com/ledgerwatch/erigon/consensus"
 	"github.com/ledgerwatch/erigon/consensus/bor"
+	"github.com/ledgerwatch/erigon/consensus/bor/finality"
 	"github.com/ledgerwatch/erigon/consensus/clique"
 	"github.com/ledgerwatch/erigon/consensus/ethash"
 	"github.com/ledgerwatch/erigon/consensus/merge"
@@ -106,6 +108,7 @@ import (
 	"github.com/ledgerwatch/erigon/node"
 	"github.com/ledgerwatch/erigon/p2p"
 	"github.com/ledgerwatch/erigon/p2p/enode"
+	"github.com/ledgerwatch/erigon/p2p/txpolicy"
 	"github.com/ledgerwatch/erigon/params"
 	"github.com/ledgerwatch/erigon/rpc"
 	"github.com/ledgerwatch/erigon/turbo/services"
@@ -126,7 +129,8 @@ type Ethereum struct {
 	chainDB    kv.RwDB
 	privateAPI *grpc.Server
 
-	engine consensus.Engine
+	engine      consensus.Engine
+	borFinality *finality.Whitelist // Checkpoints and milestones of Heimdall, nil if not a Bor chain with Heimdall
 
 	gasPrice  *uint256.Int
 	etherbase libcommon.Address
@@ -152,6 +156,7 @@ type Ethereum struct {
 	sentryCancel   context.CancelFunc
 	sentriesClient *sentry.MultiClient
 	sentryServers  []*sentry.GrpcServer
+	privateTxs     *txpolicy.PrivateTxs // Registry of the private transactions shared by the sentries and the RPC daemon
 
 	stagedSync      *stagedsync.Sync
 	syncStages      []*stagedsync.Stage
@@ -257,6 +262,7 @@ func New(stack *node.Node, config *ethconfig.Config, logger log.Logger) (*Ethere
 		config:               config,
 		chainDB:              chainKv,
 		networkID:            config.NetworkID,
+		privateTxs:           txpolicy.NewPrivateTxs(txpolicy.PrivateTxTTL),
 		etherbase:            config.Miner.Etherbase,
 		waitForStageLoopStop: make(chan struct{}),
 		waitForMiningStop:    make(chan struct{}),
@@ -323,6 +329,8 @@ func New(stack *node.Node, config *ethconfig.Config, logger log.Logger) (*Ethere
 
 	var sentries []direct.SentryClient
 	if len(stack.Config().P2P.SentryAddr) > 0 {
+		// The external sentries can't see the private transactions of the RPC daemon
+		backend.privateTxs.SetExternalSentries()
 		for _, addr := range stack.Config().P2P.SentryAddr {
 			sentryClient, err := sentry.GrpcClient(backend.sentryCtx, addr)
 			if err != nil {
@@ -356,9 +364,17 @@ func New(stack *node.Node, config *ethconfig.Config, logger log.Logger) (*Ethere
 		}
 
 		var pi int // points to next port to be picked from refCfg.AllowedPorts
-		for _, protocol := range refCfg.ProtocolVersion {
+		for i, protocol := range refCfg.ProtocolVersion {
 			cfg := refCfg
 			cfg.NodeDatabase = filepath.Join(stack.Config().Dirs.Nodes, eth.ProtocolToString[protocol])
+			cfg.PrivateTxs = backend.privateTxs
+			if refCfg.SentryIdentities && i > 0 {
+				keyConfig := p2p.NodeKeyConfig{}
+				cfg.NodeKeyFile = filepath.Join(cfg.NodeDatabase, "nodekey")
+				if cfg.PrivateKey, cfg.PreviousKeys, err = keyConfig.LoadIdentity(cfg.NodeKeyFile); err != nil {
+					return nil, err
+				}
+			}
 
 			// pick port from allowed list
 			var picked bool
@@ -388,6 +404,9 @@ func New(stack *node.Node, config *ethconfig.Config, logger log.Logger) (*Ethere
 			cfg.ListenAddr = fmt.Sprintf("%s:%d", listenHost, listenPort)
 
 			server := sentry.NewGrpcServer(backend.sentryCtx, discovery, readNodeInfo, &cfg, protocol, logger)
+			if config.SnapServe && !config.HistoryV3 {
+				server.EnableSnap(backend.chainDB, blockReader)
+			}
 			backend.sentryServers = append(backend.sentryServers, server)
 			sentries = append(sentries, direct.NewSentryClientDirect(protocol, server))
 		}
@@ -490,6 +509,12 @@ func New(stack *node.Node, config *ethconfig.Config, logger log.Logger) (*Ethere
 	if err != nil {
 		return nil, err
 	}
+	if borEngine, ok := backend.engine.(*bor.Bor); ok && borEngine.HeimdallClient != nil {
+		if backend.borFinality, err = finality.NewWhitelist(borEngine.DB); err != nil {
+			return nil, err
+		}
+		backend.sentriesClient.Hd.SetFinalityChecker(backend.borFinality)
+	}
 
 	var miningRPC txpool_proto.MiningServer
 	stateDiffClient := direct.NewStateDiffClientDirect(kvRPC)
@@ -559,6 +584,13 @@ func New(stack *node.Node, config *ethconfig.Config, logger log.Logger) (*Ethere
 	ethBackendRPC := privateapi.NewEthBackendServer(ctx, backend, backend.chainDB, backend.notifications.Events, blockReader, logger, latestBlockBuiltStore)
 	// intiialize engine backend
 	backend.engineBackendRPC = engineapi.NewEngineServer(ctx, logger, chainConfig, assembleBlockPOS, backend.chainDB, blockReader, backend.sentriesClient.Hd, config.Miner.EnabledPOS)
+	if config.BuilderRelay.URL != "" {
+		relay, err := builder.NewRelay(config.BuilderRelay.URL, config.BuilderRelay.Timeout, config.BuilderRelay.MaxMissedSlots, config.BuilderRelay.CooldownSlots)
+		if err != nil {
+			return nil, err
+		}
+		backend.engineBackendRPC.SetRelay(relay)
+	}
 
 	miningRPC = privateapi.NewMiningServer(ctx, backend, ethashApi, logger)
 
@@ -638,7 +670,7 @@ func New(stack *node.Node, config *ethconfig.Config, logger log.Logger) (*Ethere
 			return nil, err
 		}
 
-		go caplin1.RunCaplinPhase1(ctx, client, beaconCfg, genesisCfg, engine, state, nil)
+		go caplin1.RunCaplinPhase1(ctx, client, beaconCfg, genesisCfg, engine, state, nil, chainKv, nil)
 	}
 
 	if currentBlock == nil {
@@ -770,6 +802,7 @@ func (s *Ethereum) Init(stack *node.Node, config *ethconfig.Config) error {
 	}
 	// start HTTP API
 	httpRpcCfg := stack.Config().Http
+	httpRpcCfg.PrivateTxs = s.privateTxs
 	ethRpcClient, txPoolRpcClient, miningRpcClient, stateCache, ff, err := cli.EmbeddedServices(ctx, chainKv, httpRpcCfg.StateCache, blockReader, ethBackendRPC,
 		s.txPoolGrpcServer, miningRPC, stateDiffClient, s.logger)
 	if err != nil {
@@ -780,7 +813,8 @@ func (s *Ethereum) Init(stack *node.Node, config *ethconfig.Config) error {
 	if casted, ok := s.engine.(*bor.Bor); ok {
 		borDb = casted.DB
 	}
-	apiList := jsonrpc.APIList(chainKv, borDb, ethRpcClient, txPoolRpcClient, miningRpcClient, ff, stateCache, blockReader, s.agg, httpRpcCfg, s.engine, s.logger)
+	_, httpRpcCfg.StateCacheStats = stateCache.(*rpchelper.StateCache)
+	apiList := jsonrpc.APIList(chainKv, borDb, ethRpcClient, txPoolRpcClient, miningRpcClient, ff, stateCache, blockReader, s.agg, httpRpcCfg, s.engine, s.p2pServers, s.logger)
 	go func() {
 		if err := cli.StartRpcServer(ctx, httpRpcCfg, apiList, s.logger); err != nil {
 			s.logger.Error(err.Error())
@@ -1099,6 +1133,17 @@ func (s *Ethereum) Peers(ctx context.Context) (*remote.PeersReply, error) {
 	return &reply, nil
 }
 
+// p2pServers returns the running p2p servers of the sentries of this process, their node keys can be rotated.
+func (s *Ethereum) p2pServers() []*p2p.Server {
+	servers := make([]*p2p.Server, 0, len(s.sentryServers))
+	for _, sentryServer := range s.sentryServers {
+		if srv := sentryServer.Server(); srv != nil {
+			servers = append(servers, srv)
+		}
+	}
+	return servers
+}
+
 // Protocols returns all the currently configured
 // network protocols to start.
 func (s *Ethereum) Protocols() []p2p.Protocol {
@@ -1117,6 +1162,9 @@ func (s *Ethereum) Start() error {
 
 	hook := stages2.NewHook(s.sentryCtx, s.notifications, s.stagedSync, s.blockReader, s.chainConfig, s.logger, s.sentriesClient.UpdateHead)
 	go stages2.StageLoop(s.sentryCtx, s.chainDB, s.stagedSync, s.sentriesClient.Hd, s.waitForStageLoopStop, s.config.Sync.LoopThrottle, s.logger, s.blockReader, hook)
+	if s.borFinality != nil {
+		go finality.Run(s.sentryCtx, s.borFinality, s.engine.(*bor.Bor).HeimdallClient, s.chainDB, s.blockReader, s.logger)
+	}
 
 	return nil
 }
@@ -102,6 +102,11 @@ var Defaults = Config{
 		Produce:    true,
 	},
 	DropUselessPeers: false,
+	BuilderRelay: BuilderRelay{
+		Timeout:        time.Second,
+		MaxMissedSlots: 3,
+		CooldownSlots:  32,
+	},
 }
 
 func init() {
@@ -248,6 +253,20 @@ type Config struct {
 	OverrideShanghaiTime *big.Int `toml:",omitempty"`
 
 	DropUselessPeers bool
+
+	// Serve the snap/1 protocol from the in-process sentries
+	SnapServe bool
+
+	// External block builder, whose bids compete with the locally built payloads
+	BuilderRelay BuilderRelay
+}
+
+// BuilderRelay configures the builder-API endpoint of an external block builder, which is not used if URL is empty
+type BuilderRelay struct {
+	URL            string
+	Timeout        time.Duration // Maximum time to wait for a bid when a payload is requested
+	MaxMissedSlots int           // Number of consecutive missed slots after which the builder is not used
+	CooldownSlots  int           // Number of slots built locally once the builder is not used, before trying it again
 }
 
 type Sync struct {
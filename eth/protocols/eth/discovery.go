@@ -22,6 +22,7 @@ import (
 	libcommon "github.com/ledgerwatch/erigon-lib/common"
 
 	"github.com/ledgerwatch/erigon/core/forkid"
+	"github.com/ledgerwatch/erigon/p2p/enode"
 	"github.com/ledgerwatch/erigon/p2p/enr"
 	"github.com/ledgerwatch/erigon/rlp"
 )
@@ -56,3 +57,20 @@ func LoadENRForkID(r *enr.Record) (*forkid.ID, error) {
 	}
 	return &entry.ForkID, nil
 }
+
+// NewNodeFilter returns a filter of dial candidates rejecting the nodes which advertise, in their `eth` ENR entry,
+// a fork ID incompatible with the local chain. The fork filter is read for each node, as the local head moves, and
+// nil accepts all the nodes. Nodes without the entry are accepted, their fork ID is checked in the handshake.
+func NewNodeFilter(forkFilter func() forkid.Filter) func(*enode.Node) bool {
+	return func(n *enode.Node) bool {
+		filter := forkFilter()
+		if filter == nil {
+			return true
+		}
+		forkID, err := LoadENRForkID(n.Record())
+		if err != nil {
+			return false
+		}
+		return forkID == nil || filter(*forkID) == nil
+	}
+}
@@ -0,0 +1,42 @@
+package eth
+
+import (
+	"testing"
+
+	libcommon "github.com/ledgerwatch/erigon-lib/common"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ledgerwatch/erigon/core/forkid"
+	"github.com/ledgerwatch/erigon/crypto"
+	"github.com/ledgerwatch/erigon/p2p/enode"
+	"github.com/ledgerwatch/erigon/p2p/enr"
+)
+
+func newNodeWithForkID(t *testing.T, forkID *forkid.ID) *enode.Node {
+	var r enr.Record
+	if forkID != nil {
+		r.Set(&enrEntry{ForkID: *forkID})
+	}
+	key, err := crypto.GenerateKey()
+	require.NoError(t, err)
+	require.NoError(t, enode.SignV4(&r, key))
+	n, err := enode.New(enode.ValidSchemes, &r)
+	require.NoError(t, err)
+	return n
+}
+
+func TestNodeFilter(t *testing.T) {
+	genesis := libcommon.HexToHash("0xd4e56740f876aef8c010b86a40d5f56745a118d0906a34e69aec8c0db1cb8fa3")
+	heightForks := []uint64{1150000, 1920000}
+	local := forkid.NewIDFromForks(heightForks, nil, genesis, 2000000, 0)
+	otherChain := forkid.NewIDFromForks(heightForks, nil, libcommon.Hash{1}, 2000000, 0)
+
+	var forkFilter forkid.Filter
+	filter := NewNodeFilter(func() forkid.Filter { return forkFilter })
+	require.True(t, filter(newNodeWithForkID(t, &otherChain)), "all the nodes are accepted before the first status")
+
+	forkFilter = forkid.NewFilterFromForks(heightForks, nil, genesis, 2000000, 0)
+	require.True(t, filter(newNodeWithForkID(t, &local)))
+	require.True(t, filter(newNodeWithForkID(t, nil)), "nodes without eth entry are checked in the handshake")
+	require.False(t, filter(newNodeWithForkID(t, &otherChain)))
+}
@@ -0,0 +1,438 @@
+// Copyright 2020 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package snap
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"errors"
+
+	libcommon "github.com/ledgerwatch/erigon-lib/common"
+	"github.com/ledgerwatch/erigon-lib/common/length"
+	"github.com/ledgerwatch/erigon-lib/kv"
+
+	"github.com/ledgerwatch/erigon/common"
+	"github.com/ledgerwatch/erigon/core/types/accounts"
+	"github.com/ledgerwatch/erigon/eth/stagedsync/stages"
+	"github.com/ledgerwatch/erigon/rlp"
+	"github.com/ledgerwatch/erigon/turbo/services"
+	"github.com/ledgerwatch/erigon/turbo/trie"
+)
+
+const (
+	// softResponseLimit is the target maximum size of replies to data retrievals.
+	softResponseLimit = 2 * 1024 * 1024
+
+	// maxCodeLookups is the maximum number of bytecodes to serve. This number is
+	// there to limit the number of disk lookups.
+	maxCodeLookups = 1024
+
+	// maxTrieNodeLookups is the maximum number of state trie nodes to serve. This
+	// number is there to limit the number of disk lookups.
+	maxTrieNodeLookups = 1024
+)
+
+// ServedStateRoot returns the state root the snap protocol is served for: the root of the block the hashed state and
+// the intermediate hashes are at. It returns the zero hash if the two stages are not in sync, e.g. during a sync cycle.
+func ServedStateRoot(tx kv.Tx, blockReader services.HeaderReader) (libcommon.Hash, error) {
+	hashStateProgress, err := stages.GetStageProgress(tx, stages.HashState)
+	if err != nil {
+		return libcommon.Hash{}, err
+	}
+	trieProgress, err := stages.GetStageProgress(tx, stages.IntermediateHashes)
+	if err != nil {
+		return libcommon.Hash{}, err
+	}
+	if hashStateProgress != trieProgress {
+		return libcommon.Hash{}, nil
+	}
+	header, err := blockReader.HeaderByNumber(context.Background(), tx, trieProgress)
+	if err != nil || header == nil {
+		return libcommon.Hash{}, err
+	}
+	return header.Root, nil
+}
+
+// storagePrefix returns the prefix of the storage keys of an account, like in the hashed storage table.
+func storagePrefix(addrHash libcommon.Hash, incarnation uint64) []byte {
+	prefix := make([]byte, length.Hash+length.Incarnation)
+	copy(prefix, addrHash[:])
+	binary.BigEndian.PutUint64(prefix[length.Hash:], incarnation)
+	return prefix
+}
+
+// hexKey returns the nibbles of a key in KEY encoding, without terminator, like the keys of the retain list.
+func hexKey(key []byte) []byte {
+	nibbles := make([]byte, 2*len(key))
+	for i, b := range key {
+		nibbles[i*2] = b / 16
+		nibbles[i*2+1] = b % 16
+	}
+	return nibbles
+}
+
+// readAccount returns the account with the given hash, or nil if there is no such account.
+func readAccount(tx kv.Tx, addrHash libcommon.Hash) (*accounts.Account, error) {
+	enc, err := tx.GetOne(kv.HashedAccounts, addrHash[:])
+	if err != nil || len(enc) == 0 {
+		return nil, err
+	}
+	var acc accounts.Account
+	if err := acc.DecodeForStorage(enc); err != nil {
+		return nil, err
+	}
+	return &acc, nil
+}
+
+// storageRoot returns the root of the storage trie of an account. It is read from the intermediate hashes when the
+// root of the trie is a branch node, and computed from the hashed storage otherwise, in which case the trie is small.
+func storageRoot(tx kv.Tx, addrHash libcommon.Hash, incarnation uint64) (libcommon.Hash, error) {
+	if incarnation == 0 {
+		return trie.EmptyRoot, nil
+	}
+	prefix := storagePrefix(addrHash, incarnation)
+	v, err := tx.GetOne(kv.TrieOfStorage, prefix)
+	if err != nil {
+		return libcommon.Hash{}, err
+	}
+	if len(v) > 0 {
+		if _, _, _, _, rootHash := trie.UnmarshalTrieNode(v); len(rootHash) > 0 {
+			return libcommon.BytesToHash(rootHash), nil
+		}
+	}
+	t := trie.New(trie.EmptyRoot)
+	if err := iterateStorage(tx, prefix, nil, func(slotHash, value []byte) (bool, error) {
+		t.Update(slotHash, common.CopyBytes(value))
+		return true, nil
+	}); err != nil {
+		return libcommon.Hash{}, err
+	}
+	return t.Hash(), nil
+}
+
+// iterateStorage walks the storage of an account in the hashed storage table from the slot hash origin, until
+// walker returns false.
+func iterateStorage(tx kv.Tx, prefix, origin []byte, walker func(slotHash, value []byte) (bool, error)) error {
+	c, err := tx.CursorDupSort(kv.HashedStorage)
+	if err != nil {
+		return err
+	}
+	defer c.Close()
+	v, err := c.SeekBothRange(prefix, origin)
+	for ; err == nil && v != nil; _, v, err = c.NextDup() {
+		if ok, err := walker(v[:length.Hash], v[length.Hash:]); err != nil || !ok {
+			return err
+		}
+	}
+	return err
+}
+
+// AnswerGetAccountRangeQuery returns the accounts, in the slim format, in the requested range with the proof of its
+// boundaries. The response is empty if the requested root is not the served one.
+func AnswerGetAccountRangeQuery(tx kv.Tx, prover *Prover, req *GetAccountRangePacket, blockReader services.HeaderReader) ([]*AccountData, [][]byte, error) {
+	root, err := ServedStateRoot(tx, blockReader)
+	if err != nil || root == (libcommon.Hash{}) || root != req.Root {
+		return nil, nil, err
+	}
+	if req.Bytes > softResponseLimit {
+		req.Bytes = softResponseLimit
+	}
+	c, err := tx.Cursor(kv.HashedAccounts)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer c.Close()
+
+	var (
+		accs []*AccountData
+		size uint64
+	)
+	k, v, err := c.Seek(req.Origin[:])
+	for ; err == nil && k != nil; k, v, err = c.Next() {
+		var acc accounts.Account
+		if err := acc.DecodeForStorage(v); err != nil {
+			return nil, nil, err
+		}
+		hash := libcommon.BytesToHash(k)
+		stRoot, err := storageRoot(tx, hash, acc.Incarnation)
+		if err != nil {
+			return nil, nil, err
+		}
+		body, err := EncodeSlimAccount(&acc, stRoot)
+		if err != nil {
+			return nil, nil, err
+		}
+		size += uint64(length.Hash + len(body))
+		accs = append(accs, &AccountData{Hash: hash, Body: body})
+
+		// If we've exceeded the request threshold, abort
+		if bytes.Compare(k, req.Limit[:]) >= 0 {
+			break
+		}
+		if size > req.Bytes {
+			break
+		}
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// Generate the Merkle proofs for the first and last account
+	hexKeys := [][]byte{hexKey(req.Origin[:])}
+	if len(accs) > 0 {
+		hexKeys = append(hexKeys, hexKey(accs[len(accs)-1].Hash[:]))
+	}
+	proofs, err := prover.proofNodes(tx, root, hexKeys, false)
+	if errors.Is(err, errProofRateLimited) {
+		return nil, nil, nil
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+	var proof [][]byte
+	for _, nodes := range proofs {
+		proof = append(proof, nodes...)
+	}
+	return accs, dedupNodes(proof), nil
+}
+
+// AnswerGetStorageRangesQuery returns the storage slots of the requested accounts, with the proof of the boundaries
+// of the last range if it is incomplete. The response is empty if the requested root is not the served one.
+func AnswerGetStorageRangesQuery(tx kv.Tx, prover *Prover, req *GetStorageRangesPacket, blockReader services.HeaderReader) ([][]*StorageData, [][]byte, error) {
+	root, err := ServedStateRoot(tx, blockReader)
+	if err != nil || root == (libcommon.Hash{}) || root != req.Root {
+		return nil, nil, err
+	}
+	if req.Bytes > softResponseLimit {
+		req.Bytes = softResponseLimit
+	}
+	// Calculate the hard limit at which to abort, even if mid storage trie
+	hardLimit := uint64(float64(req.Bytes) * 1.3)
+
+	var (
+		slots [][]*StorageData
+		proof [][]byte
+		size  uint64
+	)
+	for _, account := range req.Accounts {
+		// If we've exceeded the requested data limit, abort without opening
+		// a new storage range (that we'd need to prove due to exceeded size)
+		if size >= req.Bytes {
+			break
+		}
+		// The first account might start from a different origin and end sooner
+		var origin libcommon.Hash
+		if len(req.Origin) > 0 {
+			origin, req.Origin = libcommon.BytesToHash(req.Origin), nil
+		}
+		limit := libcommon.HexToHash("0xffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffff")
+		if len(req.Limit) > 0 {
+			limit, req.Limit = libcommon.BytesToHash(req.Limit), nil
+		}
+		acc, err := readAccount(tx, account)
+		if err != nil {
+			return nil, nil, err
+		}
+		var incarnation uint64
+		if acc != nil {
+			incarnation = acc.Incarnation
+		}
+
+		// Retrieve the requested state and bail out if non existent
+		var (
+			storage []*StorageData
+			abort   bool
+		)
+		prefix := storagePrefix(account, incarnation)
+		if incarnation > 0 {
+			if err := iterateStorage(tx, prefix, origin[:], func(slotHash, value []byte) (bool, error) {
+				if size >= hardLimit {
+					abort = true
+					return false, nil
+				}
+				body, err := rlp.EncodeToBytes(value)
+				if err != nil {
+					return false, err
+				}
+				size += uint64(length.Hash + len(body))
+				storage = append(storage, &StorageData{Hash: libcommon.BytesToHash(slotHash), Body: body})
+				return bytes.Compare(slotHash, limit[:]) < 0, nil
+			}); err != nil {
+				return nil, nil, err
+			}
+		}
+		if len(storage) > 0 {
+			slots = append(slots, storage)
+		}
+		// If the range is incomplete or it started from a different origin,
+		// prove its boundaries and stop serving further accounts
+		if origin != (libcommon.Hash{}) || (abort && len(storage) > 0) {
+			hexKeys := [][]byte{hexKey(append(common.CopyBytes(prefix), origin[:]...))}
+			if len(storage) > 0 {
+				hexKeys = append(hexKeys, hexKey(append(common.CopyBytes(prefix), storage[len(storage)-1].Hash[:]...)))
+			}
+			proofs, err := prover.proofNodes(tx, root, hexKeys, true)
+			if errors.Is(err, errProofRateLimited) {
+				return nil, nil, nil
+			}
+			if err != nil {
+				return nil, nil, err
+			}
+			for _, nodes := range proofs {
+				proof = append(proof, nodes...)
+			}
+			proof = dedupNodes(proof)
+			break
+		}
+	}
+	return slots, proof, nil
+}
+
+// AnswerGetByteCodesQuery returns the requested contract codes, skipping the unknown ones.
+func AnswerGetByteCodesQuery(tx kv.Tx, req *GetByteCodesPacket) ([][]byte, error) {
+	if req.Bytes > softResponseLimit {
+		req.Bytes = softResponseLimit
+	}
+	if len(req.Hashes) > maxCodeLookups {
+		req.Hashes = req.Hashes[:maxCodeLookups]
+	}
+	var (
+		codes [][]byte
+		size  uint64
+	)
+	for _, hash := range req.Hashes {
+		if hash == trie.EmptyCodeHash {
+			// Peers should not request the empty code, but if they do, at
+			// least sent them back a correct response without db lookups
+			codes = append(codes, []byte{})
+		} else {
+			code, err := tx.GetOne(kv.Code, hash[:])
+			if err != nil {
+				return nil, err
+			}
+			if len(code) > 0 {
+				codes = append(codes, common.CopyBytes(code))
+				size += uint64(len(code))
+			}
+		}
+		if size > req.Bytes {
+			break
+		}
+	}
+	return codes, nil
+}
+
+// AnswerGetTrieNodesQuery returns the requested nodes of the account trie and of the storage tries, an empty node is
+// returned for each unknown path. The response is empty if the requested root is not the served one.
+func AnswerGetTrieNodesQuery(tx kv.Tx, prover *Prover, req *GetTrieNodesPacket, blockReader services.HeaderReader) ([][]byte, error) {
+	root, err := ServedStateRoot(tx, blockReader)
+	if err != nil || root == (libcommon.Hash{}) || root != req.Root {
+		return nil, err
+	}
+	if req.Bytes > softResponseLimit {
+		req.Bytes = softResponseLimit
+	}
+
+	// Translate the requested paths into the paths of the retain list, storage
+	// paths are prefixed by the account hash and incarnation. Paths of unknown
+	// storage tries are left nil, they are answered with empty nodes.
+	var (
+		hexPaths [][]byte
+		known    []bool
+		lookups  int
+	)
+	for _, pathset := range req.Paths {
+		if len(pathset) == 0 {
+			return nil, errBadRequest
+		}
+		if lookups += len(pathset); lookups > maxTrieNodeLookups {
+			break
+		}
+		if len(pathset) == 1 {
+			hexPaths = append(hexPaths, trimTerminator(trie.CompactToHex(pathset[0])))
+			known = append(known, true)
+			continue
+		}
+		addrHash := libcommon.BytesToHash(pathset[0])
+		acc, err := readAccount(tx, addrHash)
+		if err != nil {
+			return nil, err
+		}
+		for _, path := range pathset[1:] {
+			if acc == nil || acc.Incarnation == 0 {
+				hexPaths = append(hexPaths, nil)
+				known = append(known, false)
+				continue
+			}
+			prefix := hexKey(storagePrefix(addrHash, acc.Incarnation))
+			hexPaths = append(hexPaths, append(prefix, trimTerminator(trie.CompactToHex(path))...))
+			known = append(known, true)
+		}
+	}
+
+	var keys [][]byte
+	for i, path := range hexPaths {
+		if known[i] {
+			keys = append(keys, path)
+		}
+	}
+	found, err := prover.trieNodes(tx, root, keys)
+	if errors.Is(err, errProofRateLimited) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var (
+		nodes [][]byte
+		size  uint64
+	)
+	for i := range hexPaths {
+		var node []byte
+		if known[i] {
+			node, found = found[0], found[1:]
+		}
+		nodes = append(nodes, node)
+		if size += uint64(len(node)); size > req.Bytes {
+			break
+		}
+	}
+	return nodes, nil
+}
+
+// trimTerminator removes the terminator of a path in HEX encoding.
+func trimTerminator(hex []byte) []byte {
+	if len(hex) > 0 && hex[len(hex)-1] == 16 {
+		return hex[:len(hex)-1]
+	}
+	return hex
+}
+
+// dedupNodes removes the duplicated nodes of a proof, e.g. the ones shared by the paths of the first and last keys.
+func dedupNodes(nodes [][]byte) [][]byte {
+	seen := make(map[string]struct{}, len(nodes))
+	deduped := nodes[:0]
+	for _, n := range nodes {
+		if _, ok := seen[string(n)]; ok {
+			continue
+		}
+		seen[string(n)] = struct{}{}
+		deduped = append(deduped, n)
+	}
+	return deduped
+}
@@ -0,0 +1,252 @@
+package snap
+
+import (
+	"bytes"
+	"sort"
+	"testing"
+
+	"github.com/holiman/uint256"
+	libcommon "github.com/ledgerwatch/erigon-lib/common"
+	"github.com/ledgerwatch/erigon-lib/kv"
+	"github.com/ledgerwatch/erigon-lib/kv/memdb"
+	"github.com/ledgerwatch/log/v3"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/time/rate"
+
+	"github.com/ledgerwatch/erigon/core/rawdb"
+	"github.com/ledgerwatch/erigon/core/types"
+	"github.com/ledgerwatch/erigon/core/types/accounts"
+	"github.com/ledgerwatch/erigon/crypto"
+	"github.com/ledgerwatch/erigon/eth/ethconfig"
+	"github.com/ledgerwatch/erigon/eth/stagedsync/stages"
+	"github.com/ledgerwatch/erigon/turbo/services"
+	"github.com/ledgerwatch/erigon/turbo/snapshotsync/freezeblocks"
+	"github.com/ledgerwatch/erigon/turbo/trie"
+)
+
+type testState struct {
+	root        libcommon.Hash
+	accountTrie *trie.Trie
+	hashes      []libcommon.Hash // sorted account hashes
+	contract    libcommon.Hash   // hash of the account with storage and code
+	storageTrie *trie.Trie
+	storageRoot libcommon.Hash
+	slots       []libcommon.Hash // sorted slot hashes of the contract
+	code        []byte
+}
+
+// writeTestState writes accounts, one of which with storage and code, in the hashed state of tx, and a block with the
+// root of this state at which the snap protocol is served.
+func writeTestState(t *testing.T, tx kv.RwTx, accountCount, slotCount int) *testState {
+	s := &testState{accountTrie: trie.New(trie.EmptyRoot), storageTrie: trie.New(trie.EmptyRoot), code: []byte{0x60, 0x00, 0x60, 0x00, 0xf3}}
+	s.contract = crypto.Keccak256Hash([]byte("contract"))
+
+	prefix := storagePrefix(s.contract, 1)
+	for i := 0; i < slotCount; i++ {
+		slot := crypto.Keccak256Hash([]byte{byte(i), byte(i >> 8), 0x5}) // hashed storage key
+		value := uint256.NewInt(uint64(i + 1)).Bytes()
+		require.NoError(t, tx.Put(kv.HashedStorage, prefix, append(slot.Bytes(), value...)))
+		s.storageTrie.Update(slot[:], value)
+		s.slots = append(s.slots, slot)
+	}
+	sort.Slice(s.slots, func(i, j int) bool { return bytes.Compare(s.slots[i][:], s.slots[j][:]) < 0 })
+	s.storageRoot = s.storageTrie.Hash()
+	codeHash := crypto.Keccak256Hash(s.code)
+	require.NoError(t, tx.Put(kv.Code, codeHash[:], s.code))
+
+	for i := 0; i < accountCount; i++ {
+		hash := crypto.Keccak256Hash([]byte{byte(i), byte(i >> 8)})
+		acc := accounts.NewAccount()
+		acc.Nonce = uint64(i)
+		acc.Balance.SetUint64(uint64(i) * 1000)
+		if i == accountCount/2 {
+			hash = s.contract
+			acc.Incarnation = 1
+			acc.CodeHash = codeHash
+		}
+		enc := make([]byte, acc.EncodingLengthForStorage())
+		acc.EncodeForStorage(enc)
+		require.NoError(t, tx.Put(kv.HashedAccounts, hash[:], enc))
+		if hash == s.contract {
+			acc.Root = s.storageRoot
+		}
+		s.accountTrie.UpdateAccount(hash[:], &acc)
+		s.hashes = append(s.hashes, hash)
+	}
+	sort.Slice(s.hashes, func(i, j int) bool { return bytes.Compare(s.hashes[i][:], s.hashes[j][:]) < 0 })
+	s.root = s.accountTrie.Hash()
+
+	header := &types.Header{Number: libcommon.Big1, Root: s.root}
+	require.NoError(t, rawdb.WriteHeader(tx, header))
+	require.NoError(t, rawdb.WriteCanonicalHash(tx, header.Hash(), 1))
+	require.NoError(t, stages.SaveStageProgress(tx, stages.HashState, 1))
+	require.NoError(t, stages.SaveStageProgress(tx, stages.IntermediateHashes, 1))
+	return s
+}
+
+func testBlockReader() services.HeaderReader {
+	return freezeblocks.NewBlockReader(freezeblocks.NewRoSnapshots(ethconfig.BlocksFreezing{Enabled: false}, "", log.New()))
+}
+
+// requireProofNodes checks that proof contains the nodes of the in-memory proof of key, in the go-ethereum format.
+func requireProofNodes(t *testing.T, tr *trie.Trie, key []byte, proof [][]byte) {
+	expected, err := tr.Prove(key, 0, false)
+	require.NoError(t, err)
+	for _, node := range expected {
+		require.Contains(t, proof, node)
+	}
+}
+
+func TestAnswerGetAccountRangeQuery(t *testing.T) {
+	_, tx := memdb.NewTestTx(t)
+	s := writeTestState(t, tx, 200, 10)
+	blockReader := testBlockReader()
+	prover := NewProver()
+
+	root, err := ServedStateRoot(tx, blockReader)
+	require.NoError(t, err)
+	require.Equal(t, s.root, root)
+
+	// Whole state
+	last := libcommon.HexToHash("0xffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffff")
+	accs, proof, err := AnswerGetAccountRangeQuery(tx, prover, &GetAccountRangePacket{Root: s.root, Limit: last, Bytes: softResponseLimit}, blockReader)
+	require.NoError(t, err)
+	require.Len(t, accs, len(s.hashes))
+	more, err := VerifyAccountRange(s.root, libcommon.Hash{}, &AccountRangePacket{Accounts: accs, Proof: proof})
+	require.NoError(t, err)
+	require.False(t, more)
+	requireProofNodes(t, s.accountTrie, s.hashes[len(s.hashes)-1][:], proof)
+
+	// Partial range, limited by size
+	origin := s.hashes[10]
+	accs, proof, err = AnswerGetAccountRangeQuery(tx, prover, &GetAccountRangePacket{Root: s.root, Origin: origin, Limit: last, Bytes: 500}, blockReader)
+	require.NoError(t, err)
+	require.Less(t, len(accs), len(s.hashes)-10)
+	require.Equal(t, origin, accs[0].Hash)
+	more, err = VerifyAccountRange(s.root, origin, &AccountRangePacket{Accounts: accs, Proof: proof})
+	require.NoError(t, err)
+	require.True(t, more)
+	requireProofNodes(t, s.accountTrie, origin[:], proof)
+	requireProofNodes(t, s.accountTrie, accs[len(accs)-1].Hash[:], proof)
+
+	// Partial range, limited by the limit hash
+	accs, proof, err = AnswerGetAccountRangeQuery(tx, prover, &GetAccountRangePacket{Root: s.root, Origin: origin, Limit: s.hashes[20], Bytes: softResponseLimit}, blockReader)
+	require.NoError(t, err)
+	require.Len(t, accs, 11)
+	more, err = VerifyAccountRange(s.root, origin, &AccountRangePacket{Accounts: accs, Proof: proof})
+	require.NoError(t, err)
+	require.True(t, more)
+
+	// The storage root and code hash of the contract are in its slim body
+	for _, acc := range accs {
+		if acc.Hash == s.contract {
+			decoded, err := DecodeSlimAccount(acc.Body)
+			require.NoError(t, err)
+			require.Equal(t, s.storageRoot, decoded.Root)
+		}
+	}
+
+	// Unknown root
+	accs, proof, err = AnswerGetAccountRangeQuery(tx, prover, &GetAccountRangePacket{Root: libcommon.Hash{1}, Limit: last, Bytes: softResponseLimit}, blockReader)
+	require.NoError(t, err)
+	require.Empty(t, accs)
+	require.Empty(t, proof)
+	_, err = VerifyAccountRange(s.root, libcommon.Hash{}, &AccountRangePacket{Accounts: accs, Proof: proof})
+	require.ErrorIs(t, err, ErrStateUnavailable)
+}
+
+func TestAnswerGetStorageRangesQuery(t *testing.T) {
+	_, tx := memdb.NewTestTx(t)
+	s := writeTestState(t, tx, 20, 100)
+	blockReader := testBlockReader()
+	prover := NewProver()
+	other := s.hashes[0]
+	if other == s.contract {
+		other = s.hashes[1]
+	}
+
+	// Whole storage of an account, and of an account without storage
+	slots, proof, err := AnswerGetStorageRangesQuery(tx, prover, &GetStorageRangesPacket{Root: s.root, Accounts: []libcommon.Hash{s.contract, other}, Bytes: softResponseLimit}, blockReader)
+	require.NoError(t, err)
+	require.Len(t, slots, 1)
+	require.Len(t, slots[0], len(s.slots))
+	require.Empty(t, proof)
+	more, err := VerifyStorageRanges([]libcommon.Hash{s.storageRoot, trie.EmptyRoot}, nil, &StorageRangesPacket{Slots: slots, Proof: proof})
+	require.NoError(t, err)
+	require.False(t, more)
+
+	// Range starting from an origin, limited by size
+	origin := s.slots[30]
+	slots, proof, err = AnswerGetStorageRangesQuery(tx, prover, &GetStorageRangesPacket{Root: s.root, Accounts: []libcommon.Hash{s.contract}, Origin: origin[:], Bytes: 500}, blockReader)
+	require.NoError(t, err)
+	require.Len(t, slots, 1)
+	require.Less(t, len(slots[0]), len(s.slots)-30)
+	require.Equal(t, origin, slots[0][0].Hash)
+	require.NotEmpty(t, proof)
+	more, err = VerifyStorageRanges([]libcommon.Hash{s.storageRoot}, origin[:], &StorageRangesPacket{Slots: slots, Proof: proof})
+	require.NoError(t, err)
+	require.True(t, more)
+	requireProofNodes(t, s.storageTrie, origin[:], proof)
+	requireProofNodes(t, s.storageTrie, slots[0][len(slots[0])-1].Hash[:], proof)
+}
+
+func TestAnswerGetByteCodesQuery(t *testing.T) {
+	_, tx := memdb.NewTestTx(t)
+	s := writeTestState(t, tx, 10, 1)
+
+	codes, err := AnswerGetByteCodesQuery(tx, &GetByteCodesPacket{Hashes: []libcommon.Hash{crypto.Keccak256Hash(s.code), {1}, trie.EmptyCodeHash}, Bytes: softResponseLimit})
+	require.NoError(t, err)
+	require.Equal(t, [][]byte{s.code, {}}, codes)
+}
+
+func TestAnswerGetTrieNodesQuery(t *testing.T) {
+	_, tx := memdb.NewTestTx(t)
+	s := writeTestState(t, tx, 50, 50)
+	blockReader := testBlockReader()
+	prover := NewProver()
+
+	nodes, err := AnswerGetTrieNodesQuery(tx, prover, &GetTrieNodesPacket{
+		Root: s.root,
+		Paths: []TrieNodePathSet{
+			{{}},                            // root of the account trie
+			{s.contract[:], {}},             // root of the storage trie
+			{libcommon.Hash{1}.Bytes(), {}}, // unknown account
+		},
+		Bytes: softResponseLimit,
+	}, blockReader)
+	require.NoError(t, err)
+	require.Len(t, nodes, 3)
+	require.Equal(t, s.root, crypto.Keccak256Hash(nodes[0]))
+	require.Equal(t, s.storageRoot, crypto.Keccak256Hash(nodes[1]))
+	require.Empty(t, nodes[2])
+
+	_, err = AnswerGetTrieNodesQuery(tx, prover, &GetTrieNodesPacket{Root: s.root, Paths: []TrieNodePathSet{{}}, Bytes: softResponseLimit}, blockReader)
+	require.ErrorIs(t, err, errBadRequest)
+}
+
+func TestProverCache(t *testing.T) {
+	_, tx := memdb.NewTestTx(t)
+	s := writeTestState(t, tx, 200, 10)
+	blockReader := testBlockReader()
+	prover := NewProver()
+
+	last := libcommon.HexToHash("0xffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffff")
+	req := GetAccountRangePacket{Root: s.root, Origin: s.hashes[10], Limit: last, Bytes: 500}
+	accs, proof, err := AnswerGetAccountRangeQuery(tx, prover, &req, blockReader)
+	require.NoError(t, err)
+	require.NotEmpty(t, accs)
+
+	// Without proofs to spare, the cached proofs are still served
+	prover.limiter = rate.NewLimiter(0, 0)
+	cachedAccs, cachedProof, err := AnswerGetAccountRangeQuery(tx, prover, &req, blockReader)
+	require.NoError(t, err)
+	require.Equal(t, accs, cachedAccs)
+	require.Equal(t, proof, cachedProof)
+
+	// And the requests needing new proofs get empty responses
+	req.Origin = s.hashes[50]
+	accs, proof, err = AnswerGetAccountRangeQuery(tx, prover, &req, blockReader)
+	require.NoError(t, err)
+	require.Empty(t, accs)
+	require.Empty(t, proof)
+}
@@ -0,0 +1,253 @@
+package snap
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+
+	libcommon "github.com/ledgerwatch/erigon-lib/common"
+
+	"github.com/ledgerwatch/erigon/crypto"
+	"github.com/ledgerwatch/erigon/p2p"
+	"github.com/ledgerwatch/erigon/turbo/trie"
+)
+
+// ErrStateUnavailable is returned when a peer answers with an empty response, because it does not serve the
+// requested state root.
+var ErrStateUnavailable = errors.New("state not available from peer")
+
+// Peer is a remote peer of the snap protocol. It sends the requests and matches the responses, handed over with
+// Deliver, by request ID. The responses are verified against the requested roots.
+type Peer struct {
+	id string
+	rw p2p.MsgReadWriter
+
+	lock    sync.Mutex
+	nextID  uint64
+	pending map[uint64]chan Packet
+}
+
+func NewPeer(id string, rw p2p.MsgReadWriter) *Peer {
+	return &Peer{id: id, rw: rw, pending: map[uint64]chan Packet{}}
+}
+
+func (p *Peer) ID() string {
+	return p.id
+}
+
+// Deliver decodes a response and hands it over to the request it answers. Responses to unknown or expired requests
+// are dropped.
+func (p *Peer) Deliver(msg p2p.Msg) error {
+	if msg.Size > maxMessageSize {
+		return fmt.Errorf("%w: %v > %v", errMsgTooLarge, msg.Size, maxMessageSize)
+	}
+	var res Packet
+	switch msg.Code {
+	case AccountRangeMsg:
+		res = new(AccountRangePacket)
+	case StorageRangesMsg:
+		res = new(StorageRangesPacket)
+	case ByteCodesMsg:
+		res = new(ByteCodesPacket)
+	case TrieNodesMsg:
+		res = new(TrieNodesPacket)
+	default:
+		return fmt.Errorf("%w: %v", errInvalidMsgCode, msg.Code)
+	}
+	if err := msg.Decode(res); err != nil {
+		return fmt.Errorf("%w: message %v: %v", errDecode, msg, err)
+	}
+	var id uint64
+	switch res := res.(type) {
+	case *AccountRangePacket:
+		id = res.ID
+	case *StorageRangesPacket:
+		id = res.ID
+	case *ByteCodesPacket:
+		id = res.ID
+	case *TrieNodesPacket:
+		id = res.ID
+	}
+	p.lock.Lock()
+	ch, ok := p.pending[id]
+	delete(p.pending, id)
+	p.lock.Unlock()
+	if ok {
+		ch <- res
+	}
+	return nil
+}
+
+// request sends the packet built for a new request ID and waits for its response.
+func (p *Peer) request(ctx context.Context, build func(id uint64) Packet, kind byte) (Packet, error) {
+	ch := make(chan Packet, 1)
+	p.lock.Lock()
+	p.nextID++
+	id := p.nextID
+	p.pending[id] = ch
+	p.lock.Unlock()
+	defer func() {
+		p.lock.Lock()
+		delete(p.pending, id)
+		p.lock.Unlock()
+	}()
+
+	req := build(id)
+	if err := p2p.Send(p.rw, uint64(req.Kind()), req); err != nil {
+		return nil, err
+	}
+	select {
+	case res := <-ch:
+		if res.Kind() != kind {
+			return nil, fmt.Errorf("unexpected response %s to request %s", res.Name(), req.Name())
+		}
+		return res, nil
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+// RequestAccountRange fetches a range of accounts of the state trie with the given root, starting at origin. The
+// accounts are verified with the range proof, it returns whether there are more accounts after the returned ones.
+func (p *Peer) RequestAccountRange(ctx context.Context, root, origin, limit libcommon.Hash, bytes uint64) (*AccountRangePacket, bool, error) {
+	res, err := p.request(ctx, func(id uint64) Packet {
+		return &GetAccountRangePacket{ID: id, Root: root, Origin: origin, Limit: limit, Bytes: bytes}
+	}, AccountRangeMsg)
+	if err != nil {
+		return nil, false, err
+	}
+	accounts := res.(*AccountRangePacket)
+	more, err := VerifyAccountRange(root, origin, accounts)
+	if err != nil {
+		return nil, false, err
+	}
+	return accounts, more, nil
+}
+
+// RequestStorageRanges fetches the storage slots of the given accounts, whose storage tries have the given roots. The
+// origin and limit only apply to the first account. The slots are verified against the roots, it returns whether
+// there are more slots after the last returned ones.
+func (p *Peer) RequestStorageRanges(ctx context.Context, root libcommon.Hash, accounts, roots []libcommon.Hash, origin, limit []byte, bytes uint64) (*StorageRangesPacket, bool, error) {
+	if len(accounts) != len(roots) {
+		return nil, false, fmt.Errorf("mismatching number of accounts and storage roots: %d != %d", len(accounts), len(roots))
+	}
+	res, err := p.request(ctx, func(id uint64) Packet {
+		return &GetStorageRangesPacket{ID: id, Root: root, Accounts: accounts, Origin: origin, Limit: limit, Bytes: bytes}
+	}, StorageRangesMsg)
+	if err != nil {
+		return nil, false, err
+	}
+	slots := res.(*StorageRangesPacket)
+	more, err := VerifyStorageRanges(roots, origin, slots)
+	if err != nil {
+		return nil, false, err
+	}
+	return slots, more, nil
+}
+
+// RequestByteCodes fetches the contract codes with the given hashes. The codes are returned in the order of the
+// hashes, with nil for the ones the peer did not return.
+func (p *Peer) RequestByteCodes(ctx context.Context, hashes []libcommon.Hash, bytes uint64) ([][]byte, error) {
+	res, err := p.request(ctx, func(id uint64) Packet {
+		return &GetByteCodesPacket{ID: id, Hashes: hashes, Bytes: bytes}
+	}, ByteCodesMsg)
+	if err != nil {
+		return nil, err
+	}
+	byHash := make(map[libcommon.Hash][]byte, len(res.(*ByteCodesPacket).Codes))
+	for _, code := range res.(*ByteCodesPacket).Codes {
+		byHash[crypto.Keccak256Hash(code)] = code
+	}
+	codes := make([][]byte, len(hashes))
+	for i, hash := range hashes {
+		codes[i] = byHash[hash]
+		delete(byHash, hash)
+	}
+	if len(byHash) > 0 {
+		return nil, fmt.Errorf("peer returned %d unrequested codes", len(byHash))
+	}
+	return codes, nil
+}
+
+// RequestTrieNodes fetches trie nodes of the state trie with the given root, see TrieNodePathSet. The nodes are not
+// verified, they are to be checked against the hashes referencing them.
+func (p *Peer) RequestTrieNodes(ctx context.Context, root libcommon.Hash, paths []TrieNodePathSet, bytes uint64) ([][]byte, error) {
+	res, err := p.request(ctx, func(id uint64) Packet {
+		return &GetTrieNodesPacket{ID: id, Root: root, Paths: paths, Bytes: bytes}
+	}, TrieNodesMsg)
+	if err != nil {
+		return nil, err
+	}
+	var count int
+	for _, pathset := range paths {
+		if len(pathset) > 1 {
+			count += len(pathset) - 1
+		} else {
+			count++
+		}
+	}
+	nodes := res.(*TrieNodesPacket).Nodes
+	if len(nodes) > count {
+		return nil, fmt.Errorf("peer returned %d nodes for %d paths", len(nodes), count)
+	}
+	return nodes, nil
+}
+
+// VerifyAccountRange checks the accounts of a response against the state root, using its range proof. It returns
+// whether the state trie has more accounts after the returned ones.
+func VerifyAccountRange(root, origin libcommon.Hash, res *AccountRangePacket) (bool, error) {
+	if len(res.Accounts) == 0 && len(res.Proof) == 0 {
+		return false, ErrStateUnavailable
+	}
+	keys := make([][]byte, len(res.Accounts))
+	values := make([][]byte, len(res.Accounts))
+	for i, acc := range res.Accounts {
+		value, err := FullAccountRLP(acc.Body)
+		if err != nil {
+			return false, fmt.Errorf("invalid account %x: %w", acc.Hash, err)
+		}
+		keys[i], values[i] = acc.Hash[:], value
+	}
+	return trie.VerifyRangeProof(root, origin[:], keys, values, res.Proof)
+}
+
+// VerifyStorageRanges checks the slots of a response against the storage roots of the requested accounts. Only the
+// last range may be incomplete, in which case it comes with a range proof starting at origin if it is the first one.
+// It returns whether the last storage trie has more slots after the returned ones.
+func VerifyStorageRanges(roots []libcommon.Hash, origin []byte, res *StorageRangesPacket) (bool, error) {
+	if len(res.Slots) > len(roots) {
+		return false, fmt.Errorf("peer returned %d storage ranges for %d accounts", len(res.Slots), len(roots))
+	}
+	if len(res.Slots) == 0 && len(res.Proof) == 0 {
+		return false, ErrStateUnavailable
+	}
+	ranges := res.Slots
+	if len(ranges) == 0 {
+		// The first range starts after the last slot of its trie, only its proof is returned
+		ranges = [][]*StorageData{nil}
+	}
+	var more bool
+	for i, slots := range ranges {
+		keys := make([][]byte, len(slots))
+		values := make([][]byte, len(slots))
+		for j, slot := range slots {
+			keys[j], values[j] = slot.Hash[:], slot.Body
+		}
+		var (
+			start = make([]byte, len(libcommon.Hash{}))
+			proof [][]byte
+		)
+		if i == len(ranges)-1 {
+			proof = res.Proof
+		}
+		if i == 0 && len(origin) > 0 {
+			start = libcommon.BytesToHash(origin).Bytes()
+		}
+		var err error
+		if more, err = trie.VerifyRangeProof(roots[i], start, keys, values, proof); err != nil {
+			return false, fmt.Errorf("invalid storage range of account %d: %w", i, err)
+		}
+	}
+	return more, nil
+}
@@ -0,0 +1,135 @@
+package snap
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+
+	lru "github.com/hashicorp/golang-lru/v2"
+	libcommon "github.com/ledgerwatch/erigon-lib/common"
+	"github.com/ledgerwatch/erigon-lib/kv"
+	"golang.org/x/time/rate"
+
+	"github.com/ledgerwatch/erigon/turbo/trie"
+)
+
+const (
+	// proofCacheSize is the number of proven keys and paths whose nodes are kept for the served root.
+	proofCacheSize = 16 * 1024
+
+	// proofsPerSecond and proofBurst limit the rate at which the proofs missing from the cache are built.
+	proofsPerSecond = 8
+	proofBurst      = 32
+)
+
+// errProofRateLimited is returned when a proof is not cached and the proof rate limit is exceeded, the request is
+// then answered with an empty response, which tells the peer to ask someone else.
+var errProofRateLimited = errors.New("snap proof rate limit exceeded")
+
+const (
+	accountProofKind byte = iota
+	storageProofKind
+	trieNodeKind
+)
+
+// Prover builds the Merkle proofs of the served state. Proofs are built by walking the intermediate hashes down to the
+// proven keys, so the proof nodes are cached for the served root and the proofs missing from the cache are built one
+// at a time, at a limited rate shared by all the peers.
+type Prover struct {
+	lock    sync.Mutex
+	root    libcommon.Hash
+	cache   *lru.Cache[string, [][]byte] // Proof nodes of the served root, by kind and key
+	limiter *rate.Limiter
+}
+
+func NewProver() *Prover {
+	cache, err := lru.New[string, [][]byte](proofCacheSize)
+	if err != nil {
+		panic(err)
+	}
+	return &Prover{cache: cache, limiter: rate.NewLimiter(proofsPerSecond, proofBurst)}
+}
+
+// proofNodes returns the nodes of the proofs of the given keys, in HEX encoding, in the account trie or in the storage
+// tries.
+func (p *Prover) proofNodes(tx kv.Tx, root libcommon.Hash, hexKeys [][]byte, storage bool) ([][][]byte, error) {
+	kind := accountProofKind
+	if storage {
+		kind = storageProofKind
+	}
+	return p.lookup(tx, root, kind, hexKeys, func(pr *trie.ProofRetainer, hexKey []byte) [][]byte {
+		return pr.ProofNodes(hexKey, storage)
+	})
+}
+
+// trieNodes returns the trie nodes at the given paths, in HEX encoding, nil for the paths without a node.
+func (p *Prover) trieNodes(tx kv.Tx, root libcommon.Hash, hexPaths [][]byte) ([][]byte, error) {
+	found, err := p.lookup(tx, root, trieNodeKind, hexPaths, func(pr *trie.ProofRetainer, hexPath []byte) [][]byte {
+		return [][]byte{pr.Node(hexPath)}
+	})
+	if err != nil {
+		return nil, err
+	}
+	nodes := make([][]byte, len(found))
+	for i, n := range found {
+		nodes[i] = n[0]
+	}
+	return nodes, nil
+}
+
+// lookup returns the cached nodes of the given keys, and builds a single proof for all the missing ones.
+func (p *Prover) lookup(tx kv.Tx, root libcommon.Hash, kind byte, hexKeys [][]byte, extract func(pr *trie.ProofRetainer, hexKey []byte) [][]byte) ([][][]byte, error) {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	if root != p.root {
+		p.cache.Purge()
+		p.root = root
+	}
+
+	results := make([][][]byte, len(hexKeys))
+	var (
+		missing    [][]byte
+		missingIdx []int
+	)
+	for i, key := range hexKeys {
+		if nodes, ok := p.cache.Get(string(append([]byte{kind}, key...))); ok {
+			results[i] = nodes
+		} else {
+			missing = append(missing, key)
+			missingIdx = append(missingIdx, i)
+		}
+	}
+	if len(missing) == 0 {
+		return results, nil
+	}
+	if !p.limiter.Allow() {
+		return nil, errProofRateLimited
+	}
+	pr, err := proveKeys(tx, root, missing)
+	if err != nil {
+		return nil, err
+	}
+	for j, key := range missing {
+		nodes := extract(pr, key)
+		results[missingIdx[j]] = nodes
+		p.cache.Add(string(append([]byte{kind}, key...)), nodes)
+	}
+	return results, nil
+}
+
+// proveKeys collects the trie nodes on the paths of the given keys, in HEX encoding, see trie.NewMultiProofRetainer.
+// It fails if the state root computed from the intermediate hashes is not root.
+func proveKeys(tx kv.Tx, root libcommon.Hash, hexKeys [][]byte) (*trie.ProofRetainer, error) {
+	rl := trie.NewRetainList(0)
+	pr := trie.NewMultiProofRetainer(hexKeys, rl)
+	loader := trie.NewFlatDBTrieLoader("snap", rl, nil, nil, false)
+	loader.SetProofRetainer(pr)
+	computed, err := loader.CalcTrieRoot(tx, nil)
+	if err != nil {
+		return nil, err
+	}
+	if computed != root {
+		return nil, fmt.Errorf("computed state root %x does not match the served root %x", computed, root)
+	}
+	return pr, nil
+}
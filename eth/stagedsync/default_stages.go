@@ -4,6 +4,7 @@ import (
 	"context"
 
 	"github.com/ledgerwatch/erigon-lib/kv"
+	"github.com/ledgerwatch/erigon/core/verkletrie"
 	"github.com/ledgerwatch/erigon/eth/ethconfig"
 	"github.com/ledgerwatch/erigon/eth/stagedsync/stages"
 	"github.com/ledgerwatch/log/v3"
@@ -114,7 +115,7 @@ func DefaultStages(ctx context.Context, snapshots SnapshotsCfg, headers HeadersC
 			Description: "Generate intermediate hashes and computing state root",
 			Disabled:    bodies.historyV3 && ethconfig.EnableHistoryV4InTest,
 			Forward: func(firstCycle bool, badBlockUnwind bool, s *StageState, u Unwinder, tx kv.RwTx, logger log.Logger) error {
-				if exec.chainConfig.IsPrague(0) {
+				if verkletrie.IsVerkleChain(exec.chainConfig) {
 					_, err := SpawnVerkleTrie(s, u, tx, trieCfg, ctx, logger)
 					return err
 				}
@@ -122,12 +123,15 @@ func DefaultStages(ctx context.Context, snapshots SnapshotsCfg, headers HeadersC
 				return err
 			},
 			Unwind: func(firstCycle bool, u *UnwindState, s *StageState, tx kv.RwTx, logger log.Logger) error {
-				if exec.chainConfig.IsPrague(0) {
+				if verkletrie.IsVerkleChain(exec.chainConfig) {
 					return UnwindVerkleTrie(u, s, tx, trieCfg, ctx, logger)
 				}
 				return UnwindIntermediateHashesStage(u, s, tx, trieCfg, ctx, logger)
 			},
 			Prune: func(firstCycle bool, p *PruneState, tx kv.RwTx, logger log.Logger) error {
+				if verkletrie.IsVerkleChain(exec.chainConfig) {
+					return PruneVerkleTries(p, tx, trieCfg, ctx)
+				}
 				return PruneIntermediateHashesStage(p, tx, trieCfg, ctx)
 			},
 		},
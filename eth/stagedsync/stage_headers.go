@@ -177,7 +177,8 @@ func HeadersPOS(
 	interrupt, requestId, requestWithStatus := cfg.hd.BeaconRequestList.WaitForRequest(syncing, test)
 
 	cfg.hd.SetHeaderReader(&ChainReaderImpl{config: &cfg.chainConfig, tx: tx, blockReader: cfg.blockReader})
-	headerInserter := headerdownload.NewHeaderInserter(s.LogPrefix(), nil, s.BlockNumber, cfg.blockReader)
+	headerInserter := headerdownload.NewHeaderInserter(s.LogPrefix(), nil, s.BlockNumber, cfg.blockReader, logger)
+	headerInserter.SetFinalityChecker(cfg.hd.FinalityChecker())
 
 	interrupted, err := handleInterrupt(interrupt, cfg, tx, headerInserter, useExternalTx, logger)
 	if err != nil {
@@ -382,6 +383,23 @@ func startHandlingForkChoice(
 	if err != nil {
 		return nil, err
 	}
+	valid, err := headerInserter.IsValidChain(tx, cfg.blockReader, header, headerHash, forkingPoint)
+	if err != nil {
+		return nil, err
+	}
+	if !valid {
+		logger.Warn(fmt.Sprintf("[%s] Fork choice: refused chain conflicting with finalized block", s.LogPrefix()), "hash", headerHash, "height", headerNumber, "forkingPoint", forkingPoint)
+		cfg.hd.ReportBadHeader(headerHash)
+		lastValidHash, err := cfg.blockReader.CanonicalHash(ctx, tx, forkingPoint)
+		if err != nil {
+			return nil, err
+		}
+		return &engine_types.PayloadStatus{
+			Status:          engine_types.InvalidStatus,
+			LatestValidHash: &lastValidHash,
+			ValidationError: engine_types.NewStringifiedError(headerdownload.ErrChainRefused),
+		}, nil
+	}
 	if forkingPoint < preProgress {
 
 		logger.Info(fmt.Sprintf("[%s] Fork choice: re-org", s.LogPrefix()), "goal", headerNumber, "from", preProgress, "unwind to", forkingPoint)
@@ -812,7 +830,8 @@ func HeadersPOW(
 	if localTd == nil {
 		return fmt.Errorf("localTD is nil: %d, %x", headerProgress, hash)
 	}
-	headerInserter := headerdownload.NewHeaderInserter(logPrefix, localTd, headerProgress, cfg.blockReader)
+	headerInserter := headerdownload.NewHeaderInserter(logPrefix, localTd, headerProgress, cfg.blockReader, logger)
+	headerInserter.SetFinalityChecker(cfg.hd.FinalityChecker())
 	cfg.hd.SetHeaderReader(&ChainReaderImpl{config: &cfg.chainConfig, tx: tx, blockReader: cfg.blockReader})
 
 	stopped := false
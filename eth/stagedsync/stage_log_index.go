@@ -20,6 +20,7 @@ import (
 	"golang.org/x/exp/slices"
 
 	"github.com/ledgerwatch/erigon/common/dbutils"
+	"github.com/ledgerwatch/erigon/core/rawdb"
 	"github.com/ledgerwatch/erigon/core/types"
 	"github.com/ledgerwatch/erigon/ethdb/cbor"
 	"github.com/ledgerwatch/erigon/ethdb/prune"
@@ -108,8 +109,20 @@ func promoteLogIndex(logPrefix string, tx kv.RwTx, start uint64, endBlock uint64
 	logEvery := time.NewTicker(30 * time.Second)
 	defer logEvery.Stop()
 
+	if err := rawdb.CreateLogCounts(tx); err != nil {
+		return err
+	}
+	// The logs are counted from the first blocks indexed with the counts, the blocks indexed before are not counted
+	if _, ok, err := rawdb.ReadLogCountsFrom(tx); err != nil {
+		return err
+	} else if !ok {
+		if err := rawdb.WriteLogCountsFrom(tx, start); err != nil {
+			return err
+		}
+	}
 	topics := map[string]*roaring.Bitmap{}
 	addresses := map[string]*roaring.Bitmap{}
+	counts := map[string]uint64{}
 	logs, err := tx.Cursor(kv.Log)
 	if err != nil {
 		return err
@@ -166,6 +179,13 @@ func promoteLogIndex(logPrefix string, tx kv.RwTx, start uint64, endBlock uint64
 				}
 				addresses = map[string]*roaring.Bitmap{}
 			}
+
+			if needFlushCounts(counts, cfg.bufLimit) {
+				if err := rawdb.AddLogCounts(tx, counts, false); err != nil {
+					return err
+				}
+				counts = map[string]uint64{}
+			}
 		}
 
 		var ll types.Logs
@@ -173,6 +193,7 @@ func promoteLogIndex(logPrefix string, tx kv.RwTx, start uint64, endBlock uint64
 		if err := cbor.Unmarshal(&ll, reader); err != nil {
 			return fmt.Errorf("receipt unmarshal failed: %w, blocl=%d", err, blockNum)
 		}
+		rawdb.CountLogs(counts, blockNum, ll)
 
 		for _, l := range ll {
 			for _, topic := range l.Topics {
@@ -201,6 +222,9 @@ func promoteLogIndex(logPrefix string, tx kv.RwTx, start uint64, endBlock uint64
 	if err := flushBitmaps(collectorAddrs, addresses); err != nil {
 		return err
 	}
+	if err := rawdb.AddLogCounts(tx, counts, false); err != nil {
+		return err
+	}
 
 	var currentBitmap = roaring.New()
 	var buf = bytes.NewBuffer(nil)
@@ -259,7 +283,7 @@ func UnwindLogIndex(u *UnwindState, s *StageState, tx kv.RwTx, cfg LogIndexCfg,
 	}
 
 	logPrefix := s.LogPrefix()
-	if err := unwindLogIndex(logPrefix, tx, u.UnwindPoint, cfg, quitCh); err != nil {
+	if err := unwindLogIndex(logPrefix, tx, u.UnwindPoint, s.BlockNumber, cfg, quitCh); err != nil {
 		return err
 	}
 
@@ -274,9 +298,18 @@ func UnwindLogIndex(u *UnwindState, s *StageState, tx kv.RwTx, cfg LogIndexCfg,
 	return nil
 }
 
-func unwindLogIndex(logPrefix string, db kv.RwTx, to uint64, cfg LogIndexCfg, quitCh <-chan struct{}) error {
+// unwindLogIndex removes the blocks after to from the index, which is at the block indexed
+func unwindLogIndex(logPrefix string, db kv.RwTx, to, indexed uint64, cfg LogIndexCfg, quitCh <-chan struct{}) error {
+	if err := rawdb.CreateLogCounts(db); err != nil {
+		return err
+	}
+	countsFrom, counted, err := rawdb.ReadLogCountsFrom(db)
+	if err != nil {
+		return err
+	}
 	topics := map[string]struct{}{}
 	addrs := map[string]struct{}{}
+	counts := map[string]uint64{}
 
 	reader := bytes.NewReader(nil)
 	c, err := db.Cursor(kv.Log)
@@ -297,6 +330,9 @@ func unwindLogIndex(logPrefix string, db kv.RwTx, to uint64, cfg LogIndexCfg, qu
 		if err := cbor.Unmarshal(&logs, reader); err != nil {
 			return fmt.Errorf("receipt unmarshal: %w, block=%d", err, binary.BigEndian.Uint64(k))
 		}
+		if blockNum := binary.BigEndian.Uint64(k); counted && blockNum >= countsFrom && blockNum <= indexed {
+			rawdb.CountLogs(counts, blockNum, logs)
+		}
 
 		for _, l := range logs {
 			for _, topic := range l.Topics {
@@ -312,6 +348,13 @@ func unwindLogIndex(logPrefix string, db kv.RwTx, to uint64, cfg LogIndexCfg, qu
 	if err := truncateBitmaps(db, kv.LogAddressIndex, addrs, to); err != nil {
 		return err
 	}
+	if err := rawdb.AddLogCounts(db, counts, true); err != nil {
+		return err
+	}
+	// The blocks after to are counted when they are indexed again
+	if counted && countsFrom > to+1 {
+		return rawdb.WriteLogCountsFrom(db, to+1)
+	}
 	return nil
 }
 
@@ -324,6 +367,11 @@ func needFlush(bitmaps map[string]*roaring.Bitmap, memLimit datasize.ByteSize) b
 	return uint64(len(bitmaps)*memoryNeedsForKey)+sz > uint64(memLimit)
 }
 
+func needFlushCounts(counts map[string]uint64, memLimit datasize.ByteSize) bool {
+	const memoryNeedsForCount = (1 + 32 + 4 + 8) * 2 * 2 // len(key+value) * (string and bytes) overhead * go's map overhead
+	return uint64(len(counts)*memoryNeedsForCount) > uint64(memLimit)
+}
+
 func flushBitmaps(c *etl.Collector, inMem map[string]*roaring.Bitmap) error {
 	for k, v := range inMem {
 		v.RunOptimize()
@@ -380,7 +428,7 @@ func pruneOldLogChunks(tx kv.RwTx, bucket string, inMem *etl.Collector, pruneTo
 				return fmt.Errorf("failed delete, block=%d: %w", blockNum, err)
 			}
 		}
-		return nil
+		return rawdb.PruneLogCounts(tx, key, pruneTo)
 	}, etl.TransformArgs{
 		Quit: ctx.Done(),
 	}); err != nil {
@@ -421,6 +469,9 @@ func PruneLogIndex(s *PruneState, tx kv.RwTx, cfg LogIndexCfg, ctx context.Conte
 }
 
 func pruneLogIndex(logPrefix string, tx kv.RwTx, tmpDir string, pruneTo uint64, ctx context.Context, logger log.Logger) error {
+	if err := rawdb.CreateLogCounts(tx); err != nil {
+		return err
+	}
 	logEvery := time.NewTicker(logInterval)
 	defer logEvery.Stop()
 
@@ -479,5 +530,5 @@ func pruneLogIndex(logPrefix string, tx kv.RwTx, tmpDir string, pruneTo uint64,
 	if err := pruneOldLogChunks(tx, kv.LogAddressIndex, addrs, pruneTo, ctx); err != nil {
 		return err
 	}
-	return nil
+	return rawdb.PruneAllLogCounts(tx, pruneTo)
 }
@@ -118,6 +118,21 @@ func TestPromoteLogIndex(t *testing.T) {
 		require.NoError(err)
 		require.Equal(expect, m.GetCardinality())
 	}
+
+	// Check the counts of logs, in which topics count once per log
+	count, err := rawdb.ReadAllLogCount(tx, 0, rawdb.LogCountsShardSize)
+	require.NoError(err)
+	require.Equal(uint64(201), count)
+	for addr, expect := range map[libcommon.Address]uint64{{1}: 102, {2}: 66, {3}: 33} {
+		count, err := rawdb.ReadAddressLogCount(tx, addr, 0, rawdb.LogCountsShardSize)
+		require.NoError(err)
+		require.Equal(expect, count)
+	}
+	for topic, expect := range map[libcommon.Hash]uint64{{1}: 34, {2}: 167, {3}: 66} {
+		count, err := rawdb.ReadTopicLogCount(tx, topic, 0, rawdb.LogCountsShardSize)
+		require.NoError(err)
+		require.Equal(expect, count)
+	}
 }
 
 func TestPruneLogIndex(t *testing.T) {
@@ -179,7 +194,7 @@ func TestUnwindLogIndex(t *testing.T) {
 	require.NoError(err)
 
 	// Unwind test
-	err = unwindLogIndex("logPrefix", tx, 70, cfg, nil)
+	err = unwindLogIndex("logPrefix", tx, 70, 99, cfg, nil)
 	require.NoError(err)
 
 	for addr := range expectAddrs {
@@ -192,4 +207,42 @@ func TestUnwindLogIndex(t *testing.T) {
 		require.NoError(err)
 		require.True(m.Maximum() <= 700)
 	}
+
+	count, err := rawdb.ReadAllLogCount(tx, 0, rawdb.LogCountsShardSize)
+	require.NoError(err)
+	require.Equal(uint64(144), count)
+	count, err = rawdb.ReadAddressLogCount(tx, libcommon.Address{1}, 0, rawdb.LogCountsShardSize)
+	require.NoError(err)
+	require.Equal(uint64(72), count)
+}
+
+func TestLogCountsFrom(t *testing.T) {
+	logger := log.New()
+	require, ctx := require.New(t), context.Background()
+	_, tx := memdb.NewTestTx(t)
+	genReceipts(t, tx, 100)
+	cfg := StageLogIndexCfg(nil, prune.DefaultMode, "")
+
+	// The logs of the blocks indexed before the counts are not counted
+	require.NoError(promoteLogIndex("logPrefix", tx, 50, 0, cfg, ctx, logger))
+	from, ok, err := rawdb.ReadLogCountsFrom(tx)
+	require.NoError(err)
+	require.True(ok)
+	require.Equal(uint64(50), from)
+	count, err := rawdb.ReadAllLogCount(tx, 0, rawdb.LogCountsShardSize)
+	require.NoError(err)
+	require.Equal(uint64(99), count)
+
+	// Unwinding the uncounted blocks leaves the counts consistent, and the blocks indexed again are counted
+	require.NoError(unwindLogIndex("logPrefix", tx, 30, 99, cfg, nil))
+	count, err = rawdb.ReadAllLogCount(tx, 0, rawdb.LogCountsShardSize)
+	require.NoError(err)
+	require.Zero(count)
+	require.NoError(promoteLogIndex("logPrefix", tx, 31, 0, cfg, ctx, logger))
+	from, _, err = rawdb.ReadLogCountsFrom(tx)
+	require.NoError(err)
+	require.Equal(uint64(31), from)
+	count, err = rawdb.ReadAllLogCount(tx, 0, rawdb.LogCountsShardSize)
+	require.NoError(err)
+	require.Equal(uint64(138), count)
 }
@@ -8,12 +8,23 @@ import (
 	"github.com/ledgerwatch/erigon-lib/kv"
 	"github.com/ledgerwatch/log/v3"
 
-	"github.com/ledgerwatch/erigon/cmd/verkle/verkletrie"
 	"github.com/ledgerwatch/erigon/core/rawdb"
+	"github.com/ledgerwatch/erigon/core/types"
+	"github.com/ledgerwatch/erigon/core/verkletrie"
 	"github.com/ledgerwatch/erigon/eth/stagedsync/stages"
 )
 
+// verkleWorkers is the number of goroutines computing the tree keys of the changed accounts and storage slots
+const verkleWorkers = 10
+
+// SpawnVerkleTrie applies the state changes of the executed blocks to the verkle tree, and checks the root of every
+// block against its header. The tree of the last block is built from the values after the batch, and the trees of the
+// other blocks are then found by reverting the blocks one by one from the last, with the values before them of their
+// change sets. The root of every block is saved.
 func SpawnVerkleTrie(s *StageState, u Unwinder, tx kv.RwTx, cfg TrieCfg, ctx context.Context, logger log.Logger) (libcommon.Hash, error) {
+	if cfg.historyV3 {
+		return libcommon.Hash{}, fmt.Errorf("verkle trie is built from the change sets, which are not kept with history v3")
+	}
 	var err error
 	useExternalTx := tx != nil
 	if !useExternalTx {
@@ -23,26 +34,62 @@ func SpawnVerkleTrie(s *StageState, u Unwinder, tx kv.RwTx, cfg TrieCfg, ctx con
 		}
 		defer tx.Rollback()
 	}
-	from := uint64(0)
-	if s.BlockNumber > 0 {
-		from = s.BlockNumber + 1
-	}
 	to, err := s.ExecutionAt(tx)
 	if err != nil {
 		return libcommon.Hash{}, err
 	}
-	verkleWriter := verkletrie.NewVerkleTreeWriter(tx, cfg.tmpDir, logger)
-	if err := verkletrie.IncrementAccount(tx, tx, 10, verkleWriter, from, to); err != nil {
+	if s.BlockNumber >= to {
+		return libcommon.Hash{}, nil
+	}
+	root, err := rawdb.ReadVerkleRoot(tx, s.BlockNumber)
+	if err != nil {
 		return libcommon.Hash{}, err
 	}
-	var newRoot libcommon.Hash
-	if newRoot, err = verkletrie.IncrementStorage(tx, tx, 10, verkleWriter, from, to); err != nil {
+	if root == (libcommon.Hash{}) {
+		return libcommon.Hash{}, fmt.Errorf("no verkle root of block %d", s.BlockNumber)
+	}
+	newRoot, err := incrementVerkleTrie(tx, cfg, s.BlockNumber+1, to, root, logger)
+	if err != nil {
 		return libcommon.Hash{}, err
 	}
-	if cfg.checkRoot {
-		header := rawdb.ReadHeaderByNumber(tx, to)
-		if header.Root != newRoot {
-			return libcommon.Hash{}, fmt.Errorf("invalid verkle root, header has %x, computed: %x", header.Root, newRoot)
+
+	// roots[i] is the root of block s.BlockNumber+1+i
+	roots := make([]libcommon.Hash, to-s.BlockNumber)
+	roots[len(roots)-1] = newRoot
+	for blockNum := to; blockNum > s.BlockNumber+1; blockNum-- {
+		i := blockNum - s.BlockNumber - 1
+		if roots[i-1], err = revertVerkleTrie(tx, cfg, blockNum, roots[i], logger); err != nil {
+			return libcommon.Hash{}, err
+		}
+	}
+
+	for i, root := range roots {
+		blockNum := s.BlockNumber + 1 + uint64(i)
+		if cfg.checkRoot {
+			header, err := verkleCheckHeader(ctx, tx, cfg, blockNum)
+			if err != nil {
+				return libcommon.Hash{}, err
+			}
+			if header.Root != root {
+				headerHash := header.Hash()
+				logger.Error(fmt.Sprintf("[VerkleTrie] Wrong verkle root of block %d: %x, expected (from header): %x. Block hash: %x", blockNum, root, header.Root, headerHash))
+				if cfg.badBlockHalt {
+					return libcommon.Hash{}, fmt.Errorf("wrong verkle root")
+				}
+				if cfg.hd != nil {
+					cfg.hd.ReportBadHeaderPoS(headerHash, header.ParentHash)
+				}
+				// The roots of the blocks before are correct, so the block is the first bad one
+				logger.Warn("Unwinding due to incorrect verkle root", "to", blockNum-1)
+				u.UnwindTo(blockNum-1, headerHash)
+				if !useExternalTx {
+					return root, tx.Commit()
+				}
+				return root, nil
+			}
+		}
+		if err := rawdb.WriteVerkleRoot(tx, blockNum, root); err != nil {
+			return libcommon.Hash{}, err
 		}
 	}
 	if err := s.Update(tx, to); err != nil {
@@ -57,6 +104,7 @@ func SpawnVerkleTrie(s *StageState, u Unwinder, tx kv.RwTx, cfg TrieCfg, ctx con
 	return newRoot, nil
 }
 
+// UnwindVerkleTrie brings the verkle tree back to the unwind point, whose root was saved with the tree of its block.
 func UnwindVerkleTrie(u *UnwindState, s *StageState, tx kv.RwTx, cfg TrieCfg, ctx context.Context, logger log.Logger) (err error) {
 	useExternalTx := tx != nil
 	if !useExternalTx {
@@ -66,22 +114,20 @@ func UnwindVerkleTrie(u *UnwindState, s *StageState, tx kv.RwTx, cfg TrieCfg, ct
 		}
 		defer tx.Rollback()
 	}
-	from := u.UnwindPoint + 1
-	to, err := s.ExecutionAt(tx)
+	root, err := rawdb.ReadVerkleRoot(tx, u.UnwindPoint)
 	if err != nil {
 		return err
 	}
-	verkleWriter := verkletrie.NewVerkleTreeWriter(tx, cfg.tmpDir, logger)
-	if err := verkletrie.IncrementAccount(tx, tx, 10, verkleWriter, from, to); err != nil {
-		return err
+	if root == (libcommon.Hash{}) {
+		return fmt.Errorf("no verkle root of block %d", u.UnwindPoint)
 	}
-	if _, err = verkletrie.IncrementStorage(tx, tx, 10, verkleWriter, from, to); err != nil {
+	if err := rawdb.TruncateVerkleRoots(tx, u.UnwindPoint+1); err != nil {
 		return err
 	}
-	if err := s.Update(tx, from); err != nil {
+	if err := u.Done(tx); err != nil {
 		return err
 	}
-	if err := stages.SaveStageProgress(tx, stages.VerkleTrie, from); err != nil {
+	if err := stages.SaveStageProgress(tx, stages.VerkleTrie, u.UnwindPoint); err != nil {
 		return err
 	}
 	if !useExternalTx {
@@ -90,6 +136,37 @@ func UnwindVerkleTrie(u *UnwindState, s *StageState, tx kv.RwTx, cfg TrieCfg, ct
 	return nil
 }
 
+// incrementVerkleTrie applies the changes of blocks [from, to] to the tree of root and returns its new root
+func incrementVerkleTrie(tx kv.RwTx, cfg TrieCfg, from, to uint64, root libcommon.Hash, logger log.Logger) (libcommon.Hash, error) {
+	verkleWriter := verkletrie.NewVerkleTreeWriter(tx, cfg.tmpDir, logger)
+	defer verkleWriter.Close()
+	if err := verkletrie.IncrementAccount(tx, tx, verkleWorkers, verkleWriter, from, to); err != nil {
+		return libcommon.Hash{}, err
+	}
+	return verkletrie.IncrementStorage(tx, tx, verkleWorkers, verkleWriter, from, to, root)
+}
+
+// revertVerkleTrie reverts the changes of block from the tree of its root and returns the root of its parent
+func revertVerkleTrie(tx kv.RwTx, cfg TrieCfg, block uint64, root libcommon.Hash, logger log.Logger) (libcommon.Hash, error) {
+	verkleWriter := verkletrie.NewVerkleTreeWriter(tx, cfg.tmpDir, logger)
+	defer verkleWriter.Close()
+	if err := verkletrie.RevertAccount(tx, verkleWorkers, verkleWriter, block); err != nil {
+		return libcommon.Hash{}, err
+	}
+	return verkletrie.RevertStorage(tx, verkleWorkers, verkleWriter, block, root)
+}
+
+func verkleCheckHeader(ctx context.Context, tx kv.Tx, cfg TrieCfg, blockNum uint64) (*types.Header, error) {
+	header, err := cfg.blockReader.HeaderByNumber(ctx, tx, blockNum)
+	if err != nil {
+		return nil, err
+	}
+	if header == nil {
+		return nil, fmt.Errorf("no header found with number %d", blockNum)
+	}
+	return header, nil
+}
+
 func PruneVerkleTries(s *PruneState, tx kv.RwTx, cfg TrieCfg, ctx context.Context) (err error) {
 	useExternalTx := tx != nil
 	if !useExternalTx {
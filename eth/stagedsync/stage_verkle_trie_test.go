@@ -0,0 +1,119 @@
+package stagedsync_test
+
+import (
+	"context"
+	"encoding/binary"
+	"math/big"
+	"testing"
+
+	"github.com/holiman/uint256"
+	"github.com/ledgerwatch/erigon-lib/chain"
+	libcommon "github.com/ledgerwatch/erigon-lib/common"
+	"github.com/ledgerwatch/erigon-lib/kv"
+	"github.com/ledgerwatch/erigon-lib/kv/memdb"
+	"github.com/ledgerwatch/log/v3"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ledgerwatch/erigon/core"
+	"github.com/ledgerwatch/erigon/core/rawdb"
+	"github.com/ledgerwatch/erigon/core/state"
+	"github.com/ledgerwatch/erigon/core/types"
+	"github.com/ledgerwatch/erigon/core/verkletrie"
+	"github.com/ledgerwatch/erigon/eth/ethconfig"
+	"github.com/ledgerwatch/erigon/eth/stagedsync"
+	"github.com/ledgerwatch/erigon/eth/stagedsync/stages"
+	"github.com/ledgerwatch/erigon/params"
+	"github.com/ledgerwatch/erigon/turbo/snapshotsync/freezeblocks"
+)
+
+// executeVerkleTestBlock writes the state changes of a block as the execution stage does
+func executeVerkleTestBlock(t *testing.T, tx kv.RwTx, blockNum uint64, change func(ibs *state.IntraBlockState)) {
+	ibs := state.New(state.NewPlainStateReader(tx))
+	change(ibs)
+	w := state.NewPlainStateWriter(tx, tx, blockNum)
+	require.NoError(t, ibs.CommitBlock(&chain.Rules{}, w))
+	require.NoError(t, w.WriteChangeSets())
+	require.NoError(t, stages.SaveStageProgress(tx, stages.Execution, blockNum))
+}
+
+func TestVerkleTrieForwardAndUnwind(t *testing.T) {
+	db, tx := memdb.NewTestTx(t)
+	ctx, logger := context.Background(), log.New()
+
+	addr := libcommon.HexToAddress("0x71562b71999873db5b286df957af199ec94617f7")
+	contract := libcommon.HexToAddress("0x00000000000000000000000000000000000000aa")
+	slot := libcommon.HexToHash("0x01")
+	config := *params.TestChainConfig
+	config.PragueTime = big.NewInt(0)
+	require.True(t, verkletrie.IsVerkleChain(&config))
+	genesis := &types.Genesis{
+		Config: &config,
+		Alloc: types.GenesisAlloc{
+			addr: {Balance: big.NewInt(params.Ether)},
+			contract: {
+				Balance: big.NewInt(0),
+				Code:    []byte{0x60, 0x00, 0x54, 0x00},
+				Storage: map[libcommon.Hash]libcommon.Hash{slot: libcommon.HexToHash("0x2a")},
+			},
+		},
+	}
+	genesisBlock, _, err := core.WriteGenesisState(genesis, tx, t.TempDir())
+	require.NoError(t, err)
+	root0, err := rawdb.ReadVerkleRoot(tx, 0)
+	require.NoError(t, err)
+	require.Equal(t, genesisBlock.Root(), root0)
+
+	blockReader := freezeblocks.NewBlockReader(freezeblocks.NewRoSnapshots(ethconfig.BlocksFreezing{Enabled: false}, "", log.New()))
+	cfg := stagedsync.StageTrieCfg(db, false, true, false, t.TempDir(), blockReader, nil, false, nil)
+
+	// Block 1 in a batch of its own
+	executeVerkleTestBlock(t, tx, 1, func(ibs *state.IntraBlockState) {
+		ibs.AddBalance(addr, uint256.NewInt(1))
+		ibs.SetState(contract, &slot, *uint256.NewInt(7))
+	})
+	root1, err := stagedsync.SpawnVerkleTrie(&stagedsync.StageState{ID: stages.IntermediateHashes, BlockNumber: 0}, nil, tx, cfg, ctx, logger)
+	require.NoError(t, err)
+	require.NotEqual(t, root0, root1)
+	stored, err := rawdb.ReadVerkleRoot(tx, 1)
+	require.NoError(t, err)
+	require.Equal(t, root1, stored)
+
+	// Unwinding to a block with a root keeps it
+	u := &stagedsync.UnwindState{ID: stages.IntermediateHashes, UnwindPoint: 0}
+	require.NoError(t, stagedsync.UnwindVerkleTrie(u, &stagedsync.StageState{ID: stages.IntermediateHashes, BlockNumber: 1}, tx, cfg, ctx, logger))
+	stored, err = rawdb.ReadVerkleRoot(tx, 1)
+	require.NoError(t, err)
+	require.Equal(t, libcommon.Hash{}, stored)
+
+	// Blocks 1 and 2 in a single batch: the root of block 1 is found by reverting block 2, then unwinding to block 1
+	// keeps it
+	executeVerkleTestBlock(t, tx, 2, func(ibs *state.IntraBlockState) {
+		ibs.AddBalance(addr, uint256.NewInt(1))
+		ibs.SetState(contract, &slot, *uint256.NewInt(0))
+	})
+	root2, err := stagedsync.SpawnVerkleTrie(&stagedsync.StageState{ID: stages.IntermediateHashes, BlockNumber: 0}, nil, tx, cfg, ctx, logger)
+	require.NoError(t, err)
+	require.NotEqual(t, root1, root2)
+	stored, err = rawdb.ReadVerkleRoot(tx, 1)
+	require.NoError(t, err)
+	require.Equal(t, root1, stored)
+	u = &stagedsync.UnwindState{ID: stages.IntermediateHashes, UnwindPoint: 1}
+	require.NoError(t, stagedsync.UnwindVerkleTrie(u, &stagedsync.StageState{ID: stages.IntermediateHashes, BlockNumber: 2}, tx, cfg, ctx, logger))
+	stored, err = rawdb.ReadVerkleRoot(tx, 1)
+	require.NoError(t, err)
+	require.Equal(t, root1, stored)
+	stored, err = rawdb.ReadVerkleRoot(tx, 2)
+	require.NoError(t, err)
+	require.Equal(t, libcommon.Hash{}, stored)
+	progress, err := stages.GetStageProgress(tx, stages.VerkleTrie)
+	require.NoError(t, err)
+	require.Equal(t, uint64(1), progress)
+
+	// The proof of the account at block 1
+	proof, values, err := verkletrie.Prove(tx, root1, verkletrie.AccountKeys(addr, nil))
+	require.NoError(t, err)
+	require.NotEmpty(t, proof)
+	balance := make([]byte, 32)
+	binary.LittleEndian.PutUint64(balance, params.Ether+1)
+	require.Equal(t, balance, values[1])
+}
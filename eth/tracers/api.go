@@ -16,6 +16,7 @@ type TraceConfig struct {
 	Reexec         *uint64
 	NoRefunds      *bool // Turns off gas refunds when tracing
 	StateOverrides *ethapi.StateOverrides
+	BlockOverrides *ethapi.BlockOverrides // Only used by debug_traceCall
 
 	BorTraceEnabled *bool
 	BorTx           *bool
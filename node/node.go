@@ -37,6 +37,7 @@ import (
 	"github.com/ledgerwatch/erigon-lib/kv"
 	"github.com/ledgerwatch/erigon-lib/kv/mdbx"
 	"github.com/ledgerwatch/erigon-lib/kv/memdb"
+	"github.com/ledgerwatch/erigon/core/rawdb"
 	"github.com/ledgerwatch/erigon/migrations"
 	"github.com/ledgerwatch/log/v3"
 )
@@ -324,6 +325,9 @@ func OpenDatabase(config *nodecfg.Config, label kv.Label, name string, readonly
 			opts = opts.Exclusive()
 		}
 
+		if label == kv.ChainDB {
+			opts = opts.WithTableCfg(rawdb.WithLogCounts)
+		}
 		switch label {
 		case kv.ChainDB, kv.ConsensusDB:
 			if config.MdbxPageSize.Bytes() > 0 {
@@ -133,7 +133,7 @@ type dialScheduler struct {
 type dialSetupFunc func(net.Conn, connFlag, *enode.Node) error
 
 type dialConfig struct {
-	self           enode.ID         // our own ID
+	self           func() enode.ID  // our own ID, which changes when the node key is rotated
 	maxDialPeers   int              // maximum number of dialed peers
 	maxActiveDials int              // maximum number of active dials
 	netRestrict    *netutil.Netlist // IP whitelist, disabled if nil
@@ -392,7 +392,7 @@ func (d *dialScheduler) freeDialSlots() int {
 
 // checkDial returns an error if node n should not be dialed.
 func (d *dialScheduler) checkDial(n *enode.Node) error {
-	if n.ID() == d.self {
+	if d.self != nil && n.ID() == d.self() {
 		return errSelf
 	}
 	if n.IP() != nil && n.TCP() == 0 {
@@ -0,0 +1,117 @@
+package p2p
+
+import (
+	"fmt"
+	"strings"
+	"sync/atomic"
+
+	"github.com/ledgerwatch/erigon/p2p/discover"
+	"github.com/ledgerwatch/erigon/p2p/enode"
+	"github.com/ledgerwatch/erigon/p2p/enr"
+	"github.com/ledgerwatch/erigon/rlp"
+)
+
+// ParseENRFilter parses predicates on the entries of node records, all of which must hold for a node to be
+// accepted. A predicate is either the key of an entry the record must have, e.g. "eth", or the key of an entry it
+// must not have, prefixed by "!", e.g. "!les".
+func ParseENRFilter(predicates []string) (func(*enode.Node) bool, error) {
+	var required, forbidden []string
+	for _, p := range predicates {
+		p = strings.TrimSpace(p)
+		key := strings.TrimPrefix(p, "!")
+		if key == "" {
+			return nil, fmt.Errorf("invalid ENR predicate %q", p)
+		}
+		if strings.HasPrefix(p, "!") {
+			forbidden = append(forbidden, key)
+		} else {
+			required = append(required, key)
+		}
+	}
+	return func(n *enode.Node) bool {
+		for _, key := range required {
+			if !hasENREntry(n.Record(), key) {
+				return false
+			}
+		}
+		for _, key := range forbidden {
+			if hasENREntry(n.Record(), key) {
+				return false
+			}
+		}
+		return true
+	}, nil
+}
+
+func hasENREntry(r *enr.Record, key string) bool {
+	var value rlp.RawValue
+	return r.Load(enr.WithEntry(key, &value)) == nil
+}
+
+// DialFilterStats counts the dial candidates from the discovery sources accepted and rejected by the dial filters.
+type DialFilterStats struct {
+	Accepted uint64 `json:"accepted"`
+	Rejected uint64 `json:"rejected"`
+}
+
+// DiscoveryStatsProtocolKey is the key of the discovery stats among the protocols of a node info sent over the
+// sentry gRPC interface, whose reply has no dedicated field for them.
+const DiscoveryStatsProtocolKey = "discovery"
+
+// DiscoveryStats is the state of the node discovery of a server.
+type DiscoveryStats struct {
+	V4         *discover.TableStats `json:"v4,omitempty"` // Nil if discovery v4 is disabled
+	V5         *discover.TableStats `json:"v5,omitempty"` // Nil if discovery v5 is disabled
+	DialFilter DialFilterStats      `json:"dialFilter"`
+}
+
+// dialFilter applies the dial filter and the ENR filter of the server configuration to the discovery sources.
+type dialFilter struct {
+	check    func(*enode.Node) bool
+	accepted atomic.Uint64
+	rejected atomic.Uint64
+}
+
+func newDialFilter(config *Config) (*dialFilter, error) {
+	var checks []func(*enode.Node) bool
+	if len(config.ENRFilter) > 0 {
+		check, err := ParseENRFilter(config.ENRFilter)
+		if err != nil {
+			return nil, err
+		}
+		checks = append(checks, check)
+	}
+	if config.DialFilter != nil {
+		checks = append(checks, config.DialFilter)
+	}
+	if len(checks) == 0 {
+		return &dialFilter{}, nil
+	}
+	return &dialFilter{check: func(n *enode.Node) bool {
+		for _, check := range checks {
+			if !check(n) {
+				return false
+			}
+		}
+		return true
+	}}, nil
+}
+
+// apply returns the nodes of it passing the filter.
+func (f *dialFilter) apply(it enode.Iterator) enode.Iterator {
+	if f.check == nil {
+		return it
+	}
+	return enode.Filter(it, func(n *enode.Node) bool {
+		if f.check(n) {
+			f.accepted.Add(1)
+			return true
+		}
+		f.rejected.Add(1)
+		return false
+	})
+}
+
+func (f *dialFilter) stats() DialFilterStats {
+	return DialFilterStats{Accepted: f.accepted.Load(), Rejected: f.rejected.Load()}
+}
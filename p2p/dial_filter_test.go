@@ -0,0 +1,56 @@
+package p2p
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/ledgerwatch/erigon/p2p/enode"
+	"github.com/ledgerwatch/erigon/p2p/enr"
+)
+
+func newNodeWithEntries(t *testing.T, keys ...string) *enode.Node {
+	var r enr.Record
+	for _, key := range keys {
+		r.Set(enr.WithEntry(key, uint(1)))
+	}
+	require.NoError(t, enode.SignV4(&r, newkey()))
+	n, err := enode.New(enode.ValidSchemes, &r)
+	require.NoError(t, err)
+	return n
+}
+
+func TestParseENRFilter(t *testing.T) {
+	filter, err := ParseENRFilter([]string{"eth", " !les"})
+	require.NoError(t, err)
+	require.True(t, filter(newNodeWithEntries(t, "eth")))
+	require.True(t, filter(newNodeWithEntries(t, "eth", "snap")))
+	require.False(t, filter(newNodeWithEntries(t, "snap")))
+	require.False(t, filter(newNodeWithEntries(t, "eth", "les")))
+
+	_, err = ParseENRFilter([]string{"!"})
+	require.Error(t, err)
+}
+
+func TestDialFilter(t *testing.T) {
+	nodes := []*enode.Node{newNodeWithEntries(t, "eth"), newNodeWithEntries(t), newNodeWithEntries(t, "eth", "snap")}
+	f, err := newDialFilter(&Config{
+		ENRFilter:  []string{"eth"},
+		DialFilter: func(n *enode.Node) bool { return n.ID() != nodes[2].ID() },
+	})
+	require.NoError(t, err)
+
+	it := f.apply(enode.IterNodes(nodes))
+	var accepted []*enode.Node
+	for it.Next() {
+		accepted = append(accepted, it.Node())
+	}
+	require.Equal(t, []*enode.Node{nodes[0]}, accepted)
+	require.Equal(t, DialFilterStats{Accepted: 1, Rejected: 2}, f.stats())
+
+	// Without filters the sources are not wrapped
+	f, err = newDialFilter(&Config{})
+	require.NoError(t, err)
+	source := enode.IterNodes(nodes)
+	require.Equal(t, source, f.apply(source))
+}
@@ -444,6 +444,27 @@ func (tab *Table) len() (n int) {
 	return n
 }
 
+// TableStats summarizes the content of a node table.
+type TableStats struct {
+	Nodes        int   `json:"nodes"`        // Number of live nodes
+	Replacements int   `json:"replacements"` // Number of nodes waiting for a place in a bucket
+	Buckets      []int `json:"buckets"`      // Number of live nodes per bucket, by increasing distance
+}
+
+// stats returns a summary of the content of the table.
+func (tab *Table) stats() TableStats {
+	tab.mutex.Lock()
+	defer tab.mutex.Unlock()
+
+	stats := TableStats{Buckets: make([]int, len(tab.buckets))}
+	for i, b := range &tab.buckets {
+		stats.Nodes += len(b.entries)
+		stats.Replacements += len(b.replacements)
+		stats.Buckets[i] = len(b.entries)
+	}
+	return stats
+}
+
 // bucketLen returns the number of nodes in the bucket for the given ID.
 func (tab *Table) bucketLen(id enode.ID) int {
 	tab.mutex.Lock()
@@ -274,6 +274,11 @@ func (t *UDPv4) LookupPubkey(key *ecdsa.PublicKey) []*enode.Node {
 	return t.newLookup(t.closeCtx, key).run()
 }
 
+// TableStats returns a summary of the content of the node table.
+func (t *UDPv4) TableStats() TableStats {
+	return t.tab.stats()
+}
+
 // RandomNodes is an iterator yielding nodes from a random walk of the DHT.
 func (t *UDPv4) RandomNodes() enode.Iterator {
 	return newLookupIterator(t.closeCtx, t.newRandomLookup)
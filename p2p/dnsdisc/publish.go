@@ -0,0 +1,218 @@
+package dnsdisc
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// RecordChange sets the TXT record of a name, or deletes it if the value is empty.
+type RecordChange struct {
+	Name  string
+	Value string
+}
+
+// Provider is a DNS provider hosting the TXT records of the trees.
+type Provider interface {
+	// Records returns the TXT records of the domain and its subdomains by name.
+	Records(ctx context.Context, domain string) (map[string]string, error)
+	// Apply applies the changes to the records of the domain, in order.
+	Apply(ctx context.Context, domain string, changes []RecordChange) error
+}
+
+// Changes returns the changes from the old TXT records of a tree to the new ones.
+// The new entries are created first and the stale entries are deleted last,
+// so clients syncing the tree during the update find all the entries of the root they got.
+// Records which are not tree entries are left alone.
+func Changes(domain string, oldRecords, newRecords map[string]string) []RecordChange {
+	var created, deleted []RecordChange
+	for name, value := range newRecords {
+		if (name != domain) && (oldRecords[name] != value) {
+			created = append(created, RecordChange{name, value})
+		}
+	}
+	for name, value := range oldRecords {
+		if _, ok := newRecords[name]; !ok && isTreeRecord(value) {
+			deleted = append(deleted, RecordChange{name, ""})
+		}
+	}
+	sortChanges(created)
+	sortChanges(deleted)
+
+	changes := created
+	if root, ok := newRecords[domain]; ok && (oldRecords[domain] != root) {
+		changes = append(changes, RecordChange{domain, root})
+	}
+	return append(changes, deleted...)
+}
+
+func isTreeRecord(value string) bool {
+	for _, prefix := range []string{rootPrefix, linkPrefix, branchPrefix, enrPrefix} {
+		if strings.HasPrefix(value, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+func sortChanges(changes []RecordChange) {
+	sort.Slice(changes, func(i, j int) bool { return changes[i].Name < changes[j].Name })
+}
+
+// Publish deploys the signed tree to the domain of the provider, replacing the previous tree.
+func Publish(ctx context.Context, provider Provider, domain string, t *Tree) error {
+	if len(t.root.sig) == 0 {
+		return errors.New("can't publish an unsigned tree")
+	}
+	oldRecords, err := provider.Records(ctx, domain)
+	if err != nil {
+		return fmt.Errorf("failed to get the records of %s: %w", domain, err)
+	}
+	changes := Changes(domain, oldRecords, t.ToTXT(domain))
+	if len(changes) == 0 {
+		return nil
+	}
+	if err := provider.Apply(ctx, domain, changes); err != nil {
+		return fmt.Errorf("failed to update the records of %s: %w", domain, err)
+	}
+	return nil
+}
+
+// maxTXTStringLength is the length limit of the character strings of a TXT record.
+const maxTXTStringLength = 255
+
+// WriteZone writes the TXT records as a DNS zone file of the domain.
+func WriteZone(w io.Writer, domain string, ttl uint, records map[string]string) error {
+	names := make([]string, 0, len(records))
+	for name := range records {
+		if (name != domain) && !strings.HasSuffix(name, "."+domain) {
+			return fmt.Errorf("record %s is not in the zone of %s", name, domain)
+		}
+		names = append(names, name)
+	}
+	// root first
+	sort.Slice(names, func(i, j int) bool {
+		return (names[i] == domain) || ((names[j] != domain) && (names[i] < names[j]))
+	})
+
+	if _, err := fmt.Fprintf(w, "$ORIGIN %s.\n$TTL %d\n", domain, ttl); err != nil {
+		return err
+	}
+	for _, name := range names {
+		label := "@"
+		if name != domain {
+			label = strings.TrimSuffix(name, "."+domain)
+		}
+		if _, err := fmt.Fprintf(w, "%s IN TXT %s\n", label, zoneTXTValue(records[name])); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// zoneTXTValue splits the value in quoted character strings, the ENR entries are longer than a string.
+func zoneTXTValue(value string) string {
+	var parts []string
+	for len(value) > maxTXTStringLength {
+		parts = append(parts, value[:maxTXTStringLength])
+		value = value[maxTXTStringLength:]
+	}
+	parts = append(parts, value)
+	for i, part := range parts {
+		part = strings.ReplaceAll(part, `\`, `\\`)
+		parts[i] = `"` + strings.ReplaceAll(part, `"`, `\"`) + `"`
+	}
+	return strings.Join(parts, " ")
+}
+
+// FileProvider keeps the TXT records of each domain in a JSON file of a directory.
+// It is meant for testing, and is also a Resolver for clients to sync the published trees.
+type FileProvider struct {
+	dir  string
+	lock sync.Mutex
+}
+
+func NewFileProvider(dir string) *FileProvider {
+	return &FileProvider{dir: dir}
+}
+
+func (p *FileProvider) path(domain string) string {
+	return filepath.Join(p.dir, domain+".json")
+}
+
+func (p *FileProvider) read(domain string) (map[string]string, error) {
+	records := make(map[string]string)
+	data, err := os.ReadFile(p.path(domain))
+	if errors.Is(err, os.ErrNotExist) {
+		return records, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(data, &records); err != nil {
+		return nil, fmt.Errorf("invalid records file %s: %w", p.path(domain), err)
+	}
+	return records, nil
+}
+
+func (p *FileProvider) Records(ctx context.Context, domain string) (map[string]string, error) {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	return p.read(domain)
+}
+
+func (p *FileProvider) Apply(ctx context.Context, domain string, changes []RecordChange) error {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	records, err := p.read(domain)
+	if err != nil {
+		return err
+	}
+	for _, change := range changes {
+		if (change.Name != domain) && !strings.HasSuffix(change.Name, "."+domain) {
+			return fmt.Errorf("record %s is not in the zone of %s", change.Name, domain)
+		}
+		if change.Value == "" {
+			delete(records, change.Name)
+		} else {
+			records[change.Name] = change.Value
+		}
+	}
+	data, err := json.MarshalIndent(records, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(p.dir, 0755); err != nil {
+		return err
+	}
+	tmpPath := p.path(domain) + ".tmp"
+	if err := os.WriteFile(tmpPath, data, 0644); err != nil {
+		return err
+	}
+	return os.Rename(tmpPath, p.path(domain))
+}
+
+// LookupTXT finds the record in the file of the name or of one of its parent domains.
+func (p *FileProvider) LookupTXT(ctx context.Context, name string) ([]string, error) {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	for domain := name; domain != ""; {
+		records, err := p.read(domain)
+		if err != nil {
+			return nil, err
+		}
+		if value, ok := records[name]; ok {
+			return []string{value}, nil
+		}
+		_, domain, _ = strings.Cut(domain, ".")
+	}
+	return nil, &net.DNSError{Err: "no such host", Name: name, IsNotFound: true}
+}
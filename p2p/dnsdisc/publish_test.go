@@ -0,0 +1,102 @@
+package dnsdisc
+
+import (
+	"context"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/ledgerwatch/log/v3"
+
+	"github.com/ledgerwatch/erigon/turbo/testlog"
+)
+
+func TestPublish(t *testing.T) {
+	ctx := context.Background()
+	provider := NewFileProvider(t.TempDir())
+	nodes := testNodes(testKeys(10))
+
+	for _, count := range []int{10, 4} {
+		tree, url := makeTestTree("nodes.example.org", nodes[:count], nil)
+		if err := Publish(ctx, provider, "nodes.example.org", tree); err != nil {
+			t.Fatal(err)
+		}
+
+		// stale entries of the previous tree are deleted
+		records, err := provider.Records(ctx, "nodes.example.org")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if want := tree.ToTXT("nodes.example.org"); !reflect.DeepEqual(records, want) {
+			t.Fatalf("got records %v, want %v", records, want)
+		}
+
+		c := NewClient(Config{Resolver: provider, Logger: testlog.Logger(t, log.LvlTrace)})
+		synced, err := c.SyncTree(url)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got, want := sortByID(synced.Nodes()), sortByID(nodes[:count]); !reflect.DeepEqual(got, want) {
+			t.Fatalf("synced %d nodes, want %d", len(got), len(want))
+		}
+	}
+
+	unsigned, err := MakeTree(1, nodes, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := Publish(ctx, provider, "nodes.example.org", unsigned); err == nil {
+		t.Fatal("published an unsigned tree")
+	}
+}
+
+func TestChanges(t *testing.T) {
+	oldRecords := map[string]string{
+		"n":   "enrtree-root:v1 e=A l=B seq=1 sig=S",
+		"A.n": "enrtree-branch:",
+		"B.n": "enrtree-branch:",
+		"X.n": "v=spf1 -all",
+	}
+	newRecords := map[string]string{
+		"n":   "enrtree-root:v1 e=C l=B seq=2 sig=S",
+		"B.n": "enrtree-branch:",
+		"C.n": "enr:-",
+	}
+	want := []RecordChange{
+		{"C.n", "enr:-"},
+		{"n", "enrtree-root:v1 e=C l=B seq=2 sig=S"},
+		{"A.n", ""},
+	}
+	if got := Changes("n", oldRecords, newRecords); !reflect.DeepEqual(got, want) {
+		t.Fatalf("got changes %v, want %v", got, want)
+	}
+	if got := Changes("n", newRecords, newRecords); len(got) != 0 {
+		t.Fatalf("got changes %v for the same records", got)
+	}
+}
+
+func TestWriteZone(t *testing.T) {
+	long := "enr:-" + strings.Repeat("a", 300)
+	records := map[string]string{
+		"B.n": "enrtree-branch:",
+		"n":   "enrtree-root:v1 e=A l=B seq=1 sig=S",
+		"A.n": long,
+	}
+	var zone strings.Builder
+	if err := WriteZone(&zone, "n", 3600, records); err != nil {
+		t.Fatal(err)
+	}
+	want := `$ORIGIN n.
+$TTL 3600
+@ IN TXT "enrtree-root:v1 e=A l=B seq=1 sig=S"
+A IN TXT "` + long[:255] + `" "` + long[255:] + `"
+B IN TXT "enrtree-branch:"
+`
+	if zone.String() != want {
+		t.Fatalf("got zone\n%s\nwant\n%s", zone.String(), want)
+	}
+
+	if err := WriteZone(&zone, "n", 3600, map[string]string{"A.m": "enr:-"}); err == nil {
+		t.Fatal("wrote a record out of the zone")
+	}
+}
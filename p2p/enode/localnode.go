@@ -43,12 +43,12 @@ const (
 // of the record is signed on demand when the Node method is called.
 type LocalNode struct {
 	cur atomic.Pointer[Node] // holds a non-nil node pointer while the record is up-to-date.
-	id  ID
-	key *ecdsa.PrivateKey
 	db  *DB
 
 	// everything below is protected by a lock
 	mu        sync.Mutex
+	id        ID
+	key       *ecdsa.PrivateKey
 	seq       uint64
 	entries   map[string]enr.Entry
 	endpoint4 lnEndpoint
@@ -110,9 +110,28 @@ func (ln *LocalNode) Seq() uint64 {
 
 // ID returns the local node ID.
 func (ln *LocalNode) ID() ID {
+	ln.mu.Lock()
+	defer ln.mu.Unlock()
+
 	return ln.id
 }
 
+// SetKey switches the local node to another key, e.g. when the node key is rotated. The
+// sequence number of the record continues from the one of the previous key, so that the
+// records signed with the new key supersede all the records signed before.
+func (ln *LocalNode) SetKey(key *ecdsa.PrivateKey) {
+	ln.mu.Lock()
+	defer ln.mu.Unlock()
+
+	id := PubkeyToIDV4(&key.PublicKey)
+	if seq := ln.db.localSeq(id); seq > ln.seq {
+		ln.seq = seq
+	}
+	ln.id, ln.key = id, key
+	ln.db.storeLocalSeq(id, ln.seq)
+	ln.invalidate()
+}
+
 // Set puts the given entry into the local record, overwriting any existing value.
 // Use Set*IP and SetFallbackUDP to set IP addresses and UDP port, otherwise they'll
 // be overwritten by the endpoint predictor.
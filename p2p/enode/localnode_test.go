@@ -86,6 +86,42 @@ func TestLocalNodeSeqPersist(t *testing.T) {
 	}
 }
 
+func TestLocalNodeSetKey(t *testing.T) {
+	tmpDir := t.TempDir()
+	logger := log.New()
+	ln, db := newLocalNodeForTesting(tmpDir, logger)
+	defer db.Close()
+
+	if s := ln.Node().Seq(); s != 1 {
+		t.Fatalf("wrong initial seq %d, want 1", s)
+	}
+	ln.Set(enr.WithEntry("x", uint(1)))
+	if s := ln.Node().Seq(); s != 2 {
+		t.Fatalf("wrong seq %d after set, want 2", s)
+	}
+
+	// The record signed with the new key continues the sequence of the old one.
+	key, _ := crypto.GenerateKey()
+	ln.SetKey(key)
+	n := ln.Node()
+	if n.Seq() != 3 {
+		t.Fatalf("wrong seq %d after key change, want 3", n.Seq())
+	}
+	if n.ID() != PubkeyToIDV4(&key.PublicKey) || ln.ID() != n.ID() {
+		t.Fatal("record not signed with the new key")
+	}
+	var x uint
+	if err := n.Load(enr.WithEntry("x", &x)); err != nil || x != 1 {
+		t.Fatal("entries lost after key change:", err)
+	}
+
+	// The sequence is persisted for the new key.
+	ln2 := NewLocalNode(db, key, logger)
+	if s := ln2.Node().Seq(); s != 4 {
+		t.Fatalf("wrong seq %d on new instance, want 4", s)
+	}
+}
+
 // This test checks behavior of the endpoint predictor.
 func TestLocalNodeEndpoint(t *testing.T) {
 	var (
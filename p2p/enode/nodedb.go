@@ -46,6 +46,7 @@ const (
 	dbVersionKey   = "version" // Version of the database to flush if changes
 	dbNodePrefix   = "n:"      // Identifier to prefix node entries with
 	dbLocalPrefix  = "local:"
+	dbRepPrefix    = "rep:" // Identifier to prefix peer reputation entries with, keyed by ID only
 	dbDiscoverRoot = "v4"
 	dbDiscv5Root   = "v5"
 
@@ -62,8 +63,9 @@ const (
 )
 
 const (
-	dbNodeExpiration = 24 * time.Hour // Time after which an unseen node should be dropped.
-	dbCleanupCycle   = time.Hour      // Time period for running the expiration task.
+	dbNodeExpiration = 24 * time.Hour      // Time after which an unseen node should be dropped.
+	dbRepExpiration  = 30 * 24 * time.Hour // Time after which the reputation of an unseen peer should be dropped.
+	dbCleanupCycle   = time.Hour           // Time period for running the expiration task.
 	dbVersion        = 10
 )
 
@@ -399,6 +401,7 @@ func (db *DB) expirer() {
 		select {
 		case <-tick.C:
 			db.expireNodes()
+			db.expireReputations()
 		case <-db.quit:
 			return
 		}
@@ -460,6 +463,106 @@ func (db *DB) expireNodes() {
 	}
 }
 
+// Reputation is the long-term record of the behaviour of a peer, kept across restarts.
+type Reputation struct {
+	Useful      uint64 // Responses received in time
+	Timeouts    uint64 // Requests not answered in time
+	Invalid     uint64 // Invalid blocks and messages received
+	LatencyMs   uint64 // Moving average of the response latency in milliseconds
+	BannedUntil uint64 // Unix time until which the peer is banned, 0 if it is not
+	Updated     uint64 // Unix time of the last update
+}
+
+// reputationKey returns the database key of the reputation of a peer.
+func reputationKey(id ID) []byte {
+	return append([]byte(dbRepPrefix), id[:]...)
+}
+
+// Reputation retrieves the reputation of a peer, ok is false if there is none.
+func (db *DB) Reputation(id ID) (rep Reputation, ok bool) {
+	if err := db.kv.View(context.Background(), func(tx kv.Tx) error {
+		blob, errGet := tx.GetOne(kv.Inodes, reputationKey(id))
+		if errGet != nil {
+			return errGet
+		}
+		if blob == nil {
+			return nil
+		}
+		if err := rlp.DecodeBytes(blob, &rep); err != nil {
+			return err
+		}
+		ok = true
+		return nil
+	}); err != nil {
+		return Reputation{}, false
+	}
+	return rep, ok
+}
+
+// UpdateReputation inserts - potentially overwriting - the reputation of a peer.
+func (db *DB) UpdateReputation(id ID, rep Reputation) error {
+	blob, err := rlp.EncodeToBytes(&rep)
+	if err != nil {
+		return err
+	}
+	return db.kv.Update(context.Background(), func(tx kv.RwTx) error {
+		return tx.Put(kv.Inodes, reputationKey(id), blob)
+	})
+}
+
+// Reputations retrieves the reputations of all the peers accepted by the filter.
+func (db *DB) Reputations(filter func(Reputation) bool) map[ID]Reputation {
+	reps := make(map[ID]Reputation)
+	if err := db.kv.View(context.Background(), func(tx kv.Tx) error {
+		c, err := tx.Cursor(kv.Inodes)
+		if err != nil {
+			return err
+		}
+		p := []byte(dbRepPrefix)
+		for k, v, err := c.Seek(p); bytes.HasPrefix(k, p); k, v, err = c.Next() {
+			if err != nil {
+				return err
+			}
+			var rep Reputation
+			if err := rlp.DecodeBytes(v, &rep); err != nil {
+				continue
+			}
+			if filter == nil || filter(rep) {
+				var id ID
+				copy(id[:], k[len(p):])
+				reps[id] = rep
+			}
+		}
+		return nil
+	}); err != nil {
+		log.Warn("nodeDB.Reputations failed", "err", err)
+	}
+	return reps
+}
+
+// expireReputations deletes the reputations of the peers which are not banned
+// and have not been updated for some time.
+func (db *DB) expireReputations() {
+	now := time.Now()
+	threshold := uint64(now.Add(-dbRepExpiration).Unix())
+	expired := db.Reputations(func(rep Reputation) bool {
+		return rep.Updated < threshold && rep.BannedUntil < uint64(now.Unix())
+	})
+	if len(expired) == 0 {
+		return
+	}
+	if err := db.kv.Update(context.Background(), func(tx kv.RwTx) error {
+		for id := range expired {
+			if err := tx.Delete(kv.Inodes, reputationKey(id)); err != nil {
+				return err
+			}
+		}
+		return nil
+	}); err != nil {
+		log.Warn("nodeDB.expireReputations failed", "err", err)
+	}
+}
+
 // LastPingReceived retrieves the time of the last ping packet received from
 // a remote node.
 func (db *DB) LastPingReceived(id ID, ip net.IP) time.Time {
@@ -113,6 +113,43 @@ func TestDBInt64(t *testing.T) {
 	}
 }
 
+func TestDBReputation(t *testing.T) {
+	tmpDir := t.TempDir()
+	db, err := OpenDB("", tmpDir)
+	if err != nil {
+		panic(err)
+	}
+	defer db.Close()
+
+	now := time.Now()
+	fresh := Reputation{Useful: 10, Timeouts: 1, LatencyMs: 120, Updated: uint64(now.Unix())}
+	stale := Reputation{Useful: 3, Updated: uint64(now.Add(-dbRepExpiration - time.Hour).Unix())}
+	banned := Reputation{BannedUntil: uint64(now.Add(time.Hour).Unix()), Updated: stale.Updated}
+	reps := map[ID]Reputation{{1}: fresh, {2}: stale, {3}: banned}
+
+	if _, ok := db.Reputation(ID{1}); ok {
+		t.Errorf("non-existing reputation found")
+	}
+	for id, rep := range reps {
+		if err := db.UpdateReputation(id, rep); err != nil {
+			t.Fatalf("failed to update reputation: %v", err)
+		}
+	}
+	if rep, ok := db.Reputation(ID{1}); !ok || rep != fresh {
+		t.Errorf("reputation mismatch: have %v, want %v", rep, fresh)
+	}
+	if stored := db.Reputations(nil); !reflect.DeepEqual(stored, reps) {
+		t.Errorf("reputations mismatch: have %v, want %v", stored, reps)
+	}
+
+	// Only the stale reputations of the peers which aren't banned expire.
+	db.expireReputations()
+	delete(reps, ID{2})
+	if stored := db.Reputations(nil); !reflect.DeepEqual(stored, reps) {
+		t.Errorf("reputations mismatch after expiration: have %v, want %v", stored, reps)
+	}
+}
+
 func TestDBFetchStore(t *testing.T) {
 	node := NewV4(
 		hexPubkey("1dd9d65c4552b5eb43d5ad55a2ee3f56c6cbc1c64a5c8d659f51fcd51bace24351232b8d7821617d2b29b54b81cdefb9b3e9c37d7fd5f63270bcc9e1a6f6a439"),
@@ -1,10 +1,14 @@
 package p2p
 
 import (
+	"bufio"
+	"bytes"
 	"crypto/ecdsa"
 	"fmt"
 	"os"
 	"path"
+	"strings"
+	"time"
 
 	"github.com/ledgerwatch/erigon/crypto"
 )
@@ -80,3 +84,114 @@ func (config NodeKeyConfig) LoadOrParseOrGenerateAndSave(file, hex, datadir stri
 		return config.LoadOrGenerateAndSave(config.DefaultPath(datadir))
 	}
 }
+
+// LoadIdentity loads a node key file and its previous keys, a new key is generated and saved
+// if the file doesn't exist.
+func (config NodeKeyConfig) LoadIdentity(keyfile string) (*ecdsa.PrivateKey, []PreviousNodeKey, error) {
+	key, err := config.LoadOrGenerateAndSave(keyfile)
+	if err != nil {
+		return nil, nil, err
+	}
+	previous, err := config.LoadPrevious(keyfile)
+	if err != nil {
+		return nil, nil, err
+	}
+	return key, previous, nil
+}
+
+// PreviousNodeKey is a node key replaced by a rotation, the node keeps accepting connections
+// to its identity until it expires.
+type PreviousNodeKey struct {
+	Key     *ecdsa.PrivateKey
+	Expires time.Time
+}
+
+func unexpiredNodeKeys(keys []PreviousNodeKey, now time.Time) []PreviousNodeKey {
+	var unexpired []PreviousNodeKey
+	for _, k := range keys {
+		if k.Expires.After(now) {
+			unexpired = append(unexpired, k)
+		}
+	}
+	return unexpired
+}
+
+// PreviousPath returns the file keeping the previous keys of a node key file.
+func (config NodeKeyConfig) PreviousPath(keyfile string) string {
+	return keyfile + ".previous"
+}
+
+// LoadPrevious loads the previous keys of a node key file which haven't expired yet.
+func (config NodeKeyConfig) LoadPrevious(keyfile string) ([]PreviousNodeKey, error) {
+	file := config.PreviousPath(keyfile)
+	data, err := os.ReadFile(file)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to load previous node keys from %s: %w", file, err)
+	}
+
+	var keys []PreviousNodeKey
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+		fields := strings.Fields(line)
+		if len(fields) != 2 {
+			return nil, fmt.Errorf("failed to load previous node keys from %s: invalid line %q", file, line)
+		}
+		key, err := crypto.HexToECDSA(fields[0])
+		if err != nil {
+			return nil, fmt.Errorf("failed to load previous node keys from %s: %w", file, err)
+		}
+		expires, err := time.Parse(time.RFC3339, fields[1])
+		if err != nil {
+			return nil, fmt.Errorf("failed to load previous node keys from %s: %w", file, err)
+		}
+		keys = append(keys, PreviousNodeKey{Key: key, Expires: expires})
+	}
+	return unexpiredNodeKeys(keys, time.Now()), nil
+}
+
+func (config NodeKeyConfig) savePrevious(keyfile string, keys []PreviousNodeKey) error {
+	var buf bytes.Buffer
+	for _, k := range keys {
+		fmt.Fprintf(&buf, "%x %s\n", crypto.FromECDSA(k.Key), k.Expires.UTC().Format(time.RFC3339))
+	}
+	file := config.PreviousPath(keyfile)
+	if err := os.WriteFile(file, buf.Bytes(), 0600); err != nil {
+		return fmt.Errorf("failed to save previous node keys to %s: %w", file, err)
+	}
+	return nil
+}
+
+// Rotate replaces the key of a node key file with a new one. The replaced key is kept with the
+// previous keys of the file for the grace period, the previous keys are saved before the new key
+// so that no key is lost if saving fails.
+func (config NodeKeyConfig) Rotate(keyfile string, grace time.Duration) (*ecdsa.PrivateKey, []PreviousNodeKey, error) {
+	old, err := config.load(keyfile)
+	if err != nil {
+		return nil, nil, err
+	}
+	previous, err := config.LoadPrevious(keyfile)
+	if err != nil {
+		return nil, nil, err
+	}
+	key, err := config.generateKey()
+	if err != nil {
+		return nil, nil, err
+	}
+	if grace > 0 {
+		previous = append(previous, PreviousNodeKey{Key: old, Expires: time.Now().Add(grace)})
+	}
+	if err := config.savePrevious(keyfile, previous); err != nil {
+		return nil, nil, err
+	}
+	if err := config.save(keyfile, key); err != nil {
+		return nil, nil, err
+	}
+	return key, previous, nil
+}
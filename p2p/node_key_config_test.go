@@ -0,0 +1,126 @@
+package p2p
+
+import (
+	"crypto/ecdsa"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/ledgerwatch/log/v3"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ledgerwatch/erigon/crypto"
+	"github.com/ledgerwatch/erigon/p2p/enode"
+)
+
+func TestNodeKeyRotate(t *testing.T) {
+	config := NodeKeyConfig{}
+	keyfile := filepath.Join(t.TempDir(), "nodekey")
+	first, err := config.LoadOrGenerateAndSave(keyfile)
+	require.NoError(t, err)
+
+	second, previous, err := config.Rotate(keyfile, time.Hour)
+	require.NoError(t, err)
+	require.NotEqual(t, crypto.FromECDSA(first), crypto.FromECDSA(second))
+	require.Len(t, previous, 1)
+	require.Equal(t, crypto.FromECDSA(first), crypto.FromECDSA(previous[0].Key))
+
+	loaded, err := config.LoadOrGenerateAndSave(keyfile)
+	require.NoError(t, err)
+	require.Equal(t, crypto.FromECDSA(second), crypto.FromECDSA(loaded))
+	loadedPrevious, err := config.LoadPrevious(keyfile)
+	require.NoError(t, err)
+	require.Len(t, loadedPrevious, 1)
+	require.Equal(t, crypto.FromECDSA(first), crypto.FromECDSA(loadedPrevious[0].Key))
+	require.WithinDuration(t, previous[0].Expires, loadedPrevious[0].Expires, time.Second)
+
+	// Without a grace period the replaced key is dropped right away
+	_, previous, err = config.Rotate(keyfile, 0)
+	require.NoError(t, err)
+	require.Len(t, previous, 1)
+
+	// The expired keys are dropped on load
+	require.NoError(t, config.savePrevious(keyfile, []PreviousNodeKey{{Key: first, Expires: time.Now().Add(-time.Minute)}}))
+	loadedPrevious, err = config.LoadPrevious(keyfile)
+	require.NoError(t, err)
+	require.Empty(t, loadedPrevious)
+}
+
+// startKeyRotationServer starts a server with a key loaded from keyfile, or a new key if key is nil.
+func startKeyRotationServer(t *testing.T, logger log.Logger, key *ecdsa.PrivateKey, keyfile string) *Server {
+	if key == nil {
+		key = newkey()
+	}
+	srv := &Server{Config: Config{
+		Name:            "test",
+		MaxPeers:        10,
+		MaxPendingPeers: 10,
+		ListenAddr:      "127.0.0.1:0",
+		NoDiscovery:     true,
+		PrivateKey:      key,
+		NodeKeyFile:     keyfile,
+	}}
+	require.NoError(t, srv.TestStart(logger))
+	t.Cleanup(srv.Stop)
+	return srv
+}
+
+func waitPeer(t *testing.T, srv *Server, id enode.ID) {
+	require.Eventually(t, func() bool {
+		for _, p := range srv.Peers() {
+			if p.ID() == id {
+				return true
+			}
+		}
+		return false
+	}, 5*time.Second, 10*time.Millisecond)
+}
+
+func TestServerRotateKey(t *testing.T) {
+	logger := log.New()
+	srv := startKeyRotationServer(t, logger, nil, "")
+	first := srv.Self()
+
+	require.NoError(t, srv.RotateKey(newkey(), time.Hour))
+	second := srv.Self()
+	require.NotEqual(t, first.ID(), second.ID())
+	require.Greater(t, second.Seq(), first.Seq())
+	require.Equal(t, first.TCP(), second.TCP())
+
+	// Both identities are accepted during the grace period
+	oldPeer, newPeer := startKeyRotationServer(t, logger, nil, ""), startKeyRotationServer(t, logger, nil, "")
+	oldPeer.AddPeer(first)
+	waitPeer(t, oldPeer, first.ID())
+	newPeer.AddPeer(second)
+	waitPeer(t, newPeer, second.ID())
+
+	// The identity replaced without a grace period isn't
+	require.NoError(t, srv.RotateKey(newkey(), 0))
+	latePeer := startKeyRotationServer(t, logger, nil, "")
+	latePeer.AddPeer(second)
+	require.Never(t, func() bool { return latePeer.PeerCount() > 0 }, time.Second, 50*time.Millisecond)
+	latePeer.AddPeer(first)
+	waitPeer(t, latePeer, first.ID())
+}
+
+func TestServerRotateKeyDiscovery(t *testing.T) {
+	logger := log.New()
+	srv := &Server{Config: Config{
+		Name:            "test",
+		MaxPeers:        10,
+		MaxPendingPeers: 10,
+		ListenAddr:      "127.0.0.1:0",
+		PrivateKey:      newkey(),
+	}}
+	require.NoError(t, srv.TestStart(logger))
+	t.Cleanup(srv.Stop)
+	first := srv.Self()
+
+	// The discovery restarts on the same port and the dialer knows the new identity
+	require.NoError(t, srv.RotateKey(newkey(), time.Hour))
+	second := srv.Self()
+	require.NotEqual(t, first.ID(), second.ID())
+	require.Equal(t, first.UDP(), second.UDP())
+	require.Equal(t, second.ID(), srv.dialsched.self())
+	require.ErrorIs(t, srv.dialsched.checkDial(second), errSelf)
+}
@@ -0,0 +1,70 @@
+package p2p
+
+import (
+	"crypto/ecdsa"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/ledgerwatch/erigon/p2p/enode"
+)
+
+// NodeKeyRotation describes the rotation of one node identity, shared by all the servers which had it.
+type NodeKeyRotation struct {
+	Previous string    `json:"previous"`          // ID of the replaced identity
+	Enodes   []string  `json:"enodes"`            // Enode URLs of the servers under the new identity
+	Expires  time.Time `json:"expires"`           // End of the grace period of the replaced identity
+	KeyFile  string    `json:"keyFile,omitempty"` // File the new key is saved to, empty if it is only kept in memory
+}
+
+// RotateNodeKeys gives a new identity to every identity of the running servers, the servers sharing an
+// identity keep sharing it. The keys loaded from files are saved there, the replaced keys are kept
+// with the previous keys of the files for the grace period so that they survive restarts.
+func RotateNodeKeys(running []*Server, grace time.Duration) ([]*NodeKeyRotation, error) {
+	if len(running) == 0 {
+		return nil, errors.New("no p2p server running in this process, the node keys of standalone sentries can't be rotated")
+	}
+
+	type identity struct {
+		file string
+		id   enode.ID
+	}
+	var (
+		order   []identity
+		servers = make(map[identity][]*Server)
+	)
+	for _, srv := range running {
+		key := srv.NodeKey()
+		ident := identity{file: srv.NodeKeyFile, id: enode.PubkeyToIDV4(&key.PublicKey)}
+		if servers[ident] == nil {
+			order = append(order, ident)
+		}
+		servers[ident] = append(servers[ident], srv)
+	}
+
+	config := NodeKeyConfig{}
+	rotations := make([]*NodeKeyRotation, 0, len(order))
+	for _, ident := range order {
+		rotation := &NodeKeyRotation{Previous: ident.id.String(), Expires: time.Now().Add(grace), KeyFile: ident.file}
+		var (
+			key *ecdsa.PrivateKey
+			err error
+		)
+		if ident.file != "" {
+			key, _, err = config.Rotate(ident.file, grace)
+		} else {
+			key, err = config.generateKey()
+		}
+		if err != nil {
+			return rotations, err
+		}
+		for _, srv := range servers[ident] {
+			if err := srv.RotateKey(key, grace); err != nil {
+				return rotations, fmt.Errorf("rotate node key of %s: %w", ident.id, err)
+			}
+			rotation.Enodes = append(rotation.Enodes, srv.Self().URLv4())
+		}
+		rotations = append(rotations, rotation)
+	}
+	return rotations, nil
+}
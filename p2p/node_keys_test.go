@@ -0,0 +1,52 @@
+package p2p
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/ledgerwatch/log/v3"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ledgerwatch/erigon/crypto"
+	"github.com/ledgerwatch/erigon/p2p/enode"
+)
+
+func TestRotateNodeKeys(t *testing.T) {
+	logger := log.New()
+	_, err := RotateNodeKeys(nil, time.Hour)
+	require.Error(t, err)
+
+	// Two servers sharing the identity of a key file, and one with an identity of its own
+	config := NodeKeyConfig{}
+	keyfile := filepath.Join(t.TempDir(), "nodekey")
+	key, err := config.LoadOrGenerateAndSave(keyfile)
+	require.NoError(t, err)
+	var shared []*Server
+	for i := 0; i < 2; i++ {
+		srv := startKeyRotationServer(t, logger, key, keyfile)
+		shared = append(shared, srv)
+	}
+	single := startKeyRotationServer(t, logger, nil, "")
+	singleID := single.Self().ID()
+
+	rotations, err := RotateNodeKeys(append(shared, single), time.Hour)
+	require.NoError(t, err)
+	require.Len(t, rotations, 2)
+
+	require.Equal(t, enode.PubkeyToIDV4(&key.PublicKey).String(), rotations[0].Previous)
+	require.Equal(t, keyfile, rotations[0].KeyFile)
+	require.Len(t, rotations[0].Enodes, 2)
+	require.Equal(t, shared[0].NodeKey(), shared[1].NodeKey())
+	saved, err := crypto.LoadECDSA(keyfile)
+	require.NoError(t, err)
+	require.Equal(t, crypto.FromECDSA(shared[0].NodeKey()), crypto.FromECDSA(saved))
+
+	require.Equal(t, singleID.String(), rotations[1].Previous)
+	require.Empty(t, rotations[1].KeyFile)
+	require.NotEqual(t, singleID, single.Self().ID())
+
+	rotations, err = RotateNodeKeys(shared, time.Hour)
+	require.NoError(t, err)
+	require.Len(t, rotations, 1)
+}
@@ -0,0 +1,233 @@
+package p2p
+
+import (
+	"sync"
+	"time"
+
+	"github.com/ledgerwatch/erigon/p2p/enode"
+)
+
+const (
+	peerScoreUsefulCap     = 200 // Useful responses counted in the score, so that a long history doesn't shield misbehaviour
+	peerScoreTimeoutWeight = 4   // Score points lost per request timeout
+	peerScoreInvalidWeight = 100 // Score points lost per invalid block or message
+	peerScoreLatencyUnit   = 100 // Average response latency in milliseconds costing one score point
+
+	// PeerBanScore is the score at which a peer gets banned.
+	PeerBanScore = -250
+	// PeerBanDuration is how long a peer stays banned, the ban survives restarts.
+	PeerBanDuration = 24 * time.Hour
+)
+
+// PeerScoresProtocolKey is the key of the peer scores among the protocols of a node info sent over the sentry gRPC
+// interface, whose reply has no dedicated field for them.
+const PeerScoresProtocolKey = "peerScores"
+
+// PeerScore is the reputation of a peer reported by the admin RPC.
+type PeerScore struct {
+	ID          string `json:"id"`             // Unique node identifier
+	Name        string `json:"name,omitempty"` // Name of the node, if connected
+	Connected   bool   `json:"connected"`
+	Score       int64  `json:"score"`
+	Useful      uint64 `json:"useful"`
+	Timeouts    uint64 `json:"timeouts"`
+	Invalid     uint64 `json:"invalid"`
+	LatencyMs   uint64 `json:"latencyMs"`
+	BannedUntil uint64 `json:"bannedUntil,omitempty"` // Unix time
+}
+
+// ReputationScore is the score of a peer reputation: the useful responses, up to a cap, minus penalties for the
+// timeouts, the invalid blocks and messages, and the average latency.
+func ReputationScore(rep enode.Reputation) int64 {
+	useful := rep.Useful
+	if useful > peerScoreUsefulCap {
+		useful = peerScoreUsefulCap
+	}
+	return int64(useful) -
+		peerScoreTimeoutWeight*int64(rep.Timeouts) -
+		peerScoreInvalidWeight*int64(rep.Invalid) -
+		int64(rep.LatencyMs/peerScoreLatencyUnit)
+}
+
+type peerScore struct {
+	rep  enode.Reputation
+	name string
+}
+
+// PeerScores keeps the reputations of the connected peers in memory and of the other ones in the node database,
+// and bans the peers whose score drops to PeerBanScore.
+type PeerScores struct {
+	lock  sync.Mutex
+	db    *enode.DB // Nil until SetDB, the reputations aren't persisted then
+	peers map[enode.ID]*peerScore
+	now   func() time.Time
+}
+
+func NewPeerScores() *PeerScores {
+	return &PeerScores{peers: make(map[enode.ID]*peerScore), now: time.Now}
+}
+
+// SetDB sets the node database the reputations are loaded from and saved to, nil to stop persisting them.
+func (ps *PeerScores) SetDB(db *enode.DB) {
+	ps.lock.Lock()
+	defer ps.lock.Unlock()
+	ps.db = db
+}
+
+// Close saves the reputations of the connected peers and stops persisting them.
+func (ps *PeerScores) Close() {
+	ps.lock.Lock()
+	defer ps.lock.Unlock()
+	for id, score := range ps.peers {
+		ps.save(id, score.rep)
+	}
+	ps.db = nil
+}
+
+func (ps *PeerScores) load(id enode.ID) enode.Reputation {
+	if ps.db == nil {
+		return enode.Reputation{}
+	}
+	rep, _ := ps.db.Reputation(id)
+	return rep
+}
+
+func (ps *PeerScores) save(id enode.ID, rep enode.Reputation) {
+	if ps.db == nil {
+		return
+	}
+	_ = ps.db.UpdateReputation(id, rep)
+}
+
+func (ps *PeerScores) banned(rep enode.Reputation) bool {
+	return rep.BannedUntil > uint64(ps.now().Unix())
+}
+
+// Connect starts tracking the reputation of a connected peer, it returns whether the peer is banned.
+func (ps *PeerScores) Connect(id enode.ID, name string) bool {
+	ps.lock.Lock()
+	defer ps.lock.Unlock()
+	score, ok := ps.peers[id]
+	if !ok {
+		score = &peerScore{rep: ps.load(id)}
+		ps.peers[id] = score
+	}
+	score.name = name
+	return ps.banned(score.rep)
+}
+
+// Disconnect saves the reputation of a peer and stops tracking it in memory.
+func (ps *PeerScores) Disconnect(id enode.ID) {
+	ps.lock.Lock()
+	defer ps.lock.Unlock()
+	if score, ok := ps.peers[id]; ok {
+		ps.save(id, score.rep)
+		delete(ps.peers, id)
+	}
+}
+
+// update applies f to the reputation of a peer, banning the peer if its score drops to PeerBanScore.
+// The reputations of the peers which are not connected are updated in the database. It returns whether the
+// peer got banned.
+func (ps *PeerScores) update(id enode.ID, f func(rep *enode.Reputation)) bool {
+	ps.lock.Lock()
+	defer ps.lock.Unlock()
+	score, connected := ps.peers[id]
+	if !connected {
+		score = &peerScore{rep: ps.load(id)}
+	}
+	f(&score.rep)
+	now := ps.now()
+	score.rep.Updated = uint64(now.Unix())
+
+	banned := false
+	if ReputationScore(score.rep) <= PeerBanScore {
+		// Start over with a clean record once the ban expires
+		score.rep = enode.Reputation{BannedUntil: uint64(now.Add(PeerBanDuration).Unix()), Updated: score.rep.Updated}
+		banned = true
+	}
+	if banned || !connected {
+		ps.save(id, score.rep)
+	}
+	return banned
+}
+
+// Response records a response of a peer received in time.
+func (ps *PeerScores) Response(id enode.ID, latency time.Duration) {
+	ps.update(id, func(rep *enode.Reputation) {
+		ms := uint64(latency.Milliseconds())
+		if rep.Useful == 0 {
+			rep.LatencyMs = ms
+		} else {
+			// Exponential moving average with the weight of 1/8, as of the TCP round-trip time
+			rep.LatencyMs = (7*rep.LatencyMs + ms) / 8
+		}
+		rep.Useful++
+	})
+}
+
+// Timeouts records requests not answered by a peer in time, it returns whether the peer got banned.
+func (ps *PeerScores) Timeouts(id enode.ID, count int) bool {
+	return ps.update(id, func(rep *enode.Reputation) {
+		rep.Timeouts += uint64(count)
+	})
+}
+
+// Invalid records an invalid block or message received from a peer, it returns whether the peer got banned.
+func (ps *PeerScores) Invalid(id enode.ID) bool {
+	return ps.update(id, func(rep *enode.Reputation) {
+		rep.Invalid++
+	})
+}
+
+// Score returns the score of a connected peer, 0 for unknown peers.
+func (ps *PeerScores) Score(id enode.ID) int64 {
+	ps.lock.Lock()
+	defer ps.lock.Unlock()
+	if score, ok := ps.peers[id]; ok {
+		return ReputationScore(score.rep)
+	}
+	return 0
+}
+
+// Banned returns whether a peer is banned.
+func (ps *PeerScores) Banned(id enode.ID) bool {
+	ps.lock.Lock()
+	defer ps.lock.Unlock()
+	if score, ok := ps.peers[id]; ok {
+		return ps.banned(score.rep)
+	}
+	return ps.banned(ps.load(id))
+}
+
+// List returns the scores of the connected peers and of the banned ones.
+func (ps *PeerScores) List() []*PeerScore {
+	ps.lock.Lock()
+	defer ps.lock.Unlock()
+	list := make([]*PeerScore, 0, len(ps.peers))
+	for id, score := range ps.peers {
+		list = append(list, makePeerScore(id, score.rep, score.name, true))
+	}
+	if ps.db != nil {
+		for id, rep := range ps.db.Reputations(ps.banned) {
+			if _, connected := ps.peers[id]; !connected {
+				list = append(list, makePeerScore(id, rep, "", false))
+			}
+		}
+	}
+	return list
+}
+
+func makePeerScore(id enode.ID, rep enode.Reputation, name string, connected bool) *PeerScore {
+	return &PeerScore{
+		ID:          id.String(),
+		Name:        name,
+		Connected:   connected,
+		Score:       ReputationScore(rep),
+		Useful:      rep.Useful,
+		Timeouts:    rep.Timeouts,
+		Invalid:     rep.Invalid,
+		LatencyMs:   rep.LatencyMs,
+		BannedUntil: rep.BannedUntil,
+	}
+}
@@ -0,0 +1,80 @@
+package p2p
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/ledgerwatch/erigon/p2p/enode"
+)
+
+func TestReputationScore(t *testing.T) {
+	require.Equal(t, int64(0), ReputationScore(enode.Reputation{}))
+	require.Equal(t, int64(10-2*peerScoreTimeoutWeight-peerScoreInvalidWeight-3), ReputationScore(enode.Reputation{
+		Useful:    10,
+		Timeouts:  2,
+		Invalid:   1,
+		LatencyMs: 350,
+	}))
+	require.Equal(t, int64(peerScoreUsefulCap), ReputationScore(enode.Reputation{Useful: 10 * peerScoreUsefulCap}))
+}
+
+func TestPeerScores(t *testing.T) {
+	db, err := enode.OpenDB("", t.TempDir())
+	require.NoError(t, err)
+	defer db.Close()
+
+	now := time.Unix(1_700_000_000, 0)
+	scores := NewPeerScores()
+	scores.now = func() time.Time { return now }
+	scores.SetDB(db)
+
+	good, bad := enode.ID{1}, enode.ID{2}
+	require.False(t, scores.Connect(good, "good"))
+	require.False(t, scores.Connect(bad, "bad"))
+
+	scores.Response(good, 20*time.Millisecond)
+	scores.Response(good, 60*time.Millisecond)
+	require.Equal(t, int64(2), scores.Score(good))
+	require.False(t, scores.Timeouts(bad, 10))
+	require.Equal(t, int64(-10*peerScoreTimeoutWeight), scores.Score(bad))
+
+	// the reputation survives reconnects
+	scores.Disconnect(good)
+	require.Equal(t, int64(0), scores.Score(good))
+	require.False(t, scores.Connect(good, "good"))
+	require.Equal(t, int64(2), scores.Score(good))
+
+	// invalid blocks get the peer banned, also while disconnected
+	scores.Disconnect(bad)
+	require.False(t, scores.Invalid(bad))
+	require.False(t, scores.Invalid(bad))
+	require.True(t, scores.Invalid(bad))
+	require.True(t, scores.Banned(bad))
+	require.False(t, scores.Banned(good))
+
+	list := scores.List()
+	require.Len(t, list, 2)
+	for _, score := range list {
+		if score.ID == bad.String() {
+			require.False(t, score.Connected)
+			require.Equal(t, uint64(now.Add(PeerBanDuration).Unix()), score.BannedUntil)
+		} else {
+			require.True(t, score.Connected)
+			require.Equal(t, "good", score.Name)
+			require.Equal(t, uint64(25), score.LatencyMs)
+		}
+	}
+
+	// the ban survives restarts and expires with a clean record
+	scores.Close()
+	scores = NewPeerScores()
+	scores.now = func() time.Time { return now }
+	scores.SetDB(db)
+	require.True(t, scores.Connect(bad, "bad"))
+	scores.Disconnect(bad)
+	now = now.Add(PeerBanDuration + time.Second)
+	require.False(t, scores.Connect(bad, "bad"))
+	require.Equal(t, int64(0), scores.Score(bad))
+}
@@ -298,23 +298,32 @@ func (m *hashMAC) compute(sum1, seed []byte) []byte {
 // Handshake performs the handshake. This must be called before any data is written
 // or read from the connection.
 func (c *Conn) Handshake(prv *ecdsa.PrivateKey) (*ecdsa.PublicKey, error) {
+	remote, _, err := c.HandshakeKeys(prv, nil)
+	return remote, err
+}
+
+// HandshakeKeys performs the handshake like Handshake. On the listening side, the remote
+// node may also address one of the previous keys, e.g. the node key before a rotation.
+// The local key the remote node addressed is returned.
+func (c *Conn) HandshakeKeys(prv *ecdsa.PrivateKey, previous []*ecdsa.PrivateKey) (*ecdsa.PublicKey, *ecdsa.PrivateKey, error) {
 	var (
 		sec Secrets
 		err error
 		h   handshakeState
 	)
+	local := prv
 	if c.dialDest != nil {
 		sec, err = h.runInitiator(c.conn, prv, c.dialDest)
 	} else {
-		sec, err = h.runRecipient(c.conn, prv)
+		sec, local, err = h.runRecipient(c.conn, append([]*ecdsa.PrivateKey{prv}, previous...))
 	}
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
 	c.InitWithSecrets(sec)
 	c.session.rbuf = h.rbuf
 	c.session.wbuf = h.wbuf
-	return sec.remote, err
+	return sec.remote, local, err
 }
 
 // InitWithSecrets injects connection secrets as if a handshake had
@@ -410,30 +419,32 @@ type authRespV4 struct {
 // runRecipient negotiates a session token on conn.
 // it should be called on the listening side of the connection.
 //
-// prv is the local client's private key.
-func (h *handshakeState) runRecipient(conn io.ReadWriter, prv *ecdsa.PrivateKey) (s Secrets, err error) {
+// keys are the local client's private keys, the one the initiator
+// encrypted its auth message to is returned.
+func (h *handshakeState) runRecipient(conn io.ReadWriter, keys []*ecdsa.PrivateKey) (s Secrets, prv *ecdsa.PrivateKey, err error) {
 	authMsg := new(authMsgV4)
-	authPacket, err := h.readMsg(authMsg, prv, conn)
+	authPacket, prv, err := h.readMsgKeys(authMsg, keys, conn)
 	if err != nil {
-		return s, err
+		return s, nil, err
 	}
 	if err := h.handleAuthMsg(authMsg, prv); err != nil {
-		return s, err
+		return s, nil, err
 	}
 
 	authRespMsg, err := h.makeAuthResp()
 	if err != nil {
-		return s, err
+		return s, nil, err
 	}
 	authRespPacket, err := h.sealEIP8(authRespMsg)
 	if err != nil {
-		return s, err
+		return s, nil, err
 	}
 	if _, err = conn.Write(authRespPacket); err != nil {
-		return s, err
+		return s, nil, err
 	}
 
-	return h.secrets(authPacket, authRespPacket)
+	s, err = h.secrets(authPacket, authRespPacket)
+	return s, prv, err
 }
 
 func (h *handshakeState) handleAuthMsg(msg *authMsgV4, prv *ecdsa.PrivateKey) error {
@@ -595,30 +606,45 @@ func (h *handshakeState) makeAuthResp() (msg *authRespV4, err error) {
 
 // readMsg reads an encrypted handshake message, decoding it into msg.
 func (h *handshakeState) readMsg(msg interface{}, prv *ecdsa.PrivateKey, r io.Reader) ([]byte, error) {
+	data, _, err := h.readMsgKeys(msg, []*ecdsa.PrivateKey{prv}, r)
+	return data, err
+}
+
+// readMsgKeys reads a handshake message encrypted to any of the keys, decoding it
+// into msg. The key which decrypted the message is returned.
+func (h *handshakeState) readMsgKeys(msg interface{}, keys []*ecdsa.PrivateKey, r io.Reader) ([]byte, *ecdsa.PrivateKey, error) {
 	h.rbuf.reset()
 	h.rbuf.grow(512)
 
 	// Read the size prefix.
 	prefix, err := h.rbuf.read(r, 2)
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
 	size := binary.BigEndian.Uint16(prefix)
 
 	// Read the handshake packet.
 	packet, err := h.rbuf.read(r, int(size))
 	if err != nil {
-		return nil, err
+		return nil, nil, err
+	}
+	var (
+		dec []byte
+		prv *ecdsa.PrivateKey
+	)
+	for _, prv = range keys {
+		if dec, err = ecies.ImportECDSA(prv).Decrypt(packet, nil, prefix); err == nil {
+			break
+		}
 	}
-	dec, err := ecies.ImportECDSA(prv).Decrypt(packet, nil, prefix)
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
 	// Can't use rlp.DecodeBytes here because it rejects
 	// trailing data (forward-compatibility).
 	s := rlp.NewStream(bytes.NewReader(dec), 0)
 	err = s.Decode(msg)
-	return h.rbuf.data[:len(prefix)+len(packet)], err
+	return h.rbuf.data[:len(prefix)+len(packet)], prv, err
 }
 
 // sealEIP8 encrypts a handshake message.
@@ -48,6 +48,49 @@ func TestHandshake(t *testing.T) {
 	p2.Close()
 }
 
+// This test checks that the listener accepts handshakes addressed to its previous keys.
+func TestHandshakePreviousKey(t *testing.T) {
+	dialerKey, current, previous := newkey(), newkey(), newkey()
+	for _, remote := range []*ecdsa.PrivateKey{current, previous} {
+		conn1, conn2 := net.Pipe()
+		dialer, listener := NewConn(conn1, &remote.PublicKey), NewConn(conn2, nil)
+		type result struct {
+			local *ecdsa.PrivateKey
+			err   error
+		}
+		done := make(chan result, 1)
+		go func() {
+			_, local, err := listener.HandshakeKeys(current, []*ecdsa.PrivateKey{previous})
+			done <- result{local, err}
+		}()
+		if _, err := dialer.Handshake(dialerKey); err != nil {
+			t.Fatal("dialer handshake error:", err)
+		}
+		res := <-done
+		if res.err != nil {
+			t.Fatal("listener handshake error:", res.err)
+		}
+		if res.local != remote {
+			t.Fatal("wrong local key returned")
+		}
+		checkMsgReadWrite(t, listener, dialer, 1, []byte("test"))
+		dialer.Close()
+		listener.Close()
+	}
+
+	// Keys the listener doesn't have are rejected.
+	conn1, conn2 := net.Pipe()
+	dialer, listener := NewConn(conn1, &newkey().PublicKey), NewConn(conn2, nil)
+	go func() {
+		_, _ = dialer.Handshake(dialerKey)
+		dialer.Close()
+	}()
+	if _, _, err := listener.HandshakeKeys(current, []*ecdsa.PrivateKey{previous}); err == nil {
+		t.Fatal("handshake to an unknown key succeeded")
+	}
+	listener.Close()
+}
+
 // This test checks that messages can be sent and received through WriteMsg/ReadMsg.
 func TestReadWriteMsg(t *testing.T) {
 	peer1, peer2 := createPeers(t)
@@ -44,6 +44,7 @@ import (
 	"github.com/ledgerwatch/erigon/p2p/enr"
 	"github.com/ledgerwatch/erigon/p2p/nat"
 	"github.com/ledgerwatch/erigon/p2p/netutil"
+	"github.com/ledgerwatch/erigon/p2p/txpolicy"
 )
 
 const (
@@ -75,6 +76,14 @@ type Config struct {
 	// This field must be set to a valid secp256k1 private key.
 	PrivateKey *ecdsa.PrivateKey `toml:"-"`
 
+	// PreviousKeys are the node keys replaced by rotations. The server keeps
+	// accepting connections to their identities until they expire.
+	PreviousKeys []PreviousNodeKey `toml:"-"`
+
+	// NodeKeyFile is the file PrivateKey was loaded from, the rotations of the
+	// key are saved there. It is empty if the key wasn't loaded from a file.
+	NodeKeyFile string `toml:"-"`
+
 	// MaxPeers is the maximum number of peers that can be
 	// connected. It must be greater than zero.
 	MaxPeers int
@@ -161,6 +170,30 @@ type Config struct {
 	// If NoDial is true, the server will not dial any peers.
 	NoDial bool `toml:",omitempty"`
 
+	// If DialFilter is set to a non-nil value, only the nodes from the discovery
+	// sources it accepts are dialed, e.g. the ones on the right fork.
+	DialFilter func(*enode.Node) bool `toml:"-"`
+
+	// ENRFilter are predicates on the node records of the nodes from the discovery
+	// sources, all of which must hold for a node to be dialed, see ParseENRFilter.
+	ENRFilter []string `toml:",omitempty"`
+
+	// TxBroadcast is how the sentry broadcasts the transactions in full besides
+	// their announcements, see the txpolicy broadcast modes.
+	TxBroadcast string `toml:",omitempty"`
+
+	// TxPeerRate caps the transactions and the announcements the sentry sends to
+	// a peer per second, unlimited if 0.
+	TxPeerRate int `toml:",omitempty"`
+
+	// PrivateTxs is the registry of the transactions the sentry never sends to
+	// its peers, shared with the RPC daemon of the process. Nil if none is.
+	PrivateTxs *txpolicy.PrivateTxs `toml:"-"`
+
+	// SentryIdentities gives every sentry of the node but the first its own node
+	// key, kept in the node database directory of the sentry.
+	SentryIdentities bool `toml:",omitempty"`
+
 	// If EnableMsgEvents is set then the server will emit PeerEvents
 	// whenever a message is sent to or received from a peer
 	EnableMsgEvents bool
@@ -187,6 +220,10 @@ type Server struct {
 	lock    sync.Mutex // protects running
 	running bool
 
+	keyLock      sync.Mutex // protects key and previousKeys
+	key          *ecdsa.PrivateKey
+	previousKeys []PreviousNodeKey
+
 	listener     net.Listener
 	ourHandshake *protoHandshake
 	loopWG       sync.WaitGroup // loop, listenLoop
@@ -198,7 +235,9 @@ type Server struct {
 	localnodeAddrCache atomic.Pointer[string]
 	ntab               *discover.UDPv4
 	DiscV5             *discover.UDPv5
+	discoveryAddr      *net.UDPAddr // address the discovery is bound to
 	discmix            *enode.FairMix
+	dialFilter         *dialFilter
 	dialsched          *dialScheduler
 
 	// Channels into the run loop.
@@ -249,7 +288,7 @@ type conn struct {
 
 type transport interface {
 	// The two handshakes.
-	doEncHandshake(prv *ecdsa.PrivateKey) (*ecdsa.PublicKey, error)
+	doEncHandshake(prv *ecdsa.PrivateKey, previous []*ecdsa.PrivateKey) (remote *ecdsa.PublicKey, local *ecdsa.PrivateKey, err error)
 	doProtoHandshake(our *protoHandshake) (*protoHandshake, error)
 	// The MsgReadWriter can only be used after the encryption
 	// handshake has completed. The code uses conn.id to track this
@@ -315,6 +354,11 @@ func (srv *Server) LocalNode() *enode.LocalNode {
 	return srv.localnode
 }
 
+// NodeDB returns the node database, nil before the server is started.
+func (srv *Server) NodeDB() *enode.DB {
+	return srv.nodedb
+}
+
 // Peers returns all connected peers.
 func (srv *Server) Peers() []*Peer {
 	var ps []*Peer
@@ -410,6 +454,85 @@ func (srv *Server) Self() *enode.Node {
 	return ln.Node()
 }
 
+// NodeKey returns the current node key of the server, which differs from
+// Config.PrivateKey once the key is rotated.
+func (srv *Server) NodeKey() *ecdsa.PrivateKey {
+	srv.keyLock.Lock()
+	defer srv.keyLock.Unlock()
+	if srv.key == nil {
+		return srv.PrivateKey
+	}
+	return srv.key
+}
+
+// nodeKeys returns the current node key and the previous keys which haven't expired yet.
+func (srv *Server) nodeKeys() (*ecdsa.PrivateKey, []*ecdsa.PrivateKey) {
+	srv.keyLock.Lock()
+	defer srv.keyLock.Unlock()
+	srv.previousKeys = unexpiredNodeKeys(srv.previousKeys, time.Now())
+	previous := make([]*ecdsa.PrivateKey, len(srv.previousKeys))
+	for i, k := range srv.previousKeys {
+		previous[i] = k.Key
+	}
+	return srv.key, previous
+}
+
+// RotateKey switches the running server to a new node key. The server keeps accepting
+// connections to the identity of the replaced key for the grace period, the connected peers
+// stay connected. New connections are dialed with the new identity right away, the local
+// node record continues its sequence number under the new key and the discovery restarts
+// with the new identity.
+func (srv *Server) RotateKey(key *ecdsa.PrivateKey, grace time.Duration) error {
+	srv.lock.Lock()
+	defer srv.lock.Unlock()
+	if !srv.running {
+		return errServerStopped
+	}
+
+	srv.keyLock.Lock()
+	if grace > 0 {
+		srv.previousKeys = append(srv.previousKeys, PreviousNodeKey{Key: srv.key, Expires: time.Now().Add(grace)})
+	}
+	srv.key = key
+	srv.keyLock.Unlock()
+
+	discovering := srv.ntab != nil || srv.DiscV5 != nil
+	if srv.ntab != nil {
+		srv.ntab.Close()
+		srv.ntab = nil
+	}
+	if srv.DiscV5 != nil {
+		srv.DiscV5.Close()
+		srv.DiscV5 = nil
+	}
+	srv.localnode.SetKey(key)
+	srv.updateLocalNodeStaticAddrCache()
+	srv.logger.Info("Rotated node key", "self", srv.localnode.Node().URLv4(), "grace", grace)
+	if !discovering {
+		return nil
+	}
+	if err := srv.listenDiscovery(srv.quitCtx, false); err != nil {
+		return fmt.Errorf("restart discovery: %w", err)
+	}
+	return nil
+}
+
+// discoveryResolver resolves the dial destinations with the current discovery table,
+// which is replaced when the node key is rotated.
+type discoveryResolver struct {
+	srv *Server
+}
+
+func (r discoveryResolver) Resolve(n *enode.Node) *enode.Node {
+	r.srv.lock.Lock()
+	ntab := r.srv.ntab
+	r.srv.lock.Unlock()
+	if ntab == nil {
+		return n
+	}
+	return ntab.Resolve(n)
+}
+
 // Stop terminates the server and all active peer connections.
 // It blocks until all active connections have been closed.
 func (srv *Server) Stop() {
@@ -486,6 +609,8 @@ func (srv *Server) Start(ctx context.Context, logger log.Logger) error {
 	if srv.PrivateKey == nil {
 		return errors.New("Server.PrivateKey must be set to a non-nil key")
 	}
+	srv.key = srv.PrivateKey
+	srv.previousKeys = unexpiredNodeKeys(srv.PreviousKeys, time.Now())
 	if srv.MaxPendingPeers <= 0 {
 		return errors.New("MaxPendingPeers must be greater than zero")
 	}
@@ -543,7 +668,13 @@ func (srv *Server) setupLocalNode() error {
 		return err
 	}
 	srv.nodedb = db
-	srv.localnode = enode.NewLocalNode(db, srv.PrivateKey, srv.logger)
+	if n := len(srv.previousKeys); n > 0 {
+		// Continue the record sequence of the last identity
+		srv.localnode = enode.NewLocalNode(db, srv.previousKeys[n-1].Key, srv.logger)
+		srv.localnode.SetKey(srv.PrivateKey)
+	} else {
+		srv.localnode = enode.NewLocalNode(db, srv.PrivateKey, srv.logger)
+	}
 	srv.localnode.SetFallbackIP(net.IP{127, 0, 0, 1})
 	srv.updateLocalNodeStaticAddrCache()
 	// TODO: check conflicts
@@ -578,12 +709,16 @@ func (srv *Server) setupLocalNode() error {
 
 func (srv *Server) setupDiscovery(ctx context.Context) error {
 	srv.discmix = enode.NewFairMix(discmixTimeout)
+	var err error
+	if srv.dialFilter, err = newDialFilter(&srv.Config); err != nil {
+		return err
+	}
 
 	// Add protocol-specific discovery sources.
 	added := make(map[string]bool)
 	for _, proto := range srv.Protocols {
 		if proto.DialCandidates != nil && !added[proto.Name] {
-			srv.discmix.AddSource(proto.DialCandidates)
+			srv.discmix.AddSource(srv.dialFilter.apply(proto.DialCandidates))
 			added[proto.Name] = true
 		}
 	}
@@ -592,18 +727,27 @@ func (srv *Server) setupDiscovery(ctx context.Context) error {
 	if srv.NoDiscovery && !srv.DiscoveryV5 {
 		return nil
 	}
+	return srv.listenDiscovery(ctx, true)
+}
 
-	addr, err := net.ResolveUDPAddr("udp", srv.ListenAddr)
-	if err != nil {
-		return err
+// listenDiscovery starts the discovery protocols with the current node key.
+func (srv *Server) listenDiscovery(ctx context.Context, mapPort bool) error {
+	// The discovery restarts on the port it was bound to before, which is not the configured one if that is 0.
+	addr := srv.discoveryAddr
+	if addr == nil {
+		var err error
+		if addr, err = net.ResolveUDPAddr("udp", srv.ListenAddr); err != nil {
+			return err
+		}
 	}
 	conn, err := net.ListenUDP("udp", addr)
 	if err != nil {
 		return err
 	}
 	realaddr := conn.LocalAddr().(*net.UDPAddr)
+	srv.discoveryAddr = realaddr
 	srv.logger.Trace("UDP listener up", "addr", realaddr)
-	if srv.NAT != nil {
+	if srv.NAT != nil && mapPort {
 		if !realaddr.IP.IsLoopback() && srv.NAT.SupportsMapping() {
 			srv.loopWG.Add(1)
 			go func() {
@@ -614,6 +758,7 @@ func (srv *Server) setupDiscovery(ctx context.Context) error {
 		}
 	}
 	srv.localnode.SetFallbackUDP(realaddr.Port)
+	key := srv.NodeKey()
 
 	// Discovery V4
 	var unhandled chan discover.ReadPacket
@@ -624,7 +769,7 @@ func (srv *Server) setupDiscovery(ctx context.Context) error {
 			sconn = &sharedUDPConn{conn, unhandled}
 		}
 		cfg := discover.Config{
-			PrivateKey:  srv.PrivateKey,
+			PrivateKey:  key,
 			NetRestrict: srv.NetRestrict,
 			Bootnodes:   srv.BootstrapNodes,
 			Unhandled:   unhandled,
@@ -635,13 +780,13 @@ func (srv *Server) setupDiscovery(ctx context.Context) error {
 			return err
 		}
 		srv.ntab = ntab
-		srv.discmix.AddSource(ntab.RandomNodes())
+		srv.discmix.AddSource(srv.dialFilter.apply(ntab.RandomNodes()))
 	}
 
 	// Discovery V5
 	if srv.DiscoveryV5 {
 		cfg := discover.Config{
-			PrivateKey:  srv.PrivateKey,
+			PrivateKey:  key,
 			NetRestrict: srv.NetRestrict,
 			Bootnodes:   srv.BootstrapNodesV5,
 			Log:         srv.logger,
@@ -659,9 +804,29 @@ func (srv *Server) setupDiscovery(ctx context.Context) error {
 	return nil
 }
 
+// DiscoveryStats returns the state of the node tables of the discovery protocols
+// and the number of dial candidates accepted and rejected by the dial filters.
+func (srv *Server) DiscoveryStats() *DiscoveryStats {
+	srv.lock.Lock()
+	defer srv.lock.Unlock()
+	stats := &DiscoveryStats{}
+	if srv.ntab != nil {
+		v4 := srv.ntab.TableStats()
+		stats.V4 = &v4
+	}
+	if srv.DiscV5 != nil {
+		v5 := srv.DiscV5.TableStats()
+		stats.V5 = &v5
+	}
+	if srv.dialFilter != nil {
+		stats.DialFilter = srv.dialFilter.stats()
+	}
+	return stats
+}
+
 func (srv *Server) setupDialScheduler() {
 	config := dialConfig{
-		self:           srv.localnode.ID(),
+		self:           srv.localnode.ID,
 		maxDialPeers:   srv.maxDialedConns(),
 		maxActiveDials: srv.MaxPendingPeers,
 		log:            srv.logger,
@@ -670,7 +835,7 @@ func (srv *Server) setupDialScheduler() {
 		clock:          srv.clock,
 	}
 	if srv.ntab != nil {
-		config.resolver = srv.ntab
+		config.resolver = discoveryResolver{srv}
 	}
 	if config.dialer == nil {
 		config.dialer = tcpDialer{&net.Dialer{Timeout: defaultDialTimeout}}
@@ -835,12 +1000,14 @@ running:
 	srv.logger.Trace("P2P networking is spinning down")
 
 	// Terminate discovery. If there is a running lookup it will terminate soon.
+	srv.lock.Lock()
 	if srv.ntab != nil {
 		srv.ntab.Close()
 	}
 	if srv.DiscV5 != nil {
 		srv.DiscV5.Close()
 	}
+	srv.lock.Unlock()
 	// Disconnect all peers.
 	for _, p := range peers {
 		p.Disconnect(DiscQuitting)
@@ -1003,7 +1170,8 @@ func (srv *Server) setupConn(c *conn, flags connFlag, dialDest *enode.Node) erro
 	}
 
 	// Run the RLPx handshake.
-	remotePubkey, err := c.doEncHandshake(srv.PrivateKey)
+	key, previous := srv.nodeKeys()
+	remotePubkey, localKey, err := c.doEncHandshake(key, previous)
 	if err != nil {
 		srv.logger.Trace("Failed RLPx handshake", "addr", c.fd.RemoteAddr(), "conn", c.flags, "err", err)
 		return err
@@ -1021,8 +1189,10 @@ func (srv *Server) setupConn(c *conn, flags connFlag, dialDest *enode.Node) erro
 		return err
 	}
 
-	// Run the capability negotiation handshake.
-	phs, err := c.doProtoHandshake(srv.ourHandshake)
+	// Run the capability negotiation handshake, under the identity the remote node addressed.
+	our := *srv.ourHandshake
+	our.Pubkey = crypto.MarshalPubkey(&localKey.PublicKey)
+	phs, err := c.doProtoHandshake(&our)
 	if err != nil {
 		clog.Trace("Failed p2p handshake", "err", err)
 		return err
@@ -1120,6 +1290,8 @@ type NodeInfo struct {
 	} `json:"ports"`
 	ListenAddr string                 `json:"listenAddr"`
 	Protocols  map[string]interface{} `json:"protocols"`
+	Discovery  *DiscoveryStats        `json:"discovery,omitempty"`
+	PeerScores []*PeerScore           `json:"-"` // Filled in from the sentry, served by admin_peerScores
 }
 
 // NodeInfo gathers and returns a collection of metadata known about the host.
@@ -1137,6 +1309,7 @@ func (srv *Server) NodeInfo() *NodeInfo {
 	info.Ports.Discovery = node.UDP()
 	info.Ports.Listener = node.TCP()
 	info.ENR = node.String()
+	info.Discovery = srv.DiscoveryStats()
 
 	// Gather all the running protocol infos (only once per protocol type)
 	for _, proto := range srv.Protocols {
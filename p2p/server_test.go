@@ -53,8 +53,8 @@ func newTestTransport(rpub *ecdsa.PublicKey, fd net.Conn, dialDest *ecdsa.Public
 	return &testTransport{rpub: rpub, rlpxTransport: wrapped}
 }
 
-func (c *testTransport) doEncHandshake(prv *ecdsa.PrivateKey) (*ecdsa.PublicKey, error) {
-	return c.rpub, nil
+func (c *testTransport) doEncHandshake(prv *ecdsa.PrivateKey, previous []*ecdsa.PrivateKey) (*ecdsa.PublicKey, *ecdsa.PrivateKey, error) {
+	return c.rpub, prv, nil
 }
 
 func (c *testTransport) doProtoHandshake(our *protoHandshake) (*protoHandshake, error) {
@@ -488,11 +488,11 @@ type setupTransport struct {
 	lock     sync.Mutex
 }
 
-func (c *setupTransport) doEncHandshake(prv *ecdsa.PrivateKey) (*ecdsa.PublicKey, error) {
+func (c *setupTransport) doEncHandshake(prv *ecdsa.PrivateKey, previous []*ecdsa.PrivateKey) (*ecdsa.PublicKey, *ecdsa.PrivateKey, error) {
 	c.lock.Lock()
 	defer c.lock.Unlock()
 	c.calls += "doEncHandshake,"
-	return c.pubkey, c.encHandshakeErr
+	return c.pubkey, prv, c.encHandshakeErr
 }
 
 func (c *setupTransport) doProtoHandshake(our *protoHandshake) (*protoHandshake, error) {
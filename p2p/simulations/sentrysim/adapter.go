@@ -0,0 +1,75 @@
+// Package sentrysim is a simulations adapter running networks of Erigon nodes in-process. Every node has a real
+// sentry speaking the eth protocol on the loopback interface, and a mock chain backend from turbo/stages which
+// downloads, executes and propagates blocks through it, so that partitions, eclipse attempts, slow peers and fork
+// propagation can be scripted and asserted on in Go tests.
+package sentrysim
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/ledgerwatch/log/v3"
+
+	"github.com/ledgerwatch/erigon/core/types"
+	"github.com/ledgerwatch/erigon/p2p"
+	"github.com/ledgerwatch/erigon/p2p/enode"
+	"github.com/ledgerwatch/erigon/p2p/simulations/adapters"
+)
+
+// Adapter creates the nodes of a simulation, all of them sharing the same genesis. The lifecycles of the node
+// configurations are ignored, the nodes always run the eth protocol.
+type Adapter struct {
+	tb      testing.TB
+	genesis *types.Genesis
+	links   *links
+	logger  log.Logger
+
+	// P2PConfig, if set, adjusts the p2p configuration of the sentry of a node before the node starts, e.g. its
+	// peer limit.
+	P2PConfig func(node *adapters.NodeConfig, cfg *p2p.Config)
+}
+
+// NewAdapter creates an adapter for the nodes of a test, which are stopped when the test finishes.
+func NewAdapter(tb testing.TB, genesis *types.Genesis) *Adapter {
+	return &Adapter{tb: tb, genesis: genesis, links: newLinks(), logger: log.New()}
+}
+
+// Name returns the name of the adapter for logging purposes.
+func (a *Adapter) Name() string {
+	return "sentry"
+}
+
+// NewNode creates a node with the private key, the name and the port of a configuration, see
+// adapters.RandomNodeConfig.
+func (a *Adapter) NewNode(config *adapters.NodeConfig) (adapters.Node, error) {
+	if config.PrivateKey == nil {
+		return nil, errors.New("node has no private key")
+	}
+	if id := enode.PubkeyToIDV4(&config.PrivateKey.PublicKey); id != config.ID {
+		return nil, fmt.Errorf("node ID %v doesn't match the private key, expected %v", config.ID, id)
+	}
+	if config.Port == 0 {
+		return nil, errors.New("node has no port")
+	}
+	return &Node{adapter: a, config: config, logger: a.logger.New("node", config.Name)}, nil
+}
+
+// Partition disconnects the nodes of different groups from each other and prevents them from connecting again
+// until Heal. Nodes which are in none of the groups are not affected.
+func (a *Adapter) Partition(groups ...[]enode.ID) {
+	a.links.block(groups)
+}
+
+// Heal allows all the nodes to connect to each other again. The static peers are dialed again only after the dial
+// history of their nodes expires, connect the other way round to reconnect them right away.
+func (a *Adapter) Heal() {
+	a.links.unblockAll()
+}
+
+// SetLatency slows down the link between two nodes, every read and write of their connection waits for the
+// latency. The latency of 0 restores the link.
+func (a *Adapter) SetLatency(one, other enode.ID, latency time.Duration) {
+	a.links.setLatency(makeLinkKey(one, other), latency)
+}
@@ -0,0 +1,166 @@
+package sentrysim
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"net"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/ledgerwatch/erigon/p2p/enode"
+)
+
+var errLinkBlocked = errors.New("link is partitioned")
+
+// linkKey identifies the link between two nodes, whichever of them dialed the other.
+type linkKey [2]enode.ID
+
+func makeLinkKey(one, other enode.ID) linkKey {
+	if bytes.Compare(one[:], other[:]) > 0 {
+		one, other = other, one
+	}
+	return linkKey{one, other}
+}
+
+// links are the connections between the nodes of a simulation. Every connection is dialed through a linkDialer,
+// so that links can be blocked to partition the network and slowed down.
+type links struct {
+	lock    sync.Mutex
+	blocked map[linkKey]bool
+	latency map[linkKey]time.Duration
+	conns   map[linkKey]map[*linkConn]struct{}
+}
+
+func newLinks() *links {
+	return &links{
+		blocked: make(map[linkKey]bool),
+		latency: make(map[linkKey]time.Duration),
+		conns:   make(map[linkKey]map[*linkConn]struct{}),
+	}
+}
+
+// block blocks the links between the nodes of different groups and closes their connections.
+func (l *links) block(groups [][]enode.ID) {
+	l.lock.Lock()
+	var closing []*linkConn
+	for i, group := range groups {
+		for _, other := range groups[i+1:] {
+			for _, one := range group {
+				for _, two := range other {
+					key := makeLinkKey(one, two)
+					l.blocked[key] = true
+					for conn := range l.conns[key] {
+						closing = append(closing, conn)
+					}
+				}
+			}
+		}
+	}
+	l.lock.Unlock()
+
+	for _, conn := range closing {
+		conn.Close()
+	}
+}
+
+func (l *links) unblockAll() {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+	l.blocked = make(map[linkKey]bool)
+}
+
+func (l *links) setLatency(key linkKey, latency time.Duration) {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+	if latency <= 0 {
+		delete(l.latency, key)
+	} else {
+		l.latency[key] = latency
+	}
+}
+
+func (l *links) getLatency(key linkKey) time.Duration {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+	return l.latency[key]
+}
+
+// add tracks a connection dialed over a link, unless the link got blocked while dialing.
+func (l *links) add(key linkKey, conn net.Conn) (*linkConn, error) {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+	if l.blocked[key] {
+		conn.Close()
+		return nil, errLinkBlocked
+	}
+	c := &linkConn{Conn: conn, links: l, key: key}
+	if l.conns[key] == nil {
+		l.conns[key] = make(map[*linkConn]struct{})
+	}
+	l.conns[key][c] = struct{}{}
+	return c, nil
+}
+
+func (l *links) remove(c *linkConn) {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+	delete(l.conns[c.key], c)
+	if len(l.conns[c.key]) == 0 {
+		delete(l.conns, c.key)
+	}
+}
+
+// linkDialer is the p2p dialer of a node, dialing the other nodes over the links of the simulation.
+type linkDialer struct {
+	links *links
+	self  enode.ID
+	net.Dialer
+}
+
+func (d *linkDialer) Dial(ctx context.Context, dest *enode.Node) (net.Conn, error) {
+	key := makeLinkKey(d.self, dest.ID())
+	d.links.lock.Lock()
+	blocked := d.links.blocked[key]
+	d.links.lock.Unlock()
+	if blocked {
+		return nil, errLinkBlocked
+	}
+	conn, err := d.DialContext(ctx, "tcp", net.JoinHostPort(dest.IP().String(), strconv.Itoa(dest.TCP())))
+	if err != nil {
+		return nil, err
+	}
+	return d.links.add(key, conn)
+}
+
+// linkConn is a connection over a link, each of its reads and writes is delayed by the latency of the link, so
+// that both directions are slowed down.
+type linkConn struct {
+	net.Conn
+	links     *links
+	key       linkKey
+	closeOnce sync.Once
+}
+
+func (c *linkConn) Read(b []byte) (int, error) {
+	n, err := c.Conn.Read(b)
+	if n > 0 {
+		if latency := c.links.getLatency(c.key); latency > 0 {
+			time.Sleep(latency)
+		}
+	}
+	return n, err
+}
+
+func (c *linkConn) Write(b []byte) (int, error) {
+	if latency := c.links.getLatency(c.key); latency > 0 {
+		time.Sleep(latency)
+	}
+	return c.Conn.Write(b)
+}
+
+func (c *linkConn) Close() error {
+	c.closeOnce.Do(func() { c.links.remove(c) })
+	return c.Conn.Close()
+}
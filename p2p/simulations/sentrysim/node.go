@@ -0,0 +1,324 @@
+package sentrysim
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"sync"
+	"time"
+
+	"github.com/gorilla/websocket"
+	libcommon "github.com/ledgerwatch/erigon-lib/common"
+	"github.com/ledgerwatch/erigon-lib/direct"
+	"github.com/ledgerwatch/erigon-lib/kv"
+	"github.com/ledgerwatch/log/v3"
+	"google.golang.org/protobuf/types/known/emptypb"
+
+	"github.com/ledgerwatch/erigon/cmd/sentry/sentry"
+	"github.com/ledgerwatch/erigon/consensus/ethash"
+	"github.com/ledgerwatch/erigon/core/rawdb"
+	"github.com/ledgerwatch/erigon/core/types"
+	"github.com/ledgerwatch/erigon/eth/protocols/eth"
+	"github.com/ledgerwatch/erigon/p2p"
+	"github.com/ledgerwatch/erigon/p2p/enode"
+	"github.com/ledgerwatch/erigon/p2p/simulations/adapters"
+	"github.com/ledgerwatch/erigon/rpc"
+	"github.com/ledgerwatch/erigon/turbo/stages"
+	"github.com/ledgerwatch/erigon/turbo/stages/headerdownload"
+)
+
+const (
+	networkID       = 1 // Network ID of the mock chain backends
+	startTimeout    = 10 * time.Second
+	headPollTimeout = 30 * time.Second
+)
+
+// Node is a node of a simulation: a sentry with its p2p server, and a mock chain backend running the staged sync
+// loop. It serves the admin_addPeer, admin_removePeer and admin_peerEvents RPC methods the simulation network
+// drives its nodes with. Nodes can't be restarted once stopped.
+type Node struct {
+	adapter *Adapter
+	config  *adapters.NodeConfig
+	logger  log.Logger
+
+	lock     sync.RWMutex
+	cancel   context.CancelFunc
+	sentry   *sentry.GrpcServer
+	server   *p2p.Server
+	mock     *stages.MockSentry
+	rpc      *rpc.Server
+	client   *rpc.Client
+	loopDone chan struct{}
+	stopped  bool
+}
+
+// Addr returns the enode URL of the node.
+func (n *Node) Addr() []byte {
+	return []byte(n.Self().URLv4())
+}
+
+// Self returns the node record of the node, listening on the loopback interface.
+func (n *Node) Self() *enode.Node {
+	return enode.NewV4(&n.config.PrivateKey.PublicKey, net.IPv4(127, 0, 0, 1), int(n.config.Port), 0)
+}
+
+// Client returns the RPC client of the node once it is started.
+func (n *Node) Client() (*rpc.Client, error) {
+	n.lock.RLock()
+	defer n.lock.RUnlock()
+	if n.client == nil {
+		return nil, errors.New("node not started")
+	}
+	return n.client, nil
+}
+
+// ServeRPC serves the RPC requests of a websocket connection.
+func (n *Node) ServeRPC(conn *websocket.Conn) error {
+	n.lock.RLock()
+	server := n.rpc
+	n.lock.RUnlock()
+	if server == nil {
+		return errors.New("node not started")
+	}
+	server.ServeCodec(rpc.NewFuncCodec(conn, conn.WriteJSON, conn.ReadJSON), 0)
+	return nil
+}
+
+// Start starts the sentry and the staged sync loop of the node, it returns once the p2p server listens.
+func (n *Node) Start(snapshots map[string][]byte) error {
+	n.lock.Lock()
+	defer n.lock.Unlock()
+	if n.stopped || n.mock != nil {
+		return errors.New("node already started")
+	}
+	if len(snapshots) > 0 {
+		return errors.New("snapshots are not supported")
+	}
+
+	cfg := &p2p.Config{
+		PrivateKey:      n.config.PrivateKey,
+		Name:            n.config.Name,
+		MaxPeers:        32,
+		MaxPendingPeers: 8,
+		NoDiscovery:     true,
+		ListenAddr:      fmt.Sprintf("127.0.0.1:%d", n.config.Port),
+		Dialer:          &linkDialer{links: n.adapter.links, self: n.config.ID},
+		EnableMsgEvents: n.config.EnableMsgEvents,
+		TmpDir:          n.adapter.tb.TempDir(),
+	}
+	if n.adapter.P2PConfig != nil {
+		n.adapter.P2PConfig(n.config, cfg)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	var mock *stages.MockSentry
+	readNodeInfo := func() *eth.NodeInfo {
+		var info *eth.NodeInfo
+		_ = mock.DB.View(ctx, func(tx kv.Tx) error {
+			info = eth.ReadNodeInfo(tx, mock.ChainConfig, mock.Genesis.Hash(), networkID)
+			return nil
+		})
+		return info
+	}
+	sentryServer := sentry.NewGrpcServer(ctx, nil, readNodeInfo, cfg, direct.ETH68, n.logger)
+	mock = stages.MockWithSentry(n.adapter.tb, n.adapter.genesis, n.config.PrivateKey, ethash.NewFaker(), sentryServer)
+	// Registered after the cleanups of the mock, so that the node stops before its database is closed
+	n.adapter.tb.Cleanup(func() { _ = n.Stop() })
+
+	// The p2p server starts when the stream loops of the mock send the first status to the sentry
+	deadline := time.Now().Add(startTimeout)
+	for {
+		if _, err := sentryServer.NodeInfo(ctx, &emptypb.Empty{}); err == nil {
+			break
+		} else if time.Now().After(deadline) {
+			cancel()
+			mock.Close()
+			return fmt.Errorf("start sentry: %w", err)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	rpcServer := rpc.NewServer(1, false /* traceRequests */, false /* disableStreaming */, n.logger)
+	if err := rpcServer.RegisterName("admin", &adminAPI{server: sentryServer.P2pServer}); err != nil {
+		cancel()
+		sentryServer.Close()
+		mock.Close()
+		return err
+	}
+
+	hook := stages.NewHook(ctx, mock.Notifications, mock.Sync, mock.BlockReader, mock.ChainConfig, n.logger, mock.UpdateHead)
+	n.loopDone = make(chan struct{})
+	go stages.StageLoop(ctx, mock.DB, mock.Sync, mock.HeaderDownload(), n.loopDone, 0, n.logger, mock.BlockReader, hook)
+
+	n.cancel = cancel
+	n.sentry = sentryServer
+	n.server = sentryServer.P2pServer
+	n.mock = mock
+	n.rpc = rpcServer
+	n.client = rpc.DialInProc(rpcServer, n.logger)
+	return nil
+}
+
+// Stop stops the node and closes its chain database.
+func (n *Node) Stop() error {
+	n.lock.Lock()
+	defer n.lock.Unlock()
+	if n.mock == nil {
+		return nil
+	}
+	n.client.Close()
+	n.rpc.Stop()
+	n.cancel()
+	n.sentry.Close()
+	<-n.loopDone
+	n.mock.Close()
+
+	n.client, n.rpc, n.sentry, n.server, n.mock = nil, nil, nil, nil, nil
+	n.stopped = true
+	return nil
+}
+
+// NodeInfo returns information about the node.
+func (n *Node) NodeInfo() *p2p.NodeInfo {
+	if server := n.Server(); server != nil {
+		return server.NodeInfo()
+	}
+	return &p2p.NodeInfo{
+		ID:    n.config.ID.String(),
+		Name:  n.config.Name,
+		Enode: n.Self().URLv4(),
+	}
+}
+
+// Snapshots is not supported, the chain of a node can't be saved.
+func (n *Node) Snapshots() (map[string][]byte, error) {
+	return nil, errors.New("snapshots are not supported")
+}
+
+// Server returns the p2p server of the sentry of the node, nil if the node isn't running.
+func (n *Node) Server() *p2p.Server {
+	n.lock.RLock()
+	defer n.lock.RUnlock()
+	return n.server
+}
+
+// Mock returns the chain backend of the node, nil if the node isn't running.
+func (n *Node) Mock() *stages.MockSentry {
+	n.lock.RLock()
+	defer n.lock.RUnlock()
+	return n.mock
+}
+
+// Head returns the number and the hash of the head block of the node.
+func (n *Node) Head() (number uint64, hash libcommon.Hash, err error) {
+	mock := n.Mock()
+	if mock == nil {
+		return 0, libcommon.Hash{}, errors.New("node not started")
+	}
+	err = mock.DB.View(context.Background(), func(tx kv.Tx) error {
+		hash = rawdb.ReadHeadBlockHash(tx)
+		if num := rawdb.ReadHeaderNumber(tx, hash); num != nil {
+			number = *num
+		}
+		return nil
+	})
+	return number, hash, err
+}
+
+// MineBlocks adds blocks as if the node mined them: the staged sync loop inserts them, and once they are the head
+// of the node their hashes are announced to all its peers. The blocks must extend the chain of the node and become
+// its head, e.g. generated with core.GenerateChain on top of its head.
+func (n *Node) MineBlocks(blocks []*types.Block) error {
+	mock := n.Mock()
+	if mock == nil {
+		return errors.New("node not started")
+	}
+	if len(blocks) == 0 {
+		return nil
+	}
+	client := mock.MultiClient()
+	for _, block := range blocks {
+		if err := client.Hd.AddMinedHeader(block.Header()); err != nil {
+			return err
+		}
+		client.Bd.AddToPrefetch(block.Header(), block.RawBody())
+	}
+
+	top := blocks[len(blocks)-1]
+	if err := n.WaitHead(top.Hash(), headPollTimeout); err != nil {
+		return err
+	}
+	client.PropagateNewBlockHashes(mock.Ctx, []headerdownload.Announce{{Number: top.NumberU64(), Hash: top.Hash()}})
+	return nil
+}
+
+// WaitHead waits until the head block of the node has the given hash.
+func (n *Node) WaitHead(hash libcommon.Hash, timeout time.Duration) error {
+	deadline := time.Now().Add(timeout)
+	for {
+		_, head, err := n.Head()
+		if err != nil {
+			return err
+		}
+		if head == hash {
+			return nil
+		}
+		if time.Now().After(deadline) {
+			return fmt.Errorf("node %s: head %x, expected %x", n.config.Name, head, hash)
+		}
+		time.Sleep(50 * time.Millisecond)
+	}
+}
+
+// adminAPI is the part of the admin RPC API the simulation network drives the nodes with.
+type adminAPI struct {
+	server *p2p.Server
+}
+
+// AddPeer requests connecting to a remote node, and maintaining the connection.
+func (api *adminAPI) AddPeer(url string) (bool, error) {
+	node, err := enode.Parse(enode.ValidSchemes, url)
+	if err != nil {
+		return false, fmt.Errorf("invalid enode: %w", err)
+	}
+	api.server.AddPeer(node)
+	return true, nil
+}
+
+// RemovePeer disconnects from a remote node if the connection exists.
+func (api *adminAPI) RemovePeer(url string) (bool, error) {
+	node, err := enode.Parse(enode.ValidSchemes, url)
+	if err != nil {
+		return false, fmt.Errorf("invalid enode: %w", err)
+	}
+	api.server.RemovePeer(node)
+	return true, nil
+}
+
+// PeerEvents creates an RPC subscription which receives the peer events of the p2p server.
+func (api *adminAPI) PeerEvents(ctx context.Context) (*rpc.Subscription, error) {
+	notifier, supported := rpc.NotifierFromContext(ctx)
+	if !supported {
+		return nil, rpc.ErrNotificationsUnsupported
+	}
+	rpcSub := notifier.CreateSubscription()
+
+	go func() {
+		events := make(chan *p2p.PeerEvent)
+		sub := api.server.SubscribeEvents(events)
+		defer sub.Unsubscribe()
+
+		for {
+			select {
+			case event := <-events:
+				_ = notifier.Notify(rpcSub.ID, event)
+			case <-sub.Err():
+				return
+			case <-rpcSub.Err():
+				return
+			}
+		}
+	}()
+	return rpcSub, nil
+}
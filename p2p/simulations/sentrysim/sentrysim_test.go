@@ -0,0 +1,162 @@
+package sentrysim
+
+import (
+	"crypto/ecdsa"
+	"math/big"
+	"testing"
+	"time"
+
+	libcommon "github.com/ledgerwatch/erigon-lib/common"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ledgerwatch/erigon/core"
+	"github.com/ledgerwatch/erigon/core/types"
+	"github.com/ledgerwatch/erigon/crypto"
+	"github.com/ledgerwatch/erigon/p2p"
+	"github.com/ledgerwatch/erigon/p2p/enode"
+	"github.com/ledgerwatch/erigon/p2p/simulations"
+	"github.com/ledgerwatch/erigon/p2p/simulations/adapters"
+	"github.com/ledgerwatch/erigon/params"
+	"github.com/ledgerwatch/erigon/turbo/stages"
+)
+
+const syncTimeout = 2 * time.Minute
+
+type testEnv struct {
+	t         *testing.T
+	adapter   *Adapter
+	network   *simulations.Network
+	generator *stages.MockSentry // Generates the chains, it isn't part of the network
+}
+
+func newTestEnv(t *testing.T) *testEnv {
+	if testing.Short() {
+		t.Skip("runs networks of nodes")
+	}
+	key, _ := crypto.HexToECDSA("b71c71a67e1177ad4e901695e1b4b9ee17ae16c6668d313eac2f96dbcda3f291")
+	gspec := testGenesis(key)
+	adapter := NewAdapter(t, gspec)
+	network := simulations.NewNetwork(adapter, &simulations.NetworkConfig{DefaultService: "eth"})
+	t.Cleanup(network.Shutdown)
+	return &testEnv{t: t, adapter: adapter, network: network, generator: stages.MockWithGenesis(t, gspec, key, false)}
+}
+
+func testGenesis(key *ecdsa.PrivateKey) *types.Genesis {
+	return &types.Genesis{
+		Config: params.TestChainConfig,
+		Alloc: types.GenesisAlloc{
+			crypto.PubkeyToAddress(key.PublicKey): {Balance: big.NewInt(params.Ether)},
+		},
+	}
+}
+
+func (env *testEnv) startNode(name string) *Node {
+	conf := adapters.RandomNodeConfig()
+	conf.Name = name
+	conf.EnableMsgEvents = false
+	_, err := env.network.NewNodeWithConfig(conf)
+	require.NoError(env.t, err)
+	require.NoError(env.t, env.network.Start(conf.ID))
+	return env.network.GetNode(conf.ID).Node.(*Node)
+}
+
+// connect makes one dial other and waits for the connection.
+func (env *testEnv) connect(one, other *Node) {
+	require.NoError(env.t, env.network.Connect(one.config.ID, other.config.ID))
+	env.waitConn(one, other, true)
+}
+
+func (env *testEnv) waitConn(one, other *Node, up bool) {
+	require.Eventually(env.t, func() bool {
+		conn := env.network.GetConn(one.config.ID, other.config.ID)
+		return conn != nil && conn.Up == up
+	}, 10*time.Second, 50*time.Millisecond)
+}
+
+// chain generates blocks on top of parent, the coinbase makes the blocks of different chains differ.
+func (env *testEnv) chain(parent *types.Block, n int, coinbase byte) *core.ChainPack {
+	chain, err := core.GenerateChain(env.generator.ChainConfig, parent, env.generator.Engine, env.generator.DB, n, func(i int, b *core.BlockGen) {
+		b.SetCoinbase(libcommon.Address{coinbase})
+	})
+	require.NoError(env.t, err)
+	return chain
+}
+
+func (env *testEnv) waitHead(block *types.Block, nodes ...*Node) {
+	for _, node := range nodes {
+		require.NoError(env.t, node.WaitHead(block.Hash(), syncTimeout))
+	}
+}
+
+func TestForkPropagation(t *testing.T) {
+	env := newTestEnv(t)
+	a, b, c := env.startNode("a"), env.startNode("b"), env.startNode("c")
+
+	// a and c mine competing chains, the longer one wins once they connect over a slow link
+	short := env.chain(env.generator.Genesis, 3, 1)
+	long := env.chain(env.generator.Genesis, 5, 2)
+	require.NoError(t, a.MineBlocks(short.Blocks))
+	require.NoError(t, c.MineBlocks(long.Blocks))
+	env.adapter.SetLatency(a.config.ID, c.config.ID, 50*time.Millisecond)
+	env.connect(a, c)
+	env.waitHead(long.TopBlock, a)
+
+	// b syncs from a, then the new blocks of c reach b through a
+	env.connect(b, a)
+	env.waitHead(long.TopBlock, b)
+	require.NoError(t, env.generator.InsertChain(long, nil))
+	next := env.chain(long.TopBlock, 2, 2)
+	require.NoError(t, c.MineBlocks(next.Blocks))
+	env.waitHead(next.TopBlock, a, b)
+}
+
+func TestPartition(t *testing.T) {
+	env := newTestEnv(t)
+	a, b := env.startNode("a"), env.startNode("b")
+	env.connect(a, b)
+
+	env.adapter.Partition([]enode.ID{a.config.ID}, []enode.ID{b.config.ID})
+	env.waitConn(a, b, false)
+	chain := env.chain(env.generator.Genesis, 2, 1)
+	require.NoError(t, a.MineBlocks(chain.Blocks))
+	require.Never(t, func() bool {
+		_, head, err := b.Head()
+		return err == nil && head == chain.TopBlock.Hash()
+	}, 3*time.Second, 100*time.Millisecond)
+
+	// a keeps redialing b as a static peer, b dials a to reconnect right away
+	env.adapter.Heal()
+	env.connect(b, a)
+	env.waitHead(chain.TopBlock, b)
+}
+
+func TestEclipseAttempt(t *testing.T) {
+	env := newTestEnv(t)
+	// 3 peers leave 2 inbound slots and 1 dial slot
+	env.adapter.P2PConfig = func(node *adapters.NodeConfig, cfg *p2p.Config) {
+		if node.Name == "victim" {
+			cfg.MaxPeers = 3
+		}
+	}
+	victim, honest := env.startNode("victim"), env.startNode("honest")
+	attackers := []*Node{env.startNode("attacker1"), env.startNode("attacker2")}
+
+	honestChain := env.chain(env.generator.Genesis, 6, 1)
+	require.NoError(t, honest.MineBlocks(honestChain.Blocks))
+	for i, attacker := range attackers {
+		require.NoError(t, attacker.MineBlocks(env.chain(env.generator.Genesis, 2, byte(10+i)).Blocks))
+		env.connect(attacker, victim)
+	}
+
+	// the attackers took all the inbound slots of the victim
+	require.NoError(t, env.network.Connect(honest.config.ID, victim.config.ID))
+	require.Never(t, func() bool {
+		conn := env.network.GetConn(honest.config.ID, victim.config.ID)
+		return conn != nil && conn.Up
+	}, 3*time.Second, 100*time.Millisecond)
+
+	// but the victim still reaches the honest chain through its dial slot
+	victim.Server().AddPeer(honest.Self())
+	env.waitConn(victim, honest, true)
+	env.waitHead(honestChain.TopBlock, victim)
+}
@@ -131,11 +131,11 @@ func (t *rlpxTransport) close(err error) {
 	t.conn.Close() //nolint:errcheck
 }
 
-func (t *rlpxTransport) doEncHandshake(prv *ecdsa.PrivateKey) (*ecdsa.PublicKey, error) {
+func (t *rlpxTransport) doEncHandshake(prv *ecdsa.PrivateKey, previous []*ecdsa.PrivateKey) (*ecdsa.PublicKey, *ecdsa.PrivateKey, error) {
 	if err := t.conn.SetDeadline(time.Now().Add(handshakeTimeout)); err != nil {
-		return nil, err
+		return nil, nil, err
 	}
-	return t.conn.Handshake(prv)
+	return t.conn.HandshakeKeys(prv, previous)
 }
 
 func (t *rlpxTransport) doProtoHandshake(our *protoHandshake) (their *protoHandshake, err error) {
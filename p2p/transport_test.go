@@ -50,7 +50,7 @@ func TestProtocolHandshake(t *testing.T) {
 		defer wg.Done()
 		defer fd0.Close()
 		frame := newRLPX(fd0, &prv1.PublicKey)
-		rpubkey, err := frame.doEncHandshake(prv0)
+		rpubkey, _, err := frame.doEncHandshake(prv0, nil)
 		if err != nil {
 			t.Errorf("dial side enc handshake failed: %v", err)
 			return
@@ -76,7 +76,7 @@ func TestProtocolHandshake(t *testing.T) {
 		defer wg.Done()
 		defer fd1.Close()
 		rlpx := newRLPX(fd1, nil)
-		rpubkey, err := rlpx.doEncHandshake(prv1)
+		rpubkey, _, err := rlpx.doEncHandshake(prv1, nil)
 		if err != nil {
 			t.Errorf("listen side enc handshake failed: %v", err)
 			return
@@ -0,0 +1,109 @@
+// Package txpolicy holds the parts of the transaction broadcast policy of the sentries shared with the other
+// components: the broadcast modes and the registry of the private transactions, filled in by the RPC daemon.
+package txpolicy
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	libcommon "github.com/ledgerwatch/erigon-lib/common"
+)
+
+// Broadcast modes, how the transactions are sent in full besides the announcements of their hashes to all the peers.
+const (
+	BroadcastSqrt     = "sqrt"     // Send the transactions to the square root of the peers
+	BroadcastAnnounce = "announce" // Only announce the transactions, the peers fetch the ones they miss
+)
+
+// CheckBroadcast returns an error if the broadcast mode is unknown, the empty mode is BroadcastSqrt.
+func CheckBroadcast(mode string) error {
+	switch mode {
+	case "", BroadcastSqrt, BroadcastAnnounce:
+		return nil
+	default:
+		return fmt.Errorf("unknown transaction broadcast mode %q, expected %s or %s", mode, BroadcastSqrt, BroadcastAnnounce)
+	}
+}
+
+// PrivateTxTTL is how long the transactions stay private, the pool re-broadcasts the local transactions while they
+// are pending.
+const PrivateTxTTL = 24 * time.Hour
+
+// PrivateTxs is the registry of the hashes of the private transactions, which are included locally but never
+// broadcast, announced or served to the peers. One registry is shared by the RPC daemon and the sentries of its
+// process through their configs, only these sentries withhold the private transactions, so the registry is enforced
+// only when the node has no sentries in other processes.
+type PrivateTxs struct {
+	lock     sync.Mutex
+	expiry   map[libcommon.Hash]time.Time
+	ttl      time.Duration
+	pruneAt  int  // Size of the registry at which the expired hashes are pruned
+	sentries int  // Number of the sentries of the process withholding the private transactions
+	external bool // Whether the node uses sentries of other processes, which can't see the registry
+	now      func() time.Time
+}
+
+func NewPrivateTxs(ttl time.Duration) *PrivateTxs {
+	return &PrivateTxs{expiry: make(map[libcommon.Hash]time.Time), ttl: ttl, pruneAt: 1024, now: time.Now}
+}
+
+// ErrNotEnforced is returned for the private transactions when some of the sentries of the node would broadcast them.
+var ErrNotEnforced = errors.New("private transactions are only supported with all the sentries running in the process of the RPC daemon")
+
+// AddSentry registers a sentry withholding the private transactions, RemoveSentry unregisters it.
+func (p *PrivateTxs) AddSentry() {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	p.sentries++
+}
+
+func (p *PrivateTxs) RemoveSentry() {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	p.sentries--
+}
+
+// SetExternalSentries records that the node uses sentries of other processes.
+func (p *PrivateTxs) SetExternalSentries() {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	p.external = true
+}
+
+// Enforced returns whether the private transactions are withheld by all the sentries of the node: there is at least
+// one sentry in the process, and none in other processes.
+func (p *PrivateTxs) Enforced() bool {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	return p.sentries > 0 && !p.external
+}
+
+// Add makes a transaction private for the TTL of the registry.
+func (p *PrivateTxs) Add(hash libcommon.Hash) {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	now := p.now()
+	p.expiry[hash] = now.Add(p.ttl)
+	if len(p.expiry) < p.pruneAt {
+		return
+	}
+	for h, expiry := range p.expiry {
+		if !expiry.After(now) {
+			delete(p.expiry, h)
+		}
+	}
+	p.pruneAt = 2 * len(p.expiry)
+	if p.pruneAt < 1024 {
+		p.pruneAt = 1024
+	}
+}
+
+// Contains returns whether a transaction is private.
+func (p *PrivateTxs) Contains(hash libcommon.Hash) bool {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	expiry, ok := p.expiry[hash]
+	return ok && expiry.After(p.now())
+}
@@ -103,6 +103,10 @@ const (
 	ExtcodeHashGasConstantinople uint64 = 400  // Cost of EXTCODEHASH (introduced in Constantinople)
 	ExtcodeHashGasEIP1884        uint64 = 700  // Cost of EXTCODEHASH after EIP 1884 (part in Istanbul)
 	SelfdestructGasEIP150        uint64 = 5000 // Cost of SELFDESTRUCT post EIP 150 (Tangerine)
+	RjumpGasEIP4200              uint64 = 2    // Cost of RJUMP in EOF code
+	RjumpiGasEIP4200             uint64 = 4    // Cost of RJUMPI and RJUMPV in EOF code
+	CallfGasEIP4750              uint64 = 5    // Cost of CALLF in EOF code
+	RetfGasEIP4750               uint64 = 3    // Cost of RETF in EOF code
 
 	// EXP has a dynamic portion depending on the size of the exponent
 	ExpByteFrontier uint64 = 10 // was set to 10 in Frontier
@@ -0,0 +1,370 @@
+package rpc
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math/rand"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/ledgerwatch/log/v3"
+
+	"github.com/ledgerwatch/erigon/crypto"
+	"github.com/ledgerwatch/erigon/rpc/rpccfg"
+)
+
+// Redaction actions of the capture rules.
+const (
+	// RedactDrop does not capture the requests of the method
+	RedactDrop = "drop"
+	// RedactHash replaces the string parameters of the requests of the method by their keccak256 hash
+	RedactHash = "hash"
+)
+
+const (
+	captureFilePrefix = "capture-"
+	captureFileSuffix = ".txt"
+	// maxCapturedResponse is the size of the largest captured response, `rpctest replay` reads lines of up to 64MB
+	maxCapturedResponse = 32 * 1024 * 1024
+	captureQueueSize    = 1024
+)
+
+// Capture writes sampled HTTP requests with their responses to rotating files, in the format `rpctest replay` reads:
+// each request on one line, followed by its response, a comment line with its method, time and duration and an
+// empty line. The requests of a batch are written as single requests, with the time of the batch.
+//
+// The files are written by a goroutine, the exchanges are dropped rather than slowing down the server when it lags.
+type Capture struct {
+	config  rpccfg.CaptureConfig
+	methods map[string]struct{}
+	redact  map[string]string
+	logger  log.Logger
+
+	lock      sync.RWMutex // guards the sends to exchanges against Close
+	closed    bool
+	exchanges chan *capturedExchange
+	dropped   uint64
+	done      chan struct{}
+
+	file  *os.File
+	w     *bufio.Writer
+	size  int64
+	files int // number of files opened, which orders the files opened at the same time
+}
+
+type capturedExchange struct {
+	start    time.Time
+	took     time.Duration
+	request  []byte
+	response []byte
+}
+
+// NewCapture creates the capture directory and starts writing the captured exchanges
+func NewCapture(config rpccfg.CaptureConfig, logger log.Logger) (*Capture, error) {
+	if config.SampleRate <= 0 || config.SampleRate > 1 {
+		return nil, fmt.Errorf("capture sample rate %v is not in (0, 1]", config.SampleRate)
+	}
+	if config.MaxFiles < 0 {
+		return nil, fmt.Errorf("negative number of capture files %d", config.MaxFiles)
+	}
+	c := &Capture{
+		config:    config,
+		methods:   map[string]struct{}{},
+		redact:    map[string]string{},
+		logger:    logger,
+		exchanges: make(chan *capturedExchange, captureQueueSize),
+		done:      make(chan struct{}),
+	}
+	for _, method := range config.Methods {
+		c.methods[method] = struct{}{}
+	}
+	for _, rule := range config.Redact {
+		method, action, ok := strings.Cut(rule, ":")
+		if !ok || method == "" {
+			return nil, fmt.Errorf("invalid capture redaction rule %q, expected method:action", rule)
+		}
+		switch action {
+		case RedactDrop, RedactHash:
+		default:
+			return nil, fmt.Errorf("invalid capture redaction action %q, expected %s or %s", action, RedactDrop, RedactHash)
+		}
+		c.redact[method] = action
+	}
+	if err := os.MkdirAll(config.Dir, 0700); err != nil {
+		return nil, err
+	}
+	go c.run()
+	return c, nil
+}
+
+// Close writes the queued exchanges and closes the capture file
+func (c *Capture) Close() {
+	c.lock.Lock()
+	if !c.closed {
+		c.closed = true
+		close(c.exchanges)
+	}
+	c.lock.Unlock()
+	<-c.done
+}
+
+func (c *Capture) sample() bool {
+	return rand.Float64() < c.config.SampleRate // nolint:gosec
+}
+
+// serveHTTP serves the request with next, capturing it and its response
+func (c *Capture) serveHTTP(w http.ResponseWriter, r *http.Request, next func(w http.ResponseWriter, r *http.Request)) {
+	if r.Method != http.MethodPost || !c.sample() {
+		next(w, r)
+		return
+	}
+	request, err := io.ReadAll(io.LimitReader(r.Body, maxRequestContentLength+1))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	r.Body = io.NopCloser(bytes.NewReader(request))
+
+	cw := &captureResponseWriter{ResponseWriter: w}
+	start := time.Now()
+	next(cw, r)
+	if cw.truncated {
+		return
+	}
+
+	exchange := &capturedExchange{start: start, took: time.Since(start), request: request, response: cw.response.Bytes()}
+
+	c.lock.RLock()
+	defer c.lock.RUnlock()
+	if c.closed {
+		return
+	}
+	select {
+	case c.exchanges <- exchange:
+	default:
+		if dropped := atomic.AddUint64(&c.dropped, 1); dropped%1000 == 1 {
+			c.logger.Warn("[rpc] Capture is lagging, dropping requests", "dropped", dropped)
+		}
+	}
+}
+
+// captureResponseWriter keeps a copy of the response, up to maxCapturedResponse
+type captureResponseWriter struct {
+	http.ResponseWriter
+	response  bytes.Buffer
+	truncated bool
+}
+
+func (w *captureResponseWriter) Write(b []byte) (int, error) {
+	if !w.truncated {
+		if w.response.Len()+len(b) > maxCapturedResponse {
+			w.truncated = true
+			w.response = bytes.Buffer{}
+		} else {
+			w.response.Write(b)
+		}
+	}
+	return w.ResponseWriter.Write(b)
+}
+
+func (c *Capture) run() {
+	defer close(c.done)
+	defer c.closeFile()
+	for exchange := range c.exchanges {
+		if err := c.write(exchange); err != nil {
+			c.logger.Warn("[rpc] Capture failed", "err", err)
+		}
+	}
+}
+
+type capturedMessage struct {
+	ID     json.RawMessage   `json:"id"`
+	Method string            `json:"method"`
+	Params []json.RawMessage `json:"params"`
+}
+
+// write writes the request of the exchange, or each of the requests of a batch, with its response
+func (c *Capture) write(exchange *capturedExchange) error {
+	requests, responses := []json.RawMessage{exchange.request}, []json.RawMessage{exchange.response}
+	if isBatch(exchange.request) {
+		if err := json.Unmarshal(exchange.request, &requests); err != nil {
+			return nil
+		}
+		if err := json.Unmarshal(exchange.response, &responses); err != nil {
+			return nil
+		}
+	}
+
+	responsesByID := map[string]json.RawMessage{}
+	for _, response := range responses {
+		var msg capturedMessage
+		if err := json.Unmarshal(response, &msg); err != nil || len(msg.ID) == 0 {
+			continue
+		}
+		responsesByID[string(msg.ID)] = response
+	}
+
+	for _, request := range requests {
+		var msg capturedMessage
+		if err := json.Unmarshal(request, &msg); err != nil || len(msg.ID) == 0 {
+			continue
+		}
+		response, ok := responsesByID[string(msg.ID)]
+		if !ok {
+			continue
+		}
+		if len(c.methods) > 0 {
+			if _, ok := c.methods[msg.Method]; !ok {
+				continue
+			}
+		}
+		switch c.redact[msg.Method] {
+		case RedactDrop:
+			continue
+		case RedactHash:
+			request = redactParams(request, msg)
+		}
+
+		var line bytes.Buffer
+		if err := json.Compact(&line, request); err != nil {
+			continue
+		}
+		line.WriteByte('\n')
+		if err := json.Compact(&line, response); err != nil {
+			continue
+		}
+		fmt.Fprintf(&line, "\n# method=%s time=%s took=%s\n\n", msg.Method, exchange.start.UTC().Format(time.RFC3339Nano), exchange.took)
+
+		if err := c.writeLine(line.Bytes()); err != nil {
+			return err
+		}
+	}
+	if c.w != nil {
+		return c.w.Flush()
+	}
+	return nil
+}
+
+// redactParams returns the request with the strings of its parameters, also those nested in objects and arrays,
+// replaced by their hash
+func redactParams(request json.RawMessage, msg capturedMessage) json.RawMessage {
+	params := make([]json.RawMessage, len(msg.Params))
+	for i, param := range msg.Params {
+		params[i] = redactValue(param)
+	}
+	redacted, err := json.Marshal(struct {
+		JSONRPC string            `json:"jsonrpc"`
+		ID      json.RawMessage   `json:"id"`
+		Method  string            `json:"method"`
+		Params  []json.RawMessage `json:"params"`
+	}{vsn, msg.ID, msg.Method, params})
+	if err != nil {
+		return request
+	}
+	return redacted
+}
+
+// redactValue replaces the strings of a JSON value by their hash, null, numbers and booleans are kept
+func redactValue(value json.RawMessage) json.RawMessage {
+	trimmed := bytes.TrimSpace(value)
+	if len(trimmed) == 0 {
+		return value
+	}
+	switch trimmed[0] {
+	case '"':
+		var s string
+		if err := json.Unmarshal(trimmed, &s); err != nil {
+			return value
+		}
+		redacted, _ := json.Marshal(crypto.Keccak256Hash([]byte(s)))
+		return redacted
+	case '{':
+		var fields map[string]json.RawMessage
+		if err := json.Unmarshal(trimmed, &fields); err != nil {
+			return value
+		}
+		for name, field := range fields {
+			fields[name] = redactValue(field)
+		}
+		redacted, err := json.Marshal(fields)
+		if err != nil {
+			return value
+		}
+		return redacted
+	case '[':
+		var elems []json.RawMessage
+		if err := json.Unmarshal(trimmed, &elems); err != nil {
+			return value
+		}
+		for i, elem := range elems {
+			elems[i] = redactValue(elem)
+		}
+		redacted, err := json.Marshal(elems)
+		if err != nil {
+			return value
+		}
+		return redacted
+	default:
+		return value
+	}
+}
+
+func (c *Capture) writeLine(line []byte) error {
+	if c.file != nil && c.config.MaxFileSize > 0 && c.size+int64(len(line)) > int64(c.config.MaxFileSize) {
+		c.closeFile()
+	}
+	if c.file == nil {
+		if err := c.openFile(); err != nil {
+			return err
+		}
+	}
+	n, err := c.w.Write(line)
+	c.size += int64(n)
+	return err
+}
+
+// openFile starts a new capture file, deleting the oldest files beyond MaxFiles
+func (c *Capture) openFile() error {
+	if c.config.MaxFiles > 0 {
+		files, err := filepath.Glob(filepath.Join(c.config.Dir, captureFilePrefix+"*"+captureFileSuffix))
+		if err != nil {
+			return err
+		}
+		sort.Strings(files)
+		for len(files) >= c.config.MaxFiles {
+			if err := os.Remove(files[0]); err != nil {
+				return err
+			}
+			files = files[1:]
+		}
+	}
+	name := filepath.Join(c.config.Dir, fmt.Sprintf("%s%s-%06d%s", captureFilePrefix, time.Now().UTC().Format("20060102-150405.000000"), c.files, captureFileSuffix))
+	c.files++
+	file, err := os.OpenFile(name, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0600)
+	if err != nil {
+		return err
+	}
+	c.file, c.w, c.size = file, bufio.NewWriter(file), 0
+	c.logger.Info("[rpc] Capturing requests", "file", name)
+	return nil
+}
+
+func (c *Capture) closeFile() {
+	if c.file == nil {
+		return
+	}
+	if err := c.w.Flush(); err != nil {
+		c.logger.Warn("[rpc] Capture failed", "err", err)
+	}
+	c.file.Close()
+	c.file, c.w = nil, nil
+}
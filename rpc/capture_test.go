@@ -0,0 +1,157 @@
+package rpc
+
+import (
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/ledgerwatch/log/v3"
+
+	"github.com/ledgerwatch/erigon/crypto"
+	"github.com/ledgerwatch/erigon/rpc/rpccfg"
+)
+
+// captureRequests serves requests with a capture and returns the lines of the capture files, oldest first
+func captureRequests(t *testing.T, config rpccfg.CaptureConfig, calls func(c *Client)) [][]string {
+	t.Helper()
+	logger := log.New()
+	config.Dir = t.TempDir()
+	capture, err := NewCapture(config, logger)
+	if err != nil {
+		t.Fatal(err)
+	}
+	server := newTestServer(logger)
+	server.SetCapture(capture)
+	ts := httptest.NewServer(server)
+	client, err := DialHTTP(ts.URL, logger)
+	if err != nil {
+		t.Fatal(err)
+	}
+	calls(client)
+	client.Close()
+	ts.Close()
+	server.Stop()
+	capture.Close()
+
+	files, err := filepath.Glob(filepath.Join(config.Dir, "capture-*.txt"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	sort.Strings(files)
+	var lines [][]string
+	for _, file := range files {
+		// The captured requests may hold transactions and signatures, only the owner can read them
+		info, err := os.Stat(file)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if perm := info.Mode().Perm(); perm != 0600 {
+			t.Fatalf("capture file %s has mode %o, want 600", file, perm)
+		}
+		data, err := os.ReadFile(file)
+		if err != nil {
+			t.Fatal(err)
+		}
+		lines = append(lines, strings.Split(strings.TrimSuffix(string(data), "\n\n"), "\n"))
+	}
+	return lines
+}
+
+func TestCapture(t *testing.T) {
+	config := rpccfg.DefaultCaptureConfig
+	config.SampleRate = 1
+	config.Redact = []string{"test_rets:drop", "test_echoWithCtx:hash"}
+
+	files := captureRequests(t, config, func(c *Client) {
+		var result echoResult
+		if err := c.Call(&result, "test_echo", "a", 1, nil); err != nil {
+			t.Fatal(err)
+		}
+		if err := c.Call(&result, "test_echoWithCtx", "secret", 2, &echoArgs{S: "nested"}); err != nil {
+			t.Fatal(err)
+		}
+		var rets string
+		if err := c.Call(&rets, "test_rets"); err != nil {
+			t.Fatal(err)
+		}
+		batch := []BatchElem{
+			{Method: "test_echo", Args: []interface{}{"b", 3, nil}, Result: &echoResult{}},
+			{Method: "test_echo", Args: []interface{}{"c", 4, nil}, Result: &echoResult{}},
+		}
+		if err := c.BatchCall(batch); err != nil {
+			t.Fatal(err)
+		}
+	})
+	if len(files) != 1 {
+		t.Fatalf("got %d capture files, want 1", len(files))
+	}
+
+	// Each exchange is the request, the response, a comment and an empty line, as rpctest replay reads them
+	lines := files[0]
+	if len(lines) != 4*4-1 {
+		t.Fatalf("got %d lines, want %d:\n%s", len(lines), 4*4-1, strings.Join(lines, "\n"))
+	}
+	for i, want := range []struct{ method, request, response string }{
+		{"test_echo", `"params":["a",1,null]`, `"result":{"String":"a","Int":1,"Args":null}`},
+		{"test_echoWithCtx", `"params":["0x65462b0520ef7d3df61b9992ed3bea0c56ead753be7c8b3614e0ce01e4cac41b",2,{"S":"` + crypto.Keccak256Hash([]byte("nested")).Hex() + `"}]`, `"result":{"String":"secret","Int":2,"Args":{"S":"nested"}}`},
+		{"test_echo", `"params":["b",3,null]`, `"result":{"String":"b","Int":3,"Args":null}`},
+		{"test_echo", `"params":["c",4,null]`, `"result":{"String":"c","Int":4,"Args":null}`},
+	} {
+		request, response, comment := lines[4*i], lines[4*i+1], lines[4*i+2]
+		if !strings.Contains(request, `"method":"`+want.method+`"`) || !strings.Contains(request, want.request) {
+			t.Errorf("exchange %d: got request %s, want %s with %s", i, request, want.method, want.request)
+		}
+		if !strings.Contains(response, want.response) {
+			t.Errorf("exchange %d: got response %s, want %s", i, response, want.response)
+		}
+		if !strings.HasPrefix(comment, "# method="+want.method+" ") {
+			t.Errorf("exchange %d: got comment %s", i, comment)
+		}
+		if i < 3 && lines[4*i+3] != "" {
+			t.Errorf("exchange %d: got %q after the comment, want an empty line", i, lines[4*i+3])
+		}
+	}
+}
+
+func TestCaptureRotation(t *testing.T) {
+	config := rpccfg.DefaultCaptureConfig
+	config.SampleRate = 1
+	config.MaxFileSize = 1
+	config.MaxFiles = 2
+
+	files := captureRequests(t, config, func(c *Client) {
+		for _, s := range []string{"a", "b", "c"} {
+			var result echoResult
+			if err := c.Call(&result, "test_echo", s, 1, nil); err != nil {
+				t.Fatal(err)
+			}
+		}
+	})
+	if len(files) != 2 {
+		t.Fatalf("got %d capture files, want 2", len(files))
+	}
+	for i, s := range []string{"b", "c"} {
+		if !strings.Contains(files[i][0], `"params":["`+s+`",1,null]`) {
+			t.Errorf("file %d: got request %s, want %s", i, files[i][0], s)
+		}
+	}
+}
+
+func TestCaptureConfig(t *testing.T) {
+	config := rpccfg.DefaultCaptureConfig
+	config.Dir = t.TempDir()
+	for _, redact := range []string{"eth_sendRawTransaction", "eth_sendRawTransaction:keep", ":drop"} {
+		config.Redact = []string{redact}
+		if _, err := NewCapture(config, log.New()); err == nil {
+			t.Errorf("redaction rule %q: got no error", redact)
+		}
+	}
+	config.Redact = nil
+	config.SampleRate = 0
+	if _, err := NewCapture(config, log.New()); err == nil {
+		t.Error("sample rate 0: got no error")
+	}
+}
@@ -78,6 +78,7 @@ type Client struct {
 	isHTTP          bool
 	services        *serviceRegistry
 	methodAllowList AllowList
+	methodContext   bool // Whether the calls served carry the name of their method, see MethodFromContext
 
 	idCounter uint32
 
@@ -114,6 +115,7 @@ type clientConn struct {
 func (c *Client) newClientConn(conn ServerCodec) *clientConn {
 	ctx := context.WithValue(context.Background(), clientContextKey{}, c)
 	handler := newHandler(ctx, conn, c.idgen, c.services, c.methodAllowList, 50, false /* traceRequests */, c.logger)
+	handler.methodContext = c.methodContext
 	return &clientConn{conn, handler}
 }
 
@@ -198,12 +200,12 @@ func newClient(initctx context.Context, connect reconnectFunc, logger log.Logger
 	if err != nil {
 		return nil, err
 	}
-	c := initClient(conn, randomIDGenerator(), &serviceRegistry{logger: logger}, logger)
+	c := initClient(conn, randomIDGenerator(), &serviceRegistry{logger: logger}, false /* methodContext */, logger)
 	c.reconnectFunc = connect
 	return c, nil
 }
 
-func initClient(conn ServerCodec, idgen func() ID, services *serviceRegistry, logger log.Logger) *Client {
+func initClient(conn ServerCodec, idgen func() ID, services *serviceRegistry, methodContext bool, logger log.Logger) *Client {
 	_, isHTTP := conn.(*httpConn)
 	c := &Client{
 		idgen:       idgen,
@@ -220,6 +222,8 @@ func initClient(conn ServerCodec, idgen func() ID, services *serviceRegistry, lo
 		reqSent:     make(chan error, 1),
 		reqTimeout:  make(chan *requestOp),
 		logger:      logger,
+
+		methodContext: methodContext,
 	}
 	if !isHTTP {
 		go c.dispatch(conn)
@@ -71,6 +71,7 @@ type handler struct {
 	serverSubs          map[ID]*Subscription
 	maxBatchConcurrency uint
 	traceRequests       bool
+	methodContext       bool // Whether the calls carry the name of their method, see MethodFromContext
 }
 
 type callProc struct {
@@ -440,8 +441,12 @@ func (h *handler) handleCall(cp *callProc, msg *jsonrpcMessage, stream *jsoniter
 	if err != nil {
 		return msg.errorResponse(&InvalidParamsError{err.Error()})
 	}
+	ctx := cp.ctx
+	if h.methodContext {
+		ctx = context.WithValue(ctx, methodKey{}, msg.Method)
+	}
 	start := time.Now()
-	answer := h.runMethod(cp.ctx, msg, callb, args, stream)
+	answer := h.runMethod(ctx, msg, callb, args, stream)
 
 	// Collect the statistics for RPC calls if metrics is enabled.
 	// We only care about pure rpc call. Filter out subscription.
@@ -2,6 +2,8 @@ package rpccfg
 
 import (
 	"time"
+
+	"github.com/c2h5oh/datasize"
 )
 
 // HTTPTimeouts represents the configuration params for the HTTP RPC server.
@@ -37,3 +39,27 @@ var DefaultHTTPTimeouts = HTTPTimeouts{
 }
 
 const DefaultEvmCallTimeout = 5 * time.Minute
+
+// CaptureConfig configures the capture of sampled requests of the HTTP RPC server with their responses, in the
+// format `rpctest replay` reads.
+type CaptureConfig struct {
+	// Dir is the directory of the capture files, the capture is disabled if it is empty
+	Dir string
+	// SampleRate is the fraction of the requests which are captured
+	SampleRate float64
+	// MaxFileSize is the size above which a new capture file is started
+	MaxFileSize datasize.ByteSize
+	// MaxFiles is the number of capture files kept, the oldest are deleted. 0 keeps all of them.
+	MaxFiles int
+	// Methods are the captured methods, all of them if it is empty
+	Methods []string
+	// Redact are the redaction rules of the captured methods, as method:action
+	Redact []string
+}
+
+var DefaultCaptureConfig = CaptureConfig{
+	SampleRate:  0.01,
+	MaxFileSize: 256 * datasize.MB,
+	MaxFiles:    16,
+	Redact:      []string{"eth_sendRawTransaction:drop", "eth_sendTransaction:drop", "eth_sign:drop", "eth_signTransaction:drop"},
+}
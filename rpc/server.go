@@ -54,6 +54,8 @@ type Server struct {
 	disableStreaming bool
 	traceRequests    bool // Whether to print requests at INFO level
 	batchLimit       int  // Maximum number of requests in a batch
+	capture          *Capture
+	methodContext    bool // Whether the calls carry the name of their method, see MethodFromContext
 	logger           log.Logger
 }
 
@@ -78,6 +80,17 @@ func (s *Server) SetBatchLimit(limit int) {
 	s.batchLimit = limit
 }
 
+// SetCapture sets the capture of the HTTP requests served by this server
+func (s *Server) SetCapture(capture *Capture) {
+	s.capture = capture
+}
+
+// SetMethodContext makes the callbacks find the name of the method they serve with MethodFromContext. It costs an
+// allocation per call, so it is only enabled when something collects statistics per method.
+func (s *Server) SetMethodContext(enabled bool) {
+	s.methodContext = enabled
+}
+
 // RegisterName creates a service for the given receiver type under the given name. When no
 // methods on the given receiver match the criteria to be either a RPC method or a
 // subscription an error is returned. Otherwise a new service is created and added to the
@@ -103,7 +116,7 @@ func (s *Server) ServeCodec(codec ServerCodec, options CodecOption) {
 	s.codecs.Add(codec)
 	defer s.codecs.Remove(codec)
 
-	c := initClient(codec, s.idgen, &s.services, s.logger)
+	c := initClient(codec, s.idgen, &s.services, s.methodContext, s.logger)
 	<-codec.closed()
 	c.Close()
 }
@@ -119,6 +132,7 @@ func (s *Server) serveSingleRequest(ctx context.Context, codec ServerCodec, stre
 
 	h := newHandler(ctx, codec, s.idgen, &s.services, s.methodAllowList, s.batchConcurrency, s.traceRequests, s.logger)
 	h.allowSubscribe = false
+	h.methodContext = s.methodContext
 	defer h.close(io.EOF, nil)
 
 	reqs, batch, err := codec.readBatch()
@@ -50,12 +50,35 @@ func TestServerRegisterName(t *testing.T) {
 		t.Fatalf("Expected service calc to be registered")
 	}
 
-	wantCallbacks := 9
+	wantCallbacks := 10
 	if len(svc.callbacks) != wantCallbacks {
 		t.Errorf("Expected %d callbacks for service 'service', got %d", wantCallbacks, len(svc.callbacks))
 	}
 }
 
+func TestServerMethodContext(t *testing.T) {
+	logger := log.New()
+	for _, enabled := range []bool{false, true} {
+		server := newTestServer(logger)
+		server.SetMethodContext(enabled)
+		client := DialInProc(server, logger)
+
+		var method string
+		if err := client.Call(&method, "test_method"); err != nil {
+			t.Fatal(err)
+		}
+		want := ""
+		if enabled {
+			want = "test_method"
+		}
+		if method != want {
+			t.Errorf("method context enabled %v: got method %q, want %q", enabled, method, want)
+		}
+		client.Close()
+		server.Stop()
+	}
+}
+
 func TestServer(t *testing.T) {
 	logger := log.New()
 	files, err := os.ReadDir("testdata")
@@ -0,0 +1,290 @@
+package tests
+
+import (
+	"bytes"
+	"fmt"
+	"math/big"
+
+	"github.com/holiman/uint256"
+	libcommon "github.com/ledgerwatch/erigon-lib/common"
+	"github.com/ledgerwatch/erigon-lib/common/hexutility"
+	types2 "github.com/ledgerwatch/erigon-lib/types"
+	"github.com/ledgerwatch/log/v3"
+
+	"github.com/ledgerwatch/erigon/common"
+	"github.com/ledgerwatch/erigon/common/math"
+	"github.com/ledgerwatch/erigon/core"
+	"github.com/ledgerwatch/erigon/core/rawdb"
+	"github.com/ledgerwatch/erigon/core/state"
+	"github.com/ledgerwatch/erigon/core/types"
+	"github.com/ledgerwatch/erigon/crypto"
+	"github.com/ledgerwatch/erigon/eth/ethconsensusconfig"
+	"github.com/ledgerwatch/erigon/rlp"
+	"github.com/ledgerwatch/erigon/turbo/stages"
+)
+
+// BlockTestFiller is the source of a block test: the pre-state, the transactions of the blocks and the expected
+// post-state. Filling it executes the blocks and produces a BlockTest fixture with the resulting headers, block RLPs
+// and full post-state.
+type BlockTestFiller struct {
+	Network string                                  `json:"network"`
+	Genesis btFillerGenesis                         `json:"genesis"`
+	Pre     types.GenesisAlloc                      `json:"pre"`
+	Blocks  []btFillerBlock                         `json:"blocks"`
+	Expect  map[libcommon.Address]btExpectedAccount `json:"expect"`
+}
+
+type btFillerGenesis struct {
+	Coinbase   libcommon.Address     `json:"coinbase"`
+	Difficulty *math.HexOrDecimal256 `json:"difficulty"`
+	GasLimit   math.HexOrDecimal64   `json:"gasLimit"`
+	Timestamp  math.HexOrDecimal64   `json:"timestamp"`
+	BaseFee    *math.HexOrDecimal256 `json:"baseFee"`
+	ExtraData  hexutility.Bytes      `json:"extraData"`
+	Nonce      math.HexOrDecimal64   `json:"nonce"`
+	MixHash    libcommon.Hash        `json:"mixHash"`
+}
+
+type btFillerBlock struct {
+	Coinbase     *libcommon.Address   `json:"coinbase"`
+	Timestamp    *math.HexOrDecimal64 `json:"timestamp"` // Defaults to 10 seconds after the parent
+	ExtraData    hexutility.Bytes     `json:"extraData"`
+	Transactions []btFillerTx         `json:"transactions"`
+}
+
+// btFillerTx is a transaction signed with SecretKey when filling. The transaction is a dynamic fee one if
+// MaxFeePerGas is set, an access list one if AccessList is set, and a legacy one otherwise.
+type btFillerTx struct {
+	SecretKey            libcommon.Hash        `json:"secretKey"`
+	To                   *libcommon.Address    `json:"to"`    // Contract creation if nil
+	Nonce                *math.HexOrDecimal64  `json:"nonce"` // Defaults to the nonce of the sender
+	Value                *math.HexOrDecimal256 `json:"value"`
+	Data                 hexutility.Bytes      `json:"data"`
+	GasLimit             math.HexOrDecimal64   `json:"gasLimit"`
+	GasPrice             *math.HexOrDecimal256 `json:"gasPrice"`
+	MaxFeePerGas         *math.HexOrDecimal256 `json:"maxFeePerGas"`
+	MaxPriorityFeePerGas *math.HexOrDecimal256 `json:"maxPriorityFeePerGas"`
+	AccessList           *types2.AccessList    `json:"accessList"`
+}
+
+// btExpectedAccount holds the expected fields of an account in the post-state, the missing ones are not checked.
+type btExpectedAccount struct {
+	Balance *math.HexOrDecimal256             `json:"balance"`
+	Nonce   *math.HexOrDecimal64              `json:"nonce"`
+	Code    *hexutility.Bytes                 `json:"code"`
+	Storage map[libcommon.Hash]libcommon.Hash `json:"storage"`
+}
+
+// Fill executes the blocks of the filler and returns the resulting block test. It fails if the blocks can't be
+// executed, or if the post-state doesn't match the expectations of the filler.
+func (f *BlockTestFiller) Fill() (*BlockTest, error) {
+	config, ok := Forks[f.Network]
+	if !ok {
+		return nil, UnsupportedForkError{f.Network}
+	}
+	bt := &BlockTest{json: btJSON{
+		Genesis: btHeader{
+			Coinbase:   f.Genesis.Coinbase,
+			MixHash:    f.Genesis.MixHash,
+			Nonce:      types.EncodeNonce(uint64(f.Genesis.Nonce)),
+			Difficulty: (*big.Int)(f.Genesis.Difficulty),
+			GasLimit:   uint64(f.Genesis.GasLimit),
+			Timestamp:  uint64(f.Genesis.Timestamp),
+			ExtraData:  f.Genesis.ExtraData,
+			BaseFee:    (*big.Int)(f.Genesis.BaseFee),
+		},
+		Pre:        f.Pre,
+		Network:    f.Network,
+		SealEngine: "NoProof",
+	}}
+	if bt.json.Genesis.Difficulty == nil {
+		bt.json.Genesis.Difficulty = new(big.Int)
+	}
+	engine := ethconsensusconfig.CreateConsensusEngineBareBones(config, log.New())
+	m := stages.MockWithGenesisEngine(nil, bt.genesis(config), engine, false)
+	defer m.Close()
+
+	bt.json.Genesis = newBtHeader(m.Genesis.Header())
+	genesisRLP, err := rlp.EncodeToBytes(m.Genesis)
+	if err != nil {
+		return nil, err
+	}
+	bt.json.GenesisRLP = genesisRLP
+
+	chainPack, err := f.generateChain(m)
+	if err != nil {
+		return nil, err
+	}
+	tx, err := m.DB.BeginRw(m.Ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+	if len(f.Blocks) > 0 {
+		if err := m.InsertChain(chainPack, tx); err != nil {
+			return nil, fmt.Errorf("inserting the filled blocks: %w", err)
+		}
+		for _, block := range chainPack.Blocks {
+			blockRLP, err := rlp.EncodeToBytes(block)
+			if err != nil {
+				return nil, err
+			}
+			header := newBtHeader(block.Header())
+			bt.json.Blocks = append(bt.json.Blocks, btBlock{BlockHeader: &header, Rlp: hexutility.Encode(blockRLP), UncleHeaders: []*btHeader{}})
+		}
+	}
+	bt.json.BestBlock = common.UnprefixedHash(rawdb.ReadHeadBlockHash(tx))
+
+	if err := f.checkExpectations(state.New(m.NewStateReader(tx))); err != nil {
+		return nil, err
+	}
+	// The latest state is in the plain state tables with either history layout, and is read as of the block after
+	// the head block, whose changesets are always empty
+	head := rawdb.ReadHeaderNumber(tx, libcommon.Hash(bt.json.BestBlock))
+	if head == nil {
+		return nil, fmt.Errorf("head block %x not found", bt.json.BestBlock)
+	}
+	post := make(alloc)
+	if _, err := state.NewDumper(tx, *head+1, false).DumpToCollector(post, false, false, libcommon.Address{}, 0); err != nil {
+		return nil, err
+	}
+	bt.json.Post = types.GenesisAlloc(post)
+	return bt, nil
+}
+
+// generateChain builds the blocks of the filler on top of the genesis block of m
+func (f *BlockTestFiller) generateChain(m *stages.MockSentry) (chainPack *core.ChainPack, err error) {
+	if len(f.Blocks) == 0 {
+		return nil, nil
+	}
+	signer := types.LatestSignerForChainID(m.ChainConfig.ChainID)
+	// The block generator panics if a transaction can't be executed
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("generating blocks: %v", r)
+		}
+	}()
+	return core.GenerateChain(m.ChainConfig, m.Genesis, m.Engine, m.DB, len(f.Blocks), func(i int, b *core.BlockGen) {
+		block := f.Blocks[i]
+		if block.Timestamp != nil {
+			b.OffsetTime(int64(uint64(*block.Timestamp) - b.GetHeader().Time))
+		}
+		if block.ExtraData != nil {
+			b.SetExtra(block.ExtraData)
+		}
+		if block.Coinbase != nil {
+			b.SetCoinbase(*block.Coinbase)
+		}
+		for j, fillerTx := range block.Transactions {
+			txn, err := fillerTx.sign(b, signer)
+			if err != nil {
+				panic(fmt.Errorf("transaction %d of block %d: %w", j, i+1, err))
+			}
+			b.AddTx(txn)
+		}
+	})
+}
+
+func (t *btFillerTx) sign(b *core.BlockGen, signer *types.Signer) (types.Transaction, error) {
+	key, err := crypto.ToECDSA(t.SecretKey.Bytes())
+	if err != nil {
+		return nil, err
+	}
+	nonce := b.TxNonce(crypto.PubkeyToAddress(key.PublicKey))
+	if t.Nonce != nil {
+		nonce = uint64(*t.Nonce)
+	}
+	commonTx := types.CommonTx{Nonce: nonce, Gas: uint64(t.GasLimit), To: t.To, Value: toUint256(t.Value), Data: t.Data}
+
+	var txn types.Transaction
+	switch {
+	case t.MaxFeePerGas != nil:
+		dynamicFeeTx := &types.DynamicFeeTransaction{CommonTx: commonTx, ChainID: signer.ChainID(), Tip: toUint256(t.MaxPriorityFeePerGas), FeeCap: toUint256(t.MaxFeePerGas)}
+		if t.AccessList != nil {
+			dynamicFeeTx.AccessList = *t.AccessList
+		}
+		txn = dynamicFeeTx
+	case t.AccessList != nil:
+		txn = &types.AccessListTx{LegacyTx: types.LegacyTx{CommonTx: commonTx, GasPrice: toUint256(t.GasPrice)}, ChainID: signer.ChainID(), AccessList: *t.AccessList}
+	default:
+		txn = &types.LegacyTx{CommonTx: commonTx, GasPrice: toUint256(t.GasPrice)}
+	}
+	return types.SignTx(txn, *signer, key)
+}
+
+func toUint256(i *math.HexOrDecimal256) *uint256.Int {
+	if i == nil {
+		return new(uint256.Int)
+	}
+	u, _ := uint256.FromBig((*big.Int)(i))
+	return u
+}
+
+func (f *BlockTestFiller) checkExpectations(statedb *state.IntraBlockState) error {
+	for addr, acct := range f.Expect {
+		if acct.Balance != nil {
+			if balance := statedb.GetBalance(addr); balance.ToBig().Cmp((*big.Int)(acct.Balance)) != 0 {
+				return fmt.Errorf("account balance mismatch for addr: %x, want: %d, have: %d", addr, (*big.Int)(acct.Balance), balance)
+			}
+		}
+		if acct.Nonce != nil {
+			if nonce := statedb.GetNonce(addr); nonce != uint64(*acct.Nonce) {
+				return fmt.Errorf("account nonce mismatch for addr: %x want: %d have: %d", addr, uint64(*acct.Nonce), nonce)
+			}
+		}
+		if acct.Code != nil {
+			if code := statedb.GetCode(addr); !bytes.Equal(code, *acct.Code) {
+				return fmt.Errorf("account code mismatch for addr: %x want: %x have: %x", addr, []byte(*acct.Code), code)
+			}
+		}
+		for loc, val := range acct.Storage {
+			loc := loc
+			want := new(uint256.Int).SetBytes(val.Bytes())
+			have := new(uint256.Int)
+			statedb.GetState(addr, &loc, have)
+			if !want.Eq(have) {
+				return fmt.Errorf("storage mismatch for addr: %x loc: %x want: %d have: %d", addr, loc, want, have)
+			}
+		}
+	}
+	return nil
+}
+
+func newBtHeader(h *types.Header) btHeader {
+	return btHeader{
+		Bloom:            h.Bloom,
+		Coinbase:         h.Coinbase,
+		MixHash:          h.MixDigest,
+		Nonce:            h.Nonce,
+		Number:           h.Number,
+		Hash:             h.Hash(),
+		ParentHash:       h.ParentHash,
+		ReceiptTrie:      h.ReceiptHash,
+		StateRoot:        h.Root,
+		TransactionsTrie: h.TxHash,
+		UncleHash:        h.UncleHash,
+		ExtraData:        h.Extra,
+		Difficulty:       h.Difficulty,
+		GasLimit:         h.GasLimit,
+		GasUsed:          h.GasUsed,
+		Timestamp:        h.Time,
+		BaseFee:          h.BaseFee,
+	}
+}
+
+// alloc collects the accounts of a state dump
+type alloc types.GenesisAlloc
+
+func (a alloc) OnRoot(libcommon.Hash) {}
+
+func (a alloc) OnAccount(addr libcommon.Address, dumpAccount state.DumpAccount) {
+	balance, _ := new(big.Int).SetString(dumpAccount.Balance, 10)
+	var storage map[libcommon.Hash]libcommon.Hash
+	if len(dumpAccount.Storage) > 0 {
+		storage = make(map[libcommon.Hash]libcommon.Hash, len(dumpAccount.Storage))
+		for k, v := range dumpAccount.Storage {
+			storage[libcommon.HexToHash(k)] = libcommon.HexToHash(v)
+		}
+	}
+	a[addr] = types.GenesisAccount{Code: dumpAccount.Code, Storage: storage, Balance: balance, Nonce: dumpAccount.Nonce}
+}
@@ -0,0 +1,96 @@
+package tests
+
+import (
+	"encoding/json"
+	"math/big"
+	"testing"
+
+	libcommon "github.com/ledgerwatch/erigon-lib/common"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ledgerwatch/erigon/common/math"
+	"github.com/ledgerwatch/erigon/core/vm"
+	"github.com/ledgerwatch/erigon/eth/tracers/logger"
+)
+
+// blockTestFiller sends a value transfer and a call to a contract storing CALLVALUE in its slot 0
+const blockTestFiller = `{
+	"network": "London",
+	"genesis": {"gasLimit": "0x989680", "timestamp": "0x0"},
+	"pre": {
+		"0x71562b71999873db5b286df957af199ec94617f7": {"balance": "0x3635c9adc5dea00000"},
+		"0x00000000000000000000000000000000000000aa": {"balance": "0x0", "code": "0x34600055"}
+	},
+	"blocks": [
+		{
+			"transactions": [
+				{
+					"secretKey": "0xb71c71a67e1177ad4e901695e1b4b9ee17ae16c6668d313eac2f96dbcda3f291",
+					"to": "0x00000000000000000000000000000000000000bb",
+					"value": "1000",
+					"gasLimit": "21000",
+					"gasPrice": "10000000000"
+				}
+			]
+		},
+		{
+			"coinbase": "0x00000000000000000000000000000000000000cc",
+			"transactions": [
+				{
+					"secretKey": "0xb71c71a67e1177ad4e901695e1b4b9ee17ae16c6668d313eac2f96dbcda3f291",
+					"to": "0x00000000000000000000000000000000000000aa",
+					"value": "42",
+					"gasLimit": "100000",
+					"maxFeePerGas": "10000000000",
+					"maxPriorityFeePerGas": "1"
+				}
+			]
+		}
+	],
+	"expect": {
+		"0x71562b71999873db5b286df957af199ec94617f7": {"nonce": "2"},
+		"0x00000000000000000000000000000000000000bb": {"balance": "1000"},
+		"0x00000000000000000000000000000000000000aa": {"balance": "42", "storage": {"0x0000000000000000000000000000000000000000000000000000000000000000": "0x000000000000000000000000000000000000000000000000000000000000002a"}}
+	}
+}`
+
+func TestFillBlockTest(t *testing.T) {
+	var filler BlockTestFiller
+	require.NoError(t, json.Unmarshal([]byte(blockTestFiller), &filler))
+	filled, err := filler.Fill()
+	require.NoError(t, err)
+
+	fixture, err := json.Marshal(filled)
+	require.NoError(t, err)
+	var bt BlockTest
+	require.NoError(t, json.Unmarshal(fixture, &bt))
+	require.Len(t, bt.json.Blocks, 2)
+	require.Equal(t, libcommon.HexToAddress("0xcc"), bt.json.Blocks[1].BlockHeader.Coinbase)
+	require.Contains(t, bt.json.Post, libcommon.HexToAddress("0xbb"))
+	require.NoError(t, bt.Run(t, false))
+
+	// The expectations are checked when filling
+	expected := filler.Expect[libcommon.HexToAddress("0xbb")]
+	expected.Balance = (*math.HexOrDecimal256)(big.NewInt(1001))
+	filler.Expect[libcommon.HexToAddress("0xbb")] = expected
+	_, err = filler.Fill()
+	require.ErrorContains(t, err, "account balance mismatch")
+}
+
+func TestBlockTestRunWithTracer(t *testing.T) {
+	var filler BlockTestFiller
+	require.NoError(t, json.Unmarshal([]byte(blockTestFiller), &filler))
+	bt, err := filler.Fill()
+	require.NoError(t, err)
+
+	tracers := map[uint64]*logger.StructLogger{}
+	err = bt.RunWithTracer(func(blockNum uint64, txIndex int, txHash libcommon.Hash) vm.EVMLogger {
+		require.Equal(t, 0, txIndex)
+		tracers[blockNum] = logger.NewStructLogger(&logger.LogConfig{})
+		return tracers[blockNum]
+	})
+	require.NoError(t, err)
+	require.Len(t, tracers, 2)
+	require.Empty(t, tracers[1].StructLogs())
+	require.Equal(t, vm.CALLVALUE, tracers[2].StructLogs()[0].Op)
+}
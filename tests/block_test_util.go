@@ -43,6 +43,7 @@ import (
 	"github.com/ledgerwatch/erigon/core/rawdb"
 	"github.com/ledgerwatch/erigon/core/state"
 	"github.com/ledgerwatch/erigon/core/types"
+	"github.com/ledgerwatch/erigon/core/vm"
 	"github.com/ledgerwatch/erigon/eth/ethconsensusconfig"
 	"github.com/ledgerwatch/erigon/rlp"
 	"github.com/ledgerwatch/erigon/turbo/stages"
@@ -59,9 +60,20 @@ func (bt *BlockTest) UnmarshalJSON(in []byte) error {
 	return json.Unmarshal(in, &bt.json)
 }
 
+// MarshalJSON implements json.Marshaler interface.
+func (bt *BlockTest) MarshalJSON() ([]byte, error) {
+	return json.Marshal(&bt.json)
+}
+
+// Network returns the name of the fork rules of the test.
+func (bt *BlockTest) Network() string {
+	return bt.json.Network
+}
+
 type btJSON struct {
 	Blocks     []btBlock             `json:"blocks"`
 	Genesis    btHeader              `json:"genesisBlockHeader"`
+	GenesisRLP hexutility.Bytes      `json:"genesisRLP,omitempty"`
 	Pre        types.GenesisAlloc    `json:"pre"`
 	Post       types.GenesisAlloc    `json:"postState"`
 	BestBlock  common.UnprefixedHash `json:"lastblockhash"`
@@ -70,10 +82,10 @@ type btJSON struct {
 }
 
 type btBlock struct {
-	BlockHeader     *btHeader
-	ExpectException string
-	Rlp             string
-	UncleHeaders    []*btHeader
+	BlockHeader     *btHeader   `json:"blockHeader,omitempty"`
+	ExpectException string      `json:"expectException,omitempty"`
+	Rlp             string      `json:"rlp"`
+	UncleHeaders    []*btHeader `json:"uncleHeaders"`
 }
 
 //go:generate gencodec -type btHeader -field-override btHeaderMarshaling -out gen_btheader.go
@@ -109,12 +121,25 @@ type btHeaderMarshaling struct {
 }
 
 func (bt *BlockTest) Run(t *testing.T, _ bool) error {
+	return bt.run(t, nil)
+}
+
+// RunWithTracer executes the test outside of go test. If tracer is not nil, the transactions of the imported canonical
+// blocks are replayed with the EVM logger returned by tracer, even if the test fails.
+func (bt *BlockTest) RunWithTracer(tracer func(blockNum uint64, txIndex int, txHash libcommon.Hash) vm.EVMLogger) error {
+	return bt.run(nil, tracer)
+}
+
+func (bt *BlockTest) run(tb testing.TB, tracer func(blockNum uint64, txIndex int, txHash libcommon.Hash) vm.EVMLogger) error {
 	config, ok := Forks[bt.json.Network]
 	if !ok {
 		return UnsupportedForkError{bt.json.Network}
 	}
 	engine := ethconsensusconfig.CreateConsensusEngineBareBones(config, log.New())
-	m := stages.MockWithGenesisEngine(t, bt.genesis(config), engine, false)
+	m := stages.MockWithGenesisEngine(tb, bt.genesis(config), engine, false)
+	if tb == nil {
+		defer m.Close()
+	}
 
 	bt.br = m.BlockReader
 	// import pre accounts & construct test genesis block & state root
@@ -132,6 +157,11 @@ func (bt *BlockTest) Run(t *testing.T, _ bool) error {
 	defer tx.Rollback()
 
 	validBlocks, err := bt.insertBlocks(m, tx)
+	if tracer != nil {
+		if traceErr := bt.traceBlocks(m, tx, tracer); err == nil {
+			err = traceErr
+		}
+	}
 	if err != nil {
 		return err
 	}
@@ -275,6 +305,39 @@ func validateHeader(h *btHeader, h2 *types.Header) error {
 	return nil
 }
 
+// traceBlocks replays the transactions of the canonical blocks on top of the state preceding each block
+func (bt *BlockTest) traceBlocks(m *stages.MockSentry, tx kv.Tx, tracer func(blockNum uint64, txIndex int, txHash libcommon.Hash) vm.EVMLogger) error {
+	head, err := m.BlockReader.CurrentBlock(tx)
+	if err != nil || head == nil {
+		return err
+	}
+	getHeader := func(hash libcommon.Hash, number uint64) *types.Header {
+		h, _ := m.BlockReader.Header(m.Ctx, tx, hash, number)
+		return h
+	}
+	for blockNum := uint64(1); blockNum <= head.NumberU64(); blockNum++ {
+		block, err := m.BlockReader.BlockByNumber(m.Ctx, tx, blockNum)
+		if err != nil {
+			return err
+		}
+		if block == nil {
+			return fmt.Errorf("canonical block %d not found", blockNum)
+		}
+		header := block.Header()
+		ibs := state.New(m.NewHistoryStateReader(blockNum, tx))
+		gp := new(core.GasPool).AddGas(header.GasLimit).AddDataGas(chain.MaxDataGasPerBlock)
+		var usedGas, usedDataGas uint64
+		for i, txn := range block.Transactions() {
+			ibs.SetTxContext(txn.Hash(), block.Hash(), i)
+			vmConfig := vm.Config{Debug: true, Tracer: tracer(blockNum, i, txn.Hash())}
+			if _, _, err := core.ApplyTransaction(m.ChainConfig, core.GetHashFn(header, getHeader), m.Engine, nil, gp, ibs, state.NewNoopWriter(), header, txn, &usedGas, &usedDataGas, vmConfig); err != nil {
+				return fmt.Errorf("tracing transaction %d of block %d: %w", i, blockNum, err)
+			}
+		}
+	}
+	return nil
+}
+
 func (bt *BlockTest) validatePostState(statedb *state.IntraBlockState) error {
 	// validate post state accounts in test file against what we have in state db
 	for addr, acct := range bt.json.Post {
@@ -350,7 +350,7 @@ func MakePreState(rules *chain.Rules, tx kv.RwTx, accounts types.GenesisAlloc, b
 }
 
 func (t *StateTest) genesis(config *chain.Config) *types.Genesis {
-	return &types.Genesis{
+	genesis := &types.Genesis{
 		Config:     config,
 		Coinbase:   t.json.Env.Coinbase,
 		Difficulty: t.json.Env.Difficulty,
@@ -359,6 +359,11 @@ func (t *StateTest) genesis(config *chain.Config) *types.Genesis {
 		Timestamp:  t.json.Env.Timestamp,
 		Alloc:      t.json.Pre,
 	}
+	if config.IsCancun(t.json.Env.Timestamp) {
+		// Retesteth starts the blob gas market from 0
+		genesis.ExcessDataGas = new(uint64)
+	}
+	return genesis
 }
 
 func rlpHash(x interface{}) (h libcommon.Hash) {
@@ -0,0 +1,58 @@
+package ethapi
+
+import (
+	"math/big"
+
+	"github.com/holiman/uint256"
+	libcommon "github.com/ledgerwatch/erigon-lib/common"
+
+	"github.com/ledgerwatch/erigon/common/hexutil"
+	"github.com/ledgerwatch/erigon/core/vm/evmtypes"
+)
+
+// BlockOverrides is the set of header fields overridden in the block context of a call
+type BlockOverrides struct {
+	BlockNumber *hexutil.Uint64            `json:"blockNumber"`
+	Coinbase    *libcommon.Address         `json:"coinbase"`
+	Timestamp   *hexutil.Uint64            `json:"timestamp"`
+	GasLimit    *hexutil.Uint              `json:"gasLimit"`
+	Difficulty  *hexutil.Uint              `json:"difficulty"`
+	BaseFee     *uint256.Int               `json:"baseFee"`
+	BlockHash   *map[uint64]libcommon.Hash `json:"blockHash"`
+}
+
+// Override applies the overrides to blockCtx. The overridden block hashes take precedence over the ones returned
+// by the GetHash function of blockCtx.
+func (overrides *BlockOverrides) Override(blockCtx *evmtypes.BlockContext) {
+	if overrides.BlockNumber != nil {
+		blockCtx.BlockNumber = uint64(*overrides.BlockNumber)
+	}
+	if overrides.BaseFee != nil {
+		blockCtx.BaseFee = overrides.BaseFee
+	}
+	if overrides.Coinbase != nil {
+		blockCtx.Coinbase = *overrides.Coinbase
+	}
+	if overrides.Difficulty != nil {
+		blockCtx.Difficulty = big.NewInt(int64(*overrides.Difficulty))
+	}
+	if overrides.Timestamp != nil {
+		blockCtx.Time = uint64(*overrides.Timestamp)
+	}
+	if overrides.GasLimit != nil {
+		blockCtx.GasLimit = uint64(*overrides.GasLimit)
+	}
+	if overrides.BlockHash != nil {
+		hashes := make(map[uint64]libcommon.Hash, len(*overrides.BlockHash))
+		for blockNum, hash := range *overrides.BlockHash {
+			hashes[blockNum] = hash
+		}
+		getHash := blockCtx.GetHash
+		blockCtx.GetHash = func(blockNum uint64) libcommon.Hash {
+			if hash, ok := hashes[blockNum]; ok {
+				return hash
+			}
+			return getHash(blockNum)
+		}
+	}
+}
@@ -15,6 +15,7 @@ type BlockBuilderFunc func(param *core.BlockBuilderParameters, interrupt *int32)
 
 // BlockBuilder wraps a goroutine that builds Proof-of-Stake payloads (PoS "mining")
 type BlockBuilder struct {
+	param     *core.BlockBuilderParameters
 	interrupt int32
 	syncCond  *sync.Cond
 	result    *types.BlockWithReceipts
@@ -22,7 +23,7 @@ type BlockBuilder struct {
 }
 
 func NewBlockBuilder(build BlockBuilderFunc, param *core.BlockBuilderParameters) *BlockBuilder {
-	builder := new(BlockBuilder)
+	builder := &BlockBuilder{param: param}
 	builder.syncCond = sync.NewCond(new(sync.Mutex))
 
 	go func() {
@@ -58,6 +59,11 @@ func (b *BlockBuilder) Stop() (*types.BlockWithReceipts, error) {
 	return b.result, b.err
 }
 
+// Parameters returns the parameters of the block being built
+func (b *BlockBuilder) Parameters() *core.BlockBuilderParameters {
+	return b.param
+}
+
 func (b *BlockBuilder) Block() *types.Block {
 	b.syncCond.L.Lock()
 	defer b.syncCond.L.Unlock()
@@ -0,0 +1,92 @@
+package builder
+
+import (
+	"sync"
+
+	libcommon "github.com/ledgerwatch/erigon-lib/common"
+	"github.com/ledgerwatch/log/v3"
+)
+
+// CircuitBreaker stops the use of the external builder after consecutive missed slots, i.e. payloads of the builder
+// which did not make it into the chain, for a number of slots built locally.
+type CircuitBreaker struct {
+	lock         sync.Mutex
+	maxMissed    int
+	cooldown     int
+	missed       int  // Consecutive missed slots
+	cooldownLeft int  // Slots left before trying the builder again
+	pending      bool // Whether a payload of the builder was delivered and not seen in the chain yet
+	pendingHash  libcommon.Hash
+	pendingBlock uint64
+}
+
+func NewCircuitBreaker(maxMissed, cooldown int) *CircuitBreaker {
+	return &CircuitBreaker{maxMissed: maxMissed, cooldown: cooldown}
+}
+
+// Allow tells whether the builder can be used for the payload of the current slot
+func (cb *CircuitBreaker) Allow() bool {
+	cb.lock.Lock()
+	defer cb.lock.Unlock()
+	if cb.cooldownLeft > 0 {
+		cb.cooldownLeft--
+		return false
+	}
+	return true
+}
+
+// Open tells whether the builder is not used because of missed slots
+func (cb *CircuitBreaker) Open() bool {
+	cb.lock.Lock()
+	defer cb.lock.Unlock()
+	return cb.cooldownLeft > 0
+}
+
+// Delivered records a payload of the builder handed over to the consensus layer
+func (cb *CircuitBreaker) Delivered(number uint64, hash libcommon.Hash) {
+	cb.lock.Lock()
+	defer cb.lock.Unlock()
+	if cb.pending {
+		// The previous payload was not seen, and superseded before a new head reached it
+		cb.miss()
+	}
+	cb.pending, cb.pendingBlock, cb.pendingHash = true, number, hash
+}
+
+// Seen records a payload received from the consensus layer, which includes the delivered payload of the builder if
+// it made it into the chain
+func (cb *CircuitBreaker) Seen(hash libcommon.Hash) {
+	cb.lock.Lock()
+	defer cb.lock.Unlock()
+	if cb.pending && hash == cb.pendingHash {
+		cb.pending = false
+		cb.missed = 0
+	}
+}
+
+// Head records a new head of the chain. The delivered payload of the builder is missed if the head reached its number
+// without it being seen.
+func (cb *CircuitBreaker) Head(number uint64, hash libcommon.Hash) {
+	cb.lock.Lock()
+	defer cb.lock.Unlock()
+	if !cb.pending {
+		return
+	}
+	if hash == cb.pendingHash {
+		cb.pending = false
+		cb.missed = 0
+	} else if number >= cb.pendingBlock {
+		cb.miss()
+	}
+}
+
+func (cb *CircuitBreaker) miss() {
+	cb.pending = false
+	cb.missed++
+	log.Warn("[builder] Missed slot with the payload of the builder", "block", cb.pendingBlock, "hash", cb.pendingHash, "consecutive", cb.missed)
+	if cb.missed >= cb.maxMissed {
+		log.Warn("[builder] Too many missed slots, building locally", "slots", cb.cooldown)
+		cb.missed = 0
+		cb.cooldownLeft = cb.cooldown
+	}
+}
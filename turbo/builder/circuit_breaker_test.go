@@ -0,0 +1,47 @@
+package builder
+
+import (
+	"testing"
+
+	libcommon "github.com/ledgerwatch/erigon-lib/common"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCircuitBreaker(t *testing.T) {
+	cb := NewCircuitBreaker(2, 3)
+	require.True(t, cb.Allow())
+
+	// Delivered payload seen in the chain
+	cb.Delivered(10, libcommon.Hash{10})
+	cb.Seen(libcommon.Hash{10})
+	cb.Head(10, libcommon.Hash{10})
+	require.False(t, cb.Open())
+
+	// Two consecutive missed slots open the circuit
+	require.True(t, cb.Allow())
+	cb.Delivered(11, libcommon.Hash{11})
+	cb.Head(11, libcommon.Hash{0x11})
+	require.False(t, cb.Open())
+	require.True(t, cb.Allow())
+	cb.Delivered(12, libcommon.Hash{12})
+	cb.Head(11, libcommon.Hash{0x11}) // Head not reaching the payload yet
+	require.False(t, cb.Open())
+	cb.Head(12, libcommon.Hash{0x12})
+	require.True(t, cb.Open())
+
+	// The builder is not used for the cooldown slots
+	for i := 0; i < 3; i++ {
+		require.False(t, cb.Allow())
+	}
+	require.False(t, cb.Open())
+	require.True(t, cb.Allow())
+
+	// A payload seen in the chain resets the missed slots
+	cb.Delivered(20, libcommon.Hash{20})
+	cb.Head(20, libcommon.Hash{0x20})
+	cb.Delivered(21, libcommon.Hash{21})
+	cb.Head(21, libcommon.Hash{21})
+	cb.Delivered(22, libcommon.Hash{22})
+	cb.Head(22, libcommon.Hash{0x22})
+	require.False(t, cb.Open())
+}
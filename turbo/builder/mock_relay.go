@@ -0,0 +1,60 @@
+package builder
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+
+	libcommon "github.com/ledgerwatch/erigon-lib/common"
+)
+
+// MockRelay is a builder-API endpoint serving the bids it is given, for tests and devnets
+type MockRelay struct {
+	lock     sync.Mutex
+	bids     map[libcommon.Hash]*Bid // By parent hash
+	requests int
+}
+
+func NewMockRelay() *MockRelay {
+	return &MockRelay{bids: map[libcommon.Hash]*Bid{}}
+}
+
+// SetBid makes the relay serve bid for the payloads built on its parent
+func (m *MockRelay) SetBid(bid *Bid) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	m.bids[bid.Payload.ParentHash] = bid
+}
+
+// Requests returns the number of bids requested so far
+func (m *MockRelay) Requests() int {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	return m.requests
+}
+
+func (m *MockRelay) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	parts := strings.Split(strings.TrimPrefix(r.URL.Path, BidPath), "/")
+	if !strings.HasPrefix(r.URL.Path, BidPath) || len(parts) != 2 {
+		http.NotFound(w, r)
+		return
+	}
+	timestamp, err := strconv.ParseUint(parts[1], 10, 64)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	m.lock.Lock()
+	m.requests++
+	bid, ok := m.bids[libcommon.HexToHash(parts[0])]
+	m.lock.Unlock()
+	if !ok || uint64(bid.Payload.Timestamp) != timestamp {
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(&bidResponse{Data: bid})
+}
@@ -0,0 +1,121 @@
+package builder
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/ledgerwatch/erigon/common/hexutil"
+	"github.com/ledgerwatch/erigon/core"
+	"github.com/ledgerwatch/erigon/turbo/engineapi/engine_types"
+)
+
+// BidPath is the path of the builder-API endpoint serving the best bid for a payload, after the parent hash and the
+// timestamp of the payload. The fee recipient and the prev randao of the payload are passed as query parameters.
+const BidPath = "/eth/v1/builder/payload/"
+
+// Bid is a payload offered by an external block builder, with the value it pays to the fee recipient. The value is paid
+// by the last transaction of the payload, a plain transfer to the fee recipient.
+type Bid struct {
+	Value   *hexutil.Big                   `json:"value"`
+	Payload *engine_types.ExecutionPayload `json:"executionPayload"`
+}
+
+type bidResponse struct {
+	Data *Bid `json:"data"`
+}
+
+// Relay fetches bids from a builder-API endpoint. Its circuit breaker tells whether the bids can be used, depending on
+// the slots missed with the payloads of the builder.
+type Relay struct {
+	url     *url.URL
+	client  http.Client
+	Breaker *CircuitBreaker
+}
+
+// NewRelay returns a relay to the builder-API endpoint at rawURL, not used after maxMissedSlots consecutive missed
+// slots for cooldownSlots slots
+func NewRelay(rawURL string, timeout time.Duration, maxMissedSlots, cooldownSlots int) (*Relay, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid builder relay url %q: %w", rawURL, err)
+	}
+	return &Relay{
+		url:     u,
+		client:  http.Client{Timeout: timeout},
+		Breaker: NewCircuitBreaker(maxMissedSlots, cooldownSlots),
+	}, nil
+}
+
+// GetBid fetches the best bid for a payload with the given parameters, nil if the builder has none
+func (r *Relay) GetBid(ctx context.Context, param *core.BlockBuilderParameters) (*Bid, error) {
+	u := *r.url
+	u.Path = strings.TrimSuffix(u.Path, "/") + BidPath + fmt.Sprintf("%s/%d", param.ParentHash.Hex(), param.Timestamp)
+	u.RawQuery = url.Values{
+		"fee_recipient": {param.SuggestedFeeRecipient.Hex()},
+		"prev_randao":   {param.PrevRandao.Hex()},
+	}.Encode()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u.String(), nil)
+	if err != nil {
+		return nil, err
+	}
+	res, err := r.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode == http.StatusNoContent {
+		return nil, nil
+	}
+	if res.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("builder relay responded with status %d", res.StatusCode)
+	}
+	body, err := io.ReadAll(res.Body)
+	if err != nil {
+		return nil, err
+	}
+	var response bidResponse
+	if err := json.Unmarshal(body, &response); err != nil {
+		return nil, fmt.Errorf("invalid bid: %w", err)
+	}
+	bid := response.Data
+	if bid == nil {
+		return nil, nil
+	}
+	if bid.Value == nil || bid.Payload == nil {
+		return nil, fmt.Errorf("invalid bid: missing value or payload")
+	}
+	if err := checkBid(bid.Payload, param); err != nil {
+		return nil, err
+	}
+	return bid, nil
+}
+
+// checkBid checks that a payload is built with the requested parameters
+func checkBid(payload *engine_types.ExecutionPayload, param *core.BlockBuilderParameters) error {
+	switch {
+	case payload.ParentHash != param.ParentHash:
+		return fmt.Errorf("invalid bid: parent hash %x, requested %x", payload.ParentHash, param.ParentHash)
+	case uint64(payload.Timestamp) != param.Timestamp:
+		return fmt.Errorf("invalid bid: timestamp %d, requested %d", payload.Timestamp, param.Timestamp)
+	case payload.PrevRandao != param.PrevRandao:
+		return fmt.Errorf("invalid bid: prev randao %x, requested %x", payload.PrevRandao, param.PrevRandao)
+	case payload.FeeRecipient != param.SuggestedFeeRecipient:
+		return fmt.Errorf("invalid bid: fee recipient %x, requested %x", payload.FeeRecipient, param.SuggestedFeeRecipient)
+	case len(payload.Withdrawals) != len(param.Withdrawals):
+		return fmt.Errorf("invalid bid: %d withdrawals, requested %d", len(payload.Withdrawals), len(param.Withdrawals))
+	}
+	for i, w := range payload.Withdrawals {
+		if *w != *param.Withdrawals[i] {
+			return fmt.Errorf("invalid bid: withdrawal %d differs from the requested one", i)
+		}
+	}
+	return nil
+}
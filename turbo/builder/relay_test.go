@@ -0,0 +1,63 @@
+package builder
+
+import (
+	"context"
+	"math/big"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	libcommon "github.com/ledgerwatch/erigon-lib/common"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ledgerwatch/erigon/common/hexutil"
+	"github.com/ledgerwatch/erigon/core"
+	"github.com/ledgerwatch/erigon/core/types"
+	"github.com/ledgerwatch/erigon/turbo/engineapi/engine_types"
+)
+
+func TestRelayGetBid(t *testing.T) {
+	mock := NewMockRelay()
+	server := httptest.NewServer(mock)
+	defer server.Close()
+	relay, err := NewRelay(server.URL, time.Second, 3, 32)
+	require.NoError(t, err)
+
+	param := &core.BlockBuilderParameters{
+		ParentHash:            libcommon.Hash{1},
+		Timestamp:             100,
+		PrevRandao:            libcommon.Hash{2},
+		SuggestedFeeRecipient: libcommon.Address{3},
+		Withdrawals:           []*types.Withdrawal{{Index: 1, Validator: 2, Amount: 3}},
+	}
+	ctx := context.Background()
+
+	// No bid
+	bid, err := relay.GetBid(ctx, param)
+	require.NoError(t, err)
+	require.Nil(t, bid)
+	require.Equal(t, 1, mock.Requests())
+
+	payload := &engine_types.ExecutionPayload{
+		ParentHash:   param.ParentHash,
+		Timestamp:    hexutil.Uint64(param.Timestamp),
+		PrevRandao:   param.PrevRandao,
+		FeeRecipient: param.SuggestedFeeRecipient,
+		BlockHash:    libcommon.Hash{4},
+		Withdrawals:  []*types.Withdrawal{{Index: 1, Validator: 2, Amount: 3}},
+	}
+	mock.SetBid(&Bid{Value: (*hexutil.Big)(big.NewInt(1000)), Payload: payload})
+	bid, err = relay.GetBid(ctx, param)
+	require.NoError(t, err)
+	require.Equal(t, big.NewInt(1000), bid.Value.ToInt())
+	require.Equal(t, payload.BlockHash, bid.Payload.BlockHash)
+
+	// Bid not matching the requested parameters
+	payload.FeeRecipient = libcommon.Address{5}
+	_, err = relay.GetBid(ctx, param)
+	require.Error(t, err)
+	payload.FeeRecipient = param.SuggestedFeeRecipient
+	payload.Withdrawals[0].Amount = 4
+	_, err = relay.GetBid(ctx, param)
+	require.Error(t, err)
+}
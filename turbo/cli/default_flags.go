@@ -72,9 +72,20 @@ var DefaultFlags = []cli.Flag{
 	&utils.RpcGasCapFlag,
 	&utils.RpcBatchLimit,
 	&utils.RpcReturnDataLimit,
+	&utils.RpcSignerKeystoreFlag,
+	&utils.RpcSignerPasswordFlag,
+	&utils.RpcSignerURLFlag,
+	&utils.RpcCaptureDirFlag,
+	&utils.RpcCaptureSampleFlag,
+	&utils.RpcCaptureFileSizeFlag,
+	&utils.RpcCaptureMaxFilesFlag,
+	&utils.RpcCaptureMethodsFlag,
+	&utils.RpcCaptureRedactFlag,
 	&utils.RPCGlobalTxFeeCapFlag,
 	&utils.TxpoolApiAddrFlag,
 	&utils.TraceMaxtracesFlag,
+	&utils.RpcMaxLogsFlag,
+	&utils.RpcPrivateTxClientsFlag,
 	&HTTPReadTimeoutFlag,
 	&HTTPWriteTimeoutFlag,
 	&HTTPIdleTimeoutFlag,
@@ -98,6 +109,11 @@ var DefaultFlags = []cli.Flag{
 	&utils.ListenPortFlag,
 	&utils.P2pProtocolVersionFlag,
 	&utils.P2pProtocolAllowedPorts,
+	&utils.P2pProtocolSnapFlag,
+	&utils.P2pENRFilterFlag,
+	&utils.P2pTxBroadcastFlag,
+	&utils.P2pTxPeerRateFlag,
+	&utils.P2pSentryIdentitiesFlag,
 	&utils.NATFlag,
 	&utils.NoDiscoverFlag,
 	&utils.DiscoveryV5Flag,
@@ -125,6 +141,10 @@ var DefaultFlags = []cli.Flag{
 	&utils.CliqueDataDirFlag,
 	&utils.MiningEnabledFlag,
 	&utils.ProposingDisableFlag,
+	&utils.BuilderRelayURLFlag,
+	&utils.BuilderRelayTimeoutFlag,
+	&utils.BuilderRelayMaxMissedSlotsFlag,
+	&utils.BuilderRelayCooldownSlotsFlag,
 	&utils.MinerNotifyFlag,
 	&utils.MinerGasLimitFlag,
 	&utils.MinerEtherbaseFlag,
@@ -18,6 +18,7 @@ import (
 	"github.com/spf13/pflag"
 	"github.com/urfave/cli/v2"
 
+	"github.com/ledgerwatch/erigon/accounts/signer"
 	"github.com/ledgerwatch/erigon/cmd/rpcdaemon/cli/httpcfg"
 	"github.com/ledgerwatch/erigon/cmd/utils"
 	"github.com/ledgerwatch/erigon/common/hexutil"
@@ -393,9 +394,23 @@ func setEmbeddedRpcDaemon(ctx *cli.Context, cfg *nodecfg.Config, logger log.Logg
 		RpcAllowListFilePath: ctx.String(utils.RpcAccessListFlag.Name),
 		Gascap:               ctx.Uint64(utils.RpcGasCapFlag.Name),
 		MaxTraces:            ctx.Uint64(utils.TraceMaxtracesFlag.Name),
+		MaxLogs:              ctx.Uint64(utils.RpcMaxLogsFlag.Name),
+		PrivateTxClients:     ctx.String(utils.RpcPrivateTxClientsFlag.Name),
 		TraceCompatibility:   ctx.Bool(utils.RpcTraceCompatFlag.Name),
 		BatchLimit:           ctx.Int(utils.RpcBatchLimit.Name),
 		ReturnDataLimit:      ctx.Int(utils.RpcReturnDataLimit.Name),
+		Signer: signer.Config{
+			Keystore:     ctx.String(utils.RpcSignerKeystoreFlag.Name),
+			PasswordFile: ctx.String(utils.RpcSignerPasswordFlag.Name),
+			URL:          ctx.String(utils.RpcSignerURLFlag.Name),
+		},
+		Capture: rpccfg.CaptureConfig{
+			Dir:        ctx.String(utils.RpcCaptureDirFlag.Name),
+			SampleRate: ctx.Float64(utils.RpcCaptureSampleFlag.Name),
+			MaxFiles:   ctx.Int(utils.RpcCaptureMaxFilesFlag.Name),
+			Methods:    utils.SplitAndTrim(ctx.String(utils.RpcCaptureMethodsFlag.Name)),
+			Redact:     utils.SplitAndTrim(ctx.String(utils.RpcCaptureRedactFlag.Name)),
+		},
 
 		TxPoolApiAddr: ctx.String(utils.TxpoolApiAddrFlag.Name),
 
@@ -412,6 +427,10 @@ func setEmbeddedRpcDaemon(ctx *cli.Context, cfg *nodecfg.Config, logger log.Logg
 		c.WebsocketCompression = true
 	}
 
+	if err := c.Capture.MaxFileSize.UnmarshalText([]byte(ctx.String(utils.RpcCaptureFileSizeFlag.Name))); err != nil {
+		utils.Fatalf("Invalid rpc.capture.filesize value provided")
+	}
+
 	err := c.StateCache.CacheSize.UnmarshalText([]byte(ctx.String(utils.StateCacheFlag.Name)))
 	if err != nil {
 		utils.Fatalf("Invalid state.cache value provided")
@@ -32,6 +32,7 @@ import (
 	"github.com/ledgerwatch/erigon/consensus/merge"
 	"github.com/ledgerwatch/erigon/core"
 	"github.com/ledgerwatch/erigon/core/rawdb"
+	"github.com/ledgerwatch/erigon/core/state"
 	"github.com/ledgerwatch/erigon/core/types"
 	"github.com/ledgerwatch/erigon/rpc"
 	"github.com/ledgerwatch/erigon/turbo/builder"
@@ -61,6 +62,7 @@ type EngineServer struct {
 	builders       map[uint64]*builder.BlockBuilder
 	builderFunc    builder.BlockBuilderFunc
 	proposing      bool
+	relay          *builder.Relay // External block builder, nil if payloads are only built locally
 
 	ctx    context.Context
 	lock   sync.Mutex
@@ -85,6 +87,11 @@ func NewEngineServer(ctx context.Context, logger log.Logger, config *chain.Confi
 	}
 }
 
+// SetRelay makes the payloads compete with the bids of an external block builder
+func (e *EngineServer) SetRelay(relay *builder.Relay) {
+	e.relay = relay
+}
+
 func (e *EngineServer) Start(httpConfig httpcfg.HttpCfg,
 	filters *rpchelper.Filters, stateCache kvcache.Cache, agg *libstate.AggregatorV3, engineReader consensus.EngineReader,
 	eth rpchelper.ApiBackend, txPool txpool.TxpoolClient, mining txpool.MiningClient) {
@@ -136,17 +143,12 @@ func (s *EngineServer) checkWithdrawalsPresence(time uint64, withdrawals []*type
 	return nil
 }
 
-// EngineNewPayload validates and possibly executes payload
-func (s *EngineServer) newPayload(ctx context.Context, req *engine_types.ExecutionPayload, version clparams.StateVersion) (*engine_types.PayloadStatus, error) {
+// payloadHeader returns the header of an execution payload, with the fields of the given version
+func payloadHeader(req *engine_types.ExecutionPayload, txs [][]byte, withdrawals []*types.Withdrawal, version clparams.StateVersion) *types.Header {
 	var bloom types.Bloom
 	copy(bloom[:], req.LogsBloom)
 
-	txs := [][]byte{}
-	for _, transaction := range req.Transactions {
-		txs = append(txs, transaction)
-	}
-
-	header := types.Header{
+	header := &types.Header{
 		ParentHash:  req.ParentHash,
 		Coinbase:    req.FeeRecipient,
 		Root:        req.StateRoot,
@@ -164,24 +166,36 @@ func (s *EngineServer) newPayload(ctx context.Context, req *engine_types.Executi
 		ReceiptHash: req.ReceiptsRoot,
 		TxHash:      types.DeriveSha(types.BinaryTransactions(txs)),
 	}
-	var withdrawals []*types.Withdrawal
-	if version >= clparams.CapellaVersion {
-		withdrawals = req.Withdrawals
-	}
 
 	if withdrawals != nil {
 		wh := types.DeriveSha(types.Withdrawals(withdrawals))
 		header.WithdrawalsHash = &wh
 	}
 
-	if err := s.checkWithdrawalsPresence(header.Time, withdrawals); err != nil {
-		return nil, err
-	}
-
 	if version >= clparams.DenebVersion {
 		header.DataGasUsed = (*uint64)(req.DataGasUsed)
 		header.ExcessDataGas = (*uint64)(req.ExcessDataGas)
 	}
+	return header
+}
+
+// EngineNewPayload validates and possibly executes payload
+func (s *EngineServer) newPayload(ctx context.Context, req *engine_types.ExecutionPayload, version clparams.StateVersion) (*engine_types.PayloadStatus, error) {
+	txs := [][]byte{}
+	for _, transaction := range req.Transactions {
+		txs = append(txs, transaction)
+	}
+
+	var withdrawals []*types.Withdrawal
+	if version >= clparams.CapellaVersion {
+		withdrawals = req.Withdrawals
+	}
+
+	if err := s.checkWithdrawalsPresence(uint64(req.Timestamp), withdrawals); err != nil {
+		return nil, err
+	}
+
+	header := payloadHeader(req, txs, withdrawals, version)
 
 	if !s.config.IsCancun(header.Time) && (header.DataGasUsed != nil || header.ExcessDataGas != nil) {
 		return nil, &rpc.InvalidParamsError{Message: "dataGasUsed/excessDataGas present before Cancun"}
@@ -199,6 +213,9 @@ func (s *EngineServer) newPayload(ctx context.Context, req *engine_types.Executi
 			ValidationError: engine_types.NewStringifiedErrorFromString("invalid block hash"),
 		}, nil
 	}
+	if s.relay != nil {
+		s.relay.Breaker.Seen(blockHash)
+	}
 
 	for _, txn := range req.Transactions {
 		if types.TypedTransactionMarshalledAsRlpString(txn) {
@@ -218,7 +235,7 @@ func (s *EngineServer) newPayload(ctx context.Context, req *engine_types.Executi
 			ValidationError: engine_types.NewStringifiedError(err),
 		}, nil
 	}
-	block := types.NewBlockFromStorage(blockHash, &header, transactions, nil /* uncles */, withdrawals)
+	block := types.NewBlockFromStorage(blockHash, header, transactions, nil /* uncles */, withdrawals)
 
 	possibleStatus, err := s.getQuickPayloadStatusIfPossible(blockHash, uint64(req.BlockNumber), header.ParentHash, nil, true)
 	if err != nil {
@@ -231,7 +248,12 @@ func (s *EngineServer) newPayload(ctx context.Context, req *engine_types.Executi
 	s.lock.Lock()
 	defer s.lock.Unlock()
 
-	s.logger.Debug("[NewPayload] sending block", "height", header.Number, "hash", blockHash)
+	return s.sendPayloadRequest(block)
+}
+
+// sendPayloadRequest has the stage loop validate a new block, the caller must hold the lock
+func (s *EngineServer) sendPayloadRequest(block *types.Block) (*engine_types.PayloadStatus, error) {
+	s.logger.Debug("[NewPayload] sending block", "height", block.Number(), "hash", block.Hash())
 	s.hd.BeaconRequestList.AddPayloadRequest(block)
 
 	payloadStatus := <-s.hd.PayloadStatusCh
@@ -405,13 +427,22 @@ func (s *EngineServer) getPayload(ctx context.Context, payloadId uint64) (*engin
 	defer s.lock.Unlock()
 	s.logger.Debug("[GetPayload] lock acquired")
 
-	builder, ok := s.builders[payloadId]
+	blockBuilder, ok := s.builders[payloadId]
 	if !ok {
 		log.Warn("Payload not stored", "payloadId", payloadId)
 		return nil, &engine_helpers.UnknownPayloadErr
 	}
 
-	blockWithReceipts, err := builder.Stop()
+	// The bid of the external builder is fetched while the local block is completed
+	var relayBidCh chan *externalBid
+	if s.relay != nil && s.relay.Breaker.Allow() {
+		relayBidCh = make(chan *externalBid, 1)
+		go func(param *core.BlockBuilderParameters) {
+			relayBidCh <- s.relayBid(ctx, param)
+		}(blockBuilder.Parameters())
+	}
+
+	blockWithReceipts, err := blockBuilder.Stop()
 	if err != nil {
 		s.logger.Error("Failed to build PoS block", "err", err)
 		return nil, err
@@ -458,6 +489,18 @@ func (s *EngineServer) getPayload(ctx context.Context, payloadId uint64) (*engin
 
 	blockValue := blockValue(blockWithReceipts, baseFee)
 
+	if relayBidCh != nil {
+		if bid := <-relayBidCh; bid != nil && bid.Value.ToInt().Cmp(blockValue.ToBig()) > 0 && s.validateBid(bid) {
+			s.logger.Info("[GetPayload] using payload of the external builder", "hash", bid.Payload.BlockHash, "value", bid.Value.ToInt(), "localValue", blockValue)
+			s.relay.Breaker.Delivered(uint64(bid.Payload.BlockNumber), bid.Payload.BlockHash)
+			return &engine_types.GetPayloadResponse{
+				ExecutionPayload: bid.Payload,
+				BlockValue:       bid.Value,
+				BlobsBundle:      &engine_types.BlobsBundleV1{},
+			}, nil
+		}
+	}
+
 	blobsBundle := &engine_types.BlobsBundleV1{}
 	for i, tx := range block.Transactions() {
 		if tx.Type() != types.BlobTxType {
@@ -491,6 +534,125 @@ func (s *EngineServer) getPayload(ctx context.Context, payloadId uint64) (*engin
 	}, nil
 }
 
+// externalBid is a bid of the external builder along with the block of its payload
+type externalBid struct {
+	*builder.Bid
+	block *types.Block
+}
+
+// relayBid fetches the bid of the external builder for a payload, nil if there is none or it is invalid
+func (s *EngineServer) relayBid(ctx context.Context, param *core.BlockBuilderParameters) *externalBid {
+	bid, err := s.relay.GetBid(ctx, param)
+	if err != nil {
+		s.logger.Warn("[GetPayload] failed to get bid of the external builder", "err", err)
+		return nil
+	}
+	if bid == nil {
+		return nil
+	}
+	block, err := s.bidBlock(bid.Payload)
+	if err != nil {
+		s.logger.Warn("[GetPayload] invalid payload of the external builder", "hash", bid.Payload.BlockHash, "err", err)
+		return nil
+	}
+	if err := s.checkBidPayment(block, bid.Value.ToInt()); err != nil {
+		s.logger.Warn("[GetPayload] invalid payment of the external builder", "hash", bid.Payload.BlockHash, "value", bid.Value.ToInt(), "err", err)
+		return nil
+	}
+	return &externalBid{Bid: bid, block: block}
+}
+
+// bidBlock checks the fields of a payload of the external builder which can be checked without executing it
+// and returns its block
+func (s *EngineServer) bidBlock(payload *engine_types.ExecutionPayload) (*types.Block, error) {
+	version := clparams.BellatrixVersion
+	if s.config.IsCancun(uint64(payload.Timestamp)) {
+		version = clparams.DenebVersion
+	} else if s.config.IsShanghai(uint64(payload.Timestamp)) {
+		version = clparams.CapellaVersion
+	}
+	if err := s.checkWithdrawalsPresence(uint64(payload.Timestamp), payload.Withdrawals); err != nil {
+		return nil, err
+	}
+
+	txs := make([][]byte, 0, len(payload.Transactions))
+	for _, txn := range payload.Transactions {
+		if len(txn) > 0 && txn[0] == types.BlobTxType {
+			return nil, fmt.Errorf("blob transactions are not supported in payloads of the external builder")
+		}
+		txs = append(txs, txn)
+	}
+	transactions, err := types.DecodeTransactions(txs)
+	if err != nil {
+		return nil, err
+	}
+	header := payloadHeader(payload, txs, payload.Withdrawals, version)
+	if header.Hash() != payload.BlockHash {
+		return nil, fmt.Errorf("invalid block hash %x, actual %x", payload.BlockHash, header.Hash())
+	}
+	return types.NewBlockFromStorage(payload.BlockHash, header, transactions, nil /* uncles */, payload.Withdrawals), nil
+}
+
+// checkBidPayment checks that the value of a bid is paid to the fee recipient by the last transaction of its block.
+// The value reported by the builder is not trusted, and the priority fees of the block are not counted in the payment.
+// The payment must be a plain transfer to an account without code, so that it can't be reverted.
+func (s *EngineServer) checkBidPayment(block *types.Block, value *big.Int) error {
+	txs := block.Transactions()
+	if len(txs) == 0 {
+		return fmt.Errorf("missing payment to the fee recipient")
+	}
+	payment := txs[len(txs)-1]
+	if to := payment.GetTo(); to == nil || *to != block.Coinbase() {
+		return fmt.Errorf("last transaction does not pay the fee recipient")
+	}
+	if len(payment.GetData()) > 0 {
+		return fmt.Errorf("payment to the fee recipient has call data")
+	}
+	if payment.GetValue().ToBig().Cmp(value) < 0 {
+		return fmt.Errorf("payment to the fee recipient %d is below the value of the bid", payment.GetValue())
+	}
+	sender, err := payment.Sender(*types.MakeSigner(s.config, block.NumberU64(), block.Time()))
+	if err != nil {
+		return err
+	}
+	if sender == block.Coinbase() {
+		return fmt.Errorf("payment to the fee recipient is sent by the fee recipient")
+	}
+
+	tx, err := s.db.BeginRo(s.ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	account, err := state.NewPlainStateReader(tx).ReadAccountData(block.Coinbase())
+	if err != nil {
+		return err
+	}
+	if account != nil && !account.IsEmptyCodeHash() {
+		return fmt.Errorf("fee recipient %x has code", block.Coinbase())
+	}
+	return nil
+}
+
+// validateBid executes the block of a bid like a new payload, it is preferred to the local payload only if it is valid.
+// The caller must hold the lock.
+func (s *EngineServer) validateBid(bid *externalBid) bool {
+	block := bid.block
+	status, err := s.getQuickPayloadStatusIfPossible(block.Hash(), block.NumberU64(), block.ParentHash(), nil, true)
+	if err == nil && status == nil {
+		status, err = s.sendPayloadRequest(block)
+	}
+	if err != nil {
+		s.logger.Warn("[GetPayload] failed to validate payload of the external builder", "hash", block.Hash(), "err", err)
+		return false
+	}
+	if status.Status != engine_types.ValidStatus {
+		s.logger.Warn("[GetPayload] payload of the external builder is not valid", "hash", block.Hash(), "status", status.Status, "err", status.ValidationError)
+		return false
+	}
+	return true
+}
+
 // engineForkChoiceUpdated either states new block head or request the assembling of a new block
 func (s *EngineServer) forkchoiceUpdated(ctx context.Context, forkchoiceState *engine_types.ForkChoiceState, payloadAttributes *engine_types.PayloadAttributes, version clparams.StateVersion,
 ) (*engine_types.ForkChoiceUpdatedResponse, error) {
@@ -521,6 +683,17 @@ func (s *EngineServer) forkchoiceUpdated(ctx context.Context, forkchoiceState *e
 		}
 	}
 
+	if s.relay != nil && status.Status == engine_types.ValidStatus {
+		if err := s.db.View(ctx, func(tx kv.Tx) error {
+			if number := rawdb.ReadHeaderNumber(tx, forkChoice.HeadHash); number != nil {
+				s.relay.Breaker.Head(*number, forkChoice.HeadHash)
+			}
+			return nil
+		}); err != nil {
+			return nil, err
+		}
+	}
+
 	// No need for payload building
 	if payloadAttributes == nil || status.Status != engine_types.ValidStatus {
 		return &engine_types.ForkChoiceUpdatedResponse{PayloadStatus: status}, nil
@@ -3,16 +3,25 @@ package engineapi
 import (
 	"context"
 	"math/big"
+	"net/http/httptest"
 	"testing"
+	"time"
 
+	"github.com/holiman/uint256"
 	"github.com/ledgerwatch/erigon-lib/chain"
 	libcommon "github.com/ledgerwatch/erigon-lib/common"
+	"github.com/ledgerwatch/erigon-lib/common/hexutility"
 	"github.com/ledgerwatch/erigon-lib/kv"
 	"github.com/ledgerwatch/erigon-lib/kv/memdb"
 	"github.com/stretchr/testify/require"
 
+	"github.com/ledgerwatch/erigon/cl/clparams"
 	"github.com/ledgerwatch/erigon/common/hexutil"
+	"github.com/ledgerwatch/erigon/core"
 	"github.com/ledgerwatch/erigon/core/rawdb"
+	"github.com/ledgerwatch/erigon/core/types"
+	"github.com/ledgerwatch/erigon/crypto"
+	"github.com/ledgerwatch/erigon/turbo/builder"
 	"github.com/ledgerwatch/erigon/turbo/engineapi/engine_types"
 	"github.com/ledgerwatch/erigon/turbo/stages/headerdownload"
 	"github.com/ledgerwatch/log/v3"
@@ -237,3 +246,99 @@ func TestNoTTD(t *testing.T) {
 
 	require.Equal(err.Error(), "not a proof-of-stake chain")
 }
+
+func TestGetPayloadWithRelay(t *testing.T) {
+	logger := log.New()
+	db := memdb.NewTestDB(t)
+	ctx := context.Background()
+	require := require.New(t)
+
+	hd := headerdownload.NewHeaderDownload(0, 0, nil, nil, logger)
+	hd.SetPOSSync(true)
+	local := types.NewBlockWithHeader(&types.Header{Number: big.NewInt(51), ParentHash: startingHeadHash, BaseFee: big.NewInt(0x0b3), Time: 4})
+	buildLocal := func(*core.BlockBuilderParameters, *int32) (*types.BlockWithReceipts, error) {
+		return &types.BlockWithReceipts{Block: local}, nil
+	}
+	config := &chain.Config{TerminalTotalDifficulty: libcommon.Big1}
+	backend := NewEngineServer(ctx, logger, config, buildLocal, db, nil, hd, true)
+
+	mock := builder.NewMockRelay()
+	server := httptest.NewServer(mock)
+	defer server.Close()
+	relay, err := builder.NewRelay(server.URL, time.Second, 1 /* maxMissedSlots */, 1 /* cooldownSlots */)
+	require.NoError(err)
+	backend.SetRelay(relay)
+
+	param := &core.BlockBuilderParameters{
+		ParentHash:            startingHeadHash,
+		Timestamp:             4,
+		PrevRandao:            libcommon.HexToHash("0x0b3"),
+		SuggestedFeeRecipient: libcommon.HexToAddress("0x1"),
+	}
+	getPayload := func() *engine_types.GetPayloadResponse {
+		backend.payloadId++
+		backend.builders[backend.payloadId] = builder.NewBlockBuilder(buildLocal, param)
+		res, err := backend.getPayload(ctx, backend.payloadId)
+		require.NoError(err)
+		return res
+	}
+	// The payload of the builder is validated by the stage loop, which replies with status
+	getPayloadValidated := func(status engine_types.EngineStatus) *engine_types.GetPayloadResponse {
+		go func() {
+			_, id, _ := hd.BeaconRequestList.WaitForRequest(true, false)
+			hd.BeaconRequestList.Remove(id)
+			hd.PayloadStatusCh <- engine_types.PayloadStatus{Status: status}
+		}()
+		return getPayload()
+	}
+	bidPayload := func(payment uint64) *engine_types.ExecutionPayload {
+		key, err := crypto.GenerateKey()
+		require.NoError(err)
+		tx, err := types.SignTx(types.NewTransaction(0, param.SuggestedFeeRecipient, uint256.NewInt(payment), 21000, uint256.NewInt(0x0b3), nil), *types.MakeSigner(config, 51, 4), key)
+		require.NoError(err)
+		txs, err := types.MarshalTransactionsBinary(types.Transactions{tx})
+		require.NoError(err)
+		payload := *mockPayload3
+		payload.Transactions = []hexutility.Bytes{txs[0]}
+		payload.BlockHash = payloadHeader(&payload, txs, nil, clparams.BellatrixVersion).Hash()
+		return &payload
+	}
+
+	// Without bid, the local payload is returned
+	require.Equal(local.Hash(), getPayload().ExecutionPayload.BlockHash)
+	require.Equal(1, mock.Requests())
+
+	// A valid bid with a higher value than the local payload is returned
+	payload := bidPayload(1)
+	mock.SetBid(&builder.Bid{Value: (*hexutil.Big)(big.NewInt(1)), Payload: payload})
+	res := getPayloadValidated(engine_types.ValidStatus)
+	require.Equal(payload.BlockHash, res.ExecutionPayload.BlockHash)
+	require.Equal(big.NewInt(1), res.BlockValue.ToInt())
+
+	// A bid with an invalid block hash is ignored
+	invalid := *payload
+	invalid.BlockHash = libcommon.Hash{1}
+	mock.SetBid(&builder.Bid{Value: (*hexutil.Big)(big.NewInt(1)), Payload: &invalid})
+	require.Equal(local.Hash(), getPayload().ExecutionPayload.BlockHash)
+
+	// A bid which pays less than its value to the fee recipient is ignored
+	mock.SetBid(&builder.Bid{Value: (*hexutil.Big)(big.NewInt(2)), Payload: payload})
+	require.Equal(local.Hash(), getPayload().ExecutionPayload.BlockHash)
+
+	// A bid without payment to the fee recipient is ignored
+	unpaid := *mockPayload3
+	unpaid.BlockHash = payloadHeader(&unpaid, nil, nil, clparams.BellatrixVersion).Hash()
+	mock.SetBid(&builder.Bid{Value: (*hexutil.Big)(big.NewInt(1)), Payload: &unpaid})
+	require.Equal(local.Hash(), getPayload().ExecutionPayload.BlockHash)
+
+	// A bid whose payload fails the validation is ignored
+	mock.SetBid(&builder.Bid{Value: (*hexutil.Big)(big.NewInt(1)), Payload: payload})
+	require.Equal(local.Hash(), getPayloadValidated(engine_types.InvalidStatus).ExecutionPayload.BlockHash)
+
+	// Once the payload of the builder is missed, the next slot is built locally without asking the builder
+	relay.Breaker.Head(uint64(payload.BlockNumber), libcommon.Hash{2})
+	requests := mock.Requests()
+	require.Equal(local.Hash(), getPayload().ExecutionPayload.BlockHash)
+	require.Equal(requests, mock.Requests())
+	require.Equal(payload.BlockHash, getPayloadValidated(engine_types.ValidStatus).ExecutionPayload.BlockHash)
+}
@@ -4,6 +4,10 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"time"
+
+	"github.com/c2h5oh/datasize"
+	"github.com/ledgerwatch/erigon-lib/kv/kvcache"
 
 	"github.com/ledgerwatch/erigon/p2p"
 	"github.com/ledgerwatch/erigon/turbo/rpchelper"
@@ -17,17 +21,47 @@ type AdminAPI interface {
 	// Peers returns information about the connected remote nodes.
 	// https://geth.ethereum.org/docs/rpc/ns-admin#admin_peers
 	Peers(ctx context.Context) ([]*p2p.PeerInfo, error)
+
+	// DiscoveryStats returns the state of the node discovery of each sentry: the
+	// content of the discovery tables and the number of dial candidates accepted
+	// and rejected by the dial filters.
+	DiscoveryStats(ctx context.Context) ([]*p2p.DiscoveryStats, error)
+
+	// PeerScores returns the reputations of the connected and the banned peers
+	// of all the sentries: the useful responses, the timeouts, the invalid blocks
+	// and messages, the average response latency and the resulting score.
+	PeerScores(ctx context.Context) ([]*p2p.PeerScore, error)
+
+	// RotateNodeKey gives new node keys to the sentries running in the process
+	// of the RPC daemon. The sentries keep accepting connections to their
+	// previous identities for the grace period, a duration like "24h", by
+	// default DefaultNodeKeyGrace.
+	RotateNodeKey(ctx context.Context, grace *string) ([]*p2p.NodeKeyRotation, error)
+
+	// ResizeStateCache replaces the state cache of the RPC daemon with an
+	// empty one of the given sizes, such as "1GB", and disables it when
+	// cacheSize is 0. The size of the code cache is kept when codeCacheSize is
+	// not given.
+	ResizeStateCache(ctx context.Context, cacheSize string, codeCacheSize *string) (*rpchelper.CacheStats, error)
 }
 
+// DefaultNodeKeyGrace is how long the previous identities of the sentries stay
+// reachable after a rotation of their node keys by default.
+const DefaultNodeKeyGrace = 24 * time.Hour
+
 // AdminAPIImpl data structure to store things needed for admin_* commands.
 type AdminAPIImpl struct {
 	ethBackend rpchelper.ApiBackend
+	p2pServers func() []*p2p.Server // running p2p servers of this process, nil in a standalone RPC daemon
+	stateCache kvcache.Cache
 }
 
 // NewAdminAPI returns AdminAPIImpl instance.
-func NewAdminAPI(eth rpchelper.ApiBackend) *AdminAPIImpl {
+func NewAdminAPI(eth rpchelper.ApiBackend, stateCache kvcache.Cache, p2pServers func() []*p2p.Server) *AdminAPIImpl {
 	return &AdminAPIImpl{
 		ethBackend: eth,
+		p2pServers: p2pServers,
+		stateCache: stateCache,
 	}
 }
 
@@ -47,3 +81,70 @@ func (api *AdminAPIImpl) NodeInfo(ctx context.Context) (*p2p.NodeInfo, error) {
 func (api *AdminAPIImpl) Peers(ctx context.Context) ([]*p2p.PeerInfo, error) {
 	return api.ethBackend.Peers(ctx)
 }
+
+func (api *AdminAPIImpl) DiscoveryStats(ctx context.Context) ([]*p2p.DiscoveryStats, error) {
+	nodes, err := api.ethBackend.NodeInfo(ctx, 0)
+	if err != nil {
+		return nil, fmt.Errorf("node info request error: %w", err)
+	}
+
+	stats := make([]*p2p.DiscoveryStats, 0, len(nodes))
+	for i := range nodes {
+		if nodes[i].Discovery != nil {
+			stats = append(stats, nodes[i].Discovery)
+		}
+	}
+	return stats, nil
+}
+
+func (api *AdminAPIImpl) PeerScores(ctx context.Context) ([]*p2p.PeerScore, error) {
+	nodes, err := api.ethBackend.NodeInfo(ctx, 0)
+	if err != nil {
+		return nil, fmt.Errorf("node info request error: %w", err)
+	}
+
+	var scores []*p2p.PeerScore
+	for i := range nodes {
+		scores = append(scores, nodes[i].PeerScores...)
+	}
+	return scores, nil
+}
+
+func (api *AdminAPIImpl) RotateNodeKey(ctx context.Context, grace *string) ([]*p2p.NodeKeyRotation, error) {
+	duration := DefaultNodeKeyGrace
+	if grace != nil {
+		var err error
+		if duration, err = time.ParseDuration(*grace); err != nil {
+			return nil, fmt.Errorf("invalid grace period: %w", err)
+		}
+		if duration < 0 {
+			return nil, errors.New("invalid grace period: negative duration")
+		}
+	}
+	var running []*p2p.Server
+	if api.p2pServers != nil {
+		running = api.p2pServers()
+	}
+	return p2p.RotateNodeKeys(running, duration)
+}
+
+func (api *AdminAPIImpl) ResizeStateCache(_ context.Context, cacheSize string, codeCacheSize *string) (*rpchelper.CacheStats, error) {
+	cache, ok := api.stateCache.(*rpchelper.StateCache)
+	if !ok {
+		return nil, errNoCacheStats
+	}
+	var size, codeSize datasize.ByteSize
+	if err := size.UnmarshalText([]byte(cacheSize)); err != nil {
+		return nil, fmt.Errorf("cache size %q is not valid: %w", cacheSize, err)
+	}
+	codeSize = datasize.ByteSize(cache.Stats().CodeCacheSize)
+	if codeCacheSize != nil {
+		if err := codeSize.UnmarshalText([]byte(*codeCacheSize)); err != nil {
+			return nil, fmt.Errorf("code cache size %q is not valid: %w", *codeCacheSize, err)
+		}
+	}
+	if err := cache.Resize(size, codeSize); err != nil {
+		return nil, err
+	}
+	return cache.Stats(), nil
+}
@@ -4,6 +4,7 @@ import (
 	"github.com/ledgerwatch/erigon-lib/common"
 	"github.com/ledgerwatch/erigon-lib/kv"
 
+	"github.com/ledgerwatch/erigon/consensus/bor/finality"
 	"github.com/ledgerwatch/erigon/consensus/bor/valset"
 	"github.com/ledgerwatch/erigon/rpc"
 )
@@ -20,6 +21,10 @@ type BorAPI interface {
 	GetCurrentValidators() ([]*valset.Validator, error)
 	GetSnapshotProposerSequence(blockNrOrHash *rpc.BlockNumberOrHash) (BlockSigners, error)
 	GetRootHash(start uint64, end uint64) (string, error)
+
+	// Heimdall finality related (see ./bor_finality.go)
+	GetWhitelistedCheckpoint() (*finality.Entry, error)
+	GetWhitelistedMilestone() (*finality.Entry, error)
 }
 
 // BorImpl is implementation of the BorAPI interface
@@ -0,0 +1,30 @@
+package jsonrpc
+
+import (
+	"context"
+
+	"github.com/ledgerwatch/erigon-lib/kv"
+
+	"github.com/ledgerwatch/erigon/consensus/bor/finality"
+)
+
+// GetWhitelistedCheckpoint returns the end block of the last Heimdall checkpoint verified against the chain, null if
+// there is none.
+func (api *BorImpl) GetWhitelistedCheckpoint() (*finality.Entry, error) {
+	return api.readWhitelist(finality.ReadCheckpoint)
+}
+
+// GetWhitelistedMilestone returns the end block of the last Heimdall milestone, null if there is none.
+func (api *BorImpl) GetWhitelistedMilestone() (*finality.Entry, error) {
+	return api.readWhitelist(finality.ReadMilestone)
+}
+
+func (api *BorImpl) readWhitelist(read func(tx kv.Getter) (*finality.Entry, error)) (*finality.Entry, error) {
+	// init consensus db
+	borTx, err := api.borDb.BeginRo(context.Background())
+	if err != nil {
+		return nil, err
+	}
+	defer borTx.Rollback()
+	return read(borTx)
+}
@@ -0,0 +1,165 @@
+package jsonrpc
+
+import (
+	"bytes"
+	"encoding/json"
+	"math/big"
+	"testing"
+
+	jsoniter "github.com/json-iterator/go"
+	libcommon "github.com/ledgerwatch/erigon-lib/common"
+	"github.com/ledgerwatch/erigon-lib/common/hexutility"
+	"github.com/ledgerwatch/log/v3"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ledgerwatch/erigon/cmd/rpcdaemon/cli/httpcfg"
+	"github.com/ledgerwatch/erigon/cmd/rpcdaemon/rpcdaemontest"
+	"github.com/ledgerwatch/erigon/common/hexutil"
+	"github.com/ledgerwatch/erigon/eth/tracers"
+	"github.com/ledgerwatch/erigon/rpc"
+	"github.com/ledgerwatch/erigon/turbo/adapter/ethapi"
+)
+
+var (
+	// NUMBER PUSH1 0 MSTORE PUSH1 32 PUSH1 0 RETURN
+	returnNumberCode = hexutility.Bytes(hexutil.MustDecode("0x4360005260206000f3"))
+	// CALLER BALANCE PUSH1 0 MSTORE PUSH1 32 PUSH1 0 RETURN
+	returnCallerBalanceCode = hexutility.Bytes(hexutil.MustDecode("0x333160005260206000f3"))
+	// Reverts if NUMBER < 1000
+	requireNumberCode = hexutility.Bytes(hexutil.MustDecode("0x6103e84310600957005b600080fd"))
+	// NUMBER BALANCE STOP, reads the balance of the account whose address is the block number
+	balanceOfNumberCode = hexutility.Bytes(hexutil.MustDecode("0x433100"))
+)
+
+func codeOverrides(address libcommon.Address, code hexutility.Bytes) *ethapi.StateOverrides {
+	return &ethapi.StateOverrides{address: ethapi.Account{Code: &code}}
+}
+
+func numberOverrides(number uint64) *ethapi.BlockOverrides {
+	return &ethapi.BlockOverrides{BlockNumber: (*hexutil.Uint64)(&number)}
+}
+
+func TestCallOverrides(t *testing.T) {
+	m, _, _ := rpcdaemontest.CreateTestSentry(t)
+	api := NewEthAPI(newBaseApiForTest(m), m.DB, nil, nil, nil, 5000000, 100_000, log.New())
+	from := libcommon.HexToAddress("0x71562b71999873db5b286df957af199ec94617f7")
+	to := libcommon.HexToAddress("0x1234")
+	args := ethapi.CallArgs{From: &from, To: &to}
+	latest := rpc.BlockNumberOrHashWithNumber(rpc.LatestBlockNumber)
+
+	t.Run("state", func(t *testing.T) {
+		balance := (*hexutil.Big)(big.NewInt(12345))
+		overrides := ethapi.StateOverrides{
+			to:   ethapi.Account{Code: &returnCallerBalanceCode},
+			from: ethapi.Account{Balance: &balance},
+		}
+		res, err := api.Call(m.Ctx, args, latest, &overrides, nil)
+		require.NoError(t, err)
+		require.Equal(t, big.NewInt(12345), new(big.Int).SetBytes(res))
+	})
+	t.Run("block", func(t *testing.T) {
+		res, err := api.Call(m.Ctx, args, latest, codeOverrides(to, returnNumberCode), numberOverrides(1000))
+		require.NoError(t, err)
+		require.Equal(t, big.NewInt(1000), new(big.Int).SetBytes(res))
+
+		timestamp := hexutil.Uint64(1234567)
+		coinbase := libcommon.HexToAddress("0xc0ffee")
+		// TIMESTAMP COINBASE XOR PUSH1 0 MSTORE PUSH1 32 PUSH1 0 RETURN
+		code := hexutility.Bytes(hexutil.MustDecode("0x42411860005260206000f3"))
+		res, err = api.Call(m.Ctx, args, latest, codeOverrides(to, code), &ethapi.BlockOverrides{Timestamp: &timestamp, Coinbase: &coinbase})
+		require.NoError(t, err)
+		require.Equal(t, new(big.Int).Xor(big.NewInt(1234567), new(big.Int).SetBytes(coinbase[:])), new(big.Int).SetBytes(res))
+	})
+}
+
+func TestEstimateGasOverrides(t *testing.T) {
+	m, _, _ := rpcdaemontest.CreateTestSentry(t)
+	api := NewEthAPI(newBaseApiForTest(m), m.DB, nil, nil, nil, 5000000, 100_000, log.New())
+	from := libcommon.HexToAddress("0x71562b71999873db5b286df957af199ec94617f7")
+	to := libcommon.HexToAddress("0x1234")
+	args := ethapi.CallArgs{From: &from, To: &to}
+
+	gas, err := api.EstimateGas(m.Ctx, &args, nil, nil, nil)
+	require.NoError(t, err)
+	require.Equal(t, hexutil.Uint64(21000), gas)
+
+	// The code reverts before the block 1000
+	_, err = api.EstimateGas(m.Ctx, &args, nil, codeOverrides(to, requireNumberCode), nil)
+	require.Error(t, err)
+
+	gas, err = api.EstimateGas(m.Ctx, &args, nil, codeOverrides(to, requireNumberCode), numberOverrides(1000))
+	require.NoError(t, err)
+	require.Greater(t, uint64(gas), uint64(21000))
+
+	// The gas is capped by the overridden balance
+	gasPrice := (*hexutil.Big)(big.NewInt(1))
+	balance := (*hexutil.Big)(big.NewInt(21000))
+	args.GasPrice = gasPrice
+	gas, err = api.EstimateGas(m.Ctx, &args, nil, &ethapi.StateOverrides{from: ethapi.Account{Balance: &balance}}, nil)
+	require.NoError(t, err)
+	require.Equal(t, hexutil.Uint64(21000), gas)
+	balance = (*hexutil.Big)(big.NewInt(20999))
+	_, err = api.EstimateGas(m.Ctx, &args, nil, &ethapi.StateOverrides{from: ethapi.Account{Balance: &balance}}, nil)
+	require.Error(t, err)
+}
+
+func TestCreateAccessListOverrides(t *testing.T) {
+	m, _, _ := rpcdaemontest.CreateTestSentry(t)
+	api := NewEthAPI(newBaseApiForTest(m), m.DB, nil, nil, nil, 5000000, 100_000, log.New())
+	from := libcommon.HexToAddress("0x71562b71999873db5b286df957af199ec94617f7")
+	to := libcommon.HexToAddress("0x1234")
+	args := ethapi.CallArgs{From: &from, To: &to}
+	latest := rpc.BlockNumberOrHashWithNumber(rpc.LatestBlockNumber)
+
+	res, err := api.CreateAccessList(m.Ctx, args, &latest, nil, nil, nil)
+	require.NoError(t, err)
+	require.Empty(t, *res.Accesslist)
+
+	// The code reads the balance of the account whose address is the block number
+	res, err = api.CreateAccessList(m.Ctx, args, &latest, nil, codeOverrides(to, balanceOfNumberCode), numberOverrides(1000))
+	require.NoError(t, err)
+	require.Empty(t, res.Error)
+	require.Len(t, *res.Accesslist, 1)
+	require.Equal(t, libcommon.BigToAddress(big.NewInt(1000)), (*res.Accesslist)[0].Address)
+}
+
+func TestTraceCallOverrides(t *testing.T) {
+	m, _, _ := rpcdaemontest.CreateTestSentry(t)
+	from := libcommon.HexToAddress("0x71562b71999873db5b286df957af199ec94617f7")
+	to := libcommon.HexToAddress("0x1234")
+	latest := rpc.BlockNumberOrHashWithNumber(rpc.LatestBlockNumber)
+
+	t.Run("debug_traceCall", func(t *testing.T) {
+		api := NewPrivateDebugAPI(newBaseApiForTest(m), m.DB, 0)
+		traceCall := func(config *tracers.TraceConfig) ethapi.ExecutionResult {
+			var buf bytes.Buffer
+			stream := jsoniter.NewStream(jsoniter.ConfigDefault, &buf, 4096)
+			require.NoError(t, api.TraceCall(m.Ctx, ethapi.CallArgs{From: &from, To: &to}, latest, config, stream))
+			require.NoError(t, stream.Flush())
+			var res ethapi.ExecutionResult
+			require.NoError(t, json.Unmarshal(buf.Bytes(), &res))
+			return res
+		}
+
+		res := traceCall(&tracers.TraceConfig{StateOverrides: codeOverrides(to, returnNumberCode)})
+		require.False(t, res.Failed)
+		require.NotEqual(t, big.NewInt(1000), new(big.Int).SetBytes(hexutil.MustDecode("0x"+res.ReturnValue)))
+
+		res = traceCall(&tracers.TraceConfig{StateOverrides: codeOverrides(to, returnNumberCode), BlockOverrides: numberOverrides(1000)})
+		require.False(t, res.Failed)
+		require.Equal(t, big.NewInt(1000), new(big.Int).SetBytes(hexutil.MustDecode("0x"+res.ReturnValue)))
+	})
+	t.Run("trace_call", func(t *testing.T) {
+		api := NewTraceAPI(newBaseApiForTest(m), m.DB, &httpcfg.HttpCfg{})
+		args := TraceCallParam{From: &from, To: &to}
+
+		res, err := api.Call(m.Ctx, args, []string{TraceTypeTrace}, &latest, codeOverrides(to, returnNumberCode), numberOverrides(1000))
+		require.NoError(t, err)
+		require.Equal(t, big.NewInt(1000), new(big.Int).SetBytes(res.Output))
+
+		// The state diff is computed against the overridden state
+		res, err = api.Call(m.Ctx, args, []string{TraceTypeStateDiff}, &latest, codeOverrides(to, returnNumberCode), nil)
+		require.NoError(t, err)
+		require.NotContains(t, res.StateDiff, to)
+	})
+}
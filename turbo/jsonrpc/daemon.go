@@ -1,13 +1,19 @@
 package jsonrpc
 
 import (
+	"context"
+
 	"github.com/ledgerwatch/erigon-lib/gointerfaces/txpool"
 	"github.com/ledgerwatch/erigon-lib/kv"
 	"github.com/ledgerwatch/erigon-lib/kv/kvcache"
 	libstate "github.com/ledgerwatch/erigon-lib/state"
+	"github.com/ledgerwatch/erigon/accounts/signer"
 	"github.com/ledgerwatch/erigon/cmd/rpcdaemon/cli/httpcfg"
 	"github.com/ledgerwatch/erigon/consensus"
 	"github.com/ledgerwatch/erigon/consensus/clique"
+	"github.com/ledgerwatch/erigon/p2p"
+	"github.com/ledgerwatch/erigon/p2p/netutil"
+	"github.com/ledgerwatch/erigon/p2p/txpolicy"
 	"github.com/ledgerwatch/erigon/rpc"
 	"github.com/ledgerwatch/erigon/turbo/rpchelper"
 	"github.com/ledgerwatch/erigon/turbo/services"
@@ -18,10 +24,32 @@ import (
 func APIList(db kv.RoDB, borDb kv.RoDB, eth rpchelper.ApiBackend, txPool txpool.TxpoolClient, mining txpool.MiningClient,
 	filters *rpchelper.Filters, stateCache kvcache.Cache,
 	blockReader services.FullBlockReader, agg *libstate.AggregatorV3, cfg httpcfg.HttpCfg, engine consensus.EngineReader,
-	logger log.Logger,
+	p2pServers func() []*p2p.Server, logger log.Logger,
 ) (list []rpc.API) {
 	base := NewBaseApi(filters, stateCache, blockReader, agg, cfg.WithDatadir, cfg.EvmCallTimeout, engine, cfg.Dirs)
 	ethImpl := NewEthAPI(base, db, eth, txPool, mining, cfg.Gascap, cfg.ReturnDataLimit, logger)
+	ethImpl.MaxLogs = cfg.MaxLogs
+	if cfg.Signer.Enabled() {
+		s, err := signer.New(context.Background(), cfg.Signer, logger)
+		if err != nil {
+			logger.Error("Could not open signer, eth_sign and eth_sendTransaction are disabled", "err", err)
+		} else {
+			ethImpl.SetSigner(s)
+		}
+	}
+	if cfg.PrivateTxClients != "" {
+		clients, err := netutil.ParseNetlist(cfg.PrivateTxClients)
+		if err != nil {
+			logger.Error("Invalid private transaction clients, all the transactions are broadcast", "err", err)
+		} else {
+			registry := cfg.PrivateTxs
+			if registry == nil {
+				// No sentry withholds the transactions of this registry, so those of the private clients are refused
+				registry = txpolicy.NewPrivateTxs(txpolicy.PrivateTxTTL)
+			}
+			ethImpl.SetPrivateTxClients(clients, registry)
+		}
+	}
 	erigonImpl := NewErigonAPI(base, db, eth)
 	txpoolImpl := NewTxPoolAPI(base, db, txPool)
 	netImpl := NewNetAPIImpl(eth)
@@ -29,7 +57,7 @@ func APIList(db kv.RoDB, borDb kv.RoDB, eth rpchelper.ApiBackend, txPool txpool.
 	traceImpl := NewTraceAPI(base, db, &cfg)
 	web3Impl := NewWeb3APIImpl(eth)
 	dbImpl := NewDBAPIImpl() /* deprecated */
-	adminImpl := NewAdminAPI(eth)
+	adminImpl := NewAdminAPI(eth, stateCache, p2pServers)
 	parityImpl := NewParityAPIImpl(base, db)
 	borImpl := NewBorAPI(base, db, borDb) // bor (consensus) specific
 	otsImpl := NewOtterscanAPI(base, db)
@@ -8,14 +8,20 @@ import (
 	"github.com/ledgerwatch/erigon-lib/common"
 	"github.com/ledgerwatch/erigon-lib/common/hexutility"
 	"github.com/ledgerwatch/erigon-lib/kv"
+	"github.com/ledgerwatch/erigon-lib/kv/memdb"
 	"github.com/ledgerwatch/erigon-lib/kv/order"
 	"github.com/ledgerwatch/erigon-lib/kv/rawdbv3"
+	"github.com/ledgerwatch/log/v3"
 
 	"github.com/ledgerwatch/erigon/common/changeset"
 	"github.com/ledgerwatch/erigon/common/hexutil"
+	"github.com/ledgerwatch/erigon/consensus"
+	"github.com/ledgerwatch/erigon/core"
 	"github.com/ledgerwatch/erigon/core/rawdb"
 	"github.com/ledgerwatch/erigon/core/state"
 	"github.com/ledgerwatch/erigon/core/types/accounts"
+	"github.com/ledgerwatch/erigon/core/verkletrie"
+	"github.com/ledgerwatch/erigon/eth/stagedsync"
 	"github.com/ledgerwatch/erigon/eth/stagedsync/stages"
 	"github.com/ledgerwatch/erigon/eth/tracers"
 	"github.com/ledgerwatch/erigon/rlp"
@@ -41,6 +47,7 @@ type PrivateDebugAPI interface {
 	AccountAt(ctx context.Context, blockHash common.Hash, txIndex uint64, account common.Address) (*AccountResult, error)
 	GetRawHeader(ctx context.Context, blockNrOrHash rpc.BlockNumberOrHash) (hexutility.Bytes, error)
 	GetRawBlock(ctx context.Context, blockNrOrHash rpc.BlockNumberOrHash) (hexutility.Bytes, error)
+	GetBlockWitness(ctx context.Context, blockNrOrHash rpc.BlockNumberOrHash) (hexutility.Bytes, error)
 }
 
 // PrivateDebugAPIImpl is implementation of the PrivateDebugAPI interface based on remote Db access
@@ -402,3 +409,82 @@ func (api *PrivateDebugAPIImpl) GetRawBlock(ctx context.Context, blockNrOrHash r
 	}
 	return rlp.EncodeToBytes(block)
 }
+
+// GetBlockWitness implements debug_getBlockWitness. Returns the RLP encoding of the witness of a block, with which it
+// can be executed on top of its parent header without the state. The state of the parent is rewound like in
+// eth_getProof, so the block must be within maxGetProofRewindBlockCount blocks of the head.
+func (api *PrivateDebugAPIImpl) GetBlockWitness(ctx context.Context, blockNrOrHash rpc.BlockNumberOrHash) (hexutility.Bytes, error) {
+	tx, err := api.db.BeginRo(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+	if api.historyV3(tx) {
+		return nil, fmt.Errorf("not supported by Erigon3")
+	}
+	chainConfig, err := api.chainConfig(tx)
+	if err != nil {
+		return nil, err
+	}
+	if verkletrie.IsVerkleChain(chainConfig) {
+		return nil, fmt.Errorf("the state is committed in a verkle tree, which has no witnesses")
+	}
+
+	n, h, _, err := rpchelper.GetBlockNumber(blockNrOrHash, tx, api.filters)
+	if err != nil {
+		return nil, err
+	}
+	if n == 0 {
+		return nil, fmt.Errorf("the genesis block has no witness")
+	}
+	block, err := api.blockWithSenders(ctx, tx, h, n)
+	if err != nil {
+		return nil, err
+	}
+	if block == nil {
+		return nil, fmt.Errorf("block not found")
+	}
+	parent, err := api._blockReader.Header(ctx, tx, block.ParentHash(), n-1)
+	if err != nil {
+		return nil, err
+	}
+	if parent == nil {
+		return nil, fmt.Errorf("parent header not found")
+	}
+	latestBlock, err := rpchelper.GetLatestBlockNumber(tx)
+	if err != nil {
+		return nil, err
+	}
+	if latestBlock < n {
+		return nil, fmt.Errorf("block number is in the future latest=%d requested=%d", latestBlock, n)
+	}
+	if latestBlock-parent.Number.Uint64() > maxGetProofRewindBlockCount {
+		return nil, fmt.Errorf("requested block is too old, block must be within %d blocks of the head block number (currently %d)", maxGetProofRewindBlockCount, latestBlock)
+	}
+
+	// The trie of the parent is rewound in memory
+	logger := log.New()
+	batch := memdb.NewMemoryBatch(tx, api.dirs.Tmp)
+	defer batch.Rollback()
+	unwindState := &stagedsync.UnwindState{UnwindPoint: parent.Number.Uint64()}
+	stageState := &stagedsync.StageState{BlockNumber: latestBlock}
+	hashStageCfg := stagedsync.StageHashStateCfg(nil, api.dirs, false)
+	if err := stagedsync.UnwindHashStateStage(unwindState, stageState, batch, hashStageCfg, ctx, logger); err != nil {
+		return nil, err
+	}
+	interHashStageCfg := stagedsync.StageTrieCfg(nil, false, false, false, api.dirs.Tmp, api._blockReader, nil, false, api._agg)
+	if err := stagedsync.UnwindIntermediateHashesTo("debug_getBlockWitness", unwindState, stageState, batch, interHashStageCfg, parent.Root, ctx.Done(), logger); err != nil {
+		return nil, err
+	}
+
+	reader, err := rpchelper.CreateHistoryStateReader(tx, n, 0, false, chainConfig.ChainName)
+	if err != nil {
+		return nil, err
+	}
+	chainReader := stagedsync.NewChainReaderImpl(chainConfig, tx, api._blockReader)
+	witness, err := core.BuildBlockWitness(batch, chainConfig, api.engine().(consensus.Engine), block, parent, reader, chainReader, logger)
+	if err != nil {
+		return nil, err
+	}
+	return rlp.EncodeToBytes(witness)
+}
@@ -15,11 +15,15 @@ import (
 	"github.com/ledgerwatch/erigon-lib/kv/order"
 	"github.com/ledgerwatch/erigon/cmd/rpcdaemon/rpcdaemontest"
 	common2 "github.com/ledgerwatch/erigon/common"
+	"github.com/ledgerwatch/erigon/core"
 	"github.com/ledgerwatch/erigon/core/types"
 	"github.com/ledgerwatch/erigon/eth/tracers"
+	"github.com/ledgerwatch/erigon/rlp"
 	"github.com/ledgerwatch/erigon/rpc"
 	"github.com/ledgerwatch/erigon/rpc/rpccfg"
 	"github.com/ledgerwatch/erigon/turbo/adapter/ethapi"
+	"github.com/ledgerwatch/erigon/turbo/rpchelper"
+	"github.com/ledgerwatch/erigon/turbo/stages"
 	"github.com/ledgerwatch/log/v3"
 	"github.com/stretchr/testify/require"
 )
@@ -504,3 +508,46 @@ func TestAccountAt(t *testing.T) {
 		require.Equal(0, int(results.Nonce))
 	})
 }
+
+func TestGetBlockWitness(t *testing.T) {
+	for name, m := range map[string]*stages.MockSentry{
+		"test sentry": func() *stages.MockSentry { m, _, _ := rpcdaemontest.CreateTestSentry(t); return m }(),
+		"collision":   rpcdaemontest.CreateTestSentryForTracesCollision(t),
+	} {
+		t.Run(name, func(t *testing.T) {
+			if m.HistoryV3 {
+				t.Skip("not supported by Erigon3")
+			}
+			require := require.New(t)
+			api := NewPrivateDebugAPI(newBaseApiForTest(m), m.DB, 0)
+
+			var head uint64
+			require.NoError(m.DB.View(m.Ctx, func(tx kv.Tx) (err error) {
+				head, err = rpchelper.GetLatestBlockNumber(tx)
+				return err
+			}))
+			for bn := uint64(1); bn <= head; bn++ {
+				enc, err := api.GetBlockWitness(m.Ctx, rpc.BlockNumberOrHashWithNumber(rpc.BlockNumber(bn)))
+				require.NoError(err, "block %d", bn)
+				witness, err := core.DecodeBlockWitness(enc)
+				require.NoError(err)
+
+				enc, err = api.GetRawBlock(m.Ctx, rpc.BlockNumberOrHashWithNumber(rpc.BlockNumber(bn)))
+				require.NoError(err)
+				block := new(types.Block)
+				require.NoError(rlp.DecodeBytes(enc, block))
+				enc, err = api.GetRawHeader(m.Ctx, rpc.BlockNumberOrHashWithNumber(rpc.BlockNumber(bn-1)))
+				require.NoError(err)
+				parent := new(types.Header)
+				require.NoError(rlp.DecodeBytes(enc, parent))
+
+				require.NoError(core.ExecuteBlockStateless(m.ChainConfig, m.Engine, parent, block, witness, log.New()), "block %d", bn)
+
+				// A block with another state root does not verify
+				header := block.Header()
+				header.Root = common.Hash{0x01}
+				require.Error(core.ExecuteBlockStateless(m.ChainConfig, m.Engine, parent, block.WithSeal(header), witness, log.New()))
+			}
+		})
+	}
+}
@@ -34,9 +34,14 @@ type ErigonAPI interface {
 	//GetLogsByNumber(ctx context.Context, number rpc.BlockNumber) ([][]*types.Log, error)
 	GetLogs(ctx context.Context, crit ethFilters.FilterCriteria) (types.ErigonLogs, error)
 	GetLatestLogs(ctx context.Context, crit filters.FilterCriteria, logOptions ethFilters.LogFilterOptions) (types.ErigonLogs, error)
+	GetLogsCount(ctx context.Context, crit ethFilters.FilterCriteria) (*LogsCount, error) // see ./erigon_logs_count.go
 	// Gets cannonical block receipt through hash. If the block is not cannonical returns error
 	GetBlockReceiptsByBlockHash(ctx context.Context, cannonicalBlockHash common.Hash) ([]map[string]interface{}, error)
 
+	// Cache related (see ./erigon_cache.go)
+	CacheStats(ctx context.Context) (*rpchelper.CacheStats, error)
+	CacheInspect(ctx context.Context, address common.Address, storageKeys []common.Hash) (*CacheInspectResult, error)
+
 	// NodeInfo returns a collection of metadata known about the host.
 	NodeInfo(ctx context.Context) ([]p2p.NodeInfo, error)
 }
@@ -0,0 +1,78 @@
+package jsonrpc
+
+import (
+	"context"
+	"errors"
+
+	"github.com/ledgerwatch/erigon-lib/common"
+
+	"github.com/ledgerwatch/erigon/turbo/rpchelper"
+)
+
+var errNoCacheStats = errors.New("the state cache of this rpcdaemon has no statistics, it runs with --datadir")
+
+// CacheInspectResult is what the state cache holds of an account at the latest state
+type CacheInspectResult struct {
+	Account rpchelper.CacheEntry   `json:"account"`
+	Code    *rpchelper.CacheEntry  `json:"code,omitempty"`
+	Storage []rpchelper.CacheEntry `json:"storage"`
+}
+
+func (api *ErigonImpl) statsCache() (*rpchelper.StateCache, error) {
+	cache, ok := api.stateCache.(*rpchelper.StateCache)
+	if !ok {
+		return nil, errNoCacheStats
+	}
+	return cache, nil
+}
+
+// CacheStats implements erigon_cacheStats. Returns the hits and misses of the state cache, in total and per RPC
+// method, and the keys the new blocks invalidated or evicted
+func (api *ErigonImpl) CacheStats(_ context.Context) (*rpchelper.CacheStats, error) {
+	cache, err := api.statsCache()
+	if err != nil {
+		return nil, err
+	}
+	return cache.Stats(), nil
+}
+
+// CacheInspect implements erigon_cacheInspect. Returns the entries of the state cache for the account, its code and
+// the given storage slots at the latest state, without adding the missing ones to the cache
+func (api *ErigonImpl) CacheInspect(ctx context.Context, address common.Address, storageKeys []common.Hash) (*CacheInspectResult, error) {
+	cache, err := api.statsCache()
+	if err != nil {
+		return nil, err
+	}
+	tx, err := api.db.BeginRo(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	// The incarnation and the code hash, which make the keys of the storage and the code, are read from the state
+	acc, err := rpchelper.NewLatestStateReader(tx).ReadAccountData(address)
+	if err != nil {
+		return nil, err
+	}
+	var incarnation uint64
+	var codeKeys [][]byte
+	if acc != nil {
+		incarnation = acc.Incarnation
+		if !acc.IsEmptyCodeHash() {
+			codeKeys = append(codeKeys, acc.CodeHash.Bytes())
+		}
+	}
+	keys := [][]byte{address.Bytes()}
+	for _, key := range storageKeys {
+		keys = append(keys, rpchelper.StorageCacheKey(address, incarnation, key))
+	}
+	state, code, err := cache.Inspect(ctx, tx, keys, codeKeys)
+	if err != nil {
+		return nil, err
+	}
+	res := &CacheInspectResult{Account: state[0], Storage: state[1:]}
+	if len(code) > 0 {
+		res.Code = &code[0]
+	}
+	return res, nil
+}
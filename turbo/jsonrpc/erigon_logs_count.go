@@ -0,0 +1,182 @@
+package jsonrpc
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+
+	"github.com/ledgerwatch/erigon-lib/common"
+	"github.com/ledgerwatch/erigon-lib/common/hexutility"
+	"github.com/ledgerwatch/erigon-lib/kv"
+	"github.com/ledgerwatch/erigon-lib/kv/bitmapdb"
+
+	"github.com/ledgerwatch/erigon/common/hexutil"
+	"github.com/ledgerwatch/erigon/core/rawdb"
+	"github.com/ledgerwatch/erigon/core/types"
+	"github.com/ledgerwatch/erigon/eth/filters"
+	"github.com/ledgerwatch/erigon/ethdb/cbor"
+)
+
+// LogsCount is the number of logs of a range of blocks matching a filter
+type LogsCount struct {
+	FromBlock hexutil.Uint64 `json:"fromBlock"`
+	ToBlock   hexutil.Uint64 `json:"toBlock"`
+	Count     hexutil.Uint64 `json:"count"`
+	// Exact is false when the count is an upper bound, for the filters with topics
+	Exact bool `json:"exact"`
+}
+
+// GetLogsCount implements erigon_getLogsCount. Returns the number of logs eth_getLogs returns for the filter, without
+// reading the logs of most of the blocks
+func (api *ErigonImpl) GetLogsCount(ctx context.Context, crit filters.FilterCriteria) (*LogsCount, error) {
+	tx, err := api.db.BeginRo(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	if api.historyV3(tx) {
+		return nil, fmt.Errorf("erigon_getLogsCount is not implemented with history v3")
+	}
+	begin, end, err := getLogsRange(tx, crit)
+	if err != nil {
+		return nil, err
+	}
+	count, exact, err := logsCount(ctx, tx, begin, end, crit)
+	if err != nil {
+		return nil, err
+	}
+	return &LogsCount{FromBlock: hexutil.Uint64(begin), ToBlock: hexutil.Uint64(end), Count: hexutil.Uint64(count), Exact: exact}, nil
+}
+
+// logsCount counts the logs of the blocks [begin, end] matching crit. The counts of the shards of blocks inside the
+// range are read from rawdb.LogCounts, and the logs of the other blocks, at its edges or indexed before the logs were
+// counted, are counted one by one. The count is an upper bound when crit has topics, since the counts do not tell in
+// which position the logs have them, nor which addresses emitted them.
+func logsCount(ctx context.Context, tx kv.Tx, begin, end uint64, crit filters.FilterCriteria) (count uint64, exact bool, err error) {
+	countsFrom, hasCounts, err := rawdb.ReadLogCountsFrom(tx)
+	if err != nil {
+		return 0, false, err
+	}
+	from, to := rawdb.LogCountsShard(begin+rawdb.LogCountsShardSize-1), rawdb.LogCountsShard(end+1)
+	if from < countsFrom {
+		from = rawdb.LogCountsShard(countsFrom + rawdb.LogCountsShardSize - 1)
+	}
+	if !hasCounts || from >= to {
+		count, err = countMatchingLogs(ctx, tx, begin, end, crit)
+		return count, true, err
+	}
+	if count, exact, err = shardsLogsCount(tx, from, to, crit); err != nil {
+		return 0, false, err
+	}
+	if begin < from {
+		n, err := countMatchingLogs(ctx, tx, begin, from-1, crit)
+		if err != nil {
+			return 0, false, err
+		}
+		count += n
+	}
+	if to <= end {
+		n, err := countMatchingLogs(ctx, tx, to, end, crit)
+		if err != nil {
+			return 0, false, err
+		}
+		count += n
+	}
+	return count, exact, nil
+}
+
+// shardsLogsCount counts the logs matching crit of the shards starting in [from, to), exactly for the filters without
+// topics
+func shardsLogsCount(tx kv.Tx, from, to uint64, crit filters.FilterCriteria) (count uint64, exact bool, err error) {
+	count, err = rawdb.ReadAllLogCount(tx, from, to)
+	if err != nil {
+		return 0, false, err
+	}
+	exact = true
+	if len(crit.Addresses) > 0 {
+		var n uint64
+		for i, addr := range crit.Addresses {
+			if containsAddress(crit.Addresses[:i], addr) {
+				continue
+			}
+			c, err := rawdb.ReadAddressLogCount(tx, addr, from, to)
+			if err != nil {
+				return 0, false, err
+			}
+			n += c
+		}
+		if n < count {
+			count = n
+		}
+	}
+	for _, sub := range crit.Topics {
+		if len(sub) == 0 {
+			continue
+		}
+		exact = false
+		var n uint64
+		for i, topic := range sub {
+			if containsHash(sub[:i], topic) {
+				continue
+			}
+			c, err := rawdb.ReadTopicLogCount(tx, topic, from, to)
+			if err != nil {
+				return 0, false, err
+			}
+			n += c
+		}
+		if n < count {
+			count = n
+		}
+	}
+	return count, exact, nil
+}
+
+func containsAddress(addrs []common.Address, addr common.Address) bool {
+	for _, a := range addrs {
+		if a == addr {
+			return true
+		}
+	}
+	return false
+}
+
+func containsHash(hashes []common.Hash, hash common.Hash) bool {
+	for _, h := range hashes {
+		if h == hash {
+			return true
+		}
+	}
+	return false
+}
+
+// countMatchingLogs counts the logs of the blocks [begin, end] matching crit by reading them
+func countMatchingLogs(ctx context.Context, tx kv.Tx, begin, end uint64, crit filters.FilterCriteria) (uint64, error) {
+	blockNumbers := bitmapdb.NewBitmap()
+	defer bitmapdb.ReturnToPool(blockNumbers)
+	if err := applyFilters(blockNumbers, tx, begin, end, crit); err != nil {
+		return 0, err
+	}
+	addrMap := make(map[common.Address]struct{}, len(crit.Addresses))
+	for _, v := range crit.Addresses {
+		addrMap[v] = struct{}{}
+	}
+	var count uint64
+	for iter := blockNumbers.Iterator(); iter.HasNext(); {
+		if err := ctx.Err(); err != nil {
+			return 0, err
+		}
+		if err := tx.ForPrefix(kv.Log, hexutility.EncodeTs(uint64(iter.Next())), func(k, v []byte) error {
+			var logs types.Logs
+			if err := cbor.Unmarshal(&logs, bytes.NewReader(v)); err != nil {
+				return fmt.Errorf("receipt unmarshal failed:  %w", err)
+			}
+			count += uint64(len(logs.Filter(addrMap, crit.Topics)))
+			return nil
+		}); err != nil {
+			return 0, err
+		}
+	}
+	return count, nil
+}
@@ -0,0 +1,104 @@
+package jsonrpc
+
+import (
+	"bytes"
+	"context"
+	"testing"
+
+	"github.com/RoaringBitmap/roaring"
+	libcommon "github.com/ledgerwatch/erigon-lib/common"
+	"github.com/ledgerwatch/erigon-lib/kv"
+	"github.com/ledgerwatch/erigon-lib/kv/memdb"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ledgerwatch/erigon/core/rawdb"
+	"github.com/ledgerwatch/erigon/core/types"
+	"github.com/ledgerwatch/erigon/eth/filters"
+)
+
+func TestLogsCount(t *testing.T) {
+	ctx := context.Background()
+	_, tx := memdb.NewTestTx(t)
+
+	// Logs of 3 addresses with 3 topics over 2.5 shards, indexed and counted as the log index stage does
+	addrs := []libcommon.Address{{1}, {2}, {3}}
+	topics := []libcommon.Hash{{1}, {2}, {3}}
+	bitmaps := map[string]*roaring.Bitmap{}
+	index := func(key []byte, blockNum uint64) {
+		bm, ok := bitmaps[string(key)]
+		if !ok {
+			bm = roaring.New()
+			bitmaps[string(key)] = bm
+		}
+		bm.Add(uint32(blockNum))
+	}
+	counts, firstShard := map[string]uint64{}, map[string]uint64{}
+	blocks := uint64(2*rawdb.LogCountsShardSize + rawdb.LogCountsShardSize/2)
+	for i := uint64(0); i < blocks; i++ {
+		var logs types.Logs
+		for j := uint64(0); j <= i%3; j++ {
+			l := &types.Log{Address: addrs[(i+j)%3], Topics: []libcommon.Hash{topics[j], topics[i%3]}}
+			logs = append(logs, l)
+			index(append([]byte{'a'}, l.Address[:]...), i)
+			for _, topic := range l.Topics {
+				index(append([]byte{'t'}, topic[:]...), i)
+			}
+		}
+		require.NoError(t, rawdb.AppendReceipts(tx, i, types.Receipts{{Logs: logs}}))
+		rawdb.CountLogs(counts, i, logs)
+		if i < rawdb.LogCountsShardSize {
+			rawdb.CountLogs(firstShard, i, logs)
+		}
+	}
+	require.NoError(t, rawdb.CreateLogCounts(tx))
+	require.NoError(t, rawdb.WriteLogCountsFrom(tx, 0))
+	require.NoError(t, rawdb.AddLogCounts(tx, counts, false))
+	for key, bm := range bitmaps {
+		var buf bytes.Buffer
+		_, err := bm.WriteTo(&buf)
+		require.NoError(t, err)
+		table := kv.LogAddressIndex
+		if key[0] == 't' {
+			table = kv.LogTopicIndex
+		}
+		require.NoError(t, tx.Put(table, append([]byte(key[1:]), 0xff, 0xff, 0xff, 0xff), buf.Bytes()))
+	}
+
+	for _, crit := range []filters.FilterCriteria{
+		{},
+		{Addresses: addrs[:1]},
+		{Addresses: []libcommon.Address{addrs[0], addrs[2], addrs[0]}},
+		{Topics: [][]libcommon.Hash{{topics[1]}}},
+		{Topics: [][]libcommon.Hash{{}, {topics[0], topics[2]}}},
+		{Addresses: addrs[1:2], Topics: [][]libcommon.Hash{{topics[0]}}},
+	} {
+		for _, r := range [][2]uint64{{0, blocks - 1}, {10, 20}, {999, 1000}, {500, 2100}, {1000, 1999}, {1, 2000}} {
+			expect, err := countMatchingLogs(ctx, tx, r[0], r[1], crit)
+			require.NoError(t, err)
+			count, exact, err := logsCount(ctx, tx, r[0], r[1], crit)
+			require.NoError(t, err)
+			if exact {
+				require.Equal(t, expect, count, "range %v, criteria %+v", r, crit)
+			} else {
+				require.LessOrEqual(t, expect, count, "range %v, criteria %+v", r, crit)
+			}
+			hasTopics := len(crit.Topics) > 0
+			require.True(t, exact || hasTopics, "range %v, criteria %+v", r, crit)
+		}
+	}
+
+	// The counts of all the logs of a shard follow the blocks
+	count, err := rawdb.ReadAllLogCount(tx, rawdb.LogCountsShardSize, 2*rawdb.LogCountsShardSize)
+	require.NoError(t, err)
+	require.Equal(t, uint64(2*rawdb.LogCountsShardSize), count)
+
+	// The blocks indexed before the logs were counted are counted one by one
+	require.NoError(t, rawdb.AddLogCounts(tx, firstShard, true))
+	require.NoError(t, rawdb.WriteLogCountsFrom(tx, rawdb.LogCountsShardSize/2))
+	expect, err := countMatchingLogs(ctx, tx, 0, blocks-1, filters.FilterCriteria{})
+	require.NoError(t, err)
+	count, exact, err := logsCount(ctx, tx, 0, blocks-1, filters.FilterCriteria{})
+	require.NoError(t, err)
+	require.True(t, exact)
+	require.Equal(t, expect, count)
+}
@@ -8,3 +8,6 @@ const NotAvailableChainData = "the function %s is not available, please use --pr
 
 // NotAvailableDeprecated x
 const NotAvailableDeprecated = "the method has been deprecated: %s"
+
+// NotAvailableSigner x
+const NotAvailableSigner = "the method %s is not available, please use --rpc.signer.keystore or --rpc.signer.url option to enable a signer"
@@ -79,7 +79,6 @@ func (api *BaseAPI) getReceipts(ctx context.Context, tx kv.Tx, chainConfig *chai
 
 // GetLogs implements eth_getLogs. Returns an array of logs matching a given filter object.
 func (api *APIImpl) GetLogs(ctx context.Context, crit filters.FilterCriteria) (types.Logs, error) {
-	var begin, end uint64
 	logs := types.Logs{}
 
 	tx, beginErr := api.db.BeginRo(ctx)
@@ -88,57 +87,28 @@ func (api *APIImpl) GetLogs(ctx context.Context, crit filters.FilterCriteria) (t
 	}
 	defer tx.Rollback()
 
-	if crit.BlockHash != nil {
-		num := rawdb.ReadHeaderNumber(tx, *crit.BlockHash)
-		//header, err := api._blockReader.HeaderByHash(ctx, tx, *crit.BlockHash)
-		//if err != nil {
-		//	return nil, err
-		//}
-		if num == nil {
-			return nil, fmt.Errorf("block not found: %x", *crit.BlockHash)
-		}
-		begin = *num
-		end = *num
-	} else {
-		// Convert the RPC block numbers into internal representations
-		latest, _, _, err := rpchelper.GetBlockNumber(rpc.BlockNumberOrHashWithNumber(rpc.LatestExecutedBlockNumber), tx, nil)
-		if err != nil {
-			return nil, err
-		}
-
-		begin = latest
-		if crit.FromBlock != nil {
-			if crit.FromBlock.Sign() >= 0 {
-				begin = crit.FromBlock.Uint64()
-			} else if !crit.FromBlock.IsInt64() || crit.FromBlock.Int64() != int64(rpc.LatestBlockNumber) {
-				return nil, fmt.Errorf("negative value for FromBlock: %v", crit.FromBlock)
-			}
-		}
-		end = latest
-		if crit.ToBlock != nil {
-			if crit.ToBlock.Sign() >= 0 {
-				end = crit.ToBlock.Uint64()
-			} else if !crit.ToBlock.IsInt64() || crit.ToBlock.Int64() != int64(rpc.LatestBlockNumber) {
-				return nil, fmt.Errorf("negative value for ToBlock: %v", crit.ToBlock)
-			}
-		}
+	begin, end, err := getLogsRange(tx, crit)
+	if err != nil {
+		return nil, err
 	}
-	if end < begin {
-		return nil, fmt.Errorf("end (%d) < begin (%d)", end, begin)
+	if api.historyV3(tx) {
+		return api.getLogsV3(ctx, tx.(kv.TemporalTx), begin, end, crit)
 	}
-	if end > roaring.MaxUint32 {
-		latest, err := rpchelper.GetLatestBlockNumber(tx)
+
+	// The logs are counted up front when they are limited, to refuse the queries over the limit before reading them,
+	// unless some of them are not counted, which would read them twice
+	countsFrom, hasCounts, err := rawdb.ReadLogCountsFrom(tx)
+	if err != nil {
+		return nil, err
+	}
+	if api.MaxLogs > 0 && hasCounts && countsFrom <= begin {
+		count, exact, err := logsCount(ctx, tx, begin, end, crit)
 		if err != nil {
 			return nil, err
 		}
-		if begin > latest {
-			return nil, fmt.Errorf("begin (%d) > latest (%d)", begin, latest)
+		if exact && count > api.MaxLogs {
+			return nil, fmt.Errorf("query matches %d logs, more than the limit of %d, use a smaller block range", count, api.MaxLogs)
 		}
-		end = latest
-	}
-
-	if api.historyV3(tx) {
-		return api.getLogsV3(ctx, tx.(kv.TemporalTx), begin, end, crit)
 	}
 
 	blockNumbers := bitmapdb.NewBitmap()
@@ -219,11 +189,64 @@ func (api *APIImpl) GetLogs(ctx context.Context, crit filters.FilterCriteria) (t
 			}
 		}
 		logs = append(logs, blockLogs...)
+		if api.MaxLogs > 0 && uint64(len(logs)) > api.MaxLogs {
+			return nil, fmt.Errorf("query matches more than the limit of %d logs, use a smaller block range", api.MaxLogs)
+		}
 	}
 
 	return logs, nil
 }
 
+// getLogsRange returns the range of blocks of the filter, which ends at the latest executed block by default
+func getLogsRange(tx kv.Tx, crit filters.FilterCriteria) (begin, end uint64, err error) {
+	if crit.BlockHash != nil {
+		num := rawdb.ReadHeaderNumber(tx, *crit.BlockHash)
+		if num == nil {
+			return 0, 0, fmt.Errorf("block not found: %x", *crit.BlockHash)
+		}
+		begin = *num
+		end = *num
+	} else {
+		// Convert the RPC block numbers into internal representations
+		latest, _, _, err := rpchelper.GetBlockNumber(rpc.BlockNumberOrHashWithNumber(rpc.LatestExecutedBlockNumber), tx, nil)
+		if err != nil {
+			return 0, 0, err
+		}
+
+		begin = latest
+		if crit.FromBlock != nil {
+			if crit.FromBlock.Sign() >= 0 {
+				begin = crit.FromBlock.Uint64()
+			} else if !crit.FromBlock.IsInt64() || crit.FromBlock.Int64() != int64(rpc.LatestBlockNumber) {
+				return 0, 0, fmt.Errorf("negative value for FromBlock: %v", crit.FromBlock)
+			}
+		}
+		end = latest
+		if crit.ToBlock != nil {
+			if crit.ToBlock.Sign() >= 0 {
+				end = crit.ToBlock.Uint64()
+			} else if !crit.ToBlock.IsInt64() || crit.ToBlock.Int64() != int64(rpc.LatestBlockNumber) {
+				return 0, 0, fmt.Errorf("negative value for ToBlock: %v", crit.ToBlock)
+			}
+		}
+	}
+	if end < begin {
+		return 0, 0, fmt.Errorf("end (%d) < begin (%d)", end, begin)
+	}
+	if end > roaring.MaxUint32 {
+		latest, err := rpchelper.GetLatestBlockNumber(tx)
+		if err != nil {
+			return 0, 0, err
+		}
+		if begin > latest {
+			return 0, 0, fmt.Errorf("begin (%d) > latest (%d)", begin, latest)
+		}
+		end = latest
+	}
+
+	return begin, end, nil
+}
+
 // The Topic list restricts matches to particular event topics. Each event has a list
 // of topics. Topics matches a prefix of that list. An empty element slice matches any
 // topic. Non-empty elements represent an alternative that matches any of the
@@ -452,6 +475,9 @@ func (api *APIImpl) getLogsV3(ctx context.Context, tx kv.TemporalTx, begin, end
 			log.TxHash = txn.Hash()
 		}
 		logs = append(logs, filtered...)
+		if api.MaxLogs > 0 && uint64(len(logs)) > api.MaxLogs {
+			return nil, fmt.Errorf("query matches more than the limit of %d logs, use a smaller block range", api.MaxLogs)
+		}
 	}
 
 	//stats := api._agg.GetAndResetStats()
@@ -0,0 +1,241 @@
+package jsonrpc
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+	"sync"
+
+	"github.com/holiman/uint256"
+	"github.com/ledgerwatch/erigon-lib/common"
+	"github.com/ledgerwatch/erigon-lib/common/hexutility"
+
+	"github.com/ledgerwatch/erigon/accounts/signer"
+	"github.com/ledgerwatch/erigon/common/hexutil"
+	"github.com/ledgerwatch/erigon/consensus/misc"
+	"github.com/ledgerwatch/erigon/core/rawdb"
+	"github.com/ledgerwatch/erigon/core/types"
+	"github.com/ledgerwatch/erigon/eth/ethconfig"
+	"github.com/ledgerwatch/erigon/rpc"
+	ethapi2 "github.com/ledgerwatch/erigon/turbo/adapter/ethapi"
+)
+
+// SignTransactionResult is the result of eth_signTransaction
+type SignTransactionResult struct {
+	Raw hexutility.Bytes `json:"raw"`
+	Tx  *RPCTransaction  `json:"tx"`
+}
+
+// SetSigner enables eth_accounts, eth_sign, eth_signTransaction and eth_sendTransaction with the accounts of s
+func (api *APIImpl) SetSigner(s signer.Signer) {
+	api.signer = s
+}
+
+// Accounts implements eth_accounts. Returns a list of addresses owned by the client.
+func (api *APIImpl) Accounts(ctx context.Context) ([]common.Address, error) {
+	if api.signer == nil {
+		return []common.Address{}, fmt.Errorf(NotAvailableDeprecated, "eth_accounts")
+	}
+	return api.signer.Accounts(ctx)
+}
+
+// Sign implements eth_sign. Calculates an Ethereum specific signature with: sign(keccak256('\\x19Ethereum Signed Message:\\n' + len(message) + message))).
+func (api *APIImpl) Sign(ctx context.Context, address common.Address, data hexutility.Bytes) (hexutility.Bytes, error) {
+	if api.signer == nil {
+		return hexutility.Bytes(""), fmt.Errorf(NotAvailableSigner, "eth_sign")
+	}
+	return api.signer.SignText(ctx, address, data)
+}
+
+// SignTransaction implements eth_signTransaction. Signs a transaction with the key of its sender, without sending it.
+// The missing nonce, gas and fee fields are filled like for eth_sendTransaction.
+func (api *APIImpl) SignTransaction(ctx context.Context, args ethapi2.CallArgs) (*SignTransactionResult, error) {
+	if api.signer == nil {
+		return nil, fmt.Errorf(NotAvailableSigner, "eth_signTransaction")
+	}
+	if args.From == nil {
+		return nil, errors.New("from not specified")
+	}
+	unlock := api.nonceLocks.lock(*args.From)
+	defer unlock()
+
+	signed, err := api.signTx(ctx, args)
+	if err != nil {
+		return nil, err
+	}
+	var buf bytes.Buffer
+	if err := signed.MarshalBinary(&buf); err != nil {
+		return nil, err
+	}
+	return &SignTransactionResult{Raw: buf.Bytes(), Tx: newRPCTransaction(signed, common.Hash{}, 0, 0, nil)}, nil
+}
+
+// SendTransaction implements eth_sendTransaction. Creates new message call transaction or a contract creation if the data field contains code.
+// The transaction is signed with the key of its sender, and its missing nonce, gas and fee fields are filled from the
+// txpool, the gas estimation and the gas price oracle.
+func (api *APIImpl) SendTransaction(ctx context.Context, args ethapi2.CallArgs) (common.Hash, error) {
+	if api.signer == nil {
+		return common.Hash{}, fmt.Errorf(NotAvailableSigner, "eth_sendTransaction")
+	}
+	if args.From == nil {
+		return common.Hash{}, errors.New("from not specified")
+	}
+	// The transaction must be in the txpool before the next one of the account takes its nonce from the txpool
+	unlock := api.nonceLocks.lock(*args.From)
+	defer unlock()
+
+	signed, err := api.signTx(ctx, args)
+	if err != nil {
+		return common.Hash{}, err
+	}
+	var buf bytes.Buffer
+	if err := signed.MarshalBinary(&buf); err != nil {
+		return common.Hash{}, err
+	}
+	return api.SendRawTransaction(ctx, buf.Bytes())
+}
+
+func (api *APIImpl) signTx(ctx context.Context, args ethapi2.CallArgs) (types.Transaction, error) {
+	chainID, err := api.fillTxDefaults(ctx, &args)
+	if err != nil {
+		return nil, err
+	}
+	tx, err := toTransaction(&args, chainID)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkTxFee(tx.GetFeeCap().ToBig(), tx.GetGas(), ethconfig.Defaults.RPCTxFeeCap); err != nil {
+		return nil, err
+	}
+	return api.signer.SignTx(ctx, *args.From, tx, chainID)
+}
+
+// fillTxDefaults fills the nonce, the fees and the gas of args which are not specified, and returns the chain id
+func (api *APIImpl) fillTxDefaults(ctx context.Context, args *ethapi2.CallArgs) (*big.Int, error) {
+	if args.GasPrice != nil && (args.MaxFeePerGas != nil || args.MaxPriorityFeePerGas != nil) {
+		return nil, errors.New("both gasPrice and (maxFeePerGas or maxPriorityFeePerGas) specified")
+	}
+	if args.To == nil && (args.Data == nil || len(*args.Data) == 0) {
+		return nil, errors.New("contract creation without any data provided")
+	}
+
+	tx, err := api.db.BeginRo(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+	cc, err := api.chainConfig(tx)
+	if err != nil {
+		return nil, err
+	}
+	if args.ChainID != nil && args.ChainID.ToInt().Cmp(cc.ChainID) != 0 {
+		return nil, fmt.Errorf("invalid chain id, expected: %d got: %d", cc.ChainID, args.ChainID.ToInt())
+	}
+	var baseFee *big.Int
+	if head := rawdb.ReadCurrentHeader(tx); head != nil && cc.IsLondon(head.Number.Uint64()+1) {
+		baseFee = misc.CalcBaseFee(cc, head)
+	}
+	tx.Rollback()
+
+	switch {
+	case args.GasPrice != nil:
+	case baseFee == nil:
+		if args.GasPrice, err = api.GasPrice(ctx); err != nil {
+			return nil, err
+		}
+	default:
+		if args.MaxPriorityFeePerGas == nil {
+			if args.MaxPriorityFeePerGas, err = api.MaxPriorityFeePerGas(ctx); err != nil {
+				return nil, err
+			}
+		}
+		if args.MaxFeePerGas == nil {
+			// Leaves room for the base fee to double before the transaction is included
+			feeCap := new(big.Int).Add(args.MaxPriorityFeePerGas.ToInt(), new(big.Int).Mul(baseFee, big.NewInt(2)))
+			args.MaxFeePerGas = (*hexutil.Big)(feeCap)
+		}
+		if args.MaxFeePerGas.ToInt().Cmp(args.MaxPriorityFeePerGas.ToInt()) < 0 {
+			return nil, fmt.Errorf("maxFeePerGas (%v) < maxPriorityFeePerGas (%v)", args.MaxFeePerGas, args.MaxPriorityFeePerGas)
+		}
+	}
+	if args.Nonce == nil {
+		nonce, err := api.GetTransactionCount(ctx, *args.From, rpc.BlockNumberOrHashWithNumber(rpc.PendingBlockNumber))
+		if err != nil {
+			return nil, err
+		}
+		args.Nonce = nonce
+	}
+	if args.Gas == nil {
+		gas, err := api.EstimateGas(ctx, args, nil, nil, nil)
+		if err != nil {
+			return nil, err
+		}
+		args.Gas = &gas
+	}
+	return cc.ChainID, nil
+}
+
+// toTransaction returns the unsigned transaction of args, whose nonce, fees and gas are filled
+func toTransaction(args *ethapi2.CallArgs, chainID *big.Int) (types.Transaction, error) {
+	value := new(uint256.Int)
+	if args.Value != nil {
+		if overflow := value.SetFromBig(args.Value.ToInt()); overflow {
+			return nil, errors.New("value overflows 256 bits")
+		}
+	}
+	var data []byte
+	if args.Data != nil {
+		data = *args.Data
+	}
+	commonTx := types.CommonTx{Nonce: uint64(*args.Nonce), Gas: uint64(*args.Gas), To: args.To, Value: value, Data: data}
+	chainIDInt, _ := uint256.FromBig(chainID)
+
+	if args.GasPrice != nil {
+		gasPrice, overflow := uint256.FromBig(args.GasPrice.ToInt())
+		if overflow {
+			return nil, errors.New("gasPrice overflows 256 bits")
+		}
+		legacy := types.LegacyTx{CommonTx: commonTx, GasPrice: gasPrice}
+		if args.AccessList == nil {
+			return &legacy, nil
+		}
+		return &types.AccessListTx{LegacyTx: legacy, ChainID: chainIDInt, AccessList: *args.AccessList}, nil
+	}
+	tip, overflow := uint256.FromBig(args.MaxPriorityFeePerGas.ToInt())
+	if overflow {
+		return nil, errors.New("maxPriorityFeePerGas overflows 256 bits")
+	}
+	feeCap, overflow := uint256.FromBig(args.MaxFeePerGas.ToInt())
+	if overflow {
+		return nil, errors.New("maxFeePerGas overflows 256 bits")
+	}
+	tx := &types.DynamicFeeTransaction{CommonTx: commonTx, ChainID: chainIDInt, Tip: tip, FeeCap: feeCap}
+	if args.AccessList != nil {
+		tx.AccessList = *args.AccessList
+	}
+	return tx, nil
+}
+
+// nonceLocks serializes the transactions signed for an account, for them to get consecutive nonces from the txpool
+type nonceLocks struct {
+	mu    sync.Mutex
+	locks map[common.Address]*sync.Mutex
+}
+
+func (l *nonceLocks) lock(account common.Address) (unlock func()) {
+	l.mu.Lock()
+	if l.locks == nil {
+		l.locks = map[common.Address]*sync.Mutex{}
+	}
+	lock, ok := l.locks[account]
+	if !ok {
+		lock = &sync.Mutex{}
+		l.locks[account] = lock
+	}
+	l.mu.Unlock()
+
+	lock.Lock()
+	return lock.Unlock
+}
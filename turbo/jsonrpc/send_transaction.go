@@ -5,6 +5,7 @@ import (
 	"errors"
 	"fmt"
 	"math/big"
+	"net"
 
 	"github.com/ledgerwatch/erigon-lib/common"
 	"github.com/ledgerwatch/erigon-lib/common/hexutility"
@@ -12,6 +13,8 @@ import (
 
 	"github.com/ledgerwatch/erigon/core/types"
 	"github.com/ledgerwatch/erigon/eth/ethconfig"
+	"github.com/ledgerwatch/erigon/p2p/netutil"
+	"github.com/ledgerwatch/erigon/p2p/txpolicy"
 	"github.com/ledgerwatch/erigon/params"
 )
 
@@ -53,6 +56,18 @@ func (api *APIImpl) SendRawTransaction(ctx context.Context, encodedTx hexutility
 	}
 
 	hash := txn.Hash()
+	private, err := api.isPrivateTxClient(ctx)
+	if err != nil {
+		return common.Hash{}, err
+	}
+	if private {
+		// Refused rather than broadcast by the sentries which can't see the registry
+		if !api.privateTxs.Enforced() {
+			return common.Hash{}, txpolicy.ErrNotEnforced
+		}
+		// Registered before the pool gets the transaction, so that the sentries never see it unregistered
+		api.privateTxs.Add(hash)
+	}
 	res, err := api.txPool.Add(ctx, &txPoolProto.AddRequest{RlpTxs: [][]byte{encodedTx}})
 	if err != nil {
 		return common.Hash{}, err
@@ -65,11 +80,6 @@ func (api *APIImpl) SendRawTransaction(ctx context.Context, encodedTx hexutility
 	return txn.Hash(), nil
 }
 
-// SendTransaction implements eth_sendTransaction. Creates new message call transaction or a contract creation if the data field contains code.
-func (api *APIImpl) SendTransaction(_ context.Context, txObject interface{}) (common.Hash, error) {
-	return common.Hash{0}, fmt.Errorf(NotImplemented, "eth_sendTransaction")
-}
-
 // checkTxFee is an internal function used to check whether the fee of
 // the given transaction is _reasonable_(under the cap).
 func checkTxFee(gasPrice *big.Int, gas uint64, gasCap float64) error {
@@ -84,3 +94,34 @@ func checkTxFee(gasPrice *big.Int, gas uint64, gasCap float64) error {
 	}
 	return nil
 }
+
+// SetPrivateTxClients makes the raw transactions sent by the clients in the list private: they are included by
+// this node but never broadcast. The registry must be shared with the sentries, so the transactions of these
+// clients are refused unless all the sentries run in the same process, see txpolicy.PrivateTxs.Enforced.
+func (api *APIImpl) SetPrivateTxClients(clients *netutil.Netlist, registry *txpolicy.PrivateTxs) {
+	api.privateTxClients = clients
+	api.privateTxs = registry
+}
+
+// errUnknownTxClient is returned for the raw transactions whose client address is unknown while some clients are private.
+var errUnknownTxClient = errors.New("raw transactions are only accepted over HTTP when private transaction clients are set")
+
+// isPrivateTxClient returns whether the remote address of an HTTP request is among the private transaction clients.
+// Only the HTTP transport knows the address of the client, so with private clients set the transactions sent over the
+// other transports (websocket, IPC) are refused rather than risk broadcasting them. Clients behind a proxy are seen
+// with the address of the proxy.
+func (api *APIImpl) isPrivateTxClient(ctx context.Context) (bool, error) {
+	if api.privateTxClients == nil || api.privateTxs == nil {
+		return false, nil
+	}
+	remote, ok := ctx.Value("remote").(string)
+	if !ok {
+		return false, errUnknownTxClient
+	}
+	host, _, err := net.SplitHostPort(remote)
+	if err != nil {
+		host = remote
+	}
+	ip := net.ParseIP(host)
+	return ip != nil && api.privateTxClients.Contains(ip), nil
+}
@@ -2,6 +2,7 @@ package jsonrpc_test
 
 import (
 	"bytes"
+	"context"
 	"crypto/ecdsa"
 	"math/big"
 	"testing"
@@ -14,13 +15,17 @@ import (
 	"github.com/ledgerwatch/erigon/rpc/rpccfg"
 	"github.com/stretchr/testify/require"
 
+	"github.com/ledgerwatch/erigon/accounts/signer"
 	"github.com/ledgerwatch/erigon/cmd/rpcdaemon/rpcdaemontest"
+	"github.com/ledgerwatch/erigon/common/hexutil"
 	"github.com/ledgerwatch/erigon/common/u256"
 	"github.com/ledgerwatch/erigon/core"
 	"github.com/ledgerwatch/erigon/core/types"
+	"github.com/ledgerwatch/erigon/crypto"
 	"github.com/ledgerwatch/erigon/eth/protocols/eth"
 	"github.com/ledgerwatch/erigon/params"
 	"github.com/ledgerwatch/erigon/rlp"
+	"github.com/ledgerwatch/erigon/turbo/adapter/ethapi"
 	"github.com/ledgerwatch/erigon/turbo/jsonrpc"
 	"github.com/ledgerwatch/erigon/turbo/rpchelper"
 	"github.com/ledgerwatch/erigon/turbo/stages"
@@ -114,3 +119,40 @@ func pricedTransaction(nonce uint64, gaslimit uint64, gasprice *uint256.Int, key
 	tx, _ := types.SignTx(types.NewTransaction(nonce, common.Address{}, uint256.NewInt(100), gaslimit, gasprice, nil), *types.LatestSignerForChainID(big.NewInt(1337)), key)
 	return tx
 }
+
+func TestSignTransaction(t *testing.T) {
+	m, require := stages.Mock(t), require.New(t)
+	ctx := context.Background()
+	api := jsonrpc.NewEthAPI(newBaseApiForTest(m), m.DB, nil, nil, nil, 5000000, 100_000, log.New())
+	account := crypto.PubkeyToAddress(m.Key.PublicKey)
+	to := common.Address{1}
+	nonce := hexutil.Uint64(0)
+	args := ethapi.CallArgs{From: &account, To: &to, Value: (*hexutil.Big)(big.NewInt(1234)), Nonce: &nonce}
+
+	_, err := api.SignTransaction(ctx, args)
+	require.Error(err, "no signer")
+
+	api.SetSigner(signer.NewKeyStore(m.Key))
+	accounts, err := api.Accounts(ctx)
+	require.NoError(err)
+	require.Equal([]common.Address{account}, accounts)
+
+	res, err := api.SignTransaction(ctx, args)
+	require.NoError(err)
+	txn, err := types.DecodeWrappedTransaction(res.Raw)
+	require.NoError(err)
+	sender, err := txn.Sender(*types.LatestSignerForChainID(m.ChainConfig.ChainID))
+	require.NoError(err)
+	require.Equal(account, sender)
+	require.Equal(uint64(1234), txn.GetValue().Uint64())
+	require.Equal(params.TxGas, txn.GetGas())
+	require.Equal(uint8(types.DynamicFeeTxType), txn.Type())
+	require.Equal(txn.Hash(), res.Tx.Hash)
+
+	sig, err := api.Sign(ctx, account, []byte("hello"))
+	require.NoError(err)
+	require.Len(sig, 65)
+
+	_, err = api.Sign(ctx, to, []byte("hello"))
+	require.ErrorIs(err, signer.ErrUnknownAccount)
+}
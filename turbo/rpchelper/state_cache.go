@@ -0,0 +1,416 @@
+package rpchelper
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"sync"
+	"sync/atomic"
+
+	"github.com/VictoriaMetrics/metrics"
+	"github.com/c2h5oh/datasize"
+	libcommon "github.com/ledgerwatch/erigon-lib/common"
+	"github.com/ledgerwatch/erigon-lib/common/hexutility"
+	"github.com/ledgerwatch/erigon-lib/common/length"
+	"github.com/ledgerwatch/erigon-lib/gointerfaces"
+	"github.com/ledgerwatch/erigon-lib/gointerfaces/remote"
+	"github.com/ledgerwatch/erigon-lib/kv"
+	"github.com/ledgerwatch/erigon-lib/kv/kvcache"
+
+	"github.com/ledgerwatch/erigon/rpc"
+)
+
+// errNotCached is returned by the reads of peekTx, which never reach the database
+var errNotCached = errors.New("not cached")
+
+// StateCache is the state cache of the rpcdaemon: it wraps a kvcache.Cache to count the hits and misses of its views,
+// per RPC method, and the keys the new blocks overwrite or push out of it, and it can be resized at runtime. Resizing
+// replaces the cache with an empty one, which fills up again from the next block.
+type StateCache struct {
+	lock  sync.RWMutex
+	cache kvcache.Cache
+	cfg   kvcache.CoherentConfig
+	db    kv.RoDB // Database of the cached state, read by the new blocks to check which keys they overwrite
+
+	// Version of the latest block applied, the number of keys of its root, and the keys added to it by the misses since
+	latest        atomic.Uint64
+	latestKeys    uint64
+	latestInserts atomic.Uint64
+
+	blocks        atomic.Uint64
+	invalidations atomic.Uint64
+	evictions     atomic.Uint64
+	resets        atomic.Uint64
+
+	total       *cacheCounters
+	methodsLock sync.Mutex
+	methods     map[string]*cacheCounters
+}
+
+var _ kvcache.Cache = (*StateCache)(nil)
+
+type cacheCounters struct {
+	hits, misses, codeHits, codeMisses atomic.Uint64
+
+	hitsMetric, missesMetric, codeHitsMetric, codeMissesMetric *metrics.Counter
+}
+
+func newCacheCounters(method string) *cacheCounters {
+	return &cacheCounters{
+		hitsMetric:       metrics.GetOrCreateCounter(fmt.Sprintf(`rpc_cache_total{method="%s",result="hit"}`, method)),
+		missesMetric:     metrics.GetOrCreateCounter(fmt.Sprintf(`rpc_cache_total{method="%s",result="miss"}`, method)),
+		codeHitsMetric:   metrics.GetOrCreateCounter(fmt.Sprintf(`rpc_cache_code_total{method="%s",result="hit"}`, method)),
+		codeMissesMetric: metrics.GetOrCreateCounter(fmt.Sprintf(`rpc_cache_code_total{method="%s",result="miss"}`, method)),
+	}
+}
+
+func (c *cacheCounters) count(code, miss bool) {
+	switch {
+	case code && miss:
+		c.codeMisses.Add(1)
+		c.codeMissesMetric.Inc()
+	case code:
+		c.codeHits.Add(1)
+		c.codeHitsMetric.Inc()
+	case miss:
+		c.misses.Add(1)
+		c.missesMetric.Inc()
+	default:
+		c.hits.Add(1)
+		c.hitsMetric.Inc()
+	}
+}
+
+func (c *cacheCounters) stats() CacheMethodStats {
+	s := CacheMethodStats{
+		Hits:       c.hits.Load(),
+		Misses:     c.misses.Load(),
+		CodeHits:   c.codeHits.Load(),
+		CodeMisses: c.codeMisses.Load(),
+	}
+	s.HitRatio = hitRatio(s.Hits, s.Misses)
+	s.CodeHitRatio = hitRatio(s.CodeHits, s.CodeMisses)
+	return s
+}
+
+func hitRatio(hits, misses uint64) float64 {
+	if hits+misses == 0 {
+		return 0
+	}
+	return float64(hits) / float64(hits+misses)
+}
+
+// NewStateCache makes a coherent cache with cfg, or a dummy one if its CacheSize is 0, of the state in db
+func NewStateCache(cfg kvcache.CoherentConfig, db kv.RoDB) *StateCache {
+	s := &StateCache{
+		cfg:     cfg,
+		db:      db,
+		total:   newCacheCounters("all"),
+		methods: map[string]*cacheCounters{},
+	}
+	s.cache = newCache(cfg)
+	return s
+}
+
+func newCache(cfg kvcache.CoherentConfig) kvcache.Cache {
+	if cfg.CacheSize == 0 {
+		return kvcache.NewDummy()
+	}
+	return kvcache.New(cfg)
+}
+
+func (s *StateCache) current() kvcache.Cache {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+	return s.cache
+}
+
+func (s *StateCache) counters(ctx context.Context) *cacheCounters {
+	method, ok := rpc.MethodFromContext(ctx)
+	if !ok {
+		method = "other"
+	}
+	s.methodsLock.Lock()
+	defer s.methodsLock.Unlock()
+	c, ok := s.methods[method]
+	if !ok {
+		c = newCacheCounters(method)
+		s.methods[method] = c
+	}
+	return c
+}
+
+func (s *StateCache) View(ctx context.Context, tx kv.Tx) (kvcache.CacheView, error) {
+	statsTx := &statsTx{Tx: tx}
+	view, err := s.current().View(ctx, statsTx)
+	if err != nil {
+		return nil, err
+	}
+	return &statsView{view: view, tx: statsTx, cache: s, counters: s.counters(ctx)}, nil
+}
+
+func (s *StateCache) OnNewBlock(sc *remote.StateChangeBatch) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	written, writtenCached := s.written(sc)
+	s.cache.OnNewBlock(sc)
+	keys := uint64(s.cache.Len())
+
+	s.blocks.Add(1)
+	s.invalidations.Add(writtenCached)
+	if s.latest.Load()+1 == sc.StateVersionId {
+		// The root of the block is a copy of the previous one, with the keys added since: the missing ones were evicted
+		if expected := s.latestKeys + s.latestInserts.Load() + written - writtenCached; expected > keys {
+			s.evictions.Add(expected - keys)
+		}
+	} else if s.latest.Load() != sc.StateVersionId {
+		s.resets.Add(1)
+	}
+	s.latest.Store(sc.StateVersionId)
+	s.latestKeys = keys
+	s.latestInserts.Store(0)
+}
+
+// written returns the number of state keys the block writes to the cache, and how many of them are in the root of the
+// previous block
+func (s *StateCache) written(sc *remote.StateChangeBatch) (written, cached uint64) {
+	coherent, ok := s.cache.(*kvcache.Coherent)
+	if !ok {
+		return 0, 0
+	}
+	keys := map[string]struct{}{}
+	for _, change := range sc.ChangeBatch {
+		for _, ac := range change.Changes {
+			addr := gointerfaces.ConvertH160toAddress(ac.Address)
+			if ac.Action != remote.Action_STORAGE && ac.Action != remote.Action_CODE {
+				keys[string(addr[:])] = struct{}{}
+			}
+			if !s.cfg.WithStorage {
+				continue
+			}
+			for _, storage := range ac.StorageChanges {
+				loc := gointerfaces.ConvertH256ToHash(storage.Location)
+				keys[string(StorageCacheKey(addr, ac.Incarnation, loc))] = struct{}{}
+			}
+		}
+	}
+	if len(keys) == 0 {
+		return 0, 0
+	}
+	// The keys are looked up with a peekTx, so that the missing ones are neither read nor added to the cache
+	tx, err := s.db.BeginRo(context.Background())
+	if err != nil {
+		return uint64(len(keys)), 0
+	}
+	defer tx.Rollback()
+	latest := s.latest.Load()
+	for k := range keys {
+		if _, err := coherent.Get([]byte(k), &peekTx{Tx: tx}, latest); err == nil {
+			cached++
+		}
+	}
+	return uint64(len(keys)), cached
+}
+
+func (s *StateCache) Len() int {
+	return s.current().Len()
+}
+
+func (s *StateCache) ValidateCurrentRoot(ctx context.Context, tx kv.Tx) (*kvcache.CacheValidationResult, error) {
+	return s.current().ValidateCurrentRoot(ctx, tx)
+}
+
+// Resize replaces the cache with an empty one of the given sizes, disabling it when cacheSize is 0
+func (s *StateCache) Resize(cacheSize, codeCacheSize datasize.ByteSize) error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	cfg := s.cfg
+	cfg.CacheSize, cfg.CodeCacheSize = cacheSize, codeCacheSize
+	if cfg.CacheSize > 0 && cfg.KeepViews == 0 {
+		return fmt.Errorf("the state cache was started without a configuration")
+	}
+	s.cfg = cfg
+	s.cache = newCache(cfg)
+	s.latest.Store(0)
+	s.latestKeys = 0
+	s.latestInserts.Store(0)
+	return nil
+}
+
+// CacheStats are the statistics of the state cache since the rpcdaemon started
+type CacheStats struct {
+	Enabled       bool   `json:"enabled"`
+	CacheSize     uint64 `json:"cacheSize"`
+	CodeCacheSize uint64 `json:"codeCacheSize"`
+	Keys          int    `json:"keys"`
+	// Number of keys in the roots of the cache, which are the state versions the views are consistent with
+	Views []CacheViewStats `json:"views"`
+
+	Blocks uint64 `json:"blocks"`
+	// Cached keys overwritten by the new blocks
+	Invalidations uint64 `json:"invalidations"`
+	// Keys pushed out by the size limit
+	Evictions uint64 `json:"evictions"`
+	// New blocks which did not follow the previous one, after which the cache starts over
+	Resets uint64 `json:"resets"`
+
+	CacheMethodStats
+	Methods map[string]CacheMethodStats `json:"methods"`
+}
+
+type CacheViewStats struct {
+	StateVersion uint64 `json:"stateVersion"`
+	Keys         int    `json:"keys"`
+}
+
+// CacheMethodStats are the hits and misses of the views of the cache
+type CacheMethodStats struct {
+	Hits         uint64  `json:"hits"`
+	Misses       uint64  `json:"misses"`
+	HitRatio     float64 `json:"hitRatio"`
+	CodeHits     uint64  `json:"codeHits"`
+	CodeMisses   uint64  `json:"codeMisses"`
+	CodeHitRatio float64 `json:"codeHitRatio"`
+}
+
+func (s *StateCache) Stats() *CacheStats {
+	s.lock.RLock()
+	cache, cfg := s.cache, s.cfg
+	s.lock.RUnlock()
+	_, enabled := cache.(*kvcache.Coherent)
+	stats := &CacheStats{
+		Enabled:          enabled,
+		CacheSize:        cfg.CacheSize.Bytes(),
+		CodeCacheSize:    cfg.CodeCacheSize.Bytes(),
+		Keys:             cache.Len(),
+		Views:            []CacheViewStats{},
+		Blocks:           s.blocks.Load(),
+		Invalidations:    s.invalidations.Load(),
+		Evictions:        s.evictions.Load(),
+		Resets:           s.resets.Load(),
+		CacheMethodStats: s.total.stats(),
+		Methods:          map[string]CacheMethodStats{},
+	}
+	for _, view := range kvcache.DebugStats(cache) {
+		stats.Views = append(stats.Views, CacheViewStats{StateVersion: view.BlockNum, Keys: view.Lenght})
+	}
+	s.methodsLock.Lock()
+	defer s.methodsLock.Unlock()
+	for method, c := range s.methods {
+		stats.Methods[method] = c.stats()
+	}
+	return stats
+}
+
+// CacheEntry is a key of the state cache, with its value when it is cached. A nil value is cached for the keys absent
+// from the state.
+type CacheEntry struct {
+	Key    hexutility.Bytes `json:"key"`
+	Cached bool             `json:"cached"`
+	Value  hexutility.Bytes `json:"value"`
+}
+
+// Inspect looks up the given state and code keys in the view of the cache consistent with tx, without reading the
+// database nor adding them to the cache
+func (s *StateCache) Inspect(ctx context.Context, tx kv.Tx, keys, codeKeys [][]byte) (state, code []CacheEntry, err error) {
+	view, err := s.current().View(ctx, &peekTx{Tx: tx})
+	if err != nil {
+		return nil, nil, err
+	}
+	lookup := func(keys [][]byte, get func([]byte) ([]byte, error)) ([]CacheEntry, error) {
+		entries := make([]CacheEntry, 0, len(keys))
+		for _, k := range keys {
+			v, err := get(k)
+			if err != nil && !errors.Is(err, errNotCached) {
+				return nil, err
+			}
+			entries = append(entries, CacheEntry{Key: k, Cached: err == nil, Value: v})
+		}
+		return entries, nil
+	}
+	if state, err = lookup(keys, view.Get); err != nil {
+		return nil, nil, err
+	}
+	if code, err = lookup(codeKeys, view.GetCode); err != nil {
+		return nil, nil, err
+	}
+	return state, code, nil
+}
+
+// StorageCacheKey is the key of a storage slot in the state cache
+func StorageCacheKey(address libcommon.Address, incarnation uint64, location libcommon.Hash) []byte {
+	k := make([]byte, length.Addr+length.Incarnation+length.Hash)
+	copy(k, address[:])
+	binary.BigEndian.PutUint64(k[length.Addr:], incarnation)
+	copy(k[length.Addr+length.Incarnation:], location[:])
+	return k
+}
+
+// statsView counts the hits and misses of a view: the cache reads the database on the misses only
+type statsView struct {
+	view     kvcache.CacheView
+	tx       *statsTx
+	cache    *StateCache
+	counters *cacheCounters
+}
+
+func (v *statsView) read(code bool, k []byte, get func([]byte) ([]byte, error)) ([]byte, error) {
+	reads := v.tx.reads
+	val, err := get(k)
+	if err != nil {
+		return nil, err
+	}
+	miss := v.tx.reads > reads
+	v.counters.count(code, miss)
+	v.cache.total.count(code, miss)
+	if miss && !code && v.tx.versionRead && v.tx.version == v.cache.latest.Load() {
+		v.cache.latestInserts.Add(1)
+	}
+	return val, nil
+}
+
+func (v *statsView) Get(k []byte) ([]byte, error) { return v.read(false, k, v.view.Get) }
+func (v *statsView) GetCode(k []byte) ([]byte, error) {
+	return v.read(true, k, v.view.GetCode)
+}
+
+// statsTx counts the reads of the state and the code, and records the state version the view is consistent with
+type statsTx struct {
+	kv.Tx
+	reads       int
+	version     uint64
+	versionRead bool
+}
+
+func (tx *statsTx) GetOne(table string, key []byte) ([]byte, error) {
+	v, err := tx.Tx.GetOne(table, key)
+	if err != nil {
+		return nil, err
+	}
+	switch table {
+	case kv.PlainState, kv.Code:
+		tx.reads++
+	case kv.Sequence:
+		if bytes.Equal(key, kv.PlainStateVersion) {
+			tx.version, tx.versionRead = 0, true
+			if len(v) == 8 {
+				tx.version = binary.BigEndian.Uint64(v)
+			}
+		}
+	}
+	return v, nil
+}
+
+// peekTx fails the reads of the state and the code, so that the cache returns the cached values only
+type peekTx struct {
+	kv.Tx
+}
+
+func (tx *peekTx) GetOne(table string, key []byte) ([]byte, error) {
+	if table == kv.PlainState || table == kv.Code {
+		return nil, errNotCached
+	}
+	return tx.Tx.GetOne(table, key)
+}
@@ -0,0 +1,104 @@
+package rpchelper
+
+import (
+	"context"
+	"encoding/binary"
+	"testing"
+
+	libcommon "github.com/ledgerwatch/erigon-lib/common"
+	"github.com/ledgerwatch/erigon-lib/gointerfaces"
+	"github.com/ledgerwatch/erigon-lib/gointerfaces/remote"
+	"github.com/ledgerwatch/erigon-lib/kv"
+	"github.com/ledgerwatch/erigon-lib/kv/kvcache"
+	"github.com/ledgerwatch/erigon-lib/kv/memdb"
+)
+
+func TestStateCacheStats(t *testing.T) {
+	ctx := context.Background()
+	cfg := kvcache.DefaultCoherentConfig
+	cfg.NewBlockWait = 0
+	db := memdb.NewTestDB(t)
+	c := NewStateCache(cfg, db)
+	k1, k2, k3 := libcommon.Address{1}, libcommon.Address{2}, libcommon.Address{3}
+
+	tx, err := db.BeginRw(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer tx.Rollback()
+	if err := tx.Put(kv.PlainState, k1[:], []byte{1}); err != nil {
+		t.Fatal(err)
+	}
+	var version [8]byte
+	binary.BigEndian.PutUint64(version[:], 1)
+	if err := tx.Put(kv.Sequence, kv.PlainStateVersion, version[:]); err != nil {
+		t.Fatal(err)
+	}
+	c.OnNewBlock(&remote.StateChangeBatch{StateVersionId: 1})
+
+	view, err := c.View(ctx, tx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, k := range []libcommon.Address{k1, k1, k2} {
+		if _, err := view.Get(k[:]); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if _, err := view.GetCode(libcommon.Hash{1}.Bytes()); err != nil {
+		t.Fatal(err)
+	}
+	stats := c.Stats()
+	if !stats.Enabled || stats.Hits != 1 || stats.Misses != 2 || stats.CodeHits != 0 || stats.CodeMisses != 1 {
+		t.Fatalf("unexpected stats %+v", stats.CacheMethodStats)
+	}
+	if other := stats.Methods["other"]; other != stats.CacheMethodStats {
+		t.Fatalf("unexpected stats of the views without a method %+v", other)
+	}
+	if stats.Keys != 2 {
+		t.Fatalf("expected 2 keys, got %d", stats.Keys)
+	}
+
+	// Inspecting the cache does not fill it
+	for i := 0; i < 2; i++ {
+		state, code, err := c.Inspect(ctx, tx, [][]byte{k1[:], k2[:], k3[:]}, [][]byte{libcommon.Hash{1}.Bytes(), libcommon.Hash{2}.Bytes()})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !state[0].Cached || string(state[0].Value) != "\x01" || !state[1].Cached || state[1].Value != nil || state[2].Cached {
+			t.Fatalf("unexpected state entries %+v", state)
+		}
+		if !code[0].Cached || code[1].Cached {
+			t.Fatalf("unexpected code entries %+v", code)
+		}
+	}
+	if stats = c.Stats(); stats.Keys != 2 || stats.Misses != 2 {
+		t.Fatalf("inspecting changed the cache: %d keys, %d misses", stats.Keys, stats.Misses)
+	}
+
+	c.OnNewBlock(&remote.StateChangeBatch{
+		StateVersionId: 2,
+		ChangeBatch: []*remote.StateChange{{
+			Direction: remote.Direction_FORWARD,
+			Changes: []*remote.AccountChange{
+				{Action: remote.Action_UPSERT, Address: gointerfaces.ConvertAddressToH160(k1), Data: []byte{2}},
+				{Action: remote.Action_UPSERT, Address: gointerfaces.ConvertAddressToH160(k3), Data: []byte{3}},
+			},
+		}},
+	})
+	stats = c.Stats()
+	if stats.Blocks != 2 || stats.Invalidations != 1 || stats.Evictions != 0 || stats.Resets != 0 || stats.Keys != 3 {
+		t.Fatalf("unexpected block stats %+v", stats)
+	}
+	c.OnNewBlock(&remote.StateChangeBatch{StateVersionId: 5})
+	if stats = c.Stats(); stats.Resets != 1 {
+		t.Fatalf("expected a reset, got %d", stats.Resets)
+	}
+
+	if err := c.Resize(0, 0); err != nil {
+		t.Fatal(err)
+	}
+	if stats = c.Stats(); stats.Enabled || stats.Keys != 0 {
+		t.Fatalf("the cache is still enabled after resizing it to 0")
+	}
+}
@@ -5,8 +5,12 @@ import (
 	"math/big"
 	"testing"
 
+	libcommon "github.com/ledgerwatch/erigon-lib/common"
 	"github.com/ledgerwatch/erigon-lib/kv"
+	"github.com/ledgerwatch/erigon-lib/kv/memdb"
+	"github.com/stretchr/testify/require"
 
+	"github.com/ledgerwatch/erigon/consensus/bor/finality"
 	"github.com/ledgerwatch/erigon/core"
 	"github.com/ledgerwatch/erigon/core/types"
 	"github.com/ledgerwatch/erigon/crypto"
@@ -39,7 +43,7 @@ func TestInserter1(t *testing.T) {
 	}
 	defer tx.Rollback()
 	br := m.BlockReader
-	hi := headerdownload.NewHeaderInserter("headers", big.NewInt(0), 0, br)
+	hi := headerdownload.NewHeaderInserter("headers", big.NewInt(0), 0, br, m.Log)
 	h1 := types.Header{
 		Number:     big.NewInt(1),
 		Difficulty: big.NewInt(10),
@@ -61,3 +65,50 @@ func TestInserter1(t *testing.T) {
 		t.Errorf("feed empty header 2: %v", err)
 	}
 }
+
+func TestInserterFinality(t *testing.T) {
+	key, _ := crypto.HexToECDSA("b71c71a67e1177ad4e901695e1b4b9ee17ae16c6668d313eac2f96dbcda3f291")
+	gspec := &types.Genesis{Config: params.AllProtocolChanges}
+	m := stages.MockWithGenesis(t, gspec, key, false)
+	_, genesis, err := core.CommitGenesisBlock(m.DB, gspec, "", m.Log)
+	require.NoError(t, err)
+	tx, err := m.DB.BeginRw(context.Background())
+	require.NoError(t, err)
+	defer tx.Rollback()
+	br := m.BlockReader
+
+	whitelist, err := finality.NewWhitelist(memdb.NewTestDB(t))
+	require.NoError(t, err)
+	hi := headerdownload.NewHeaderInserter("headers", big.NewInt(0), 0, br, m.Log)
+	hi.SetFinalityChecker(whitelist)
+	feedErr := func(h *types.Header) (libcommon.Hash, error) {
+		data, err := rlp.EncodeToBytes(h)
+		require.NoError(t, err)
+		_, err = hi.FeedHeaderPoW(tx, br, h, data, h.Hash(), h.Number.Uint64())
+		return h.Hash(), err
+	}
+	feed := func(h *types.Header) libcommon.Hash {
+		hash, err := feedErr(h)
+		require.NoError(t, err)
+		return hash
+	}
+
+	h1 := feed(&types.Header{Number: big.NewInt(1), Difficulty: big.NewInt(10), ParentHash: genesis.Hash()})
+	h2 := feed(&types.Header{Number: big.NewInt(2), Difficulty: big.NewInt(1010), ParentHash: h1})
+	require.Equal(t, h2, hi.GetHighestHash())
+	require.NoError(t, whitelist.ProcessMilestone(1, h1))
+
+	// A heavier fork replacing the finalized block is refused
+	fork1, err := feedErr(&types.Header{Number: big.NewInt(1), Difficulty: big.NewInt(2000), ParentHash: genesis.Hash(), Extra: []byte("fork")})
+	require.ErrorIs(t, err, headerdownload.ErrChainRefused)
+	require.Equal(t, h2, hi.GetHighestHash())
+
+	// So are its descendants, without failing on their missing parent
+	_, err = feedErr(&types.Header{Number: big.NewInt(2), Difficulty: big.NewInt(10), ParentHash: fork1})
+	require.ErrorIs(t, err, headerdownload.ErrChainRefused)
+	require.Equal(t, h2, hi.GetHighestHash())
+
+	// A heavier chain containing the finalized block is accepted
+	h3 := feed(&types.Header{Number: big.NewInt(3), Difficulty: big.NewInt(5000), ParentHash: h2})
+	require.Equal(t, h3, hi.GetHighestHash())
+}
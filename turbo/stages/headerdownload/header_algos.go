@@ -106,6 +106,14 @@ func (hd *HeaderDownload) SingleHeaderAsSegment(headerRaw []byte, header *types.
 		hd.logger.Warn("[downloader] Rejected header marked as bad", "hash", headerHash, "height", header.Number.Uint64())
 		return nil, BadBlockPenalty, nil
 	}
+	if hd.finality != nil && !hd.finality.IsValidHeader(header.Number.Uint64(), headerHash) {
+		hd.logger.Warn("[downloader] Rejected header conflicting with finalized block", "hash", headerHash, "height", header.Number.Uint64())
+		return nil, BadBlockPenalty, nil
+	}
+	if _, bad := hd.badHeaders[header.ParentHash]; bad {
+		hd.logger.Warn("[downloader] Rejected header descending from bad header", "hash", headerHash, "height", header.Number.Uint64())
+		return nil, BadBlockPenalty, nil
+	}
 	if penalizePoSBlocks && header.Difficulty.Sign() == 0 {
 		return nil, NewBlockGossipAfterMergePenalty, nil
 	}
@@ -557,6 +565,15 @@ func (hd *HeaderDownload) InsertHeader(hf FeedHeaderFunc, terminalTotalDifficult
 		default:
 		}
 		td, err := hf(link.header, link.headerRaw, link.hash, link.blockHeight)
+		if errors.Is(err, ErrChainRefused) {
+			// Throw out the refused link with its descendants, and reject them if they are delivered again
+			hd.badHeaders[link.hash] = struct{}{}
+			hd.moveLinkToQueue(link, NoQueue)
+			delete(hd.links, link.hash)
+			hd.removeUpwards(link)
+			dataflow.HeaderDownloadStates.AddChange(link.blockHeight, dataflow.HeaderBad)
+			return true, false, 0, lastTime, nil
+		}
 		if err != nil {
 			return false, false, 0, lastTime, err
 		}
@@ -858,6 +875,9 @@ func (hi *HeaderInserter) ForkingPoint(db kv.StatelessRwTx, header, parent *type
 	return
 }
 
+// ErrChainRefused is returned for the headers of chains conflicting with the finalized blocks
+var ErrChainRefused = errors.New("chain conflicts with finalized block")
+
 func (hi *HeaderInserter) FeedHeaderPoW(db kv.StatelessRwTx, headerReader services.HeaderReader, header *types.Header, headerRaw []byte, hash libcommon.Hash, blockHeight uint64) (td *big.Int, err error) {
 	if hash == hi.prevHash {
 		// Skip duplicates
@@ -871,6 +891,11 @@ func (hi *HeaderInserter) FeedHeaderPoW(db kv.StatelessRwTx, headerReader servic
 		// Already inserted, skip
 		return nil, nil
 	}
+	if _, refused := hi.refused[header.ParentHash]; refused {
+		// Descendants of a refused header are refused too, as their parent was never written
+		hi.refused[hash] = struct{}{}
+		return nil, ErrChainRefused
+	}
 	// Load parent header
 	parent, err := headerReader.Header(context.Background(), db, header.ParentHash, blockHeight-1)
 	if err != nil {
@@ -889,11 +914,20 @@ func (hi *HeaderInserter) FeedHeaderPoW(db kv.StatelessRwTx, headerReader servic
 	td = new(big.Int).Add(parentTd, header.Difficulty)
 	// Now we can decide wether this header will create a change in the canonical head
 	if td.Cmp(hi.localTd) > 0 {
-		hi.newCanonical = true
 		forkingPoint, err := hi.ForkingPoint(db, header, parent)
 		if err != nil {
 			return nil, err
 		}
+		valid, err := hi.IsValidChain(db, headerReader, header, hash, forkingPoint)
+		if err != nil {
+			return nil, err
+		}
+		if !valid {
+			hi.logger.Warn(fmt.Sprintf("[%s] Refused chain conflicting with finalized block", hi.logPrefix), "hash", hash, "height", blockHeight, "forkingPoint", forkingPoint)
+			hi.refused[hash] = struct{}{}
+			return nil, ErrChainRefused
+		}
+		hi.newCanonical = true
 		hi.highest = blockHeight
 		hi.highestHash = hash
 		hi.highestTimestamp = header.Time
@@ -918,6 +952,46 @@ func (hi *HeaderInserter) FeedHeaderPoW(db kv.StatelessRwTx, headerReader servic
 	return td, nil
 }
 
+// IsValidChain tells whether the chain ending at header, which forks from the canonical chain at forkingPoint, may become
+// canonical without conflicting with the finalized blocks
+func (hi *HeaderInserter) IsValidChain(db kv.Getter, headerReader services.HeaderReader, header *types.Header, hash libcommon.Hash, forkingPoint uint64) (bool, error) {
+	if hi.finality == nil {
+		return true, nil
+	}
+	return hi.finality.IsValidChain(hi.canonicalHeight(), forkingPoint, header.Number.Uint64(), func(number uint64) (libcommon.Hash, error) {
+		return ancestorHash(db, headerReader, header, hash, number)
+	})
+}
+
+// canonicalHeight returns the height of the canonical chain, with the headers inserted so far
+func (hi *HeaderInserter) canonicalHeight() uint64 {
+	if hi.newCanonical {
+		return hi.highest
+	}
+	return hi.headerProgress
+}
+
+// ancestorHash returns the hash of the ancestor at the given height of a header not inserted yet
+func ancestorHash(db kv.Getter, headerReader services.HeaderReader, header *types.Header, hash libcommon.Hash, height uint64) (libcommon.Hash, error) {
+	for h := header; h.Number.Uint64() > height; {
+		hash = h.ParentHash
+		parent, err := headerReader.Header(context.Background(), db, hash, h.Number.Uint64()-1)
+		if err != nil {
+			return libcommon.Hash{}, err
+		}
+		if parent == nil {
+			return libcommon.Hash{}, fmt.Errorf("could not find ancestor with hash %x and height %d", hash, h.Number.Uint64()-1)
+		}
+		h = parent
+	}
+	return hash, nil
+}
+
+// SetFinalityChecker makes the inserter refuse the new canonical chains conflicting with finalized blocks
+func (hi *HeaderInserter) SetFinalityChecker(finality FinalityChecker) {
+	hi.finality = finality
+}
+
 func (hi *HeaderInserter) FeedHeaderPoS(db kv.RwTx, header *types.Header, hash libcommon.Hash) error {
 	blockHeight := header.Number.Uint64()
 	// TODO(yperbasis): do we need to check if the header is already inserted (oldH)?
@@ -979,6 +1053,16 @@ func (hd *HeaderDownload) ProcessHeader(sh ChainSegmentHeader, newBlock bool, pe
 		// Duplicate
 		return false
 	}
+	if hd.finality != nil && !hd.finality.IsValidHeader(sh.Number, sh.Hash) {
+		hd.logger.Debug("[downloader] Rejected header conflicting with finalized block", "hash", sh.Hash, "height", sh.Number)
+		hd.badHeaders[sh.Hash] = struct{}{}
+		return false
+	}
+	if _, bad := hd.badHeaders[sh.Header.ParentHash]; bad {
+		hd.logger.Debug("[downloader] Rejected header descending from bad header", "hash", sh.Hash, "height", sh.Number)
+		hd.badHeaders[sh.Hash] = struct{}{}
+		return false
+	}
 	parent, foundParent := hd.links[sh.Header.ParentHash]
 	anchor, foundAnchor := hd.anchors[sh.Hash]
 	if !foundParent && !foundAnchor {
@@ -1085,6 +1169,19 @@ func (hd *HeaderDownload) SetHeaderReader(headerReader consensus.ChainHeaderRead
 	hd.consensusHeaderReader = headerReader
 }
 
+// SetFinalityChecker makes the downloader refuse the headers conflicting with finalized blocks
+func (hd *HeaderDownload) SetFinalityChecker(finality FinalityChecker) {
+	hd.lock.Lock()
+	defer hd.lock.Unlock()
+	hd.finality = finality
+}
+
+func (hd *HeaderDownload) FinalityChecker() FinalityChecker {
+	hd.lock.RLock()
+	defer hd.lock.RUnlock()
+	return hd.finality
+}
+
 func (hd *HeaderDownload) AfterInitialCycle() {
 	hd.lock.Lock()
 	defer hd.lock.Unlock()
@@ -254,6 +254,7 @@ type HeaderDownload struct {
 
 	consensusHeaderReader consensus.ChainHeaderReader
 	headerReader          services.HeaderReader
+	finality              FinalityChecker // Refuses the headers and chains conflicting with the blocks finalized outside of the chain, nil if there are none
 
 	// Proof of Stake (PoS)
 	firstSeenHeightPoS   *uint64
@@ -274,6 +275,16 @@ type HeaderDownload struct {
 	logger               log.Logger
 }
 
+// FinalityChecker tells whether headers and chains are consistent with the blocks finalized outside of the chain,
+// e.g. by the checkpoints and milestones of Bor
+type FinalityChecker interface {
+	// IsValidHeader tells whether a header is not a different block at a finalized number
+	IsValidHeader(number uint64, hash common.Hash) bool
+	// IsValidChain tells whether the chain ending at tip, forking from the canonical chain ending at current at the block
+	// forkPoint, may become canonical. hashAt returns the hashes of the blocks of the chain after forkPoint.
+	IsValidChain(current, forkPoint, tip uint64, hashAt func(number uint64) (common.Hash, error)) (bool, error)
+}
+
 // HeaderRecord encapsulates two forms of the same header - raw RLP encoding (to avoid duplicated decodings and encodings), and parsed value types.Header
 type HeaderRecord struct {
 	Header *types.Header
@@ -382,16 +393,23 @@ type HeaderInserter struct {
 	unwindPoint      uint64
 	highest          uint64
 	highestTimestamp uint64
+	headerProgress   uint64
 	canonicalCache   *lru.Cache[uint64, common.Hash]
 	headerReader     services.HeaderAndCanonicalReader
+	finality         FinalityChecker
+	refused          map[common.Hash]struct{} // Headers of the chains refused for conflicting with finalized blocks
+	logger           log.Logger
 }
 
-func NewHeaderInserter(logPrefix string, localTd *big.Int, headerProgress uint64, headerReader services.HeaderAndCanonicalReader) *HeaderInserter {
+func NewHeaderInserter(logPrefix string, localTd *big.Int, headerProgress uint64, headerReader services.HeaderAndCanonicalReader, logger log.Logger) *HeaderInserter {
 	hi := &HeaderInserter{
-		logPrefix:    logPrefix,
-		localTd:      localTd,
-		unwindPoint:  headerProgress,
-		headerReader: headerReader,
+		logPrefix:      logPrefix,
+		localTd:        localTd,
+		unwindPoint:    headerProgress,
+		headerProgress: headerProgress,
+		headerReader:   headerReader,
+		refused:        make(map[common.Hash]struct{}),
+		logger:         logger,
 	}
 	hi.canonicalCache, _ = lru.New[uint64, common.Hash](1000)
 	return hi
@@ -230,6 +230,19 @@ func MockWithGenesisPruneMode(tb testing.TB, gspec *types.Genesis, key *ecdsa.Pr
 }
 
 func MockWithEverything(tb testing.TB, gspec *types.Genesis, key *ecdsa.PrivateKey, prune prune.Mode, engine consensus.Engine, blockBufferSize int, withTxPool bool, withPosDownloader bool) *MockSentry {
+	return mockWithSentry(tb, gspec, key, prune, engine, blockBufferSize, withTxPool, withPosDownloader, nil)
+}
+
+// MockWithSentry creates a mock exchanging blocks with the peers of a real sentry rather than having them
+// injected: the staged sync sends its requests to the sentry and broadcasts the new blocks to the peers.
+// The transaction pool is disabled, and the stream loops of the sentry are started, but not the staged
+// sync loop, see StageLoop.
+func MockWithSentry(tb testing.TB, gspec *types.Genesis, key *ecdsa.PrivateKey, engine consensus.Engine, sentryServer proto_sentry.SentryServer) *MockSentry {
+	return mockWithSentry(tb, gspec, key, prune.DefaultMode, engine, blockBufferSize, false, false, sentryServer)
+}
+
+// mockWithSentry creates a mock with its own fake sentry if sentryServer is nil.
+func mockWithSentry(tb testing.TB, gspec *types.Genesis, key *ecdsa.PrivateKey, prune prune.Mode, engine consensus.Engine, blockBufferSize int, withTxPool bool, withPosDownloader bool, sentryServer proto_sentry.SentryServer) *MockSentry {
 	var tmpdir string
 	if tb != nil {
 		tmpdir = tb.TempDir()
@@ -286,7 +299,11 @@ func MockWithEverything(tb testing.TB, gspec *types.Genesis, key *ecdsa.PrivateK
 	propagateNewBlockHashes := func(context.Context, []headerdownload.Announce) {}
 	penalize := func(context.Context, []headerdownload.PenaltyItem) {}
 
-	mock.SentryClient = direct.NewSentryClientDirect(direct.ETH68, mock)
+	if sentryServer != nil {
+		mock.SentryClient = direct.NewSentryClientDirect(direct.ETH68, sentryServer)
+	} else {
+		mock.SentryClient = direct.NewSentryClientDirect(direct.ETH68, mock)
+	}
 	sentries := []direct.SentryClient{mock.SentryClient}
 
 	sendBodyRequest := func(context.Context, *bodydownload.BodyRequest) ([64]byte, bool) { return [64]byte{}, false }
@@ -375,7 +392,6 @@ func MockWithEverything(tb testing.TB, gspec *types.Genesis, key *ecdsa.PrivateK
 		logger,
 	)
 
-	mock.sentriesClient.IsMock = true
 	if err != nil {
 		if tb != nil {
 			tb.Fatal(err)
@@ -383,6 +399,15 @@ func MockWithEverything(tb testing.TB, gspec *types.Genesis, key *ecdsa.PrivateK
 			panic(err)
 		}
 	}
+	mock.sentriesClient.IsMock = sentryServer == nil
+	if sentryServer != nil {
+		sendHeaderRequest = mock.sentriesClient.SendHeaderRequest
+		propagateNewBlockHashes = mock.sentriesClient.PropagateNewBlockHashes
+		penalize = mock.sentriesClient.Penalize
+		sendBodyRequest = mock.sentriesClient.SendBodyRequest
+		blockPropagator = mock.sentriesClient.BroadcastNewBlock
+		mock.UpdateHead = mock.sentriesClient.UpdateHead
+	}
 
 	var snapshotsDownloader proto_downloader.DownloaderClient
 
@@ -455,6 +480,11 @@ func MockWithEverything(tb testing.TB, gspec *types.Genesis, key *ecdsa.PrivateK
 		logger,
 	)
 
+	if sentryServer != nil {
+		mock.sentriesClient.StartStreamLoops(mock.Ctx)
+		return mock
+	}
+
 	mock.StreamWg.Add(1)
 	go mock.sentriesClient.RecvMessageLoop(mock.Ctx, mock.SentryClient, &mock.ReceiveWg)
 	mock.StreamWg.Wait()
@@ -722,6 +752,11 @@ func (ms *MockSentry) HeaderDownload() *headerdownload.HeaderDownload {
 	return ms.sentriesClient.Hd
 }
 
+// MultiClient returns the client of the sentries the mock downloads and propagates blocks with.
+func (ms *MockSentry) MultiClient() *sentry.MultiClient {
+	return ms.sentriesClient
+}
+
 func (ms *MockSentry) NewHistoryStateReader(blockNum uint64, tx kv.Tx) state.StateReader {
 	r, err := rpchelper.CreateHistoryStateReader(tx, blockNum, 0, ms.HistoryV3, ms.ChainConfig.ChainName)
 	if err != nil {
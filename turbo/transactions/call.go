@@ -31,6 +31,7 @@ func DoCall(
 	blockNrOrHash rpc.BlockNumberOrHash,
 	header *types.Header,
 	overrides *ethapi2.StateOverrides,
+	blockOverrides *ethapi2.BlockOverrides,
 	gasCap uint64,
 	chainConfig *chain.Config,
 	stateReader state.StateReader,
@@ -76,11 +77,17 @@ func DoCall(
 			return nil, fmt.Errorf("header.BaseFee uint256 overflow")
 		}
 	}
+	blockCtx := NewEVMBlockContext(engine, header, blockNrOrHash.RequireCanonical, tx, headerReader)
+	if blockOverrides != nil {
+		blockOverrides.Override(&blockCtx)
+		if blockOverrides.BaseFee != nil {
+			baseFee = blockOverrides.BaseFee
+		}
+	}
 	msg, err := args.ToMessage(gasCap, baseFee)
 	if err != nil {
 		return nil, err
 	}
-	blockCtx := NewEVMBlockContext(engine, header, blockNrOrHash.RequireCanonical, tx, headerReader)
 	txCtx := core.NewEVMTxContext(msg)
 
 	evm := vm.NewEVM(blockCtx, txCtx, state, chainConfig, vm.Config{NoBaseFee: true})
@@ -130,6 +137,7 @@ type ReusableCaller struct {
 	gasCap          uint64
 	baseFee         *uint256.Int
 	stateReader     state.StateReader
+	overrides       *ethapi2.StateOverrides
 	callTimeout     time.Duration
 	message         *types.Message
 }
@@ -154,6 +162,11 @@ func (r *ReusableCaller) DoCallWithNewGas(
 	// reset the EVM so that we can continue to use it with the new context
 	txCtx := core.NewEVMTxContext(r.message)
 	r.intraBlockState = state.New(r.stateReader)
+	if r.overrides != nil {
+		if err := r.overrides.Override(r.intraBlockState); err != nil {
+			return nil, err
+		}
+	}
 	r.evm.Reset(txCtx, r.intraBlockState)
 
 	timedOut := false
@@ -181,6 +194,7 @@ func NewReusableCaller(
 	engine consensus.EngineReader,
 	stateReader state.StateReader,
 	overrides *ethapi2.StateOverrides,
+	blockOverrides *ethapi2.BlockOverrides,
 	header *types.Header,
 	initialArgs ethapi2.CallArgs,
 	gasCap uint64,
@@ -207,12 +221,18 @@ func NewReusableCaller(
 		}
 	}
 
+	blockCtx := NewEVMBlockContext(engine, header, blockNrOrHash.RequireCanonical, tx, headerReader)
+	if blockOverrides != nil {
+		blockOverrides.Override(&blockCtx)
+		if blockOverrides.BaseFee != nil {
+			baseFee = blockOverrides.BaseFee
+		}
+	}
+
 	msg, err := initialArgs.ToMessage(gasCap, baseFee)
 	if err != nil {
 		return nil, err
 	}
-
-	blockCtx := NewEVMBlockContext(engine, header, blockNrOrHash.RequireCanonical, tx, headerReader)
 	txCtx := core.NewEVMTxContext(msg)
 
 	evm := vm.NewEVM(blockCtx, txCtx, ibs, chainConfig, vm.Config{NoBaseFee: true})
@@ -224,6 +244,7 @@ func NewReusableCaller(
 		gasCap:          gasCap,
 		callTimeout:     callTimeout,
 		stateReader:     stateReader,
+		overrides:       overrides,
 		message:         &msg,
 	}, nil
 }
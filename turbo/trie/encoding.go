@@ -58,6 +58,11 @@ func hexToCompact(hex []byte) []byte {
 	return buf
 }
 
+// CompactToHex translates from COMPACT to HEX encoding, it is the encoding of trie node paths in the snap protocol.
+func CompactToHex(compact []byte) []byte {
+	return compactToHex(compact)
+}
+
 func compactToHex(compact []byte) []byte {
 	if len(compact) == 0 {
 		return compact
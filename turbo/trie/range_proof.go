@@ -0,0 +1,157 @@
+package trie
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+
+	libcommon "github.com/ledgerwatch/erigon-lib/common"
+	"github.com/ledgerwatch/erigon-lib/common/hexutility"
+)
+
+// rangeRelation tells where a subtree is relative to a range of keys.
+type rangeRelation int
+
+const (
+	rangeOverlaps rangeRelation = iota
+	rangeLeft
+	rangeInside
+	rangeRight
+)
+
+// rangePruner removes from a partial trie, expanded from proof nodes, every entry in a range of keys.
+type rangePruner struct {
+	nodes map[libcommon.Hash]node
+	lo    []byte // first key of the range in HEX encoding, without terminator
+	hi    []byte // last key of the range in HEX encoding, without terminator, nil if the range is unbounded
+}
+
+// relation compares the subtree of all the keys starting with prefix to the range.
+func (r *rangePruner) relation(prefix []byte, keyLength int) rangeRelation {
+	minKey := make([]byte, keyLength)
+	maxKey := make([]byte, keyLength)
+	copy(minKey, prefix)
+	copy(maxKey, prefix)
+	for i := len(prefix); i < keyLength; i++ {
+		maxKey[i] = 15
+	}
+	switch {
+	case bytes.Compare(maxKey, r.lo) < 0:
+		return rangeLeft
+	case r.hi != nil && bytes.Compare(minKey, r.hi) > 0:
+		return rangeRight
+	case bytes.Compare(minKey, r.lo) >= 0 && (r.hi == nil || bytes.Compare(maxKey, r.hi) <= 0):
+		return rangeInside
+	default:
+		return rangeOverlaps
+	}
+}
+
+// prune returns n without the entries in the range, and whether any entry after the range is left.
+func (r *rangePruner) prune(n node, prefix []byte) (node, bool, error) {
+	switch n := n.(type) {
+	case nil:
+		return nil, false, nil
+	case hashNode:
+		switch r.relation(prefix, len(r.lo)) {
+		case rangeLeft:
+			return n, false, nil
+		case rangeRight:
+			return n, true, nil
+		case rangeInside:
+			return nil, false, nil
+		}
+		resolved, ok := r.nodes[libcommon.BytesToHash(n.hash)]
+		if !ok {
+			return nil, false, fmt.Errorf("missing proof node %x at path %x", n.hash, prefix)
+		}
+		return r.prune(resolved, prefix)
+	case valueNode:
+		switch r.relation(prefix, len(prefix)) {
+		case rangeInside:
+			return nil, false, nil
+		case rangeRight:
+			return n, true, nil
+		default:
+			return n, false, nil
+		}
+	case *shortNode:
+		key := append(append([]byte{}, prefix...), n.Key...)
+		if hasTerm(key) {
+			return r.prune(n.Val, key[:len(key)-1])
+		}
+		child, more, err := r.prune(n.Val, key)
+		if err != nil || child == nil {
+			return nil, more, err
+		}
+		return &shortNode{Key: n.Key, Val: child}, more, nil
+	case *fullNode:
+		pruned := &fullNode{}
+		var more bool
+		for i := 0; i < 16; i++ {
+			child, childMore, err := r.prune(n.Children[i], append(append([]byte{}, prefix...), byte(i)))
+			if err != nil {
+				return nil, false, err
+			}
+			pruned.Children[i] = child
+			more = more || childMore
+		}
+		pruned.Children[16] = n.Children[16]
+		return pruned, more, nil
+	default:
+		return nil, false, fmt.Errorf("unexpected node %T in proof", n)
+	}
+}
+
+// VerifyRangeProof checks that keys, with their values, are all the entries of the trie with the given root between
+// origin and the last key, using the proof nodes of origin and of the last key. Without proof nodes the entries must
+// be the whole trie. Keys must be sorted and have the same length, values are raw leaf values, e.g. RLP encoded
+// accounts or RLP encoded storage values, like in the snap protocol. It returns whether the trie has more entries after
+// the last key.
+func VerifyRangeProof(root libcommon.Hash, origin []byte, keys, values [][]byte, proof [][]byte) (bool, error) {
+	if len(keys) != len(values) {
+		return false, fmt.Errorf("mismatching number of keys and values: %d != %d", len(keys), len(values))
+	}
+	for i := range keys {
+		if i > 0 && bytes.Compare(keys[i-1], keys[i]) >= 0 {
+			return false, errors.New("keys are not sorted")
+		}
+		if len(keys[i]) != len(origin) {
+			return false, fmt.Errorf("key %x has a different length than origin %x", keys[i], origin)
+		}
+		if len(values[i]) == 0 {
+			return false, fmt.Errorf("empty value for key %x", keys[i])
+		}
+	}
+	t := NewTestRLPTrie(libcommon.Hash{})
+	var more bool
+	if len(proof) > 0 {
+		if len(keys) > 0 && bytes.Compare(keys[0], origin) < 0 {
+			return false, errors.New("keys start before origin")
+		}
+		encoded := make([]hexutility.Bytes, len(proof))
+		for i := range proof {
+			encoded[i] = proof[i]
+		}
+		nodes, _, err := proofMap(encoded)
+		if err != nil {
+			return false, err
+		}
+		r := &rangePruner{nodes: nodes, lo: keybytesToHex(origin)}
+		r.lo = r.lo[:len(r.lo)-1]
+		if len(keys) > 0 {
+			r.hi = keybytesToHex(keys[len(keys)-1])
+			r.hi = r.hi[:len(r.hi)-1]
+		}
+		if t.root, more, err = r.prune(hashNode{hash: root[:]}, nil); err != nil {
+			return false, err
+		}
+	}
+	for i := range keys {
+		t.Update(keys[i], values[i])
+	}
+	if hash := t.Hash(); hash != root {
+		return false, fmt.Errorf("invalid range proof, root mismatch: %x != %x", hash, root)
+	}
+	return more, nil
+}
@@ -0,0 +1,110 @@
+package trie
+
+import (
+	"bytes"
+	"sort"
+	"testing"
+
+	libcommon "github.com/ledgerwatch/erigon-lib/common"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ledgerwatch/erigon/crypto"
+	"github.com/ledgerwatch/erigon/rlp"
+)
+
+func rangeProofTestEntries(t *testing.T, n int) (*Trie, [][]byte, [][]byte) {
+	tr := NewTestRLPTrie(libcommon.Hash{})
+	var keys, values [][]byte
+	for i := 0; i < n; i++ {
+		key := crypto.Keccak256([]byte{byte(i), byte(i >> 8)})
+		value, err := rlp.EncodeToBytes(bytes.Repeat([]byte{byte(i + 1)}, i%40+1))
+		require.NoError(t, err)
+		keys = append(keys, key)
+		values = append(values, value)
+		tr.Update(key, value)
+	}
+	sort.Sort(&sortedEntries{keys, values})
+	return tr, keys, values
+}
+
+type sortedEntries struct{ keys, values [][]byte }
+
+func (s *sortedEntries) Len() int           { return len(s.keys) }
+func (s *sortedEntries) Less(i, j int) bool { return bytes.Compare(s.keys[i], s.keys[j]) < 0 }
+func (s *sortedEntries) Swap(i, j int) {
+	s.keys[i], s.keys[j] = s.keys[j], s.keys[i]
+	s.values[i], s.values[j] = s.values[j], s.values[i]
+}
+
+func rangeProof(t *testing.T, tr *Trie, keys ...[]byte) [][]byte {
+	var proof [][]byte
+	for _, key := range keys {
+		nodes, err := tr.Prove(key, 0, false)
+		require.NoError(t, err)
+		proof = append(proof, nodes...)
+	}
+	return proof
+}
+
+func TestVerifyRangeProofWholeTrie(t *testing.T) {
+	tr, keys, values := rangeProofTestEntries(t, 100)
+	root := tr.Hash()
+
+	more, err := VerifyRangeProof(root, make([]byte, 32), keys, values, nil)
+	require.NoError(t, err)
+	require.False(t, more)
+
+	// Without proof, a missing entry is detected
+	_, err = VerifyRangeProof(root, make([]byte, 32), keys[1:], values[1:], nil)
+	require.Error(t, err)
+}
+
+func TestVerifyRangeProof(t *testing.T) {
+	tr, keys, values := rangeProofTestEntries(t, 100)
+	root := tr.Hash()
+
+	for _, r := range []struct{ start, end int }{{0, 100}, {0, 10}, {10, 20}, {50, 51}, {90, 100}} {
+		origin := keys[r.start]
+		proof := rangeProof(t, tr, origin, keys[r.end-1])
+		more, err := VerifyRangeProof(root, origin, keys[r.start:r.end], values[r.start:r.end], proof)
+		require.NoError(t, err, "range %d-%d", r.start, r.end)
+		require.Equal(t, r.end < len(keys), more, "range %d-%d", r.start, r.end)
+	}
+
+	// Origin before the first key of the range
+	origin := make([]byte, 32)
+	proof := rangeProof(t, tr, origin, keys[9])
+	more, err := VerifyRangeProof(root, origin, keys[:10], values[:10], proof)
+	require.NoError(t, err)
+	require.True(t, more)
+
+	// Origin after the last key of the trie, the proof alone shows that the range is empty
+	origin = bytes.Repeat([]byte{0xff}, 32)
+	more, err = VerifyRangeProof(root, origin, nil, nil, rangeProof(t, tr, origin))
+	require.NoError(t, err)
+	require.False(t, more)
+}
+
+func TestVerifyRangeProofInvalid(t *testing.T) {
+	tr, keys, values := rangeProofTestEntries(t, 100)
+	root := tr.Hash()
+	proof := rangeProof(t, tr, keys[10], keys[19])
+
+	// Missing entry in the middle of the range
+	_, err := VerifyRangeProof(root, keys[10], append(append([][]byte{}, keys[10:14]...), keys[15:20]...), append(append([][]byte{}, values[10:14]...), values[15:20]...), proof)
+	require.Error(t, err)
+
+	// Modified value
+	modified := append([][]byte{}, values[10:20]...)
+	modified[5] = []byte{0x01}
+	_, err = VerifyRangeProof(root, keys[10], keys[10:20], modified, proof)
+	require.Error(t, err)
+
+	// Missing proof node
+	_, err = VerifyRangeProof(root, keys[10], keys[10:20], values[10:20], proof[1:])
+	require.Error(t, err)
+
+	// Unsorted keys
+	_, err = VerifyRangeProof(root, keys[10], [][]byte{keys[11], keys[10]}, [][]byte{values[11], values[10]}, rangeProof(t, tr, keys[10], keys[11]))
+	require.Error(t, err)
+}
@@ -56,6 +56,8 @@ type ProofRetainer struct {
 	storageKeys    []libcommon.Hash
 	storageHexKeys [][]byte
 	proofs         []*proofElement
+	// hexKeys are the paths to collect the nodes of, set only by NewMultiProofRetainer.
+	hexKeys [][]byte
 }
 
 // NewProofRetainer creates a new ProofRetainer instance for a given account and
@@ -94,6 +96,19 @@ func NewProofRetainer(addr libcommon.Address, a *accounts.Account, storageKeys [
 	}, nil
 }
 
+// NewMultiProofRetainer creates a new ProofRetainer collecting the nodes on the
+// paths of the given keys, in HEX encoding. Paths in storage tries are prefixed
+// by the account hash and incarnation, like the keys of the storage loader. Keys
+// may be shorter than full keys, to collect the nodes at arbitrary paths. The
+// nodes are retrieved with ProofNodes and Node once the Load of the
+// FlatDBTrieLoader has completed.
+func NewMultiProofRetainer(hexKeys [][]byte, rl *RetainList) *ProofRetainer {
+	for _, hexKey := range hexKeys {
+		rl.AddHex(hexKey)
+	}
+	return &ProofRetainer{rl: rl, hexKeys: hexKeys}
+}
+
 // ProofElement requests a new proof element for a given prefix.  This proof
 // element is retained by the ProofRetainer, and will be utilized to compute the
 // proof after the trie computation has completed.  The prefix is the standard
@@ -103,6 +118,19 @@ func (pr *ProofRetainer) ProofElement(prefix []byte) *proofElement {
 		return nil
 	}
 
+	if pr.hexKeys != nil {
+		for _, hexKey := range pr.hexKeys {
+			if bytes.HasPrefix(hexKey, prefix) {
+				pe := &proofElement{
+					hexKey: append([]byte{}, prefix...),
+				}
+				pr.proofs = append([]*proofElement{pe}, pr.proofs...)
+				return pe
+			}
+		}
+		return nil
+	}
+
 	switch {
 	case bytes.HasPrefix(pr.accHexKey, prefix):
 		// This prefix is a node between the account and the root
@@ -192,6 +220,66 @@ func (pr *ProofRetainer) ProofResult() (*accounts.AccProofResult, error) {
 	return result, nil
 }
 
+// ProofNodes returns the RLP encoding of the nodes on the path of a key given to
+// NewMultiProofRetainer, root first. Only the nodes of the account trie are
+// returned if storage is false, and only the ones of the storage trie otherwise.
+// Nodes embedded in their parent are skipped, like in the proofs of go-ethereum.
+func (pr *ProofRetainer) ProofNodes(hexKey []byte, storage bool) [][]byte {
+	var nodes [][]byte
+	for _, pe := range pr.proofs {
+		if !bytes.HasPrefix(hexKey, pe.hexKey) || (len(pe.hexKey) > 2*length.Hash) != storage {
+			continue
+		}
+		if len(nodes) > 0 && pe.proof.Len() < length.Hash {
+			continue
+		}
+		nodes = append(nodes, common.CopyBytes(pe.proof.Bytes()))
+	}
+	return nodes
+}
+
+// Node returns the RLP encoding of the node at the exact path, which must be one
+// of the keys given to NewMultiProofRetainer, or nil if there is no such node.
+func (pr *ProofRetainer) Node(hexPath []byte) []byte {
+	for _, pe := range pr.proofs {
+		if bytes.Equal(pe.hexKey, hexPath) {
+			return common.CopyBytes(pe.proof.Bytes())
+		}
+	}
+	return nil
+}
+
+// SiblingPaths returns the paths of the siblings of a key given to
+// NewMultiProofRetainer in the branch nodes on its path. Deleting the key
+// may collapse such a branch into its remaining child, which must then be
+// known.
+func (pr *ProofRetainer) SiblingPaths(hexKey []byte) [][]byte {
+	var paths [][]byte
+	for _, pe := range pr.proofs {
+		if len(pe.hexKey) >= len(hexKey) || !bytes.HasPrefix(hexKey, pe.hexKey) {
+			continue
+		}
+		if (len(pe.hexKey) > 2*length.Hash) != (len(hexKey) > 2*length.Hash) {
+			// Only the branches of the trie of the key, not the ones above its storage trie
+			continue
+		}
+		n, err := decodeNode(pe.proof.Bytes())
+		if err != nil {
+			continue
+		}
+		branch, ok := n.(*fullNode)
+		if !ok {
+			continue
+		}
+		for i, child := range branch.Children[:16] {
+			if child != nil && byte(i) != hexKey[len(pe.hexKey)] {
+				paths = append(paths, concat(pe.hexKey, byte(i)))
+			}
+		}
+	}
+	return paths
+}
+
 // proofElement represent a node or leaf in the trie and its
 // corresponding RLP encoding.  We store the elements individually when
 // aggregating as multiple keys (in particular storage keys) may need to
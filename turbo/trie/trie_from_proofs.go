@@ -0,0 +1,134 @@
+package trie
+
+import (
+	"bytes"
+	"fmt"
+
+	libcommon "github.com/ledgerwatch/erigon-lib/common"
+	"github.com/ledgerwatch/erigon-lib/common/length"
+
+	"github.com/ledgerwatch/erigon/common"
+	"github.com/ledgerwatch/erigon/core/types/accounts"
+	"github.com/ledgerwatch/erigon/crypto"
+	"github.com/ledgerwatch/erigon/rlp"
+)
+
+// storageRootPathLen is the length of the paths of the storage roots collected by the trie loader, made of the account
+// hash and the incarnation in HEX encoding
+const storageRootPathLen = 2 * (length.Hash + length.Incarnation)
+
+// PartialTrie builds the trie of root out of the nodes collected by a ProofRetainer made with NewMultiProofRetainer,
+// once the Load of the FlatDBTrieLoader has completed. The nodes which were not collected are kept as hash nodes. The
+// given codes are attached to the accounts with their hash.
+func (pr *ProofRetainer) PartialTrie(root libcommon.Hash, codes map[libcommon.Hash][]byte) (*Trie, error) {
+	t := New(root)
+	if root == EmptyRoot {
+		return t, nil
+	}
+	nodes := make(map[string][]byte, len(pr.proofs))
+	storageRoots := make(map[string][]byte)
+	for _, pe := range pr.proofs {
+		nodes[string(pe.hexKey)] = pe.proof.Bytes()
+		if len(pe.hexKey) == storageRootPathLen {
+			storageRoots[string(pe.hexKey[:2*length.Hash])] = pe.hexKey
+		}
+	}
+	b := &partialTrieBuilder{nodes: nodes, storageRoots: storageRoots, codes: codes}
+	n, err := b.expand(hashNode{hash: root[:]}, nil, false)
+	if err != nil {
+		return nil, err
+	}
+	t.root = n
+	return t, nil
+}
+
+type partialTrieBuilder struct {
+	nodes        map[string][]byte // RLP encoding of the nodes by path
+	storageRoots map[string][]byte // Paths of the storage roots by account path
+	codes        map[libcommon.Hash][]byte
+}
+
+// expand replaces the hash node n at path with the decoded node if it was collected, and does so recursively with its
+// children
+func (b *partialTrieBuilder) expand(n node, path []byte, storage bool) (node, error) {
+	switch n := n.(type) {
+	case nil, valueNode:
+		return n, nil
+	case hashNode:
+		enc, ok := b.nodes[string(path)]
+		if !ok {
+			return n, nil
+		}
+		if !bytes.Equal(crypto.Keccak256(enc), n.hash) {
+			return nil, fmt.Errorf("node at path %x does not match its hash %x", path, n.hash)
+		}
+		decoded, err := decodeNode(enc)
+		if err != nil {
+			return nil, fmt.Errorf("decoding node at path %x: %w", path, err)
+		}
+		return b.expand(decoded, path, storage)
+	case *fullNode:
+		for i := 0; i < 16; i++ {
+			child, err := b.expand(n.Children[i], concat(path, byte(i)), storage)
+			if err != nil {
+				return nil, err
+			}
+			n.Children[i] = child
+		}
+		return n, nil
+	case *shortNode:
+		if !hasTerm(n.Key) {
+			child, err := b.expand(n.Val, concat(path, n.Key...), storage)
+			if err != nil {
+				return nil, err
+			}
+			n.Val = child
+			return n, nil
+		}
+		val, ok := n.Val.(valueNode)
+		if !ok {
+			return nil, fmt.Errorf("unexpected %T leaf at path %x", n.Val, path)
+		}
+		if storage {
+			// Leaves hold the RLP encoding of the storage values, and the trie the values
+			_, content, _, err := rlp.Split(val)
+			if err != nil {
+				return nil, fmt.Errorf("decoding storage value at path %x: %w", path, err)
+			}
+			n.Val = valueNode(content)
+			return n, nil
+		}
+		accountPath := concat(path, n.Key[:len(n.Key)-1]...)
+		accNode, err := b.account(val, accountPath)
+		if err != nil {
+			return nil, err
+		}
+		n.Val = accNode
+		return n, nil
+	default:
+		return nil, fmt.Errorf("unexpected node %T at path %x", n, path)
+	}
+}
+
+// account decodes the leaf of an account, with its storage trie and code when they were collected
+func (b *partialTrieBuilder) account(enc []byte, path []byte) (*accountNode, error) {
+	var acc accounts.Account
+	if err := acc.DecodeForHashing(enc); err != nil {
+		return nil, fmt.Errorf("decoding account at path %x: %w", path, err)
+	}
+	n := &accountNode{Account: acc, rootCorrect: true}
+	if acc.Root != EmptyRoot {
+		n.storage = hashNode{hash: common.CopyBytes(acc.Root[:])}
+		if storagePath, ok := b.storageRoots[string(path)]; ok {
+			storage, err := b.expand(n.storage, storagePath, true)
+			if err != nil {
+				return nil, err
+			}
+			n.storage = storage
+		}
+	}
+	if code, ok := b.codes[acc.CodeHash]; ok {
+		n.code, n.codeSize = code, len(code)
+	}
+	return n, nil
+}